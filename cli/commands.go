@@ -7,26 +7,35 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"os/exec"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
+	"unicode/utf8"
 
+	"github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/database"
 	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
 	"github.com/etix/mirrorbits/rpc"
 	"github.com/etix/mirrorbits/utils"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/howeyc/gopass"
 	"github.com/op/go-logging"
 	"github.com/pkg/errors"
@@ -39,12 +48,25 @@ import (
 const (
 	commentSeparator  = "##### Comments go below this line #####"
 	defaultRPCTimeout = time.Second * 10
+	listPageSize      = 100
 )
 
 var (
 	log = logging.MustGetLogger("main")
 )
 
+// stringList is a flag that can be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 type cli struct {
 	sync.Mutex
 	rpcconn *grpc.ClientConn
@@ -56,6 +78,7 @@ func ParseCommands(args ...string) error {
 	c := &cli{
 		creds: &loginCreds{
 			Password: core.RPCPassword,
+			Token:    core.RPCToken,
 		},
 	}
 
@@ -63,9 +86,10 @@ func ParseCommands(args ...string) error {
 		method, exists := c.getMethod(args[0])
 		if !exists {
 			fmt.Println("Error: Command not found:", args[0])
-			return c.CmdHelp()
+			c.CmdHelp()
+			return ErrUsage
 		}
-		if len(c.creds.Password) == 0 && core.RPCAskPass {
+		if len(c.creds.Password) == 0 && len(c.creds.Token) == 0 && core.RPCAskPass {
 			fmt.Print("Password: ")
 			passwd, err := gopass.GetPasswdMasked()
 			if err != nil {
@@ -89,33 +113,50 @@ func ParseCommands(args ...string) error {
 }
 
 func (c *cli) getMethod(name string) (reflect.Method, bool) {
+	name = strings.Replace(name, "-", "", -1)
 	methodName := "Cmd" + strings.ToUpper(name[:1]) + strings.ToLower(name[1:])
 	return reflect.TypeOf(c).MethodByName(methodName)
 }
 
 func (c *cli) CmdHelp() error {
 	help := fmt.Sprintf("Usage: mirrorbits [OPTIONS] COMMAND [arg...]\n\nA smart download redirector.\n\n")
-	help += fmt.Sprintf("Server commands:\n    %-10.10s%s\n\n", "daemon", "Start the server")
+	help += fmt.Sprintf("Server commands:\n    %-16.16s%s\n\n", "daemon", "Start the server")
 	help += fmt.Sprintf("CLI commands:\n")
 	for _, command := range [][]string{
 		{"add", "Add a new mirror"},
+		{"check", "Check the health of a mirror"},
+		{"cluster", "Show the nodes of the cluster"},
+		{"config", "Print the configuration in use"},
+		{"configtest", "Check the configuration file"},
+		{"dbdump", "Dump the database"},
+		{"dbrestore", "Restore a database dump"},
 		{"disable", "Disable a mirror"},
 		{"edit", "Edit a mirror"},
 		{"enable", "Enable a mirror"},
+		{"explain-config", "Describe the configuration keys"},
 		{"export", "Export the mirror database"},
+		{"fallback", "Preview the fallbacks"},
+		{"file", "Print the details of a file"},
 		{"geoupdate", "Update geolocation of a mirror"},
 		{"list", "List all mirrors"},
 		{"logs", "Print logs of a mirror"},
+		{"maintenance", "Put a mirror under maintenance"},
+		{"promote", "Promote a standby instance"},
 		{"refresh", "Refresh the local repository"},
 		{"reload", "Reload configuration"},
 		{"remove", "Remove a mirror"},
+		{"restore", "Restore a removed mirror"},
 		{"scan", "(Re-)Scan a mirror"},
 		{"show", "Print a mirror configuration"},
+		{"standby", "Switch the instance to standby"},
 		{"stats", "Show download stats"},
+		{"test", "Simulate the selection of mirrors"},
+		{"traffic", "Predict the traffic share of the mirrors"},
 		{"upgrade", "Seamless binary upgrade"},
 		{"version", "Print version information"},
+		{"watch", "Print the events as they happen"},
 	} {
-		help += fmt.Sprintf("    %-10.10s%s\n", command[0], command[1])
+		help += fmt.Sprintf("    %-16.16s%s\n", command[0], command[1])
 	}
 	fmt.Fprintf(os.Stderr, "%s\n", help)
 	return nil
@@ -148,24 +189,63 @@ func (c *cli) CmdList(args ...string) error {
 	disabled := cmd.Bool("disabled", false, "List disabled mirrors only")
 	enabled := cmd.Bool("enabled", false, "List enabled mirrors only")
 	down := cmd.Bool("down", false, "List only mirrors currently down")
+	sync := cmd.Bool("sync", false, "Print the last successful sync with its protocol and precision")
+	country := cmd.String("country", "", "List only mirrors serving the given country code")
+	tag := cmd.String("tag", "", "List only mirrors having the given tag")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	client := c.GetRPC()
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
-	defer cancel()
-	list, err := client.List(ctx, &empty.Empty{})
+	req := &rpc.MirrorListRequest{
+		Fields:  []string{"Name", "Enabled", "Up", "StateSince", "Maintenance", "MaintenanceUntil", "DisabledReason", "DisabledUntil"},
+		Country: *country,
+		Tag:     *tag,
+	}
+	if *enabled == true {
+		req.Enabled = rpc.MirrorListRequest_YES
+	}
+	if *disabled == true {
+		req.Enabled = rpc.MirrorListRequest_NO
+	}
+	if *down == true {
+		req.Enabled = rpc.MirrorListRequest_YES
+		req.Up = rpc.MirrorListRequest_NO
+		req.Fields = append(req.Fields, "NodeHealth")
+	}
+	if *score == true {
+		req.Fields = append(req.Fields, "Score")
+	}
+	if *http == true {
+		req.Fields = append(req.Fields, "HttpURL")
+	}
+	if *rsync == true {
+		req.Fields = append(req.Fields, "RsyncURL")
+	}
+	if *ftp == true {
+		req.Fields = append(req.Fields, "FtpURL")
+	}
+	if *location == true {
+		req.Fields = append(req.Fields, "CountryCodes", "ContinentCode")
+	}
+	if *sync == true {
+		req.Fields = append(req.Fields, "LastSuccessfulSync", "LastSuccessfulSyncProtocol", "LastSuccessfulSyncPrecision", "ModTimePrecision")
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	list, err := listMirrors(client, req)
 	if err != nil {
-		log.Fatal("list error:", err)
+		return rpcError(err, "list error")
 	}
 
-	sort.Sort(ByDate(list.Mirrors))
+	sort.Sort(ByDate(list))
 
 	w := new(tabwriter.Writer)
 	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
@@ -185,30 +265,18 @@ func (c *cli) CmdList(args ...string) error {
 	if *location == true {
 		fmt.Fprint(w, "\tLOCATION ")
 	}
+	if *sync == true {
+		fmt.Fprint(w, "\tLAST SYNC\tPROTOCOL\tPRECISION ")
+	}
 	if *state == true {
 		fmt.Fprint(w, "\tSTATE\tSINCE")
 	}
 	fmt.Fprint(w, "\n")
 
-	for _, mirror := range list.Mirrors {
-		if *disabled == true {
-			if mirror.Enabled == true {
-				continue
-			}
-		}
-		if *enabled == true {
-			if mirror.Enabled == false {
-				continue
-			}
-		}
-		if *down == true {
-			if mirror.Up == true || mirror.Enabled == false {
-				continue
-			}
-		}
+	for _, mirror := range list {
 		stateSince, err := ptypes.Timestamp(mirror.StateSince)
 		if err != nil {
-			log.Fatal("list error:", err)
+			return rpcError(err, "list error")
 		}
 		fmt.Fprintf(w, "%s ", mirror.Name)
 		if *score == true {
@@ -231,9 +299,24 @@ func (c *cli) CmdList(args ...string) error {
 			}
 			fmt.Fprintf(w, "\t%s (%s) ", countryCode, mirror.ContinentCode)
 		}
+		if *sync == true {
+			lastSync, err := ptypes.Timestamp(mirror.LastSuccessfulSync)
+			if err != nil {
+				return rpcError(err, "list error")
+			}
+			if lastSync.Unix() <= 0 {
+				fmt.Fprint(w, "\tnever\t-\t- ")
+			} else {
+				fmt.Fprintf(w, "\t%s\t%s\t%s ", lastSync.Format(time.RFC1123),
+					core.ScannerType(mirror.LastSuccessfulSyncProtocol),
+					precisionString(mirror.LastSuccessfulSyncPrecision, mirror.ModTimePrecision))
+			}
+		}
 		if *state == true {
 			if mirror.Enabled == false {
-				fmt.Fprintf(w, "\tdisabled")
+				fmt.Fprintf(w, "\tdisabled%s", disabledDetails(mirror))
+			} else if inMaintenance(mirror) {
+				fmt.Fprintf(w, "\tmaintenance")
 			} else if mirror.Up == true {
 				fmt.Fprintf(w, "\tup")
 			} else {
@@ -241,6 +324,17 @@ func (c *cli) CmdList(args ...string) error {
 			}
 			fmt.Fprintf(w, " \t(%s)", stateSince.Format(time.RFC1123))
 		}
+		if *down == true && len(mirror.NodeHealth) > 0 {
+			var results []string
+			for _, h := range mirror.NodeHealth {
+				if h.Up {
+					results = append(results, h.Node+": up")
+				} else {
+					results = append(results, fmt.Sprintf("%s: down (%s)", h.Node, h.Reason))
+				}
+			}
+			fmt.Fprintf(w, " \t%s", strings.Join(results, ", "))
+		}
 		fmt.Fprint(w, "\n")
 	}
 
@@ -249,9 +343,66 @@ func (c *cli) CmdList(args ...string) error {
 	return nil
 }
 
+// disabledDetails returns the reason and the end of the
+// disabling of a mirror, if any
+func disabledDetails(m *rpc.Mirror) string {
+	var details []string
+	if m.DisabledReason != "" {
+		details = append(details, m.DisabledReason)
+	}
+	if m.DisabledUntil != nil {
+		if until, err := ptypes.Timestamp(m.DisabledUntil); err == nil && until.Unix() > 0 {
+			details = append(details, "until "+until.Local().Format(time.RFC1123))
+		}
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(details, ", ")
+}
+
+// inMaintenance returns true if the mirror is currently under maintenance
+func inMaintenance(m *rpc.Mirror) bool {
+	mirror := mirrors.Mirror{
+		Maintenance: m.Maintenance,
+	}
+	if m.MaintenanceUntil != nil {
+		until, err := ptypes.Timestamp(m.MaintenanceUntil)
+		if err == nil {
+			mirror.MaintenanceUntil = mirrors.Time{}.FromTime(until)
+		}
+	}
+	mirror.Prepare()
+	return mirror.InMaintenance(time.Now())
+}
+
+// listMirrors fetches the mirrors matching the request one page at a time
+func listMirrors(client rpc.CLIClient, req *rpc.MirrorListRequest) ([]*rpc.Mirror, error) {
+	req.PageSize = listPageSize
+	var list []*rpc.Mirror
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+		reply, err := client.List(ctx, req)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, reply.Mirrors...)
+		if reply.NextPageToken == "" {
+			return list, nil
+		}
+		req.PageToken = reply.NextPageToken
+	}
+}
+
 func (c *cli) CmdAdd(args ...string) error {
-	cmd := SubCmd("add", "[OPTIONS] IDENTIFIER", "Add a new mirror")
+	cmd := SubCmd("add", "[OPTIONS] IDENTIFIER | -f FILE", "Add a new mirror")
+	file := cmd.String("f", "", "Add at once the mirrors listed in a YAML or JSON file (as written by the export command)")
+	cmd.StringVar(file, "from-yaml", "", "Same as -f")
+	update := cmd.Bool("update", false, "Update the mirrors of the file already existing, matched by name")
 	http := cmd.String("http", "", "HTTP base URL")
+	var altHTTP stringList
+	cmd.Var(&altHTTP, "alt-http", "Alternative HTTP base URL of the mirror, located on its own (can be given several times)")
 	rsync := cmd.String("rsync", "", "RSYNC base URL (for scanning only)")
 	ftp := cmd.String("ftp", "", "FTP base URL (for scanning only)")
 	sponsorName := cmd.String("sponsor-name", "", "Name of the sponsor")
@@ -265,23 +416,36 @@ func (c *cli) CmdAdd(args ...string) error {
 	asOnly := cmd.Bool("as-only", false, "The mirror should only handle clients in the same AS number")
 	score := cmd.Int("score", 0, "Weight to give to the mirror during selection")
 	comment := cmd.String("comment", "", "Comment")
+	note := cmd.String("note", "", "Public note shown in the mirrorlist when the mirror is excluded")
+	tags := cmd.String("tags", "", "Space separated list of tags used to filter the mirrors")
+	hostHeader := cmd.String("host-header", "", "Host header to send to the mirror instead of the hostname of its URL")
+	healthCheckPath := cmd.String("health-check-path", "", "Path to request during health checks instead of a random file")
+	healthCheckCodes := cmd.String("health-check-codes", "", "HTTP status codes accepted during health checks (default: 200)")
+	scanInterval := cmd.Int("scan-interval", 0, "Interval in minutes between the scans of the mirror (default: ScanInterval)")
+	rsyncBwLimit := cmd.Int("rsync-bwlimit", 0, "Bandwidth limit of the rsync scans in KiB/s (default: unlimited)")
+	ftpConnections := cmd.Int("ftp-connections", 0, "Number of concurrent connections of the ftp scans (default: 1)")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
+	}
+	if *file != "" {
+		if cmd.NArg() > 0 {
+			cmd.Usage()
+			return ErrUsage
+		}
+		return c.addMirrors(*file, *update)
 	}
 	if cmd.NArg() < 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
 	if strings.Contains(cmd.Arg(0), " ") {
-		fmt.Fprintf(os.Stderr, "The identifier cannot contain a space\n")
-		os.Exit(-1)
+		return newError(ExitUsage, "The identifier cannot contain a space")
 	}
 
 	if *http == "" {
-		fmt.Fprintf(os.Stderr, "You *must* pass at least an HTTP URL\n")
-		os.Exit(-1)
+		return newError(ExitUsage, "You *must* pass at least an HTTP URL")
 	}
 
 	if !strings.HasPrefix(*http, "http://") && !strings.HasPrefix(*http, "https://") {
@@ -290,41 +454,60 @@ func (c *cli) CmdAdd(args ...string) error {
 
 	_, err := url.Parse(*http)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't parse url\n")
-		os.Exit(-1)
+		return newError(ExitUsage, "Can't parse url")
+	}
+
+	var altURLs mirrors.AltURLs
+	for _, alt := range altHTTP {
+		if !strings.HasPrefix(alt, "http://") && !strings.HasPrefix(alt, "https://") {
+			alt = "http://" + alt
+		}
+		altURLs = append(altURLs, mirrors.AltURL{URL: alt})
 	}
 
 	mirror := &mirrors.Mirror{
-		Name:           cmd.Arg(0),
-		HttpURL:        *http,
-		RsyncURL:       *rsync,
-		FtpURL:         *ftp,
-		SponsorName:    *sponsorName,
-		SponsorURL:     *sponsorURL,
-		SponsorLogoURL: *sponsorLogo,
-		AdminName:      *adminName,
-		AdminEmail:     *adminEmail,
-		CustomData:     *customData,
-		ContinentOnly:  *continentOnly,
-		CountryOnly:    *countryOnly,
-		ASOnly:         *asOnly,
-		Score:          *score,
-		Comment:        *comment,
-	}
-
-	client := c.GetRPC()
+		Name:                cmd.Arg(0),
+		HttpURL:             *http,
+		RsyncURL:            *rsync,
+		FtpURL:              *ftp,
+		SponsorName:         *sponsorName,
+		SponsorURL:          *sponsorURL,
+		SponsorLogoURL:      *sponsorLogo,
+		AdminName:           *adminName,
+		AdminEmail:          *adminEmail,
+		CustomData:          *customData,
+		ContinentOnly:       *continentOnly,
+		CountryOnly:         *countryOnly,
+		ASOnly:              *asOnly,
+		Score:               *score,
+		Comment:             *comment,
+		HealthCheckPath:     *healthCheckPath,
+		HealthCheckCodes:    *healthCheckCodes,
+		Note:                *note,
+		Tags:                *tags,
+		HostHeader:          *hostHeader,
+		ScanInterval:        *scanInterval,
+		RsyncBandwidthLimit: *rsyncBwLimit,
+		FtpConnections:      *ftpConnections,
+		AltURLs:             altURLs,
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
 	m, err := rpc.MirrorToRPC(mirror)
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 	reply, err := client.AddMirror(ctx, m)
 	if err != nil {
 		if err.Error() == rpc.ErrNameAlreadyTaken.Error() {
-			log.Fatalf("Mirror %s already exists!\n", mirror.Name)
+			return newError(ExitFailure, "Mirror %s already exists!", mirror.Name)
 		}
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 
 	for i := 0; i < len(reply.Warnings); i++ {
@@ -350,19 +533,107 @@ func (c *cli) CmdAdd(args ...string) error {
 	return nil
 }
 
+// addMirrors adds the mirrors listed in the given YAML file in a single
+// transaction
+func (c *cli) addMirrors(file string, update bool) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return newError(ExitFailure, "Unable to read %s: %s", file, err)
+	}
+	var list []mirrors.Mirror
+	if err := yaml.Unmarshal(content, &list); err != nil {
+		return newError(ExitUsage, "Invalid list of mirrors: %s", err)
+	}
+	if len(list) == 0 {
+		return newError(ExitUsage, "No mirror to add")
+	}
+
+	req := &rpc.AddMirrorsRequest{
+		Update: update,
+	}
+	for i := range list {
+		m := &list[i]
+		if m.HttpURL != "" && !strings.HasPrefix(m.HttpURL, "http://") && !strings.HasPrefix(m.HttpURL, "https://") {
+			m.HttpURL = "http://" + m.HttpURL
+		}
+		rm, err := rpc.MirrorToRPC(m)
+		if err != nil {
+			return rpcError(err, "add error")
+		}
+		req.Mirrors = append(req.Mirrors, rm)
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	// The mirrors are located one by one on the server
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout*time.Duration(1+len(list)/10))
+	defer cancel()
+	reply, err := client.AddMirrors(ctx, req)
+	if err != nil {
+		return rpcError(err, "add error")
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	fmt.Fprint(w, "Identifier \tCountry\tASN\tResult\n")
+	for _, r := range reply.Results {
+		country, asn, result := "", "", "added"
+		if r.Updated {
+			result = "updated"
+		}
+		if r.Location != nil {
+			country, asn = r.Location.Country, r.Location.ASN
+			if len(r.Location.Warnings) > 0 {
+				result = strings.Join(r.Location.Warnings, " ")
+			}
+		}
+		if r.Error != "" {
+			result = "error: " + r.Error
+		}
+		fmt.Fprintf(w, "%s \t%s\t%s\t%s\n", r.Name, country, asn, result)
+	}
+	w.Flush()
+
+	if !reply.Committed {
+		return newError(ExitFailure, "\nNo mirror saved, fix the errors above and retry")
+	}
+	updated := 0
+	for _, r := range reply.Results {
+		if r.Updated {
+			updated++
+		}
+	}
+	fmt.Printf("\n%d mirrors added and %d updated successfully\n", len(reply.Results)-updated, updated)
+	if updated < len(reply.Results) {
+		fmt.Printf("Enable the new mirrors using\n  $ mirrorbits enable IDENTIFIER\n")
+	}
+	return nil
+}
+
 func (c *cli) CmdRemove(args ...string) error {
-	cmd := SubCmd("remove", "IDENTIFIER", "Remove an existing mirror")
+	cmd := SubCmd("remove", "IDENTIFIER", "Remove an existing mirror, it can be restored until it is purged")
 	force := cmd.Bool("f", false, "Never prompt for confirmation")
+	purge := cmd.Bool("purge", false, "Purge the mirror immediately, it cannot be restored")
+	dryRun := cmd.Bool("dry-run", false, "Report what would be deleted without removing the mirror")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	id, name := c.matchMirror(cmd.Arg(0))
+	id, name, err := c.matchMirror(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		return c.removalReport(id, name, *purge)
+	}
 
 	if *force == false {
 		fmt.Printf("Removing %s, are you sure? [y/N]", name)
@@ -376,20 +647,115 @@ func (c *cli) CmdRemove(args ...string) error {
 		}
 	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
-	_, err := client.RemoveMirror(ctx, &rpc.MirrorIDRequest{
-		ID: int32(id),
+	_, err = client.RemoveMirror(ctx, &rpc.RemoveMirrorRequest{
+		ID:    int32(id),
+		Purge: *purge,
 	})
 	if err != nil {
-		log.Fatal("remove error:", err)
+		return rpcError(err, "remove error")
 	}
 
 	fmt.Printf("Mirror '%s' removed successfully\n", name)
 	return nil
 }
 
+// removalReport prints what removing the given mirror would delete
+func (c *cli) removalReport(id int, name string, purge bool) error {
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	report, err := client.RemovalReport(ctx, &rpc.MirrorIDRequest{
+		ID: int32(id),
+	})
+	if err != nil {
+		return rpcError(err, "remove error")
+	}
+
+	purge = purge || report.Retention == 0
+	if purge {
+		fmt.Printf("Removing %s would purge it immediately, it could not be restored:\n", name)
+		fmt.Printf("  %d FILEINFO keys and %d file references deleted\n", report.Files, report.Files)
+		fmt.Printf("  %d log entries deleted\n", report.Logs)
+	} else {
+		fmt.Printf("Removing %s would keep it restorable for %d days before purging it:\n", name, report.Retention)
+		fmt.Printf("  %d files not served anymore, %d FILEINFO keys deleted once purged\n", report.Files, report.Files)
+		fmt.Printf("  %d log entries deleted once purged\n", report.Logs)
+	}
+	fmt.Printf("  %d stats keys referencing the mirror retained\n", report.StatsKeys)
+	return nil
+}
+
+func (c *cli) CmdRestore(args ...string) error {
+	cmd := SubCmd("restore", "[IDENTIFIER]", "Restore a removed mirror, or list the removed mirrors")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() > 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	reply, err := client.ListRemovedMirrors(ctx, &empty.Empty{})
+	if err != nil {
+		return rpcError(err, "restore error")
+	}
+
+	if cmd.NArg() == 0 {
+		if len(reply.Mirrors) == 0 {
+			fmt.Println("No removed mirror")
+			return nil
+		}
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+		fmt.Fprint(w, "Identifier \tRemoved \tPurged\n")
+		for _, m := range reply.Mirrors {
+			removedAt, _ := ptypes.Timestamp(m.RemovedAt)
+			purgeAt, _ := ptypes.Timestamp(m.PurgeAt)
+			fmt.Fprintf(w, "%s \t%s \t%s\n", m.Name, removedAt.Local().Format(time.RFC1123), purgeAt.Local().Format(time.RFC1123))
+		}
+		w.Flush()
+		return nil
+	}
+
+	// Match the removed mirrors by name or by ID
+	var match *rpc.RemovedMirror
+	for _, m := range reply.Mirrors {
+		if m.Name == cmd.Arg(0) || strconv.Itoa(int(m.ID)) == cmd.Arg(0) {
+			match = m
+			break
+		}
+	}
+	if match == nil {
+		return newError(ExitNotFound, "No removed mirror matching '%s'", cmd.Arg(0))
+	}
+
+	_, err = client.RestoreMirror(ctx, &rpc.MirrorIDRequest{
+		ID: match.ID,
+	})
+	if err != nil {
+		return rpcError(err, "restore error")
+	}
+
+	fmt.Printf("Mirror '%s' restored successfully\n", match.Name)
+	return nil
+}
+
 func (c *cli) CmdScan(args ...string) error {
 	cmd := SubCmd("scan", "[IDENTIFIER]", "(Re-)Scan a mirror")
 	enable := cmd.Bool("enable", false, "Enable the mirror automatically if the scan is successful")
@@ -397,16 +763,20 @@ func (c *cli) CmdScan(args ...string) error {
 	ftp := cmd.Bool("ftp", false, "Force a scan using FTP")
 	rsync := cmd.Bool("rsync", false, "Force a scan using rsync")
 	timeout := cmd.Uint("timeout", 0, "Timeout in seconds")
+	force := cmd.Bool("force", false, "Apply the changes even if too many files would be removed")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if !*all && cmd.NArg() != 1 || *all && cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -426,7 +796,10 @@ func (c *cli) CmdScan(args ...string) error {
 		}
 	} else {
 		// Single mirror
-		id, name := c.matchMirror(cmd.Arg(0))
+		id, name, err := c.matchMirror(cmd.Arg(0))
+		if err != nil {
+			return err
+		}
 		list[id] = name
 	}
 
@@ -453,6 +826,7 @@ func (c *cli) CmdScan(args ...string) error {
 			ID:         int32(id),
 			AutoEnable: *enable,
 			Protocol:   method,
+			Force:      *force,
 		})
 		if err != nil {
 			s := status.Convert(err)
@@ -466,6 +840,12 @@ func (c *cli) CmdScan(args ...string) error {
 			if reply.GetTZOffsetMs() != 0 {
 				fmt.Printf("  ∟ Timezone offset detected and corrected: %d milliseconds\n", reply.TZOffsetMs)
 			}
+			if len(reply.CaseCollisions) > 0 {
+				fmt.Printf("  ∟ %d files excluded, their path collides with another file differing only by case:\n", len(reply.CaseCollisions))
+				for _, p := range reply.CaseCollisions {
+					fmt.Printf("      %s\n", p)
+				}
+			}
 			if reply.Enabled {
 				fmt.Println("  ∟ Enabled")
 			}
@@ -480,24 +860,27 @@ func (c *cli) CmdRefresh(args ...string) error {
 	rehash := cmd.Bool("rehash", false, "Force a rehash of the files")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
 	fmt.Print("Refreshing the local repository... ")
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	_, err := client.RefreshRepository(ctx, &rpc.RefreshRepositoryRequest{
+	_, err = client.RefreshRepository(ctx, &rpc.RefreshRepositoryRequest{
 		Rehash: *rehash,
 	})
 	if err != nil {
 		fmt.Println("")
-		log.Fatal(err)
+		return rpcError(err, "refresh error")
 	}
 
 	fmt.Println("done")
@@ -505,40 +888,36 @@ func (c *cli) CmdRefresh(args ...string) error {
 	return nil
 }
 
-func (c *cli) matchMirror(pattern string) (id int, name string) {
+func (c *cli) matchMirror(pattern string) (id int, name string, err error) {
 	if len(pattern) == 0 {
-		return -1, ""
+		return -1, "", nil
 	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return -1, "", err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
 	reply, err := client.MatchMirror(ctx, &rpc.MatchRequest{
 		Pattern: pattern,
 	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "mirror matching: %s\n", err)
-		os.Exit(1)
+		return -1, "", rpcError(err, "mirror matching")
 	}
 
 	switch len(reply.Mirrors) {
 	case 0:
-		fmt.Fprintf(os.Stderr, "No match for '%s'\n", pattern)
-		os.Exit(1)
+		return -1, "", newError(ExitNotFound, "No match for '%s'", pattern)
 	case 1:
-		id, name, err := GetSingle(reply.Mirrors)
-		if err != nil {
-			log.Fatal("unexpected error:", err)
-		}
-		return id, name
+		return GetSingle(reply.Mirrors)
 	default:
-		fmt.Fprintln(os.Stderr, "Multiple match:")
+		names := make([]string, 0, len(reply.Mirrors))
 		for _, mirror := range reply.Mirrors {
-			fmt.Fprintf(os.Stderr, "  %s\n", mirror.Name)
+			names = append(names, "  "+mirror.Name)
 		}
-		os.Exit(1)
+		return -1, "", newError(ExitAmbiguous, "Multiple match:\n%s", strings.Join(names, "\n"))
 	}
-	return
 }
 
 func GetSingle(list []*rpc.MirrorID) (int, string, error) {
@@ -554,34 +933,40 @@ func (c *cli) CmdEdit(args ...string) error {
 	cmd := SubCmd("edit", "[IDENTIFIER]", "Edit a mirror")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
 	// Find the editor to use
 	editor := os.Getenv("EDITOR")
 
 	if editor == "" {
-		log.Fatal("Environment variable $EDITOR not set")
+		return errors.New("Environment variable $EDITOR not set")
 	}
 
-	id, _ := c.matchMirror(cmd.Arg(0))
+	id, _, err := c.matchMirror(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
 	rpcm, err := client.MirrorInfo(ctx, &rpc.MirrorIDRequest{
 		ID: int32(id),
 	})
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 	mirror, err := rpc.MirrorFromRPC(rpcm)
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 
 	// Generate a yaml configuration string from the struct
@@ -590,7 +975,7 @@ func (c *cli) CmdEdit(args ...string) error {
 	// Open a temporary file
 	f, err := ioutil.TempFile(os.TempDir(), "edit")
 	if err != nil {
-		log.Fatal("Cannot create temporary file:", err)
+		return errors.Wrap(err, "Cannot create temporary file")
 	}
 	defer os.Remove(f.Name())
 	f.WriteString("# You can now edit this mirror configuration.\n" +
@@ -611,13 +996,13 @@ reopen:
 
 	err = exe.Run()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Read the file back
 	out, err = ioutil.ReadFile(f.Name())
 	if err != nil {
-		log.Fatal("Cannot read file", f.Name())
+		return errors.Errorf("Cannot read file %s", f.Name())
 	}
 
 	// Checksum the file back and compare
@@ -669,7 +1054,7 @@ reopen:
 	defer cancel()
 	m, err := rpc.MirrorToRPC(mirror)
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 	reply, err := client.UpdateMirror(ctx, m)
 	if err != nil {
@@ -681,7 +1066,11 @@ reopen:
 				return nil
 			}
 		}
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
+	}
+
+	for _, w := range reply.Warnings {
+		fmt.Println(w)
 	}
 
 	if len(reply.Diff) > 0 {
@@ -698,24 +1087,30 @@ func (c *cli) CmdGeoupdate(args ...string) error {
 	force := cmd.Bool("f", false, "Never prompt for confirmation")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	id, name := c.matchMirror(cmd.Arg(0))
+	id, name, err := c.matchMirror(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
 
 	// Get mirror with geolocation updated
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
 	reply, err := client.GeoUpdateMirror(ctx, &rpc.MirrorIDRequest{
 		ID: int32(id),
 	})
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 
 	// Print warnings if any
@@ -752,7 +1147,7 @@ func (c *cli) CmdGeoupdate(args ...string) error {
 	defer cancel()
 	reply2, err := client.UpdateMirror(ctx, reply.Mirror)
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 
 	// The diff shouldn't have changed, but let's check
@@ -770,316 +1165,1705 @@ func (c *cli) CmdShow(args ...string) error {
 	cmd := SubCmd("show", "[IDENTIFIER]", "Print a mirror configuration")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	id, _ := c.matchMirror(cmd.Arg(0))
+	id, _, err := c.matchMirror(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
 	rpcm, err := client.MirrorInfo(ctx, &rpc.MirrorIDRequest{
 		ID: int32(id),
 	})
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 	mirror, err := rpc.MirrorFromRPC(rpcm)
 	if err != nil {
-		log.Fatal("edit error:", err)
+		return rpcError(err, "edit error")
 	}
 
 	// Generate a yaml configuration string from the struct
 	out, err := yaml.Marshal(mirror)
 	if err != nil {
-		log.Fatal("show error:", err)
+		return rpcError(err, "show error")
+	}
+
+	if mirror.LastSuccessfulSync.Unix() > 0 {
+		fmt.Printf("# Last successful sync: %s (protocol: %s, precision: %s)\n",
+			mirror.LastSuccessfulSync.Format(time.RFC1123), mirror.LastSuccessfulSyncProtocol,
+			precisionString(int64(mirror.LastSuccessfulSyncPrecision), int64(mirror.ModTimePrecision)))
+	}
+	for _, alt := range mirror.AltURLs {
+		if alt.Down {
+			fmt.Printf("# Alternative URL down: %s\n", alt.URL)
+		}
 	}
 
 	fmt.Printf("%s\nComment:\n%s\n", out, mirror.Comment)
 	return nil
 }
 
-func (c *cli) CmdExport(args ...string) error {
-	cmd := SubCmd("export", "[format]", "Export the mirror database.\n\nAvailable formats: mirmon")
-	rsync := cmd.Bool("rsync", true, "Export rsync URLs")
-	http := cmd.Bool("http", true, "Export http URLs")
-	ftp := cmd.Bool("ftp", true, "Export ftp URLs")
-	disabled := cmd.Bool("disabled", true, "Export disabled mirrors")
+// precisionString returns the effective precision of a mirror
+func precisionString(detected, override int64) string {
+	if override > 0 {
+		return core.Precision(override).String() + " (forced)"
+	}
+	return core.Precision(detected).String()
+}
+
+func (c *cli) CmdExplainconfig(args ...string) error {
+	cmd := SubCmd("explain-config", "[KEY]", "Describe the keys of the configuration file, or only the ones starting with KEY.\n\nThe keys marked as restart are only applied when the server is restarted,\nthe other ones are applied when the configuration is reloaded.\n\nEach key can be overridden by the environment variable MIRRORBITS_ followed\nby the key in upper case, the dots being replaced with underscores.")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
-	}
-	if cmd.NArg() != 1 {
-		cmd.Usage()
-		return nil
+		return usageError(err)
 	}
-
-	if cmd.Arg(0) != "mirmon" {
-		fmt.Fprintf(os.Stderr, "Unsupported format\n")
+	if cmd.NArg() > 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	client := c.GetRPC()
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
-	defer cancel()
-	list, err := client.List(ctx, &empty.Empty{})
-	if err != nil {
-		log.Fatal("export error:", err)
-	}
+	filter := strings.ToLower(cmd.Arg(0))
 
 	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
-
-	for _, m := range list.Mirrors {
-		if *disabled == false {
-			if m.Enabled == false {
-				continue
-			}
-		}
-		ccodes := strings.Fields(m.CountryCodes)
-
-		urls := make([]string, 0, 3)
-		if *rsync == true && m.RsyncURL != "" {
-			urls = append(urls, m.RsyncURL)
-		}
-		if *http == true && m.HttpURL != "" {
-			urls = append(urls, m.HttpURL)
-		}
-		if *ftp == true && m.FtpURL != "" {
-			urls = append(urls, m.FtpURL)
+	w.Init(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprint(w, "Key\tType\tDefault\tReload\tDescription\n")
+	for _, k := range config.Explain() {
+		if !strings.HasPrefix(strings.ToLower(k.Key), filter) {
+			continue
 		}
-
-		for _, u := range urls {
-			fmt.Fprintf(w, "%s\t%s\t%s\n", ccodes[0], u, m.AdminEmail)
+		reload := "yes"
+		if !k.Reloadable {
+			reload = "restart"
 		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.Key, k.Type, k.Default, reload, k.Description)
 	}
-
 	w.Flush()
 	return nil
 }
 
-func (c *cli) CmdEnable(args ...string) error {
-	cmd := SubCmd("enable", "[IDENTIFIER]", "Enable a mirror")
+func (c *cli) CmdConfig(args ...string) error {
+	cmd := SubCmd("config", "[KEY]", "Print the configuration in use by the server, the secrets being redacted.\n\nIf a KEY is given, only the values of the keys starting with KEY are printed,\nusing the notation of explain-config.")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
-	if cmd.NArg() != 1 {
+	if cmd.NArg() > 1 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	c.changeStatus(cmd.Arg(0), true)
-	return nil
-}
-
-func (c *cli) CmdDisable(args ...string) error {
-	cmd := SubCmd("disable", "[IDENTIFIER]", "Disable a mirror")
-
-	if err := cmd.Parse(args); err != nil {
-		return nil
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	reply, err := client.GetConfig(ctx, &empty.Empty{})
+	if err != nil {
+		return rpcError(err, "config error")
+	}
+
+	if cmd.NArg() == 0 {
+		fmt.Printf("# %s\n%s", reply.ConfigFile, reply.Config)
+		return nil
+	}
+
+	var cfg config.Configuration
+	if err = yaml.Unmarshal([]byte(reply.Config), &cfg); err != nil {
+		return errors.Wrap(err, "invalid configuration")
+	}
+	filter := strings.ToLower(cmd.Arg(0))
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprint(w, "Key\tValue\n")
+	for _, s := range cfg.Settings() {
+		if strings.HasPrefix(strings.ToLower(s.Key), filter) {
+			fmt.Fprintf(w, "%s\t%s\n", s.Key, s.Value)
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+func (c *cli) CmdConfigtest(args ...string) error {
+	cmd := SubCmd("configtest", "[OPTIONS]", "Check the configuration file without starting the server.\n\nThe file is parsed and validated, the keys unknown to this version, the\nmissing local paths, the GeoIP databases and the connection to redis are\nchecked. The command fails if any error is found, the warnings are only\nreported.")
+	file := cmd.String("config", core.ConfigFile, "Path to the config file (default: "+config.DefaultConfigFile+")")
+	jsonOutput := cmd.Bool("json", false, "Print the problems as JSON")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	return ConfigTest(*file, *jsonOutput)
+}
+
+// ConfigTest checks the given configuration file, as well as the GeoIP
+// databases and the redis server it references, and prints the problems
+// found. An error is returned if the configuration cannot be loaded.
+func ConfigTest(filename string, jsonOutput bool) error {
+	if filename == "" {
+		filename = config.DefaultConfigFile
+	}
+
+	cfg, problems := config.CheckConfig(filename)
+	if cfg != nil {
+		// The databases are checked with the configuration being tested
+		config.SetConfiguration(cfg)
+		if !core.Debug {
+			logging.SetLevel(logging.CRITICAL, "main")
+		}
+
+		if err := network.NewGeoIP().LoadGeoIP(); err != nil {
+			severity := config.SeverityWarning
+			errs := []error{err}
+			if e, ok := err.(network.GeoIPError); ok {
+				if e.IsFatal() {
+					severity = config.SeverityError
+				}
+				errs = e.Errors
+			}
+			for _, err := range errs {
+				problems = append(problems, config.Problem{
+					Severity: severity,
+					Key:      "GeoipDatabasePath",
+					Message:  err.Error(),
+				})
+			}
+		}
+		if err := database.CheckConnection(); err != nil {
+			problems = append(problems, config.Problem{
+				Severity: config.SeverityError,
+				Key:      "RedisAddress",
+				Message:  fmt.Sprintf("cannot connect to redis: %s", err),
+			})
+		}
+	}
+
+	errorCount := 0
+	for _, p := range problems {
+		if p.Severity == config.SeverityError {
+			errorCount++
+		}
+	}
+
+	if jsonOutput {
+		if problems == nil {
+			problems = []config.Problem{}
+		}
+		out, err := json.MarshalIndent(problems, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if errorCount == 0 {
+			fmt.Printf("Configuration file %s is valid\n", filename)
+		}
+	}
+
+	if errorCount > 0 {
+		return newError(ExitFailure, "Configuration file %s has %d error(s)", filename, errorCount)
+	}
+	return nil
+}
+
+func (c *cli) CmdExport(args ...string) error {
+	cmd := SubCmd("export", "[format]", "Export the mirror database.\n\nAvailable formats: mirmon, yaml, json, apt, yum, dnf, pacman\n\nThe yaml and json exports can be imported back with add -f FILE -update\n\nThe apt, yum, dnf and pacman formats are mirrorlists of the http URLs\nof the enabled mirrors, grouped by country")
+	rsync := cmd.Bool("rsync", true, "Export rsync URLs")
+	http := cmd.Bool("http", true, "Export http URLs")
+	ftp := cmd.Bool("ftp", true, "Export ftp URLs")
+	disabled := cmd.Bool("disabled", true, "Export disabled mirrors")
+	suffix := cmd.String("suffix", "", "Path appended to the mirror URLs (default to $repo/os/$arch for pacman)")
+	var countries stringList
+	cmd.Var(&countries, "country", "Only export the mirrors of the given country code (can be repeated)")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	switch cmd.Arg(0) {
+	case "mirmon":
+	case "yaml", "json":
+		return c.exportMirrors(cmd.Arg(0), *disabled)
+	case "apt", "yum", "dnf", "pacman":
+		return c.exportMirrorlist(cmd.Arg(0), *suffix, countries)
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported format\n")
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	req := &rpc.MirrorListRequest{
+		Fields: []string{"CountryCodes", "RsyncURL", "HttpURL", "FtpURL", "AdminEmail"},
+	}
+	if *disabled == false {
+		req.Enabled = rpc.MirrorListRequest_YES
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	list, err := listMirrors(client, req)
+	if err != nil {
+		return rpcError(err, "export error")
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+
+	for _, m := range list {
+		ccodes := strings.Fields(m.CountryCodes)
+
+		urls := make([]string, 0, 3)
+		if *rsync == true && m.RsyncURL != "" {
+			urls = append(urls, m.RsyncURL)
+		}
+		if *http == true && m.HttpURL != "" {
+			urls = append(urls, m.HttpURL)
+		}
+		if *ftp == true && m.FtpURL != "" {
+			urls = append(urls, m.FtpURL)
+		}
+
+		for _, u := range urls {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", ccodes[0], u, m.AdminEmail)
+		}
+	}
+
+	w.Flush()
+	return nil
+}
+
+// exportMirrorlist writes the http URLs of the enabled mirrors in a
+// mirrorlist readable by the package manager of the given format
+func (c *cli) exportMirrorlist(format, suffix string, countries []string) error {
+	req := &rpc.MirrorListRequest{
+		Fields:  []string{"Name", "CountryCodes", "HttpURL"},
+		Enabled: rpc.MirrorListRequest_YES,
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	list, err := listMirrors(client, req)
+	if err != nil {
+		return rpcError(err, "export error")
+	}
+
+	if format == "pacman" && suffix == "" {
+		suffix = "$repo/os/$arch"
+	}
+	suffix = strings.TrimPrefix(suffix, "/")
+
+	wanted := make(map[string]bool)
+	for _, cc := range countries {
+		wanted[strings.ToUpper(cc)] = true
+	}
+
+	type entry struct {
+		country string
+		name    string
+		url     string
+	}
+
+	entries := make([]entry, 0, len(list))
+	for _, m := range list {
+		if m.HttpURL == "" {
+			continue
+		}
+		country := "ZZ"
+		if ccodes := strings.Fields(strings.ToUpper(m.CountryCodes)); len(ccodes) > 0 {
+			country = ccodes[0]
+		}
+		if len(wanted) > 0 && !wanted[country] {
+			continue
+		}
+		u := m.HttpURL
+		if !strings.HasSuffix(u, "/") {
+			u += "/"
+		}
+		entries = append(entries, entry{
+			country: country,
+			name:    m.Name,
+			url:     u + suffix,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].country != entries[j].country {
+			return entries[i].country < entries[j].country
+		}
+		return entries[i].name < entries[j].name
+	})
+
+	w := bufio.NewWriter(os.Stdout)
+	country := ""
+	for i, e := range entries {
+		if i == 0 || e.country != country {
+			country = e.country
+			switch format {
+			case "pacman":
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "## %s\n", country)
+			default:
+				fmt.Fprintf(w, "# %s\n", country)
+			}
+		}
+		switch format {
+		case "pacman":
+			fmt.Fprintf(w, "Server = %s\n", e.url)
+		default:
+			fmt.Fprintf(w, "%s\n", e.url)
+		}
+	}
+	return w.Flush()
+}
+
+// exportMirrors writes the settings of the mirrors in the format read by
+// the add command
+func (c *cli) exportMirrors(format string, disabled bool) error {
+	req := &rpc.MirrorListRequest{}
+	if !disabled {
+		req.Enabled = rpc.MirrorListRequest_YES
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	list, err := listMirrors(client, req)
+	if err != nil {
+		return rpcError(err, "export error")
+	}
+
+	mlist := make([]*mirrors.Mirror, 0, len(list))
+	for _, rm := range list {
+		m, err := rpc.MirrorFromRPC(rm)
+		if err != nil {
+			return rpcError(err, "export error")
+		}
+		mlist = append(mlist, m)
+	}
+
+	out, err := yaml.Marshal(mlist)
+	if err != nil {
+		return newError(ExitFailure, "export error: %s", err)
+	}
+	if format == "json" {
+		// Use the same keys as the yaml export, skipping the runtime values
+		var values []map[string]interface{}
+		if err := yaml.Unmarshal(out, &values); err != nil {
+			return newError(ExitFailure, "export error: %s", err)
+		}
+		if values == nil {
+			values = []map[string]interface{}{}
+		}
+		if out, err = json.MarshalIndent(values, "", "    "); err != nil {
+			return newError(ExitFailure, "export error: %s", err)
+		}
+		out = append(out, '\n')
+	}
+	os.Stdout.Write(out)
+	return nil
+}
+
+func (c *cli) CmdEnable(args ...string) error {
+	cmd := SubCmd("enable", "[IDENTIFIER]", "Enable a mirror")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	return c.changeStatus(cmd.Arg(0), true, nil)
+}
+
+func (c *cli) CmdDisable(args ...string) error {
+	cmd := SubCmd("disable", "[OPTIONS] IDENTIFIER", "Disable a mirror, optionally for the given time after which\nthe mirror is enabled and scanned again")
+	reason := cmd.String("reason", "", "Reason shown along the disabled mirror")
+	duration := cmd.String("for", "", "Enable the mirror again after this time, either a date (YYYY-MM-DD [HH:MM]) or a duration (i.e. 48h)")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() < 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	// Accept the options after the identifier as well
+	identifier := cmd.Arg(0)
+	if err := cmd.Parse(cmd.Args()[1:]); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	req := &rpc.ChangeStatusRequest{
+		Reason: *reason,
+	}
+	if *duration != "" {
+		t, err := parseMaintenanceTime(*duration)
+		if err != nil {
+			return newError(ExitUsage, fmt.Sprintf("Invalid time: %s", err))
+		}
+		if !t.After(time.Now()) {
+			return newError(ExitUsage, "The mirror must be disabled until a time in the future")
+		}
+		req.Until, err = ptypes.TimestampProto(t)
+		if err != nil {
+			return newError(ExitUsage, err.Error())
+		}
+	}
+
+	return c.changeStatus(identifier, false, req)
+}
+
+// changeStatus enables or disables a mirror. The request, if given, holds
+// the reason and the end of the disabling.
+func (c *cli) changeStatus(pattern string, enabled bool, req *rpc.ChangeStatusRequest) error {
+	id, name, err := c.matchMirror(pattern)
+	if err != nil {
+		return err
+	}
+
+	if req == nil {
+		req = &rpc.ChangeStatusRequest{}
+	}
+	req.ID = int32(id)
+	req.Enabled = enabled
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	_, err = client.ChangeStatus(ctx, req)
+	if err != nil {
+		if enabled {
+			return rpcError(err, fmt.Sprintf("Couldn't enable mirror '%s'", name))
+		}
+		return rpcError(err, fmt.Sprintf("Couldn't disable mirror '%s'", name))
+	}
+
+	if enabled {
+		fmt.Printf("Mirror '%s' enabled successfully\n", name)
+	} else if req.Until != nil {
+		until, _ := ptypes.Timestamp(req.Until)
+		fmt.Printf("Mirror '%s' disabled successfully until %s\n", name, until.Local().Format(time.RFC1123))
+	} else {
+		fmt.Printf("Mirror '%s' disabled successfully\n", name)
+	}
+	return nil
+}
+
+func (c *cli) CmdMaintenance(args ...string) error {
+	cmd := SubCmd("maintenance", "[OPTIONS] IDENTIFIER", "Put a mirror under maintenance, excluding it from the selection\nwithout its health-check failures bringing it down")
+	until := cmd.String("until", "1h", "End of the maintenance, either a date (YYYY-MM-DD [HH:MM]) or a duration (i.e. 2h)")
+	end := cmd.Bool("end", false, "End the maintenance now")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	req := &rpc.SetMaintenanceRequest{}
+	if !*end {
+		t, err := parseMaintenanceTime(*until)
+		if err != nil {
+			return newError(ExitUsage, fmt.Sprintf("Invalid end of maintenance: %s", err))
+		}
+		if !t.After(time.Now()) {
+			return newError(ExitUsage, "The end of the maintenance must be in the future")
+		}
+		req.Until, err = ptypes.TimestampProto(t)
+		if err != nil {
+			return newError(ExitUsage, err.Error())
+		}
+	}
+
+	id, name, err := c.matchMirror(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	req.ID = int32(id)
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	_, err = client.SetMaintenance(ctx, req)
+	if err != nil {
+		return rpcError(err, fmt.Sprintf("Couldn't change the maintenance of mirror '%s'", name))
+	}
+
+	if *end {
+		fmt.Printf("Maintenance of mirror '%s' ended\n", name)
+	} else {
+		until, _ := ptypes.Timestamp(req.Until)
+		fmt.Printf("Mirror '%s' under maintenance until %s\n", name, until.Local().Format(time.RFC1123))
+	}
+	return nil
+}
+
+// parseMaintenanceTime parses either a date or a duration from now
+func parseMaintenanceTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(d), nil
+	}
+	if t, err := time.ParseInLocation("2006-1-2 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-1-2", value, time.Local)
+}
+
+func (c *cli) CmdFile(args ...string) error {
+	cmd := SubCmd("file", "PATH", "Print the details of a file of the local repository")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	reply, err := client.FileInfo(ctx, &rpc.FileInfoRequest{
+		Path: cmd.Arg(0),
+	})
+	if err != nil {
+		return rpcError(err, "file error")
+	}
+
+	// seen formats the given time along with the time elapsed since then
+	seen := func(ts *timestamp.Timestamp) string {
+		if ts == nil {
+			return "unknown"
+		}
+		t, err := ptypes.Timestamp(ts)
+		if err != nil {
+			return "unknown"
+		}
+		return fmt.Sprintf("%s (%s)", t.Local().Format(time.RFC1123), utils.RelativeTime(t))
+	}
+
+	fmt.Printf("Path:        %s\n", reply.Path)
+	fmt.Printf("Size:        %s (%d bytes)\n", utils.ReadableSize(reply.Size), reply.Size)
+	fmt.Printf("Modified:    %s\n", seen(reply.ModTime))
+	fmt.Printf("First seen:  %s\n", seen(reply.FirstSeen))
+	fmt.Printf("Last seen:   %s\n", seen(reply.LastSeen))
+	for _, h := range []struct {
+		name, value string
+	}{
+		{"MD5", reply.Md5},
+		{"SHA1", reply.Sha1},
+		{"SHA256", reply.Sha256},
+		{"SHA512", reply.Sha512},
+		{"BLAKE2b", reply.Blake2B},
+	} {
+		if h.value != "" {
+			fmt.Printf("%-13s%s\n", h.name+":", h.value)
+		}
+	}
+	return nil
+}
+
+func (c *cli) CmdTest(args ...string) error {
+	cmd := SubCmd("test", "[OPTIONS] [IDENTIFIER] PATH", "Simulate the selection of mirrors for the given file as it would be made\nby the HTTP server, optionally for a single mirror")
+	ip := cmd.String("ip", "", "IP address of the client")
+	country := cmd.String("country", "", "Country code of the client, overriding its geolocation")
+	https := cmd.Bool("https", false, "Simulate a request requiring HTTPS")
+	plainHTTP := cmd.Bool("http", false, "Simulate a request requiring plain HTTP")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() < 1 || cmd.NArg() > 2 || (*https && *plainHTTP) {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	req := &rpc.SimulateSelectionRequest{
+		Path:    cmd.Arg(cmd.NArg() - 1),
+		IP:      *ip,
+		Country: *country,
+	}
+	if !strings.HasPrefix(req.Path, "/") {
+		req.Path = "/" + req.Path
+	}
+	if *https {
+		req.Secure = rpc.SimulateSelectionRequest_HTTPS
+	} else if *plainHTTP {
+		req.Secure = rpc.SimulateSelectionRequest_HTTP
+	}
+
+	id := 0
+	if cmd.NArg() == 2 {
+		var err error
+		id, _, err = c.matchMirror(cmd.Arg(0))
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	reply, err := client.SimulateSelection(ctx, req)
+	if err != nil {
+		return rpcError(err, "test error")
+	}
+
+	fmt.Printf("File:      %s (%s)\n", reply.Path, utils.ReadableSize(reply.Size))
+	location := reply.Country
+	if reply.City != "" {
+		location = fmt.Sprintf("%s, %s", reply.City, reply.Country)
+	}
+	if location == "" {
+		location = "unknown"
+	}
+	fmt.Printf("Client:    %s (%s) %.2f,%.2f\n", location, reply.Continent, reply.Latitude, reply.Longitude)
+	if reply.ASN != "" {
+		fmt.Printf("ASN:       %s\n", reply.ASN)
+	}
+	if reply.Fallback {
+		fmt.Printf("Fallback:  no mirror selected, using the fallbacks\n")
+	}
+	fmt.Println()
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	fmt.Fprint(w, "Rank\tIdentifier \tScore\tSelection\tDistance\tCountry\tContinent\n")
+	for i, m := range reply.Mirrors {
+		if id > 0 && int(m.ID) != id {
+			continue
+		}
+		selection := "n/a"
+		if m.Weight > 0 {
+			selection = fmt.Sprintf("%.1f%%", m.Weight)
+		}
+		fmt.Fprintf(w, "%d.\t%s \t%d\t%s\t%.0f km\t%s\t%s\n", i+1, m.Name, m.ComputedScore,
+			selection, m.Distance, m.CountryCodes, m.ContinentCode)
+	}
+	w.Flush()
+
+	if len(reply.Excluded) > 0 {
+		fmt.Println()
+		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+		fmt.Fprint(w, "Excluded \tDistance\tCountry\tContinent\tReason\n")
+		for _, m := range reply.Excluded {
+			if id > 0 && int(m.ID) != id {
+				continue
+			}
+			fmt.Fprintf(w, "%s \t%.0f km\t%s\t%s\t%s\n", m.Name, m.Distance,
+				m.CountryCodes, m.ContinentCode, m.ExcludeReason)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+func (c *cli) CmdFallback(args ...string) error {
+	if len(args) > 0 && args[0] == "test" {
+		return c.fallbackTest(args[1:]...)
+	}
+
+	cmd := SubCmd("fallback", "test [OPTIONS] [PATH]", "Preview the fallbacks used when the database is unavailable")
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	cmd.Usage()
+	return ErrUsage
+}
+
+func (c *cli) fallbackTest(args ...string) error {
+	cmd := SubCmd("fallback test", "[OPTIONS] [PATH]", "Simulate the database being unavailable and show which fallback would be\nchosen for the given client and file")
+	ip := cmd.String("ip", "", "IP address of the client")
+	country := cmd.String("country", "", "Country code of the client, overriding its geolocation")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() > 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	req := &rpc.SimulateSelectionRequest{
+		Path:     cmd.Arg(0),
+		IP:       *ip,
+		Country:  *country,
+		Fallback: true,
+	}
+	if !strings.HasPrefix(req.Path, "/") {
+		req.Path = "/" + req.Path
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	reply, err := client.SimulateSelection(ctx, req)
+	if err != nil {
+		return rpcError(err, "fallback test error")
+	}
+
+	location := reply.Country
+	if location == "" {
+		location = "unknown"
+	}
+	fmt.Printf("Client:    %s (%s)\n", location, reply.Continent)
+	if len(reply.Mirrors) > 0 {
+		fmt.Printf("Redirect:  %s\n", utils.ConcatURL(reply.Mirrors[0].HttpURL, reply.Path))
+	}
+	fmt.Println()
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	fmt.Fprint(w, "Rank\tIdentifier \tURL\tCountry\tContinent\n")
+	for i, m := range reply.Mirrors {
+		fmt.Fprintf(w, "%d.\t%s \t%s\t%s\t%s\n", i+1, m.Name, m.HttpURL, m.CountryCodes, m.ContinentCode)
+	}
+	w.Flush()
+
+	if len(reply.Warnings) > 0 {
+		fmt.Println()
+		for _, warning := range reply.Warnings {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+	return nil
+}
+
+func (c *cli) CmdTraffic(args ...string) error {
+	cmd := SubCmd("traffic", "[OPTIONS]", "Predict how the traffic would be redistributed among the mirrors after\ndisabling or adding mirrors, based on the last requests of the clients")
+	var disable, add stringList
+	cmd.Var(&disable, "disable", "Identifier of a mirror to disable (can be repeated)")
+	cmd.Var(&add, "add", "Mirror to add, as NAME,LATITUDE,LONGITUDE[,COUNTRY[,CONTINENT]] (can be repeated)")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	req := &rpc.SimulateTrafficRequest{}
+	for _, pattern := range disable {
+		id, _, err := c.matchMirror(pattern)
+		if err != nil {
+			return err
+		}
+		req.Disable = append(req.Disable, int32(id))
+	}
+	for _, spec := range add {
+		m, err := parseHypotheticalMirror(spec)
+		if err != nil {
+			return newError(ExitUsage, "Invalid mirror '%s': %s", spec, err)
+		}
+		req.Add = append(req.Add, m)
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	reply, err := client.SimulateTraffic(ctx, req)
+	if err != nil {
+		return rpcError(err, "traffic simulation error")
+	}
+
+	fmt.Printf("Based on the last %d requests\n\n", reply.Samples)
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	fmt.Fprint(w, "Identifier \tBefore\tAfter\tChange\n")
+	for _, m := range reply.Mirrors {
+		name := m.Name
+		if m.ID < 0 {
+			name += " (new)"
+		}
+		fmt.Fprintf(w, "%s \t%.1f%%\t%.1f%%\t%+.1f%%\n", name, m.Before, m.After, m.After-m.Before)
+	}
+	if reply.UnservedBefore > 0 || reply.UnservedAfter > 0 {
+		fmt.Fprintf(w, "%s \t%.1f%%\t%.1f%%\t%+.1f%%\n", "(no mirror)", reply.UnservedBefore,
+			reply.UnservedAfter, reply.UnservedAfter-reply.UnservedBefore)
+	}
+	w.Flush()
+	return nil
+}
+
+// parseHypotheticalMirror parses a mirror given as
+// NAME,LATITUDE,LONGITUDE[,COUNTRY[,CONTINENT]]
+func parseHypotheticalMirror(spec string) (*rpc.HypotheticalMirror, error) {
+	fields := strings.Split(spec, ",")
+	if len(fields) < 3 || len(fields) > 5 || strings.TrimSpace(fields[0]) == "" {
+		return nil, errors.New("expected NAME,LATITUDE,LONGITUDE[,COUNTRY[,CONTINENT]]")
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 32)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return nil, errors.New("invalid latitude")
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 32)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return nil, errors.New("invalid longitude")
+	}
+	m := &rpc.HypotheticalMirror{
+		Name:      strings.TrimSpace(fields[0]),
+		Latitude:  float32(latitude),
+		Longitude: float32(longitude),
+	}
+	if len(fields) > 3 {
+		m.CountryCode = strings.TrimSpace(fields[3])
+	}
+	if len(fields) > 4 {
+		m.ContinentCode = strings.TrimSpace(fields[4])
+	}
+	return m, nil
+}
+
+func (c *cli) CmdStats(args ...string) error {
+	if len(args) > 0 && args[0] == "top" {
+		return c.statsTop(args[1:]...)
+	}
+	if len(args) > 0 && args[0] == "prune" {
+		return c.statsPrune(args[1:]...)
+	}
+	if len(args) > 0 && args[0] == "http" {
+		return c.statsHTTP(args[1:]...)
+	}
+
+	cmd := SubCmd("stats", "[OPTIONS] [mirror|file|top|prune|http] [IDENTIFIER|PATTERN]", "Show download stats for a particular mirror or a file pattern")
+	dateStart := cmd.String("start-date", "", "Starting date (format YYYY-MM-DD)")
+	dateEnd := cmd.String("end-date", "", "Ending date (format YYYY-MM-DD)")
+	human := cmd.Bool("h", true, "Human readable version")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 2 || (cmd.Arg(0) != "mirror" && cmd.Arg(0) != "file") {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	start, err := time.Parse("2006-1-2", *dateStart)
+	if err != nil {
+		start = time.Now()
+	}
+	startproto, _ := ptypes.TimestampProto(start)
+
+	end, err := time.Parse("2006-1-2", *dateEnd)
+	if err != nil {
+		end = time.Now()
+	}
+	endproto, _ := ptypes.TimestampProto(end)
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+
+	if cmd.Arg(0) == "file" {
+		// File stats
+
+		reply, err := client.StatsFile(ctx, &rpc.StatsFileRequest{
+			Pattern:   cmd.Arg(1),
+			DateStart: startproto,
+			DateEnd:   endproto,
+		})
+		if err != nil {
+			return rpcError(err, "file stats error")
+		}
+
+		// Format the results
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+
+		// Sort keys and count requests
+		var keys []string
+		var requests int64
+		for k, req := range reply.Files {
+			requests += req
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s:\t%d\n", k, reply.Files[k])
+		}
+
+		if len(keys) > 0 {
+			// Add a line separator
+			fmt.Fprintf(w, "\t\n")
+		}
+
+		fmt.Fprintf(w, "Total download requests: \t%d\n", requests)
+		w.Flush()
+	} else if cmd.Arg(0) == "mirror" {
+		// Mirror stats
+
+		id, name, err := c.matchMirror(cmd.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		reply, err := client.StatsMirror(ctx, &rpc.StatsMirrorRequest{
+			ID:        int32(id),
+			DateStart: startproto,
+			DateEnd:   endproto,
+		})
+		if err != nil {
+			return rpcError(err, "mirror stats error")
+		}
+
+		// Format the results
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+
+		fmt.Fprintf(w, "Identifier:\t%s\n", name)
+		if !reply.Mirror.Enabled {
+			fmt.Fprintf(w, "Status:\tdisabled\n")
+		} else if reply.Mirror.Up {
+			fmt.Fprintf(w, "Status:\tup\n")
+		} else {
+			fmt.Fprintf(w, "Status:\tdown\n")
+		}
+		fmt.Fprintf(w, "Download requests:\t%d\n", reply.Requests)
+		fmt.Fprint(w, "Bytes transferred:\t")
+		if *human {
+			fmt.Fprintln(w, utils.ReadableSize(reply.Bytes))
+		} else {
+			fmt.Fprintln(w, reply.Bytes)
+		}
+		w.Flush()
+	}
+
+	return nil
+}
+
+func (c *cli) statsTop(args ...string) error {
+	cmd := SubCmd("stats top", "[OPTIONS] [PATTERN]", "Show the most downloaded files for a given period")
+	period := cmd.String("period", "day", "Period to consider (day, month, year or all)")
+	date := cmd.String("date", "", "Date within the period (format YYYY-MM-DD, default: today)")
+	count := cmd.Int("n", 10, "Number of files to show")
+	jsonOutput := cmd.Bool("json", false, "Output the results as JSON")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() > 1 || *count <= 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	p, ok := rpc.StatsTopRequest_PeriodType_value[strings.ToUpper(*period)]
+	if !ok {
+		return fmt.Errorf("invalid period: %s", *period)
+	}
+
+	when := time.Now()
+	if *date != "" {
+		var err error
+		when, err = time.Parse("2006-1-2", *date)
+		if err != nil {
+			return errors.Wrap(err, "invalid date")
+		}
+	}
+	dateproto, _ := ptypes.TimestampProto(when)
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+
+	reply, err := client.StatsTop(ctx, &rpc.StatsTopRequest{
+		Period:  rpc.StatsTopRequest_PeriodType(p),
+		Date:    dateproto,
+		Count:   int32(*count),
+		Pattern: cmd.Arg(0),
+	})
+	if err != nil {
+		return rpcError(err, "top stats error")
+	}
+
+	if *jsonOutput {
+		type fileDownloads struct {
+			Path      string
+			Downloads int64
+		}
+		files := make([]fileDownloads, 0, len(reply.Files))
+		for _, f := range reply.Files {
+			files = append(files, fileDownloads{f.Path, f.Downloads})
+		}
+		out, err := json.MarshalIndent(struct {
+			Files []fileDownloads
+			Total int64
+		}{files, reply.Total}, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	// Format the results
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+
+	for i, f := range reply.Files {
+		fmt.Fprintf(w, "%d.\t%s:\t%d\n", i+1, f.Path, f.Downloads)
+	}
+
+	if len(reply.Files) > 0 {
+		// Add a line separator
+		fmt.Fprintf(w, "\t\t\n")
+	}
+
+	fmt.Fprintf(w, "Total download requests:\t\t%d\n", reply.Total)
+	w.Flush()
+
+	return nil
+}
+
+func (c *cli) statsPrune(args ...string) error {
+	cmd := SubCmd("stats prune", "", "Remove the stats older than the configured retention")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+
+	reply, err := client.StatsPrune(ctx, &empty.Empty{})
+	if err != nil {
+		return rpcError(err, "stats prune error")
+	}
+
+	fmt.Printf("%d stats keys removed\n", reply.Removed)
+	return nil
+}
+
+func (c *cli) statsHTTP(args ...string) error {
+	cmd := SubCmd("stats http", "[OPTIONS]", "Show the HTTP responses sent to the clients per handler and status code")
+	dateStart := cmd.String("start-date", "", "Starting date (format YYYY-MM-DD)")
+	dateEnd := cmd.String("end-date", "", "Ending date (format YYYY-MM-DD)")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	start, err := time.Parse("2006-1-2", *dateStart)
+	if err != nil {
+		start = time.Now()
+	}
+	startproto, _ := ptypes.TimestampProto(start)
+
+	end, err := time.Parse("2006-1-2", *dateEnd)
+	if err != nil {
+		end = time.Now()
+	}
+	endproto, _ := ptypes.TimestampProto(end)
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+
+	reply, err := client.StatsHTTP(ctx, &rpc.StatsHTTPRequest{
+		DateStart: startproto,
+		DateEnd:   endproto,
+	})
+	if err != nil {
+		return rpcError(err, "stats http error")
+	}
+
+	if len(reply.Responses) == 0 {
+		fmt.Println("No response recorded for this period")
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	fmt.Fprint(w, "Handler \tCode \tResponses\n")
+	for _, r := range reply.Responses {
+		fmt.Fprintf(w, "%s \t%d \t%d\n", r.Handler, r.Code, r.Count)
+	}
+	w.Flush()
+	return nil
+}
+
+func (c *cli) CmdLogs(args ...string) error {
+	cmd := SubCmd("logs", "[IDENTIFIER]", "Print logs of a mirror")
+	maxResults := cmd.Uint("l", 500, "Maximum number of logs to return")
+	since := cmd.String("since", "", "Only return the logs since the given date (YYYY-MM-DD [HH:MM]) or duration (e.g. 168h)")
+	until := cmd.String("until", "", "Only return the logs until the given date (YYYY-MM-DD [HH:MM]) or duration (e.g. 24h)")
+	cursor := cmd.Int64("cursor", 0, "Resume from the cursor returned by a previous call")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
 	}
 	if cmd.NArg() != 1 {
 		cmd.Usage()
+		return ErrUsage
+	}
+
+	request := &rpc.GetMirrorLogsRequest{
+		MaxResults: int32(*maxResults),
+		Cursor:     *cursor,
+	}
+
+	if *since != "" {
+		t, err := parseLogTime(*since)
+		if err != nil {
+			return newError(ExitUsage, "invalid -since value: %s", *since)
+		}
+		request.Since, _ = ptypes.TimestampProto(t)
+	}
+	if *until != "" {
+		t, err := parseLogTime(*until)
+		if err != nil {
+			return newError(ExitUsage, "invalid -until value: %s", *until)
+		}
+		request.Until, _ = ptypes.TimestampProto(t)
+	}
+
+	id, name, err := c.matchMirror(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	request.ID = int32(id)
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	resp, err := client.GetMirrorLogs(ctx, request)
+	if err != nil {
+		return rpcError(err, "logs error")
+	}
+
+	if len(resp.Line) == 0 {
+		fmt.Printf("No logs for %s\n", name)
 		return nil
 	}
 
-	c.changeStatus(cmd.Arg(0), false)
+	fmt.Printf("Printing logs for %s:\n", name)
+
+	for _, l := range resp.Line {
+		fmt.Println(l)
+	}
+
+	if resp.NextCursor > 0 {
+		fmt.Printf("More logs available, continue with -cursor %d\n", resp.NextCursor)
+	}
+
 	return nil
 }
 
-func (c *cli) changeStatus(pattern string, enabled bool) {
-	id, name := c.matchMirror(pattern)
+func (c *cli) CmdWatch(args ...string) error {
+	cmd := SubCmd("watch", "[OPTIONS] [IDENTIFIER]", "Print the events of the mirrors and the configuration reloads as they happen")
+	var types stringList
+	cmd.Var(&types, "type", "Only print the events of the given type (e.g. state-changed, scan-completed, config-reloaded), can be repeated")
 
-	client := c.GetRPC()
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
-	defer cancel()
-	_, err := client.ChangeStatus(ctx, &rpc.ChangeStatusRequest{
-		ID:      int32(id),
-		Enabled: enabled,
-	})
-	if err != nil {
-		if enabled {
-			log.Fatalf("Couldn't enable mirror '%s': %s\n", name, err)
-		} else {
-			log.Fatalf("Couldn't disable mirror '%s': %s\n", name, err)
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() > 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	request := &rpc.WatchEventsRequest{
+		Types: types,
+	}
+	if cmd.NArg() == 1 {
+		id, _, err := c.matchMirror(cmd.Arg(0))
+		if err != nil {
+			return err
 		}
+		request.MirrorID = int32(id)
 	}
 
-	if enabled {
-		fmt.Printf("Mirror '%s' enabled successfully\n", name)
-	} else {
-		fmt.Printf("Mirror '%s' disabled successfully\n", name)
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	stream, err := client.WatchEvents(context.Background(), request)
+	if err != nil {
+		return rpcError(err, "watch error")
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return rpcError(err, "watch error")
+		}
+		timestamp, _ := ptypes.Timestamp(event.Timestamp)
+		subject := ""
+		if event.MirrorName != "" {
+			subject = event.MirrorName + ": "
+		}
+		message := event.Message
+		if event.Actor != "" {
+			message += " (by " + event.Actor + ")"
+		}
+		fmt.Printf("%s %-15s %s%s\n", timestamp.Local().Format("2006-01-02 15:04:05"), event.Type, subject, message)
 	}
-	return
 }
 
-func (c *cli) CmdStats(args ...string) error {
-	cmd := SubCmd("stats", "[OPTIONS] [mirror|file] [IDENTIFIER|PATTERN]", "Show download stats for a particular mirror or a file pattern")
-	dateStart := cmd.String("start-date", "", "Starting date (format YYYY-MM-DD)")
-	dateEnd := cmd.String("end-date", "", "Ending date (format YYYY-MM-DD)")
-	human := cmd.Bool("h", true, "Human readable version")
+func (c *cli) CmdCheck(args ...string) error {
+	cmd := SubCmd("check", "[OPTIONS] [IDENTIFIER]", "Check the health of a mirror, or of all the enabled mirrors, immediately")
+	all := cmd.Bool("all", false, "Check all the enabled mirrors")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
-	if cmd.NArg() != 2 || (cmd.Arg(0) != "mirror" && cmd.Arg(0) != "file") {
+	if (*all && cmd.NArg() != 0) || (!*all && cmd.NArg() != 1) {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	start, err := time.Parse("2006-1-2", *dateStart)
-	if err != nil {
-		start = time.Now()
+	request := &rpc.CheckMirrorsRequest{}
+	if !*all {
+		id, _, err := c.matchMirror(cmd.Arg(0))
+		if err != nil {
+			return err
+		}
+		request.ID = int32(id)
 	}
-	startproto, _ := ptypes.TimestampProto(start)
 
-	end, err := time.Parse("2006-1-2", *dateEnd)
+	client, err := c.GetRPC()
 	if err != nil {
-		end = time.Now()
+		return err
+	}
+	stream, err := client.CheckMirrors(context.Background(), request)
+	if err != nil {
+		return rpcError(err, "check error")
 	}
-	endproto, _ := ptypes.TimestampProto(end)
-
-	client := c.GetRPC()
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
-	defer cancel()
-
-	if cmd.Arg(0) == "file" {
-		// File stats
 
-		reply, err := client.StatsFile(ctx, &rpc.StatsFileRequest{
-			Pattern:   cmd.Arg(1),
-			DateStart: startproto,
-			DateEnd:   endproto,
-		})
+	failed := 0
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			log.Fatal("file stats error:", err)
+			return rpcError(err, "check error")
 		}
 
-		// Format the results
-		w := new(tabwriter.Writer)
-		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
-
-		// Sort keys and count requests
-		var keys []string
-		var requests int64
-		for k, req := range reply.Files {
-			requests += req
-			keys = append(keys, k)
+		result := "up"
+		if !reply.CheckUp {
+			result = "down"
+			failed++
 		}
-		sort.Strings(keys)
+		status := "-"
+		if reply.StatusCode > 0 {
+			status = strconv.Itoa(int(reply.StatusCode))
+		}
+		state := "up"
+		if !reply.Up {
+			state = "down"
+			if reply.ExcludeReason != "" {
+				state += " (" + reply.ExcludeReason + ")"
+			}
+		}
+		fmt.Printf("%-30.30s %-4s %-3s %6dms %s => %s\n", reply.MirrorName, result, status, reply.LatencyMs, reply.File, state)
+		if reply.Error != "" {
+			fmt.Printf("    Error: %s\n", reply.Error)
+		}
+	}
 
-		for _, k := range keys {
-			fmt.Fprintf(w, "%s:\t%d\n", k, reply.Files[k])
+	if failed > 0 {
+		return newError(ExitFailure, "%d mirror(s) failed the health check", failed)
+	}
+	return nil
+}
+
+// dumpHeader is the first line of a database dump
+type dumpHeader struct {
+	Mirrorbits string
+	DBVersion  int
+	Created    time.Time
+}
+
+// dumpLine is a key of a database dump, one per line. The values are
+// encoded in base64 when one of them isn't valid UTF-8.
+type dumpLine struct {
+	Key    string
+	Type   string
+	Values []string
+	Base64 bool  `json:",omitempty"`
+	TTL    int64 `json:",omitempty"`
+}
+
+func (c *cli) CmdDbdump(args ...string) error {
+	cmd := SubCmd("dbdump", "", "Dump the database to the standard output, one JSON document per line.\n\nThe dump contains the mirrors, the files, the stats and the other keys of\nthe database and can be loaded with dbrestore.")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	stream, err := client.DumpDatabase(context.Background(), &empty.Empty{})
+	if err != nil {
+		return rpcError(err, "dump error")
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	enc.Encode(dumpHeader{
+		Mirrorbits: core.VERSION,
+		DBVersion:  core.DBVersion,
+		Created:    time.Now().UTC(),
+	})
+
+	keys := 0
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rpcError(err, "dump error")
 		}
 
-		if len(keys) > 0 {
-			// Add a line separator
-			fmt.Fprintf(w, "\t\n")
+		line := dumpLine{
+			Key:    record.Key,
+			Type:   record.Type,
+			Values: make([]string, len(record.Values)),
+			TTL:    record.TTL,
+		}
+		for _, v := range record.Values {
+			if !utf8.Valid(v) {
+				line.Base64 = true
+				break
+			}
+		}
+		for i, v := range record.Values {
+			if line.Base64 {
+				line.Values[i] = base64.StdEncoding.EncodeToString(v)
+			} else {
+				line.Values[i] = string(v)
+			}
 		}
+		if err = enc.Encode(line); err != nil {
+			return err
+		}
+		keys++
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d keys dumped\n", keys)
+	return nil
+}
 
-		fmt.Fprintf(w, "Total download requests: \t%d\n", requests)
-		w.Flush()
-	} else if cmd.Arg(0) == "mirror" {
-		// Mirror stats
+func (c *cli) CmdDbrestore(args ...string) error {
+	cmd := SubCmd("dbrestore", "[OPTIONS] FILE", "Restore a database dump made with dbdump.\n\nThe keys of the dump replace the existing ones. The running instances\nmust be restarted once the restore is complete to drop their caches.")
+	force := cmd.Bool("force", false, "Restore even if the database already contains mirrors or files")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		return ErrUsage
+	}
 
-		id, name := c.matchMirror(cmd.Arg(1))
+	f, err := os.Open(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-		reply, err := client.StatsMirror(ctx, &rpc.StatsMirrorRequest{
-			ID:        int32(id),
-			DateStart: startproto,
-			DateEnd:   endproto,
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var header dumpHeader
+	if err = dec.Decode(&header); err != nil {
+		return errors.Wrap(err, "invalid dump")
+	}
+	if header.DBVersion == 0 || header.Mirrorbits == "" {
+		return errors.New("invalid dump: missing header")
+	}
+	if header.DBVersion > core.DBVersion {
+		return fmt.Errorf("the dump uses the database format %d, unsupported by this version of mirrorbits", header.DBVersion)
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	// Cancelling the stream aborts the restoration, closing it would
+	// commit the records already sent
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.RestoreDatabase(ctx)
+	if err != nil {
+		return rpcError(err, "restore error")
+	}
+
+	first := true
+	for {
+		var line dumpLine
+		if err = dec.Decode(&line); err == io.EOF {
+			break
+		} else if err != nil {
+			cancel()
+			return errors.Wrap(err, "invalid dump")
+		}
+
+		record := &rpc.DumpRecord{
+			Key:    line.Key,
+			Type:   line.Type,
+			Values: make([][]byte, len(line.Values)),
+			TTL:    line.TTL,
+		}
+		for i, v := range line.Values {
+			if !line.Base64 {
+				record.Values[i] = []byte(v)
+			} else if record.Values[i], err = base64.StdEncoding.DecodeString(v); err != nil {
+				cancel()
+				return errors.Wrapf(err, "invalid value for key %s", line.Key)
+			}
+		}
+
+		err = stream.Send(&rpc.RestoreDatabaseRequest{
+			Record: record,
+			Force:  first && *force,
 		})
 		if err != nil {
-			log.Fatal("mirror stats error:", err)
+			// The actual error is returned by CloseAndRecv
+			break
 		}
+		first = false
+	}
 
-		// Format the results
-		w := new(tabwriter.Writer)
-		w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	reply, err := stream.CloseAndRecv()
+	if err != nil {
+		return rpcError(err, "restore error")
+	}
+	fmt.Printf("%d keys restored, restart the running instances to drop their caches\n", reply.Restored)
+	return nil
+}
 
-		fmt.Fprintf(w, "Identifier:\t%s\n", name)
-		if !reply.Mirror.Enabled {
-			fmt.Fprintf(w, "Status:\tdisabled\n")
-		} else if reply.Mirror.Up {
-			fmt.Fprintf(w, "Status:\tup\n")
-		} else {
-			fmt.Fprintf(w, "Status:\tdown\n")
-		}
-		fmt.Fprintf(w, "Download requests:\t%d\n", reply.Requests)
-		fmt.Fprint(w, "Bytes transferred:\t")
-		if *human {
-			fmt.Fprintln(w, utils.ReadableSize(reply.Bytes))
-		} else {
-			fmt.Fprintln(w, reply.Bytes)
-		}
-		w.Flush()
+// parseLogTime parses either a date or a duration relative to now
+func parseLogTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-1-2 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-1-2", value, time.Local)
+}
+
+func (c *cli) CmdReload(args ...string) error {
+	cmd := SubCmd("reload", "", "Reload configuration")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
+
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	defer cancel()
+	_, err = client.Reload(ctx, &empty.Empty{})
+	if err != nil {
+		return rpcError(err, "reload error")
 	}
 
 	return nil
 }
 
-func (c *cli) CmdLogs(args ...string) error {
-	cmd := SubCmd("logs", "[IDENTIFIER]", "Print logs of a mirror")
-	maxResults := cmd.Uint("l", 500, "Maximum number of logs to return")
+func (c *cli) CmdPromote(args ...string) error {
+	cmd := SubCmd("promote", "", "Promote a standby instance and start serving requests")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
-	if cmd.NArg() != 1 {
+	if cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	id, name := c.matchMirror(cmd.Arg(0))
+	return c.setStandby(false)
+}
+
+func (c *cli) CmdCluster(args ...string) error {
+	cmd := SubCmd("cluster", "", "Show the nodes of the cluster and the mirrors they handle")
+	mirrorsFlag := cmd.Bool("mirrors", false, "List the mirrors handled by each node")
+
+	if err := cmd.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if cmd.NArg() != 0 {
+		cmd.Usage()
+		return ErrUsage
+	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
-	resp, err := client.GetMirrorLogs(ctx, &rpc.GetMirrorLogsRequest{
-		ID:         int32(id),
-		MaxResults: int32(*maxResults),
-	})
+	reply, err := client.ClusterStatus(ctx, &empty.Empty{})
 	if err != nil {
-		log.Fatal("logs error:", err)
+		return rpcError(err, "cluster error")
 	}
 
-	if len(resp.Line) == 0 {
-		fmt.Printf("No logs for %s\n", name)
-		return nil
+	if reply.RedisReachable {
+		fmt.Println("Redis: reachable")
+	} else {
+		fmt.Println("Redis: unreachable")
+	}
+	if reply.Standby {
+		fmt.Println("Standby: the local node doesn't take part in the cluster")
 	}
 
-	fmt.Printf("Printing logs for %s:\n", name)
+	if len(reply.Nodes) == 0 {
+		fmt.Println("No node in the cluster")
+		return nil
+	}
 
-	for _, l := range resp.Line {
-		fmt.Println(l)
+	fmt.Println()
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
+	fmt.Fprint(w, "Node \tLast announce \tMirrors\n")
+	for _, n := range reply.Nodes {
+		lastAnnounce, err := ptypes.Timestamp(n.LastAnnounce)
+		if err != nil {
+			return rpcError(err, "cluster error")
+		}
+		id := n.ID
+		if n.Self {
+			id += " (local)"
+		}
+		if n.ID == reply.Leader {
+			id += " (leader)"
+		}
+		fmt.Fprintf(w, "%s \t%s \t%d", id, lastAnnounce.Local().Format(time.RFC1123), len(n.Mirrors))
+		if *mirrorsFlag && len(n.Mirrors) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(n.Mirrors, ", "))
+		}
+		fmt.Fprint(w, "\n")
 	}
+	w.Flush()
 
 	return nil
 }
 
-func (c *cli) CmdReload(args ...string) error {
-	cmd := SubCmd("reload", "", "Reload configuration")
+func (c *cli) CmdStandby(args ...string) error {
+	cmd := SubCmd("standby", "", "Switch the instance to standby, all requests will be answered with 503")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	client := c.GetRPC()
+	return c.setStandby(true)
+}
+
+func (c *cli) setStandby(standby bool) error {
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
-	_, err := client.Reload(ctx, &empty.Empty{})
+	_, err = client.SetStandby(ctx, &rpc.SetStandbyRequest{
+		Standby: standby,
+	})
 	if err != nil {
-		log.Fatal("reload error:", err)
+		return rpcError(err, "standby error")
 	}
 
 	return nil
@@ -1089,19 +2873,22 @@ func (c *cli) CmdUpgrade(args ...string) error {
 	cmd := SubCmd("upgrade", "", "Seamless binary upgrade")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
-	_, err := client.Upgrade(ctx, &empty.Empty{})
+	_, err = client.Upgrade(ctx, &empty.Empty{})
 	if err != nil {
-		log.Fatal("upgrade error:", err)
+		return rpcError(err, "upgrade error")
 	}
 
 	return nil
@@ -1111,18 +2898,21 @@ func (c *cli) CmdVersion(args ...string) error {
 	cmd := SubCmd("version", "", "Print version information")
 
 	if err := cmd.Parse(args); err != nil {
-		return nil
+		return usageError(err)
 	}
 	if cmd.NArg() != 0 {
 		cmd.Usage()
-		return nil
+		return ErrUsage
 	}
 
 	fmt.Printf("Client:\n")
 	core.PrintVersion(core.GetVersionInfo())
 	fmt.Println()
 
-	client := c.GetRPC()
+	client, err := c.GetRPC()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
 	defer cancel()
 	reply, err := client.GetVersion(ctx, &empty.Empty{})
@@ -0,0 +1,101 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes returned by the command line interface. They are part of the
+// public interface and can safely be relied upon by scripts.
+const (
+	// ExitOK is returned when the command succeeded
+	ExitOK = 0
+	// ExitFailure is returned for any failure not covered below
+	ExitFailure = 1
+	// ExitUsage is returned when the command line is invalid
+	ExitUsage = 2
+	// ExitUnavailable is returned when the server cannot be reached
+	ExitUnavailable = 3
+	// ExitUnauthorized is returned when the server refused the credentials
+	ExitUnauthorized = 4
+	// ExitNotFound is returned when no mirror matches the given identifier
+	ExitNotFound = 5
+	// ExitAmbiguous is returned when several mirrors match the given identifier
+	ExitAmbiguous = 6
+)
+
+var (
+	// ErrUsage is returned when a command is invoked with invalid arguments.
+	// The usage of the command has already been printed when it is returned.
+	ErrUsage = &Error{Code: ExitUsage, Err: errors.New("invalid usage")}
+)
+
+// Error is an error returned by a command along with the exit code
+// the process should terminate with
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code associated with the given error
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ExitFailure
+}
+
+// newError returns a formatted error carrying the given exit code
+func newError(code int, format string, args ...interface{}) error {
+	return &Error{
+		Code: code,
+		Err:  fmt.Errorf(format, args...),
+	}
+}
+
+// rpcError converts an error returned by the server into an Error whose
+// exit code reflects the gRPC status
+func rpcError(err error, msg string) error {
+	code := ExitFailure
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		code = ExitUnavailable
+	case codes.Unauthenticated, codes.PermissionDenied:
+		code = ExitUnauthorized
+	case codes.NotFound:
+		code = ExitNotFound
+	}
+	return &Error{
+		Code: code,
+		Err:  fmt.Errorf("%s: %s", msg, err),
+	}
+}
+
+// usageError returns the error to use when the flags of a command cannot
+// be parsed. Asking for help is not considered as an error.
+func usageError(err error) error {
+	if err == flag.ErrHelp {
+		return nil
+	}
+	return ErrUsage
+}
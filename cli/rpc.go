@@ -5,8 +5,6 @@ package cli
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/etix/mirrorbits/core"
@@ -14,45 +12,62 @@ import (
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 )
 
-func (c *cli) GetRPC() rpc.CLIClient {
+// GetRPC returns a client connected to the server
+func (c *cli) GetRPC() (rpc.CLIClient, error) {
 	c.Lock()
 	defer c.Unlock()
 
 	if c.rpcconn == nil {
+		security := grpc.WithInsecure()
+		if core.RPCTLSCert != "" || core.RPCTLSKey != "" || core.RPCTLSCA != "" {
+			tlsConfig, err := rpc.ClientTLSConfig(core.RPCTLSCert, core.RPCTLSKey, core.RPCTLSCA)
+			if err != nil {
+				return nil, newError(ExitFailure, "tls: %s", err)
+			}
+			security = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		}
 		conn, err := grpc.Dial(core.RPCHost+":"+strconv.FormatUint(uint64(core.RPCPort), 10),
-			grpc.WithInsecure(),
+			security,
 			grpc.WithBlock(),
 			grpc.FailOnNonTempDialError(true),
 			grpc.WithPerRPCCredentials(c.creds))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "rpc: %s\n", err)
-			os.Exit(1)
+			return nil, newError(ExitUnavailable, "rpc: %s", err)
 		}
-		c.rpcconn = conn
-		client := rpc.NewCLIClient(c.rpcconn)
+		client := rpc.NewCLIClient(conn)
 		_, err = client.Ping(context.Background(), &empty.Empty{})
 		s := status.Convert(err)
 		if s.Code() == codes.Unauthenticated {
+			conn.Close()
+			if len(c.creds.Token) > 0 {
+				return nil, newError(ExitUnauthorized, "Token refused")
+			}
 			if len(c.creds.Password) == 0 {
-				fmt.Fprintf(os.Stderr, "Please set the server password with the -P option.\n")
-			} else {
-				fmt.Fprintf(os.Stderr, "Password refused\n")
+				return nil, newError(ExitUnauthorized, "Please set the server password with the -P option or an access token with the -T option.")
 			}
-			os.Exit(1)
+			return nil, newError(ExitUnauthorized, "Password refused")
 		}
+		c.rpcconn = conn
 	}
 
-	return rpc.NewCLIClient(c.rpcconn)
+	return rpc.NewCLIClient(c.rpcconn), nil
 }
 
 type loginCreds struct {
 	Password string
+	Token    string
 }
 
 func (c *loginCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	if len(c.Token) > 0 {
+		return map[string]string{
+			"token": c.Token,
+		}, nil
+	}
 	return map[string]string{
 		"password": c.Password,
 	}, nil
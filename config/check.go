@@ -0,0 +1,168 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of the problems found in a configuration file
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// DefaultConfigFile is the configuration file used when none is given
+const DefaultConfigFile = "/etc/mirrorbits.conf"
+
+// Problem is an issue found in a configuration file
+type Problem struct {
+	Severity string
+	Line     int    `json:",omitempty"`
+	Key      string `json:",omitempty"`
+	Message  string
+}
+
+func (p Problem) String() string {
+	var location []string
+	if p.Line > 0 {
+		location = append(location, fmt.Sprintf("line %d", p.Line))
+	}
+	if p.Key != "" {
+		location = append(location, p.Key)
+	}
+	if len(location) == 0 {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, strings.Join(location, ": "), p.Message)
+}
+
+var (
+	yamlLineRegexp         = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	yamlUnknownFieldRegexp = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// CheckConfig parses and validates the given configuration file without
+// loading it. Unlike ReloadConfig, it reports the keys unknown to this
+// version and the local paths that are missing. The configuration is
+// only returned if it can be loaded, that is if no error was found.
+func CheckConfig(filename string) (*Configuration, []Problem) {
+	var problems []Problem
+
+	if filename == "" {
+		filename = DefaultConfigFile
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, []Problem{{Severity: SeverityError, Message: err.Error()}}
+	}
+
+	c := defaultConfig()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&c)
+	switch e := err.(type) {
+	case nil:
+	case *yaml.TypeError:
+		// The decoding went on, the configuration can still be validated
+		for _, msg := range e.Errors {
+			problems = append(problems, yamlProblem(msg))
+		}
+	default:
+		if err != io.EOF {
+			return nil, append(problems, yamlProblem(err.Error()))
+		}
+	}
+
+	if err = c.applyEnv(os.LookupEnv); err != nil {
+		return nil, append(problems, Problem{
+			Severity: SeverityError,
+			Message:  strings.TrimPrefix(err.Error(), "Config: "),
+		})
+	}
+
+	if err = c.sanitize(); err != nil {
+		problems = append(problems, Problem{
+			Severity: SeverityError,
+			Message:  strings.TrimPrefix(err.Error(), "Config: "),
+		})
+	}
+	for _, w := range c.CheckFallbacks() {
+		problems = append(problems, Problem{
+			Severity: SeverityWarning,
+			Key:      "Fallbacks",
+			Message:  w,
+		})
+	}
+	problems = append(problems, c.checkPaths()...)
+
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return nil, problems
+		}
+	}
+	return &c, problems
+}
+
+// yamlProblem converts an error of the yaml decoder into a problem. The
+// unknown keys are only warnings since they are ignored when the
+// configuration is loaded.
+func yamlProblem(msg string) Problem {
+	p := Problem{
+		Severity: SeverityError,
+		Message:  msg,
+	}
+	if m := yamlLineRegexp.FindStringSubmatch(msg); m != nil {
+		p.Line, _ = strconv.Atoi(m[1])
+		p.Message = m[2]
+	}
+	if m := yamlUnknownFieldRegexp.FindStringSubmatch(p.Message); m != nil {
+		p.Severity = SeverityWarning
+		p.Key = m[1]
+		p.Message = "unknown key"
+	}
+	return p
+}
+
+// checkPaths verifies that the local files and directories referenced by
+// the configuration exist
+func (c *Configuration) checkPaths() (problems []Problem) {
+	check := func(key, path string, dir bool) {
+		if path == "" {
+			return
+		}
+		fi, err := os.Stat(path)
+		switch {
+		case err != nil:
+			problems = append(problems, Problem{Severity: SeverityError, Key: key, Message: err.Error()})
+		case dir && !fi.IsDir():
+			problems = append(problems, Problem{Severity: SeverityError, Key: key, Message: fmt.Sprintf("%s is not a directory", path)})
+		case !dir && fi.IsDir():
+			problems = append(problems, Problem{Severity: SeverityError, Key: key, Message: fmt.Sprintf("%s is a directory", path)})
+		}
+	}
+
+	check("Repository", c.Repository, true)
+	check("Templates", c.Templates, true)
+	check("LogDir", c.LogDir, true)
+	check("GeoipDatabasePath", c.GeoipDatabasePath, true)
+	if c.Snapshot.Path != "" {
+		check("Snapshot.Path", filepath.Dir(c.Snapshot.Path), true)
+	}
+	check("RPCTLS.CertFile", c.RPCTLS.CertFile, false)
+	check("RPCTLS.KeyFile", c.RPCTLS.KeyFile, false)
+	check("RPCTLS.ClientCAFile", c.RPCTLS.ClientCAFile, false)
+	return
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(content string) string {
+		filename := filepath.Join(dir, "mirrorbits.conf")
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	// Unknown keys are only warnings
+	c, problems := CheckConfig(write("Repository: " + dir + "\nGeoipDatabasePath: " + dir + "\nFoo: 1\nSnapshot:\n    Bar: 2\n"))
+	if c == nil || c.Repository != dir {
+		t.Fatalf("Expected a valid configuration, got %+v", problems)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %+v", problems)
+	}
+	if p := problems[0]; p.Severity != SeverityWarning || p.Line != 3 || p.Key != "Foo" {
+		t.Fatalf("Unexpected problem: %+v", p)
+	}
+	if p := problems[1]; p.Severity != SeverityWarning || p.Line != 5 || p.Key != "Bar" {
+		t.Fatalf("Unexpected problem: %+v", p)
+	}
+
+	// Invalid types, invalid values and missing paths are errors
+	c, problems = CheckConfig(write("Repository: " + dir + "/missing\nGeoipDatabasePath: " + dir + "\nScanInterval: abc\nOutputMode: none\n"))
+	if c != nil {
+		t.Fatalf("Expected an invalid configuration")
+	}
+	if len(problems) != 3 {
+		t.Fatalf("Expected 3 problems, got %+v", problems)
+	}
+	if p := problems[0]; p.Severity != SeverityError || p.Line != 3 {
+		t.Fatalf("Unexpected problem: %+v", p)
+	}
+	if p := problems[1]; p.Severity != SeverityError || p.Message != "outputMode can only be set to 'auto', 'json' or 'redirect'" {
+		t.Fatalf("Unexpected problem: %+v", p)
+	}
+	if p := problems[2]; p.Severity != SeverityError || p.Key != "Repository" {
+		t.Fatalf("Unexpected problem: %+v", p)
+	}
+
+	// Syntax errors
+	c, problems = CheckConfig(write("Repository: [\n"))
+	if c != nil || len(problems) != 1 || problems[0].Severity != SeverityError || problems[0].Line == 0 {
+		t.Fatalf("Expected a syntax error, got %+v", problems)
+	}
+}
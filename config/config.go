@@ -4,11 +4,19 @@
 package config
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/textproto"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/etix/mirrorbits/core"
 	"github.com/op/go-logging"
@@ -35,6 +43,7 @@ func defaultConfig() Configuration {
 		Templates:              TEMPLATES_PATH,
 		LocalJSPath:            "",
 		OutputMode:             "auto",
+		DirectoryIndex:         "none",
 		ListenAddress:          ":8080",
 		Gzip:                   false,
 		SameDownloadInterval:   600,
@@ -46,6 +55,9 @@ func defaultConfig() Configuration {
 		GeoipDatabasePath:      "/usr/share/GeoIP/",
 		ConcurrentSync:         5,
 		ScanInterval:           30,
+		FtpScanTimeout:         60,
+		IncrementalScans:       false,
+		RescanOnRecovery:       true,
 		CheckInterval:          1,
 		RepositoryScanInterval: 5,
 		MaxLinkHeaders:         10,
@@ -54,63 +66,407 @@ func defaultConfig() Configuration {
 			SHA1:   false,
 			SHA256: true,
 			MD5:    false,
+			SHA512: false,
+			BLAKE2: false,
 		},
+		PackFileInfo:            false,
 		DisallowRedirects:       false,
 		WeightDistributionRange: 1.5,
 		DisableOnMissingFile:    false,
+		RemovedMirrorRetention:  7,
 		RPCListenAddress:        "localhost:3390",
 		RPCPassword:             "",
+		VirtualChecksums: virtualChecksums{
+			MD5:    ".md5",
+			SHA1:   ".sha1",
+			SHA256: ".sha256",
+			SHA512: ".sha512",
+			BLAKE2: ".blake2b",
+		},
+		StatsEnabled: true,
+		MetricsExport: metricsExport{
+			Interval: 60,
+		},
+		Telemetry: telemetry{
+			Interval: 24,
+		},
+		Snapshot: snapshot{
+			Interval: 60,
+		},
+		Capacity: capacity{
+			TopFiles: 50,
+			Interval: 60,
+		},
+		Probes: probes{
+			Liveness:  "/healthz",
+			Readiness: "/readyz",
+		},
+		AccessLog: accessLog{
+			SampleRate: 1,
+		},
+		SelectionHook: selectionHook{
+			Timeout: 200,
+		},
+		HealthCheckScheduling: healthCheckScheduling{
+			Adaptive:          false,
+			MaxInterval:       5,
+			RetryInterval:     10,
+			FlappingChanges:   0,
+			FlappingWindow:    30,
+			RecoverySuccesses: 3,
+		},
+		IntegrityCheck: integrityCheck{
+			Samples:     0,
+			MaxFileSize: 10 * 1024 * 1024,
+		},
+		ConsensusFallback: consensusFallback{
+			Enabled:    false,
+			MinMirrors: 2,
+		},
+		MaxPathLength: 1024,
+		MaxPathDepth:  64,
+		Latency: latency{
+			Continent:     "",
+			SlowThreshold: 0,
+			SlowPenalty:   50,
+		},
+		BigFiles: bigFiles{
+			MinSize: 0,
+			Tag:     "highbandwidth",
+			Bonus:   100,
+		},
+		ListenOptions: listenOptions{
+			Backlog:   0,
+			FastOpen:  0,
+			ReusePort: false,
+			Acceptors: 1,
+		},
+		NotFoundRescan: notFoundRescan{
+			Threshold:   0,
+			Window:      60,
+			PrefixDepth: 1,
+			Reports:     false,
+		},
+		RateLimit: rateLimit{
+			Rate:       0,
+			Burst:      20,
+			IPv4Prefix: 32,
+			IPv6Prefix: 64,
+			Downloads:  false,
+		},
 	}
 }
 
 // Configuration contains all the option available in the yaml file
 type Configuration struct {
-	Repository              string     `yaml:"Repository"`
-	Templates               string     `yaml:"Templates"`
-	LocalJSPath             string     `yaml:"LocalJSPath"`
-	OutputMode              string     `yaml:"OutputMode"`
-	ListenAddress           string     `yaml:"ListenAddress"`
-	Gzip                    bool       `yaml:"Gzip"`
-	SameDownloadInterval    int        `yaml:"SameDownloadInterval"`
-	RedisAddress            string     `yaml:"RedisAddress"`
-	RedisPassword           string     `yaml:"RedisPassword"`
-	RedisDB                 int        `yaml:"RedisDB"`
-	LogDir                  string     `yaml:"LogDir"`
-	TraceFileLocation       string     `yaml:"TraceFileLocation"`
-	GeoipDatabasePath       string     `yaml:"GeoipDatabasePath"`
-	ConcurrentSync          int        `yaml:"ConcurrentSync"`
-	ScanInterval            int        `yaml:"ScanInterval"`
-	CheckInterval           int        `yaml:"CheckInterval"`
-	RepositoryScanInterval  int        `yaml:"RepositoryScanInterval"`
-	MaxLinkHeaders          int        `yaml:"MaxLinkHeaders"`
-	FixTimezoneOffsets      bool       `yaml:"FixTimezoneOffsets"`
-	Hashes                  hashing    `yaml:"Hashes"`
-	DisallowRedirects       bool       `yaml:"DisallowRedirects"`
-	WeightDistributionRange float32    `yaml:"WeightDistributionRange"`
-	DisableOnMissingFile    bool       `yaml:"DisableOnMissingFile"`
-	Fallbacks               []fallback `yaml:"Fallbacks"`
-
-	RedisSentinelMasterName string      `yaml:"RedisSentinelMasterName"`
-	RedisSentinels          []sentinels `yaml:"RedisSentinels"`
-
-	RPCListenAddress string `yaml:"RPCListenAddress"`
-	RPCPassword      string `yaml:"RPCPassword"`
+	Repository              string     `yaml:"Repository" doc:"Path to the local repository"`
+	Templates               string     `yaml:"Templates" doc:"Path to the templates"`
+	LocalJSPath             string     `yaml:"LocalJSPath" doc:"Local path or URL to the javascript files used by the templates"`
+	OutputMode              string     `yaml:"OutputMode" doc:"Output mode of the downloads: auto, json or redirect"`
+	DirectoryIndex          string     `yaml:"DirectoryIndex" doc:"Answer to the requests of directories: none (404), list or redirect to a mirror"`
+	ListenAddress           string     `yaml:"ListenAddress" doc:"Address the HTTP server listens on"`
+	ListenAddresses         []string   `yaml:"ListenAddresses" doc:"Addresses the HTTP server listens on, overriding ListenAddress"`
+	Gzip                    bool       `yaml:"Gzip" doc:"Compress the responses"`
+	SameDownloadInterval    int        `yaml:"SameDownloadInterval" doc:"Interval in seconds during which the downloads of a file by the same client are counted once"`
+	RedisAddress            string     `yaml:"RedisAddress" doc:"Address of the redis database" reload:"restart"`
+	RedisPassword           string     `yaml:"RedisPassword" doc:"Password of the redis database" reload:"restart" secret:"true"`
+	RedisPasswordFile       string     `yaml:"RedisPasswordFile" doc:"File containing the password of the redis database, instead of RedisPassword" reload:"restart"`
+	RedisDB                 int        `yaml:"RedisDB" doc:"Index of the redis database" reload:"restart"`
+	LogDir                  string     `yaml:"LogDir" doc:"Directory of the logs, stdout if empty"`
+	TraceFileLocation       string     `yaml:"TraceFileLocation" doc:"Path of the trace file relative to the root of the mirrors"`
+	GeoipDatabasePath       string     `yaml:"GeoipDatabasePath" doc:"Directory of the GeoIP2 databases"`
+	ConcurrentSync          int        `yaml:"ConcurrentSync" doc:"Number of mirrors scanned concurrently" reload:"restart"`
+	ScanInterval            int        `yaml:"ScanInterval" doc:"Interval in minutes between the scans of a mirror"`
+	FtpScanTimeout          int        `yaml:"FtpScanTimeout" doc:"Maximum duration in minutes of the scan of a mirror over ftp, 0 to disable"`
+	IncrementalScans        bool       `yaml:"IncrementalScans" doc:"Skip the scans of the mirrors whose trace file didn't change and only rescan their changed subdirectories"`
+	RescanOnRecovery        bool       `yaml:"RescanOnRecovery" doc:"Rescan a mirror coming back up after being down for longer than its scan interval"`
+	CheckInterval           int        `yaml:"CheckInterval" doc:"Interval in minutes between the health checks of a mirror"`
+	RepositoryScanInterval  int        `yaml:"RepositoryScanInterval" doc:"Interval in minutes between the scans of the local repository, 0 to disable"`
+	MaxLinkHeaders          int        `yaml:"MaxLinkHeaders" doc:"Maximum number of Link headers listing the alternative mirrors"`
+	FixTimezoneOffsets      bool       `yaml:"FixTimezoneOffsets" doc:"Detect and fix the timezone offsets of the mirrors"`
+	Hashes                  hashing    `yaml:"Hashes" doc:"Hashes computed for the files of the local repository"`
+	PackFileInfo            bool       `yaml:"PackFileInfo" doc:"Store the details of the files in a packed binary encoding"`
+	DisallowRedirects       bool       `yaml:"DisallowRedirects" doc:"Disallow the mirrors to redirect the health checks"`
+	WeightDistributionRange float32    `yaml:"WeightDistributionRange" doc:"Spread of the mirrors selected for a request"`
+	DisableOnMissingFile    bool       `yaml:"DisableOnMissingFile" doc:"Disable a mirror when a file is missing (HTTP 404)"`
+	RemovedMirrorRetention  int        `yaml:"RemovedMirrorRetention" doc:"Days during which a removed mirror can be restored, 0 to delete the mirrors immediately"`
+	MaxPathLength           int        `yaml:"MaxPathLength" doc:"Maximum length of the requested paths, 0 to disable"`
+	MaxPathDepth            int        `yaml:"MaxPathDepth" doc:"Maximum depth of the requested paths, 0 to disable"`
+	ExcludePaths            []string   `yaml:"ExcludePaths" doc:"Glob patterns of the paths never indexed nor served"`
+	IncludePaths            []string   `yaml:"IncludePaths" doc:"Glob patterns of the only paths indexed and served, all if empty"`
+	FollowSymlinks          bool       `yaml:"FollowSymlinks" doc:"Follow the symbolic links of the local repository pointing outside of it"`
+	Fallbacks               []fallback `yaml:"Fallbacks" doc:"Mirrors used when no other mirror is available"`
+
+	VirtualChecksums virtualChecksums `yaml:"VirtualChecksums" doc:"Suffixes serving the checksums of the files"`
+	Embargoes        []embargo        `yaml:"Embargoes" doc:"Prefixes hidden until their release time"`
+	IntegrityCheck   integrityCheck   `yaml:"IntegrityCheck" doc:"Verification of the content of random files after a scan"`
+
+	ConsensusFallback consensusFallback `yaml:"ConsensusFallback" doc:"Serve the files missing locally when enough mirrors agree on them"`
+
+	ScanRemovalThreshold  int                   `yaml:"ScanRemovalThreshold" doc:"Percentage of files a scan may remove before the changes are held, 0 to disable"`
+	HealthCheckScheduling healthCheckScheduling `yaml:"HealthCheckScheduling" doc:"Adaptive scheduling of the health checks"`
+	HealthCheckQuorum     int                   `yaml:"HealthCheckQuorum" doc:"Number of nodes that must agree a mirror is down, 0 to disable"`
+	Latency               latency               `yaml:"Latency" doc:"Latency measured by the health checks"`
+	BigFiles              bigFiles              `yaml:"BigFiles" doc:"Preference for the high-bandwidth mirrors when serving big files"`
+	ListenOptions         listenOptions         `yaml:"ListenOptions" doc:"Options of the listening sockets" reload:"restart"`
+
+	RedisSentinelMasterName string      `yaml:"RedisSentinelMasterName" doc:"Name of the redis master monitored by the sentinels" reload:"restart"`
+	RedisSentinels          []sentinels `yaml:"RedisSentinels" doc:"Addresses of the redis sentinels" reload:"restart"`
+	Snapshot                snapshot    `yaml:"Snapshot" doc:"Local copy of the database served when it is unreachable at start"`
+	Replica                 replica     `yaml:"Replica" doc:"Read-only mode serving the redirects from a redis replica" reload:"restart"`
+
+	RPCListenAddress string     `yaml:"RPCListenAddress" doc:"Address the RPC server listens on" reload:"restart"`
+	RPCPassword      string     `yaml:"RPCPassword" doc:"Password granting the admin role to the RPC clients" secret:"true"`
+	RPCPasswordFile  string     `yaml:"RPCPasswordFile" doc:"File containing the password granting the admin role, instead of RPCPassword"`
+	RPCTokens        []RPCToken `yaml:"RPCTokens" doc:"Tokens granting a role to the RPC clients"`
+	RPCTLS           rpcTLS     `yaml:"RPCTLS" doc:"TLS settings of the RPC server" reload:"restart"`
+
+	StatsEnabled          bool           `yaml:"StatsEnabled" doc:"Record the download statistics"`
+	StatsExcludedPrefixes []string       `yaml:"StatsExcludedPrefixes" doc:"Prefixes excluded from the statistics"`
+	StatsRetention        statsRetention `yaml:"StatsRetention" doc:"Retention in days of the statistics, 0 to keep them forever"`
+	StatsFileShards       int            `yaml:"StatsFileShards" doc:"Number of hashes the per-file statistics are spread over"`
+	StatsExcludedAgents   []string       `yaml:"StatsExcludedAgents" doc:"User agents excluded from the statistics"`
+	StatsExcludedNetworks []string       `yaml:"StatsExcludedNetworks" doc:"Networks excluded from the statistics"`
+	MetricsExport         metricsExport  `yaml:"MetricsExport" doc:"Export of the metrics" reload:"restart"`
+	Telemetry             telemetry      `yaml:"Telemetry" doc:"Periodic report of anonymous usage aggregates"`
+
+	Standby bool `yaml:"Standby" doc:"Start the instance in standby"`
+
+	TrustedProxies      []string          `yaml:"TrustedProxies" doc:"Networks of the proxies allowed to give the client address"`
+	TrustedCDNs         []string          `yaml:"TrustedCDNs" doc:"Networks of the CDNs allowed to give the client address with the CF-Connecting-IP or True-Client-IP headers"`
+	StickySelection     bool              `yaml:"StickySelection" doc:"Redirect a client to the same mirror for the same file"`
+	SelectionCache      int               `yaml:"SelectionCache" doc:"Seconds during which the ranking of the mirrors of a file is reused for nearby clients, 0 to disable"`
+	ServeStale          int               `yaml:"ServeStale" doc:"Seconds during which the cached mirrors and files are served when the database fails after a reconnection, 0 to disable"`
+	SelectionHook       selectionHook     `yaml:"SelectionHook" doc:"Lua script reviewing the mirrors of each request"`
+	LocationOverride    locationOverride  `yaml:"LocationOverride" doc:"Clients allowed to override their location"`
+	MirrorDetailsAccess AccessControl     `yaml:"MirrorDetailsAccess" doc:"Access control of the mirror details page, disabled if not restricted"`
+	MirrorStatsAccess   AccessControl     `yaml:"MirrorStatsAccess" doc:"Access control of the mirror stats page"`
+	Capacity            capacity          `yaml:"Capacity" doc:"Coverage of the countries and continents by the mirrors, shown in the mirror stats page"`
+	Probes              probes            `yaml:"Probes" doc:"Liveness and readiness probes of the HTTP server"`
+	AccessLog           accessLog         `yaml:"AccessLog" doc:"Log of all the requests (access.log in LogDir), with their latency"`
+	RateLimit           rateLimit         `yaml:"RateLimit" doc:"Rate limiting of the requests per client"`
+	ResponseHeaders     []responseHeaders `yaml:"ResponseHeaders" doc:"Extra headers of the responses per path prefix"`
+
+	CountryPreferences []countryPreference `yaml:"CountryPreferences" doc:"Protocol or mirrors preferred for the clients of some countries"`
+
+	TemplateVars map[string]string `yaml:"TemplateVars" doc:"Variables available to the templates"`
+
+	ClientHints        bool               `yaml:"ClientHints" doc:"Log and count the protocols negotiated by the clients"`
+	ClientHintsHeaders clientHintsHeaders `yaml:"ClientHintsHeaders" doc:"Headers set by the trusted proxies with the protocols negotiated by the clients"`
+
+	NotFoundRescan notFoundRescan `yaml:"NotFoundRescan" doc:"Rescan of the mirrors missing files"`
+
+	statsExcludedNets []*net.IPNet
+	trustedProxies    []*net.IPNet
+	trustedCDNs       []*net.IPNet
 }
 
 type fallback struct {
-	URL           string `yaml:"URL"`
-	CountryCode   string `yaml:"CountryCode"`
-	ContinentCode string `yaml:"ContinentCode"`
+	URL             string `yaml:"URL" doc:"URL of the mirror"`
+	CountryCode     string `yaml:"CountryCode" doc:"Country of the mirror"`
+	ContinentCode   string `yaml:"ContinentCode" doc:"Continent of the mirror"`
+	Weight          int    `yaml:"Weight" doc:"Share of the clients sent to the mirror among the equally close fallbacks, 1 by default"`
+	HealthCheckPath string `yaml:"HealthCheckPath" doc:"Path checked relative to the URL, the URL itself if empty"`
+}
+
+type statsRetention struct {
+	Daily   int `yaml:"Daily" doc:"Days the daily statistics are kept"`
+	Monthly int `yaml:"Monthly" doc:"Days the monthly statistics are kept"`
+	Yearly  int `yaml:"Yearly" doc:"Days the yearly statistics are kept"`
+}
+
+// AccessControl restricts the access to an endpoint to the clients from
+// the given networks, to the holders of one of the bearer tokens or to the
+// given basic auth credentials. The endpoint is public if none is set.
+type AccessControl struct {
+	Networks []string `yaml:"Networks" doc:"Networks allowed"`
+	Tokens   []string `yaml:"Tokens" doc:"Bearer tokens allowed" secret:"true"`
+	Username string   `yaml:"Username" doc:"Basic auth user name"`
+	Password string   `yaml:"Password" doc:"Basic auth password" secret:"true"`
+
+	networks []*net.IPNet
+}
+
+// Roles that can be granted to the RPC clients, each role including the
+// permissions of the previous ones
+const (
+	RoleReadOnly = "read-only"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// RPCToken grants a role to the RPC clients presenting the token
+type RPCToken struct {
+	Name  string `yaml:"Name" doc:"Name of the token"`
+	Token string `yaml:"Token" doc:"Secret of the token" secret:"true"`
+	Role  string `yaml:"Role" doc:"Role granted: read-only, operator or admin"`
+}
+
+type rpcTLS struct {
+	CertFile     string `yaml:"CertFile" doc:"Certificate of the server"`
+	KeyFile      string `yaml:"KeyFile" doc:"Private key of the server"`
+	ClientCAFile string `yaml:"ClientCAFile" doc:"CA verifying the client certificates"`
+}
+
+type locationOverride struct {
+	Tokens   []string `yaml:"Tokens" doc:"Tokens allowed to override the location" secret:"true"`
+	Networks []string `yaml:"Networks" doc:"Networks allowed to override the location"`
+
+	networks []*net.IPNet
+}
+
+type metricsExport struct {
+	Type     string `yaml:"Type" doc:"Type of exporter: influxdb, graphite, statsd or prometheus"`
+	Address  string `yaml:"Address" doc:"Address of the metrics server"`
+	Database string `yaml:"Database" doc:"Database of the influxdb server"`
+	Prefix   string `yaml:"Prefix" doc:"Prefix of the metric names"`
+	Interval int    `yaml:"Interval" doc:"Interval in seconds between two exports"`
+
+	Access AccessControl `yaml:"Access" doc:"Access control of the prometheus endpoint"`
 }
 
 type sentinels struct {
-	Host string `yaml:"Host"`
+	Host string `yaml:"Host" doc:"Address of the sentinel"`
+}
+
+type healthCheckScheduling struct {
+	Adaptive          bool `yaml:"Adaptive" doc:"Adapt the interval between the checks to the health of the mirror"`
+	MaxInterval       int  `yaml:"MaxInterval" doc:"Maximum interval in minutes between the checks of a healthy mirror"`
+	RetryInterval     int  `yaml:"RetryInterval" doc:"Interval in seconds before checking a failing mirror again"`
+	FlappingChanges   int  `yaml:"FlappingChanges" doc:"State changes within FlappingWindow holding a mirror down, 0 to disable"`
+	FlappingWindow    int  `yaml:"FlappingWindow" doc:"Window in minutes of the flapping detection"`
+	RecoverySuccesses int  `yaml:"RecoverySuccesses" doc:"Successful checks bringing a flapping mirror back"`
+}
+
+type latency struct {
+	Continent     string `yaml:"Continent" doc:"Continent the latency is measured from"`
+	SlowThreshold int    `yaml:"SlowThreshold" doc:"Latency in milliseconds above which a mirror is penalized, 0 to disable"`
+	SlowPenalty   int    `yaml:"SlowPenalty" doc:"Percentage of the score removed from the slow mirrors"`
+}
+
+type bigFiles struct {
+	MinSize int64  `yaml:"MinSize" doc:"Size in bytes from which a file is big, 0 to disable"`
+	Tag     string `yaml:"Tag" doc:"Tag of the high-bandwidth mirrors"`
+	Bonus   int    `yaml:"Bonus" doc:"Percentage of the score added to the high-bandwidth mirrors for the big files"`
+}
+
+type clientHintsHeaders struct {
+	Proto string `yaml:"Proto" doc:"Header holding the HTTP protocol, the protocol used by the proxy if empty"`
+	TLS   string `yaml:"TLS" doc:"Header holding the TLS version"`
+	ALPN  string `yaml:"ALPN" doc:"Header holding the ALPN protocol"`
+}
+
+type selectionHook struct {
+	Script  string `yaml:"Script" doc:"Lua script reviewing the candidates and the selection, disabled if empty"`
+	Timeout int    `yaml:"Timeout" doc:"Milliseconds the script may run per request before the mirrors are kept as is"`
+}
+
+type responseHeaders struct {
+	Prefix  string            `yaml:"Prefix" doc:"Prefix of the requested paths"`
+	Headers map[string]string `yaml:"Headers" doc:"Headers to set, removed if the value is empty"`
+}
+
+type snapshot struct {
+	Path     string `yaml:"Path" doc:"File the snapshot is written to, disabled if empty"`
+	Interval int    `yaml:"Interval" doc:"Minutes between two snapshots"`
+}
+
+type capacity struct {
+	TopFiles int `yaml:"TopFiles" doc:"Number of the most downloaded files of the month whose coverage is computed, disabled if 0"`
+	Interval int `yaml:"Interval" doc:"Minutes between two computations"`
+}
+
+type probes struct {
+	Liveness  string `yaml:"Liveness" doc:"Path of the liveness probe, disabled if empty"`
+	Readiness string `yaml:"Readiness" doc:"Path of the readiness probe, disabled if empty"`
+}
+
+type accessLog struct {
+	Enabled    bool    `yaml:"Enabled" doc:"Write the requests to the access log, including the pages, the APIs and the errors"`
+	SampleRate float64 `yaml:"SampleRate" doc:"Fraction of the successful requests written to the access log (0 to 1), the errors being always written"`
+}
+
+type replica struct {
+	Enabled       bool   `yaml:"Enabled" doc:"Connect to the redis replica at RedisAddress, without running the monitor nor writing to the database"`
+	MasterAddress string `yaml:"MasterAddress" doc:"Address of the redis master the stats are forwarded to, the master of the replica if empty"`
+}
+
+type telemetry struct {
+	URL      string `yaml:"URL" doc:"Endpoint receiving the reports, disabled if empty"`
+	Interval int    `yaml:"Interval" doc:"Hours between two reports"`
+}
+
+type rateLimit struct {
+	Rate       float64  `yaml:"Rate" doc:"Requests per second allowed per client, 0 to disable"`
+	Burst      int      `yaml:"Burst" doc:"Requests a client can make at once before being limited"`
+	IPv4Prefix int      `yaml:"IPv4Prefix" doc:"Length of the prefix grouping the IPv4 clients"`
+	IPv6Prefix int      `yaml:"IPv6Prefix" doc:"Length of the prefix grouping the IPv6 clients"`
+	Downloads  bool     `yaml:"Downloads" doc:"Limit the downloads too, not only the pages and the APIs"`
+	Allowed    []string `yaml:"Allowed" doc:"Networks never limited"`
+
+	allowed []*net.IPNet
+}
+
+type listenOptions struct {
+	Backlog     int    `yaml:"Backlog" doc:"Size of the accept queue, 0 for the system default"`
+	FastOpen    int    `yaml:"FastOpen" doc:"Size of the TCP Fast Open queue, 0 to disable"`
+	ReusePort   bool   `yaml:"ReusePort" doc:"Set SO_REUSEPORT on the sockets"`
+	Acceptors   int    `yaml:"Acceptors" doc:"Number of sockets per address, requires ReusePort"`
+	SocketMode  string `yaml:"SocketMode" doc:"Permissions of the unix sockets in octal, such as 0660"`
+	SocketUser  string `yaml:"SocketUser" doc:"Owner of the unix sockets"`
+	SocketGroup string `yaml:"SocketGroup" doc:"Group of the unix sockets"`
+}
+
+// SocketFileMode returns the permissions of the unix sockets, zero if
+// they are left as created
+func (o listenOptions) SocketFileMode() os.FileMode {
+	mode, _ := strconv.ParseUint(o.SocketMode, 8, 32)
+	return os.FileMode(mode)
+}
+
+type integrityCheck struct {
+	Samples     int   `yaml:"Samples" doc:"Number of files verified after a scan, 0 to disable"`
+	MaxFileSize int64 `yaml:"MaxFileSize" doc:"Maximum size in bytes of the verified files"`
+}
+
+type consensusFallback struct {
+	Enabled    bool `yaml:"Enabled" doc:"Enable the consensus fallback"`
+	MinMirrors int  `yaml:"MinMirrors" doc:"Number of mirrors that must agree on a file"`
+}
+
+type notFoundRescan struct {
+	Threshold   int  `yaml:"Threshold" doc:"404 under a prefix triggering a rescan, 0 to disable"`
+	Window      int  `yaml:"Window" doc:"Window in minutes of the 404 count"`
+	PrefixDepth int  `yaml:"PrefixDepth" doc:"Number of directories forming a prefix"`
+	Reports     bool `yaml:"Reports" doc:"Accept the 404 reported by the clients"`
+}
+
+type countryPreference struct {
+	Countries []string `yaml:"Countries" doc:"Country codes of the clients"`
+	Protocol  string   `yaml:"Protocol" doc:"Protocol preferred (http or https)"`
+	Mirrors   []string `yaml:"Mirrors" doc:"Names of the mirrors preferred"`
+}
+
+type embargo struct {
+	Prefix  string    `yaml:"Prefix" doc:"Prefix under embargo"`
+	Release time.Time `yaml:"Release" doc:"Release time of the prefix"`
+}
+
+type virtualChecksums struct {
+	MD5        string `yaml:"MD5" doc:"Suffix serving the MD5"`
+	SHA1       string `yaml:"SHA1" doc:"Suffix serving the SHA1"`
+	SHA256     string `yaml:"SHA256" doc:"Suffix serving the SHA256"`
+	SHA512     string `yaml:"SHA512" doc:"Suffix serving the SHA512"`
+	BLAKE2     string `yaml:"BLAKE2" doc:"Suffix serving the BLAKE2b"`
+	SHA256SUMS string `yaml:"SHA256SUMS" doc:"File name listing the SHA256 of a directory"`
 }
 
 type hashing struct {
-	SHA1   bool `yaml:"SHA1"`
-	SHA256 bool `yaml:"SHA256"`
-	MD5    bool `yaml:"MD5"`
+	SHA1   bool `yaml:"SHA1" doc:"Compute the SHA1"`
+	SHA256 bool `yaml:"SHA256" doc:"Compute the SHA256"`
+	MD5    bool `yaml:"MD5" doc:"Compute the MD5"`
+	SHA512 bool `yaml:"SHA512" doc:"Compute the SHA512"`
+	BLAKE2 bool `yaml:"BLAKE2" doc:"Compute the BLAKE2b"`
 }
 
 // LoadConfig loads the configuration file if it has not yet been loaded
@@ -127,8 +483,8 @@ func LoadConfig() {
 // ReloadConfig reloads the configuration file and update it globally
 func ReloadConfig() error {
 	if core.ConfigFile == "" {
-		if fileExists("/etc/mirrorbits.conf") {
-			core.ConfigFile = "/etc/mirrorbits.conf"
+		if fileExists(DefaultConfigFile) {
+			core.ConfigFile = DefaultConfigFile
 		}
 	}
 
@@ -150,22 +506,18 @@ func ReloadConfig() error {
 		return fmt.Errorf("%s in %s", err, core.ConfigFile)
 	}
 
-	// Sanitize
-	if c.WeightDistributionRange <= 0 {
-		return fmt.Errorf("WeightDistributionRange must be > 0")
-	}
-	if !isInSlice(c.OutputMode, []string{"auto", "json", "redirect"}) {
-		return fmt.Errorf("Config: outputMode can only be set to 'auto', 'json' or 'redirect'")
+	if err = c.applyEnv(os.LookupEnv); err != nil {
+		return err
 	}
-	if c.Repository == "" {
-		return fmt.Errorf("Path to local repository not configured (see mirrorbits.conf)")
-	}
-	c.Repository, err = filepath.Abs(c.Repository)
-	if err != nil {
-		return fmt.Errorf("Invalid local repository path: %s", err)
+
+	if err = c.sanitize(); err != nil {
+		return err
 	}
-	if c.RepositoryScanInterval < 0 {
-		c.RepositoryScanInterval = 0
+
+	if config != nil {
+		for _, change := range Diff(config, &c) {
+			log.Noticef("Config: %s", change)
+		}
 	}
 
 	if config != nil &&
@@ -187,6 +539,258 @@ func ReloadConfig() error {
 	return nil
 }
 
+// sanitize validates the configuration and normalizes its values
+func (c *Configuration) sanitize() (err error) {
+	if c.RedisPasswordFile != "" {
+		if c.RedisPassword != "" {
+			return fmt.Errorf("Config: RedisPassword and RedisPasswordFile cannot be both set")
+		}
+		if c.RedisPassword, err = readSecretFile(c.RedisPasswordFile); err != nil {
+			return fmt.Errorf("Config: invalid RedisPasswordFile: %s", err)
+		}
+	}
+	if c.RPCPasswordFile != "" {
+		if c.RPCPassword != "" {
+			return fmt.Errorf("Config: RPCPassword and RPCPasswordFile cannot be both set")
+		}
+		if c.RPCPassword, err = readSecretFile(c.RPCPasswordFile); err != nil {
+			return fmt.Errorf("Config: invalid RPCPasswordFile: %s", err)
+		}
+	}
+	if c.WeightDistributionRange <= 0 {
+		return fmt.Errorf("WeightDistributionRange must be > 0")
+	}
+	if !isInSlice(c.OutputMode, []string{"auto", "json", "redirect"}) {
+		return fmt.Errorf("Config: outputMode can only be set to 'auto', 'json' or 'redirect'")
+	}
+	if !isInSlice(c.DirectoryIndex, []string{"none", "list", "redirect"}) {
+		return fmt.Errorf("Config: DirectoryIndex can only be set to 'none', 'list' or 'redirect'")
+	}
+	if c.Repository == "" {
+		return fmt.Errorf("Path to local repository not configured (see mirrorbits.conf)")
+	}
+	c.Repository, err = filepath.Abs(c.Repository)
+	if err != nil {
+		return fmt.Errorf("Invalid local repository path: %s", err)
+	}
+	if c.RepositoryScanInterval < 0 {
+		c.RepositoryScanInterval = 0
+	}
+	if c.FtpScanTimeout < 0 {
+		c.FtpScanTimeout = 0
+	}
+	if c.IncrementalScans && c.TraceFileLocation == "" {
+		return fmt.Errorf("Config: IncrementalScans requires a TraceFileLocation")
+	}
+	for _, patterns := range [][]string{c.ExcludePaths, c.IncludePaths} {
+		for i, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("Config: invalid path pattern %q: %s", pattern, err)
+			}
+			// Patterns with a slash are anchored at the root of the repository
+			if strings.Contains(pattern, "/") {
+				patterns[i] = "/" + strings.Trim(pattern, "/")
+			}
+		}
+	}
+	for i, prefix := range c.StatsExcludedPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			c.StatsExcludedPrefixes[i] = "/" + prefix
+		}
+	}
+	for i, f := range c.Fallbacks {
+		if f.Weight < 0 {
+			return fmt.Errorf("Config: the Weight of the fallbacks cannot be negative")
+		}
+		if f.Weight == 0 {
+			c.Fallbacks[i].Weight = 1
+		}
+	}
+	for i, e := range c.Embargoes {
+		if e.Prefix == "" || e.Release.IsZero() {
+			return fmt.Errorf("Config: embargoes require both a Prefix and a Release time")
+		}
+		if !strings.HasPrefix(e.Prefix, "/") {
+			c.Embargoes[i].Prefix = "/" + e.Prefix
+		}
+	}
+	for i, rh := range c.ResponseHeaders {
+		if !strings.HasPrefix(rh.Prefix, "/") {
+			c.ResponseHeaders[i].Prefix = "/" + rh.Prefix
+		}
+		headers := make(map[string]string, len(rh.Headers))
+		for name, value := range rh.Headers {
+			if name == "" || strings.ContainsAny(name, " \t:\r\n") || strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("Config: invalid response header %q", name)
+			}
+			headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+		}
+		c.ResponseHeaders[i].Headers = headers
+	}
+	for i, p := range c.CountryPreferences {
+		if len(p.Countries) == 0 || (p.Protocol == "" && len(p.Mirrors) == 0) {
+			return fmt.Errorf("Config: country preferences require Countries and either a Protocol or Mirrors")
+		}
+		p.Protocol = strings.ToLower(p.Protocol)
+		if p.Protocol != "" && p.Protocol != "http" && p.Protocol != "https" {
+			return fmt.Errorf("Config: invalid protocol in CountryPreferences: %s", p.Protocol)
+		}
+		for j, country := range p.Countries {
+			p.Countries[j] = strings.ToUpper(country)
+		}
+		c.CountryPreferences[i].Protocol = p.Protocol
+	}
+	for i, agent := range c.StatsExcludedAgents {
+		c.StatsExcludedAgents[i] = strings.ToLower(agent)
+	}
+	c.statsExcludedNets, err = parseNetworks(c.StatsExcludedNetworks)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in StatsExcludedNetworks: %s", err)
+	}
+	c.trustedProxies, err = parseNetworks(c.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in TrustedProxies: %s", err)
+	}
+	c.trustedCDNs, err = parseNetworks(c.TrustedCDNs)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in TrustedCDNs: %s", err)
+	}
+	if c.RateLimit.Rate < 0 || c.RateLimit.Burst < 1 {
+		return fmt.Errorf("Config: RateLimit Rate must be positive and Burst at least 1")
+	}
+	if c.RateLimit.IPv4Prefix < 0 || c.RateLimit.IPv4Prefix > 32 || c.RateLimit.IPv6Prefix < 0 || c.RateLimit.IPv6Prefix > 128 {
+		return fmt.Errorf("Config: invalid RateLimit prefix length")
+	}
+	c.RateLimit.allowed, err = parseNetworks(c.RateLimit.Allowed)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in RateLimit Allowed: %s", err)
+	}
+	c.LocationOverride.networks, err = parseNetworks(c.LocationOverride.Networks)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in LocationOverride: %s", err)
+	}
+	c.MirrorDetailsAccess.networks, err = parseNetworks(c.MirrorDetailsAccess.Networks)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in MirrorDetailsAccess: %s", err)
+	}
+	c.MirrorStatsAccess.networks, err = parseNetworks(c.MirrorStatsAccess.Networks)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in MirrorStatsAccess: %s", err)
+	}
+	c.MetricsExport.Access.networks, err = parseNetworks(c.MetricsExport.Access.Networks)
+	if err != nil {
+		return fmt.Errorf("Config: invalid network in MetricsExport Access: %s", err)
+	}
+	if (c.RPCTLS.CertFile == "") != (c.RPCTLS.KeyFile == "") {
+		return fmt.Errorf("Config: RPCTLS requires both a CertFile and a KeyFile")
+	}
+	if c.RPCTLS.ClientCAFile != "" && c.RPCTLS.CertFile == "" {
+		return fmt.Errorf("Config: RPCTLS ClientCAFile requires a CertFile and a KeyFile")
+	}
+	for i, t := range c.RPCTokens {
+		if t.Token == "" {
+			return fmt.Errorf("Config: RPC token %d has no Token", i+1)
+		}
+		if !isInSlice(t.Role, []string{RoleReadOnly, RoleOperator, RoleAdmin}) {
+			return fmt.Errorf("Config: RPC token role can only be set to '%s', '%s' or '%s'", RoleReadOnly, RoleOperator, RoleAdmin)
+		}
+	}
+	if !isInSlice(c.MetricsExport.Type, []string{"", "influxdb", "graphite", "statsd", "prometheus"}) {
+		return fmt.Errorf("Config: MetricsExport type can only be set to 'influxdb', 'graphite', 'statsd' or 'prometheus'")
+	}
+	if c.ScanRemovalThreshold < 0 || c.ScanRemovalThreshold > 100 {
+		return fmt.Errorf("Config: ScanRemovalThreshold must be a percentage between 0 and 100")
+	}
+	c.Latency.Continent = strings.ToUpper(c.Latency.Continent)
+	if c.Latency.SlowPenalty < 0 || c.Latency.SlowPenalty > 100 {
+		return fmt.Errorf("Config: Latency SlowPenalty must be a percentage between 0 and 100")
+	}
+	if c.BigFiles.MinSize < 0 || c.BigFiles.Bonus < 0 {
+		return fmt.Errorf("Config: BigFiles MinSize and Bonus must be positive")
+	}
+	if c.StatsFileShards < 0 {
+		return fmt.Errorf("Config: StatsFileShards cannot be negative")
+	}
+	if c.HealthCheckQuorum < 0 {
+		c.HealthCheckQuorum = 0
+	}
+	if c.NotFoundRescan.Threshold < 0 || c.NotFoundRescan.PrefixDepth < 0 {
+		return fmt.Errorf("Config: NotFoundRescan Threshold and PrefixDepth cannot be negative")
+	}
+	if c.RemovedMirrorRetention < 0 {
+		return fmt.Errorf("Config: RemovedMirrorRetention cannot be negative")
+	}
+	if c.SelectionCache < 0 {
+		return fmt.Errorf("Config: SelectionCache cannot be negative")
+	}
+	if c.ServeStale < 0 {
+		return fmt.Errorf("Config: ServeStale cannot be negative")
+	}
+	if c.NotFoundRescan.Window <= 0 {
+		return fmt.Errorf("Config: NotFoundRescan Window must be positive")
+	}
+	if c.ListenOptions.Backlog < 0 || c.ListenOptions.FastOpen < 0 {
+		return fmt.Errorf("Config: ListenOptions Backlog and FastOpen cannot be negative")
+	}
+	if c.ListenOptions.Acceptors < 1 {
+		c.ListenOptions.Acceptors = 1
+	}
+	if c.ListenOptions.Acceptors > 1 && !c.ListenOptions.ReusePort {
+		return fmt.Errorf("Config: ListenOptions Acceptors requires ReusePort")
+	}
+	if c.ListenOptions.SocketMode != "" {
+		if mode, err := strconv.ParseUint(c.ListenOptions.SocketMode, 8, 32); err != nil || mode > 0777 {
+			return fmt.Errorf("Config: ListenOptions SocketMode must be an octal mode such as 0660")
+		}
+	}
+	if c.ConsensusFallback.MinMirrors < 1 {
+		c.ConsensusFallback.MinMirrors = 1
+	}
+	if c.MetricsExport.Interval <= 0 {
+		c.MetricsExport.Interval = 60
+	}
+	if c.SelectionHook.Script != "" {
+		if _, err := os.Stat(c.SelectionHook.Script); err != nil {
+			return fmt.Errorf("Config: SelectionHook Script: %s", err)
+		}
+		if c.SelectionHook.Timeout < 1 {
+			return fmt.Errorf("Config: SelectionHook Timeout must be at least 1 millisecond")
+		}
+	}
+	if c.Snapshot.Path != "" && c.Snapshot.Interval < 1 {
+		return fmt.Errorf("Config: Snapshot Interval must be at least 1 minute")
+	}
+	if c.Capacity.TopFiles < 0 {
+		return fmt.Errorf("Config: Capacity TopFiles must be positive")
+	}
+	if c.Capacity.TopFiles > 0 && c.Capacity.Interval < 1 {
+		return fmt.Errorf("Config: Capacity Interval must be at least 1 minute")
+	}
+	for _, p := range []string{c.Probes.Liveness, c.Probes.Readiness} {
+		if p != "" && !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("Config: the paths of the Probes must start with a slash")
+		}
+	}
+	if c.AccessLog.SampleRate < 0 || c.AccessLog.SampleRate > 1 {
+		return fmt.Errorf("Config: AccessLog SampleRate must be between 0 and 1")
+	}
+	if c.AccessLog.Enabled && c.LogDir == "" {
+		return fmt.Errorf("Config: AccessLog requires LogDir")
+	}
+	if c.Replica.Enabled && c.RedisAddress == "" {
+		return fmt.Errorf("Config: Replica requires the RedisAddress of the replica")
+	}
+	if c.Telemetry.URL != "" {
+		if u, err := url.Parse(c.Telemetry.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("Config: Telemetry URL must be an http or https URL")
+		}
+		if c.Telemetry.Interval < 1 {
+			return fmt.Errorf("Config: Telemetry Interval must be at least 1 hour")
+		}
+	}
+	return nil
+}
+
 // GetConfig returns a pointer to a configuration object
 // FIXME reading from the pointer could cause a race!
 func GetConfig() *Configuration {
@@ -205,6 +809,303 @@ func SetConfiguration(c *Configuration) {
 	config = c
 }
 
+// GetListenAddresses returns the list of addresses the HTTP
+// server must listen on
+func (c *Configuration) GetListenAddresses() []string {
+	if len(c.ListenAddresses) > 0 {
+		return c.ListenAddresses
+	}
+	return []string{c.ListenAddress}
+}
+
+// IsStatsEnabled returns true if the downloads of the given
+// file must be accounted in the statistics and the downloads log
+func (c *Configuration) IsStatsEnabled(path string) bool {
+	if !c.StatsEnabled {
+		return false
+	}
+	for _, prefix := range c.StatsExcludedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmbargoed returns true if the given file is under embargo and
+// must not be served until its release time
+func (c *Configuration) IsEmbargoed(path string) bool {
+	return c.isEmbargoedAt(path, time.Now())
+}
+
+// isEmbargoedAt returns true if the given file is under embargo at the
+// given time, the file being released at the exact release time
+func (c *Configuration) isEmbargoedAt(path string, now time.Time) bool {
+	for _, e := range c.Embargoes {
+		if strings.HasPrefix(path, e.Prefix) && now.Before(e.Release) {
+			return true
+		}
+	}
+	return false
+}
+
+// HeadersForPath returns the extra headers of the responses to the given
+// path, the later rules taking precedence over the earlier ones
+func (c *Configuration) HeadersForPath(path string) map[string]string {
+	var headers map[string]string
+	for _, rh := range c.ResponseHeaders {
+		if !strings.HasPrefix(path, rh.Prefix) {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		for name, value := range rh.Headers {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// CountryPreference returns the protocol ("http", "https" or empty) and
+// the names of the mirrors preferred for the clients of the given country,
+// ok being false if no preference applies
+func (c *Configuration) CountryPreference(country string) (protocol string, mirrors []string, ok bool) {
+	for _, p := range c.CountryPreferences {
+		if isInSlice(country, p.Countries) {
+			return p.Protocol, p.Mirrors, true
+		}
+	}
+	return "", nil, false
+}
+
+// ExceedsPathLimits returns true if the given path is longer or
+// deeper than allowed by the configuration
+func (c *Configuration) ExceedsPathLimits(path string) bool {
+	if c.MaxPathLength > 0 && len(path) > c.MaxPathLength {
+		return true
+	}
+	if c.MaxPathDepth > 0 && len(strings.FieldsFunc(path, func(r rune) bool { return r == '/' })) > c.MaxPathDepth {
+		return true
+	}
+	return false
+}
+
+// IsPathExcluded returns true if the given path of the repository matches
+// one of the ExcludePaths patterns or, when IncludePaths is set, none of its
+// patterns. Such files are neither indexed nor served.
+func (c *Configuration) IsPathExcluded(p string) bool {
+	for _, pattern := range c.ExcludePaths {
+		if matchPath(pattern, p) {
+			return true
+		}
+	}
+	if len(c.IncludePaths) == 0 {
+		return false
+	}
+	for _, pattern := range c.IncludePaths {
+		if matchPath(pattern, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPath returns true if the pattern matches the path or one of its
+// parent directories when it contains a slash, or any of the components
+// of the path otherwise
+func matchPath(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		for _, component := range strings.Split(p, "/") {
+			if ok, _ := path.Match(pattern, component); ok {
+				return true
+			}
+		}
+		return false
+	}
+	for dir := path.Clean("/" + p); dir != "/"; dir = path.Dir(dir) {
+		if ok, _ := path.Match(pattern, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFallbacks returns the problems found in the configured fallbacks
+// which would only be noticed once the database is unavailable
+func (c *Configuration) CheckFallbacks() (warnings []string) {
+	for i, f := range c.Fallbacks {
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("fallback%d: invalid URL: %s", i, err))
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			warnings = append(warnings, fmt.Sprintf("fallback%d: the URL %q is not an absolute http(s) URL", i, f.URL))
+		}
+		if f.CountryCode != "" && len(f.CountryCode) != 2 {
+			warnings = append(warnings, fmt.Sprintf("fallback%d: invalid country code %q", i, f.CountryCode))
+		}
+		switch strings.ToUpper(f.ContinentCode) {
+		case "", "AF", "AN", "AS", "EU", "NA", "OC", "SA":
+		default:
+			warnings = append(warnings, fmt.Sprintf("fallback%d: invalid continent code %q", i, f.ContinentCode))
+		}
+	}
+	return warnings
+}
+
+// IsStatsExcludedClient returns true if the downloads of the given
+// client (a crawler or a monitoring agent) must not be accounted in
+// the statistics and the downloads log
+func (c *Configuration) IsStatsExcludedClient(ip, userAgent string) bool {
+	if len(c.StatsExcludedAgents) > 0 {
+		userAgent = strings.ToLower(userAgent)
+		for _, agent := range c.StatsExcludedAgents {
+			if strings.Contains(userAgent, agent) {
+				return true
+			}
+		}
+	}
+	if len(c.statsExcludedNets) > 0 {
+		addr := net.ParseIP(ip)
+		if addr == nil {
+			return false
+		}
+		for _, ipnet := range c.statsExcludedNets {
+			if ipnet.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RateLimitKey returns the key of the bucket of the given client for the
+// rate limiting, the clients of the same subnet sharing the same bucket,
+// or false if the client is never limited
+func (c *Configuration) RateLimitKey(ip string) (string, bool) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", false
+	}
+	for _, ipnet := range c.RateLimit.allowed {
+		if ipnet.Contains(addr) {
+			return "", false
+		}
+	}
+	if v4 := addr.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(c.RateLimit.IPv4Prefix, 32)).String(), true
+	}
+	return addr.Mask(net.CIDRMask(c.RateLimit.IPv6Prefix, 128)).String(), true
+}
+
+// IsTrustedProxy returns true if the forwarding headers sent by the
+// given address can be trusted. No address is trusted if no trusted
+// proxy is configured.
+func (c *Configuration) IsTrustedProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipnet := range c.trustedProxies {
+		if ipnet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTrustedCDN returns true if the client address given by the
+// CF-Connecting-IP or True-Client-IP headers can be trusted when set by
+// the given address
+func (c *Configuration) IsTrustedCDN(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipnet := range c.trustedCDNs {
+		if ipnet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLocationOverrideAllowed returns true if the given caller, identified
+// by its address or by a token, is allowed to force the client location
+func (c *Configuration) IsLocationOverrideAllowed(ip, token string) bool {
+	if token != "" {
+		for _, t := range c.LocationOverride.Tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return true
+			}
+		}
+	}
+	if addr := net.ParseIP(ip); addr != nil {
+		for _, ipnet := range c.LocationOverride.networks {
+			if ipnet.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsRestricted returns true if the access to the endpoint is restricted
+func (a *AccessControl) IsRestricted() bool {
+	return len(a.Networks) > 0 || len(a.Tokens) > 0 || a.Password != ""
+}
+
+// RPCRole returns the role granted to an RPC client presenting the given
+// password or token and its name, or false if the credentials are refused.
+// The RPCPassword grants the admin role, an empty password being accepted
+// only as long as no token is configured.
+func (c *Configuration) RPCRole(password, token string) (role, name string, ok bool) {
+	if token != "" {
+		for _, t := range c.RPCTokens {
+			if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+				return t.Role, t.Name, true
+			}
+		}
+		return "", "", false
+	}
+	if c.RPCPassword == "" && len(c.RPCTokens) > 0 {
+		return "", "", false
+	}
+	if subtle.ConstantTimeCompare([]byte(password), []byte(c.RPCPassword)) == 1 {
+		return RoleAdmin, "", true
+	}
+	return "", "", false
+}
+
+// IsAllowed returns true if the given client, identified by its IP address,
+// its basic auth credentials or its bearer token, is allowed to access the
+// endpoint
+func (a *AccessControl) IsAllowed(ip, username, password, token string) bool {
+	if !a.IsRestricted() {
+		return true
+	}
+	if token != "" {
+		for _, t := range a.Tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return true
+			}
+		}
+	}
+	if a.Password != "" &&
+		subtle.ConstantTimeCompare([]byte(username), []byte(a.Username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(a.Password)) == 1 {
+		return true
+	}
+	if addr := net.ParseIP(ip); addr != nil {
+		for _, ipnet := range a.networks {
+			if ipnet.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // SubscribeConfig allows subscribers to get notified when
 // the configuration is updated.
 func SubscribeConfig(subscriber chan bool) {
@@ -228,6 +1129,37 @@ func notifySubscribers() {
 	}
 }
 
+// parseNetworks parses a list of networks in CIDR notation, a single
+// address being considered as a network of one host
+func parseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, ipnet)
+	}
+	return networks, nil
+}
+
+// readSecretFile returns the secret stored in the given file, without
+// its trailing newline
+func readSecretFile(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
 func fileExists(filename string) bool {
 	_, err := os.Stat(filename)
 	return err == nil
@@ -0,0 +1,149 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPathExcluded(t *testing.T) {
+	c := &Configuration{
+		ExcludePaths: []string{".~tmp~", "*.tmp", "/internal"},
+	}
+
+	for p, expected := range map[string]bool{
+		"/file.iso":              false,
+		"/file.tmp":              true,
+		"/dir/file.tmp":          true,
+		"/dir/.~tmp~/file.iso":   true,
+		"/internal":              true,
+		"/internal/file.iso":     true,
+		"/internals/file.iso":    false,
+		"/dir/internal/file.iso": false,
+	} {
+		if c.IsPathExcluded(p) != expected {
+			t.Fatalf("%s: expected excluded to be %t", p, expected)
+		}
+	}
+
+	c.IncludePaths = []string{"/pub", "*.iso"}
+	for p, expected := range map[string]bool{
+		"/pub/file.txt":      false,
+		"/pub/file.tmp":      true,
+		"/other/file.iso":    false,
+		"/other/file.txt":    true,
+		"/internal/file.iso": true,
+	} {
+		if c.IsPathExcluded(p) != expected {
+			t.Fatalf("%s: expected excluded to be %t with includes", p, expected)
+		}
+	}
+}
+
+func TestIsEmbargoed(t *testing.T) {
+	release := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := &Configuration{
+		Embargoes: []embargo{{Prefix: "/releases/2.0/", Release: release}},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		now      time.Time
+		expected bool
+	}{
+		{"before the release", "/releases/2.0/file.iso", release.Add(-time.Hour), true},
+		{"just before the release", "/releases/2.0/file.iso", release.Add(-time.Nanosecond), true},
+		{"at the release", "/releases/2.0/file.iso", release, false},
+		{"after the release", "/releases/2.0/file.iso", release.Add(time.Hour), false},
+		{"other prefix", "/releases/1.0/file.iso", release.Add(-time.Hour), false},
+	}
+
+	for _, test := range tests {
+		if c.isEmbargoedAt(test.path, test.now) != test.expected {
+			t.Errorf("%s: expected embargoed to be %t", test.name, test.expected)
+		}
+	}
+
+	// Disabled
+	c.Embargoes = nil
+	if c.isEmbargoedAt("/releases/2.0/file.iso", release.Add(-time.Hour)) {
+		t.Errorf("Expected no embargo without any rule")
+	}
+
+	// The current time is used
+	c.Embargoes = []embargo{{Prefix: "/releases/", Release: time.Now().Add(time.Hour)}}
+	if !c.IsEmbargoed("/releases/2.0/file.iso") {
+		t.Errorf("Expected the file to be embargoed until the release")
+	}
+}
+
+func TestExceedsPathLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		depth    int
+		path     string
+		expected bool
+	}{
+		{"short", 16, 3, "/a/b/file.iso", false},
+		{"exactly at the length limit", 13, 0, "/a/b/file.iso", false},
+		{"too long", 12, 0, "/a/b/file.iso", true},
+		{"exactly at the depth limit", 0, 3, "/a/b/file.iso", false},
+		{"too deep", 0, 2, "/a/b/file.iso", true},
+		{"empty segments", 0, 3, "//a//b//file.iso", false},
+		{"disabled", 0, 0, "/" + strings.Repeat("a/", 100) + "file.iso", false},
+	}
+
+	for _, test := range tests {
+		c := &Configuration{MaxPathLength: test.length, MaxPathDepth: test.depth}
+		if c.ExceedsPathLimits(test.path) != test.expected {
+			t.Errorf("%s: expected %t for %s", test.name, test.expected, test.path)
+		}
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	c := &Configuration{}
+	c.RateLimit.IPv4Prefix = 24
+	c.RateLimit.IPv6Prefix = 64
+	c.RateLimit.allowed, _ = parseNetworks([]string{"10.0.0.0/8"})
+
+	for ip, expected := range map[string]string{
+		"192.168.1.42":         "192.168.1.0",
+		"2001:db8:1:2:3:4:5:6": "2001:db8:1:2::",
+		"10.1.2.3":             "",
+		"invalid":              "",
+	} {
+		key, limited := c.RateLimitKey(ip)
+		if key != expected || limited != (expected != "") {
+			t.Fatalf("%s: expected %q, got %q (limited: %t)", ip, expected, key, limited)
+		}
+	}
+}
+
+func TestHeadersForPath(t *testing.T) {
+	c := &Configuration{
+		ResponseHeaders: []responseHeaders{
+			{Prefix: "/", Headers: map[string]string{"X-Robots-Tag": "noindex", "Cache-Control": "max-age=3600"}},
+			{Prefix: "/nightly/", Headers: map[string]string{"Cache-Control": "no-cache"}},
+		},
+	}
+
+	h := c.HeadersForPath("/release/file.iso")
+	if len(h) != 2 || h["Cache-Control"] != "max-age=3600" || h["X-Robots-Tag"] != "noindex" {
+		t.Fatalf("Unexpected headers: %v", h)
+	}
+	h = c.HeadersForPath("/nightly/file.iso")
+	if len(h) != 2 || h["Cache-Control"] != "no-cache" || h["X-Robots-Tag"] != "noindex" {
+		t.Fatalf("Expected the later rule to take precedence, got %v", h)
+	}
+
+	c.ResponseHeaders = c.ResponseHeaders[1:]
+	if h = c.HeadersForPath("/release/file.iso"); h != nil {
+		t.Fatalf("Expected no headers, got %v", h)
+	}
+}
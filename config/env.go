@@ -0,0 +1,70 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the keys
+// of the configuration file
+const EnvPrefix = "MIRRORBITS_"
+
+// EnvName returns the name of the environment variable overriding the given
+// key of the configuration file, with the notation of Explain: the key in
+// upper case, the keys of the sections being separated by an underscore.
+// The items of the lists cannot be overridden one by one.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+// applyEnv overrides the keys of the configuration with the environment
+// variables found by lookup. The strings are taken as is, the other values
+// are parsed as YAML, the lists and the maps being given in flow style
+// such as [a, b] or {a: b}.
+func (c *Configuration) applyEnv(lookup func(string) (string, bool)) error {
+	return applyEnvStruct(reflect.ValueOf(c).Elem(), "", lookup)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.PkgPath != "" || name == "" || name == "-" {
+			// Unexported or not part of the configuration file
+			continue
+		}
+
+		key := prefix + name
+		fv := v.Field(i)
+
+		if f.Type.Kind() == reflect.Struct && f.Type != timeType {
+			if err := applyEnvStruct(fv, key+".", lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookup(EnvName(key))
+		if !ok {
+			continue
+		}
+		if f.Type.Kind() == reflect.String {
+			fv.SetString(value)
+			continue
+		}
+		// Replace the value rather than merging it
+		nv := reflect.New(f.Type)
+		if err := yaml.Unmarshal([]byte(value), nv.Interface()); err != nil {
+			return fmt.Errorf("Config: invalid value of %s: %s", EnvName(key), err)
+		}
+		fv.Set(nv.Elem())
+	}
+	return nil
+}
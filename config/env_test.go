@@ -0,0 +1,92 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"MIRRORBITS_REDISADDRESS":    "redis:6379",
+		"MIRRORBITS_REDISPASSWORD":   "secret: with a colon",
+		"MIRRORBITS_SCANINTERVAL":    "15",
+		"MIRRORBITS_STATSENABLED":    "false",
+		"MIRRORBITS_LISTENADDRESSES": "[':8080', ':8081']",
+		"MIRRORBITS_SNAPSHOT_PATH":   "/var/lib/mirrorbits/snapshot",
+		"MIRRORBITS_FALLBACKS":       "[{URL: 'http://fallback.example.org/'}]",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	c := defaultConfig()
+	c.ListenAddresses = []string{":80", ":81", ":82"}
+	if err := c.applyEnv(lookup); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if c.RedisAddress != "redis:6379" || c.RedisPassword != "secret: with a colon" {
+		t.Fatalf("Strings not overridden: %s %s", c.RedisAddress, c.RedisPassword)
+	}
+	if c.ScanInterval != 15 || c.StatsEnabled {
+		t.Fatalf("Values not overridden: %d %t", c.ScanInterval, c.StatsEnabled)
+	}
+	if len(c.ListenAddresses) != 2 || c.ListenAddresses[1] != ":8081" {
+		t.Fatalf("List not replaced: %v", c.ListenAddresses)
+	}
+	if c.Snapshot.Path != "/var/lib/mirrorbits/snapshot" || c.Snapshot.Interval != 60 {
+		t.Fatalf("Section not overridden: %+v", c.Snapshot)
+	}
+	if len(c.Fallbacks) != 1 || c.Fallbacks[0].URL != "http://fallback.example.org/" {
+		t.Fatalf("Fallbacks not overridden: %+v", c.Fallbacks)
+	}
+	if c.CheckInterval != 1 {
+		t.Fatalf("Unexpected override of CheckInterval: %d", c.CheckInterval)
+	}
+
+	env = map[string]string{"MIRRORBITS_SCANINTERVAL": "abc"}
+	if err := c.applyEnv(lookup); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestPasswordFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "redis")
+	if err = ioutil.WriteFile(filename, []byte("supersecure\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := defaultConfig()
+	c.Repository = dir
+	c.RedisPasswordFile = filename
+	if err = c.sanitize(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if c.RedisPassword != "supersecure" {
+		t.Fatalf("Expected the password of the file, got %q", c.RedisPassword)
+	}
+
+	// The password and its file cannot be both set
+	if err = c.sanitize(); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	c = defaultConfig()
+	c.Repository = dir
+	c.RPCPasswordFile = filepath.Join(dir, "missing")
+	if err = c.sanitize(); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
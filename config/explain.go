@@ -0,0 +1,108 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// KeyDoc describes a key of the configuration file
+type KeyDoc struct {
+	Key         string
+	Type        string
+	Default     string
+	Reloadable  bool
+	Description string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Explain returns the description of every key of the configuration file,
+// generated from the tags of the Configuration structure. The keys of the
+// nested sections are joined with a dot, the ones of the items of a list
+// with "[].".
+func Explain() []KeyDoc {
+	var keys []KeyDoc
+	c := defaultConfig()
+	explainStruct(reflect.ValueOf(c), "", true, &keys)
+	return keys
+}
+
+func explainStruct(v reflect.Value, prefix string, reloadable bool, keys *[]KeyDoc) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.PkgPath != "" || name == "" || name == "-" {
+			// Unexported or not part of the configuration file
+			continue
+		}
+
+		key := prefix + name
+		fieldReloadable := reloadable && f.Tag.Get("reload") != "restart"
+		fv := v.Field(i)
+
+		*keys = append(*keys, KeyDoc{
+			Key:         key,
+			Type:        typeName(f.Type),
+			Default:     formatValue(fv),
+			Reloadable:  fieldReloadable,
+			Description: f.Tag.Get("doc"),
+		})
+
+		switch {
+		case f.Type.Kind() == reflect.Struct && f.Type != timeType:
+			explainStruct(fv, key+".", fieldReloadable, keys)
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct:
+			explainStruct(reflect.Zero(f.Type.Elem()), key+"[].", fieldReloadable, keys)
+		}
+	}
+}
+
+// typeName returns a readable name of the type of a configuration key
+func typeName(t reflect.Type) string {
+	if t == timeType {
+		return "time"
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return "section"
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case reflect.Map:
+		return "map of " + typeName(t.Key()) + " to " + typeName(t.Elem())
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	}
+	return t.Kind().String()
+}
+
+// formatValue returns the value of a configuration key as written in the
+// configuration file
+func formatValue(v reflect.Value) string {
+	if v.Type() == timeType {
+		if v.Interface().(time.Time).IsZero() {
+			return ""
+		}
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return ""
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return ""
+		}
+	case reflect.String:
+		if v.Len() == 0 {
+			return `""`
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
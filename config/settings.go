@@ -0,0 +1,200 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces the values of the secrets of the configuration, such
+// as the passwords and the tokens, when they are shown
+const Redacted = "<redacted>"
+
+// Setting is the value of a key of the configuration. The keys use the
+// notation of Explain, the items of a list being numbered.
+type Setting struct {
+	Key        string
+	Value      string
+	Reloadable bool
+	Secret     bool
+
+	zero bool
+}
+
+// Change is a key whose value differs between two configurations
+type Change struct {
+	Key        string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	old, new := c.Old, c.New
+	if old == "" {
+		old = "(none)"
+	}
+	if new == "" {
+		new = "(none)"
+	}
+	s := fmt.Sprintf("%s: %s -> %s", c.Key, old, new)
+	if !c.Reloadable {
+		s += " (restart required)"
+	}
+	return s
+}
+
+// Settings returns the value of every key of the configuration, the
+// secrets being redacted
+func (c *Configuration) Settings() []Setting {
+	settings := c.settings()
+	for i, s := range settings {
+		if s.Secret {
+			settings[i].Value = redact(s.Value)
+		}
+	}
+	return settings
+}
+
+func (c *Configuration) settings() []Setting {
+	var settings []Setting
+	collectSettings(reflect.ValueOf(*c), "", true, false, &settings)
+	return settings
+}
+
+func collectSettings(v reflect.Value, prefix string, reloadable, secret bool, settings *[]Setting) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.PkgPath != "" || name == "" || name == "-" {
+			// Unexported or not part of the configuration file
+			continue
+		}
+
+		key := prefix + name
+		fieldReloadable := reloadable && f.Tag.Get("reload") != "restart"
+		fieldSecret := secret || f.Tag.Get("secret") == "true"
+		fv := v.Field(i)
+
+		switch {
+		case f.Type.Kind() == reflect.Struct && f.Type != timeType:
+			collectSettings(fv, key+".", fieldReloadable, fieldSecret, settings)
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				collectSettings(fv.Index(j), fmt.Sprintf("%s[%d].", key, j), fieldReloadable, fieldSecret, settings)
+			}
+		default:
+			*settings = append(*settings, Setting{
+				Key:        key,
+				Value:      formatValue(fv),
+				Reloadable: fieldReloadable,
+				Secret:     fieldSecret,
+				zero:       fv.IsZero(),
+			})
+		}
+	}
+}
+
+// Diff returns the keys whose value differs between the old and the new
+// configuration, the secrets being redacted
+func Diff(old, new *Configuration) []Change {
+	var changes []Change
+
+	newSettings := make(map[string]Setting)
+	for _, s := range new.settings() {
+		newSettings[s.Key] = s
+	}
+
+	add := func(key string, o, n Setting) {
+		if o.Value == n.Value {
+			return
+		}
+		change := Change{
+			Key:        key,
+			Old:        o.Value,
+			New:        n.Value,
+			Reloadable: o.Reloadable && n.Reloadable,
+		}
+		if o.Secret || n.Secret {
+			change.Old, change.New = redact(o.Value), redact(n.Value)
+		}
+		changes = append(changes, change)
+	}
+
+	oldSettings := old.settings()
+	seen := make(map[string]bool, len(oldSettings))
+	for _, o := range oldSettings {
+		seen[o.Key] = true
+		n, ok := newSettings[o.Key]
+		if !ok {
+			// Removed item of a list, only its values set are shown
+			if o.zero {
+				continue
+			}
+			n = Setting{Reloadable: o.Reloadable, Secret: o.Secret}
+		}
+		add(o.Key, o, n)
+	}
+	for _, n := range new.settings() {
+		if !seen[n.Key] && !n.zero {
+			// Added item of a list
+			add(n.Key, Setting{Reloadable: n.Reloadable, Secret: n.Secret}, n)
+		}
+	}
+	return changes
+}
+
+// redact hides the value of a secret unless it is empty
+func redact(value string) string {
+	if value == "" || value == `""` {
+		return value
+	}
+	return Redacted
+}
+
+// RedactedYAML returns the configuration as written in the configuration
+// file, the secrets being redacted
+func (c *Configuration) RedactedYAML() ([]byte, error) {
+	// Work on a copy of the configuration
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var redacted Configuration
+	if err = yaml.Unmarshal(out, &redacted); err != nil {
+		return nil, err
+	}
+	redactSecrets(reflect.ValueOf(&redacted).Elem(), false)
+	return yaml.Marshal(&redacted)
+}
+
+func redactSecrets(v reflect.Value, secret bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			redactSecrets(v.Field(i), secret || f.Tag.Get("secret") == "true")
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redactSecrets(v.Index(i), secret)
+		}
+	case reflect.String:
+		if secret {
+			v.SetString(redact(v.String()))
+		}
+	}
+}
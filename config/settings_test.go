@@ -0,0 +1,82 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDiff(t *testing.T) {
+	old := defaultConfig()
+	old.RedisPassword = "secret"
+	old.Fallbacks = []fallback{{URL: "http://a.example.org/"}, {URL: "http://b.example.org/"}}
+
+	new := defaultConfig()
+	new.RedisPassword = "other"
+	new.ScanInterval = old.ScanInterval + 1
+	new.Fallbacks = []fallback{{URL: "http://a.example.org/"}}
+	new.LocationOverride.Tokens = []string{"token"}
+
+	if changes := Diff(&old, &old); len(changes) != 0 {
+		t.Fatalf("Expected no change, got %+v", changes)
+	}
+
+	changes := Diff(&old, &new)
+	expected := map[string]Change{
+		"RedisPassword":           {Key: "RedisPassword", Old: Redacted, New: Redacted, Reloadable: false},
+		"ScanInterval":            {Key: "ScanInterval", Old: "30", New: "31", Reloadable: true},
+		"Fallbacks[1].URL":        {Key: "Fallbacks[1].URL", Old: "http://b.example.org/", New: "", Reloadable: true},
+		"LocationOverride.Tokens": {Key: "LocationOverride.Tokens", Old: "", New: Redacted, Reloadable: true},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes)
+	}
+	for _, c := range changes {
+		if c != expected[c.Key] {
+			t.Fatalf("Expected %+v, got %+v", expected[c.Key], c)
+		}
+	}
+	if s := expected["RedisPassword"].String(); s != "RedisPassword: <redacted> -> <redacted> (restart required)" {
+		t.Fatalf("Unexpected change: %s", s)
+	}
+}
+
+func TestRedactedYAML(t *testing.T) {
+	c := defaultConfig()
+	c.RedisPassword = "secret"
+	c.RPCTokens = []RPCToken{{Name: "ci", Token: "token", Role: RoleReadOnly}}
+	c.MirrorStatsAccess.Tokens = []string{"token1", "token2"}
+	c.LocationOverride.Tokens = []string{"token3"}
+
+	out, err := c.RedactedYAML()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if strings.Contains(string(out), "secret") || strings.Contains(string(out), "token") {
+		t.Fatalf("Secrets not redacted:\n%s", out)
+	}
+
+	var redacted Configuration
+	if err = yaml.Unmarshal(out, &redacted); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if redacted.RedisPassword != Redacted || redacted.RPCTokens[0].Token != Redacted || redacted.RPCTokens[0].Name != "ci" {
+		t.Fatalf("Unexpected configuration: %+v", redacted)
+	}
+	if len(redacted.MirrorStatsAccess.Tokens) != 2 || redacted.MirrorStatsAccess.Tokens[0] != Redacted {
+		t.Fatalf("Unexpected tokens: %v", redacted.MirrorStatsAccess.Tokens)
+	}
+	if len(redacted.LocationOverride.Tokens) != 1 || redacted.LocationOverride.Tokens[0] != Redacted {
+		t.Fatalf("Unexpected tokens: %v", redacted.LocationOverride.Tokens)
+	}
+	if redacted.RPCPassword != "" || redacted.ScanInterval != c.ScanInterval {
+		t.Fatalf("Unexpected configuration: %+v", redacted)
+	}
+	if c.RedisPassword != "secret" {
+		t.Fatalf("The configuration has been modified")
+	}
+}
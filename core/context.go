@@ -13,4 +13,7 @@ const (
 	ContextMirrorID
 	// ContextMirrorName is the key for the variable: MirrorName
 	ContextMirrorName
+	// ContextAcceptRedirects is the key for the variable: AcceptRedirects
+	// (a redirection is an acceptable answer and must not be followed)
+	ContextAcceptRedirects
 )
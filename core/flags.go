@@ -12,6 +12,7 @@ var (
 	Daemon      bool
 	Debug       bool
 	Monitor     bool
+	ConfigTest  bool
 	ConfigFile  string
 	CpuProfile  string
 	PidFile     string
@@ -19,6 +20,10 @@ var (
 	RPCPort     uint
 	RPCHost     string
 	RPCPassword string
+	RPCToken    string
+	RPCTLSCert  string
+	RPCTLSKey   string
+	RPCTLSCA    string
 	RPCAskPass  bool
 	NArg        int
 )
@@ -30,6 +35,10 @@ func Parseflags() {
 	flag.StringVar(&RPCHost, "h", "localhost", "Server host")
 	flag.StringVar(&RPCPassword, "P", "", "Server password")
 	flag.BoolVar(&RPCAskPass, "a", false, "Ask for server password")
+	flag.StringVar(&RPCToken, "T", "", "Server access token")
+	flag.StringVar(&RPCTLSCert, "tls-cert", "", "Client certificate for TLS connections")
+	flag.StringVar(&RPCTLSKey, "tls-key", "", "Client key for TLS connections")
+	flag.StringVar(&RPCTLSCA, "tls-ca", "", "CA verifying the server certificate (enables TLS)")
 	flag.Parse()
 	NArg = flag.NArg()
 
@@ -38,6 +47,7 @@ func Parseflags() {
 	daemon.StringVar(&CpuProfile, "cpuprofile", "", "write cpu profile to file")
 	daemon.StringVar(&ConfigFile, "config", "", "Path to the config file")
 	daemon.BoolVar(&Monitor, "monitor", true, "Enable the background mirrors monitor")
+	daemon.BoolVar(&ConfigTest, "configtest", false, "Check the configuration and exit")
 	daemon.StringVar(&PidFile, "p", "", "Path to pid file")
 	daemon.StringVar(&RunLog, "log", "", "File to output logs (default: stderr)")
 
@@ -1,6 +1,9 @@
 package core
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // ScannerType holds the type of scanner in use
 type ScannerType int8
@@ -12,9 +15,57 @@ const (
 	FTP
 )
 
+// String returns the name of the scanner
+func (s ScannerType) String() string {
+	switch s {
+	case RSYNC:
+		return "rsync"
+	case FTP:
+		return "ftp"
+	}
+	return "unknown"
+}
+
 // Precision is used to compute the precision of the mod time (millisecond, second)
 type Precision time.Duration
 
 func (p Precision) Duration() time.Duration {
 	return time.Duration(p)
 }
+
+// String returns the precision in a human readable form
+func (p Precision) String() string {
+	if p <= 0 {
+		return "unknown"
+	}
+	return p.Duration().String()
+}
+
+// MarshalYAML converts internal values to YAML
+func (p Precision) MarshalYAML() (interface{}, error) {
+	if p <= 0 {
+		return nil, nil
+	}
+	return p.Duration().String(), nil
+}
+
+// UnmarshalYAML converts YAML to internal values
+func (p *Precision) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*p = 0
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("invalid precision: %s", s)
+	}
+	*p = Precision(d)
+	return nil
+}
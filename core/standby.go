@@ -0,0 +1,23 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package core
+
+import "sync/atomic"
+
+var standby int32
+
+// SetStandby switches the running instance in or out of standby mode.
+// It returns true if the state has changed.
+func SetStandby(enabled bool) bool {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	return atomic.SwapInt32(&standby, v) != v
+}
+
+// IsStandby returns true if the running instance is in standby mode
+func IsStandby() bool {
+	return atomic.LoadInt32(&standby) == 1
+}
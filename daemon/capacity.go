@@ -0,0 +1,53 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+// capacityRetryInterval is the delay before the first computation of the
+// capacity, letting the mirrors be checked, and between two failures
+var capacityRetryInterval = time.Minute
+
+// capacityLoop periodically computes the coverage of the regions by the
+// mirrors shown in the mirrorstats page
+func (m *monitor) capacityLoop() {
+	defer m.wg.Done()
+
+	delay := capacityRetryInterval
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		delay = capacityRetryInterval
+		cfg := GetConfig().Capacity
+		if cfg.TopFiles <= 0 || m.redis.Failure() {
+			continue
+		}
+		if core.IsStandby() || !m.leader.IsLeader() {
+			// The active instance leading the cluster takes care of it
+			continue
+		}
+
+		start := time.Now()
+		c, err := mirrors.ComputeCapacity(m.redis, m.cache, cfg.TopFiles)
+		if err == nil {
+			err = mirrors.SaveCapacity(m.redis, c)
+		}
+		if err != nil {
+			log.Errorf("Computing the capacity failed: %s", err)
+			continue
+		}
+		log.Debugf("Capacity of %d countries computed for %d files in %s", len(c.Countries), c.TopFiles, time.Since(start))
+		delay = time.Duration(cfg.Interval) * time.Minute
+	}
+}
@@ -43,6 +43,14 @@ type node struct {
 	LastAnnounce int64
 }
 
+// NodeStatus describes a node of the cluster as seen by the local node
+type NodeStatus struct {
+	ID           string
+	LastAnnounce time.Time
+	Self         bool
+	Mirrors      []int // mirrors handled by the node
+}
+
 type byNodeID []node
 
 func (n byNodeID) Len() int           { return len(n) }
@@ -96,16 +104,20 @@ func (c *cluster) Stop() {
 }
 
 func (c *cluster) clusterLoop() {
+	defer c.wg.Done()
+
 	clusterChan := make(chan string, 10)
 	announceTicker := time.NewTicker(1 * time.Second)
+	defer announceTicker.Stop()
 
 	c.refreshNodeList(c.nodeID, c.nodeID)
+	// The cluster is left and joined again on each standby toggle
 	c.redis.Pubsub.SubscribeEvent(database.CLUSTER, clusterChan)
+	defer c.redis.Pubsub.UnsubscribeEvent(database.CLUSTER, clusterChan)
 
 	for {
 		select {
 		case <-c.stop:
-			c.wg.Done()
 			return
 		case <-announceTicker.C:
 			c.announce()
@@ -206,6 +218,41 @@ func (c *cluster) IsHandled(mirrorID int) bool {
 	return false
 }
 
+// Status returns the nodes of the cluster along with the mirrors they handle
+func (c *cluster) Status() []NodeStatus {
+	c.StartStopLock.Lock()
+	running := c.running
+	c.StartStopLock.Unlock()
+	if !running {
+		return nil
+	}
+
+	c.nodesLock.RLock()
+	defer c.nodesLock.RUnlock()
+
+	if c.nodeTotal == 0 {
+		return nil
+	}
+
+	mRange := int(float32(len(c.mirrorsIndex))/float32(c.nodeTotal) + 0.5)
+
+	status := make([]NodeStatus, 0, len(c.nodes))
+	for i, n := range c.nodes {
+		start := utils.Min(mRange*i, len(c.mirrorsIndex))
+		end := utils.Min(start+mRange, len(c.mirrorsIndex))
+		if i == c.nodeTotal-1 {
+			end = len(c.mirrorsIndex)
+		}
+		status = append(status, NodeStatus{
+			ID:           n.ID,
+			LastAnnounce: time.Unix(n.LastAnnounce, 0),
+			Self:         n.ID == c.nodeID,
+			Mirrors:      append([]int(nil), c.mirrorsIndex[start:end]...),
+		})
+	}
+	return status
+}
+
 func removeMirrorIDFromSlice(slice []int, mirrorID int) []int {
 	// See https://golang.org/pkg/sort/#SearchInts
 	idx := sort.SearchInts(slice, mirrorID)
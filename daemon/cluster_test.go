@@ -50,6 +50,35 @@ func TestStop(t *testing.T) {
 	}
 }
 
+func TestStandbyToggle(t *testing.T) {
+	_, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	c := NewCluster(conn)
+
+	// The monitor leaves the cluster when the node enters standby
+	// and joins it again once promoted
+	for i := 0; i < 5; i++ {
+		c.Start()
+		c.Stop()
+		if n := conn.Pubsub.Stats().Subscribers[string(database.CLUSTER)]; n != 0 {
+			t.Fatalf("Expected no subscriber once stopped, got %d", n)
+		}
+	}
+
+	c.Start()
+	defer c.Stop()
+
+	n := 0
+	for i := 0; i < 100 && n == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		n = conn.Pubsub.Stats().Subscribers[string(database.CLUSTER)]
+	}
+	if n != 1 {
+		t.Fatalf("Expected a single subscriber, got %d", n)
+	}
+}
+
 func TestClusterLoop(t *testing.T) {
 	mock, conn := PrepareRedisTest()
 	conn.ConnectPubsub()
@@ -287,3 +316,51 @@ func TestAddMirrorIDToSlice(t *testing.T) {
 		t.Fatalf("Expected %+v, got %+v", r3, r)
 	}
 }
+
+func TestClusterStatus(t *testing.T) {
+	_, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	c := NewCluster(conn)
+
+	if s := c.Status(); s != nil {
+		t.Fatalf("Expected no node when the cluster is not running, got %v", s)
+	}
+
+	// Simulate a running cluster without the announce loop
+	c.running = true
+
+	for i := 1; i <= 5; i++ {
+		c.AddMirror(&mirrors.Mirror{ID: i})
+	}
+
+	c.refreshNodeList(c.nodeID, c.nodeID)
+	c.refreshNodeList("aaa-00001", c.nodeID)
+	c.refreshNodeList("zzz-00001", c.nodeID)
+
+	status := c.Status()
+	if len(status) != 3 {
+		t.Fatalf("Expected 3 nodes, got %d", len(status))
+	}
+
+	total := 0
+	self := 0
+	for _, n := range status {
+		total += len(n.Mirrors)
+		if n.Self {
+			self++
+			if n.ID != c.nodeID {
+				t.Fatalf("Unexpected local node %s", n.ID)
+			}
+		}
+	}
+	if total != 5 {
+		t.Fatalf("Expected all 5 mirrors to be handled, got %d", total)
+	}
+	if self != 1 {
+		t.Fatalf("Expected exactly one local node, got %d", self)
+	}
+	if !reflect.DeepEqual(status[2].Mirrors, []int{5}) {
+		t.Fatalf("Expected the last node to handle the remaining mirror, got %v", status[2].Mirrors)
+	}
+}
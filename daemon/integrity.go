@@ -0,0 +1,222 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/database"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/utils"
+	"github.com/gomodule/redigo/redis"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// Number of random files to consider for each file to verify, since
+	// some of them can be too large or not yet hashed
+	integrityCandidates = 5
+
+	// Number of mirrors waiting for the verification of their content
+	integrityQueueSize = 10
+)
+
+// queueIntegrityCheck schedules the verification of the content of the
+// given mirror, unless too many verifications are already pending
+func (m *monitor) queueIntegrityCheck(id int) {
+	if GetConfig().IntegrityCheck.Samples <= 0 {
+		return
+	}
+	select {
+	case m.integrityChan <- id:
+	default:
+		log.Debugf("Integrity check of mirror #%d skipped, too many checks pending", id)
+	}
+}
+
+// integrityLoop verifies the content of the mirrors after their scan,
+// aside from the scans since the downloads can take a while
+func (m *monitor) integrityLoop() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case id := <-m.integrityChan:
+			m.mapLock.Lock()
+			mirrorPtr, ok := m.mirrors[id]
+			if !ok {
+				m.mapLock.Unlock()
+				continue
+			}
+			mir := *mirrorPtr
+			m.mapLock.Unlock()
+
+			if err := m.verifyIntegrity(mir); err != nil && !database.RedisIsLoading(err) {
+				log.Warningf("[%s] Integrity check failed: %s", mir.Name, err)
+			}
+		}
+	}
+}
+
+// verifyIntegrity downloads a random sample of the files handled by the
+// given mirror and compares their checksums with the local repository.
+// A mirror serving corrupted content is marked as down until it passes
+// the verification again.
+func (m *monitor) verifyIntegrity(mir mirror) error {
+	cfg := GetConfig().IntegrityCheck
+	if cfg.Samples <= 0 {
+		return nil
+	}
+	mirror := mir.Mirror
+
+	rconn := m.redis.Get()
+	candidates, err := redis.Strings(rconn.Do("SRANDMEMBER", fmt.Sprintf("HANDLEDFILES_%d", mirror.ID), cfg.Samples*integrityCandidates))
+	rconn.Close()
+	if err != nil {
+		return err
+	}
+
+	checked := 0
+	for _, file := range candidates {
+		if checked >= cfg.Samples || utils.IsStopped(m.stop) {
+			break
+		}
+
+		local, err := m.cache.GetFileInfo(file)
+		if err != nil {
+			return err
+		}
+		if local.Size == 0 || (cfg.MaxFileSize > 0 && local.Size > cfg.MaxFileSize) {
+			// Empty files prove nothing, large files take too long
+			continue
+		}
+		h, expected := integrityHash(local)
+		if h == nil {
+			continue
+		}
+
+		// Skip the files the mirror may not have synced yet
+		if time.Since(local.ModTime) < mir.scanInterval() {
+			continue
+		}
+
+		// Skip the files not in sync with the local repository
+		remote, err := m.cache.GetFileInfoMirror(mirror.ID, file)
+		if err != nil {
+			return err
+		}
+		if remote.Size != local.Size || !sameModTime(mirror, local.ModTime, remote.ModTime) {
+			continue
+		}
+
+		valid, err := m.verifyFile(mirror, file, local.Size, h, expected)
+		if err != nil {
+			log.Debugf("[%s] Integrity check of %s skipped: %s", mirror.Name, file, err)
+			continue
+		}
+		checked++
+
+		if !valid {
+			log.Errorf("[%s] Integrity check failed: %s doesn't match the local repository", mirror.Name, file)
+			return mirrors.SetMirrorIntegrity(m.redis, mirror.ID, false, file)
+		}
+	}
+
+	if checked > 0 && mirror.IntegrityFailed {
+		log.Noticef("[%s] Integrity check passed", mirror.Name)
+		return mirrors.SetMirrorIntegrity(m.redis, mirror.ID, true, "")
+	}
+	return nil
+}
+
+// sameModTime returns true if the modification time of the file found on
+// the mirror by the last scan matches the local one, within the precision
+// of the mirror
+func sameModTime(mirror mirrors.Mirror, local, remote time.Time) bool {
+	if remote.IsZero() {
+		return false
+	}
+	if GetConfig().FixTimezoneOffsets {
+		remote = remote.Add(time.Duration(mirror.TZOffset) * time.Millisecond)
+	}
+	precision := mirror.Precision().Duration()
+	return remote.Truncate(precision).Equal(local.Truncate(precision))
+}
+
+// verifyFile downloads a file from the mirror and returns true if its
+// checksum matches the expected one
+func (m *monitor) verifyFile(mirror mirrors.Mirror, file string, size int64, h hash.Hash, expected string) (bool, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(mirror.HttpURL, "/")+file, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Host = mirror.HostHeader
+	req.Close = true
+
+	ctx, cancel := context.WithTimeout(req.Context(), clientDeadline)
+	ctx = context.WithValue(ctx, core.ContextMirrorID, mirror.ID)
+	ctx = context.WithValue(ctx, core.ContextMirrorName, mirror.Name)
+	ctx = context.WithValue(ctx, core.ContextAllowRedirects, mirror.AllowRedirects)
+	req = req.WithContext(ctx)
+	defer cancel()
+
+	var n int64
+	_, err = m.httpDo(ctx, req, func(resp *http.Response, err error) error {
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("got status code %d", resp.StatusCode)
+		}
+		n, err = io.Copy(h, io.LimitReader(resp.Body, size+1))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// The file may have been updated since the last scan
+	if n != size {
+		return false, fmt.Errorf("size mismatch")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)) == expected, nil
+}
+
+// integrityHash returns a hasher along with the expected checksum
+// for the first hash available in the local repository
+func integrityHash(f filesystem.FileInfo) (hash.Hash, string) {
+	switch {
+	case f.Sha256 != "":
+		return sha256.New(), f.Sha256
+	case f.Sha512 != "":
+		return sha512.New(), f.Sha512
+	case f.Blake2b != "":
+		h, _ := blake2b.New512(nil)
+		return h, f.Blake2b
+	case f.Sha1 != "":
+		return sha1.New(), f.Sha1
+	case f.Md5 != "":
+		return md5.New(), f.Md5
+	}
+	return nil, ""
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/mirrors"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestMonitor_verifyIntegrity(t *testing.T) {
+	const served = "hello world"
+	const timeFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/file" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(served))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		local       string        // content of the local file
+		age         time.Duration // since the local file was modified
+		remoteShift time.Duration // of the modification time found by the scan
+		remoteKnown bool          // modification time reported by the scan
+		maxFileSize int64
+		checked     bool
+		valid       bool
+	}{
+		{"matching", served, time.Hour, 0, true, 1024, true, true},
+		{"mismatching", "hello_world", time.Hour, 0, true, 1024, true, false},
+		{"within the precision", served, time.Hour, 500 * time.Millisecond, true, 1024, true, true},
+		{"lagging mirror", served, time.Hour, -time.Hour, true, 1024, false, false},
+		{"unknown modification time", served, time.Hour, 0, false, 1024, false, false},
+		{"recently modified", served, 5 * time.Minute, 0, true, 1024, false, false},
+		{"oversized", served, time.Hour, 0, true, 4, false, false},
+	}
+
+	defer SetConfiguration(&Configuration{})
+
+	for _, test := range tests {
+		c := Configuration{ScanInterval: 30}
+		c.IntegrityCheck.Samples = 1
+		c.IntegrityCheck.MaxFileSize = test.maxFileSize
+		SetConfiguration(&c)
+
+		mock, conn := PrepareRedisTest()
+
+		sum := sha256.Sum256([]byte(test.local))
+		modTime := time.Now().Add(-test.age).Truncate(time.Second)
+		var remoteModTime []byte
+		if test.remoteKnown {
+			remoteModTime = []byte(modTime.Add(test.remoteShift).Format(timeFormat))
+		}
+		size := []byte(strconv.Itoa(len(test.local)))
+
+		mock.Command("SRANDMEMBER", "HANDLEDFILES_1", integrityCandidates).Expect([]interface{}{[]byte("/file")})
+		mock.Command("HMGET", "FILE_/file", "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").
+			Expect([]interface{}{size, []byte(modTime.Format(timeFormat)), nil, []byte(hex.EncodeToString(sum[:])), nil, nil, nil, nil, nil, nil})
+		mock.Command("HMGET", "FILEINFO_1_/file", "size", "modTime", "sha1", "sha256", "md5").
+			Expect([]interface{}{size, remoteModTime, nil, nil, nil})
+
+		cmdPassed := mock.Command("HSET", "MIRROR_1", "integrityFailed", false).Expect(int64(0))
+		cmdFailed := mock.Command("HSET", "MIRROR_1", "integrityFailed", true).Expect(int64(0))
+		mock.Command("HGET", "MIRROR_1", "up").Expect([]byte("0"))
+		mock.GenericCommand("HMSET").Expect("OK")
+		mock.GenericCommand("PUBLISH").Expect(int64(0))
+
+		// The commands are registered before the pubsub starts using the connection
+		conn.ConnectPubsub()
+		m := &monitor{
+			redis: conn,
+			cache: mirrors.NewCache(conn),
+			stop:  make(chan struct{}),
+		}
+
+		mir := mirror{Mirror: mirrors.Mirror{
+			ID:                          1,
+			Name:                        "m1",
+			HttpURL:                     server.URL,
+			LastSuccessfulSyncPrecision: core.Precision(time.Second),
+			IntegrityFailed:             true,
+		}}
+
+		atomic.StoreInt32(&requests, 0)
+		if err := m.verifyIntegrity(mir); err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+
+		if checked := atomic.LoadInt32(&requests) > 0; checked != test.checked {
+			t.Errorf("%s: expected the file to be downloaded: %t, got %t", test.name, test.checked, checked)
+		}
+		if passed := mock.Stats(cmdPassed) > 0; passed != (test.checked && test.valid) {
+			t.Errorf("%s: expected the check to pass: %t, got %t", test.name, test.checked && test.valid, passed)
+		}
+		if failed := mock.Stats(cmdFailed) > 0; failed != (test.checked && !test.valid) {
+			t.Errorf("%s: expected the check to fail: %t, got %t", test.name, test.checked && !test.valid, failed)
+		}
+	}
+}
+
+func TestMonitor_queueIntegrityCheck(t *testing.T) {
+	defer SetConfiguration(&Configuration{})
+	m := &monitor{integrityChan: make(chan int, 1)}
+
+	// Disabled
+	SetConfiguration(&Configuration{})
+	m.queueIntegrityCheck(1)
+	if len(m.integrityChan) != 0 {
+		t.Fatalf("Expected no check to be queued")
+	}
+
+	c := Configuration{}
+	c.IntegrityCheck.Samples = 1
+	SetConfiguration(&c)
+	m.queueIntegrityCheck(1)
+	// The sync routine isn't blocked by a full queue
+	m.queueIntegrityCheck(2)
+	if id := <-m.integrityChan; id != 1 || len(m.integrityChan) != 0 {
+		t.Fatalf("Expected the first check to be queued, got #%d", id)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/database"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// LeaderKey is the key holding the ID of the node currently leading the cluster
+	LeaderKey = "LEADER"
+
+	leaderLease = 10 * time.Second
+	// leaderStepDown is the delay without a successful renewal after which
+	// the leader gives up its role
+	leaderStepDown = leaderLease / 2
+)
+
+var (
+	// Extend the lease only if it is still held by the given node
+	renewLeaseScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// Release the lease only if it is still held by the given node
+	releaseLeaseScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// leader elects a single node of the cluster to perform the tasks that
+// must not be done concurrently, like scanning the local repository or
+// pruning the stats. The election relies on a lease stored in redis that
+// the leader keeps renewing: if it dies, another node takes over once the
+// lease has expired.
+type leader struct {
+	redis  *database.Redis
+	nodeID string
+
+	lock        sync.RWMutex
+	isLeader    bool
+	lastRenewal time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newLeader(r *database.Redis, nodeID string) *leader {
+	return &leader{
+		redis:  r,
+		nodeID: nodeID,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start runs the election, the first attempt being made synchronously
+func (l *leader) Start() {
+	l.elect()
+	l.wg.Add(1)
+	go l.loop()
+}
+
+// Stop stops the election and releases the lease if held
+func (l *leader) Stop() {
+	select {
+	case <-l.stop:
+		return
+	default:
+		close(l.stop)
+	}
+	l.wg.Wait()
+	l.release()
+}
+
+// IsLeader returns true if the local node currently leads the cluster
+func (l *leader) IsLeader() bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.isLeader
+}
+
+func (l *leader) loop() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(leaderLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.elect()
+		}
+	}
+}
+
+// elect renews the lease if held or tries to acquire it otherwise
+func (l *leader) elect() {
+	if core.IsStandby() {
+		// Standby instances never lead the cluster
+		l.release()
+		return
+	}
+
+	conn := l.redis.UnblockedGet()
+	defer conn.Close()
+
+	lease := int64(leaderLease / time.Millisecond)
+
+	// The lease runs from the moment the command is sent, not from the
+	// moment the reply is received
+	start := time.Now()
+
+	var elected bool
+	if l.IsLeader() {
+		renewed, err := redis.Bool(renewLeaseScript.Do(conn, LeaderKey, l.nodeID, lease))
+		if err != nil {
+			// Step down well before the lease expires to leave room for the
+			// clock drift and the pauses of the process, so that two nodes
+			// never lead the cluster at the same time
+			if time.Since(l.lastRenewal) >= leaderStepDown {
+				l.setLeader(false)
+			}
+			return
+		}
+		elected = renewed
+	} else {
+		_, err := redis.String(conn.Do("SET", LeaderKey, l.nodeID, "NX", "PX", lease))
+		if err != nil && err != redis.ErrNil {
+			return
+		}
+		elected = err == nil
+	}
+
+	if elected {
+		l.lastRenewal = start
+	}
+	l.setLeader(elected)
+}
+
+func (l *leader) release() {
+	if !l.IsLeader() {
+		return
+	}
+	conn := l.redis.UnblockedGet()
+	releaseLeaseScript.Do(conn, LeaderKey, l.nodeID)
+	conn.Close()
+	l.setLeader(false)
+}
+
+func (l *leader) setLeader(elected bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if elected == l.isLeader {
+		return
+	}
+	l.isLeader = elected
+	if elected {
+		log.Notice("This node is now leading the cluster")
+	} else {
+		log.Notice("This node is no longer leading the cluster")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestLeader_elect(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	l := newLeader(conn, "node1")
+
+	cmdAcquire := mock.Command("SET", LeaderKey, "node1", "NX", "PX", int64(10000)).Expect(nil)
+	l.elect()
+	if mock.Stats(cmdAcquire) != 1 {
+		t.Fatalf("Expected an attempt to acquire the lease")
+	}
+	if l.IsLeader() {
+		t.Fatalf("The lease is held by another node")
+	}
+
+	mock.Command("SET", LeaderKey, "node1", "NX", "PX", int64(10000)).Expect("OK")
+	l.elect()
+	if !l.IsLeader() {
+		t.Fatalf("Expected the node to be elected")
+	}
+
+	cmdRenew := mock.GenericCommand("EVALSHA").Expect(int64(1))
+	l.elect()
+	if mock.Stats(cmdRenew) != 1 {
+		t.Fatalf("Expected the lease to be renewed")
+	}
+	if !l.IsLeader() {
+		t.Fatalf("Expected the node to still lead the cluster")
+	}
+
+	// The renewal fails but the lease is still far from expiring
+	mock.GenericCommand("EVALSHA").ExpectError(errors.New("connection reset"))
+	l.elect()
+	if !l.IsLeader() {
+		t.Fatalf("Expected the node to keep leading the cluster")
+	}
+
+	// The renewal keeps failing: step down with a safety margin, before
+	// another node can acquire the expired lease
+	l.lastRenewal = time.Now().Add(-leaderLease / 2)
+	l.elect()
+	if l.IsLeader() {
+		t.Fatalf("Expected the node to step down before the lease expires")
+	}
+
+	mock.Command("SET", LeaderKey, "node1", "NX", "PX", int64(10000)).Expect("OK")
+	l.elect()
+	if !l.IsLeader() {
+		t.Fatalf("Expected the node to be elected")
+	}
+
+	mock.GenericCommand("EVALSHA").Expect(int64(0))
+	l.elect()
+	if l.IsLeader() {
+		t.Fatalf("Expected the node to step down once the lease is lost")
+	}
+}
@@ -12,11 +12,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
 	"github.com/etix/mirrorbits/database"
+	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/mirrors"
 	"github.com/etix/mirrorbits/scan"
 	"github.com/etix/mirrorbits/utils"
@@ -30,12 +32,37 @@ var (
 	userAgent           = "Mirrorbits/" + core.VERSION + " PING CHECK"
 	clientTimeout       = time.Duration(20 * time.Second)
 	clientDeadline      = time.Duration(40 * time.Second)
+	pruneInterval       = time.Duration(1 * time.Hour)
 	errRedirect         = errors.New("Redirect not allowed")
 	errMirrorNotScanned = errors.New("Mirror has not yet been scanned")
 
+	// Number of random files to consider for a health check, since empty
+	// files prove nothing about the mirror
+	healthCheckCandidates = 10
+
+	// ErrUnknownMirror is returned when checking a mirror unknown to the monitor
+	ErrUnknownMirror = errors.New("Unknown mirror")
+
 	log = logging.MustGetLogger("main")
+
+	loopHealth struct {
+		sync.Mutex
+		lastTick         time.Time
+		healthCheckQueue int
+		awaitingScan     int
+	}
 )
 
+// MonitorHealth returns the time of the last iteration of the monitor loop
+// over the mirrors, along with the number of health checks queued for a
+// worker and the number of mirrors due for a scan which couldn't be handed
+// to a worker, all of them being busy, as of this iteration
+func MonitorHealth() (lastTick time.Time, healthCheckQueue, awaitingScan int) {
+	loopHealth.Lock()
+	defer loopHealth.Unlock()
+	return loopHealth.lastTick, loopHealth.healthCheckQueue, loopHealth.awaitingScan
+}
+
 type monitor struct {
 	redis           *database.Redis
 	cache           *mirrors.Cache
@@ -45,28 +72,142 @@ type monitor struct {
 	httpTransport   http.Transport
 	healthCheckChan chan int
 	syncChan        chan int
+	integrityChan   chan int
 	stop            chan struct{}
 	configNotifier  chan bool
 	wg              sync.WaitGroup
 	formatLongestID int
 
 	cluster *cluster
+	leader  *leader
 	trace   *scan.Trace
+
+	// Long tasks running in the background, see runTask
+	repositoryScanning int32
+	pruning            int32
+}
+
+// CheckResult is the result of the health check of a mirror
+type CheckResult struct {
+	MirrorID   int
+	File       string
+	StatusCode int
+	Latency    time.Duration
+	Error      string
+	Up         bool
 }
 
 type mirror struct {
 	mirrors.Mirror
 	checking  bool
 	scanning  bool
+	rescan    bool   // full scan requested, regardless of the trace file
+	rescanDir string // subtree to rescan, regardless of the trace file
 	lastCheck time.Time
+
+	// Results of the previous health checks
+	successes    int
+	failures     int
+	lastResult   *bool
+	stateChanges []time.Time
+	flapping     bool
 }
 
 func (m *mirror) NeedHealthCheck() bool {
-	return time.Since(m.lastCheck) > time.Duration(GetConfig().CheckInterval)*time.Minute
+	return time.Since(m.lastCheck) > m.checkInterval()
+}
+
+// checkInterval returns the delay between two health checks of the mirror.
+// With the adaptive scheduling, failing mirrors are quickly rechecked with
+// an exponential backoff while healthy ones are progressively checked less
+// often.
+func (m *mirror) checkInterval() time.Duration {
+	interval := time.Duration(GetConfig().CheckInterval) * time.Minute
+	cfg := GetConfig().HealthCheckScheduling
+	if !cfg.Adaptive {
+		return interval
+	}
+
+	if m.failures > 0 {
+		retry := time.Duration(cfg.RetryInterval) * time.Second
+		for i := 1; i < m.failures && retry < interval; i++ {
+			retry *= 2
+		}
+		if retry > 0 && retry < interval {
+			return retry
+		}
+		return interval
+	}
+
+	maxInterval := time.Duration(cfg.MaxInterval) * time.Minute
+	if relaxed := interval * time.Duration(m.successes+1); relaxed < maxInterval {
+		return relaxed
+	} else if maxInterval > interval {
+		return maxInterval
+	}
+	return interval
+}
+
+// recordHealthCheck updates the history of the mirror with the result of
+// a health check and detects the mirrors flapping between up and down
+func (m *mirror) recordHealthCheck(up bool, now time.Time) {
+	cfg := GetConfig().HealthCheckScheduling
+
+	if up {
+		m.successes++
+		m.failures = 0
+	} else {
+		m.failures++
+		m.successes = 0
+	}
+
+	if m.lastResult != nil && *m.lastResult != up {
+		m.stateChanges = append(m.stateChanges, now)
+	}
+	m.lastResult = &up
+
+	// Forget the state changes outside of the window
+	window := time.Duration(cfg.FlappingWindow) * time.Minute
+	for len(m.stateChanges) > 0 && now.Sub(m.stateChanges[0]) > window {
+		m.stateChanges = m.stateChanges[1:]
+	}
+
+	if cfg.FlappingChanges > 0 && len(m.stateChanges) >= cfg.FlappingChanges {
+		m.flapping = true
+	}
+	if m.flapping && m.successes >= cfg.RecoverySuccesses {
+		m.flapping = false
+		m.stateChanges = nil
+	}
+}
+
+// isHeldDown returns true if the mirror is flapping and must be kept
+// down even if the next health check succeeds
+func (m *mirror) isHeldDown() bool {
+	return m.flapping && m.successes+1 < GetConfig().HealthCheckScheduling.RecoverySuccesses
 }
 
 func (m *mirror) NeedSync() bool {
-	return time.Since(m.LastSync.Time) > time.Duration(GetConfig().ScanInterval)*time.Minute
+	return time.Since(m.LastSync.Time) > m.scanInterval()
+}
+
+// scanInterval returns the delay between two scans of the mirror
+func (m *mirror) scanInterval() time.Duration {
+	interval := GetConfig().ScanInterval
+	if m.ScanInterval > 0 {
+		interval = m.ScanInterval
+	}
+	return time.Duration(interval) * time.Minute
+}
+
+// NeedRecoveryScan returns true if the mirror, known to be down, has been
+// down for longer than its scan interval and thus most likely missed some
+// updates of the repository
+func (m *mirror) NeedRecoveryScan(now time.Time) bool {
+	if !GetConfig().RescanOnRecovery || m.Up || m.StateSince.IsZero() {
+		return false
+	}
+	return now.Sub(m.StateSince.Time) > m.scanInterval()
 }
 
 func (m *mirror) IsScanning() bool {
@@ -83,9 +224,11 @@ func NewMonitor(r *database.Redis, c *mirrors.Cache) *monitor {
 	m.redis = r
 	m.cache = c
 	m.cluster = NewCluster(r)
+	m.leader = newLeader(r, m.cluster.nodeID)
 	m.mirrors = make(map[int]*mirror)
 	m.healthCheckChan = make(chan int, healthCheckThreads*5)
 	m.syncChan = make(chan int)
+	m.integrityChan = make(chan int, integrityQueueSize)
 	m.stop = make(chan struct{})
 	m.configNotifier = make(chan bool, 1)
 	m.trace = scan.NewTraceHandler(m.redis, m.stop)
@@ -121,6 +264,7 @@ func (m *monitor) Stop() {
 		return
 	default:
 		m.cluster.Stop()
+		m.leader.Stop()
 		close(m.stop)
 	}
 }
@@ -131,6 +275,11 @@ func (m *monitor) Wait() {
 
 // Return an error if the endpoint is an unauthorized redirect
 func checkRedirect(req *http.Request, via []*http.Request) error {
+	if accept, ok := req.Context().Value(core.ContextAcceptRedirects).(bool); ok && accept {
+		// The redirection itself is the expected answer
+		return http.ErrUseLastResponse
+	}
+
 	redirects := req.Context().Value(core.ContextAllowRedirects).(mirrors.Redirects)
 
 	if redirects.Allowed() {
@@ -152,6 +301,8 @@ func (m *monitor) MonitorLoop() {
 	defer m.wg.Done()
 
 	mirrorUpdateEvent := m.cache.GetMirrorInvalidationEvent()
+	rescanEvent := make(chan string, 10)
+	m.redis.Pubsub.SubscribeEvent(database.MIRROR_RESCAN, rescanEvent)
 
 	// Wait until the database is ready to be used
 	for {
@@ -165,8 +316,15 @@ func (m *monitor) MonitorLoop() {
 		break
 	}
 
+	// Elect the node in charge of the repository
+	m.leader.Start()
+
 	// Scan the local repository
 	m.retry(func(i uint) error {
+		if core.IsStandby() || !m.leader.IsLeader() {
+			// The active instance leading the cluster takes care of the repository
+			return nil
+		}
 		err := m.scanRepository()
 		if err != nil {
 			if i == 0 {
@@ -201,7 +359,17 @@ func (m *monitor) MonitorLoop() {
 	}
 
 	// Start the cluster manager
-	m.cluster.Start()
+	if !core.IsStandby() {
+		m.cluster.Start()
+	}
+
+	// Start the snapshot routine
+	m.wg.Add(1)
+	go m.snapshotLoop()
+
+	// Start the capacity routine
+	m.wg.Add(1)
+	go m.capacityLoop()
 
 	// Start the health check routines
 	for i := 0; i < healthCheckThreads; i++ {
@@ -215,10 +383,16 @@ func (m *monitor) MonitorLoop() {
 		go m.syncLoop()
 	}
 
+	// Start the integrity check routine
+	m.wg.Add(1)
+	go m.integrityLoop()
+
 	// Setup recurrent tasks
 	var repositoryScanTicker <-chan time.Time
 	repositoryScanInterval := -1
 	mirrorCheckTicker := time.NewTicker(1 * time.Second)
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
 
 	// Disable the mirror check while stopping to avoid spurious events
 	go func() {
@@ -243,6 +417,12 @@ func (m *monitor) MonitorLoop() {
 			if err == nil {
 				m.syncMirrorList(id)
 			}
+		case v := <-rescanEvent:
+			id, prefix, err := mirrors.ParseRescanRequest(v)
+			if err != nil || core.IsStandby() || !m.cluster.IsHandled(id) {
+				continue
+			}
+			m.requestRescan(id, prefix)
 		case <-m.configNotifier:
 			if repositoryScanInterval != GetConfig().RepositoryScanInterval {
 				repositoryScanInterval = GetConfig().RepositoryScanInterval
@@ -253,19 +433,63 @@ func (m *monitor) MonitorLoop() {
 					repositoryScanTicker = time.Tick(time.Duration(repositoryScanInterval) * time.Minute)
 				}
 			}
+		case <-pruneTicker.C:
+			if core.IsStandby() || m.redis.Failure() || !m.leader.IsLeader() {
+				continue
+			}
+			m.runTask(&m.pruning, func() {
+				if n, err := m.redis.PruneStats(); err != nil {
+					log.Errorf("Pruning stats failed: %s", err)
+				} else if n > 0 {
+					log.Noticef("Pruned %d expired stats keys", n)
+				}
+				retention := time.Duration(GetConfig().RemovedMirrorRetention) * 24 * time.Hour
+				if n, err := mirrors.PurgeRemovedMirrors(m.redis, retention); err != nil {
+					log.Errorf("Purging removed mirrors failed: %s", err)
+				} else if n > 0 {
+					log.Noticef("Purged %d removed mirrors", n)
+				}
+			})
+			go func() {
+				if err := m.sendTelemetry(); err != nil {
+					log.Warningf("Sending the telemetry report failed: %s", err)
+				}
+			}()
 		case <-repositoryScanTicker:
-			m.scanRepository()
+			if core.IsStandby() || !m.leader.IsLeader() {
+				continue
+			}
+			m.runTask(&m.repositoryScanning, func() {
+				m.scanRepository()
+			})
 		case <-mirrorCheckTicker.C:
-			if m.redis.Failure() {
+			loopHealth.Lock()
+			loopHealth.lastTick = time.Now()
+			loopHealth.Unlock()
+
+			if m.redis.Failure() || utils.IsStopped(m.stop) {
 				continue
 			}
+			if core.IsStandby() {
+				// Leave the cluster and let the active nodes
+				// handle the health checks and scans
+				m.cluster.Stop()
+				continue
+			}
+			m.cluster.Start()
+			var expired []int
+			awaitingScan := 0
 			m.mapLock.Lock()
 			for id, v := range m.mirrors {
+				if v.DisableExpired(time.Now()) && m.cluster.IsHandled(id) {
+					expired = append(expired, id)
+				}
 				if !v.Enabled {
 					// Ignore disabled mirrors
 					continue
 				}
-				if v.NeedHealthCheck() && !v.IsChecking() && m.cluster.IsHandled(id) {
+				// With a quorum, every node checks all the mirrors
+				if v.NeedHealthCheck() && !v.IsChecking() && (GetConfig().HealthCheckQuorum > 0 || m.cluster.IsHandled(id)) {
 					select {
 					case m.healthCheckChan <- id:
 						m.mirrors[id].checking = true
@@ -277,14 +501,68 @@ func (m *monitor) MonitorLoop() {
 					case m.syncChan <- id:
 						m.mirrors[id].scanning = true
 					default:
+						awaitingScan++
 					}
 				}
 			}
 			m.mapLock.Unlock()
+
+			loopHealth.Lock()
+			loopHealth.healthCheckQueue = len(m.healthCheckChan)
+			loopHealth.awaitingScan = awaitingScan
+			loopHealth.Unlock()
+
+			m.enableExpired(expired)
 		}
 	}
 }
 
+// runTask runs fn in the background unless its previous run, tracked by
+// the given flag, is still in progress. The monitor loop must keep iterating
+// over the mirrors during the long tasks, its ticks being watched by the
+// systemd watchdog.
+func (m *monitor) runTask(running *int32, fn func()) {
+	if !atomic.CompareAndSwapInt32(running, 0, 1) {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer atomic.StoreInt32(running, 0)
+		fn()
+	}()
+}
+
+// enableExpired enables again the mirrors disabled until a date now
+// reached and schedules a scan of them
+func (m *monitor) enableExpired(ids []int) {
+	for _, id := range ids {
+		if err := mirrors.EnableMirror(m.redis, id); err != nil {
+			log.Errorf("Unable to enable mirror #%d: %s", id, err)
+			continue
+		}
+
+		m.mapLock.Lock()
+		if mir, ok := m.mirrors[id]; ok {
+			log.Noticef("[%s] Disabled until %s, enabling the mirror", mir.Name, mir.DisabledUntil.Local().Format(time.RFC1123))
+			// Don't wait for the update to be propagated
+			mir.Enabled = true
+			mir.DisabledReason = ""
+			mir.DisabledUntil = mirrors.Time{}
+			if !mir.IsScanning() {
+				select {
+				case m.syncChan <- id:
+					mir.scanning = true
+				default:
+					// The scan will be scheduled once the mirror is
+					// considered as needing a sync
+				}
+			}
+		}
+		m.mapLock.Unlock()
+	}
+}
+
 // Returns a list of all mirrors ID
 func (m *monitor) mirrorsID() ([]int, error) {
 	var ids []int
@@ -305,7 +583,7 @@ func (m *monitor) syncMirrorList(mirrorsIDs ...int) error {
 		if err != nil && err != redis.ErrNil {
 			log.Errorf("Fetching mirror %s failed: %s", id, err.Error())
 			continue
-		} else if err == redis.ErrNil {
+		} else if err == redis.ErrNil || !mir.RemovedAt.IsZero() {
 			// Mirror has been deleted
 			m.mapLock.Lock()
 			delete(m.mirrors, id)
@@ -367,7 +645,7 @@ func (m *monitor) healthCheckLoop() {
 			mirror = *mptr
 			m.mapLock.Unlock()
 
-			err := m.healthCheck(mirror.Mirror)
+			res, err := m.healthCheck(mirror.Mirror, mirror.isHeldDown())
 
 			if err == errMirrorNotScanned {
 				// Not removing the 'checking' lock is intended here so the mirror won't
@@ -375,14 +653,38 @@ func (m *monitor) healthCheckLoop() {
 				continue
 			}
 
+			recovered := err == nil && res.Up && !mirror.isHeldDown() && mirror.NeedRecoveryScan(time.Now())
+			rescan := false
+
 			m.mapLock.Lock()
 			if mirror, ok := m.mirrors[id]; ok {
 				if !database.RedisIsLoading(err) {
 					mirror.lastCheck = time.Now().UTC()
 				}
+				if err == nil {
+					mirror.recordHealthCheck(res.Up, time.Now())
+				}
 				mirror.checking = false
+
+				// Rescan the mirror back from a long outage
+				if recovered && !mirror.IsScanning() {
+					select {
+					case m.syncChan <- id:
+						mirror.scanning = true
+						mirror.rescan = true
+						rescan = true
+					default:
+					}
+				}
 			}
 			m.mapLock.Unlock()
+
+			if rescan {
+				log.Noticef("[%s] Back after %s, rescanning", mirror.Name, time.Since(mirror.StateSince.Time).Round(time.Minute))
+				if err := mirrors.SetMirrorPendingRescan(m.redis, id); err != nil {
+					log.Errorf("[%s] Unable to flag the mirror: %s", mirror.Name, err)
+				}
+			}
 		}
 	}
 }
@@ -400,6 +702,9 @@ func (m *monitor) syncLoop() {
 			var mir mirror
 			var mirrorPtr *mirror
 			var ok bool
+			var trace *scan.TraceState
+			var subtrees []string
+			var unchanged bool
 
 			m.mapLock.Lock()
 			if mirrorPtr, ok = m.mirrors[id]; !ok {
@@ -426,31 +731,25 @@ func (m *monitor) syncLoop() {
 
 			log.Debugf("Scanning %s", mir.Name)
 
-			// Start fetching the latest trace
-			go func() {
-				err := m.trace.GetLastUpdate(mir.Mirror)
-				if err != nil && err != scan.ErrNoTrace {
-					if numError, ok := err.(*strconv.NumError); ok {
-						if numError.Err == strconv.ErrSyntax {
-							log.Warningf("[%s] parsing trace file failed: %s is not a valid timestamp", mir.Name, strconv.Quote(numError.Num))
-							return
-						}
-					} else {
-						log.Warningf("[%s] fetching trace file failed: %s", mir.Name, err)
-					}
+			trace, subtrees, unchanged = m.scanScope(mir)
+			if unchanged {
+				log.Infof("[%s] Unchanged since the last scan according to its trace file", mir.Name)
+				if err := scan.SetUnchanged(m.redis, id); err != nil {
+					log.Errorf("[%s] Unable to record the sync: %s", mir.Name, err)
 				}
-			}()
+				goto end
+			}
 
 			err = scan.ErrNoSyncMethod
 
 			// First try to scan with rsync
 			if mir.RsyncURL != "" {
-				_, err = scan.Scan(core.RSYNC, m.redis, m.cache, mir.RsyncURL, id, m.stop)
+				_, err = scan.ScanSubtrees(core.RSYNC, m.redis, m.cache, mir.RsyncURL, id, subtrees, m.stop)
 			}
 			// If it failed or rsync wasn't supported
 			// fallback to FTP
 			if err != nil && err != scan.ErrScanAborted && mir.FtpURL != "" {
-				_, err = scan.Scan(core.FTP, m.redis, m.cache, mir.FtpURL, id, m.stop)
+				_, err = scan.ScanSubtrees(core.FTP, m.redis, m.cache, mir.FtpURL, id, subtrees, m.stop)
 			}
 
 			if err == scan.ErrScanInProgress {
@@ -458,6 +757,21 @@ func (m *monitor) syncLoop() {
 				goto end
 			}
 
+			if err == scan.ErrRemovalHeld {
+				log.Warningf("[%s] Removal of files held, run 'mirrorbits scan -force %s' to apply the changes", mir.Name, mir.Name)
+				goto end
+			}
+
+			if err == nil && trace != nil {
+				if err := m.trace.SetSyncedTrace(id, trace); err != nil {
+					log.Errorf("[%s] Unable to record the trace: %s", mir.Name, err)
+				}
+			}
+
+			if err == nil {
+				m.queueIntegrityCheck(id)
+			}
+
 			if err == nil && mir.Enabled == true && mir.Up == false {
 				m.healthCheckChan <- id
 			}
@@ -466,37 +780,144 @@ func (m *monitor) syncLoop() {
 			m.mapLock.Lock()
 			if mirrorPtr, ok = m.mirrors[id]; ok {
 				mirrorPtr.scanning = false
+				mirrorPtr.rescan = false
+				mirrorPtr.rescanDir = ""
 			}
 			m.mapLock.Unlock()
 		}
 	}
 }
 
-// Do an actual health check against a given mirror
-func (m *monitor) healthCheck(mirror mirrors.Mirror) error {
+// fetchTrace gets the latest trace file of the mirror
+func (m *monitor) fetchTrace(mirror mirrors.Mirror) *scan.TraceState {
+	trace, err := m.trace.GetLastUpdate(mirror)
+	if err != nil && err != scan.ErrNoTrace {
+		if numError, ok := err.(*strconv.NumError); ok {
+			if numError.Err == strconv.ErrSyntax {
+				log.Warningf("[%s] parsing trace file failed: %s is not a valid timestamp", mirror.Name, strconv.Quote(numError.Num))
+			}
+		} else {
+			log.Warningf("[%s] fetching trace file failed: %s", mirror.Name, err)
+		}
+	}
+	return trace
+}
+
+// traceChanges compares the latest trace file of the mirror with the one of
+// its last successful scan and returns the latest trace along with the
+// subtrees to scan, nil meaning the whole mirror
+// requestRescan schedules the scan of the given prefix of a mirror, the
+// whole mirror being scanned for the root of the repository
+func (m *monitor) requestRescan(id int, prefix string) {
+	m.mapLock.Lock()
+	defer m.mapLock.Unlock()
+
+	mir, ok := m.mirrors[id]
+	if !ok || !mir.Enabled || mir.IsScanning() {
+		return
+	}
+	select {
+	case m.syncChan <- id:
+		mir.scanning = true
+		if dir := strings.TrimRight(prefix, "/"); dir == "" {
+			mir.rescan = true
+		} else {
+			mir.rescanDir = dir
+		}
+		log.Noticef("[%s] Too many files not found under %s, rescanning", mir.Name, prefix)
+	default:
+	}
+}
+
+// scanScope returns the subtrees of the mirror to scan, nil for the whole
+// mirror, along with the trace file to record once the scan succeeds
+func (m *monitor) scanScope(mir mirror) (trace *scan.TraceState, subtrees []string, unchanged bool) {
+	if mir.rescanDir != "" {
+		// Only the subtree where files are missing
+		return nil, []string{mir.rescanDir}, false
+	}
+	if GetConfig().IncrementalScans && !mir.rescan {
+		// Only scan what changed since the last scan
+		return m.traceChanges(mir.Mirror)
+	}
+	// Start fetching the latest trace
+	go m.fetchTrace(mir.Mirror)
+	return nil, nil, false
+}
+
+func (m *monitor) traceChanges(mirror mirrors.Mirror) (trace *scan.TraceState, subtrees []string, unchanged bool) {
+	trace = m.fetchTrace(mirror)
+	if trace == nil {
+		return nil, nil, false
+	}
+
+	previous, err := m.trace.SyncedTrace(mirror.ID)
+	if err != nil {
+		log.Warningf("[%s] Unable to get the trace of the last scan: %s", mirror.Name, err)
+		return trace, nil, false
+	}
+
+	subtrees, unchanged = trace.ChangedSubtrees(previous)
+	return trace, subtrees, unchanged
+}
+
+// Do an actual health check against a given mirror and return its result,
+// the mirror being up if the check succeeded. A mirror held down (flapping)
+// is kept down even if the check succeeds.
+func (m *monitor) healthCheck(mirror mirrors.Mirror, heldDown bool) (CheckResult, error) {
 	// Format log output
 	format := "%-" + fmt.Sprintf("%d.%ds", m.formatLongestID+4, m.formatLongestID+4)
 
-	// Get the URL to a random file available on this mirror
-	file, size, err := m.getRandomFile(mirror.ID)
-	if err != nil {
-		if err == redis.ErrNil {
-			return errMirrorNotScanned
-		} else if !database.RedisIsLoading(err) {
-			log.Warningf(format+"Error: Cannot obtain a random file: %s", mirror.Name, err)
+	// Use the health-check path of the mirror if any, or
+	// get the URL to a random file available on this mirror
+	file, size := mirror.HealthCheckPath, int64(-1)
+	if file == "" {
+		var err error
+		file, size, err = m.getRandomFile(mirror.ID)
+		if err != nil {
+			if err == redis.ErrNil {
+				return CheckResult{}, errMirrorNotScanned
+			} else if !database.RedisIsLoading(err) {
+				log.Warningf(format+"Error: Cannot obtain a random file: %s", mirror.Name, err)
+			}
+			return CheckResult{}, err
 		}
-		return err
 	}
 
+	res := CheckResult{
+		MirrorID: mirror.ID,
+		File:     file,
+	}
+
+	accepted := mirror.HealthCheckStatusCodes()
+	acceptRedirects := false
+	for _, code := range accepted {
+		if code >= 300 && code < 400 {
+			acceptRedirects = true
+		}
+	}
+
+	// The alternative URLs are checked in the background once the result
+	// of the main URL is recorded
+	defer func() {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.checkAltURLs(mirror, file, accepted, acceptRedirects)
+		}()
+	}()
+
 	// Prepare the HTTP request
 	req, err := http.NewRequest("HEAD", strings.TrimRight(mirror.HttpURL, "/")+file, nil)
 	req.Header.Set("User-Agent", userAgent)
+	req.Host = mirror.HostHeader
 	req.Close = true
 
 	ctx, cancel := context.WithTimeout(req.Context(), clientDeadline)
 	ctx = context.WithValue(ctx, core.ContextMirrorID, mirror.ID)
 	ctx = context.WithValue(ctx, core.ContextMirrorName, mirror.Name)
 	ctx = context.WithValue(ctx, core.ContextAllowRedirects, mirror.AllowRedirects)
+	ctx = context.WithValue(ctx, core.ContextAcceptRedirects, acceptRedirects)
 	req = req.WithContext(ctx)
 	defer cancel()
 
@@ -522,54 +943,250 @@ func (m *monitor) healthCheck(mirror mirrors.Mirror) error {
 	})
 
 	if utils.IsStopped(m.stop) {
-		return nil
+		return res, context.Canceled
 	}
 
+	res.StatusCode = statusCode
+	res.Latency = elapsed
+
+	// Failures during maintenance are expected: they neither bring
+	// the mirror down nor disable it
+	maintenance := mirror.InMaintenance(time.Now())
+
 	if err != nil {
+		res.Error = err.Error()
 		if opErr, ok := err.(*net.OpError); ok {
 			log.Debugf("Op: %s | Net: %s | Addr: %s | Err: %s | Temporary: %t", opErr.Op, opErr.Net, opErr.Addr, opErr.Error(), opErr.Temporary())
 		}
+		if maintenance {
+			log.Noticef(format+"Unreachable during maintenance: %s", mirror.Name, err.Error())
+			return res, nil
+		}
 		if strings.Contains(err.Error(), errRedirect.Error()) {
-			mirrors.MarkMirrorDown(m.redis, mirror.ID, "Unauthorized redirect")
+			m.setMirrorState(mirror.ID, false, "Unauthorized redirect")
 		} else {
-			mirrors.MarkMirrorDown(m.redis, mirror.ID, "Unreachable")
+			m.setMirrorState(mirror.ID, false, "Unreachable")
 		}
 		log.Errorf(format+"Error: %s (%dms)", mirror.Name, err.Error(), elapsed/time.Millisecond)
-		return err
+		return res, nil
 	}
 
-	switch statusCode {
-	case 200:
-		err = mirrors.MarkMirrorUp(m.redis, mirror.ID)
+	switch {
+	case utils.IsInIntSlice(statusCode, accepted):
+		if mirror.IntegrityFailed {
+			// Keep the mirror down until it passes the integrity check again
+			if !strings.HasPrefix(mirror.ExcludeReason, mirrors.IntegrityFailedReason) {
+				m.setMirrorState(mirror.ID, false, mirrors.IntegrityFailedReason)
+			}
+			log.Warningf(format+"Reachable but serving corrupted content", mirror.Name)
+			break
+		}
+		if heldDown {
+			// Wait for enough consecutive successes before bringing it back
+			err = m.setMirrorState(mirror.ID, false, "Flapping")
+			if err != nil {
+				log.Errorf(format+"Unable to mark mirror as down: %s", mirror.Name, err)
+			}
+			log.Warningf(format+"Up but held down, the mirror is flapping (%dms)", mirror.Name, elapsed/time.Millisecond)
+			res.Up = true
+			return res, nil
+		}
+		err = mirrors.RecordLatency(m.redis, mirror.ID, GetConfig().Latency.Continent, elapsed)
+		if err != nil {
+			log.Errorf(format+"Unable to record the latency: %s", mirror.Name, err)
+		}
+		err = m.setMirrorState(mirror.ID, true, "")
 		if err != nil {
 			log.Errorf(format+"Unable to mark mirror as up: %s", mirror.Name, err)
 		}
 		rsize, err := strconv.ParseInt(contentLength, 10, 64)
-		if err == nil && rsize != size {
+		if err == nil && size >= 0 && rsize != size {
 			log.Warningf(format+"File size mismatch! [%s] (%dms)", mirror.Name, file, elapsed/time.Millisecond)
 		} else {
 			log.Noticef(format+"Up! (%dms)", mirror.Name, elapsed/time.Millisecond)
 		}
-	case 404:
-		err = mirrors.MarkMirrorDown(m.redis, mirror.ID, fmt.Sprintf("File not found %s (error 404)", file))
+		res.Up = true
+		return res, nil
+	case maintenance:
+		log.Noticef(format+"Down during maintenance! Status: %d", mirror.Name, statusCode)
+	case statusCode == 404:
+		err = m.setMirrorState(mirror.ID, false, fmt.Sprintf("File not found %s (error 404)", file))
 		if err != nil {
 			log.Errorf(format+"Unable to mark mirror as down: %s", mirror.Name, err)
 		}
-		if GetConfig().DisableOnMissingFile {
-			err = mirrors.DisableMirror(m.redis, mirror.ID)
+		if GetConfig().DisableOnMissingFile && mirror.HealthCheckPath == "" {
+			err = mirrors.DisableMirrorUntil(m.redis, mirror.ID, fmt.Sprintf("File not found %s", file), time.Time{})
 			if err != nil {
 				log.Errorf(format+"Unable to disable mirror: %s", mirror.Name, err)
 			}
 		}
+		if err = mirrors.ReportNotFound(m.redis, mirror.ID, file, mirrors.NotFoundHealthCheck); err != nil {
+			log.Errorf(format+"Unable to record the missing file: %s", mirror.Name, err)
+		}
 		log.Errorf(format+"Error: File %s not found (error 404)", mirror.Name, file)
 	default:
-		err = mirrors.MarkMirrorDown(m.redis, mirror.ID, fmt.Sprintf("Got status code %d", statusCode))
+		err = m.setMirrorState(mirror.ID, false, fmt.Sprintf("Got status code %d", statusCode))
 		if err != nil {
 			log.Errorf(format+"Unable to mark mirror as down: %s", mirror.Name, err)
 		}
 		log.Warningf(format+"Down! Status: %d", mirror.Name, statusCode)
 	}
-	return nil
+	return res, nil
+}
+
+// checkAltURLs checks concurrently the alternative URLs of the mirror with
+// the file used to check its main URL, the URLs failing being excluded from
+// the selection. All the checks are bound by clientDeadline.
+func (m *monitor) checkAltURLs(mirror mirrors.Mirror, file string, accepted []int, acceptRedirects bool) {
+	if len(mirror.AltURLs) == 0 && mirror.AltURLsDown == "" {
+		return
+	}
+
+	// Format log output
+	format := "%-" + fmt.Sprintf("%d.%ds", m.formatLongestID+4, m.formatLongestID+4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), clientDeadline)
+	defer cancel()
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	up := make([]bool, len(mirror.AltURLs))
+	var wg sync.WaitGroup
+	for i, alt := range mirror.AltURLs {
+		wg.Add(1)
+		go func(i int, altURL string) {
+			defer wg.Done()
+			up[i] = m.checkAltURL(ctx, mirror, altURL, file, accepted, acceptRedirects, format)
+		}(i, alt.URL)
+	}
+	wg.Wait()
+
+	if utils.IsStopped(m.stop) {
+		return
+	}
+	var down []string
+	for i, alt := range mirror.AltURLs {
+		if !up[i] {
+			down = append(down, alt.URL)
+		}
+	}
+	if strings.Join(down, " ") != mirror.AltURLsDown {
+		if err := mirrors.SetAltURLsDown(m.redis, mirror.ID, down); err != nil {
+			log.Errorf(format+"Unable to record the state of the alternative URLs: %s", mirror.Name, err)
+		}
+	}
+}
+
+// checkAltURL returns true if the file is served by the given alternative
+// URL of the mirror
+func (m *monitor) checkAltURL(ctx context.Context, mirror mirrors.Mirror, altURL, file string, accepted []int, acceptRedirects bool, format string) bool {
+	req, err := http.NewRequest("HEAD", strings.TrimRight(altURL, "/")+file, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Close = true
+
+	ctx = context.WithValue(ctx, core.ContextMirrorID, mirror.ID)
+	ctx = context.WithValue(ctx, core.ContextMirrorName, mirror.Name)
+	ctx = context.WithValue(ctx, core.ContextAllowRedirects, mirror.AllowRedirects)
+	ctx = context.WithValue(ctx, core.ContextAcceptRedirects, acceptRedirects)
+
+	var statusCode int
+	elapsed, err := m.httpDo(ctx, req.WithContext(ctx), func(resp *http.Response, err error) error {
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		statusCode = resp.StatusCode
+		return nil
+	})
+
+	if err != nil {
+		log.Warningf(format+"Alternative URL %s unreachable: %s", mirror.Name, altURL, err.Error())
+		return false
+	} else if !utils.IsInIntSlice(statusCode, accepted) {
+		log.Warningf(format+"Alternative URL %s down! Status: %d", mirror.Name, altURL, statusCode)
+		return false
+	}
+	log.Debugf(format+"Alternative URL %s up (%dms)", mirror.Name, altURL, elapsed/time.Millisecond)
+	return true
+}
+
+// CheckMirror immediately checks the health of the given mirror and
+// returns the result of the check
+func (m *monitor) CheckMirror(id int) (CheckResult, error) {
+	m.mapLock.Lock()
+	mptr, ok := m.mirrors[id]
+	if !ok {
+		m.mapLock.Unlock()
+		return CheckResult{}, ErrUnknownMirror
+	}
+	// Prevent the health check loop from checking it at the same time
+	alreadyChecking := mptr.checking
+	mptr.checking = true
+	mirror := *mptr
+	m.mapLock.Unlock()
+
+	res, err := m.healthCheck(mirror.Mirror, mirror.isHeldDown())
+
+	m.mapLock.Lock()
+	if mptr, ok := m.mirrors[id]; ok {
+		if err == nil {
+			mptr.lastCheck = time.Now().UTC()
+			mptr.recordHealthCheck(res.Up, time.Now())
+		}
+		if !alreadyChecking {
+			mptr.checking = false
+		}
+	}
+	m.mapLock.Unlock()
+
+	return res, err
+}
+
+// ClusterStatus returns the nodes of the cluster along with the mirrors they
+// handle. The list is empty if the local node doesn't take part in the cluster.
+func (m *monitor) ClusterStatus() []NodeStatus {
+	return m.cluster.Status()
+}
+
+// setMirrorState records the state of a mirror. When the health checks
+// are made from several nodes, the state is the one agreed by a quorum
+// of them so a network issue on a single node doesn't affect the mirror.
+func (m *monitor) setMirrorState(id int, up bool, reason string) error {
+	quorum := GetConfig().HealthCheckQuorum
+	if quorum <= 0 {
+		return mirrors.SetMirrorState(m.redis, id, up, reason)
+	}
+
+	results, err := mirrors.RecordNodeHealth(m.redis, id, mirrors.NodeHealth{
+		Node:   m.cluster.nodeID,
+		Up:     up,
+		Reason: reason,
+		Time:   time.Now().UTC(),
+	}, nodeHealthMaxAge())
+	if err != nil {
+		return err
+	}
+
+	up, reason = mirrors.MergeNodeHealth(results, quorum)
+	return mirrors.SetMirrorState(m.redis, id, up, reason)
+}
+
+// nodeHealthMaxAge returns the duration after which the result of a health
+// check made by another node is not considered anymore
+func nodeHealthMaxAge() time.Duration {
+	interval := GetConfig().CheckInterval
+	if s := GetConfig().HealthCheckScheduling; s.Adaptive && s.MaxInterval > interval {
+		interval = s.MaxInterval
+	}
+	return 3 * time.Duration(interval) * time.Minute
 }
 
 func (m *monitor) httpDo(ctx context.Context, req *http.Request, f func(*http.Response, error) error) (time.Duration, error) {
@@ -593,26 +1210,56 @@ func (m *monitor) httpDo(ctx context.Context, req *http.Request, f func(*http.Re
 	}
 }
 
-// Get a random filename known to be served by the given mirror
+// Get a random filename known to be served by the given mirror,
+// preferring the files which are not empty
 func (m *monitor) getRandomFile(id int) (file string, size int64, err error) {
 	sinterKey := fmt.Sprintf("HANDLEDFILES_%d", id)
 
 	rconn := m.redis.Get()
 	defer rconn.Close()
 
-	file, err = redis.String(rconn.Do("SRANDMEMBER", sinterKey))
+	files, err := redis.Strings(rconn.Do("SRANDMEMBER", sinterKey, healthCheckCandidates))
 	if err != nil {
 		return
 	}
-
-	size, err = redis.Int64(rconn.Do("HGET", fmt.Sprintf("FILE_%s", file), "size"))
-	if err != nil {
+	if len(files) == 0 {
+		err = redis.ErrNil
 		return
 	}
 
+	for i, f := range files {
+		var s int64
+		s, err = getFileSize(rconn, f)
+		if err != nil {
+			return
+		}
+		// Fall back on the first file if all of them are empty
+		if s > 0 || i == 0 {
+			file, size = f, s
+		}
+		if s > 0 {
+			break
+		}
+	}
 	return
 }
 
+// Get the size of a file of the local repository
+func getFileSize(rconn redis.Conn, file string) (int64, error) {
+	reply, err := redis.Strings(rconn.Do("HMGET", fmt.Sprintf("FILE_%s", file), "size", "packed"))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(reply[1]) > 0 {
+		var f filesystem.FileInfo
+		err = filesystem.UnpackFileInfo([]byte(reply[1]), &f)
+		return f.Size, err
+	}
+
+	return strconv.ParseInt(reply[0], 10, 64)
+}
+
 // Trigger a sync of the local repository
 func (m *monitor) scanRepository() error {
 	err := scan.ScanSource(m.redis, false, m.stop)
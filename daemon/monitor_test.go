@@ -0,0 +1,331 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/mirrors"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+// setSchedulingConfig replaces the configuration and returns
+// a function restoring the previous one
+func setSchedulingConfig(s Configuration) func() {
+	previous := *GetConfig()
+	SetConfiguration(&s)
+	return func() {
+		SetConfiguration(&previous)
+	}
+}
+
+func TestMirror_checkInterval(t *testing.T) {
+	c := Configuration{CheckInterval: 1}
+	c.HealthCheckScheduling.MaxInterval = 5
+	c.HealthCheckScheduling.RetryInterval = 10
+	defer setSchedulingConfig(c)()
+
+	m := &mirror{successes: 10}
+	if d := m.checkInterval(); d != time.Minute {
+		t.Fatalf("Expected the fixed interval when not adaptive, got %s", d)
+	}
+
+	c.HealthCheckScheduling.Adaptive = true
+	SetConfiguration(&c)
+
+	m = &mirror{}
+	if d := m.checkInterval(); d != time.Minute {
+		t.Fatalf("Expected 1m, got %s", d)
+	}
+	m.successes = 2
+	if d := m.checkInterval(); d != 3*time.Minute {
+		t.Fatalf("Expected 3m, got %s", d)
+	}
+	m.successes = 100
+	if d := m.checkInterval(); d != 5*time.Minute {
+		t.Fatalf("Expected the interval to be capped to 5m, got %s", d)
+	}
+
+	m = &mirror{failures: 1}
+	if d := m.checkInterval(); d != 10*time.Second {
+		t.Fatalf("Expected 10s, got %s", d)
+	}
+	m.failures = 3
+	if d := m.checkInterval(); d != 40*time.Second {
+		t.Fatalf("Expected 40s, got %s", d)
+	}
+	m.failures = 10
+	if d := m.checkInterval(); d != time.Minute {
+		t.Fatalf("Expected the backoff to be capped to 1m, got %s", d)
+	}
+}
+
+func TestMirror_NeedSync(t *testing.T) {
+	defer setSchedulingConfig(Configuration{ScanInterval: 30})()
+
+	m := &mirror{}
+	m.LastSync = m.LastSync.FromTime(time.Now().Add(-time.Hour))
+	if !m.NeedSync() {
+		t.Fatalf("Expected a sync after the global interval")
+	}
+
+	m.ScanInterval = 120
+	if m.NeedSync() {
+		t.Fatalf("Expected the interval of the mirror to override the global one")
+	}
+
+	m.ScanInterval = 10
+	m.LastSync = m.LastSync.FromTime(time.Now().Add(-15 * time.Minute))
+	if !m.NeedSync() {
+		t.Fatalf("Expected a sync after the interval of the mirror")
+	}
+}
+
+func TestMirror_NeedRecoveryScan(t *testing.T) {
+	defer setSchedulingConfig(Configuration{ScanInterval: 30, RescanOnRecovery: true})()
+
+	now := time.Now()
+	m := &mirror{}
+	m.StateSince = mirrors.Time{}.FromTime(now.Add(-time.Hour))
+	if !m.NeedRecoveryScan(now) {
+		t.Fatalf("Expected a rescan after an outage longer than the scan interval")
+	}
+
+	m.StateSince = mirrors.Time{}.FromTime(now.Add(-10 * time.Minute))
+	if m.NeedRecoveryScan(now) {
+		t.Fatalf("Expected no rescan after a short outage")
+	}
+
+	m.ScanInterval = 5
+	if !m.NeedRecoveryScan(now) {
+		t.Fatalf("Expected the scan interval of the mirror to be used")
+	}
+
+	m.Up = true
+	if m.NeedRecoveryScan(now) {
+		t.Fatalf("Expected no rescan for a mirror already up")
+	}
+
+	m.Up = false
+	m.StateSince = mirrors.Time{}
+	if m.NeedRecoveryScan(now) {
+		t.Fatalf("Expected no rescan for a mirror never checked")
+	}
+
+	SetConfiguration(&Configuration{ScanInterval: 30})
+	m.StateSince = mirrors.Time{}.FromTime(now.Add(-time.Hour))
+	if m.NeedRecoveryScan(now) {
+		t.Fatalf("Expected no rescan when disabled")
+	}
+}
+
+func TestMirror_recordHealthCheck(t *testing.T) {
+	c := Configuration{CheckInterval: 1}
+	c.HealthCheckScheduling.FlappingChanges = 3
+	c.HealthCheckScheduling.FlappingWindow = 30
+	c.HealthCheckScheduling.RecoverySuccesses = 3
+	defer setSchedulingConfig(c)()
+
+	now := time.Now()
+	m := &mirror{}
+
+	m.recordHealthCheck(true, now)
+	m.recordHealthCheck(false, now)
+	m.recordHealthCheck(true, now)
+	if m.flapping {
+		t.Fatalf("Mirror is not supposed to be flapping yet")
+	}
+	if m.successes != 1 || m.failures != 0 {
+		t.Fatalf("Unexpected counters: %d successes, %d failures", m.successes, m.failures)
+	}
+
+	m.recordHealthCheck(false, now)
+	if !m.flapping {
+		t.Fatalf("Mirror is supposed to be flapping")
+	}
+	if !m.isHeldDown() {
+		t.Fatalf("Mirror is supposed to be held down")
+	}
+
+	m.recordHealthCheck(true, now)
+	if !m.isHeldDown() {
+		t.Fatalf("Mirror is supposed to be held down")
+	}
+	m.recordHealthCheck(true, now)
+	if m.isHeldDown() {
+		t.Fatalf("The next success is supposed to bring the mirror up")
+	}
+	m.recordHealthCheck(true, now)
+	if m.flapping {
+		t.Fatalf("Mirror is not supposed to be flapping anymore")
+	}
+
+	// State changes outside of the window are forgotten
+	m = &mirror{}
+	m.recordHealthCheck(true, now.Add(-2*time.Hour))
+	m.recordHealthCheck(false, now.Add(-2*time.Hour))
+	m.recordHealthCheck(true, now.Add(-time.Hour))
+	m.recordHealthCheck(false, now)
+	if m.flapping {
+		t.Fatalf("Mirror is not supposed to be flapping")
+	}
+}
+
+func TestMonitor_CheckMirrorUnknown(t *testing.T) {
+	m := &monitor{mirrors: make(map[int]*mirror)}
+
+	if _, err := m.CheckMirror(42); err != ErrUnknownMirror {
+		t.Fatalf("Expected ErrUnknownMirror, got %v", err)
+	}
+}
+
+func TestMonitor_runTask(t *testing.T) {
+	m := &monitor{}
+	var running int32
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	task := func() {
+		started <- struct{}{}
+		<-release
+	}
+
+	// The caller isn't blocked by the task
+	m.runTask(&running, task)
+	<-started
+
+	// A task still in progress isn't started twice
+	m.runTask(&running, task)
+	close(release)
+	m.wg.Wait()
+	if len(started) != 0 {
+		t.Fatalf("Expected the task to be skipped while in progress")
+	}
+
+	m.runTask(&running, func() { started <- struct{}{} })
+	m.wg.Wait()
+	if len(started) != 1 {
+		t.Fatalf("Expected the task to run again once completed")
+	}
+}
+
+func TestMonitor_requestRescan(t *testing.T) {
+	m := &monitor{
+		mirrors:  make(map[int]*mirror),
+		syncChan: make(chan int, 1),
+	}
+	m.mirrors[1] = &mirror{Mirror: mirrors.Mirror{ID: 1, Name: "m1", Enabled: true}}
+	m.mirrors[2] = &mirror{Mirror: mirrors.Mirror{ID: 2, Name: "m2"}}
+
+	// Disabled or unknown mirrors are not rescanned
+	m.requestRescan(2, "/debian/pool")
+	m.requestRescan(3, "/debian/pool")
+	if len(m.syncChan) != 0 {
+		t.Fatalf("Expected no scan to be scheduled")
+	}
+
+	// Only the prefix is rescanned
+	m.requestRescan(1, "/debian/pool")
+	if id := <-m.syncChan; id != 1 {
+		t.Fatalf("Expected the mirror to be scheduled, got #%d", id)
+	}
+	mir := *m.mirrors[1]
+	if !mir.scanning || mir.rescan {
+		t.Fatalf("Expected a partial scan in progress")
+	}
+	trace, subtrees, unchanged := m.scanScope(mir)
+	if trace != nil || unchanged || len(subtrees) != 1 || subtrees[0] != "/debian/pool" {
+		t.Fatalf("Expected the scan to be scoped to the prefix, got %v", subtrees)
+	}
+
+	// A scan in progress isn't requested twice
+	m.requestRescan(1, "/debian/dists")
+	if len(m.syncChan) != 0 || m.mirrors[1].rescanDir != "/debian/pool" {
+		t.Fatalf("Expected no scan to be scheduled")
+	}
+
+	// The files at the root of the repository need a full scan
+	m.mirrors[1].scanning = false
+	m.mirrors[1].rescanDir = ""
+	m.requestRescan(1, "/")
+	<-m.syncChan
+	if !m.mirrors[1].rescan || m.mirrors[1].rescanDir != "" {
+		t.Fatalf("Expected a full scan")
+	}
+}
+
+func TestMonitor_getRandomFile(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+	m := &monitor{redis: conn}
+
+	mock.Command("SRANDMEMBER", "HANDLEDFILES_1", healthCheckCandidates).Expect([]interface{}{
+		[]byte("/empty"),
+		[]byte("/file"),
+	})
+	mock.Command("HMGET", "FILE_/empty", "size", "packed").Expect([]interface{}{[]byte("0"), nil})
+	mock.Command("HMGET", "FILE_/file", "size", "packed").Expect([]interface{}{[]byte("1024"), nil})
+
+	file, size, err := m.getRandomFile(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if file != "/file" || size != 1024 {
+		t.Fatalf("Expected the empty file to be skipped, got %s (%d bytes)", file, size)
+	}
+
+	// Use an empty file if there is nothing else
+	mock.Command("SRANDMEMBER", "HANDLEDFILES_2", healthCheckCandidates).Expect([]interface{}{
+		[]byte("/empty"),
+	})
+
+	file, size, err = m.getRandomFile(2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if file != "/empty" || size != 0 {
+		t.Fatalf("Expected the empty file, got %s (%d bytes)", file, size)
+	}
+}
+
+func TestMonitor_checkAltURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/up/file" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	mock, conn := PrepareRedisTest()
+	m := &monitor{redis: conn, stop: make(chan struct{})}
+	m.httpClient.Transport = &m.httpTransport
+
+	mirror := mirrors.Mirror{
+		ID:   1,
+		Name: "m1",
+		AltURLs: mirrors.AltURLs{
+			{URL: server.URL + "/up/"},
+			{URL: server.URL + "/notfound/"},
+			{URL: "http://127.0.0.1:1/"},
+		},
+	}
+	down := server.URL + "/notfound/ http://127.0.0.1:1/"
+	cmdDown := mock.Command("HSET", "MIRROR_1", "altURLsDown", down).Expect(int64(1))
+	mock.GenericCommand("PUBLISH").Expect(int64(0))
+
+	m.checkAltURLs(mirror, "/file", []int{200}, false)
+	if mock.Stats(cmdDown) != 1 {
+		t.Fatalf("Expected the failing URLs to be recorded: %v", mock.Errors)
+	}
+
+	// Unchanged
+	mirror.AltURLsDown = down
+	m.checkAltURLs(mirror, "/file", []int{200}, false)
+	if mock.Stats(cmdDown) != 1 {
+		t.Fatalf("Expected the state to be kept")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+// snapshotRetryInterval is the delay before the first snapshot, letting
+// the database checks complete, and between two failed snapshots
+var snapshotRetryInterval = time.Minute
+
+// snapshotLoop periodically saves the snapshot of the database served
+// when it is unreachable at start
+func (m *monitor) snapshotLoop() {
+	defer m.wg.Done()
+
+	delay := snapshotRetryInterval
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		delay = snapshotRetryInterval
+		cfg := GetConfig().Snapshot
+		if cfg.Path == "" || m.redis.Failure() {
+			continue
+		}
+
+		start := time.Now()
+		s, err := mirrors.NewSnapshot(m.redis)
+		if err == nil {
+			err = s.Save(cfg.Path)
+		}
+		if err != nil {
+			log.Errorf("Saving the snapshot failed: %s", err)
+			continue
+		}
+		log.Debugf("Snapshot of %d mirrors and %d files saved in %s", len(s.Mirrors), len(s.Files), time.Since(start))
+		delay = time.Duration(cfg.Interval) * time.Minute
+	}
+}
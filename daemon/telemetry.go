@@ -0,0 +1,129 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/gomodule/redigo/redis"
+)
+
+// TelemetryReport holds the anonymous usage aggregates of a deployment
+type TelemetryReport struct {
+	Instance  string // Random identifier of the deployment
+	Version   string
+	Date      string // Day of the downloads
+	Mirrors   int
+	Enabled   int
+	Up        int
+	Downloads int64
+}
+
+// sendTelemetry sends the usage report to the configured endpoint if the
+// last one was sent more than the configured interval ago
+func (m *monitor) sendTelemetry() error {
+	cfg := GetConfig().Telemetry
+	if cfg.URL == "" {
+		return nil
+	}
+
+	conn := m.redis.Get()
+	defer conn.Close()
+
+	last, err := redis.Int64(conn.Do("HGET", "TELEMETRY", "lastReport"))
+	if err != nil && err != redis.ErrNil {
+		return err
+	}
+	if time.Since(time.Unix(last, 0)) < time.Duration(cfg.Interval)*time.Hour {
+		return nil
+	}
+
+	instance, err := telemetryInstance(conn)
+	if err != nil {
+		return err
+	}
+
+	report, err := m.telemetryReport(conn, instance, time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: clientTimeout}
+	req, err := http.NewRequest("POST", cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mirrorbits/"+core.VERSION)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	_, err = conn.Do("HSET", "TELEMETRY", "lastReport", strconv.FormatInt(time.Now().Unix(), 10))
+	return err
+}
+
+// telemetryInstance returns the random identifier of the deployment,
+// shared by all the nodes of the cluster, generating it on first use
+func telemetryInstance(conn redis.Conn) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	if _, err := conn.Do("HSETNX", "TELEMETRY", "instance", hex.EncodeToString(b)); err != nil {
+		return "", err
+	}
+	return redis.String(conn.Do("HGET", "TELEMETRY", "instance"))
+}
+
+// telemetryReport builds the usage report of the deployment, with the
+// downloads of the given day
+func (m *monitor) telemetryReport(conn redis.Conn, instance string, day time.Time) (*TelemetryReport, error) {
+	report := &TelemetryReport{
+		Instance: instance,
+		Version:  core.VERSION,
+		Date:     day.Format("2006-01-02"),
+	}
+
+	m.mapLock.Lock()
+	for _, mir := range m.mirrors {
+		report.Mirrors++
+		if mir.Enabled {
+			report.Enabled++
+			if mir.Up {
+				report.Up++
+			}
+		}
+	}
+	m.mapLock.Unlock()
+
+	values, err := redis.Int64s(conn.Do("HVALS", "STATS_MIRROR_"+day.Format("2006_01_02")))
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		report.Downloads += v
+	}
+	return report, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/mirrors"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestMonitor_sendTelemetry(t *testing.T) {
+	var received []TelemetryReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report TelemetryReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("Invalid report: %s", err)
+		}
+		received = append(received, report)
+	}))
+	defer server.Close()
+
+	c := *GetConfig()
+	c.Telemetry.URL = server.URL
+	c.Telemetry.Interval = 24
+	defer setSchedulingConfig(c)()
+
+	mock, conn := PrepareRedisTest()
+	m := &monitor{
+		redis: conn,
+		mirrors: map[int]*mirror{
+			1: {Mirror: mirrors.Mirror{ID: 1, Enabled: true, Up: true}},
+			2: {Mirror: mirrors.Mirror{ID: 2, Enabled: true}},
+			3: {Mirror: mirrors.Mirror{ID: 3}},
+		},
+	}
+
+	// Sent recently
+	mock.Command("HGET", "TELEMETRY", "lastReport").Expect(time.Now().Add(-time.Hour).Unix())
+	if err := m.sendTelemetry(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("Expected no report before the interval")
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	mock.Command("HGET", "TELEMETRY", "lastReport").Expect(nil)
+	mock.GenericCommand("HSETNX").Expect(int64(1))
+	mock.Command("HGET", "TELEMETRY", "instance").Expect("abcd")
+	mock.Command("HVALS", "STATS_MIRROR_"+yesterday.Format("2006_01_02")).Expect([]interface{}{[]byte("10"), []byte("32")})
+	setLast := mock.GenericCommand("HSET").Expect(int64(1))
+
+	if err := m.sendTelemetry(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("Expected one report, got %d", len(received))
+	}
+
+	expected := TelemetryReport{
+		Instance:  "abcd",
+		Version:   core.VERSION,
+		Date:      yesterday.Format("2006-01-02"),
+		Mirrors:   3,
+		Enabled:   2,
+		Up:        1,
+		Downloads: 42,
+	}
+	if received[0] != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, received[0])
+	}
+	if mock.Stats(setLast) != 1 {
+		t.Fatalf("Expected the time of the report to be recorded")
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// restoreBatchSize is the number of keys restored per pipeline
+const restoreBatchSize = 100
+
+// DumpRecord is the content of a key of the database
+type DumpRecord struct {
+	Key    string
+	Type   string   // string, hash, list, set or zset
+	Values [][]byte // Field/value pairs of a hash, member/score pairs of a sorted set
+	TTL    int64    // Time to live in milliseconds, 0 if the key doesn't expire
+}
+
+var (
+	// ErrDatabaseNotEmpty is returned when restoring a dump over existing mirrors or files
+	ErrDatabaseNotEmpty = errors.New("the database already contains mirrors or files")
+)
+
+// isRuntimeKey returns true if the given key only makes sense for the
+// running instances (locks, leadership, scans in progress) and must not
+// be dumped
+func isRuntimeKey(key string) bool {
+	return key == "LEADER" ||
+		strings.HasPrefix(key, "LOCK_") ||
+		strings.HasPrefix(key, "SCANNING_") ||
+		strings.HasPrefix(key, "MIRRORFILESTMP_") ||
+		strings.HasSuffix(key, "_TMP")
+}
+
+// Dump calls fn for each key of the database, the mirrors, the files and
+// the stats among others
+func (r *Redis) Dump(fn func(DumpRecord) error) error {
+	conn, err := r.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return dump(conn, fn)
+}
+
+func dump(conn redis.Conn, fn func(DumpRecord) error) error {
+	return ScanKeys(conn, "", func(keys []string) error {
+		for _, key := range keys {
+			if isRuntimeKey(key) {
+				continue
+			}
+			record, err := dumpKey(conn, key)
+			if err == redis.ErrNil {
+				// The key vanished in the meantime
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err = fn(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func dumpKey(conn redis.Conn, key string) (DumpRecord, error) {
+	record := DumpRecord{Key: key}
+
+	t, err := redis.String(conn.Do("TYPE", key))
+	if err != nil {
+		return record, err
+	}
+	record.Type = t
+
+	switch t {
+	case "none":
+		return record, redis.ErrNil
+	case "string":
+		var v []byte
+		v, err = redis.Bytes(conn.Do("GET", key))
+		record.Values = [][]byte{v}
+	case "hash":
+		record.Values, err = redis.ByteSlices(conn.Do("HGETALL", key))
+	case "list":
+		record.Values, err = redis.ByteSlices(conn.Do("LRANGE", key, 0, -1))
+	case "set":
+		record.Values, err = redis.ByteSlices(conn.Do("SMEMBERS", key))
+	case "zset":
+		record.Values, err = redis.ByteSlices(conn.Do("ZRANGE", key, 0, -1, "WITHSCORES"))
+	default:
+		return record, fmt.Errorf("unsupported type %s for key %s", t, key)
+	}
+	if err != nil {
+		return record, err
+	}
+
+	ttl, err := redis.Int64(conn.Do("PTTL", key))
+	if err != nil {
+		return record, err
+	}
+	if ttl > 0 {
+		record.TTL = ttl
+	}
+	return record, nil
+}
+
+// Restore writes the records returned by next into the database, replacing
+// the existing keys, until next returns io.EOF. Any other error of next
+// aborts the restoration, the pending batch of keys being discarded. Unless
+// force is set, the database must not contain any mirror or file. It
+// returns the number of keys restored.
+func (r *Redis) Restore(next func() (DumpRecord, error), force bool) (int64, error) {
+	conn, err := r.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return restore(conn, next, force)
+}
+
+func restore(conn redis.Conn, next func() (DumpRecord, error), force bool) (int64, error) {
+	if !force {
+		n, err := redis.Int(conn.Do("EXISTS", "MIRRORS", "FILES"))
+		if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return 0, ErrDatabaseNotEmpty
+		}
+	}
+
+	var restored, pending int64
+	multi := false
+	// discard aborts the pending transaction, the records already
+	// flushed being kept
+	discard := func() {
+		if multi {
+			conn.Do("DISCARD")
+			multi = false
+		}
+	}
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		pending = 0
+		multi = false
+		values, err := redis.Values(conn.Do("EXEC"))
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if e, ok := v.(redis.Error); ok {
+				return e
+			}
+		}
+		return nil
+	}
+
+	for {
+		record, err := next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			discard()
+			return restored, err
+		}
+
+		if !multi {
+			conn.Send("MULTI")
+			multi = true
+		}
+		if err = restoreKey(conn, record); err != nil {
+			discard()
+			return restored, err
+		}
+		pending++
+
+		if pending >= restoreBatchSize {
+			n := pending
+			if err = flush(); err != nil {
+				return restored, err
+			}
+			restored += n
+		}
+	}
+
+	n := pending
+	if err := flush(); err != nil {
+		return restored, err
+	}
+	return restored + n, nil
+}
+
+func restoreKey(conn redis.Conn, record DumpRecord) error {
+	if record.Key == "" {
+		return errors.New("missing key name")
+	}
+
+	args := redis.Args{record.Key}
+	for _, v := range record.Values {
+		args = append(args, v)
+	}
+
+	var cmd string
+	switch record.Type {
+	case "string":
+		if len(record.Values) != 1 {
+			return fmt.Errorf("invalid value for key %s", record.Key)
+		}
+		cmd = "SET"
+	case "hash":
+		if len(record.Values)%2 != 0 {
+			return fmt.Errorf("invalid value for key %s", record.Key)
+		}
+		cmd = "HMSET"
+	case "list":
+		cmd = "RPUSH"
+	case "set":
+		cmd = "SADD"
+	case "zset":
+		if len(record.Values)%2 != 0 {
+			return fmt.Errorf("invalid value for key %s", record.Key)
+		}
+		// ZADD takes the score before the member
+		for i := 1; i < len(args); i += 2 {
+			args[i], args[i+1] = args[i+1], args[i]
+		}
+		cmd = "ZADD"
+	default:
+		return fmt.Errorf("unsupported type %s for key %s", record.Type, record.Key)
+	}
+
+	conn.Send("DEL", record.Key)
+	if len(record.Values) > 0 {
+		conn.Send(cmd, args...)
+	}
+	if record.TTL > 0 {
+		conn.Send("PEXPIRE", record.Key, record.TTL)
+	}
+	return nil
+}
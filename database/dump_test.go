@@ -0,0 +1,176 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/rafaeljusto/redigomock"
+)
+
+func byteSlices(values ...string) [][]byte {
+	s := make([][]byte, len(values))
+	for i, v := range values {
+		s[i] = []byte(v)
+	}
+	return s
+}
+
+func replies(values ...string) []interface{} {
+	s := make([]interface{}, len(values))
+	for i, v := range values {
+		s[i] = []byte(v)
+	}
+	return s
+}
+
+// recordsReader returns a function reading the given records, as expected
+// by restore
+func recordsReader(records []DumpRecord) func() (DumpRecord, error) {
+	return func() (DumpRecord, error) {
+		if len(records) == 0 {
+			return DumpRecord{}, io.EOF
+		}
+		r := records[0]
+		records = records[1:]
+		return r, nil
+	}
+}
+
+func TestDumpRestore(t *testing.T) {
+	mock := redigomock.NewConn()
+
+	mock.Command("SCAN", 0, "COUNT", scanCount).Expect([]interface{}{
+		[]byte("0"),
+		replies("VERSION", "MIRROR_1", "STATS_TOP", "LOCK_SCAN", "LEADER"),
+	})
+	mock.Command("TYPE", "VERSION").Expect("string")
+	mock.Command("GET", "VERSION").Expect([]byte("2"))
+	mock.Command("PTTL", "VERSION").Expect(int64(-1))
+	mock.Command("TYPE", "MIRROR_1").Expect("hash")
+	mock.Command("HGETALL", "MIRROR_1").Expect(replies("name", "m1", "http", "http://m1.example.org/"))
+	mock.Command("PTTL", "MIRROR_1").Expect(int64(-1))
+	mock.Command("TYPE", "STATS_TOP").Expect("zset")
+	mock.Command("ZRANGE", "STATS_TOP", 0, -1, "WITHSCORES").Expect(replies("/a", "1", "/b", "42"))
+	mock.Command("PTTL", "STATS_TOP").Expect(int64(5000))
+
+	var records []DumpRecord
+	err := dump(mock, func(r DumpRecord) error {
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []DumpRecord{
+		{Key: "VERSION", Type: "string", Values: byteSlices("2")},
+		{Key: "MIRROR_1", Type: "hash", Values: byteSlices("name", "m1", "http", "http://m1.example.org/")},
+		{Key: "STATS_TOP", Type: "zset", Values: byteSlices("/a", "1", "/b", "42"), TTL: 5000},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("Expected the runtime keys to be skipped:\nExpected: %+v\nGot: %+v", expected, records)
+	}
+
+	/* Restore the records */
+
+	mock = redigomock.NewConn()
+	mock.Command("EXISTS", "MIRRORS", "FILES").Expect(int64(0))
+	mock.Command("MULTI").Expect("OK")
+	cmdSet := mock.Command("SET", "VERSION", []byte("2")).Expect("QUEUED")
+	cmdHash := mock.Command("HMSET", "MIRROR_1", []byte("name"), []byte("m1"), []byte("http"), []byte("http://m1.example.org/")).Expect("QUEUED")
+	// The scores come before the members
+	cmdZset := mock.Command("ZADD", "STATS_TOP", []byte("1"), []byte("/a"), []byte("42"), []byte("/b")).Expect("QUEUED")
+	cmdExpire := mock.Command("PEXPIRE", "STATS_TOP", int64(5000)).Expect("QUEUED")
+	mock.GenericCommand("DEL").Expect("QUEUED")
+	cmdExec := mock.Command("EXEC").Expect([]interface{}{int64(0), "OK", int64(0), "OK", int64(0), int64(2), int64(1)})
+
+	restored, err := restore(mock, recordsReader(records), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if restored != 3 {
+		t.Fatalf("Expected 3 keys restored, got %d", restored)
+	}
+	for _, cmd := range []*redigomock.Cmd{cmdSet, cmdHash, cmdZset, cmdExpire, cmdExec} {
+		if mock.Stats(cmd) != 1 {
+			t.Fatalf("Expected %s %v to be sent once", cmd.Name, cmd.Args)
+		}
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreNotEmpty(t *testing.T) {
+	mock := redigomock.NewConn()
+	mock.Command("EXISTS", "MIRRORS", "FILES").Expect(int64(1))
+	cmdMulti := mock.Command("MULTI").Expect("OK")
+
+	records := []DumpRecord{{Key: "VERSION", Type: "string", Values: byteSlices("2")}}
+	if _, err := restore(mock, recordsReader(records), false); err != ErrDatabaseNotEmpty {
+		t.Fatalf("Expected ErrDatabaseNotEmpty, got %v", err)
+	}
+	if mock.Stats(cmdMulti) != 0 {
+		t.Fatalf("Expected nothing to be restored")
+	}
+
+	// Replacing the existing keys
+	mock = redigomock.NewConn()
+	mock.Command("MULTI").Expect("OK")
+	mock.Command("DEL", "VERSION").Expect("QUEUED")
+	mock.Command("SET", "VERSION", []byte("2")).Expect("QUEUED")
+	mock.Command("EXEC").Expect([]interface{}{int64(1), "OK"})
+
+	if restored, err := restore(mock, recordsReader(records), true); err != nil || restored != 1 {
+		t.Fatalf("Expected the key to be restored, got %d %v", restored, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreAborted(t *testing.T) {
+	mock := redigomock.NewConn()
+	mock.Command("EXISTS", "MIRRORS", "FILES").Expect(int64(0))
+	mock.Command("MULTI").Expect("OK")
+	mock.Command("DEL", "VERSION").Expect("QUEUED")
+	mock.Command("SET", "VERSION", []byte("2")).Expect("QUEUED")
+	cmdDiscard := mock.Command("DISCARD").Expect("OK")
+	cmdExec := mock.Command("EXEC").Expect([]interface{}{int64(1), "OK"})
+
+	// The client cancels the restoration after the first record
+	next := recordsReader([]DumpRecord{{Key: "VERSION", Type: "string", Values: byteSlices("2")}})
+	aborted := errors.New("canceled")
+	read := 0
+	_, err := restore(mock, func() (DumpRecord, error) {
+		if read++; read > 1 {
+			return DumpRecord{}, aborted
+		}
+		return next()
+	}, false)
+	if err != aborted {
+		t.Fatalf("Expected the restoration to be aborted, got %v", err)
+	}
+	if mock.Stats(cmdDiscard) != 1 || mock.Stats(cmdExec) != 0 {
+		t.Fatalf("Expected the pending keys to be discarded")
+	}
+
+	// Invalid record
+	mock = redigomock.NewConn()
+	mock.Command("EXISTS", "MIRRORS", "FILES").Expect(int64(0))
+	mock.Command("MULTI").Expect("OK")
+	cmdDiscard = mock.Command("DISCARD").Expect("OK")
+
+	records := []DumpRecord{{Key: "VERSION", Type: "string", Values: byteSlices("1", "2")}}
+	if _, err = restore(mock, recordsReader(records), false); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if mock.Stats(cmdDiscard) != 1 {
+		t.Fatalf("Expected the pending keys to be discarded")
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Types of the events not related to a mirror
+const (
+	EventConfigReloaded = "config-reloaded"
+)
+
+// Event is a notable change published on the EVENTS channel for the
+// external tools watching the cluster
+type Event struct {
+	Type      string
+	MirrorID  int `json:",omitempty"`
+	Message   string
+	Actor     string `json:",omitempty"`
+	Timestamp time.Time
+}
+
+// PublishEvent publishes the given event on the pubsub server
+func PublishEvent(r redis.Conn, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return Publish(r, EVENTS, string(value))
+}
+
+// ParseEvent decodes an event received on the EVENTS channel
+func ParseEvent(message string) (Event, error) {
+	var event Event
+	err := json.Unmarshal([]byte(message), &event)
+	return event, err
+}
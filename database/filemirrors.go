@@ -0,0 +1,151 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"path"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// The mirrors carrying each file are stored per directory, in a hash
+// named after the directory whose fields are the names of the files and
+// whose values are bitmaps of the IDs of the mirrors, bit n of the bitmap
+// (with the SETBIT ordering) standing for the mirror n. This saves a key
+// per file and lets the scans update a whole directory at once.
+
+// fileMirrorsPrefix is the prefix of the keys holding the mirrors carrying
+// the files of a directory
+const fileMirrorsPrefix = "DIRMIRRORS_"
+
+// addFileMirrorScript sets the bit of the mirror ARGV[1] in the bitmaps
+// of the files ARGV[2..] of the directory KEYS[1]
+var addFileMirrorScript = redis.NewScript(1, `
+local id = tonumber(ARGV[1])
+local n = math.floor(id / 8) + 1
+local mask = 2 ^ (7 - id % 8)
+for i = 2, #ARGV do
+	local v = redis.call('HGET', KEYS[1], ARGV[i]) or ''
+	if #v < n then
+		v = v .. string.rep('\0', n - #v)
+	end
+	local b = string.byte(v, n)
+	if math.floor(b / mask) % 2 == 0 then
+		redis.call('HSET', KEYS[1], ARGV[i], string.sub(v, 1, n - 1) .. string.char(b + mask) .. string.sub(v, n + 1))
+	end
+end
+return 0`)
+
+// removeFileMirrorScript clears the bit of the mirror ARGV[1] in the
+// bitmaps of the files ARGV[2..] of the directory KEYS[1], removing the
+// files carried by no mirror at all
+var removeFileMirrorScript = redis.NewScript(1, `
+local id = tonumber(ARGV[1])
+local n = math.floor(id / 8) + 1
+local mask = 2 ^ (7 - id % 8)
+for i = 2, #ARGV do
+	local v = redis.call('HGET', KEYS[1], ARGV[i])
+	if v and #v >= n then
+		local b = string.byte(v, n)
+		if math.floor(b / mask) % 2 == 1 then
+			v = string.sub(v, 1, n - 1) .. string.char(b - mask) .. string.sub(v, n + 1)
+			if string.find(v, '^%z*$') then
+				redis.call('HDEL', KEYS[1], ARGV[i])
+			else
+				redis.call('HSET', KEYS[1], ARGV[i], v)
+			end
+		end
+	end
+end
+return 0`)
+
+// fileMirrorsKey returns the key and the field holding the mirrors
+// carrying the given file
+func fileMirrorsKey(file string) (key, field string) {
+	return fileMirrorsPrefix + path.Dir(file), path.Base(file)
+}
+
+// groupByDirectory groups the given files by directory, keeping the order
+// in which the directories first appear
+func groupByDirectory(files []string) (dirs []string, fields map[string][]interface{}) {
+	fields = make(map[string][]interface{})
+	for _, file := range files {
+		key, field := fileMirrorsKey(file)
+		if _, ok := fields[key]; !ok {
+			dirs = append(dirs, key)
+		}
+		fields[key] = append(fields[key], field)
+	}
+	return
+}
+
+// SendAddFileMirror queues the commands marking the given files as carried
+// by the given mirror, one command per directory
+func SendAddFileMirror(conn redis.Conn, id int, files ...string) error {
+	return sendFileMirrorScript(conn, addFileMirrorScript, id, files)
+}
+
+// SendRemoveFileMirror queues the commands marking the given files as no
+// longer carried by the given mirror, one command per directory
+func SendRemoveFileMirror(conn redis.Conn, id int, files ...string) error {
+	return sendFileMirrorScript(conn, removeFileMirrorScript, id, files)
+}
+
+func sendFileMirrorScript(conn redis.Conn, script *redis.Script, id int, files []string) error {
+	dirs, fields := groupByDirectory(files)
+	for _, key := range dirs {
+		args := append([]interface{}{key, id}, fields[key]...)
+		if err := script.Send(conn, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendGetFileMirrors queues the command fetching the mirrors carrying the
+// given file, the reply being parsed with FileMirrorIDs
+func SendGetFileMirrors(conn redis.Conn, file string) error {
+	key, field := fileMirrorsKey(file)
+	return conn.Send("HGET", key, field)
+}
+
+// GetFileMirrors returns the IDs of the mirrors carrying the given file
+func GetFileMirrors(conn redis.Conn, file string) ([]int, error) {
+	key, field := fileMirrorsKey(file)
+	return FileMirrorIDs(conn.Do("HGET", key, field))
+}
+
+// IsFileMirror returns true if the given file is carried by the given mirror
+func IsFileMirror(conn redis.Conn, file string, id int) (bool, error) {
+	ids, err := GetFileMirrors(conn, file)
+	if err != nil {
+		return false, err
+	}
+	for _, i := range ids {
+		if i == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FileMirrorIDs is a helper converting the reply fetching the mirrors of
+// a file to the IDs of those mirrors. A missing file is carried by none.
+func FileMirrorIDs(reply interface{}, err error) ([]int, error) {
+	bitmap, err := redis.Bytes(reply, err)
+	if err == redis.ErrNil {
+		return []int{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for i, b := range bitmap {
+		for j := 0; j < 8; j++ {
+			if b&(0x80>>uint(j)) != 0 {
+				ids = append(ids, i*8+j)
+			}
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+)
+
+// testRedis returns a connection to the redis server given by the
+// MIRRORBITS_TEST_REDIS environment variable (host:port). The Lua scripts
+// can only be tested against a real server.
+func testRedis(t *testing.T) redis.Conn {
+	addr := os.Getenv("MIRRORBITS_TEST_REDIS")
+	if addr == "" {
+		t.Skip("MIRRORBITS_TEST_REDIS is not set")
+	}
+	conn, err := redis.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Cannot connect to redis: %s", err)
+	}
+	return conn
+}
+
+func TestFileMirrorIDs(t *testing.T) {
+	tests := []struct {
+		bitmap []byte
+		ids    []int
+	}{
+		{nil, []int{}},
+		{[]byte{0x00}, []int{}},
+		{[]byte{0x80}, []int{0}},
+		{[]byte{0x01}, []int{7}},
+		{[]byte{0x01, 0x80}, []int{7, 8}},
+		{[]byte{0x81, 0x81, 0x40}, []int{0, 7, 8, 15, 17}},
+		{[]byte{0x00, 0x00, 0x00, 0x01}, []int{31}},
+	}
+
+	for _, test := range tests {
+		ids, err := FileMirrorIDs(test.bitmap, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("FileMirrorIDs(%x): expected %v, got %v", test.bitmap, test.ids, ids)
+		}
+	}
+
+	// A missing file is carried by no mirror
+	if ids, err := FileMirrorIDs(nil, redis.ErrNil); err != nil || len(ids) != 0 {
+		t.Fatalf("Expected no mirror, got %v %v", ids, err)
+	}
+}
+
+func TestSendFileMirrorScript(t *testing.T) {
+	mock := redigomock.NewConn()
+
+	// One script per directory, in the order the directories appear
+	cmdDir1 := mock.Command("EVAL", redigomock.NewAnyData(), 1, "DIRMIRRORS_/d1", 3, "a", "c").Expect(int64(0))
+	cmdDir2 := mock.Command("EVAL", redigomock.NewAnyData(), 1, "DIRMIRRORS_/d2", 3, "b").Expect(int64(0))
+
+	if err := SendAddFileMirror(mock, 3, "/d1/a", "/d2/b", "/d1/c"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := mock.Do(""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if mock.Stats(cmdDir1) != 1 || mock.Stats(cmdDir2) != 1 {
+		t.Fatalf("Expected a script per directory")
+	}
+}
+
+func TestFileMirrorScripts(t *testing.T) {
+	conn := testRedis(t)
+	defer conn.Close()
+
+	dir := fmt.Sprintf("/mirrorbits-test-%d", time.Now().UnixNano())
+	key := fileMirrorsPrefix + dir
+	defer conn.Do("DEL", key)
+
+	run := func(send func(redis.Conn, int, ...string) error, id int, files ...string) {
+		if err := send(conn, id, files...); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if _, err := conn.Do(""); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	bitmap := func(file string) []byte {
+		b, err := redis.Bytes(conn.Do("HGET", key, file))
+		if err == redis.ErrNil {
+			return nil
+		} else if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return b
+	}
+	mirrors := func(file string) []int {
+		ids, err := GetFileMirrors(conn, dir+"/"+file)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return ids
+	}
+
+	// Bits on both sides of the byte boundaries
+	for _, id := range []int{17, 0, 7, 8, 15} {
+		run(SendAddFileMirror, id, dir+"/a")
+	}
+	run(SendAddFileMirror, 8, dir+"/b")
+
+	if b := bitmap("a"); !reflect.DeepEqual(b, []byte{0x81, 0x81, 0x40}) {
+		t.Fatalf("Unexpected bitmap %x", b)
+	}
+	if ids := mirrors("a"); !reflect.DeepEqual(ids, []int{0, 7, 8, 15, 17}) {
+		t.Fatalf("Unexpected mirrors %v", ids)
+	}
+	if b := bitmap("b"); !reflect.DeepEqual(b, []byte{0x00, 0x80}) {
+		t.Fatalf("Unexpected bitmap %x", b)
+	}
+	if ok, _ := IsFileMirror(conn, dir+"/b", 8); !ok {
+		t.Fatalf("Expected the file to be carried by the mirror")
+	}
+
+	// Adding a mirror twice changes nothing
+	run(SendAddFileMirror, 7, dir+"/a")
+	if b := bitmap("a"); !reflect.DeepEqual(b, []byte{0x81, 0x81, 0x40}) {
+		t.Fatalf("Unexpected bitmap %x", b)
+	}
+
+	// Removing mirrors not carrying the files changes nothing
+	run(SendRemoveFileMirror, 1, dir+"/a", dir+"/missing")
+	run(SendRemoveFileMirror, 40, dir+"/a")
+	if ids := mirrors("a"); !reflect.DeepEqual(ids, []int{0, 7, 8, 15, 17}) {
+		t.Fatalf("Unexpected mirrors %v", ids)
+	}
+	if exists, _ := redis.Bool(conn.Do("HEXISTS", key, "missing")); exists {
+		t.Fatalf("Unexpected field for a missing file")
+	}
+
+	run(SendRemoveFileMirror, 17, dir+"/a")
+	run(SendRemoveFileMirror, 7, dir+"/a")
+	if ids := mirrors("a"); !reflect.DeepEqual(ids, []int{0, 8, 15}) {
+		t.Fatalf("Unexpected mirrors %v", ids)
+	}
+
+	// The files are removed along with their last mirror
+	run(SendRemoveFileMirror, 8, dir+"/a", dir+"/b")
+	if b := bitmap("b"); b != nil {
+		t.Fatalf("Expected the field to be removed, got %x", b)
+	}
+	if ids := mirrors("a"); !reflect.DeepEqual(ids, []int{0, 15}) {
+		t.Fatalf("Unexpected mirrors %v", ids)
+	}
+	run(SendRemoveFileMirror, 0, dir+"/a")
+	run(SendRemoveFileMirror, 15, dir+"/a")
+	if b := bitmap("a"); b != nil {
+		t.Fatalf("Expected the field to be removed, got %x", b)
+	}
+	if exists, _ := redis.Bool(conn.Do("EXISTS", key)); exists {
+		t.Fatalf("Expected the directory to be removed")
+	}
+}
@@ -5,6 +5,7 @@ package database
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
@@ -22,19 +23,46 @@ const (
 	FILE_UPDATE        pubsubEvent = "_mirrorbits_file_update"
 	MIRROR_UPDATE      pubsubEvent = "_mirrorbits_mirror_update"
 	MIRROR_FILE_UPDATE pubsubEvent = "_mirrorbits_mirror_file_update"
+	EVENTS             pubsubEvent = "_mirrorbits_events"
+	MIRROR_RESCAN      pubsubEvent = "_mirrorbits_mirror_rescan"
 
 	PUBSUB_RECONNECTED pubsubEvent = "_mirrorbits_pubsub_reconnected"
 )
 
+const (
+	// pubsubQueueSize is the maximum number of distinct messages waiting
+	// to be consumed by a single subscriber before new ones are dropped.
+	pubsubQueueSize = 4096
+)
+
 // Pubsub is the internal structure of the publish/subscribe handler
 type Pubsub struct {
 	r                  *Redis
 	rconn              redis.Conn
 	connlock           sync.Mutex
-	extSubscribers     map[string][]chan string
+	extSubscribers     map[string][]*eventQueue
 	extSubscribersLock sync.RWMutex
 	stop               chan bool
 	wg                 sync.WaitGroup
+
+	coalesced   int64
+	dropped     int64
+	lastDropLog int64
+}
+
+// PubsubStats contains the counters of the publish/subscribe handler
+type PubsubStats struct {
+	// Coalesced is the number of messages merged with an identical
+	// message still waiting to be consumed
+	Coalesced int64
+	// Dropped is the number of messages discarded because a
+	// subscriber queue was full
+	Dropped int64
+	// Queued is the number of messages waiting to be consumed by the
+	// subscribers, per kind of event
+	Queued map[string]int
+	// Subscribers is the number of subscribers per kind of event
+	Subscribers map[string]int
 }
 
 // NewPubsub returns a new instance of the publish/subscribe handler
@@ -42,7 +70,7 @@ func NewPubsub(r *Redis) *Pubsub {
 	pubsub := new(Pubsub)
 	pubsub.r = r
 	pubsub.stop = make(chan bool)
-	pubsub.extSubscribers = make(map[string][]chan string)
+	pubsub.extSubscribers = make(map[string][]*eventQueue)
 	go pubsub.updateEvents()
 	return pubsub
 }
@@ -63,15 +91,61 @@ func (p *Pubsub) Close() {
 
 // SubscribeEvent allows subscription to a particular kind of events and receive a
 // notification when an event is dispatched on the given channel.
+// Identical messages still waiting to be delivered are coalesced and, if the
+// subscriber doesn't keep up, the excess is dropped and a PUBSUB_RECONNECTED
+// event is dispatched so that subscribers can invalidate their state.
 func (p *Pubsub) SubscribeEvent(event pubsubEvent, channel chan string) {
 	p.extSubscribersLock.Lock()
 	defer p.extSubscribersLock.Unlock()
 
+	q := newEventQueue(channel, pubsubQueueSize)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		q.forward(p.stop)
+	}()
+
 	listeners := p.extSubscribers[string(event)]
-	listeners = append(listeners, channel)
+	listeners = append(listeners, q)
 	p.extSubscribers[string(event)] = listeners
 }
 
+// UnsubscribeEvent stops the notifications of the given kind of events on
+// the channel. The messages still waiting to be delivered are discarded.
+func (p *Pubsub) UnsubscribeEvent(event pubsubEvent, channel chan string) {
+	p.extSubscribersLock.Lock()
+	defer p.extSubscribersLock.Unlock()
+
+	listeners := p.extSubscribers[string(event)]
+	for i, q := range listeners {
+		if q.out == channel {
+			close(q.closed)
+			p.extSubscribers[string(event)] = append(listeners[:i:i], listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stats returns the counters of the publish/subscribe handler
+func (p *Pubsub) Stats() PubsubStats {
+	stats := PubsubStats{
+		Coalesced:   atomic.LoadInt64(&p.coalesced),
+		Dropped:     atomic.LoadInt64(&p.dropped),
+		Queued:      make(map[string]int),
+		Subscribers: make(map[string]int),
+	}
+
+	p.extSubscribersLock.RLock()
+	defer p.extSubscribersLock.RUnlock()
+	for event, listeners := range p.extSubscribers {
+		stats.Subscribers[event] = len(listeners)
+		for _, q := range listeners {
+			stats.Queued[event] += q.Len()
+		}
+	}
+	return stats
+}
+
 func (p *Pubsub) updateEvents() {
 	p.wg.Add(1)
 	defer p.wg.Done()
@@ -106,6 +180,8 @@ connect:
 		psc.Subscribe(FILE_UPDATE)
 		psc.Subscribe(MIRROR_UPDATE)
 		psc.Subscribe(MIRROR_FILE_UPDATE)
+		psc.Subscribe(EVENTS)
+		psc.Subscribe(MIRROR_RESCAN)
 
 		if disconnected == true {
 			// This is a way to keep the cache active while disconnected
@@ -141,12 +217,29 @@ connect:
 // Notify subscribers of the new message
 func (p *Pubsub) handleMessage(channel string, data []byte) {
 	p.extSubscribersLock.RLock()
-	defer p.extSubscribersLock.RUnlock()
-
+	overflow := false
 	listeners := p.extSubscribers[channel]
 	for _, listener := range listeners {
-		// Block if the listener is not available
-		listener <- string(data)
+		switch listener.push(string(data)) {
+		case queueCoalesced:
+			atomic.AddInt64(&p.coalesced, 1)
+		case queueDropped:
+			atomic.AddInt64(&p.dropped, 1)
+			overflow = true
+		}
+	}
+	p.extSubscribersLock.RUnlock()
+
+	// The events are only informative, losing some doesn't make the
+	// state of the subscribers outdated
+	if overflow && channel != string(PUBSUB_RECONNECTED) && channel != string(EVENTS) {
+		// Some events were lost, the subscribers have to consider
+		// their state as outdated as they would after a reconnection.
+		now := time.Now().Unix()
+		if last := atomic.LoadInt64(&p.lastDropLog); now > last && atomic.CompareAndSwapInt64(&p.lastDropLog, last, now) {
+			log.Warningf("Pubsub: subscriber queue full, dropping events on %s", channel)
+		}
+		p.handleMessage(string(PUBSUB_RECONNECTED), nil)
 	}
 }
 
@@ -161,3 +254,101 @@ func SendPublish(r redis.Conn, event pubsubEvent, message string) error {
 	err := r.Send("PUBLISH", string(event), message)
 	return err
 }
+
+type queueResult int
+
+const (
+	queueAdded queueResult = iota
+	queueCoalesced
+	queueDropped
+)
+
+// eventQueue is a bounded queue of distinct messages waiting to be
+// forwarded to a subscriber channel.
+type eventQueue struct {
+	sync.Mutex
+	out     chan string
+	items   []string
+	pending map[string]struct{}
+	size    int
+	wake    chan struct{}
+	closed  chan struct{}
+}
+
+func newEventQueue(out chan string, size int) *eventQueue {
+	return &eventQueue{
+		out:     out,
+		pending: make(map[string]struct{}),
+		size:    size,
+		wake:    make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+}
+
+// push adds a message to the queue unless the same message is already waiting
+func (q *eventQueue) push(msg string) queueResult {
+	q.Lock()
+	defer q.Unlock()
+
+	if _, ok := q.pending[msg]; ok {
+		return queueCoalesced
+	}
+	if len(q.items) >= q.size {
+		return queueDropped
+	}
+	q.pending[msg] = struct{}{}
+	q.items = append(q.items, msg)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return queueAdded
+}
+
+// pop removes the oldest message from the queue
+func (q *eventQueue) pop() (string, bool) {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.items) == 0 {
+		return "", false
+	}
+	msg := q.items[0]
+	q.items[0] = ""
+	q.items = q.items[1:]
+	delete(q.pending, msg)
+	return msg, true
+}
+
+// Len returns the number of messages waiting in the queue
+func (q *eventQueue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.items)
+}
+
+// forward delivers the queued messages to the subscriber until stopped
+// or unsubscribed
+func (q *eventQueue) forward(stop <-chan bool) {
+	for {
+		msg, ok := q.pop()
+		if !ok {
+			select {
+			case <-stop:
+				return
+			case <-q.closed:
+				return
+			case <-q.wake:
+			}
+			continue
+		}
+		select {
+		case <-stop:
+			return
+		case <-q.closed:
+			return
+		case q.out <- msg:
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestPubsub returns a publish/subscribe handler not connected to redis
+func newTestPubsub() *Pubsub {
+	return &Pubsub{
+		stop:           make(chan bool),
+		extSubscribers: make(map[string][]*eventQueue),
+	}
+}
+
+// addQueue registers a subscriber queue not consumed until popped by the test
+func (p *Pubsub) addQueue(event pubsubEvent, size int) *eventQueue {
+	q := newEventQueue(make(chan string), size)
+	p.extSubscribers[string(event)] = append(p.extSubscribers[string(event)], q)
+	return q
+}
+
+func popAll(q *eventQueue) []string {
+	var msgs []string
+	for {
+		msg, ok := q.pop()
+		if !ok {
+			return msgs
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	q := newEventQueue(make(chan string), 3)
+
+	if _, ok := q.pop(); ok {
+		t.Fatalf("Expected an empty queue")
+	}
+
+	for _, msg := range []string{"a", "b"} {
+		if r := q.push(msg); r != queueAdded {
+			t.Fatalf("Expected %s to be added, got %d", msg, r)
+		}
+	}
+	if r := q.push("a"); r != queueCoalesced {
+		t.Fatalf("Expected a pending message to be coalesced, got %d", r)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Expected 2 messages, got %d", q.Len())
+	}
+
+	if r := q.push("c"); r != queueAdded {
+		t.Fatalf("Expected c to be added, got %d", r)
+	}
+	if r := q.push("d"); r != queueDropped {
+		t.Fatalf("Expected d to be dropped, got %d", r)
+	}
+	// Coalescing is still possible on a full queue
+	if r := q.push("c"); r != queueCoalesced {
+		t.Fatalf("Expected c to be coalesced, got %d", r)
+	}
+
+	if msg, ok := q.pop(); !ok || msg != "a" {
+		t.Fatalf("Expected the oldest message, got %q", msg)
+	}
+	// A message is no longer coalesced once consumed
+	if r := q.push("a"); r != queueAdded {
+		t.Fatalf("Expected a to be added again, got %d", r)
+	}
+
+	msgs := popAll(q)
+	if len(msgs) != 3 || msgs[0] != "b" || msgs[1] != "c" || msgs[2] != "a" {
+		t.Fatalf("Expected the messages in order, got %v", msgs)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Expected an empty queue, got %d", q.Len())
+	}
+}
+
+func TestPubsub_handleMessage(t *testing.T) {
+	p := newTestPubsub()
+	q1 := p.addQueue(MIRROR_UPDATE, 2)
+	q2 := p.addQueue(MIRROR_UPDATE, 2)
+	events := p.addQueue(EVENTS, 1)
+	reconnected := p.addQueue(PUBSUB_RECONNECTED, 2)
+
+	p.handleMessage(string(MIRROR_UPDATE), []byte("1"))
+	p.handleMessage(string(MIRROR_UPDATE), []byte("1"))
+
+	stats := p.Stats()
+	if stats.Coalesced != 2 || stats.Dropped != 0 {
+		t.Fatalf("Expected a message coalesced per subscriber, got %+v", stats)
+	}
+	if stats.Queued[string(MIRROR_UPDATE)] != 2 || stats.Subscribers[string(MIRROR_UPDATE)] != 2 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+	if reconnected.Len() != 0 {
+		t.Fatalf("Unexpected reconnection event")
+	}
+
+	// Overflow
+	p.handleMessage(string(MIRROR_UPDATE), []byte("2"))
+	p.handleMessage(string(MIRROR_UPDATE), []byte("3"))
+
+	if stats = p.Stats(); stats.Dropped != 2 {
+		t.Fatalf("Expected a message dropped per subscriber, got %+v", stats)
+	}
+	for _, q := range []*eventQueue{q1, q2} {
+		if msgs := popAll(q); len(msgs) != 2 || msgs[0] != "1" || msgs[1] != "2" {
+			t.Fatalf("Expected the first messages to be kept, got %v", msgs)
+		}
+	}
+	// The subscribers are told to invalidate their state
+	if msgs := popAll(reconnected); len(msgs) != 1 {
+		t.Fatalf("Expected a single reconnection event, got %v", msgs)
+	}
+
+	// Losing informative events doesn't outdate the subscribers
+	p.handleMessage(string(EVENTS), []byte("a"))
+	p.handleMessage(string(EVENTS), []byte("b"))
+	if events.Len() != 1 || reconnected.Len() != 0 {
+		t.Fatalf("Expected the event to be dropped without reconnection event")
+	}
+
+	// The pending reconnection events are coalesced
+	p.handleMessage(string(PUBSUB_RECONNECTED), nil)
+	p.handleMessage(string(MIRROR_UPDATE), []byte("4"))
+	p.handleMessage(string(MIRROR_UPDATE), []byte("5"))
+	p.handleMessage(string(MIRROR_UPDATE), []byte("6"))
+	if reconnected.Len() != 1 {
+		t.Fatalf("Expected the reconnection events to be coalesced, got %d", reconnected.Len())
+	}
+	if stats = p.Stats(); stats.Dropped != 5 {
+		t.Fatalf("Expected 5 messages dropped, got %d", stats.Dropped)
+	}
+}
+
+func TestPubsub_SubscribeEvent(t *testing.T) {
+	p := newTestPubsub()
+	defer func() {
+		close(p.stop)
+		p.wg.Wait()
+	}()
+
+	c := make(chan string)
+	p.SubscribeEvent(MIRROR_UPDATE, c)
+
+	for _, msg := range []string{"1", "2"} {
+		p.handleMessage(string(MIRROR_UPDATE), []byte(msg))
+	}
+	for _, expected := range []string{"1", "2"} {
+		select {
+		case msg := <-c:
+			if msg != expected {
+				t.Fatalf("Expected %s, got %s", expected, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Message %s not delivered", expected)
+		}
+	}
+
+	p.UnsubscribeEvent(MIRROR_UPDATE, c)
+	if n := p.Stats().Subscribers[string(MIRROR_UPDATE)]; n != 0 {
+		t.Fatalf("Expected no subscriber, got %d", n)
+	}
+	p.handleMessage(string(MIRROR_UPDATE), []byte("3"))
+	select {
+	case msg := <-c:
+		t.Fatalf("Unexpected message %s after unsubscribing", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -37,6 +37,8 @@ type redisPool interface {
 // Redis is the instance object of the redis database
 type Redis struct {
 	pool            redisPool
+	masterPool      redisPool // Connections to the master of a read-only replica
+	readOnly        bool
 	Pubsub          *Pubsub
 	failure         bool
 	failureState    sync.RWMutex
@@ -73,6 +75,18 @@ func NewRedisCustomPool(pool redisPool) *Redis {
 		}
 		r.pool = pool
 	} else {
+		if GetConfig().Replica.Enabled {
+			r.readOnly = true
+			r.masterPool = &redis.Pool{
+				MaxIdle:     2,
+				IdleTimeout: 240 * time.Second,
+				Dial:        r.connectMaster,
+				TestOnBorrow: func(c redis.Conn, t time.Time) error {
+					_, err := c.Do("PING")
+					return err
+				},
+			}
+		}
 		r.pool = &redis.Pool{
 			MaxIdle:     10,
 			IdleTimeout: 240 * time.Second,
@@ -117,6 +131,26 @@ func (r *Redis) Get() redis.Conn {
 	return r.pool.Get()
 }
 
+// Master returns a connection to the redis master. It is a connection of
+// the pool unless the instance is a read-only replica, in which case the
+// connection is only meant to forward the stats.
+func (r *Redis) Master() redis.Conn {
+	if !r.readOnly {
+		return r.Get()
+	}
+	select {
+	case <-r.ready:
+	default:
+		return &NotReadyError{}
+	}
+	return r.masterPool.Get()
+}
+
+// ReadOnly returns true if the instance reads from a redis replica
+func (r *Redis) ReadOnly() bool {
+	return r.readOnly
+}
+
 // UnblockedGet returns a redis connection from the pool even
 // if the database checks and/or upgrade are not finished.
 func (r *Redis) UnblockedGet() redis.Conn {
@@ -132,6 +166,9 @@ func (r *Redis) Close() {
 		log.Debug("Closing databases connections")
 		r.Pubsub.Close()
 		r.pool.Close()
+		if r.masterPool != nil {
+			r.masterPool.Close()
+		}
 		close(r.stop)
 	}
 }
@@ -167,6 +204,10 @@ func (r *Redis) askVersion (conn redis.Conn) (string, error) {
 
 // Connect initiates a new connection to the redis server
 func (r *Redis) Connect() (redis.Conn, error) {
+	if r.readOnly {
+		return r.connectReplica()
+	}
+
 	sentinels := GetConfig().RedisSentinels
 
 	if len(sentinels) > 0 {
@@ -290,6 +331,107 @@ single:
 
 }
 
+// CheckConnection connects to the redis server of the current configuration
+// the way the daemon does, without starting the pool, and closes the
+// connection
+func CheckConnection() error {
+	r := &Redis{
+		readOnly: GetConfig().Replica.Enabled,
+	}
+	c, err := r.Connect()
+	if c != nil {
+		c.Close()
+	}
+	return err
+}
+
+// connectReplica initiates a new connection to the redis replica of a
+// read-only instance
+func (r *Redis) connectReplica() (redis.Conn, error) {
+	address := GetConfig().RedisAddress
+	c, err := r.connectTo(address)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.auth(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err = r.selectDB(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	role, err := r.askRole(c)
+	if err != nil {
+		r.logError("Redis replica: %s", err.Error())
+		c.Close()
+		return nil, ErrUnreachable
+	}
+	if role != "slave" && role != "master" {
+		// A promoted replica can still be read from
+		r.logError("Redis replica: %s is not a replica but a %s", address, role)
+		c.Close()
+		return nil, ErrUnreachable
+	}
+	r.printConnected("replica", address)
+
+	r.version, err = r.askVersion(c)
+
+	return c, err
+}
+
+// connectMaster initiates a new connection to the master of the redis
+// replica of a read-only instance
+func (r *Redis) connectMaster() (redis.Conn, error) {
+	address := GetConfig().Replica.MasterAddress
+	if address == "" {
+		// Ask the replica
+		c, err := r.connectReplica()
+		if err != nil {
+			return nil, err
+		}
+		reply, err := redis.Values(c.Do("ROLE"))
+		c.Close()
+		if err != nil {
+			return nil, err
+		}
+		role, _ := redis.String(reply[0], nil)
+		if role == "master" {
+			// The replica has been promoted
+			address = GetConfig().RedisAddress
+		} else if len(reply) < 3 {
+			return nil, ErrUnreachable
+		} else {
+			host, _ := redis.String(reply[1], nil)
+			port, _ := redis.Int(reply[2], nil)
+			address = fmt.Sprintf("%s:%d", host, port)
+		}
+	}
+
+	c, err := r.connectTo(address)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.auth(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err = r.selectDB(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	role, err := r.askRole(c)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if role != "master" {
+		c.Close()
+		return nil, fmt.Errorf("%s is not a master but a %s", address, role)
+	}
+	return c, nil
+}
+
 func (r *Redis) connectTo(address string) (redis.Conn, error) {
 	return redis.Dial("tcp", address,
 		redis.DialConnectTimeout(redisConnectionTimeout),
@@ -327,13 +469,17 @@ func (r *Redis) logError(format string, args ...interface{}) {
 }
 
 func (r *Redis) printConnectedMaster(address string) {
+	r.printConnected("master", address)
+}
+
+func (r *Redis) printConnected(role, address string) {
 	r.knownMasterLock.Lock()
 	defer r.knownMasterLock.Unlock()
 	if address != r.knownMaster && core.Daemon {
 		r.knownMaster = address
-		log.Infof("Connected to redis master %s", address)
+		log.Infof("Connected to redis %s %s", role, address)
 	} else {
-		log.Debugf("Connected to redis master %s", address)
+		log.Debugf("Connected to redis %s %s", role, address)
 	}
 }
 
@@ -382,6 +528,14 @@ again:
 		time.Sleep(100 * time.Millisecond)
 		goto again
 	}
+	if upneeded && r.readOnly {
+		// Only the master can be upgraded
+		logOnce.Do(func() {
+			log.Warning("Database upgrade needed. Waiting for the master to be upgraded...")
+		})
+		time.Sleep(time.Second)
+		goto again
+	}
 	if upneeded {
 		t := time.Now()
 		err = r.Upgrade()
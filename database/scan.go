@@ -0,0 +1,59 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// scanCount is the number of elements suggested to Redis per iteration of
+// the SCAN family of commands
+const scanCount = 1000
+
+// ScanKeys calls fn with the keys matching the given pattern, or all the
+// keys if the pattern is empty, a batch at a time. A key may be returned
+// more than once.
+func ScanKeys(conn redis.Conn, pattern string, fn func(keys []string) error) error {
+	args := redis.Args{}
+	if pattern != "" {
+		args = args.Add("MATCH", pattern)
+	}
+	args = args.Add("COUNT", scanCount)
+	return scanCursor(conn, "SCAN", nil, args, fn)
+}
+
+// ScanSet calls fn with the members of the given set, a batch at a time.
+// A member may be returned more than once.
+func ScanSet(conn redis.Conn, key string, fn func(members []string) error) error {
+	return scanCursor(conn, "SSCAN", redis.Args{key}, redis.Args{"COUNT", scanCount}, fn)
+}
+
+// ScanHash calls fn with the fields and the values of the given hash, a
+// batch of field/value pairs at a time. A field may be returned more than
+// once.
+func ScanHash(conn redis.Conn, key string, fn func(pairs []string) error) error {
+	return scanCursor(conn, "HSCAN", redis.Args{key}, redis.Args{"COUNT", scanCount}, fn)
+}
+
+func scanCursor(conn redis.Conn, cmd string, key, args redis.Args, fn func([]string) error) error {
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do(cmd, append(append(key, cursor), args...)...))
+		if err != nil {
+			return err
+		}
+		cursor, _ = redis.Int(values[0], nil)
+		elements, _ := redis.Strings(values[1], nil)
+
+		if len(elements) > 0 {
+			if err = fn(elements); err != nil {
+				return err
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package database
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/gomodule/redigo/redis"
+)
+
+// The stats keys prefixes (see http/stats.go), the longest prefixes first
+var statsPrefixes = []string{
+	"STATS_MIRROR_BYTES_",
+	"STATS_MIRROR_",
+	"STATS_FILE_",
+	"STATS_HTTP_",
+}
+
+// StatsFileKey returns the key where the stats of the given file are
+// recorded for the period of the base key (i.e. STATS_FILE_2019_01_02).
+// When StatsFileShards is set, the files are spread by the hash of their
+// path over as many keys named after the base key followed by :<shard>.
+func StatsFileKey(base, path string) string {
+	shards := GetConfig().StatsFileShards
+	if shards <= 0 {
+		return base
+	}
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return fmt.Sprintf("%s:%d", base, h.Sum32()%uint32(shards))
+}
+
+// StatsFileKeysForPath returns the keys possibly holding the stats of the
+// given file for the period of the base key: the unsharded key, still
+// holding the stats recorded before the sharding was enabled, and its shard.
+// The values of these keys must be summed.
+func StatsFileKeysForPath(base, path string) []string {
+	key := StatsFileKey(base, path)
+	if key == base {
+		return []string{base}
+	}
+	return []string{base, key}
+}
+
+// StatsFileKeys returns all the keys holding the stats of the files for the
+// period of the base key, the unsharded key followed by all the shards
+func StatsFileKeys(base string) []string {
+	shards := GetConfig().StatsFileShards
+	keys := make([]string, 0, shards+1)
+	keys = append(keys, base)
+	for i := 0; i < shards; i++ {
+		keys = append(keys, fmt.Sprintf("%s:%d", base, i))
+	}
+	return keys
+}
+
+// FileDownloads returns the downloads of each file for the period of the
+// base key, aggregating all the shards. HSCAN is used to not block the
+// database on the largest hashes. A field may be returned more than once
+// by HSCAN, hence the values are not summed within a shard.
+func FileDownloads(conn redis.Conn, base string) (map[string]int64, error) {
+	stats := make(map[string]int64)
+	for _, k := range StatsFileKeys(base) {
+		shard := make(map[string]int64)
+		err := ScanHash(conn, k, func(pairs []string) error {
+			for i := 0; i+1 < len(pairs); i += 2 {
+				downloads, err := strconv.ParseInt(pairs[i+1], 10, 64)
+				if err != nil {
+					return err
+				}
+				shard[pairs[i]] = downloads
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for path, downloads := range shard {
+			stats[path] += downloads
+		}
+	}
+	return stats, nil
+}
+
+// PruneStats removes the stats keys older than the configured retention.
+// It returns the number of keys removed.
+func (r *Redis) PruneStats() (int, error) {
+	retention := GetConfig().StatsRetention
+	if retention.Daily <= 0 && retention.Monthly <= 0 && retention.Yearly <= 0 {
+		// Keep everything
+		return 0, nil
+	}
+
+	conn, err := r.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	now := time.Now().UTC()
+	removed := 0
+
+	err = ScanKeys(conn, "STATS_*", func(keys []string) error {
+		for _, key := range keys {
+			if !isStatsKeyExpired(key, retention.Daily, retention.Monthly, retention.Yearly, now) {
+				continue
+			}
+			n, err := redis.Int(conn.Do("DEL", key))
+			if err != nil {
+				return err
+			}
+			removed += n
+		}
+		return nil
+	})
+
+	return removed, err
+}
+
+// isStatsKeyExpired returns true if the period covered by the given
+// stats key is older than the retention of its granularity
+func isStatsKeyExpired(key string, daily, monthly, yearly int, now time.Time) bool {
+	var date string
+	for _, prefix := range statsPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			date = key[len(prefix):]
+			break
+		}
+	}
+	// Strip the shard number of the sharded keys
+	if i := strings.Index(date, ":"); i >= 0 {
+		date = date[:i]
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch len(date) {
+	case len("2006_01_02"):
+		t, err := time.Parse("2006_01_02", date)
+		return err == nil && daily > 0 && t.Before(today.AddDate(0, 0, -daily))
+	case len("2006_01"):
+		t, err := time.Parse("2006_01", date)
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return err == nil && monthly > 0 && t.Before(month.AddDate(0, -monthly, 0))
+	case len("2006"):
+		t, err := time.Parse("2006", date)
+		year := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return err == nil && yearly > 0 && t.Before(year.AddDate(-yearly, 0, 0))
+	}
+
+	// All-time stats or unknown key
+	return false
+}
@@ -46,6 +46,10 @@ again:
 		if found {
 			return 0, nil
 		}
+		if r.readOnly {
+			// The master sets the version of the new databases
+			return core.DBVersion, nil
+		}
 		_, err = conn.Do("SET", core.DBVersionKey, core.DBVersion)
 		return core.DBVersion, err
 	} else if err != nil {
@@ -6,6 +6,7 @@ package upgrader
 import (
 	"github.com/etix/mirrorbits/database/interfaces"
 	v1 "github.com/etix/mirrorbits/database/v1"
+	v2 "github.com/etix/mirrorbits/database/v2"
 )
 
 // Upgrader is an interface to implement a database upgrade strategy
@@ -18,6 +19,8 @@ func GetUpgrader(redis interfaces.Redis, version int) Upgrader {
 	switch version {
 	case 1:
 		return v1.NewUpgraderV1(redis)
+	case 2:
+		return v2.NewUpgraderV2(redis)
 	}
 	return nil
 }
@@ -0,0 +1,140 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package v2
+
+import (
+	"path"
+	"strings"
+
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/database/interfaces"
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+)
+
+// The formats below are those of version 2 and must not follow the
+// changes made to the database package afterwards
+
+const (
+	oldPrefix = "FILEMIRRORS_"
+	newPrefix = "DIRMIRRORS_"
+	tmpPrefix = "V2_DIRMIRRORS_"
+)
+
+// NewUpgraderV2 upgrades the database from version 1 to 2
+func NewUpgraderV2(redis interfaces.Redis) *Version2 {
+	return &Version2{
+		Redis: redis,
+	}
+}
+
+// Version2 replaces the FILEMIRRORS_<file> sets, one per file, by the
+// DIRMIRRORS_<directory> hashes of bitmaps, one per directory
+type Version2 struct {
+	Redis interfaces.Redis
+}
+
+func (v *Version2) Upgrade() error {
+	conn := v.Redis.UnblockedGet()
+	defer conn.Close()
+
+	// Erase previous work keys (previous failed upgrade?)
+	if err := deleteKeys(conn, tmpPrefix+"*"); err != nil {
+		return err
+	}
+
+	// Build the new hashes aside from the production keys
+	dirs := make(map[string]bool)
+	err := scanKeys(conn, oldPrefix+"*", func(keys []string) error {
+		for _, key := range keys {
+			conn.Send("SMEMBERS", key)
+		}
+		if err := conn.Flush(); err != nil {
+			return errors.WithStack(err)
+		}
+		members := make([][]int, len(keys))
+		for i := range keys {
+			ids, err := redis.Ints(conn.Receive())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			members[i] = ids
+		}
+
+		for i, key := range keys {
+			if len(members[i]) == 0 {
+				continue
+			}
+			file := strings.TrimPrefix(key, oldPrefix)
+			dir := path.Dir(file)
+			dirs[dir] = true
+			conn.Send("HSET", tmpPrefix+dir, path.Base(file), bitmap(members[i]))
+		}
+		// Flush and drain the replies before the next batch
+		_, err := conn.Do("")
+		return errors.WithStack(err)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Start a transaction to atomically and irrevocably set the new version
+	conn.Send("MULTI")
+	for dir := range dirs {
+		conn.Send("RENAME", tmpPrefix+dir, newPrefix+dir)
+	}
+	conn.Send("SET", core.DBVersionKey, 2)
+	if _, err = conn.Do("EXEC"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// <-- At this point, the database is usable by this version of
+	// mirrorbits and the old keys are only left to be removed. An
+	// interruption from now on leaves them unused.
+
+	return deleteKeys(conn, oldPrefix+"*")
+}
+
+// bitmap returns the bitmap of the given mirror IDs, with the bit
+// ordering of SETBIT
+func bitmap(ids []int) []byte {
+	var b []byte
+	for _, id := range ids {
+		n := id / 8
+		if n >= len(b) {
+			b = append(b, make([]byte, n-len(b)+1)...)
+		}
+		b[n] |= 0x80 >> uint(id%8)
+	}
+	return b
+}
+
+// scanKeys calls fn with the keys matching the given pattern
+func scanKeys(conn redis.Conn, pattern string, fn func(keys []string) error) error {
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 1000))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		cursor, _ = redis.Int(values[0], nil)
+		keys, _ := redis.Strings(values[1], nil)
+		if len(keys) > 0 {
+			if err = fn(keys); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// deleteKeys deletes the keys matching the given pattern
+func deleteKeys(conn redis.Conn, pattern string) error {
+	return scanKeys(conn, pattern, func(keys []string) error {
+		_, err := conn.Do("DEL", redis.Args{}.AddFlat(keys)...)
+		return errors.WithStack(err)
+	})
+}
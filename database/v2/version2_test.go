@@ -0,0 +1,100 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/etix/mirrorbits/core"
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+)
+
+// redisMock hands out the same mocked connection
+type redisMock struct {
+	conn redis.Conn
+}
+
+func (r redisMock) Get() redis.Conn          { return r.conn }
+func (r redisMock) UnblockedGet() redis.Conn { return r.conn }
+
+func keys(values ...string) []interface{} {
+	s := make([]interface{}, len(values))
+	for i, v := range values {
+		s[i] = []byte(v)
+	}
+	return s
+}
+
+func TestBitmap(t *testing.T) {
+	tests := []struct {
+		ids    []int
+		bitmap []byte
+	}{
+		{[]int{0}, []byte{0x80}},
+		{[]int{7}, []byte{0x01}},
+		{[]int{8}, []byte{0x00, 0x80}},
+		{[]int{17, 0, 15, 7, 8}, []byte{0x81, 0x81, 0x40}},
+		{[]int{31}, []byte{0x00, 0x00, 0x00, 0x01}},
+	}
+
+	for _, test := range tests {
+		if b := bitmap(test.ids); !reflect.DeepEqual(b, test.bitmap) {
+			t.Errorf("bitmap(%v): expected %x, got %x", test.ids, test.bitmap, b)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	mock := redigomock.NewConn()
+
+	// Leftovers of a previous failed upgrade
+	mock.Command("SCAN", 0, "MATCH", tmpPrefix+"*", "COUNT", 1000).Expect([]interface{}{
+		[]byte("0"), keys("V2_DIRMIRRORS_/old"),
+	})
+	cmdCleanup := mock.Command("DEL", "V2_DIRMIRRORS_/old").Expect(int64(1))
+
+	// The old sets, in two batches
+	mock.Command("SCAN", 0, "MATCH", oldPrefix+"*", "COUNT", 1000).Expect([]interface{}{
+		[]byte("7"), keys("FILEMIRRORS_/d/a", "FILEMIRRORS_/d/b"),
+	}).Expect([]interface{}{
+		[]byte("0"), keys("FILEMIRRORS_/d/a", "FILEMIRRORS_/d/b", "FILEMIRRORS_/e/c", "FILEMIRRORS_/empty"),
+	})
+	mock.Command("SCAN", 7, "MATCH", oldPrefix+"*", "COUNT", 1000).Expect([]interface{}{
+		[]byte("0"), keys("FILEMIRRORS_/e/c", "FILEMIRRORS_/empty"),
+	})
+	mock.Command("SMEMBERS", "FILEMIRRORS_/d/a").Expect(keys("0", "9"))
+	mock.Command("SMEMBERS", "FILEMIRRORS_/d/b").Expect(keys("7"))
+	mock.Command("SMEMBERS", "FILEMIRRORS_/e/c").Expect(keys("8", "3"))
+	mock.Command("SMEMBERS", "FILEMIRRORS_/empty").Expect([]interface{}{})
+
+	// The bitmaps are built aside from the production keys
+	cmdA := mock.Command("HSET", "V2_DIRMIRRORS_/d", "a", []byte{0x80, 0x40}).Expect(int64(1))
+	cmdB := mock.Command("HSET", "V2_DIRMIRRORS_/d", "b", []byte{0x01}).Expect(int64(1))
+	cmdC := mock.Command("HSET", "V2_DIRMIRRORS_/e", "c", []byte{0x10, 0x80}).Expect(int64(1))
+
+	// Then swapped along with the version
+	mock.Command("MULTI").Expect("OK")
+	cmdRenameD := mock.Command("RENAME", "V2_DIRMIRRORS_/d", "DIRMIRRORS_/d").Expect("QUEUED")
+	cmdRenameE := mock.Command("RENAME", "V2_DIRMIRRORS_/e", "DIRMIRRORS_/e").Expect("QUEUED")
+	cmdVersion := mock.Command("SET", core.DBVersionKey, 2).Expect("QUEUED")
+	cmdExec := mock.Command("EXEC").Expect([]interface{}{"OK", "OK", "OK"})
+
+	// And the old sets are finally removed
+	cmdDelete := mock.Command("DEL", "FILEMIRRORS_/d/a", "FILEMIRRORS_/d/b", "FILEMIRRORS_/e/c", "FILEMIRRORS_/empty").Expect(int64(4))
+
+	if err := NewUpgraderV2(redisMock{mock}).Upgrade(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, cmd := range []*redigomock.Cmd{cmdCleanup, cmdA, cmdB, cmdC, cmdRenameD, cmdRenameE, cmdVersion, cmdExec, cmdDelete} {
+		if mock.Stats(cmd) != 1 {
+			t.Fatalf("Expected %s %v to be sent once", cmd.Name, cmd.Args)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
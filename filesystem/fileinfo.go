@@ -8,14 +8,19 @@ import (
 )
 
 // FileInfo is a struct embedding details about a file served by
-// the redirector.
+// the redirector. FirstSeen and LastSeen are the times the file was first
+// indexed and last confirmed by a scan of the local repository.
 type FileInfo struct {
-	Path    string    `redis:"-"`
-	Size    int64     `redis:"size" json:",omitempty"`
-	ModTime time.Time `redis:"modTime" json:",omitempty"`
-	Sha1    string    `redis:"sha1" json:",omitempty"`
-	Sha256  string    `redis:"sha256" json:",omitempty"`
-	Md5     string    `redis:"md5" json:",omitempty"`
+	Path      string     `redis:"-"`
+	Size      int64      `redis:"size" json:",omitempty"`
+	ModTime   time.Time  `redis:"modTime" json:",omitempty"`
+	Sha1      string     `redis:"sha1" json:",omitempty"`
+	Sha256    string     `redis:"sha256" json:",omitempty"`
+	Md5       string     `redis:"md5" json:",omitempty"`
+	Sha512    string     `redis:"sha512" json:",omitempty"`
+	Blake2b   string     `redis:"blake2b" json:",omitempty"`
+	FirstSeen *time.Time `redis:"-" json:",omitempty"`
+	LastSeen  *time.Time `redis:"-" json:",omitempty"`
 }
 
 // NewFileInfo returns a new FileInfo object
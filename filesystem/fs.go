@@ -47,6 +47,25 @@ func EvaluateFilePath(repository, urlpath string) (string, error) {
 	return fpath[len(repository):], nil
 }
 
+// EvaluateFilePathFollow is like EvaluateFilePath except that the symbolic
+// links pointing outside of the repository are followed: the file is then
+// evaluated as the path of the link instead of being refused
+func EvaluateFilePathFollow(repository, urlpath string) (string, error) {
+	p, err := EvaluateFilePath(repository, urlpath)
+	if err != ErrOutsideRepo {
+		return p, err
+	}
+
+	fpath, err := filepath.Abs(repository + urlpath)
+	if err != nil {
+		return "", err
+	}
+	if !IsInRepository(repository, fpath) {
+		return "", ErrOutsideRepo
+	}
+	return fpath[len(repository):], nil
+}
+
 // IsInRepository ensures that the given file path is contained in the repository
 func IsInRepository(repository, filePath string) bool {
 	if filePath == repository {
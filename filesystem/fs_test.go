@@ -0,0 +1,57 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluateFilePathFollow(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "mirrorbits-fs")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, "v2"), 0755); err != nil {
+		t.Fatalf("Unable to create the repository: %s", err)
+	}
+	for _, file := range []string{filepath.Join(repo, "v2", "file"), filepath.Join(tmp, "outside")} {
+		if err := ioutil.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Unable to create %s: %s", file, err)
+		}
+	}
+	os.Symlink("v2", filepath.Join(repo, "latest"))
+	os.Symlink(filepath.Join(tmp, "outside"), filepath.Join(repo, "external"))
+
+	if _, err := EvaluateFilePath(repo, "/external"); err != ErrOutsideRepo {
+		t.Fatalf("Expected ErrOutsideRepo, got %v", err)
+	}
+
+	for urlpath, expected := range map[string]string{
+		"/v2/file":     "/v2/file",
+		"/latest/file": "/v2/file",
+		"/external":    "/external",
+	} {
+		p, err := EvaluateFilePathFollow(repo, urlpath)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", urlpath, err)
+		}
+		if p != expected {
+			t.Fatalf("%s: expected %s, got %s", urlpath, expected, p)
+		}
+	}
+
+	if _, err := EvaluateFilePathFollow(repo, "/../outside"); err != ErrOutsideRepo {
+		t.Fatalf("Expected ErrOutsideRepo, got %v", err)
+	}
+	if _, err := EvaluateFilePathFollow(repo, "/missing"); !os.IsNotExist(err) {
+		t.Fatalf("Expected a not exist error, got %v", err)
+	}
+}
@@ -8,12 +8,14 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"hash"
 	"io"
 	"os"
 
 	. "github.com/etix/mirrorbits/config"
+	"golang.org/x/crypto/blake2b"
 )
 
 // HashFile generates a human readable hash of the given file path
@@ -43,6 +45,17 @@ func HashFile(path string) (hashes FileInfo, err error) {
 		defer hmd5.Close()
 		writers = append(writers, hmd5)
 	}
+	if GetConfig().Hashes.SHA512 {
+		hsha512 := newHasher(sha512.New(), &hashes.Sha512)
+		defer hsha512.Close()
+		writers = append(writers, hsha512)
+	}
+	if GetConfig().Hashes.BLAKE2 {
+		b, _ := blake2b.New512(nil) // never fails without a key
+		hblake2b := newHasher(b, &hashes.Blake2b)
+		defer hblake2b.Close()
+		writers = append(writers, hblake2b)
+	}
 
 	if len(writers) == 0 {
 		return
@@ -0,0 +1,87 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package filesystem
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+const packedVersion = 1
+
+var (
+	// ErrInvalidPackedFileInfo is returned when the packed details of a file cannot be decoded
+	ErrInvalidPackedFileInfo = errors.New("invalid packed file info")
+)
+
+// PackFileInfo encodes the size, the modification time and the hashes of
+// a file in a compact binary form, taking about half the memory of the
+// same details stored as separate fields of a hash
+func PackFileInfo(f FileInfo) ([]byte, error) {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+len(f.Sha1)+len(f.Sha256)+len(f.Md5)+len(f.Sha512)+len(f.Blake2b))
+	tmp := make([]byte, binary.MaxVarintLen64)
+
+	buf = append(buf, packedVersion)
+	buf = append(buf, tmp[:binary.PutVarint(tmp, f.Size)]...)
+	buf = append(buf, tmp[:binary.PutVarint(tmp, f.ModTime.Unix())]...)
+	buf = append(buf, tmp[:binary.PutUvarint(tmp, uint64(f.ModTime.Nanosecond()))]...)
+
+	for _, h := range []string{f.Sha1, f.Sha256, f.Md5, f.Sha512, f.Blake2b} {
+		raw, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, tmp[:binary.PutUvarint(tmp, uint64(len(raw)))]...)
+		buf = append(buf, raw...)
+	}
+	return buf, nil
+}
+
+// UnpackFileInfo decodes the details of a file encoded by PackFileInfo
+func UnpackFileInfo(data []byte, f *FileInfo) error {
+	if len(data) == 0 || data[0] != packedVersion {
+		return ErrInvalidPackedFileInfo
+	}
+	data = data[1:]
+
+	size, n := binary.Varint(data)
+	if n <= 0 {
+		return ErrInvalidPackedFileInfo
+	}
+	data = data[n:]
+
+	sec, n := binary.Varint(data)
+	if n <= 0 {
+		return ErrInvalidPackedFileInfo
+	}
+	data = data[n:]
+
+	nsec, n := binary.Uvarint(data)
+	if n <= 0 {
+		return ErrInvalidPackedFileInfo
+	}
+	data = data[n:]
+
+	var hashes [5]string
+	for i := range hashes {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return ErrInvalidPackedFileInfo
+		}
+		data = data[n:]
+		hashes[i] = hex.EncodeToString(data[:length])
+		data = data[length:]
+	}
+
+	f.Size = size
+	f.ModTime = time.Unix(sec, int64(nsec))
+	f.Sha1 = hashes[0]
+	f.Sha256 = hashes[1]
+	f.Md5 = hashes[2]
+	f.Sha512 = hashes[3]
+	f.Blake2b = hashes[4]
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package filesystem
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPackFileInfo(t *testing.T) {
+	f := FileInfo{
+		Size:    123456789,
+		ModTime: time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC),
+		Sha1:    "3f786850e387550fdab836ed7e6dc881de23001b",
+		Sha256:  "87428fc522803d31065e7bce3cf03fe475096631e5e07bbd7a0fde60c4cf25c7",
+		Md5:     "60b725f10c9c85c70d97880dfe8191b3",
+	}
+
+	data, err := PackFileInfo(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var u FileInfo
+	if err := UnpackFileInfo(data, &u); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if u.Size != f.Size || !u.ModTime.Equal(f.ModTime) {
+		t.Fatalf("Size or modification time mismatch: %+v", u)
+	}
+	if u.Sha1 != f.Sha1 || u.Sha256 != f.Sha256 || u.Md5 != f.Md5 || u.Sha512 != "" || u.Blake2b != "" {
+		t.Fatalf("Hashes mismatch: %+v", u)
+	}
+
+	if err := UnpackFileInfo(data[:len(data)-1], &u); err != ErrInvalidPackedFileInfo {
+		t.Fatalf("Expected ErrInvalidPackedFileInfo for truncated data, got %v", err)
+	}
+	if err := UnpackFileInfo([]byte{0}, &u); err != ErrInvalidPackedFileInfo {
+		t.Fatalf("Expected ErrInvalidPackedFileInfo for an unknown version, got %v", err)
+	}
+}
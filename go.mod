@@ -13,9 +13,10 @@ require (
 	github.com/rafaeljusto/redigomock v0.0.0-20190202135759-257e089e14a1
 	github.com/stretchr/testify v1.4.0 // indirect
 	github.com/youtube/vitess v0.0.0-20181105031612-54855ec7b369
-	golang.org/x/crypto v0.0.0-20190911031432-227b76d455e7 // indirect
+	github.com/yuin/gopher-lua v1.1.1
+	golang.org/x/crypto v0.0.0-20190911031432-227b76d455e7
 	golang.org/x/net v0.0.0-20190912160710-24e19bdeb0f2
-	golang.org/x/sys v0.0.0-20190913121621-c3b328c6e5a7 // indirect
+	golang.org/x/sys v0.0.0-20190913121621-c3b328c6e5a7
 	golang.org/x/text v0.3.2 // indirect
 	google.golang.org/genproto v0.0.0-20190911173649-1774047e7e51 // indirect
 	google.golang.org/grpc v1.27.1
@@ -0,0 +1,157 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+)
+
+// fileHash returns the hash of the given type for a file, if available
+func fileHash(fileInfo filesystem.FileInfo, typ string) string {
+	switch typ {
+	case "md5":
+		return fileInfo.Md5
+	case "sha1":
+		return fileInfo.Sha1
+	case "sha256":
+		return fileInfo.Sha256
+	case "sha512":
+		return fileInfo.Sha512
+	case "blake2b":
+		return fileInfo.Blake2b
+	}
+	return ""
+}
+
+// writeChecksum writes the hash of a file using the format of the
+// coreutils' *sum tools. The times the file was first and last seen in
+// the local repository are given in the X-First-Seen and X-Last-Seen
+// headers when known.
+func writeChecksum(w http.ResponseWriter, hash string, fileInfo filesystem.FileInfo) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	if fileInfo.FirstSeen != nil {
+		w.Header().Set("X-First-Seen", fileInfo.FirstSeen.UTC().Format(http.TimeFormat))
+	}
+	if fileInfo.LastSeen != nil {
+		w.Header().Set("X-Last-Seen", fileInfo.LastSeen.UTC().Format(http.TimeFormat))
+	}
+	w.Write([]byte(fmt.Sprintf("%s  %s", hash, filepath.Base(fileInfo.Path))))
+}
+
+// virtualChecksumHandler serves the checksum of a file when the requested
+// path doesn't exist in the repository but ends with one of the configured
+// checksum suffixes. It returns false if the request wasn't handled.
+func (h *HTTP) virtualChecksumHandler(w http.ResponseWriter, r *http.Request) bool {
+	cfg := GetConfig().VirtualChecksums
+
+	dir, file := path.Split(r.URL.Path)
+	if cfg.SHA256SUMS != "" && file == cfg.SHA256SUMS {
+		return h.checksumListHandler(w, r, dir)
+	}
+
+	for _, c := range []struct {
+		suffix string
+		typ    string
+	}{
+		{cfg.MD5, "md5"},
+		{cfg.SHA1, "sha1"},
+		{cfg.SHA256, "sha256"},
+		{cfg.SHA512, "sha512"},
+		{cfg.BLAKE2, "blake2b"},
+	} {
+		if c.suffix == "" || !strings.HasSuffix(file, c.suffix) || file == c.suffix {
+			continue
+		}
+
+		urlPath, err := evaluateFilePath(GetConfig().Repository, strings.TrimSuffix(r.URL.Path, c.suffix))
+		if err != nil || GetConfig().IsEmbargoed(urlPath) || GetConfig().IsPathExcluded(urlPath) {
+			continue
+		}
+
+		fileInfo, err := h.cache.GetFileInfo(urlPath)
+		if err != nil {
+			log.Errorf("Error while fetching Fileinfo: %s", err.Error())
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return true
+		}
+
+		hash := fileHash(fileInfo, c.typ)
+		if len(hash) == 0 {
+			http.Error(w, "Hash type not supported", http.StatusNotFound)
+			return true
+		}
+
+		writeChecksum(w, hash, fileInfo)
+		return true
+	}
+	return false
+}
+
+// checksumListHandler serves the SHA256 checksums of all the files
+// contained in the given directory of the repository
+func (h *HTTP) checksumListHandler(w http.ResponseWriter, r *http.Request, dir string) bool {
+	repository := GetConfig().Repository
+
+	dirPath, err := evaluateFilePath(repository, dir)
+	if err != nil {
+		return false
+	}
+
+	entries, err := ioutil.ReadDir(repository + dirPath)
+	if err != nil {
+		return false
+	}
+
+	if !GetConfig().Hashes.SHA256 {
+		http.Error(w, "Hash type not supported", http.StatusNotFound)
+		return true
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		if e.Mode()&os.ModeSymlink != 0 {
+			// Follow the symlink to make sure it targets a regular file
+			e, err = os.Stat(filepath.Join(repository+dirPath, e.Name()))
+			if err != nil {
+				continue
+			}
+		}
+		if !e.Mode().IsRegular() {
+			continue
+		}
+
+		filePath, err := evaluateFilePath(repository, path.Join(dir, e.Name()))
+		if err != nil || GetConfig().IsEmbargoed(filePath) || GetConfig().IsPathExcluded(filePath) {
+			continue
+		}
+
+		fileInfo, err := h.cache.GetFileInfo(filePath)
+		if err != nil {
+			log.Errorf("Error while fetching Fileinfo: %s", err.Error())
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return true
+		}
+
+		// Files not yet indexed have no hash
+		if len(fileInfo.Sha256) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "%s  %s\n", fileInfo.Sha256, e.Name())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	w.Write(buf.Bytes())
+	return true
+}
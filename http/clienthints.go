@@ -0,0 +1,78 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+)
+
+// maxHintLength is the maximum length of a protocol given by a proxy
+const maxHintLength = 16
+
+// ClientHints describes the protocols negotiated by a client. Mirrorbits
+// running behind a proxy, the TLS fields are only known when the proxy
+// forwards them (see ClientHintsHeaders).
+type ClientHints struct {
+	Proto string
+	TLS   string
+	ALPN  string
+}
+
+// getClientHints returns the protocols negotiated for the given request.
+// The headers giving the protocols negotiated with the proxy are only
+// honored when set by a trusted proxy.
+func getClientHints(r *http.Request) ClientHints {
+	hints := ClientHints{
+		Proto: r.Proto,
+	}
+	if !network.FromTrustedProxy(r) {
+		return hints
+	}
+
+	headers := GetConfig().ClientHintsHeaders
+	if proto := hintHeader(r, headers.Proto); proto != "" {
+		hints.Proto = proto
+	}
+	// TLSv1.3 (nginx, HAProxy) is shortened to 1.3
+	hints.TLS = strings.TrimPrefix(hintHeader(r, headers.TLS), "TLSv")
+	if hints.TLS == "1" {
+		hints.TLS = "1.0"
+	}
+	hints.ALPN = hintHeader(r, headers.ALPN)
+	return hints
+}
+
+// hintHeader returns the value of the given header, or an empty string if
+// it can't be the name of a protocol
+func hintHeader(r *http.Request, name string) string {
+	if name == "" {
+		return ""
+	}
+	value := strings.TrimSpace(r.Header.Get(name))
+	if len(value) > maxHintLength {
+		return ""
+	}
+	for _, c := range value {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("./-_", c)) {
+			return ""
+		}
+	}
+	return value
+}
+
+// String returns the hints in the format of the downloads log
+func (c ClientHints) String() string {
+	s := "proto:" + c.Proto
+	if c.TLS != "" {
+		s += " tls:" + c.TLS
+	}
+	if c.ALPN != "" {
+		s += " alpn:" + c.ALPN
+	}
+	return s
+}
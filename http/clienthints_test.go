@@ -0,0 +1,57 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestGetClientHints(t *testing.T) {
+	err := PrepareConfigTest(`Repository: /srv/repo
+TrustedProxies: [10.0.0.1]
+ClientHintsHeaders:
+    Proto: X-Proto
+    TLS: X-TLS-Version
+    ALPN: X-ALPN`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer SetConfiguration(&Configuration{})
+
+	r := httptest.NewRequest("GET", "/file.tgz", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	if s := getClientHints(r).String(); s != "proto:HTTP/1.1" {
+		t.Fatalf("Unexpected hints %q", s)
+	}
+
+	r.Header.Set("X-Proto", "HTTP/2.0")
+	r.Header.Set("X-TLS-Version", "TLSv1.3")
+	r.Header.Set("X-ALPN", "h2")
+	hints := getClientHints(r)
+	if hints.TLS != "1.3" || hints.String() != "proto:HTTP/2.0 tls:1.3 alpn:h2" {
+		t.Fatalf("Unexpected hints %q", hints.String())
+	}
+
+	r.Header.Set("X-TLS-Version", "TLSv1")
+	r.Header.Set("X-ALPN", "h2 spoofed")
+	if hints := getClientHints(r); hints.TLS != "1.0" || hints.ALPN != "" {
+		t.Fatalf("Unexpected hints %+v", hints)
+	}
+
+	// The headers of an untrusted host are ignored
+	r.RemoteAddr = "192.0.2.1:1234"
+	if s := getClientHints(r).String(); s != "proto:HTTP/1.1" {
+		t.Fatalf("Expected the headers to be ignored, got %q", s)
+	}
+
+	// A local proxy connected to a unix socket is trusted
+	r.RemoteAddr = "@"
+	if s := getClientHints(r).String(); s != "proto:HTTP/2.0 tls:1.0" {
+		t.Fatalf("Expected the headers to be honored, got %q", s)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"path"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/network"
+)
+
+// consensusFileInfo rebuilds the details of a file missing from the local
+// repository using the information reported by the mirrors still carrying
+// it. It returns false if not enough mirrors agree on its size and
+// modification time.
+func (h *HTTP) consensusFileInfo(urlPath string) (filesystem.FileInfo, bool, error) {
+	cfg := GetConfig().ConsensusFallback
+	if !cfg.Enabled {
+		return filesystem.FileInfo{}, false, nil
+	}
+
+	urlPath = path.Clean("/" + urlPath)
+	if GetConfig().IsEmbargoed(urlPath) || GetConfig().IsPathExcluded(urlPath) {
+		return filesystem.FileInfo{}, false, nil
+	}
+
+	mlist, err := h.cache.GetMirrors(urlPath, network.GeoIPRecord{})
+	if err != nil {
+		return filesystem.FileInfo{}, false, err
+	}
+
+	type version struct {
+		size    int64
+		modTime int64
+	}
+
+	votes := make(map[version]int)
+	var best version
+	for _, m := range mlist {
+		if !m.Enabled || m.FileInfo == nil || m.FileInfo.Size < 0 {
+			continue
+		}
+		v := version{size: m.FileInfo.Size}
+		if !m.FileInfo.ModTime.IsZero() {
+			v.modTime = m.FileInfo.ModTime.UnixNano()
+		}
+		votes[v]++
+		if votes[v] > votes[best] {
+			best = v
+		}
+	}
+
+	if votes[best] == 0 || votes[best] < cfg.MinMirrors {
+		return filesystem.FileInfo{}, false, nil
+	}
+
+	fileInfo := filesystem.FileInfo{
+		Path: urlPath,
+		Size: best.size,
+	}
+	if best.modTime != 0 {
+		fileInfo.ModTime = time.Unix(0, best.modTime)
+	}
+	return fileInfo, true, nil
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // RequestType defines the type of the request
@@ -21,6 +22,9 @@ const (
 	FILESTATS
 	MIRRORSTATS
 	CHECKSUM
+	MIRRORDETAILS
+	SPONSORS
+	REPORT
 
 	UNDEFINED SecureOption = iota
 	WITHTLS
@@ -29,17 +33,24 @@ const (
 
 // Context represents the context of a request
 type Context struct {
-	r             *http.Request
-	w             http.ResponseWriter
-	t             Templates
-	v             url.Values
-	typ           RequestType
-	isMirrorList  bool
-	isMirrorStats bool
-	isFileStats   bool
-	isChecksum    bool
-	isPretty      bool
-	secureOption  SecureOption
+	r               *http.Request
+	w               http.ResponseWriter
+	t               Templates
+	v               url.Values
+	typ             RequestType
+	isMirrorList    bool
+	isMirrorStats   bool
+	isFileStats     bool
+	isChecksum      bool
+	isMirrorDetails bool
+	isSponsors      bool
+	isReport        bool
+	isPretty        bool
+	secureOption    SecureOption
+	clientIP        string
+	renderer        string
+	mirror          string
+	selectionTime   time.Duration
 }
 
 // NewContext returns a new instance of Context
@@ -55,7 +66,17 @@ func NewContext(w http.ResponseWriter, r *http.Request, t Templates) *Context {
 	} else if c.paramBool("mirrorstats") {
 		c.typ = MIRRORSTATS
 		c.isMirrorStats = true
-	} else if c.paramBool("md5") || c.paramBool("sha1") || c.paramBool("sha256") {
+	} else if c.paramBool("mirrordetails") {
+		c.typ = MIRRORDETAILS
+		c.isMirrorDetails = true
+	} else if c.paramBool("sponsors") {
+		c.typ = SPONSORS
+		c.isSponsors = true
+	} else if c.paramBool("report") {
+		c.typ = REPORT
+		c.isReport = true
+	} else if c.paramBool("md5") || c.paramBool("sha1") || c.paramBool("sha256") ||
+		c.paramBool("sha512") || c.paramBool("blake2b") {
 		c.typ = CHECKSUM
 		c.isChecksum = true
 	} else {
@@ -84,6 +105,16 @@ func NewContext(w http.ResponseWriter, r *http.Request, t Templates) *Context {
 	return c
 }
 
+// SetClientIP sets the IP address of the client once resolved
+func (c *Context) SetClientIP(ip string) {
+	c.clientIP = ip
+}
+
+// ClientIP returns the IP address of the client
+func (c *Context) ClientIP() string {
+	return c.clientIP
+}
+
 // Request returns the underlying http.Request of the current request
 func (c *Context) Request() *http.Request {
 	return c.r
@@ -94,6 +125,11 @@ func (c *Context) ResponseWriter() http.ResponseWriter {
 	return c.w
 }
 
+// setResponseWriter replaces the http.ResponseWriter used by the renderers
+func (c *Context) setResponseWriter(w http.ResponseWriter) {
+	c.w = w
+}
+
 // Templates returns the instance of precompiled templates
 func (c *Context) Templates() Templates {
 	return c.t
@@ -104,6 +140,53 @@ func (c *Context) Type() RequestType {
 	return c.typ
 }
 
+// SetRenderer records the renderer used to answer the request
+func (c *Context) SetRenderer(renderer string) {
+	c.renderer = renderer
+}
+
+// SetSelection records the mirror the client is sent to, if any, and the
+// time spent selecting it
+func (c *Context) SetSelection(mirror string, d time.Duration) {
+	c.mirror = mirror
+	c.selectionTime = d
+}
+
+// Mirror returns the name of the mirror the client is sent to
+func (c *Context) Mirror() string {
+	return c.mirror
+}
+
+// SelectionTime returns the time spent selecting the mirrors
+func (c *Context) SelectionTime() time.Duration {
+	return c.selectionTime
+}
+
+// HandlerName returns the name under which the responses to
+// the request are accounted
+func (c *Context) HandlerName() string {
+	if c.renderer != "" {
+		return strings.ToLower(c.renderer)
+	}
+	switch c.typ {
+	case MIRRORLIST:
+		return "mirrorlist"
+	case FILESTATS:
+		return "filestats"
+	case MIRRORSTATS:
+		return "mirrorstats"
+	case CHECKSUM:
+		return "checksum"
+	case MIRRORDETAILS:
+		return "mirrordetails"
+	case SPONSORS:
+		return "sponsors"
+	case REPORT:
+		return "report"
+	}
+	return "standard"
+}
+
 // IsMirrorlist returns true if the mirror list has been requested
 func (c *Context) IsMirrorlist() bool {
 	return c.isMirrorList
@@ -124,6 +207,21 @@ func (c *Context) IsChecksum() bool {
 	return c.isChecksum
 }
 
+// IsMirrorDetails returns true if the mirror details has been requested
+func (c *Context) IsMirrorDetails() bool {
+	return c.isMirrorDetails
+}
+
+// IsSponsors returns true if the sponsors page has been requested
+func (c *Context) IsSponsors() bool {
+	return c.isSponsors
+}
+
+// IsReport returns true if a client is reporting an issue with a mirror
+func (c *Context) IsReport() bool {
+	return c.isReport
+}
+
 // IsPretty returns true if the pretty json has been requested
 func (c *Context) IsPretty() bool {
 	return c.isPretty
@@ -0,0 +1,119 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	"github.com/etix/mirrorbits/utils"
+)
+
+const (
+	defaultDetailsLogs = 20
+	maxDetailsLogs     = 500
+)
+
+// MirrorDetails contains the details of a mirror exposed to the operators
+type MirrorDetails struct {
+	ID            int
+	Name          string
+	Enabled       bool
+	Up            bool
+	ExcludeReason string    `json:",omitempty"`
+	StateSince    time.Time `json:",omitempty"`
+	AdminName     string    `json:",omitempty"`
+	AdminEmail    string    `json:",omitempty"`
+	Comment       string    `json:",omitempty"`
+	Logs          []string
+}
+
+// checkDetailsAccess returns true if the request is authorized to access
+// the mirror details. The page exposes the contacts of the mirrors and is
+// thus disabled unless its access is restricted.
+func checkDetailsAccess(w http.ResponseWriter, r *http.Request) bool {
+	access := &GetConfig().MirrorDetailsAccess
+	if !access.IsRestricted() {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return false
+	}
+	return network.CheckAccess(w, r, access)
+}
+
+func (h *HTTP) mirrorDetailsHandler(w http.ResponseWriter, r *http.Request, ctx *Context) {
+	if !checkDetailsAccess(w, r) {
+		return
+	}
+
+	maxLogs := defaultDetailsLogs
+	if v, err := strconv.Atoi(ctx.QueryParam("logs")); err == nil && v >= 0 {
+		maxLogs = utils.Min(v, maxDetailsLogs)
+	}
+
+	mirrorsIDs, err := h.redis.GetListOfMirrors()
+	if err != nil {
+		http.Error(w, "Cannot fetch the list of mirrors", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]MirrorDetails, 0, len(mirrorsIDs))
+	for id := range mirrorsIDs {
+		m, err := h.cache.GetMirror(id)
+		if err != nil {
+			log.Errorf("Cannot fetch mirror details: %s", err.Error())
+			http.Error(w, "Cannot fetch mirror details", http.StatusInternalServerError)
+			return
+		}
+
+		details := MirrorDetails{
+			ID:            m.ID,
+			Name:          m.Name,
+			Enabled:       m.Enabled,
+			Up:            m.Up,
+			ExcludeReason: m.ExcludeReason,
+			StateSince:    m.StateSince.Time,
+			AdminName:     m.AdminName,
+			AdminEmail:    m.AdminEmail,
+			Comment:       m.Comment,
+			Logs:          []string{},
+		}
+
+		if maxLogs > 0 {
+			logs, _, err := mirrors.ReadLogs(h.redis, id, mirrors.LogFilter{Max: maxLogs})
+			if err != nil {
+				log.Errorf("Cannot fetch mirror logs: %s", err.Error())
+			} else {
+				details.Logs = logs
+			}
+		}
+
+		results = append(results, details)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	var output []byte
+	if ctx.IsPretty() {
+		output, err = json.MarshalIndent(results, "", "    ")
+	} else {
+		output, err = json.Marshal(results)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Content-Length", strconv.Itoa(len(output)))
+	w.Write(output)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestCheckDetailsAccess(t *testing.T) {
+	defer SetConfiguration(&Configuration{})
+
+	check := func(remoteAddr string, prepare func(r *http.Request)) int {
+		r := httptest.NewRequest("GET", "/?mirrordetails", nil)
+		r.RemoteAddr = remoteAddr
+		if prepare != nil {
+			prepare(r)
+		}
+		w := httptest.NewRecorder()
+		if checkDetailsAccess(w, r) {
+			return http.StatusOK
+		}
+		return w.Code
+	}
+
+	// The page is disabled unless restricted
+	SetConfiguration(&Configuration{})
+	if code := check("192.0.2.1:1234", nil); code != http.StatusNotFound {
+		t.Fatalf("Expected the page to be disabled, got %d", code)
+	}
+
+	err := PrepareConfigTest(`Repository: /srv/repo
+TrustedProxies: [127.0.0.1/32]
+MirrorDetailsAccess:
+    Networks: [10.0.0.0/8]
+    Tokens: [secret-token]
+    Username: noc
+    Password: secret`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		prepare    func(r *http.Request)
+		code       int
+	}{
+		{"allowed network", "10.1.2.3:1234", nil, http.StatusOK},
+		{"anonymous", "192.0.2.1:1234", nil, http.StatusUnauthorized},
+		{"basic auth", "192.0.2.1:1234", func(r *http.Request) { r.SetBasicAuth("noc", "secret") }, http.StatusOK},
+		{"wrong password", "192.0.2.1:1234", func(r *http.Request) { r.SetBasicAuth("noc", "wrong") }, http.StatusUnauthorized},
+		{"token", "192.0.2.1:1234", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret-token") }, http.StatusOK},
+		{"spoofed header", "192.0.2.1:1234", func(r *http.Request) { r.Header.Set("X-Forwarded-For", "10.1.2.3") }, http.StatusUnauthorized},
+		{"trusted proxy", "127.0.0.1:1234", func(r *http.Request) { r.Header.Set("X-Forwarded-For", "10.1.2.3") }, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		if code := check(test.remoteAddr, test.prepare); code != test.code {
+			t.Errorf("%s: expected %d, got %d", test.name, test.code, code)
+		}
+	}
+}
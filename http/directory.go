@@ -0,0 +1,168 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+	"github.com/gomodule/redigo/redis"
+)
+
+// DirectoryEntry is a file or a sub-directory of a directory listing
+type DirectoryEntry struct {
+	Name    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// DirectoryPage contains the values needed to generate the directory listing
+type DirectoryPage struct {
+	Path        string
+	Entries     []DirectoryEntry
+	LocalJSPath string
+}
+
+// directoryHandler answers the requests of the directories indexed by the
+// scans of the local repository, according to DirectoryIndex. It returns
+// false if the request wasn't handled.
+func (h *HTTP) directoryHandler(w http.ResponseWriter, r *http.Request, ctx *Context, urlPath string) bool {
+	mode := GetConfig().DirectoryIndex
+	if mode != "list" && mode != "redirect" {
+		return false
+	}
+
+	if fi, err := os.Stat(GetConfig().Repository + urlPath); err != nil || !fi.IsDir() {
+		return false
+	}
+
+	dir := urlPath + "/"
+	entries, err := h.directoryEntries(dir)
+	if err != nil {
+		log.Errorf("Error while fetching the directory entries: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	if len(entries) == 0 {
+		// Not indexed
+		return false
+	}
+
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+		return true
+	}
+
+	if mode == "redirect" && h.directoryRedirect(w, r, ctx, dir, entries) {
+		return true
+	}
+
+	page := DirectoryPage{
+		Path:        dir,
+		LocalJSPath: GetConfig().LocalJSPath,
+	}
+	for _, e := range entries {
+		entry := DirectoryEntry{
+			Name:  strings.TrimSuffix(e, "/"),
+			IsDir: strings.HasSuffix(e, "/"),
+		}
+		if !entry.IsDir {
+			fileInfo, err := h.cache.GetFileInfo(dir + e)
+			if err != nil {
+				log.Errorf("Error while fetching Fileinfo: %s", err.Error())
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return true
+			}
+			entry.Size = fileInfo.Size
+			entry.ModTime = fileInfo.ModTime
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = ctx.Templates().directory.ExecuteTemplate(w, "base", page)
+	if err != nil {
+		log.Errorf("HTTP error: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return true
+}
+
+// directoryEntries returns the entries of the given directory, ending with
+// a slash, the sub-directories ending with a slash as well. The entries
+// under embargo or excluded from the index are left out.
+func (h *HTTP) directoryEntries(dir string) ([]string, error) {
+	conn := h.redis.Get()
+	defer conn.Close()
+
+	// The directories are indexed without their trailing slash but the root
+	key := strings.TrimSuffix(dir, "/")
+	if key == "" {
+		key = "/"
+	}
+
+	entries, err := redis.Strings(conn.Do("SMEMBERS", "DIR_"+key))
+	if err != nil {
+		return nil, err
+	}
+
+	list := entries[:0]
+	for _, e := range entries {
+		p := dir + strings.TrimSuffix(e, "/")
+		if GetConfig().IsEmbargoed(p) || GetConfig().IsPathExcluded(p) {
+			continue
+		}
+		list = append(list, e)
+	}
+	return list, nil
+}
+
+// directoryRedirect redirects the client to the given directory on the
+// mirror selected for one of the files it contains. It returns false if no
+// mirror is available.
+func (h *HTTP) directoryRedirect(w http.ResponseWriter, r *http.Request, ctx *Context, dir string, entries []string) bool {
+	// Find a file within the directory, possibly in a sub-directory
+	file := ""
+	for search := dir; file == "" && len(entries) > 0; {
+		for _, e := range entries {
+			if !strings.HasSuffix(e, "/") {
+				file = search + e
+				break
+			}
+		}
+		if file == "" {
+			search += entries[0]
+			var err error
+			if entries, err = h.directoryEntries(search); err != nil {
+				return false
+			}
+		}
+	}
+	if file == "" {
+		return false
+	}
+
+	fileInfo, err := h.cache.GetFileInfo(file)
+	if err != nil {
+		return false
+	}
+
+	remoteIP := network.ClientIP(r)
+	clientInfo := h.geoip.GetRecord(remoteIP)
+	h.overrideLocation(r, ctx, &clientInfo)
+
+	mlist, _, err := h.engine.Selection(ctx, h.cache, &fileInfo, clientInfo)
+	if err != nil || len(mlist) == 0 {
+		return false
+	}
+
+	http.Redirect(w, r, mlist[0].HttpURL+strings.TrimPrefix(dir, "/"), http.StatusFound)
+	return true
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	"github.com/etix/mirrorbits/utils"
+)
+
+const fallbackCheckTimeout = 20 * time.Second
+
+// fallbackChecker periodically checks the health of the fallbacks, which
+// are needed the most when the database, and thus the state of the
+// mirrors, is unavailable
+type fallbackChecker struct {
+	sync.RWMutex
+	down   map[string]string // URL -> reason
+	client http.Client
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newFallbackChecker() *fallbackChecker {
+	c := &fallbackChecker{
+		down: make(map[string]string),
+		client: http.Client{
+			Timeout: fallbackCheckTimeout,
+		},
+		stop: make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.loop()
+	return c
+}
+
+// Stop stops the health checks
+func (c *fallbackChecker) Stop() {
+	select {
+	case <-c.stop:
+		return
+	default:
+		close(c.stop)
+	}
+	c.wg.Wait()
+}
+
+func (c *fallbackChecker) loop() {
+	defer c.wg.Done()
+	for {
+		c.checkAll()
+		interval := time.Duration(GetConfig().CheckInterval) * time.Minute
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// checkAll checks all the configured fallbacks concurrently
+func (c *fallbackChecker) checkAll() {
+	var wg sync.WaitGroup
+	urls := make(map[string]bool)
+	for _, f := range GetConfig().Fallbacks {
+		urls[utils.ConcatURL(f.URL, f.HealthCheckPath)] = true
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			err := c.check(url)
+
+			c.Lock()
+			defer c.Unlock()
+			_, wasDown := c.down[url]
+			if err != nil {
+				if !wasDown {
+					log.Warningf("Fallback %s is down: %s", url, err)
+				}
+				c.down[url] = err.Error()
+			} else if wasDown {
+				log.Noticef("Fallback %s is up", url)
+				delete(c.down, url)
+			}
+		}(utils.ConcatURL(f.URL, f.HealthCheckPath))
+	}
+	wg.Wait()
+
+	// Forget the fallbacks removed from the configuration
+	c.Lock()
+	for url := range c.down {
+		if !urls[url] {
+			delete(c.down, url)
+		}
+	}
+	c.Unlock()
+}
+
+func (c *fallbackChecker) check(url string) error {
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mirrorbits/"+core.VERSION+" FALLBACK CHECK")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// isUp returns false if the last health check of the given URL failed
+func (c *fallbackChecker) isUp(url string) bool {
+	c.RLock()
+	defer c.RUnlock()
+	_, down := c.down[url]
+	return !down
+}
+
+// appendFallbacks adds the configured fallbacks to the list of mirrors,
+// leaving out the ones down unless all of them are. The fallbacks equally
+// close to the client are ordered randomly according to their weight.
+func (h *HTTP) appendFallbacks(mlist mirrors.Mirrors, clientInfo network.GeoIPRecord) mirrors.Mirrors {
+	var up, down mirrors.Mirrors
+	for i, f := range GetConfig().Fallbacks {
+		m := mirrors.Mirror{
+			ID:            i * -1,
+			Name:          fmt.Sprintf("fallback%d", i),
+			HttpURL:       f.URL,
+			CountryCodes:  strings.ToUpper(f.CountryCode),
+			CountryFields: []string{strings.ToUpper(f.CountryCode)},
+			ContinentCode: strings.ToUpper(f.ContinentCode),
+			Weight:        float32(f.Weight),
+		}
+		if h.fallbacks == nil || h.fallbacks.isUp(utils.ConcatURL(f.URL, f.HealthCheckPath)) {
+			up = append(up, m)
+		} else {
+			down = append(down, m)
+		}
+	}
+	if len(up) == 0 {
+		// Better a fallback possibly down than no answer at all
+		up = down
+	}
+
+	weightedShuffle(up)
+	mlist = append(mlist, up...)
+	if clientInfo.IsValid() {
+		sort.Stable(mirrors.ByRank{Mirrors: mlist, ClientInfo: clientInfo})
+	}
+	return mlist
+}
+
+// weightedShuffle orders the mirrors randomly, the ones with a higher
+// weight being more likely to come first
+func weightedShuffle(list mirrors.Mirrors) {
+	for i := range list {
+		var total float32
+		for _, m := range list[i:] {
+			total += m.Weight
+		}
+		r := rand.Float32() * total
+		for j := i; j < len(list); j++ {
+			r -= list[j].Weight
+			if r < 0 || j == len(list)-1 {
+				list[i], list[j] = list[j], list[i]
+				break
+			}
+		}
+	}
+}
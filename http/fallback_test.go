@@ -0,0 +1,95 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAppendFallbacks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down/" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	c := Configuration{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+Fallbacks:
+    - URL: %[1]s/fr1/
+      CountryCode: fr
+      ContinentCode: eu
+      Weight: 1
+    - URL: %[1]s/fr2/
+      CountryCode: fr
+      ContinentCode: eu
+      Weight: 3
+    - URL: %[1]s/down/
+      CountryCode: fr
+      ContinentCode: eu
+      Weight: 1
+    - URL: %[1]s/us/
+      CountryCode: us
+      ContinentCode: na
+      Weight: 1
+`, server.URL)), &c)
+	if err != nil {
+		t.Fatalf("Unable to parse the configuration: %s", err)
+	}
+	SetConfiguration(&c)
+	defer SetConfiguration(&Configuration{})
+
+	h := &HTTP{fallbacks: &fallbackChecker{down: make(map[string]string)}}
+	h.fallbacks.checkAll()
+
+	clientInfo := network.GeoIPRecord{CountryCode: "FR", ContinentCode: "EU", ASNum: 1}
+	first := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		mlist := h.appendFallbacks(nil, clientInfo)
+		if len(mlist) != 3 {
+			t.Fatalf("Expected the fallback down to be left out, got %d fallbacks", len(mlist))
+		}
+		if mlist[2].Name != "fallback3" {
+			t.Fatalf("Expected the fallback of another country last, got %s", mlist[2].Name)
+		}
+		first[mlist[0].Name]++
+	}
+	// fallback1 weighs three times more than fallback0
+	if first["fallback1"] < 600 || first["fallback1"] > 900 {
+		t.Fatalf("Expected fallback1 first about 750 times, got %d", first["fallback1"])
+	}
+
+	// All down
+	c.Fallbacks = c.Fallbacks[2:3]
+	SetConfiguration(&c)
+	h.fallbacks.checkAll()
+	if mlist := h.appendFallbacks(nil, clientInfo); len(mlist) != 1 {
+		t.Fatalf("Expected the fallbacks to be used when they are all down, got %d fallbacks", len(mlist))
+	}
+	if len(h.fallbacks.down) != 1 {
+		t.Fatalf("Expected the removed fallbacks to be forgotten, got %v", h.fallbacks.down)
+	}
+}
+
+func TestWeightedShuffle(t *testing.T) {
+	list := mirrors.Mirrors{{ID: 1, Weight: 1}, {ID: 2, Weight: 1}, {ID: 3, Weight: 1}}
+	weightedShuffle(list)
+
+	seen := make(map[int]bool)
+	for _, m := range list {
+		seen[m.ID] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("Expected the mirrors to be kept, got %v", list)
+	}
+}
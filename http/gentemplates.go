@@ -0,0 +1,56 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+//go:build ignore
+// +build ignore
+
+// This program generates templates_default.go, embedding the default
+// templates into the binary. It is invoked by running go generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	files, err := filepath.Glob("../templates/*.html")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gentemplates.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package http\n\n")
+	buf.WriteString("// defaultTemplates holds the templates shipped with mirrorbits,\n")
+	buf.WriteString("// used when they are missing from the templates directory\n")
+	buf.WriteString("var defaultTemplates = map[string]string{\n")
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		value := "`" + string(content) + "`"
+		if strings.Contains(string(content), "`") {
+			value = strconv.Quote(string(content))
+		}
+		fmt.Fprintf(&buf, "%q: %s,\n", filepath.Base(file), value)
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("templates_default.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Functions of the selection script called for each request
+const (
+	hookBeforeSelection = "before_selection"
+	hookAfterSelection  = "after_selection"
+)
+
+// Limits of the interpreters running the selection script
+const (
+	hookCallStackSize   = 64
+	hookRegistrySize    = 1024
+	hookRegistryMaxSize = 64 * 1024
+)
+
+// HookRequest is given to the selection script along with the candidate
+// mirrors of a request, ordered by preference after the selection
+type HookRequest struct {
+	Path       string
+	Size       int64
+	Mirrorlist bool
+	Client     HookClient
+	Mirrors    []HookMirror
+}
+
+// HookClient describes the client of the request
+type HookClient struct {
+	IP            string
+	CountryCode   string
+	ContinentCode string
+	ASNum         uint
+	Latitude      float32
+	Longitude     float32
+}
+
+// HookMirror describes a candidate mirror of the request
+type HookMirror struct {
+	ID            int
+	Name          string
+	HttpURL       string
+	CountryCodes  string
+	ContinentCode string
+	ASNum         uint
+	Tags          string
+	Distance      float32
+	Score         int
+}
+
+// HookResponse is the answer of the selection script. Mirrors holds the IDs
+// of the mirrors to keep in their new order, the other ones being excluded,
+// and is ignored when nil. Fallback forces the use of the fallback mirrors.
+type HookResponse struct {
+	Mirrors  *[]int
+	Fallback bool
+	Reason   string
+}
+
+// hookScript is a compiled selection script along with the interpreters
+// ready to run it, an interpreter running a single request at a time
+type hookScript struct {
+	proto     *lua.FunctionProto
+	functions map[string]bool
+	timeout   time.Duration
+	states    sync.Pool
+}
+
+// hookScripts caches the compiled selection script until the configuration
+// is reloaded
+var hookScripts struct {
+	sync.RWMutex
+	loaded bool
+	path   string
+	script *hookScript
+}
+
+// currentHookScript returns the configured selection script, compiled on
+// first use, or nil if there is none
+func currentHookScript() *hookScript {
+	cfg := GetConfig().SelectionHook
+
+	hookScripts.RLock()
+	loaded, path, script := hookScripts.loaded, hookScripts.path, hookScripts.script
+	hookScripts.RUnlock()
+	if loaded && path == cfg.Script {
+		return script
+	}
+
+	hookScripts.Lock()
+	defer hookScripts.Unlock()
+	if hookScripts.loaded && hookScripts.path == cfg.Script {
+		return hookScripts.script
+	}
+	hookScripts.loaded, hookScripts.path, hookScripts.script = true, cfg.Script, nil
+	if cfg.Script == "" {
+		return nil
+	}
+	script, err := compileHookScript(cfg.Script, time.Duration(cfg.Timeout)*time.Millisecond)
+	if err != nil {
+		// Logged once, until the configuration is reloaded
+		log.Errorf("Unable to load the selection hook: %s", err)
+		return nil
+	}
+	hookScripts.script = script
+	return script
+}
+
+// reloadHookScript compiles the selection script again on its next use
+func reloadHookScript() {
+	hookScripts.Lock()
+	hookScripts.loaded = false
+	hookScripts.script = nil
+	hookScripts.Unlock()
+}
+
+// compileHookScript compiles the given selection script and checks the
+// functions it defines
+func compileHookScript(path string, timeout time.Duration) (*hookScript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chunk, err := parse.Parse(f, path)
+	if err != nil {
+		return nil, err
+	}
+	proto, err := lua.Compile(chunk, path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &hookScript{
+		proto:     proto,
+		functions: make(map[string]bool),
+		timeout:   timeout,
+	}
+	L, err := s.newState()
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range []string{hookBeforeSelection, hookAfterSelection} {
+		s.functions[fn] = L.GetGlobal(fn).Type() == lua.LTFunction
+	}
+	if !s.functions[hookBeforeSelection] && !s.functions[hookAfterSelection] {
+		L.Close()
+		return nil, fmt.Errorf("%s defines neither %s nor %s", path, hookBeforeSelection, hookAfterSelection)
+	}
+	s.states.Put(L)
+	return s, nil
+}
+
+// newState returns an interpreter having run the main chunk of the script,
+// without access to the filesystem
+func (s *hookScript) newState() (*lua.LState, error) {
+	L := lua.NewState(lua.Options{
+		CallStackSize:   hookCallStackSize,
+		RegistrySize:    hookRegistrySize,
+		RegistryMaxSize: hookRegistryMaxSize,
+		SkipOpenLibs:    true,
+	})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	L.Push(L.NewFunctionFromProto(s.proto))
+	if err := L.PCall(0, 0, nil); err != nil {
+		L.Close()
+		return nil, err
+	}
+	return L, nil
+}
+
+// defines returns true if the script defines the given function
+func (s *hookScript) defines(fn string) bool {
+	return s != nil && s.functions[fn]
+}
+
+// call runs the given function of the script within the time budget of
+// the hook and returns its answer
+func (s *hookScript) call(parent context.Context, fn string, req *HookRequest) (*HookResponse, error) {
+	L, _ := s.states.Get().(*lua.LState)
+	if L == nil {
+		var err error
+		if L, err = s.newState(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(parent, s.timeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	err := L.CallByParam(lua.P{
+		Fn:      L.GetGlobal(fn),
+		NRet:    1,
+		Protect: true,
+	}, req.table(L))
+	L.RemoveContext()
+	if err != nil {
+		// The interpreter may have been interrupted anywhere
+		L.Close()
+		return nil, err
+	}
+
+	ret := L.Get(-1)
+	L.SetTop(0)
+	s.states.Put(L)
+	return parseHookResponse(ret)
+}
+
+// table returns the request as a Lua table
+func (r *HookRequest) table(L *lua.LState) *lua.LTable {
+	client := L.NewTable()
+	client.RawSetString("IP", lua.LString(r.Client.IP))
+	client.RawSetString("CountryCode", lua.LString(r.Client.CountryCode))
+	client.RawSetString("ContinentCode", lua.LString(r.Client.ContinentCode))
+	client.RawSetString("ASNum", lua.LNumber(r.Client.ASNum))
+	client.RawSetString("Latitude", lua.LNumber(r.Client.Latitude))
+	client.RawSetString("Longitude", lua.LNumber(r.Client.Longitude))
+
+	mlist := L.CreateTable(len(r.Mirrors), 0)
+	for _, m := range r.Mirrors {
+		t := L.NewTable()
+		t.RawSetString("ID", lua.LNumber(m.ID))
+		t.RawSetString("Name", lua.LString(m.Name))
+		t.RawSetString("HttpURL", lua.LString(m.HttpURL))
+		t.RawSetString("CountryCodes", lua.LString(m.CountryCodes))
+		t.RawSetString("ContinentCode", lua.LString(m.ContinentCode))
+		t.RawSetString("ASNum", lua.LNumber(m.ASNum))
+		t.RawSetString("Tags", lua.LString(m.Tags))
+		t.RawSetString("Distance", lua.LNumber(m.Distance))
+		t.RawSetString("Score", lua.LNumber(m.Score))
+		mlist.Append(t)
+	}
+
+	t := L.NewTable()
+	t.RawSetString("Path", lua.LString(r.Path))
+	t.RawSetString("Size", lua.LNumber(r.Size))
+	t.RawSetString("Mirrorlist", lua.LBool(r.Mirrorlist))
+	t.RawSetString("Client", client)
+	t.RawSetString("Mirrors", mlist)
+	return t
+}
+
+// parseHookResponse returns the answer of the script, nil keeping the
+// mirrors as is
+func parseHookResponse(v lua.LValue) (*HookResponse, error) {
+	if v == lua.LNil {
+		return &HookResponse{}, nil
+	}
+	t, ok := v.(*lua.LTable)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s returned", v.Type())
+	}
+
+	resp := &HookResponse{
+		Fallback: lua.LVAsBool(t.RawGetString("Fallback")),
+		Reason:   lua.LVAsString(t.RawGetString("Reason")),
+	}
+	switch ids := t.RawGetString("Mirrors").(type) {
+	case *lua.LNilType:
+	case *lua.LTable:
+		list := make([]int, 0, ids.Len())
+		for i := 1; i <= ids.Len(); i++ {
+			id, ok := ids.RawGetInt(i).(lua.LNumber)
+			if !ok {
+				return nil, fmt.Errorf("unexpected %s in Mirrors", ids.RawGetInt(i).Type())
+			}
+			list = append(list, int(id))
+		}
+		resp.Mirrors = &list
+	default:
+		return nil, fmt.Errorf("unexpected %s as Mirrors", ids.Type())
+	}
+	return resp, nil
+}
+
+// preSelectionHook submits the mirrors serving the file, before they are
+// filtered and scored, to the before_selection function of the selection
+// script and returns the candidates to consider and the mirrors excluded
+// by the script
+func preSelectionHook(ctx *Context, fileInfo *filesystem.FileInfo, clientInfo network.GeoIPRecord, mlist mirrors.Mirrors) (mirrors.Mirrors, mirrors.Mirrors) {
+	return runSelectionHook(hookBeforeSelection, ctx, fileInfo, clientInfo, mlist, nil)
+}
+
+// selectionHook submits the selected mirrors to the after_selection
+// function of the selection script and returns the mirrors to use and the
+// mirrors excluded by the script, appended to the excluded ones
+func selectionHook(ctx *Context, fileInfo *filesystem.FileInfo, clientInfo network.GeoIPRecord, mlist, excluded mirrors.Mirrors) (mirrors.Mirrors, mirrors.Mirrors) {
+	return runSelectionHook(hookAfterSelection, ctx, fileInfo, clientInfo, mlist, excluded)
+}
+
+// runSelectionHook runs the given function of the selection script. The
+// mirrors are kept as is if the script fails or runs out of time.
+func runSelectionHook(fn string, ctx *Context, fileInfo *filesystem.FileInfo, clientInfo network.GeoIPRecord, mlist, excluded mirrors.Mirrors) (mirrors.Mirrors, mirrors.Mirrors) {
+	if len(mlist) == 0 {
+		return mlist, excluded
+	}
+	script := currentHookScript()
+	if !script.defines(fn) {
+		return mlist, excluded
+	}
+
+	req := &HookRequest{
+		Path:       fileInfo.Path,
+		Size:       fileInfo.Size,
+		Mirrorlist: ctx.IsMirrorlist(),
+		Client: HookClient{
+			IP:            ctx.ClientIP(),
+			CountryCode:   clientInfo.CountryCode,
+			ContinentCode: clientInfo.ContinentCode,
+			ASNum:         clientInfo.ASNum,
+			Latitude:      clientInfo.Latitude,
+			Longitude:     clientInfo.Longitude,
+		},
+	}
+	for _, m := range mlist {
+		req.Mirrors = append(req.Mirrors, HookMirror{
+			ID:            m.ID,
+			Name:          m.Name,
+			HttpURL:       m.HttpURL,
+			CountryCodes:  m.CountryCodes,
+			ContinentCode: m.ContinentCode,
+			ASNum:         m.Asnum,
+			Tags:          m.Tags,
+			Distance:      m.Distance,
+			Score:         m.ComputedScore,
+		})
+	}
+
+	resp, err := script.call(ctx.Request().Context(), fn, req)
+	if err != nil {
+		log.Warningf("Selection hook failed, keeping the mirrors: %s", err)
+		return mlist, excluded
+	}
+	return applyHookResponse(resp, mlist, excluded)
+}
+
+// applyHookResponse reorders and filters the mirrors according to the
+// answer of the script, an empty list leading to the fallback mirrors
+func applyHookResponse(resp *HookResponse, mlist, excluded mirrors.Mirrors) (mirrors.Mirrors, mirrors.Mirrors) {
+	reason := resp.Reason
+	if reason == "" {
+		reason = "Excluded by the selection hook"
+	}
+
+	if resp.Fallback {
+		for _, m := range mlist {
+			m.ExcludeReason = reason
+			excluded = append(excluded, m)
+		}
+		return nil, excluded
+	}
+	if resp.Mirrors == nil {
+		return mlist, excluded
+	}
+
+	position := make(map[int]int, len(*resp.Mirrors))
+	for i, id := range *resp.Mirrors {
+		if _, ok := position[id]; !ok {
+			position[id] = i
+		}
+	}
+
+	kept := make(mirrors.Mirrors, len(*resp.Mirrors))
+	for _, m := range mlist {
+		if i, ok := position[m.ID]; ok {
+			kept[i] = m
+		} else {
+			m.ExcludeReason = reason
+			excluded = append(excluded, m)
+		}
+	}
+
+	// Drop the unknown or duplicate IDs
+	result := kept[:0]
+	for _, m := range kept {
+		if m.ID != 0 {
+			result = append(result, m)
+		}
+	}
+	return result, excluded
+}
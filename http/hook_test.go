@@ -0,0 +1,156 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+)
+
+const testHookScript = `
+function before_selection(req)
+	local kept = {}
+	for _, m in ipairs(req.Mirrors) do
+		if not string.find(m.Tags, "blocked") then
+			table.insert(kept, m.ID)
+		end
+	end
+	return {Mirrors = kept, Reason = "Blocked"}
+end
+
+function after_selection(req)
+	if req.Client.CountryCode ~= "FR" or #req.Mirrors ~= 3 or req.Mirrors[2].Name ~= "m2" then
+		error("unexpected request")
+	end
+	if req.Path == "/reorder.iso" then
+		return {Mirrors = {3, 1, 42}}
+	elseif req.Path == "/fallback.iso" then
+		return {Fallback = true, Reason = "Policy"}
+	elseif req.Path == "/loop.iso" then
+		while true do end
+	elseif req.Path == "/invalid.iso" then
+		return "invalid"
+	end
+	return nil
+end
+`
+
+func hookMirrors() mirrors.Mirrors {
+	return mirrors.Mirrors{
+		{ID: 1, Name: "m1"},
+		{ID: 2, Name: "m2", Tags: "blocked"},
+		{ID: 3, Name: "m3"},
+	}
+}
+
+// setHookScript writes the given selection script and configures it
+func setHookScript(t *testing.T, dir, script string) {
+	path := filepath.Join(dir, "selection.lua")
+	if err := ioutil.WriteFile(path, []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := Configuration{}
+	c.SelectionHook.Script = path
+	c.SelectionHook.Timeout = 50
+	SetConfiguration(&c)
+	reloadHookScript()
+}
+
+func TestSelectionHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetConfiguration(&Configuration{})
+
+	setHookScript(t, dir, testHookScript)
+
+	r := httptest.NewRequest("GET", "/file.iso", nil)
+	ctx := NewContext(nil, r, Templates{})
+	clientInfo := network.GeoIPRecord{CountryCode: "FR"}
+	hook := func(path string) (mirrors.Mirrors, mirrors.Mirrors) {
+		return selectionHook(ctx, &filesystem.FileInfo{Path: path}, clientInfo, hookMirrors(), nil)
+	}
+
+	mlist, excluded := hook("/reorder.iso")
+	if len(mlist) != 2 || mlist[0].ID != 3 || mlist[1].ID != 1 {
+		t.Fatalf("Expected the mirrors 3 and 1, got %v", mlist)
+	}
+	if len(excluded) != 1 || excluded[0].ID != 2 || excluded[0].ExcludeReason == "" {
+		t.Fatalf("Expected the mirror 2 to be excluded, got %v", excluded)
+	}
+
+	mlist, excluded = hook("/fallback.iso")
+	if len(mlist) != 0 || len(excluded) != 3 || excluded[0].ExcludeReason != "Policy" {
+		t.Fatalf("Expected all the mirrors to be excluded, got %v and %v", mlist, excluded)
+	}
+
+	if mlist, _ = hook("/file.iso"); len(mlist) != 3 {
+		t.Fatalf("Expected the selection to be kept, got %v", mlist)
+	}
+
+	// Failures keep the selection as is
+	for _, path := range []string{"/loop.iso", "/invalid.iso"} {
+		if mlist, _ = hook(path); len(mlist) != 3 {
+			t.Fatalf("%s: expected the selection to be kept on failure, got %v", path, mlist)
+		}
+	}
+	if mlist, _ = selectionHook(ctx, &filesystem.FileInfo{Path: "/reorder.iso"}, network.GeoIPRecord{}, hookMirrors(), nil); len(mlist) != 3 {
+		t.Fatalf("Expected the selection to be kept on error, got %v", mlist)
+	}
+
+	// The interpreters still work after a failure
+	if mlist, _ = hook("/reorder.iso"); len(mlist) != 2 {
+		t.Fatalf("Expected the selection to be reordered, got %v", mlist)
+	}
+}
+
+func TestPreSelectionHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetConfiguration(&Configuration{})
+
+	setHookScript(t, dir, testHookScript)
+
+	r := httptest.NewRequest("GET", "/file.iso", nil)
+	ctx := NewContext(nil, r, Templates{})
+	fileInfo := &filesystem.FileInfo{Path: "/file.iso"}
+
+	mlist, excluded := preSelectionHook(ctx, fileInfo, network.GeoIPRecord{}, hookMirrors())
+	if len(mlist) != 2 || mlist[0].ID != 1 || mlist[1].ID != 3 {
+		t.Fatalf("Expected the mirrors 1 and 3, got %v", mlist)
+	}
+	if len(excluded) != 1 || excluded[0].ID != 2 || excluded[0].ExcludeReason != "Blocked" {
+		t.Fatalf("Expected the mirror 2 to be excluded, got %v", excluded)
+	}
+
+	// The script is only reloaded along with the configuration
+	setHookScript(t, dir, `function after_selection(req) return nil end`)
+	if !currentHookScript().defines(hookAfterSelection) || currentHookScript().defines(hookBeforeSelection) {
+		t.Fatalf("Expected the script to be reloaded")
+	}
+	if mlist, _ = preSelectionHook(ctx, fileInfo, network.GeoIPRecord{}, hookMirrors()); len(mlist) != 3 {
+		t.Fatalf("Expected the candidates to be kept, got %v", mlist)
+	}
+
+	// Invalid scripts are ignored
+	for _, script := range []string{`function other() end`, `function (`, `while true do end`} {
+		setHookScript(t, dir, script)
+		if currentHookScript() != nil {
+			t.Fatalf("Expected %q to be refused", script)
+		}
+	}
+}
@@ -4,14 +4,17 @@
 package http
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/textproto"
 	"os"
 	"path/filepath"
 	"sort"
@@ -26,6 +29,7 @@ import (
 	"github.com/etix/mirrorbits/database"
 	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/logs"
+	"github.com/etix/mirrorbits/metrics"
 	"github.com/etix/mirrorbits/mirrors"
 	"github.com/etix/mirrorbits/network"
 	"github.com/etix/mirrorbits/utils"
@@ -54,6 +58,19 @@ const TimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
 
 var unixEpochTime = time.Unix(0, 0)
 
+// standbyRetryAfter is the delay in seconds suggested to the clients
+// while the instance is in standby
+const standbyRetryAfter = 30
+
+const (
+	// defaultStatsHistoryDays is the number of days of download history
+	// shown by default in the mirrorstats page
+	defaultStatsHistoryDays = 30
+	// maxStatsHistoryDays is the maximum number of days of history that
+	// can be requested with the days parameter of the mirrorstats page
+	maxStatsHistoryDays = 90
+)
+
 var (
 	log = logging.MustGetLogger("main")
 )
@@ -63,12 +80,17 @@ type HTTP struct {
 	geoip          *network.GeoIP
 	redis          *database.Redis
 	templates      Templates
-	Listener       *net.Listener
-	server         *graceful.Server
-	serverStopChan <-chan struct{}
+	Listeners      []net.Listener
+	servers        []*graceful.Server
+	serverStopChan chan struct{}
 	stats          *Stats
+	exporter       *metrics.Exporter
 	cache          *mirrors.Cache
 	engine         mirrorSelection
+	clients        recentClients
+	limiter        *rateLimiter
+	fallbacks      *fallbackChecker
+	locations      countryLocations
 	Restarting     bool
 	stopped        bool
 	stoppedMutex   sync.Mutex
@@ -78,8 +100,11 @@ type HTTP struct {
 type Templates struct {
 	*sync.RWMutex
 
-	mirrorlist  *template.Template
-	mirrorstats *template.Template
+	mirrorlist    *template.Template
+	mirrorstats   *template.Template
+	mirrorhistory *template.Template
+	sponsors      *template.Template
+	directory     *template.Template
 }
 
 // HTTPServer is the constructor of the HTTP server
@@ -88,11 +113,28 @@ func HTTPServer(redis *database.Redis, cache *mirrors.Cache) *HTTP {
 	h.redis = redis
 	h.geoip = network.NewGeoIP()
 	h.templates.RWMutex = new(sync.RWMutex)
-	h.templates.mirrorlist = template.Must(h.LoadTemplates("mirrorlist"))
-	h.templates.mirrorstats = template.Must(h.LoadTemplates("mirrorstats"))
+	var err error
+	if h.templates.mirrorlist, err = h.LoadTemplates("mirrorlist"); err != nil {
+		log.Fatal(err.Error())
+	}
+	if h.templates.mirrorstats, err = h.LoadTemplates("mirrorstats"); err != nil {
+		log.Fatal(err.Error())
+	}
+	if h.templates.mirrorhistory, err = h.LoadTemplates("mirrorhistory"); err != nil {
+		log.Fatal(err.Error())
+	}
+	if h.templates.sponsors, err = h.LoadTemplates("sponsors"); err != nil {
+		log.Fatal(err.Error())
+	}
+	if h.templates.directory, err = h.LoadTemplates("directory"); err != nil {
+		log.Fatal(err.Error())
+	}
 	h.cache = cache
 	h.stats = NewStats(redis)
+	h.exporter = metrics.NewExporter(h.collectMetrics)
 	h.engine = DefaultEngine{}
+	h.limiter = newRateLimiter()
+	h.fallbacks = newFallbackChecker()
 	http.Handle("/", NewGzipHandler(h.requestDispatcher))
 
 	// Load the GeoIP databases
@@ -118,10 +160,10 @@ func HTTPServer(redis *database.Redis, cache *mirrors.Cache) *HTTP {
 	return h
 }
 
-// SetListener can be used to set a different listener that should be used by the
+// SetListeners can be used to set different listeners that should be used by the
 // HTTP server. This is primarily used during seamless binary upgrade.
-func (h *HTTP) SetListener(l net.Listener) {
-	h.Listener = &l
+func (h *HTTP) SetListeners(l []net.Listener) {
+	h.Listeners = l
 }
 
 // Stop gracefully stops the HTTP server with a timeout to let
@@ -134,7 +176,16 @@ func (h *HTTP) Stop(timeout time.Duration) {
 		return
 	}
 	h.stopped = true
-	h.server.Stop(timeout)
+	for _, server := range h.servers {
+		server.Stop(timeout)
+	}
+}
+
+// Serving returns true if the HTTP server is running and not stopping
+func (h *HTTP) Serving() bool {
+	h.stoppedMutex.Lock()
+	defer h.stoppedMutex.Unlock()
+	return !h.stopped && len(h.servers) > 0
 }
 
 // Terminate terminates the current HTTP server gracefully
@@ -145,9 +196,12 @@ func (h *HTTP) Terminate() {
 	}
 	/* Commit the latest recorded stats to the database */
 	h.stats.Terminate()
+	/* Push the latest metrics */
+	h.exporter.Stop()
+	h.fallbacks.Stop()
 }
 
-// StopChan returns a channel that notifies when the server is stopped
+// StopChan returns a channel that notifies when all the servers are stopped
 func (h *HTTP) StopChan() <-chan struct{} {
 	return h.serverStopChan
 }
@@ -157,6 +211,9 @@ func (h *HTTP) Reload() {
 	// Reload the GeoIP database
 	h.geoip.LoadGeoIP()
 
+	// Compile the selection script again
+	reloadHookScript()
+
 	// Reload the templates
 	h.templates.Lock()
 	if t, err := h.LoadTemplates("mirrorlist"); err == nil {
@@ -169,43 +226,75 @@ func (h *HTTP) Reload() {
 	} else {
 		log.Errorf("could not reload templates 'mirrorstats': %s", err.Error())
 	}
+	if t, err := h.LoadTemplates("mirrorhistory"); err == nil {
+		h.templates.mirrorhistory = t
+	} else {
+		log.Errorf("could not reload templates 'mirrorhistory': %s", err.Error())
+	}
+	if t, err := h.LoadTemplates("sponsors"); err == nil {
+		h.templates.sponsors = t
+	} else {
+		log.Errorf("could not reload templates 'sponsors': %s", err.Error())
+	}
+	if t, err := h.LoadTemplates("directory"); err == nil {
+		h.templates.directory = t
+	} else {
+		log.Errorf("could not reload templates 'directory': %s", err.Error())
+	}
 	h.templates.Unlock()
 }
 
 // RunServer is the main function used to start the HTTP server
 func (h *HTTP) RunServer() (err error) {
-	// If listener isn't nil that means that we're running a seamless
-	// binary upgrade and we have recovered an already running listener
-	if h.Listener == nil {
-		proto := "tcp"
-		address := GetConfig().ListenAddress
-		if strings.HasPrefix(address, "unix:") {
-			proto = "unix"
-			address = strings.TrimPrefix(address, "unix:")
-		}
-		listener, err := net.Listen(proto, address)
-		if err != nil {
-			log.Fatal("Listen: ", err)
+	addresses := GetConfig().GetListenAddresses()
+
+	// If listeners aren't empty that means that we're running a seamless
+	// binary upgrade and we have recovered the already running listeners
+	if len(h.Listeners) == 0 {
+		listeners := make([]net.Listener, 0, len(addresses))
+		for _, address := range addresses {
+			l, err := listen(address)
+			if err != nil {
+				log.Fatal("Listen: ", err)
+			}
+			listeners = append(listeners, l...)
 		}
-		h.SetListener(listener)
+		h.SetListeners(listeners)
 	}
 
-	h.server = &graceful.Server{
-		// http
-		Server: &http.Server{
-			Handler:        nil,
-			ReadTimeout:    10 * time.Second,
-			WriteTimeout:   10 * time.Second,
-			MaxHeaderBytes: 1 << 20,
-		},
+	h.stoppedMutex.Lock()
+	h.stopped = false
+	h.servers = make([]*graceful.Server, len(h.Listeners))
+	for i := range h.Listeners {
+		h.servers[i] = &graceful.Server{
+			// http
+			Server: &http.Server{
+				Handler:        nil,
+				ReadTimeout:    10 * time.Second,
+				WriteTimeout:   10 * time.Second,
+				MaxHeaderBytes: 1 << 20,
+			},
 
-		// graceful
-		Timeout:          10 * time.Second,
-		NoSignalHandling: true,
+			// graceful
+			Timeout:          10 * time.Second,
+			NoSignalHandling: true,
+		}
 	}
-	h.serverStopChan = h.server.StopChan()
-
-	log.Infof("Service listening on %s", GetConfig().ListenAddress)
+	h.serverStopChan = make(chan struct{})
+	h.stoppedMutex.Unlock()
+
+	// The listeners may have been recovered or passed by systemd
+	addresses = nil
+	for _, l := range h.Listeners {
+		address := l.Addr().String()
+		if l.Addr().Network() == "unix" {
+			address = "unix:" + address
+		}
+		if !utils.IsInSlice(address, addresses) {
+			addresses = append(addresses, address)
+		}
+	}
+	log.Infof("Service listening on %s", strings.Join(addresses, ", "))
 
 	// Since main blocks here until completion, tell systemd we're ready.
 	// This is a no-op if NOTIFY_SOCKET isn't set.
@@ -215,28 +304,180 @@ func (h *HTTP) RunServer() (err error) {
 	}
 
 	/* Serve until we receive a SIGTERM */
-	return h.server.Serve(*h.Listener)
+	errs := make(chan error, len(h.servers))
+	for i, server := range h.servers {
+		go func(server *graceful.Server, listener net.Listener) {
+			errs <- server.Serve(listener)
+		}(server, h.Listeners[i])
+	}
+
+	for range h.servers {
+		if e := <-errs; e != nil {
+			if err == nil {
+				err = e
+			}
+			// Stop the remaining servers
+			h.Stop(0)
+		}
+	}
+
+	// The listeners are closed, new ones will be needed on restart
+	h.Listeners = nil
+	close(h.serverStopChan)
+
+	return err
 }
 
 func (h *HTTP) requestDispatcher(w http.ResponseWriter, r *http.Request) {
+	if h.probeHandler(w, r) {
+		return
+	}
+
+	start := time.Now()
+
+	rec := &statusRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+		headers:        GetConfig().HeadersForPath(r.URL.Path),
+	}
+	w = rec
+
 	h.templates.RLock()
 	ctx := NewContext(w, r, h.templates)
 	h.templates.RUnlock()
 
+	defer func() {
+		h.stats.CountResponse(ctx.HandlerName(), rec.status)
+		if logs.IsAccessLogEnabled() {
+			logs.LogAccess(logs.AccessEntry{
+				Method:    r.Method,
+				URI:       r.URL.RequestURI(),
+				Status:    rec.status,
+				Handler:   ctx.HandlerName(),
+				IP:        network.ClientIP(r),
+				UserAgent: r.Header.Get("User-Agent"),
+				Mirror:    ctx.Mirror(),
+				Duration:  time.Since(start),
+				Selection: ctx.SelectionTime(),
+			})
+		}
+	}()
+
 	w.Header().Set("Server", "Mirrorbits/"+core.VERSION)
 
+	if core.IsStandby() {
+		w.Header().Set("Retry-After", strconv.Itoa(standbyRetryAfter))
+		http.Error(w, "Service in standby", http.StatusServiceUnavailable)
+		return
+	}
+
+	if GetConfig().ExceedsPathLimits(r.URL.Path) {
+		http.Error(w, http.StatusText(http.StatusRequestURITooLong), http.StatusRequestURITooLong)
+		return
+	}
+
+	if !h.checkRateLimit(w, r, ctx) {
+		return
+	}
+
 	switch ctx.Type() {
 	case MIRRORLIST:
 		fallthrough
 	case STANDARD:
 		h.mirrorHandler(w, r, ctx)
 	case MIRRORSTATS:
+		if !network.CheckAccess(w, r, &GetConfig().MirrorStatsAccess) {
+			return
+		}
 		h.mirrorStatsHandler(w, r, ctx)
 	case FILESTATS:
 		h.fileStatsHandler(w, r, ctx)
 	case CHECKSUM:
 		h.checksumHandler(w, r, ctx)
+	case MIRRORDETAILS:
+		h.mirrorDetailsHandler(w, r, ctx)
+	case SPONSORS:
+		h.sponsorsHandler(w, r, ctx)
+	case REPORT:
+		h.reportHandler(w, r, ctx)
+	}
+}
+
+// statusRecorder keeps track of the status code sent to the client and
+// applies the extra response headers, overriding the ones set by the handlers
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	headers     map[string]string
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		for name, value := range r.headers {
+			if value == "" {
+				r.Header().Del(name)
+			} else {
+				r.Header().Set(name, value)
+			}
+		}
+	}
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
 	}
+	return r.ResponseWriter.Write(p)
+}
+
+// bufferedResponse holds a response until its ETag can be computed
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	b.status = code
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+// flush writes the buffered response along with its ETag and the given
+// modification time, or a 304 Not Modified when the client already has
+// the same content. It returns the status code sent.
+func (b *bufferedResponse) flush(r *http.Request, modtime time.Time) int {
+	w := b.ResponseWriter
+	if b.status == http.StatusOK {
+		// Weak since the content may be compressed on the fly
+		sum := sha256.Sum256(b.buf.Bytes())
+		w.Header().Set("Etag", `W/"`+hex.EncodeToString(sum[:16])+`"`)
+		setLastModified(w, modtime)
+		if checkIfNoneMatch(w, r) == condFalse {
+			writeNotModified(w)
+			return http.StatusNotModified
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(b.buf.Len()))
+	}
+	w.WriteHeader(b.status)
+	b.buf.WriteTo(w)
+	return b.status
+}
+
+// evaluateFilePath sanitizes and validates the requested file against the
+// local repository, following the symbolic links pointing outside of it when
+// FollowSymlinks is enabled
+func evaluateFilePath(repository, urlPath string) (string, error) {
+	if GetConfig().FollowSymlinks {
+		return filesystem.EvaluateFilePathFollow(repository, urlPath)
+	}
+	return filesystem.EvaluateFilePath(repository, urlPath)
 }
 
 // The functions below were picked from go/src/net/http/fs.go
@@ -269,6 +510,73 @@ func (h *HTTP) requestDispatcher(w http.ResponseWriter, r *http.Request) {
 // (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
 // OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 
+// scanETag determines if a syntactically valid ETag is present at s. If so,
+// the ETag and remaining text after consuming ETag is returned. Otherwise,
+// it returns "", "".
+func scanETag(s string) (etag string, remain string) {
+	s = textproto.TrimString(s)
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+	if len(s[start:]) < 2 || s[start] != '"' {
+		return "", ""
+	}
+	// ETag is either W/"text" or "text".
+	// See RFC 7232 2.3.
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		// Character values allowed in ETags.
+		case c == 0x21 || c >= 0x23 && c <= 0x7E || c >= 0x80:
+		case c == '"':
+			return s[:i+1], s[i+1:]
+		default:
+			return "", ""
+		}
+	}
+	return "", ""
+}
+
+// etagWeakMatch reports whether a and b match using weak ETag comparison.
+// Assumes a and b are valid ETags.
+func etagWeakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+func checkIfNoneMatch(w http.ResponseWriter, r *http.Request) condResult {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return condNone
+	}
+	inm := r.Header.Get("If-None-Match")
+	if inm == "" {
+		return condNone
+	}
+	buf := inm
+	for {
+		buf = textproto.TrimString(buf)
+		if len(buf) == 0 {
+			break
+		}
+		if buf[0] == ',' {
+			buf = buf[1:]
+			continue
+		}
+		if buf[0] == '*' {
+			return condFalse
+		}
+		etag, remain := scanETag(buf)
+		if etag == "" {
+			break
+		}
+		if etagWeakMatch(etag, w.Header().Get("Etag")) {
+			return condFalse
+		}
+		buf = remain
+	}
+	return condTrue
+}
+
 // isZeroTime reports whether t is obviously unspecified (either zero or Unix()=0).
 func isZeroTime(t time.Time) bool {
 	return t.IsZero() || t.Equal(unixEpochTime)
@@ -323,34 +631,71 @@ func (h *HTTP) mirrorHandler(w http.ResponseWriter, r *http.Request, ctx *Contex
 	//XXX it would be safer to recover in case of panic
 
 	// Sanitize path
-	urlPath, err := filesystem.EvaluateFilePath(GetConfig().Repository, r.URL.Path)
+	urlPath, err := evaluateFilePath(GetConfig().Repository, r.URL.Path)
 	if err != nil {
 		if err == filesystem.ErrOutsideRepo {
 			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 			return
 		}
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		return
+		if ctx.Type() == STANDARD && h.virtualChecksumHandler(w, r) {
+			return
+		}
+		if !os.IsNotExist(err) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
 	}
 
-	// Get details about the requested file
-	fileInfo, err := h.cache.GetFileInfo(urlPath)
+	var fileInfo filesystem.FileInfo
+	consensus := false
+
 	if err != nil {
-		log.Errorf("Error while fetching Fileinfo: %s", err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		// The file vanished from the local repository but the mirrors
+		// may still agree on what it looks like
+		var ok bool
+		fileInfo, ok, err = h.consensusFileInfo(r.URL.Path)
+		if err != nil {
+			log.Errorf("Error while fetching the mirrors consensus: %s", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		consensus = true
+		urlPath = fileInfo.Path
+		h.stats.CountConsensusFallback()
+		log.Noticef("Serving %s using the mirrors consensus (missing from the local repository)", urlPath)
+	} else {
+		// Files under embargo are not disclosed until their release and
+		// the files excluded from the index are never served
+		if GetConfig().IsEmbargoed(urlPath) || GetConfig().IsPathExcluded(urlPath) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		if ctx.Type() == STANDARD && h.directoryHandler(w, r, ctx, urlPath) {
+			return
+		}
+
+		// Get details about the requested file
+		fileInfo, err = h.cache.GetFileInfo(urlPath)
+		if err != nil {
+			log.Errorf("Error while fetching Fileinfo: %s", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 
-	if checkIfModifiedSince(r, fileInfo.ModTime) == condFalse {
+	// If-None-Match takes precedence over If-Modified-Since (RFC 7232 section 6)
+	if r.Header.Get("If-None-Match") == "" && checkIfModifiedSince(r, fileInfo.ModTime) == condFalse {
 		setLastModified(w, fileInfo.ModTime)
 		writeNotModified(w)
 		return
 	}
 
-	remoteIP := network.ExtractRemoteIP(r.Header.Get("X-Forwarded-For"))
-	if len(remoteIP) == 0 {
-		remoteIP = network.RemoteIPFromAddr(r.RemoteAddr)
-	}
+	remoteIP := network.ClientIP(r)
 
 	if ctx.IsMirrorlist() {
 		fromip := ctx.QueryParam("fromip")
@@ -360,26 +705,28 @@ func (h *HTTP) mirrorHandler(w http.ResponseWriter, r *http.Request, ctx *Contex
 	}
 
 	clientInfo := h.geoip.GetRecord(remoteIP) //TODO return a pointer?
+	h.overrideLocation(r, ctx, &clientInfo)
+	ctx.SetClientIP(remoteIP)
 
+	if !ctx.IsMirrorlist() {
+		h.clients.add(clientSample{path: fileInfo.Path, clientInfo: clientInfo})
+	}
+
+	selectionStart := time.Now()
 	mlist, excluded, err := h.engine.Selection(ctx, h.cache, &fileInfo, clientInfo)
+	if err == nil {
+		mlist, excluded = selectionHook(ctx, &fileInfo, clientInfo, mlist, excluded)
+	}
+	selectionTime := time.Since(selectionStart)
+	ctx.SetSelection("", selectionTime)
 
 	/* Handle errors */
 	fallback := false
 	if _, ok := err.(net.Error); ok || len(mlist) == 0 {
 		/* Handle fallbacks */
-		fallbacks := GetConfig().Fallbacks
-		if len(fallbacks) > 0 {
+		if len(GetConfig().Fallbacks) > 0 {
 			fallback = true
-			for i, f := range fallbacks {
-				mlist = append(mlist, mirrors.Mirror{
-					ID:            i * -1,
-					Name:          fmt.Sprintf("fallback%d", i),
-					HttpURL:       f.URL,
-					CountryCodes:  strings.ToUpper(f.CountryCode),
-					CountryFields: []string{strings.ToUpper(f.CountryCode)},
-					ContinentCode: strings.ToUpper(f.ContinentCode)})
-			}
-			sort.Sort(mirrors.ByRank{Mirrors: mlist, ClientInfo: clientInfo})
+			mlist = h.appendFallbacks(mlist, clientInfo)
 		} else {
 			// No fallback in stock, there's nothing else we can do
 			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
@@ -397,13 +744,35 @@ func (h *HTTP) mirrorHandler(w http.ResponseWriter, r *http.Request, ctx *Contex
 		ClientInfo:   clientInfo,
 		IP:           remoteIP,
 		Fallback:     fallback,
+		Degraded:     mlist.HasStale(),
 		LocalJSPath:  GetConfig().LocalJSPath,
 	}
+	if results.Degraded {
+		h.stats.CountStaleResponse()
+	}
+	if !ctx.IsMirrorlist() && len(mlist) > 0 {
+		ctx.SetSelection(mlist[0].Name, selectionTime)
+	}
+	if GetConfig().ClientHints {
+		hints := getClientHints(r)
+		results.ClientHints = hints.String()
+		h.stats.CountProtocol(hints)
+	}
 
 	var resultRenderer resultsRenderer
 
 	if ctx.IsMirrorlist() {
-		resultRenderer = &MirrorListRenderer{}
+		switch ctx.QueryParam("format") {
+		case "", "html":
+			resultRenderer = &MirrorListRenderer{}
+		case "json":
+			resultRenderer = &MirrorListJSONRenderer{}
+		case "csv":
+			resultRenderer = &MirrorListCSVRenderer{}
+		default:
+			http.Error(w, "Unsupported mirrorlist format", http.StatusBadRequest)
+			return
+		}
 	} else {
 		switch GetConfig().OutputMode {
 		case "json":
@@ -423,14 +792,36 @@ func (h *HTTP) mirrorHandler(w http.ResponseWriter, r *http.Request, ctx *Contex
 		}
 	}
 
+	ctx.SetRenderer(resultRenderer.Type())
+
 	w.Header().Set("Cache-Control", "private, no-cache")
 
+	isHead := r.Method == http.MethodHead
+	if isHead {
+		setLastModified(w, fileInfo.ModTime)
+	}
+
+	// The content of the other renderers is hashed for its ETag
+	var buffered *bufferedResponse
+	if resultRenderer.Type() != "REDIRECT" {
+		buffered = &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		ctx.setResponseWriter(buffered)
+	}
+
 	status, err := resultRenderer.Write(ctx, results)
+	if buffered != nil {
+		ctx.setResponseWriter(w)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), status)
+	} else if buffered != nil {
+		status = buffered.flush(r, fileInfo.ModTime)
 	}
 
-	if !ctx.IsMirrorlist() {
+	// HEAD requests are never accounted as downloads and those served
+	// using the mirrors consensus are accounted separately
+	if !ctx.IsMirrorlist() && !isHead && !consensus && GetConfig().IsStatsEnabled(fileInfo.Path) &&
+		!GetConfig().IsStatsExcludedClient(remoteIP, r.Header.Get("User-Agent")) {
 		logs.LogDownload(resultRenderer.Type(), status, results, err)
 		if len(mlist) > 0 {
 			timeout := GetConfig().SameDownloadInterval
@@ -442,7 +833,7 @@ func (h *HTTP) mirrorHandler(w http.ResponseWriter, r *http.Request, ctx *Contex
 				hash.Write([]byte(downloaderID))
 				chk := hex.EncodeToString(hash.Sum(nil))
 
-				rconn := h.redis.Get()
+				rconn := h.redis.Master()
 				defer rconn.Close()
 
 				tempKey := "DOWNLOADED_"+chk+"_"+urlPath
@@ -475,7 +866,16 @@ func (h *HTTP) mirrorHandler(w http.ResponseWriter, r *http.Request, ctx *Contex
 	return
 }
 
-// LoadTemplates pre-loads templates from the configured template directory
+// templateVars returns the variables defined by the TemplateVars option,
+// evaluated when the template is executed to follow configuration reloads
+func templateVars() map[string]string {
+	return GetConfig().TemplateVars
+}
+
+//go:generate go run gentemplates.go
+
+// LoadTemplates pre-loads templates from the configured template directory,
+// falling back to the templates embedded in the binary for the missing files
 func (h *HTTP) LoadTemplates(name string) (t *template.Template, err error) {
 	t = template.New("t")
 	t.Funcs(template.FuncMap{
@@ -486,18 +886,50 @@ func (h *HTTP) LoadTemplates(name string) (t *template.Template, err error) {
 		"concaturl": utils.ConcatURL,
 		"dateutc":   utils.FormattedDateUTC,
 		"iszero":    utils.IsZero,
+		"markdown":  utils.Markdown,
+		"reltime":   utils.RelativeTime,
+		"flag":      utils.CountryFlag,
+		"vars":      templateVars,
 	})
-	t, err = t.ParseFiles(
-		filepath.Clean(GetConfig().Templates+"/base.html"),
-		filepath.Clean(fmt.Sprintf("%s/%s.html", GetConfig().Templates, name)))
-	if err != nil {
-		if e, ok := err.(*os.PathError); ok {
-			log.Fatalf(fmt.Sprintf("Cannot load template %s: %s", e.Path, e.Err.Error()))
-		} else {
-			log.Fatal(err.Error())
+	for _, file := range []string{"base.html", name + ".html"} {
+		content, err := readTemplate(GetConfig().Templates, file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = t.New(file).Parse(string(content)); err != nil {
+			return nil, err
 		}
 	}
-	return t, err
+	return t, nil
+}
+
+// readTemplate reads a template from the given directory or
+// from the embedded templates if missing from there
+func readTemplate(dir, file string) ([]byte, error) {
+	if dir == "" {
+		// No templates directory configured
+		if content, ok := readDefaultTemplate(file); ok {
+			return content, nil
+		}
+		return nil, fmt.Errorf("cannot load template %s: no templates directory configured", file)
+	}
+	path := filepath.Clean(dir + "/" + file)
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		if content, ok := readDefaultTemplate(file); ok {
+			log.Debugf("Template %s not found, using the embedded one", path)
+			return content, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load template %s: %s", path, err)
+	}
+	return content, nil
+}
+
+func readDefaultTemplate(file string) ([]byte, bool) {
+	content, ok := defaultTemplates[file]
+	return []byte(content), ok
 }
 
 // StatsFileNow is the structure containing the latest stats of a file
@@ -539,8 +971,12 @@ func (h *HTTP) fileStatsHandler(w http.ResponseWriter, r *http.Request, ctx *Con
 
 		rconn.Send("MULTI")
 
+		var keys [4][]string
 		for i := 0; i < 4; i++ {
-			rconn.Send("HGET", fkey, r.URL.Path)
+			keys[i] = database.StatsFileKeysForPath(fkey, r.URL.Path)
+			for _, k := range keys[i] {
+				rconn.Send("HGET", k, r.URL.Path)
+			}
 			fkey = fkey[:strings.LastIndex(fkey, "_")]
 		}
 
@@ -551,11 +987,22 @@ func (h *HTTP) fileStatsHandler(w http.ResponseWriter, r *http.Request, ctx *Con
 			return
 		}
 
-		s := &StatsFileNow{}
-		s.Today, _ = redis.Int64(res[0], err)
-		s.Month, _ = redis.Int64(res[1], err)
-		s.Year, _ = redis.Int64(res[2], err)
-		s.Total, _ = redis.Int64(res[3], err)
+		// Sum the values of the keys of each period
+		var totals [4]int64
+		for i := range keys {
+			for range keys[i] {
+				v, _ := redis.Int64(res[0], err)
+				totals[i] += v
+				res = res[1:]
+			}
+		}
+
+		s := &StatsFileNow{
+			Today: totals[0],
+			Month: totals[1],
+			Year:  totals[2],
+			Total: totals[3],
+		}
 
 		output, err = json.MarshalIndent(s, "", "    ")
 	} else {
@@ -566,12 +1013,18 @@ func (h *HTTP) fileStatsHandler(w http.ResponseWriter, r *http.Request, ctx *Con
 		}
 		dkey = dkey[:len(dkey)-1]
 
-		v, err := redis.Int64(rconn.Do("HGET", dkey, r.URL.Path))
-		if err != nil && err != redis.ErrNil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		var downloads int64
+		var err error
+		for _, k := range database.StatsFileKeysForPath(dkey, r.URL.Path) {
+			var v int64
+			v, err = redis.Int64(rconn.Do("HGET", k, r.URL.Path))
+			if err != nil && err != redis.ErrNil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			downloads += v
 		}
-		s := &StatsFilePeriod{Period: ctx.QueryParam("stats"), Downloads: v}
+		s := &StatsFilePeriod{Period: ctx.QueryParam("stats"), Downloads: downloads}
 
 		output, err = json.MarshalIndent(s, "", "    ")
 	}
@@ -582,7 +1035,7 @@ func (h *HTTP) fileStatsHandler(w http.ResponseWriter, r *http.Request, ctx *Con
 func (h *HTTP) checksumHandler(w http.ResponseWriter, r *http.Request, ctx *Context) {
 
 	// Sanitize path
-	urlPath, err := filesystem.EvaluateFilePath(GetConfig().Repository, r.URL.Path)
+	urlPath, err := evaluateFilePath(GetConfig().Repository, r.URL.Path)
 	if err != nil {
 		if err == filesystem.ErrOutsideRepo {
 			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
@@ -592,6 +1045,11 @@ func (h *HTTP) checksumHandler(w http.ResponseWriter, r *http.Request, ctx *Cont
 		return
 	}
 
+	if GetConfig().IsEmbargoed(urlPath) || GetConfig().IsPathExcluded(urlPath) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
 	// Get details about the requested file
 	fileInfo, err := h.cache.GetFileInfo(urlPath)
 	if err != nil {
@@ -603,11 +1061,15 @@ func (h *HTTP) checksumHandler(w http.ResponseWriter, r *http.Request, ctx *Cont
 	var hash string
 
 	if ctx.paramBool("md5") {
-		hash = fileInfo.Md5
+		hash = fileHash(fileInfo, "md5")
 	} else if ctx.paramBool("sha1") {
-		hash = fileInfo.Sha1
+		hash = fileHash(fileInfo, "sha1")
 	} else if ctx.paramBool("sha256") {
-		hash = fileInfo.Sha256
+		hash = fileHash(fileInfo, "sha256")
+	} else if ctx.paramBool("sha512") {
+		hash = fileHash(fileInfo, "sha512")
+	} else if ctx.paramBool("blake2b") {
+		hash = fileHash(fileInfo, "blake2b")
 	}
 
 	if len(hash) == 0 {
@@ -615,22 +1077,102 @@ func (h *HTTP) checksumHandler(w http.ResponseWriter, r *http.Request, ctx *Cont
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
-	w.Write([]byte(fmt.Sprintf("%s  %s", hash, filepath.Base(fileInfo.Path))))
+	writeChecksum(w, hash, fileInfo)
 
 	return
 }
 
+// mirrorHistoryHandler shows the uptime and the outages of the given mirror
+func (h *HTTP) mirrorHistoryHandler(w http.ResponseWriter, r *http.Request, ctx *Context, name string) {
+	mirrorsIDs, err := h.redis.GetListOfMirrors()
+	if err != nil {
+		http.Error(w, "Cannot fetch the list of mirrors", http.StatusInternalServerError)
+		return
+	}
+
+	id := 0
+	for mid, mname := range mirrorsIDs {
+		if mname == name {
+			id = mid
+			break
+		}
+	}
+	if id == 0 {
+		http.Error(w, "Unknown mirror", http.StatusNotFound)
+		return
+	}
+
+	mirror, err := h.cache.GetMirror(id)
+	if err != nil {
+		http.Error(w, "Cannot fetch the mirror", http.StatusInternalServerError)
+		return
+	}
+
+	history, err := mirrors.GetHistory(h.redis, id)
+	if err != nil {
+		http.Error(w, "Cannot fetch the history of the mirror", http.StatusInternalServerError)
+		return
+	}
+
+	page := MirrorHistoryPage{
+		Name:        mirror.Name,
+		Enabled:     mirror.Enabled,
+		Up:          mirror.Up,
+		History:     history,
+		LocalJSPath: GetConfig().LocalJSPath,
+	}
+
+	switch ctx.QueryParam("format") {
+	case "", "html":
+	case "json":
+		var output []byte
+		if ctx.IsPretty() {
+			output, err = json.MarshalIndent(page, "", "    ")
+		} else {
+			output, err = json.Marshal(page)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(output)
+		return
+	default:
+		http.Error(w, "Unsupported mirrorstats format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = ctx.Templates().mirrorhistory.ExecuteTemplate(w, "base", page)
+	if err != nil {
+		log.Errorf("HTTP error: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // MirrorStats contains the stats of a given mirror
 type MirrorStats struct {
-	ID         int
-	Name       string
-	Downloads  int64
-	Bytes      int64
-	PercentD   float32
-	PercentB   float32
-	SyncOffset SyncOffset
-	TZOffset   time.Duration
+	ID             int
+	Name           string
+	Enabled        bool
+	DisabledReason string `json:",omitempty"`
+	Downloads      int64
+	Bytes          int64
+	PercentD       float32
+	PercentB       float32
+	SyncOffset     SyncOffset
+	TZOffset       time.Duration
+	Latency        int // in ms
+	History        []DailyStats
+}
+
+// DailyStats contains the downloads of a mirror for a given day
+type DailyStats struct {
+	Date      string
+	Downloads int64
+	Bytes     int64
+	Percent   float32 `json:"-"` // of the busiest day of the mirror
 }
 
 // SyncOffset contains the time offset between the mirror and the local repository
@@ -646,6 +1188,15 @@ type MirrorStatsPage struct {
 	MirrorList       []mirrors.Mirror
 	LocalJSPath      string
 	HasTZAdjustement bool
+	Days             int
+	Capacity         *mirrors.Capacity
+}
+
+// mirrorStatsOutput is the machine-readable version of the mirrorstats page
+type mirrorStatsOutput struct {
+	Days     int
+	Mirrors  []MirrorStats
+	Capacity *mirrors.Capacity `json:",omitempty"`
 }
 
 // byDownloadNumbers is a sorting function
@@ -666,7 +1217,21 @@ type mirrorStatsSlice []MirrorStats
 func (s mirrorStatsSlice) Len() int      { return len(s) }
 func (s mirrorStatsSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
+// MirrorHistoryPage contains the values needed to generate the availability
+// history of a mirror
+type MirrorHistoryPage struct {
+	Name        string
+	Enabled     bool
+	Up          bool
+	History     *mirrors.History
+	LocalJSPath string `json:"-"`
+}
+
 func (h *HTTP) mirrorStatsHandler(w http.ResponseWriter, r *http.Request, ctx *Context) {
+	if name := ctx.QueryParam("mirror"); name != "" {
+		h.mirrorHistoryHandler(w, r, ctx, name)
+		return
+	}
 
 	rconn := h.redis.Get()
 	defer rconn.Close()
@@ -685,13 +1250,31 @@ func (h *HTTP) mirrorStatsHandler(w http.ResponseWriter, r *http.Request, ctx *C
 		mirrorsIDs = append(mirrorsIDs, id)
 	}
 
+	// Number of days of history, today included
+	days := defaultStatsHistoryDays
+	if v := ctx.QueryParam("days"); v != "" {
+		days, err = strconv.Atoi(v)
+		if err != nil || days < 1 || days > maxStatsHistoryDays {
+			http.Error(w, fmt.Sprintf("The number of days must be between 1 and %d", maxStatsHistoryDays), http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	dates := make([]time.Time, days)
+	for i := range dates {
+		dates[i] = now.AddDate(0, 0, i-days+1)
+	}
+
 	rconn.Send("MULTI")
 
-	// Get all mirrors stats
-	for _, id := range mirrorsIDs {
-		today := time.Now().UTC().Format("2006_01_02")
-		rconn.Send("HGET", "STATS_MIRROR_"+today, id)
-		rconn.Send("HGET", "STATS_MIRROR_BYTES_"+today, id)
+	// Get all mirrors stats, one day at a time
+	if len(mirrorsIDs) > 0 {
+		for _, date := range dates {
+			day := date.Format("2006_01_02")
+			rconn.Send("HMGET", redis.Args{}.Add("STATS_MIRROR_"+day).AddFlat(mirrorsIDs)...)
+			rconn.Send("HMGET", redis.Args{}.Add("STATS_MIRROR_BYTES_"+day).AddFlat(mirrorsIDs)...)
+		}
 	}
 
 	stats, err := redis.Values(rconn.Do("EXEC"))
@@ -704,24 +1287,40 @@ func (h *HTTP) mirrorStatsHandler(w http.ResponseWriter, r *http.Request, ctx *C
 	var maxdownloads int64
 	var maxbytes int64
 	var results []MirrorStats
-	var index int64
 	mlist := make([]mirrors.Mirror, 0, len(mirrorsIDs))
-	for _, id := range mirrorsIDs {
+	for index, id := range mirrorsIDs {
 		mirror, err := h.cache.GetMirror(id)
 		if err != nil {
 			continue
 		}
 		mlist = append(mlist, mirror)
 
-		var downloads int64
-		if v, _ := redis.String(stats[index], nil); v != "" {
-			downloads, _ = strconv.ParseInt(v, 10, 64)
+		history := make([]DailyStats, days)
+		var peak int64
+		for i, date := range dates {
+			dlValues, _ := redis.Values(stats[2*i], nil)
+			bytesValues, _ := redis.Values(stats[2*i+1], nil)
+			history[i].Date = date.Format("2006-01-02")
+			if index < len(dlValues) {
+				history[i].Downloads, _ = redis.Int64(dlValues[index], nil)
+			}
+			if index < len(bytesValues) {
+				history[i].Bytes, _ = redis.Int64(bytesValues[index], nil)
+			}
+			if history[i].Downloads > peak {
+				peak = history[i].Downloads
+			}
 		}
-		var bytes int64
-		if v, _ := redis.String(stats[index+1], nil); v != "" {
-			bytes, _ = strconv.ParseInt(v, 10, 64)
+		if peak > 0 {
+			for i := range history {
+				history[i].Percent = float32(history[i].Downloads) * 100 / float32(peak)
+			}
 		}
 
+		// The totals of the day are the last entry of the history
+		downloads := history[days-1].Downloads
+		bytes := history[days-1].Bytes
+
 		if downloads > maxdownloads {
 			maxdownloads = downloads
 		}
@@ -743,19 +1342,22 @@ func (h *HTTP) mirrorStatsHandler(w http.ResponseWriter, r *http.Request, ctx *C
 		}
 
 		s := MirrorStats{
-			ID:        id,
-			Name:      mirror.Name,
-			Downloads: downloads,
-			Bytes:     bytes,
+			ID:             id,
+			Name:           mirror.Name,
+			Enabled:        mirror.Enabled,
+			DisabledReason: mirror.DisabledReason,
+			Downloads:      downloads,
+			Bytes:          bytes,
 			SyncOffset: SyncOffset{
 				Valid:         !lastModTime.IsZero(),
 				Value:         int(elapsed.Hours()),
 				HumanReadable: utils.FuzzyTimeStr(elapsed),
 			},
 			TZOffset: tzoffset,
+			Latency:  mirror.Latency(GetConfig().Latency.Continent),
+			History:  history,
 		}
 		results = append(results, s)
-		index += 2
 	}
 
 	sort.Sort(byDownloadNumbers{results})
@@ -765,8 +1367,50 @@ func (h *HTTP) mirrorStatsHandler(w http.ResponseWriter, r *http.Request, ctx *C
 		results[i].PercentB = float32(results[i].Bytes) * 100 / float32(maxbytes)
 	}
 
+	var capacity *mirrors.Capacity
+	if GetConfig().Capacity.TopFiles > 0 {
+		capacity, err = mirrors.GetCapacity(h.redis)
+		if err != nil {
+			// The page is still useful without it
+			log.Errorf("Cannot fetch the capacity: %s", err)
+		}
+	}
+
+	switch ctx.QueryParam("format") {
+	case "", "html":
+	case "json":
+		out := mirrorStatsOutput{
+			Days:     days,
+			Mirrors:  results,
+			Capacity: capacity,
+		}
+		var output []byte
+		if ctx.IsPretty() {
+			output, err = json.MarshalIndent(out, "", "    ")
+		} else {
+			output, err = json.Marshal(out)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(output)
+		return
+	default:
+		http.Error(w, "Unsupported mirrorstats format", http.StatusBadRequest)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	err = ctx.Templates().mirrorstats.ExecuteTemplate(w, "base", MirrorStatsPage{results, mlist, GetConfig().LocalJSPath, hasTZAdjustement})
+	err = ctx.Templates().mirrorstats.ExecuteTemplate(w, "base", MirrorStatsPage{
+		List:             results,
+		MirrorList:       mlist,
+		LocalJSPath:      GetConfig().LocalJSPath,
+		HasTZAdjustement: hasTZAdjustement,
+		Days:             days,
+		Capacity:         capacity,
+	})
 	if err != nil {
 		log.Errorf("HTTP error: %s", err.Error())
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,35 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+)
+
+func TestRequestDispatcherPathLimits(t *testing.T) {
+	SetConfiguration(&Configuration{MaxPathLength: 32, MaxPathDepth: 4})
+	defer SetConfiguration(&Configuration{})
+
+	h := &HTTP{
+		stats: &Stats{
+			responses:        make(map[ResponseKey]int64),
+			pendingResponses: make(map[string]int64),
+		},
+	}
+	h.templates.RWMutex = new(sync.RWMutex)
+
+	for _, path := range []string{"/" + strings.Repeat("a", 32), "/a/b/c/d/file.iso"} {
+		w := httptest.NewRecorder()
+		h.requestDispatcher(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusRequestURITooLong {
+			t.Fatalf("%s: expected %d, got %d", path, http.StatusRequestURITooLong, w.Code)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+)
+
+// listen opens the listeners of the given address, applying the socket
+// options of the configuration. Several listeners sharing the same port
+// are returned when multiple acceptors are requested.
+func listen(address string) ([]net.Listener, error) {
+	if strings.HasPrefix(address, "unix:") {
+		l, err := listenUnix(strings.TrimPrefix(address, "unix:"))
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+
+	opts := GetConfig().ListenOptions
+	lc := net.ListenConfig{
+		Control: socketControl(opts.ReusePort, opts.FastOpen),
+	}
+
+	listeners := make([]net.Listener, 0, opts.Acceptors)
+	for i := 0; i < opts.Acceptors; i++ {
+		l, err := lc.Listen(context.Background(), "tcp", address)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		if opts.Backlog > 0 {
+			if err := setBacklog(l, opts.Backlog); err != nil {
+				log.Warningf("Unable to set the backlog of %s: %s", address, err)
+			}
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenUnix opens a unix socket with the permissions and the owner given
+// in the configuration. A socket left by a previous instance is replaced
+// if nobody listens on it anymore.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if c, err := net.Dial("unix", path); err == nil {
+			c.Close()
+		} else if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the socket when the listener is closed by the parent of a
+	// seamless upgrade, the child taking over the same socket
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	opts := GetConfig().ListenOptions
+	if mode := opts.SocketFileMode(); mode != 0 {
+		if err = os.Chmod(path, mode); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	if opts.SocketUser != "" || opts.SocketGroup != "" {
+		if err = chownSocket(path, opts.SocketUser, opts.SocketGroup); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// chownSocket changes the owner and the group of the given socket, by name
+// or by id, an empty one being left unchanged
+func chownSocket(path, owner, group string) error {
+	uid, gid := -1, -1
+	if owner != "" {
+		u, err := user.Lookup(owner)
+		if err != nil {
+			if u, err = user.LookupId(owner); err != nil {
+				return err
+			}
+		}
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return err
+		}
+	}
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			if g, err = user.LookupGroupId(group); err != nil {
+				return err
+			}
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return err
+		}
+	}
+	return os.Chown(path, uid, gid)
+}
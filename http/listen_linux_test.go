@@ -0,0 +1,49 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+)
+
+func TestListenReusePort(t *testing.T) {
+	c := Configuration{}
+	c.ListenOptions.Backlog = 16
+	c.ListenOptions.FastOpen = 16
+	c.ListenOptions.ReusePort = true
+	c.ListenOptions.Acceptors = 1
+	SetConfiguration(&c)
+
+	first, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %s", err)
+	}
+	defer first[0].Close()
+
+	// Other acceptors can share the same port
+	c.ListenOptions.Acceptors = 2
+	SetConfiguration(&c)
+
+	listeners, err := listen(first[0].Addr().String())
+	if err != nil {
+		t.Fatalf("Unable to share the port: %s", err)
+	}
+	if len(listeners) != 2 {
+		t.Fatalf("Expected 2 listeners, got %d", len(listeners))
+	}
+	for _, l := range listeners {
+		l.Close()
+	}
+
+	// Without SO_REUSEPORT the port cannot be shared
+	c.ListenOptions.ReusePort = false
+	c.ListenOptions.Acceptors = 1
+	SetConfiguration(&c)
+
+	if _, err = listen(first[0].Addr().String()); err == nil {
+		t.Fatalf("Expected an error when the port is already in use")
+	}
+}
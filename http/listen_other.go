@@ -0,0 +1,28 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+//go:build !linux
+// +build !linux
+
+package http
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+var errListenOptionsUnsupported = errors.New("socket options are only supported on Linux")
+
+func socketControl(reusePort bool, fastOpen int) func(network, address string, c syscall.RawConn) error {
+	if !reusePort && fastOpen == 0 {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		return errListenOptionsUnsupported
+	}
+}
+
+func setBacklog(l net.Listener, backlog int) error {
+	return errListenOptionsUnsupported
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := Configuration{}
+	c.ListenOptions.SocketMode = "0660"
+	SetConfiguration(&c)
+	defer SetConfiguration(&Configuration{})
+
+	path := filepath.Join(dir, "http.sock")
+	listeners, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Unable to listen: %s", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Socket not found: %s", err)
+	}
+	if fi.Mode().Perm() != 0660 {
+		t.Fatalf("Expected the mode 0660, got %o", fi.Mode().Perm())
+	}
+
+	// The socket is in use
+	if _, err = listen("unix:" + path); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	// The socket is kept when closed and replaced once stale
+	listeners[0].Close()
+	if _, err = os.Stat(path); err != nil {
+		t.Fatalf("Socket removed: %s", err)
+	}
+	listeners, err = listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Unable to replace the stale socket: %s", err)
+	}
+	listeners[0].Close()
+}
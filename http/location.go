@@ -0,0 +1,136 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+)
+
+const (
+	// locationTokenHeader is the header used by privileged callers
+	// to authenticate the location override
+	locationTokenHeader = "X-Mirrorbits-Token"
+
+	// countryLocationTTL is the duration during which the location
+	// of a country is cached
+	countryLocationTTL = time.Minute
+)
+
+// countryLocations caches the locations of the countries computed from
+// the mirrors, see countryLocation
+type countryLocations struct {
+	sync.Mutex
+	m map[string]countryLocationEntry
+}
+
+type countryLocationEntry struct {
+	lat, lon  float32
+	continent string
+	ok        bool
+	expires   time.Time
+}
+
+// overrideLocation lets privileged callers (see LocationOverride) force the
+// country and/or the AS number of the client with the `country` and `asn`
+// query parameters. The caller is identified by the address of the peer,
+// or the one given by the TrustedProxies, and never by `fromip`.
+func (h *HTTP) overrideLocation(r *http.Request, ctx *Context, clientInfo *network.GeoIPRecord) {
+	country := strings.ToUpper(strings.TrimSpace(ctx.QueryParam("country")))
+	asn := strings.TrimSpace(ctx.QueryParam("asn"))
+	if country == "" && asn == "" {
+		return
+	}
+
+	if !GetConfig().IsLocationOverrideAllowed(network.ClientIP(r), r.Header.Get(locationTokenHeader)) {
+		return
+	}
+
+	if len(country) == 2 {
+		h.setClientCountry(clientInfo, country)
+	}
+
+	if asn != "" {
+		if n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(asn), "AS"), 10, 32); err == nil {
+			clientInfo.ASNum = uint(n)
+			clientInfo.ASName = ""
+		}
+	}
+}
+
+// setClientCountry relocates the client to the given country
+func (h *HTTP) setClientCountry(clientInfo *network.GeoIPRecord, country string) {
+	if country == clientInfo.CountryCode {
+		return
+	}
+	// The client coordinates are unknown, use the location of
+	// the mirrors located in the requested country instead
+	lat, lon, continent, ok := h.countryLocation(country)
+	if ok {
+		clientInfo.Latitude = lat
+		clientInfo.Longitude = lon
+		clientInfo.ContinentCode = continent
+	}
+	clientInfo.CountryCode = country
+	clientInfo.Country = ""
+	clientInfo.City = ""
+}
+
+// countryLocation returns the average coordinates and the continent of
+// the enabled mirrors having the given country as their primary country,
+// cached for countryLocationTTL
+func (h *HTTP) countryLocation(country string) (lat, lon float32, continent string, ok bool) {
+	h.locations.Lock()
+	defer h.locations.Unlock()
+
+	if e, found := h.locations.m[country]; found && time.Now().Before(e.expires) {
+		return e.lat, e.lon, e.continent, e.ok
+	}
+
+	lat, lon, continent, ok, err := h.computeCountryLocation(country)
+	if err != nil {
+		return
+	}
+	if h.locations.m == nil {
+		h.locations.m = make(map[string]countryLocationEntry)
+	}
+	h.locations.m[country] = countryLocationEntry{
+		lat:       lat,
+		lon:       lon,
+		continent: continent,
+		ok:        ok,
+		expires:   time.Now().Add(countryLocationTTL),
+	}
+	return
+}
+
+func (h *HTTP) computeCountryLocation(country string) (lat, lon float32, continent string, ok bool, err error) {
+	mirrorsIDs, err := h.redis.GetListOfMirrors()
+	if err != nil {
+		return
+	}
+
+	count := 0
+	for id := range mirrorsIDs {
+		m, merr := h.cache.GetMirror(id)
+		if merr != nil || !m.Enabled || len(m.CountryFields) == 0 || m.CountryFields[0] != country {
+			continue
+		}
+		lat += m.Latitude
+		lon += m.Longitude
+		continent = m.ContinentCode
+		count++
+	}
+
+	if count == 0 {
+		return 0, 0, "", false, nil
+	}
+	return lat / float32(count), lon / float32(count), continent, true, nil
+}
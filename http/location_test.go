@@ -0,0 +1,59 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestOverrideLocation(t *testing.T) {
+	defer SetConfiguration(&Configuration{})
+
+	err := PrepareConfigTest(`Repository: /srv/repo
+LocationOverride:
+    Networks: [10.0.0.0/8]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &HTTP{}
+	// Cached location of the country, the database being unreachable
+	h.locations.m = map[string]countryLocationEntry{
+		"FR": {lat: 48.85, lon: 2.35, continent: "EU", ok: true, expires: time.Now().Add(time.Minute)},
+	}
+
+	override := func(url, remoteAddr string, headers map[string]string) network.GeoIPRecord {
+		r := httptest.NewRequest("GET", url, nil)
+		r.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		clientInfo := network.GeoIPRecord{CountryCode: "US", ContinentCode: "NA", ASNum: 1}
+		h.overrideLocation(r, NewContext(httptest.NewRecorder(), r, Templates{}), &clientInfo)
+		return clientInfo
+	}
+
+	// Spoofed address of the caller
+	if c := override("/file?asn=AS64500", "203.0.113.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.1"}); c.ASNum != 1 {
+		t.Fatalf("Expected the forwarded address of an untrusted peer to be ignored")
+	}
+	if c := override("/file?mirrorlist&fromip=10.0.0.1&asn=AS64500", "203.0.113.1:1234", nil); c.ASNum != 1 {
+		t.Fatalf("Expected fromip not to be used to authorize the override")
+	}
+
+	if c := override("/file?asn=AS64500", "10.0.0.1:1234", nil); c.ASNum != 64500 {
+		t.Fatalf("Expected the AS number to be overridden, got %d", c.ASNum)
+	}
+
+	c := override("/file?country=FR", "10.0.0.1:1234", nil)
+	if c.CountryCode != "FR" || c.ContinentCode != "EU" || c.Latitude != 48.85 {
+		t.Fatalf("Expected the client to be relocated to FR, got %+v", c)
+	}
+}
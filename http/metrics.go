@@ -0,0 +1,139 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"strconv"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/daemon"
+	"github.com/etix/mirrorbits/metrics"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/scan"
+)
+
+// collectMetrics returns the samples pushed by the metrics exporter
+func (h *HTTP) collectMetrics() []metrics.Sample {
+	mirrorsIDs, err := h.redis.GetListOfMirrors()
+	if err != nil {
+		log.Warningf("Metrics: unable to fetch the list of mirrors: %s", err)
+		return nil
+	}
+
+	counters := h.stats.Counters()
+	samples := make([]metrics.Sample, 0, len(mirrorsIDs)*4+3)
+
+	ids := make([]int, 0, len(mirrorsIDs))
+	for id := range mirrorsIDs {
+		ids = append(ids, id)
+	}
+	notFound, err := mirrors.NotFoundCounts(h.redis, ids, notFoundWindow())
+	if err != nil {
+		log.Warningf("Metrics: unable to fetch the 404 of the mirrors: %s", err)
+	}
+
+	var totalDownloads, totalBytes int64
+	for id, name := range mirrorsIDs {
+		tags := map[string]string{"mirror": name}
+		counter := counters[id]
+		totalDownloads += counter.Downloads
+		totalBytes += counter.Bytes
+
+		samples = append(samples,
+			metrics.Sample{Name: "downloads", Tags: tags, Value: float64(counter.Downloads)},
+			metrics.Sample{Name: "bytes", Tags: tags, Value: float64(counter.Bytes)},
+		)
+
+		mirror, err := h.cache.GetMirror(id)
+		if err != nil {
+			continue
+		}
+		samples = append(samples,
+			metrics.Sample{Name: "enabled", Tags: tags, Value: boolToFloat(mirror.Enabled)},
+			metrics.Sample{Name: "up", Tags: tags, Value: boolToFloat(mirror.Up)},
+		)
+
+		for source, count := range notFound[id] {
+			samples = append(samples, metrics.Sample{
+				Name:  "not_found",
+				Tags:  map[string]string{"mirror": name, "source": source},
+				Value: float64(count),
+			})
+		}
+	}
+
+	samples = append(samples,
+		metrics.Sample{Name: "total_downloads", Value: float64(totalDownloads)},
+		metrics.Sample{Name: "total_bytes", Value: float64(totalBytes)},
+		metrics.Sample{Name: "consensus_fallbacks", Value: float64(h.stats.ConsensusFallbacks())},
+		metrics.Sample{Name: "stale_responses", Value: float64(h.stats.StaleResponses())},
+	)
+
+	// Allow alerting on a stale index of the local repository
+	if last, files, err := scan.LastSourceRefresh(h.redis); err != nil {
+		log.Warningf("Metrics: unable to fetch the last scan of the repository: %s", err)
+	} else if !last.IsZero() {
+		samples = append(samples,
+			metrics.Sample{Name: "source_last_refresh_timestamp", Value: float64(last.Unix())},
+			metrics.Sample{Name: "source_files_indexed", Value: float64(files)},
+		)
+	}
+
+	// Make a stuck monitor loop or a backlog of events visible
+	if lastTick, healthChecks, awaitingScan := daemon.MonitorHealth(); !lastTick.IsZero() {
+		samples = append(samples,
+			metrics.Sample{Name: "monitor_tick_age_seconds", Value: time.Since(lastTick).Seconds()},
+			metrics.Sample{Name: "health_check_queue", Value: float64(healthChecks)},
+			metrics.Sample{Name: "mirrors_awaiting_scan", Value: float64(awaitingScan)},
+		)
+	}
+	if h.redis.Pubsub != nil {
+		pubsub := h.redis.Pubsub.Stats()
+		for event, queued := range pubsub.Queued {
+			samples = append(samples, metrics.Sample{
+				Name:  "pubsub_queued",
+				Tags:  map[string]string{"event": event},
+				Value: float64(queued),
+			})
+		}
+		samples = append(samples,
+			metrics.Sample{Name: "pubsub_coalesced", Value: float64(pubsub.Coalesced)},
+			metrics.Sample{Name: "pubsub_dropped", Value: float64(pubsub.Dropped)},
+		)
+	}
+
+	for k, v := range h.stats.Responses() {
+		samples = append(samples, metrics.Sample{
+			Name:  "responses",
+			Tags:  map[string]string{"handler": k.Handler, "code": strconv.Itoa(k.Code)},
+			Value: float64(v),
+		})
+	}
+	for k, v := range h.stats.Protocols() {
+		tlsVersion := k.TLS
+		if tlsVersion == "" {
+			tlsVersion = "none"
+		}
+		samples = append(samples, metrics.Sample{
+			Name:  "client_protocols",
+			Tags:  map[string]string{"proto": k.Proto, "tls": tlsVersion},
+			Value: float64(v),
+		})
+	}
+	return samples
+}
+
+// notFoundWindow returns the duration over which the 404 of the mirrors
+// are counted
+func notFoundWindow() time.Duration {
+	return time.Duration(GetConfig().NotFoundRescan.Window) * time.Minute
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
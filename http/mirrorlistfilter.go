@@ -0,0 +1,172 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/utils"
+)
+
+var (
+	// ErrInvalidFilter is returned when the filter of the mirrorlist is invalid
+	ErrInvalidFilter = errors.New("invalid mirrorlist filter")
+)
+
+// mirrorListFilter restricts and orders the mirrors returned by the
+// machine-readable versions of the mirrorlist
+type mirrorListFilter struct {
+	Countries  []string
+	Continents []string
+	Protocol   string
+	Sort       string
+}
+
+// mirrorListFacets lists the values the mirrorlist can be filtered with
+type mirrorListFacets struct {
+	Countries  []string
+	Continents []string
+	Protocols  []string
+}
+
+// parseMirrorListFilter returns the filter given in the query parameters:
+// countries and continents take comma separated lists of codes, protocol
+// is one of http, https, rsync or ftp and sort one of rank, distance,
+// weight or name. The country parameter being used to override the
+// location of the client, it cannot be used as a filter.
+func parseMirrorListFilter(ctx *Context) (mirrorListFilter, error) {
+	f := mirrorListFilter{
+		Countries:  splitCodes(ctx.QueryParam("countries")),
+		Continents: splitCodes(ctx.QueryParam("continents")),
+		Protocol:   strings.ToLower(ctx.QueryParam("protocol")),
+		Sort:       strings.ToLower(ctx.QueryParam("sort")),
+	}
+	if !utils.IsInSlice(f.Protocol, []string{"", "http", "https", "rsync", "ftp"}) {
+		return f, ErrInvalidFilter
+	}
+	if !utils.IsInSlice(f.Sort, []string{"", "rank", "distance", "weight", "name"}) {
+		return f, ErrInvalidFilter
+	}
+	return f, nil
+}
+
+func splitCodes(value string) []string {
+	var codes []string
+	for _, c := range strings.Split(strings.ToUpper(value), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
+
+// mirrorProtocols returns the protocols supported by a mirror
+func mirrorProtocols(m mirrors.Mirror) []string {
+	var protocols []string
+	if m.HttpURL != "" {
+		if m.IsHTTPS() {
+			protocols = append(protocols, "https")
+		} else {
+			protocols = append(protocols, "http")
+		}
+	}
+	if m.RsyncURL != "" {
+		protocols = append(protocols, "rsync")
+	}
+	if m.FtpURL != "" {
+		protocols = append(protocols, "ftp")
+	}
+	return protocols
+}
+
+// match returns true if the mirror matches the filter
+func (f mirrorListFilter) match(m mirrors.Mirror) bool {
+	if len(f.Countries) > 0 {
+		found := false
+		for _, c := range f.Countries {
+			if m.ServesCountry(c) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Continents) > 0 && !utils.IsInSlice(m.ContinentCode, f.Continents) {
+		return false
+	}
+	if f.Protocol != "" && !utils.IsInSlice(f.Protocol, mirrorProtocols(m)) {
+		return false
+	}
+	return true
+}
+
+// apply filters and sorts the entries of the mirrorlist. The rank given
+// by the selection is kept.
+func (f mirrorListFilter) apply(entries []mirrorListEntry, list mirrors.Mirrors) []mirrorListEntry {
+	filtered := entries[:0]
+	for i, e := range entries {
+		if f.match(list[i]) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	var less func(a, b mirrorListEntry) bool
+	switch f.Sort {
+	case "distance":
+		less = func(a, b mirrorListEntry) bool { return a.Distance < b.Distance }
+	case "weight":
+		less = func(a, b mirrorListEntry) bool { return a.Weight > b.Weight }
+	case "name":
+		less = func(a, b mirrorListEntry) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	default:
+		return filtered
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return less(filtered[i], filtered[j])
+	})
+	return filtered
+}
+
+// newMirrorListFacets returns the countries, continents and protocols of
+// the given mirrors
+func newMirrorListFacets(lists ...mirrors.Mirrors) mirrorListFacets {
+	countries := make(map[string]bool)
+	continents := make(map[string]bool)
+	protocols := make(map[string]bool)
+	for _, list := range lists {
+		for _, m := range list {
+			for _, c := range m.CountryFields {
+				countries[c] = true
+			}
+			for _, c := range m.AdditionalServedFields {
+				countries[c] = true
+			}
+			if m.ContinentCode != "" {
+				continents[m.ContinentCode] = true
+			}
+			for _, p := range mirrorProtocols(m) {
+				protocols[p] = true
+			}
+		}
+	}
+	return mirrorListFacets{
+		Countries:  sortedKeys(countries),
+		Continents: sortedKeys(continents),
+		Protocols:  sortedKeys(protocols),
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
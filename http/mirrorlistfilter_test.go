@@ -0,0 +1,70 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+func TestMirrorListFilter(t *testing.T) {
+	results := &mirrors.Results{
+		MirrorList: mirrors.Mirrors{
+			{ID: 1, Name: "m1", HttpURL: "https://m1/", CountryFields: []string{"FR"}, ContinentCode: "EU", Distance: 300, Weight: 20},
+			{ID: 2, Name: "m2", HttpURL: "http://m2/", RsyncURL: "rsync://m2/", CountryFields: []string{"DE"}, ContinentCode: "EU", Distance: 100, Weight: 50},
+			{ID: 3, Name: "m3", HttpURL: "http://m3/", CountryFields: []string{"US", "CA"}, AdditionalServedFields: []string{"MX"}, ContinentCode: "NA", Distance: 200, Weight: 30},
+		},
+	}
+
+	output := func(query string) (mirrorListOutput, error) {
+		r := httptest.NewRequest(http.MethodGet, "/file?mirrorlist&format=json&"+query, nil)
+		filter, err := parseMirrorListFilter(NewContext(nil, r, Templates{}))
+		if err != nil {
+			return mirrorListOutput{}, err
+		}
+		return newMirrorListOutput(results, filter), nil
+	}
+
+	out, err := output("continents=eu&sort=distance")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(out.Mirrors) != 2 || out.Mirrors[0].Name != "m2" || out.Mirrors[0].Rank != 2 {
+		t.Fatalf("Unexpected mirrors %+v", out.Mirrors)
+	}
+	if len(out.Facets.Countries) != 5 || len(out.Facets.Continents) != 2 || len(out.Facets.Protocols) != 3 {
+		t.Fatalf("Unexpected facets %+v", out.Facets)
+	}
+
+	out, _ = output("countries=ca,fr&sort=weight")
+	if len(out.Mirrors) != 2 || out.Mirrors[0].Name != "m3" || out.Mirrors[1].Name != "m1" {
+		t.Fatalf("Unexpected mirrors %+v", out.Mirrors)
+	}
+
+	// The additional served countries are matched as well
+	out, _ = output("countries=mx")
+	if len(out.Mirrors) != 1 || out.Mirrors[0].Name != "m3" {
+		t.Fatalf("Unexpected mirrors %+v", out.Mirrors)
+	}
+
+	out, _ = output("protocol=rsync")
+	if len(out.Mirrors) != 1 || out.Mirrors[0].Name != "m2" {
+		t.Fatalf("Unexpected mirrors %+v", out.Mirrors)
+	}
+
+	out, _ = output("protocol=https")
+	if len(out.Mirrors) != 1 || out.Mirrors[0].Name != "m1" {
+		t.Fatalf("Unexpected mirrors %+v", out.Mirrors)
+	}
+
+	if _, err := output("protocol=gopher"); err != ErrInvalidFilter {
+		t.Fatalf("Expected an invalid filter, got %v", err)
+	}
+	if _, err := output("sort=random"); err != ErrInvalidFilter {
+		t.Fatalf("Expected an invalid filter, got %v", err)
+	}
+}
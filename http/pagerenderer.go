@@ -5,6 +5,7 @@ package http
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,9 +13,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
 )
 
 var (
@@ -91,6 +94,11 @@ func (w *RedirectRenderer) Write(ctx *Context, results *mirrors.Results) (status
 			}
 		}
 
+		if ctx.Request().Method == http.MethodHead {
+			// Describe the file itself since there is no body to send
+			ctx.ResponseWriter().Header().Set("Content-Length", strconv.FormatInt(results.FileInfo.Size, 10))
+		}
+
 		// Finally issue the redirect
 		http.Redirect(ctx.ResponseWriter(), ctx.Request(), results.MirrorList[0].HttpURL+path, http.StatusFound)
 		return http.StatusFound, nil
@@ -133,3 +141,167 @@ func (w *MirrorListRenderer) Write(ctx *Context, results *mirrors.Results) (stat
 	buf.WriteTo(ctx.ResponseWriter())
 	return http.StatusOK, nil
 }
+
+// mirrorListEntry describes a mirror of the mirrorlist in machine-readable form
+type mirrorListEntry struct {
+	Rank          int `json:",omitempty"`
+	ID            int
+	Name          string
+	SponsorName   string `json:",omitempty"`
+	HttpURL       string
+	HostHeader    string `json:",omitempty"`
+	CountryCodes  string
+	ContinentCode string
+	Asnum         uint
+	Distance      float32
+	Weight        float32
+	Score         int
+	ExcludeReason string   `json:",omitempty"`
+	Protocols     []string `json:",omitempty"`
+}
+
+// mirrorListOutput is the machine-readable version of the mirrorlist page
+type mirrorListOutput struct {
+	Path       string
+	Size       int64
+	ModTime    time.Time
+	IP         string
+	ClientInfo network.GeoIPRecord
+	Fallback   bool
+	Facets     mirrorListFacets
+	Mirrors    []mirrorListEntry
+	Excluded   []mirrorListEntry
+}
+
+func newMirrorListEntry(rank int, m mirrors.Mirror) mirrorListEntry {
+	return mirrorListEntry{
+		Rank:          rank,
+		ID:            m.ID,
+		Name:          m.Name,
+		SponsorName:   m.SponsorName,
+		HttpURL:       m.HttpURL,
+		HostHeader:    m.HostHeader,
+		CountryCodes:  m.CountryCodes,
+		ContinentCode: m.ContinentCode,
+		Asnum:         m.Asnum,
+		Distance:      m.Distance,
+		Weight:        m.Weight,
+		Score:         m.ComputedScore,
+		ExcludeReason: m.ExcludeReason,
+		Protocols:     mirrorProtocols(m),
+	}
+}
+
+// newMirrorListOutput returns the mirrorlist restricted by the filter, the
+// facets being computed before filtering
+func newMirrorListOutput(results *mirrors.Results, filter mirrorListFilter) mirrorListOutput {
+	// Sort the exclude reasons by message so they appear grouped
+	sort.Sort(mirrors.ByExcludeReason{Mirrors: results.ExcludedList})
+
+	out := mirrorListOutput{
+		Path:       results.FileInfo.Path,
+		Size:       results.FileInfo.Size,
+		ModTime:    results.FileInfo.ModTime,
+		IP:         results.IP,
+		ClientInfo: results.ClientInfo,
+		Fallback:   results.Fallback,
+		Facets:     newMirrorListFacets(results.MirrorList, results.ExcludedList),
+		Mirrors:    make([]mirrorListEntry, 0, len(results.MirrorList)),
+		Excluded:   make([]mirrorListEntry, 0, len(results.ExcludedList)),
+	}
+	for i, m := range results.MirrorList {
+		out.Mirrors = append(out.Mirrors, newMirrorListEntry(i+1, m))
+	}
+	for _, m := range results.ExcludedList {
+		out.Excluded = append(out.Excluded, newMirrorListEntry(0, m))
+	}
+	out.Mirrors = filter.apply(out.Mirrors, results.MirrorList)
+	out.Excluded = filter.apply(out.Excluded, results.ExcludedList)
+	return out
+}
+
+// MirrorListJSONRenderer is used to render the mirrorlist in JSON
+type MirrorListJSONRenderer struct{}
+
+// Type returns the type of renderer
+func (w *MirrorListJSONRenderer) Type() string {
+	return "MIRRORLIST_JSON"
+}
+
+// Write is used to write the result to the ResponseWriter
+func (w *MirrorListJSONRenderer) Write(ctx *Context, results *mirrors.Results) (statusCode int, err error) {
+	filter, err := parseMirrorListFilter(ctx)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	var output []byte
+	if ctx.IsPretty() {
+		output, err = json.MarshalIndent(newMirrorListOutput(results, filter), "", "    ")
+	} else {
+		output, err = json.Marshal(newMirrorListOutput(results, filter))
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	ctx.ResponseWriter().Header().Set("Content-Type", "application/json; charset=utf-8")
+	ctx.ResponseWriter().Header().Set("Content-Length", strconv.Itoa(len(output)))
+	ctx.ResponseWriter().Write(output)
+	return http.StatusOK, nil
+}
+
+// MirrorListCSVRenderer is used to render the mirrorlist in CSV, the
+// selected mirrors being followed by the excluded ones
+type MirrorListCSVRenderer struct{}
+
+// Type returns the type of renderer
+func (w *MirrorListCSVRenderer) Type() string {
+	return "MIRRORLIST_CSV"
+}
+
+// Write is used to write the result to the ResponseWriter
+func (w *MirrorListCSVRenderer) Write(ctx *Context, results *mirrors.Results) (statusCode int, err error) {
+	filter, err := parseMirrorListFilter(ctx)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	out := newMirrorListOutput(results, filter)
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write([]string{"status", "rank", "id", "name", "url", "countries", "continent",
+		"asnum", "distance", "weight", "score", "reason"})
+
+	row := func(status string, e mirrorListEntry) []string {
+		return []string{
+			status,
+			strconv.Itoa(e.Rank),
+			strconv.Itoa(e.ID),
+			e.Name,
+			e.HttpURL,
+			e.CountryCodes,
+			e.ContinentCode,
+			strconv.FormatUint(uint64(e.Asnum), 10),
+			strconv.FormatFloat(float64(e.Distance), 'f', 0, 32),
+			strconv.FormatFloat(float64(e.Weight), 'f', 2, 32),
+			strconv.Itoa(e.Score),
+			e.ExcludeReason,
+		}
+	}
+	for _, e := range out.Mirrors {
+		cw.Write(row("selected", e))
+	}
+	for _, e := range out.Excluded {
+		cw.Write(row("excluded", e))
+	}
+	cw.Flush()
+	if err = cw.Error(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	ctx.ResponseWriter().Header().Set("Content-Type", "text/csv; charset=utf-8")
+	ctx.ResponseWriter().Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	buf.WriteTo(ctx.ResponseWriter())
+	return http.StatusOK, nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+func redirectResults() *mirrors.Results {
+	return &mirrors.Results{
+		FileInfo: filesystem.FileInfo{
+			Path: "/test/file.tgz",
+			Size: 4242,
+		},
+		MirrorList: mirrors.Mirrors{
+			mirrors.Mirror{
+				ID:      1,
+				Name:    "m1",
+				HttpURL: "http://m1.mirror/",
+			},
+		},
+	}
+}
+
+func TestRedirectRendererHead(t *testing.T) {
+	SetConfiguration(&Configuration{MaxLinkHeaders: 10})
+
+	r := httptest.NewRequest(http.MethodHead, "/test/file.tgz", nil)
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, r, Templates{})
+
+	status, err := (&RedirectRenderer{}).Write(ctx, redirectResults())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if status != http.StatusFound || w.Code != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, w.Code)
+	}
+	if l := w.Header().Get("Location"); l != "http://m1.mirror/test/file.tgz" {
+		t.Fatalf("Invalid location: %s", l)
+	}
+	if cl := w.Header().Get("Content-Length"); cl != "4242" {
+		t.Fatalf("Expected the Content-Length of the file, got %s", cl)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("Expected an empty body, got %q", w.Body.String())
+	}
+}
+
+func TestRedirectRendererGet(t *testing.T) {
+	SetConfiguration(&Configuration{MaxLinkHeaders: 10})
+
+	r := httptest.NewRequest(http.MethodGet, "/test/file.tgz", nil)
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, r, Templates{})
+
+	status, err := (&RedirectRenderer{}).Write(ctx, redirectResults())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if status != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, status)
+	}
+	if cl := w.Header().Get("Content-Length"); cl == "4242" {
+		t.Fatal("The Content-Length of the file must only be set for HEAD requests")
+	}
+}
+
+func mirrorListResults() *mirrors.Results {
+	results := redirectResults()
+	results.MirrorList[0].ComputedScore = 10
+	results.MirrorList[0].Weight = 100
+	results.ExcludedList = mirrors.Mirrors{
+		mirrors.Mirror{
+			ID:            2,
+			Name:          "m2",
+			HttpURL:       "http://m2.mirror/",
+			ExcludeReason: "Down, \"unreachable\"",
+		},
+	}
+	return results
+}
+
+func TestMirrorListJSONRenderer(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/test/file.tgz?mirrorlist&format=json", nil)
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, r, Templates{})
+
+	status, err := (&MirrorListJSONRenderer{}).Write(ctx, mirrorListResults())
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Unexpected result: %d %v", status, err)
+	}
+
+	var out mirrorListOutput
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("Invalid JSON: %s", err)
+	}
+	if len(out.Mirrors) != 1 || out.Mirrors[0].Rank != 1 || out.Mirrors[0].Score != 10 {
+		t.Fatalf("Unexpected mirrors: %+v", out.Mirrors)
+	}
+	if len(out.Excluded) != 1 || out.Excluded[0].ExcludeReason != "Down, \"unreachable\"" {
+		t.Fatalf("Unexpected excluded mirrors: %+v", out.Excluded)
+	}
+}
+
+func TestMirrorListCSVRenderer(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/test/file.tgz?mirrorlist&format=csv", nil)
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, r, Templates{})
+
+	status, err := (&MirrorListCSVRenderer{}).Write(ctx, mirrorListResults())
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Unexpected result: %d %v", status, err)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("Unexpected content type %s", ct)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %s", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d", len(records))
+	}
+	if records[1][0] != "selected" || records[1][3] != "m1" || records[1][10] != "10" {
+		t.Fatalf("Unexpected selected row: %v", records[1])
+	}
+	if records[2][0] != "excluded" || records[2][11] != "Down, \"unreachable\"" {
+		t.Fatalf("Unexpected excluded row: %v", records[2])
+	}
+}
+
+func TestBufferedResponseConditional(t *testing.T) {
+	modtime := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	render := func(inm string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/test/file.tgz?mirrorlist&format=json", nil)
+		if inm != "" {
+			r.Header.Set("If-None-Match", inm)
+		}
+		w := httptest.NewRecorder()
+		buffered := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		ctx := NewContext(buffered, r, Templates{})
+
+		if _, err := (&MirrorListJSONRenderer{}).Write(ctx, mirrorListResults()); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		buffered.flush(r, modtime)
+		return w
+	}
+
+	w := render("")
+	etag := w.Header().Get("Etag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.Len() == 0 {
+		t.Fatalf("Expected a full response with an ETag, got %d %q", w.Code, etag)
+	}
+	if lm := w.Header().Get("Last-Modified"); lm != "Mon, 01 Jul 2019 12:00:00 GMT" {
+		t.Fatalf("Invalid Last-Modified: %s", lm)
+	}
+
+	w = render(`"other", ` + etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("Expected %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	w = render(`"other"`)
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("Expected a full response for a different ETag, got %d", w.Code)
+	}
+}
+
+func TestStatusRecorderHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &statusRecorder{
+		ResponseWriter: rec,
+		status:         http.StatusOK,
+		headers:        map[string]string{"Cache-Control": "no-cache", "Server": ""},
+	}
+	w.Header().Set("Server", "Mirrorbits")
+	w.Header().Set("Cache-Control", "private")
+	w.Write([]byte("content"))
+
+	if v := rec.Header().Get("Cache-Control"); v != "no-cache" {
+		t.Fatalf("Expected the handler header to be overridden, got %q", v)
+	}
+	if _, ok := rec.Header()["Server"]; ok {
+		t.Fatalf("Expected the Server header to be removed")
+	}
+	if w.status != http.StatusOK || rec.Body.String() != "content" {
+		t.Fatalf("Unexpected response: %d %q", w.status, rec.Body.String())
+	}
+}
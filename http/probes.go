@@ -0,0 +1,93 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+)
+
+// probeHandler answers the liveness and the readiness probes configured
+// in Probes, before the standby and the rate limiting are applied. It
+// returns false if the request is not a probe.
+func (h *HTTP) probeHandler(w http.ResponseWriter, r *http.Request) bool {
+	probes := GetConfig().Probes
+	switch {
+	case probes.Liveness != "" && r.URL.Path == probes.Liveness:
+		writeProbe(w, nil)
+	case probes.Readiness != "" && r.URL.Path == probes.Readiness:
+		writeProbe(w, h.readinessChecks())
+	default:
+		return false
+	}
+	return true
+}
+
+// probeCheck is the result of one of the checks of the readiness probe
+type probeCheck struct {
+	name string
+	err  error
+}
+
+// readinessChecks verifies that the instance is able to serve the requests
+func (h *HTTP) readinessChecks() []probeCheck {
+	var checks []probeCheck
+
+	var err error
+	if core.IsStandby() {
+		err = fmt.Errorf("the instance is in standby")
+	}
+	checks = append(checks, probeCheck{"standby", err})
+
+	conn := h.redis.Get()
+	_, err = conn.Do("PING")
+	conn.Close()
+	checks = append(checks, probeCheck{"redis", err})
+
+	err = nil
+	if !h.geoip.IsLoaded() {
+		err = fmt.Errorf("the GeoIP databases are not loaded")
+	}
+	checks = append(checks, probeCheck{"geoip", err})
+
+	err = nil
+	h.templates.RLock()
+	if h.templates.mirrorlist == nil || h.templates.mirrorstats == nil || h.templates.mirrorhistory == nil ||
+		h.templates.sponsors == nil || h.templates.directory == nil {
+		err = fmt.Errorf("the templates are not loaded")
+	}
+	h.templates.RUnlock()
+	checks = append(checks, probeCheck{"templates", err})
+
+	return checks
+}
+
+// writeProbe writes the result of the checks of a probe, with a status 503
+// if any of them failed
+func writeProbe(w http.ResponseWriter, checks []probeCheck) {
+	status := http.StatusOK
+	for _, c := range checks {
+		if c.err != nil {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if len(checks) == 0 {
+		fmt.Fprintln(w, "ok")
+		return
+	}
+	for _, c := range checks {
+		if c.err != nil {
+			fmt.Fprintf(w, "%s: %s\n", c.name, c.err)
+		} else {
+			fmt.Fprintf(w, "%s: ok\n", c.name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestProbeHandler(t *testing.T) {
+	c := Configuration{}
+	c.Probes.Liveness = "/healthz"
+	c.Probes.Readiness = "/readyz"
+	SetConfiguration(&c)
+	defer SetConfiguration(&Configuration{})
+
+	mock, conn := PrepareRedisTest()
+	h := &HTTP{
+		redis: conn,
+		geoip: network.NewGeoIP(),
+	}
+	h.templates.RWMutex = new(sync.RWMutex)
+
+	probe := func(path string) (int, string, bool) {
+		w := httptest.NewRecorder()
+		ok := h.probeHandler(w, httptest.NewRequest("GET", path, nil))
+		return w.Code, w.Body.String(), ok
+	}
+
+	if _, _, ok := probe("/file.iso"); ok {
+		t.Fatalf("Expected the request not to be a probe")
+	}
+
+	if code, _, ok := probe("/healthz"); !ok || code != http.StatusOK {
+		t.Fatalf("Expected the liveness probe to succeed, got %d", code)
+	}
+
+	// Neither the GeoIP databases nor the templates are loaded
+	mock.Command("PING").Expect("PONG")
+	code, body, ok := probe("/readyz")
+	if !ok || code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the readiness probe to fail, got %d", code)
+	}
+	if !strings.Contains(body, "redis: ok") || !strings.Contains(body, "geoip: the GeoIP databases are not loaded") ||
+		!strings.Contains(body, "templates: the templates are not loaded") {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+
+	// Disabled probes
+	c.Probes.Liveness = ""
+	if _, _, ok := probe("/healthz"); ok {
+		t.Fatalf("Expected the liveness probe to be disabled")
+	}
+}
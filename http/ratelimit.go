@@ -0,0 +1,106 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+)
+
+// rateLimitPruneInterval is the interval between two removals of the
+// buckets of the clients not seen recently
+const rateLimitPruneInterval = time.Minute
+
+// tokenBucket holds the tokens of a client, a request consuming one token
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket rate limiter keyed by client
+type rateLimiter struct {
+	sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow consumes a token of the given client, the bucket being refilled
+// at rate tokens per second up to burst tokens. It returns 0 if the
+// request is allowed or the delay before the next token otherwise.
+func (l *rateLimiter) allow(key string, rate float64, burst int, now time.Time) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	if now.Sub(l.lastPrune) >= rateLimitPruneInterval {
+		l.prune(rate, burst, now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// prune removes the buckets full again, which would be recreated full anyway
+func (l *rateLimiter) prune(rate float64, burst int, now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= float64(burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// checkRateLimit applies the rate limiting to the request and answers with
+// 429 Too Many Requests when the client exceeds it. It returns false if the
+// request must not be processed any further.
+func (h *HTTP) checkRateLimit(w http.ResponseWriter, r *http.Request, ctx *Context) bool {
+	cfg := GetConfig().RateLimit
+	if cfg.Rate <= 0 {
+		return true
+	}
+	if ctx.Type() == STANDARD && !cfg.Downloads {
+		return true
+	}
+
+	// The clients are identified by the address of the peer, the forwarding
+	// headers being only honored from the TrustedProxies: the clients cannot
+	// get a new bucket by rotating the addresses of these headers
+	key, limited := GetConfig().RateLimitKey(network.ClientIP(r))
+	if !limited {
+		return true
+	}
+
+	delay := h.limiter.allow(key, cfg.Rate, cfg.Burst, time.Now())
+	if delay == 0 {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	return false
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if d := l.allow("a", 2, 3, now); d != 0 {
+			t.Fatalf("Request %d should be allowed within the burst, got a delay of %s", i+1, d)
+		}
+	}
+	if d := l.allow("a", 2, 3, now); d != 500*time.Millisecond {
+		t.Fatalf("Expected a delay of 500ms, got %s", d)
+	}
+	if d := l.allow("b", 2, 3, now); d != 0 {
+		t.Fatalf("The clients must not share their bucket")
+	}
+
+	// Refilled at the given rate
+	now = now.Add(500 * time.Millisecond)
+	if d := l.allow("a", 2, 3, now); d != 0 {
+		t.Fatalf("Expected the request to be allowed after a refill, got a delay of %s", d)
+	}
+
+	// The buckets full again are pruned
+	now = now.Add(rateLimitPruneInterval)
+	l.allow("c", 2, 3, now)
+	if len(l.buckets) != 1 {
+		t.Fatalf("Expected the idle buckets to be pruned, got %d buckets", len(l.buckets))
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	defer SetConfiguration(&Configuration{})
+
+	err := PrepareConfigTest(`Repository: /srv/repo
+TrustedProxies: [192.0.2.1]
+RateLimit:
+    Rate: 1
+    Burst: 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &HTTP{limiter: newRateLimiter()}
+	request := func(remoteAddr, forwardedFor string) int {
+		r := httptest.NewRequest("GET", "/file?mirrorlist", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		r.Header.Set("CF-Connecting-IP", forwardedFor)
+		w := httptest.NewRecorder()
+		h.checkRateLimit(w, r, NewContext(w, r, Templates{}))
+		return w.Code
+	}
+
+	// A client rotating the forwarding headers shares a single bucket
+	if code := request("203.0.113.1:1234", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("Expected the first request to be allowed, got %d", code)
+	}
+	for i := 2; i < 5; i++ {
+		if code := request("203.0.113.1:1234", fmt.Sprintf("198.51.%d.1", i)); code != http.StatusTooManyRequests {
+			t.Fatalf("Expected the spoofed request %d to be limited, got %d", i, code)
+		}
+	}
+
+	// The clients behind the trusted proxy get their own bucket
+	for i := 2; i < 5; i++ {
+		if code := request("192.0.2.1:1234", fmt.Sprintf("198.51.%d.1", i)); code != http.StatusOK {
+			t.Fatalf("Expected the forwarded client %d to be allowed, got %d", i, code)
+		}
+	}
+}
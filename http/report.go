@@ -0,0 +1,96 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/database"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	"github.com/gomodule/redigo/redis"
+)
+
+// reportHandler records a 404 returned by a mirror as reported by a client.
+// A single report per client and mirror is accounted during the window of
+// the NotFoundRescan option.
+func (h *HTTP) reportHandler(w http.ResponseWriter, r *http.Request, ctx *Context) {
+	cfg := GetConfig().NotFoundRescan
+	if !cfg.Reports || h.redis.ReadOnly() {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if ctx.QueryParam("report") != "404" {
+		http.Error(w, "Unsupported report", http.StatusBadRequest)
+		return
+	}
+
+	urlPath, err := evaluateFilePath(GetConfig().Repository, r.URL.Path)
+	if err != nil || GetConfig().IsEmbargoed(urlPath) || GetConfig().IsPathExcluded(urlPath) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	mirrorsIDs, err := h.redis.GetListOfMirrors()
+	if err != nil {
+		http.Error(w, "Cannot fetch the list of mirrors", http.StatusInternalServerError)
+		return
+	}
+
+	id := 0
+	name := ctx.QueryParam("mirror")
+	for mid, mname := range mirrorsIDs {
+		if mname == name {
+			id = mid
+			break
+		}
+	}
+	if id == 0 {
+		http.Error(w, "Unknown mirror", http.StatusBadRequest)
+		return
+	}
+
+	conn := h.redis.Get()
+	defer conn.Close()
+
+	// Only the mirrors supposed to carry the file can be reported
+	handled, err := database.IsFileMirror(conn, urlPath, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !handled {
+		http.Error(w, "The mirror doesn't carry this file", http.StatusBadRequest)
+		return
+	}
+
+	window := time.Duration(cfg.Window) * time.Minute
+	key := fmt.Sprintf("NOTFOUNDREPORT_%d_%s", id, network.ClientIP(r))
+	_, err = redis.String(conn.Do("SET", key, 1, "EX", int64(window/time.Second), "NX"))
+	if err == redis.ErrNil {
+		// Already reported by this client
+		w.WriteHeader(http.StatusNoContent)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := mirrors.ReportNotFound(h.redis, id, urlPath, mirrors.NotFoundReport); err != nil {
+		log.Errorf("Unable to record the report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,9 +5,11 @@ package http
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,30 +29,95 @@ type mirrorSelection interface {
 // DefaultEngine is the default algorithm used for mirror selection
 type DefaultEngine struct{}
 
+// ranking holds the mirrors filtered and scored for a file and a client
+// location, before the random weighted selection made for each request
+type ranking struct {
+	mlist      mirrors.Mirrors
+	excluded   mirrors.Mirrors
+	weights    map[int]int
+	totalScore int
+	located    bool
+}
+
 // Selection returns an ordered list of selected mirror, a list of rejected mirrors and and an error code
 func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *filesystem.FileInfo, clientInfo network.GeoIPRecord) (mlist mirrors.Mirrors, excluded mirrors.Mirrors, err error) {
+	// Reuse the ranking computed for a nearby client if any, unless the
+	// candidates are reviewed for each request by the selection hook
+	ttl := time.Duration(GetConfig().SelectionCache) * time.Second
+	if currentHookScript().defines(hookBeforeSelection) {
+		ttl = 0
+	}
+	bucket := selectionBucket(ctx, clientInfo)
+	if ttl > 0 {
+		if v, ok := cache.GetSelection(fileInfo.Path, bucket); ok {
+			mlist, excluded = h.pick(ctx, v.(*ranking), fileInfo)
+			return
+		}
+	}
+	generation := cache.SelectionGeneration()
+
 	// Prepare and return the list of all potential mirrors
 	mlist, err = cache.GetMirrors(fileInfo.Path, clientInfo)
 	if err != nil {
 		return
 	}
+	mlist, hooked := preSelectionHook(ctx, fileInfo, clientInfo, mlist)
+	r := h.score(ctx, mlist, fileInfo, clientInfo)
+	if ttl > 0 && !mlist.HasStale() {
+		cache.SetSelection(fileInfo.Path, bucket, r, ttl, generation)
+	}
+	mlist, excluded = h.pick(ctx, r, fileInfo)
+	excluded = append(excluded, hooked...)
+	return
+}
+
+// selectionBucket returns the key shared by the clients getting the same
+// ranking of the mirrors: the clients of the same country and AS number
+// located within the same degree of latitude and longitude.
+func selectionBucket(ctx *Context, clientInfo network.GeoIPRecord) string {
+	if !clientInfo.IsValid() {
+		return fmt.Sprintf("%d", ctx.SecureOption())
+	}
+	return fmt.Sprintf("%d|%s|%s|%d|%.0f|%.0f", ctx.SecureOption(),
+		clientInfo.ContinentCode, clientInfo.CountryCode, clientInfo.ASNum,
+		math.Floor(float64(clientInfo.Latitude)), math.Floor(float64(clientInfo.Longitude)))
+}
+
+// rank filters the given mirrors and orders them by score for the client.
+func (h DefaultEngine) rank(ctx *Context, candidates mirrors.Mirrors, fileInfo *filesystem.FileInfo, clientInfo network.GeoIPRecord) (mlist mirrors.Mirrors, excluded mirrors.Mirrors) {
+	return h.pick(ctx, h.score(ctx, candidates, fileInfo, clientInfo), fileInfo)
+}
+
+// score filters the given mirrors and computes their score for the client.
+// The given slice is reused to hold the selected mirrors.
+func (h DefaultEngine) score(ctx *Context, candidates mirrors.Mirrors, fileInfo *filesystem.FileInfo, clientInfo network.GeoIPRecord) *ranking {
+	mlist := candidates
 
 	// Filter
 	safeIndex := 0
-	excluded = make([]mirrors.Mirror, 0, len(mlist))
-	var closestMirror float32
-	var farthestMirror float32
+	excluded := make([]mirrors.Mirror, 0, len(mlist))
+	now := time.Now()
 	for i, m := range mlist {
 		// Does it support http? Is it well formated?
 		if !strings.HasPrefix(m.HttpURL, "http://") && !strings.HasPrefix(m.HttpURL, "https://") {
 			m.ExcludeReason = "Invalid URL"
 			goto discard
 		}
+		// Is it removed?
+		if !m.RemovedAt.IsZero() {
+			m.ExcludeReason = "Removed"
+			goto discard
+		}
 		// Is it enabled?
 		if !m.Enabled {
 			m.ExcludeReason = "Disabled"
 			goto discard
 		}
+		// Is it under maintenance?
+		if m.InMaintenance(now) {
+			m.ExcludeReason = "Maintenance"
+			goto discard
+		}
 		// Is it up?
 		if !m.Up {
 			if m.ExcludeReason == "" {
@@ -67,23 +134,9 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 			goto discard
 		}
 		// Is it the same size / modtime as source?
-		if m.FileInfo != nil {
-			if m.FileInfo.Size != fileInfo.Size {
-				m.ExcludeReason = "File size mismatch"
-				goto discard
-			}
-			if !m.FileInfo.ModTime.IsZero() {
-				mModTime := m.FileInfo.ModTime
-				if GetConfig().FixTimezoneOffsets {
-					mModTime = mModTime.Add(time.Duration(m.TZOffset) * time.Millisecond)
-				}
-				mModTime = mModTime.Truncate(m.LastSuccessfulSyncPrecision.Duration())
-				lModTime := fileInfo.ModTime.Truncate(m.LastSuccessfulSyncPrecision.Duration())
-				if !mModTime.Equal(lModTime) {
-					m.ExcludeReason = fmt.Sprintf("Mod time mismatch (diff: %s)", lModTime.Sub(mModTime))
-					goto discard
-				}
-			}
+		if reason := fileMismatch(m, fileInfo); reason != "" {
+			m.ExcludeReason = reason
+			goto discard
 		}
 		// Is it configured to serve its continent only?
 		if m.ContinentOnly {
@@ -94,7 +147,7 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 		}
 		// Is it configured to serve its country only?
 		if m.CountryOnly {
-			if !clientInfo.IsValid() || !utils.IsInSlice(clientInfo.CountryCode, m.CountryFields) {
+			if !clientInfo.IsValid() || !m.ServesCountry(clientInfo.CountryCode) {
 				m.ExcludeReason = "Country only"
 				goto discard
 			}
@@ -111,18 +164,13 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 			m.ExcludeReason = "User's country restriction"
 			goto discard
 		}
-		if safeIndex == 0 {
-			closestMirror = m.Distance
-		} else if closestMirror > m.Distance {
-			closestMirror = m.Distance
-		}
-		if m.Distance > farthestMirror {
-			farthestMirror = m.Distance
-		}
 		mlist[safeIndex] = mlist[i]
 		safeIndex++
 		continue
 	discard:
+		if m.Note != "" {
+			m.ExcludeReason = fmt.Sprintf("%s (%s)", m.ExcludeReason, m.Note)
+		}
 		excluded = append(excluded, m)
 	}
 
@@ -130,19 +178,20 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 	mlist = mlist[:safeIndex]
 
 	if !clientInfo.IsValid() {
-		// Shuffle the list
-		//XXX Should we use the fallbacks instead?
-		for i := range mlist {
-			j := rand.Intn(i + 1)
-			mlist[i], mlist[j] = mlist[j], mlist[i]
-		}
+		return &ranking{mlist: mlist, excluded: excluded}
+	}
 
-		// Shortcut
-		if !ctx.IsMirrorlist() {
-			// Reduce the number of mirrors to process
-			mlist = mlist[:utils.Min(5, len(mlist))]
+	mlist, excluded = preferMirrors(mlist, excluded, clientInfo)
+
+	var closestMirror float32
+	var farthestMirror float32
+	for i, m := range mlist {
+		if i == 0 || closestMirror > m.Distance {
+			closestMirror = m.Distance
+		}
+		if m.Distance > farthestMirror {
+			farthestMirror = m.Distance
 		}
-		return
 	}
 
 	// We're not interested in divisions by zero
@@ -173,7 +222,7 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 			m.ComputedScore += int(score)
 		} else if utils.IsPrimaryCountry(clientInfo, m.CountryFields) {
 			m.ComputedScore += int(float32(baseScore) - (m.Distance * 5))
-		} else if utils.IsAdditionalCountry(clientInfo, m.CountryFields) {
+		} else if m.ServesCountry(clientInfo.CountryCode) {
 			m.ComputedScore += int(float32(baseScore) - closestMirror)
 		}
 
@@ -183,6 +232,21 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 
 		floatingScore := float64(m.ComputedScore) + (float64(m.ComputedScore) * (float64(m.Score) / 100)) + 0.5
 
+		// Penalize the mirrors consistently slow to answer the health-checks
+		if threshold := GetConfig().Latency.SlowThreshold; threshold > 0 && m.Latency(clientInfo.ContinentCode) > threshold {
+			floatingScore -= floatingScore * float64(GetConfig().Latency.SlowPenalty) / 100
+		}
+
+		// Favor the high-bandwidth mirrors for the big files
+		if big := GetConfig().BigFiles; big.MinSize > 0 && fileInfo != nil && fileInfo.Size >= big.MinSize && m.HasTag(big.Tag) {
+			floatingScore += floatingScore * float64(big.Bonus) / 100
+		}
+
+		// Halve the share of the mirrors back from a long outage until rescanned
+		if m.PendingRescan {
+			floatingScore /= 2
+		}
+
 		// The minimum allowed score is 1
 		m.ComputedScore = int(math.Max(floatingScore, 1))
 
@@ -193,12 +257,116 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 		}
 	}
 
-	// Get the final number of mirrors selected for weight distribution
-	selected := len(weights)
-
 	// Sort mirrors by computed score
 	sort.Sort(mirrors.ByComputedScore{Mirrors: mlist})
 
+	return &ranking{
+		mlist:      mlist,
+		excluded:   excluded,
+		weights:    weights,
+		totalScore: totalScore,
+		located:    true,
+	}
+}
+
+// preferMirrors restricts the selected mirrors to the ones preferred for the
+// country of the client, if any of them is available
+func preferMirrors(mlist mirrors.Mirrors, excluded mirrors.Mirrors, clientInfo network.GeoIPRecord) (mirrors.Mirrors, mirrors.Mirrors) {
+	protocol, names, ok := GetConfig().CountryPreference(clientInfo.CountryCode)
+	if !ok {
+		return mlist, excluded
+	}
+
+	preferred := func(m mirrors.Mirror) bool {
+		if protocol == "https" && !m.IsHTTPS() || protocol == "http" && m.IsHTTPS() {
+			return false
+		}
+		return len(names) == 0 || utils.IsInSlice(m.Name, names)
+	}
+
+	found := false
+	for _, m := range mlist {
+		if preferred(m) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return mlist, excluded
+	}
+
+	safeIndex := 0
+	for _, m := range mlist {
+		if preferred(m) {
+			mlist[safeIndex] = m
+			safeIndex++
+			continue
+		}
+		m.ExcludeReason = "Not preferred in " + clientInfo.CountryCode
+		if m.Note != "" {
+			m.ExcludeReason = fmt.Sprintf("%s (%s)", m.ExcludeReason, m.Note)
+		}
+		excluded = append(excluded, m)
+	}
+	return mlist[:safeIndex], excluded
+}
+
+// fileMismatch returns the reason why the copy of the file served by the
+// mirror differs from the local repository, if any. An empty file is a valid
+// match for an empty source file, whereas a mirror which didn't report the
+// size of the file is not excluded.
+func fileMismatch(m mirrors.Mirror, fileInfo *filesystem.FileInfo) string {
+	if m.FileInfo == nil || m.FileInfo.Size < 0 {
+		return ""
+	}
+	if m.FileInfo.Size != fileInfo.Size {
+		return "File size mismatch"
+	}
+	if !m.FileInfo.ModTime.IsZero() {
+		mModTime := m.FileInfo.ModTime
+		if GetConfig().FixTimezoneOffsets {
+			mModTime = mModTime.Add(time.Duration(m.TZOffset) * time.Millisecond)
+		}
+		mModTime = mModTime.Truncate(m.Precision().Duration())
+		lModTime := fileInfo.ModTime.Truncate(m.Precision().Duration())
+		if !mModTime.Equal(lModTime) {
+			return fmt.Sprintf("Mod time mismatch (diff: %s)", lModTime.Sub(mModTime))
+		}
+	}
+	return ""
+}
+
+// pick returns the mirrors selected for the request among the ones of the
+// ranking, the ranking itself being left untouched
+func (h DefaultEngine) pick(ctx *Context, r *ranking, fileInfo *filesystem.FileInfo) (mlist mirrors.Mirrors, excluded mirrors.Mirrors) {
+	mlist = append(make(mirrors.Mirrors, 0, len(r.mlist)), r.mlist...)
+	excluded = append(make(mirrors.Mirrors, 0, len(r.excluded)), r.excluded...)
+
+	if !r.located {
+		// Shuffle the list
+		//XXX Should we use the fallbacks instead?
+		for i := range mlist {
+			j := rand.Intn(i + 1)
+			mlist[i], mlist[j] = mlist[j], mlist[i]
+		}
+
+		// Shortcut
+		if !ctx.IsMirrorlist() {
+			// Reduce the number of mirrors to process
+			mlist = mlist[:utils.Min(5, len(mlist))]
+		}
+		return
+	}
+
+	totalScore := r.totalScore
+	weights := make(map[int]int, len(r.weights))
+	for id, w := range r.weights {
+		weights[id] = w
+	}
+
+	// Get the final number of mirrors selected for weight distribution
+	selected := len(weights)
+
 	if selected > 1 {
 
 		if ctx.IsMirrorlist() {
@@ -215,16 +383,26 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 		} else {
 			// Randomize the order of the selected mirrors considering their weights
 			weightedMirrors := make([]mirrors.Mirror, selected)
+			var stickyOrder []int
+			if GetConfig().StickySelection {
+				if subnet := network.Subnet(ctx.ClientIP()); subnet != "" {
+					stickyOrder = rendezvousOrder(subnet+"|"+fileInfo.Path, weights)
+				}
+			}
 			rest := totalScore
 			for i := 0; i < selected; i++ {
 				var id int
-				rv := rand.Int31n(int32(rest))
-				s := 0
-				for k, v := range weights {
-					s += v
-					if int32(s) > rv {
-						id = k
-						break
+				if stickyOrder != nil {
+					id = stickyOrder[i]
+				} else {
+					rv := rand.Int31n(int32(rest))
+					s := 0
+					for k, v := range weights {
+						s += v
+						if int32(s) > rv {
+							id = k
+							break
+						}
 					}
 				}
 				for _, m := range mlist {
@@ -250,3 +428,38 @@ func (h DefaultEngine) Selection(ctx *Context, cache *mirrors.Cache, fileInfo *f
 	}
 	return
 }
+
+// rendezvousOrder sorts the given mirrors using a weighted rendezvous hashing
+// of the key. A given key is always mapped to the same order as long as the
+// set of mirrors doesn't change, and removing a mirror only affects the keys
+// that were mapped to it.
+func rendezvousOrder(key string, weights map[int]int) []int {
+	type candidate struct {
+		id    int
+		score float64
+	}
+	candidates := make([]candidate, 0, len(weights))
+	for id, weight := range weights {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.Itoa(id)))
+		// Map the hash to a uniform value in (0, 1)
+		u := (float64(h.Sum64()>>11) + 0.5) / (1 << 53)
+		candidates = append(candidates, candidate{
+			id:    id,
+			score: -float64(weight) / math.Log(u),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score == candidates[j].score {
+			return candidates[i].id < candidates[j].id
+		}
+		return candidates[i].score > candidates[j].score
+	})
+	order := make([]int, len(candidates))
+	for i, c := range candidates {
+		order[i] = c.id
+	}
+	return order
+}
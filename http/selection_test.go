@@ -0,0 +1,123 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPreferMirrors(t *testing.T) {
+	var c Configuration
+	err := yaml.Unmarshal([]byte(`
+CountryPreferences:
+    - Countries: [CN]
+      Protocol: http
+    - Countries: [RU]
+      Mirrors: [m3]
+`), &c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	SetConfiguration(&c)
+
+	candidates := func() mirrors.Mirrors {
+		return mirrors.Mirrors{
+			{ID: 1, Name: "m1", HttpURL: "https://m1/"},
+			{ID: 2, Name: "m2", HttpURL: "http://m2/"},
+			{ID: 3, Name: "m3", HttpURL: "https://m3/"},
+		}
+	}
+
+	tests := []struct {
+		country  string
+		selected []int
+	}{
+		{"CN", []int{2}},
+		{"RU", []int{3}},
+		{"FR", []int{1, 2, 3}},
+	}
+
+	for _, test := range tests {
+		mlist, excluded := preferMirrors(candidates(), nil, network.GeoIPRecord{CountryCode: test.country})
+		if len(mlist) != len(test.selected) || len(excluded) != 3-len(test.selected) {
+			t.Fatalf("%s: expected %d mirrors selected, got %d (%d excluded)", test.country, len(test.selected), len(mlist), len(excluded))
+		}
+		for i, id := range test.selected {
+			if mlist[i].ID != id {
+				t.Fatalf("%s: expected mirror %d, got %d", test.country, id, mlist[i].ID)
+			}
+		}
+	}
+
+	// The other mirrors are used when no preferred mirror is available
+	mlist, excluded := preferMirrors(candidates()[:2], nil, network.GeoIPRecord{CountryCode: "RU"})
+	if len(mlist) != 2 || len(excluded) != 0 {
+		t.Fatalf("Expected the mirrors to be kept, got %d selected", len(mlist))
+	}
+}
+
+func TestScoreAdditionalServedCountry(t *testing.T) {
+	SetConfiguration(&Configuration{WeightDistributionRange: 1})
+	defer SetConfiguration(&Configuration{})
+
+	candidates := func(additional []string) mirrors.Mirrors {
+		return mirrors.Mirrors{
+			{ID: 1, Name: "m1", HttpURL: "http://m1/", Enabled: true, Up: true, Distance: 1000, CountryFields: []string{"DE"}, AdditionalServedFields: additional},
+			{ID: 2, Name: "m2", HttpURL: "http://m2/", Enabled: true, Up: true, Distance: 900, CountryFields: []string{"ES"}},
+			{ID: 3, Name: "m3", HttpURL: "http://m3/", Enabled: true, Up: true, Distance: 5000, CountryFields: []string{"US"}},
+		}
+	}
+	clientInfo := network.GeoIPRecord{CountryCode: "FR", ContinentCode: "EU"}
+	ctx := NewContext(nil, httptest.NewRequest("GET", "/file.iso", nil), Templates{})
+
+	var h DefaultEngine
+	r := h.score(ctx, candidates(nil), &filesystem.FileInfo{}, clientInfo)
+	if r.mlist[0].ID != 2 {
+		t.Fatalf("Expected the closest mirror first, got %v", r.mlist)
+	}
+
+	// A mirror serving the country of the client as a local one is favored
+	r = h.score(ctx, candidates([]string{"FR"}), &filesystem.FileInfo{}, clientInfo)
+	if r.mlist[0].ID != 1 || r.weights[1] <= r.weights[2] {
+		t.Fatalf("Expected the mirror serving FR first, got %v (weights %v)", r.mlist, r.weights)
+	}
+}
+
+func TestFileMismatch(t *testing.T) {
+	SetConfiguration(&Configuration{})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	empty := &filesystem.FileInfo{Size: 0, ModTime: now}
+	file := &filesystem.FileInfo{Size: 1024, ModTime: now}
+
+	tests := []struct {
+		name     string
+		source   *filesystem.FileInfo
+		mirror   *filesystem.FileInfo
+		mismatch bool
+	}{
+		{"empty files", empty, &filesystem.FileInfo{Size: 0}, false},
+		{"empty files with mod time", empty, &filesystem.FileInfo{Size: 0, ModTime: now}, false},
+		{"empty file on the mirror", file, &filesystem.FileInfo{Size: 0}, true},
+		{"empty source file", empty, &filesystem.FileInfo{Size: 1024}, true},
+		{"unknown size", file, &filesystem.FileInfo{Size: -1}, false},
+		{"no file info", empty, nil, false},
+		{"mod time", empty, &filesystem.FileInfo{Size: 0, ModTime: now.Add(-time.Hour)}, true},
+	}
+
+	for _, test := range tests {
+		reason := fileMismatch(mirrors.Mirror{FileInfo: test.mirror}, test.source)
+		if (reason != "") != test.mismatch {
+			t.Fatalf("%s: expected mismatch to be %t, got %q", test.name, test.mismatch, reason)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+var (
+	// ErrFileNotFound is returned when simulating the selection of a file
+	// that would not be served
+	ErrFileNotFound = errors.New("file not found")
+	// ErrNoMirror is returned when no mirror nor fallback would be selected
+	ErrNoMirror = errors.New("no mirror available and no fallback configured")
+	// ErrInvalidIP is returned when the IP address of the client is invalid
+	ErrInvalidIP = errors.New("invalid IP address")
+)
+
+// SimulateSelection runs the selection of the mirrors for the given file as
+// it would be made for a client with the given IP address, optionally
+// relocated to another country, without serving nor counting the request
+func (h *HTTP) SimulateSelection(urlPath, ip, country string, secure SecureOption) (*mirrors.Results, error) {
+	if ip != "" && net.ParseIP(ip) == nil {
+		return nil, ErrInvalidIP
+	}
+
+	query := url.Values{}
+	switch secure {
+	case WITHTLS:
+		query.Set("https", "1")
+	case WITHOUTTLS:
+		query.Set("https", "0")
+	}
+	r := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: urlPath, RawQuery: query.Encode()},
+		Header: make(http.Header),
+	}
+	ctx := NewContext(nil, r, h.templates)
+
+	if GetConfig().ExceedsPathLimits(urlPath) {
+		return nil, ErrFileNotFound
+	}
+
+	var fileInfo filesystem.FileInfo
+	p, err := evaluateFilePath(GetConfig().Repository, urlPath)
+	if err == nil {
+		if GetConfig().IsEmbargoed(p) || GetConfig().IsPathExcluded(p) {
+			return nil, ErrFileNotFound
+		}
+		fileInfo, err = h.cache.GetFileInfo(p)
+		if err != nil {
+			return nil, err
+		}
+	} else if os.IsNotExist(err) {
+		var ok bool
+		fileInfo, ok, err = h.consensusFileInfo(urlPath)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrFileNotFound
+		}
+	} else {
+		return nil, ErrFileNotFound
+	}
+
+	clientInfo := h.geoip.GetRecord(ip)
+	if country = strings.ToUpper(strings.TrimSpace(country)); len(country) == 2 {
+		h.setClientCountry(&clientInfo, country)
+	}
+	ctx.SetClientIP(ip)
+
+	mlist, excluded, err := h.engine.Selection(ctx, h.cache, &fileInfo, clientInfo)
+	if err == nil {
+		mlist, excluded = selectionHook(ctx, &fileInfo, clientInfo, mlist, excluded)
+	}
+
+	fallback := false
+	if _, ok := err.(net.Error); ok || len(mlist) == 0 {
+		if len(GetConfig().Fallbacks) == 0 {
+			return nil, ErrNoMirror
+		}
+		fallback = true
+		mlist = h.appendFallbacks(mlist, clientInfo)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &mirrors.Results{
+		FileInfo:     fileInfo,
+		MirrorList:   mlist,
+		ExcludedList: excluded,
+		ClientInfo:   clientInfo,
+		IP:           ip,
+		Fallback:     fallback,
+		Degraded:     mlist.HasStale(),
+	}, nil
+}
+
+// SimulateFallback ranks the configured fallbacks as they would be for a
+// client with the given IP address, optionally relocated to another country,
+// when the database is unavailable
+func (h *HTTP) SimulateFallback(ip, country string) (*mirrors.Results, error) {
+	if ip != "" && net.ParseIP(ip) == nil {
+		return nil, ErrInvalidIP
+	}
+	if len(GetConfig().Fallbacks) == 0 {
+		return nil, ErrNoMirror
+	}
+
+	clientInfo := h.geoip.GetRecord(ip)
+	if country = strings.ToUpper(strings.TrimSpace(country)); len(country) == 2 {
+		h.setClientCountry(&clientInfo, country)
+	}
+
+	return &mirrors.Results{
+		MirrorList: h.appendFallbacks(nil, clientInfo),
+		ClientInfo: clientInfo,
+		IP:         ip,
+		Fallback:   true,
+	}, nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+var continentNames = map[string]string{
+	"AF": "Africa",
+	"AN": "Antarctica",
+	"AS": "Asia",
+	"EU": "Europe",
+	"NA": "North America",
+	"OC": "Oceania",
+	"SA": "South America",
+}
+
+// Sponsor is an organization sponsoring at least one mirror
+type Sponsor struct {
+	Name    string
+	URL     string `json:",omitempty"`
+	LogoURL string `json:",omitempty"`
+	Mirrors []string
+}
+
+// SponsorCountry contains the sponsors of the mirrors of a country
+type SponsorCountry struct {
+	Code     string
+	Sponsors []Sponsor
+}
+
+// SponsorContinent contains the sponsors of the mirrors of a continent
+// grouped by country
+type SponsorContinent struct {
+	Code      string
+	Name      string
+	Countries []SponsorCountry
+}
+
+// SponsorsPage contains the values needed to generate the sponsors page
+type SponsorsPage struct {
+	Continents  []SponsorContinent
+	LocalJSPath string
+}
+
+// groupSponsors returns the sponsors of the enabled mirrors grouped by
+// continent and country, a sponsor appearing once per country
+func groupSponsors(mlist []mirrors.Mirror) []SponsorContinent {
+	byContinent := make(map[string]map[string][]Sponsor)
+	for _, m := range mlist {
+		if !m.Enabled || m.SponsorName == "" {
+			continue
+		}
+		continent := strings.ToUpper(m.ContinentCode)
+		country := ""
+		if len(m.CountryFields) > 0 {
+			country = m.CountryFields[0]
+		}
+
+		if byContinent[continent] == nil {
+			byContinent[continent] = make(map[string][]Sponsor)
+		}
+		sponsors := byContinent[continent][country]
+		found := false
+		for i := range sponsors {
+			if sponsors[i].Name == m.SponsorName {
+				sponsors[i].Mirrors = append(sponsors[i].Mirrors, m.Name)
+				found = true
+				break
+			}
+		}
+		if !found {
+			sponsors = append(sponsors, Sponsor{
+				Name:    m.SponsorName,
+				URL:     m.SponsorURL,
+				LogoURL: m.SponsorLogoURL,
+				Mirrors: []string{m.Name},
+			})
+		}
+		byContinent[continent][country] = sponsors
+	}
+
+	continents := make([]SponsorContinent, 0, len(byContinent))
+	for code, countries := range byContinent {
+		c := SponsorContinent{
+			Code: code,
+			Name: continentNames[code],
+		}
+		if c.Name == "" {
+			c.Name = "Unknown"
+		}
+		for country, sponsors := range countries {
+			sort.Slice(sponsors, func(i, j int) bool {
+				return strings.ToLower(sponsors[i].Name) < strings.ToLower(sponsors[j].Name)
+			})
+			for i := range sponsors {
+				sort.Strings(sponsors[i].Mirrors)
+			}
+			c.Countries = append(c.Countries, SponsorCountry{
+				Code:     country,
+				Sponsors: sponsors,
+			})
+		}
+		sort.Slice(c.Countries, func(i, j int) bool {
+			return c.Countries[i].Code < c.Countries[j].Code
+		})
+		continents = append(continents, c)
+	}
+	sort.Slice(continents, func(i, j int) bool {
+		return continents[i].Name < continents[j].Name
+	})
+	return continents
+}
+
+func (h *HTTP) sponsorsHandler(w http.ResponseWriter, r *http.Request, ctx *Context) {
+	mirrorsIDs, err := h.redis.GetListOfMirrors()
+	if err != nil {
+		http.Error(w, "Cannot fetch the list of mirrors", http.StatusInternalServerError)
+		return
+	}
+
+	mlist := make([]mirrors.Mirror, 0, len(mirrorsIDs))
+	for id := range mirrorsIDs {
+		m, err := h.cache.GetMirror(id)
+		if err != nil {
+			continue
+		}
+		mlist = append(mlist, m)
+	}
+	continents := groupSponsors(mlist)
+
+	switch ctx.QueryParam("format") {
+	case "", "html":
+	case "json":
+		var output []byte
+		if ctx.IsPretty() {
+			output, err = json.MarshalIndent(continents, "", "    ")
+		} else {
+			output, err = json.Marshal(continents)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(output)))
+		w.Write(output)
+		return
+	default:
+		http.Error(w, "Unsupported sponsors format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = ctx.Templates().sponsors.ExecuteTemplate(w, "base", SponsorsPage{
+		Continents:  continents,
+		LocalJSPath: GetConfig().LocalJSPath,
+	})
+	if err != nil {
+		log.Errorf("HTTP error: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"testing"
+
+	"github.com/etix/mirrorbits/mirrors"
+)
+
+func TestGroupSponsors(t *testing.T) {
+	mlist := []mirrors.Mirror{
+		{Name: "m1", Enabled: true, SponsorName: "Acme", SponsorURL: "https://acme.example/", ContinentCode: "EU", CountryFields: []string{"FR"}},
+		{Name: "m2", Enabled: true, SponsorName: "Acme", ContinentCode: "EU", CountryFields: []string{"FR"}},
+		{Name: "m3", Enabled: true, SponsorName: "beta", ContinentCode: "EU", CountryFields: []string{"DE"}},
+		{Name: "m4", Enabled: true, SponsorName: "Gamma", ContinentCode: "NA", CountryFields: []string{"US", "CA"}},
+		{Name: "m5", Enabled: false, SponsorName: "Disabled", ContinentCode: "EU", CountryFields: []string{"FR"}},
+		{Name: "m6", Enabled: true, ContinentCode: "EU", CountryFields: []string{"FR"}},
+	}
+
+	continents := groupSponsors(mlist)
+	if len(continents) != 2 {
+		t.Fatalf("Expected 2 continents, got %d", len(continents))
+	}
+	eu := continents[0]
+	if eu.Name != "Europe" || len(eu.Countries) != 2 {
+		t.Fatalf("Expected Europe with 2 countries, got %+v", eu)
+	}
+	if eu.Countries[0].Code != "DE" || eu.Countries[1].Code != "FR" {
+		t.Fatalf("Expected the countries to be sorted, got %+v", eu.Countries)
+	}
+	fr := eu.Countries[1].Sponsors
+	if len(fr) != 1 || fr[0].Name != "Acme" || fr[0].URL != "https://acme.example/" {
+		t.Fatalf("Expected a single enabled sponsor in FR, got %+v", fr)
+	}
+	if len(fr[0].Mirrors) != 2 || fr[0].Mirrors[0] != "m1" || fr[0].Mirrors[1] != "m2" {
+		t.Fatalf("Expected the sponsor to list both of its mirrors, got %v", fr[0].Mirrors)
+	}
+	if na := continents[1]; na.Name != "North America" || na.Countries[0].Code != "US" {
+		t.Fatalf("Expected the mirror to be listed under its first country, got %+v", na)
+	}
+
+	if len(groupSponsors(nil)) != 0 {
+		t.Fatalf("Expected no sponsor")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/database"
 	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/mirrors"
@@ -26,11 +27,20 @@ import (
 	STATS_FILE_[year]_[month]			= path -> value		By month
 	STATS_FILE_[year]_[month]_[day]		= path -> value		By day
 
+	When StatsFileShards is set, each of these hashes is split by the
+	hash of the path into STATS_FILE[...]:[shard] (see database.StatsFileKey)
+
 	List of hashes for a mirror:
 	STATS_MIRROR						= mirror -> value	All time
 	STATS_MIRROR_[year]					= mirror -> value	By year
 	STATS_MIRROR_[year]_[month]			= mirror -> value	By month
 	STATS_MIRROR_[year]_[month]_[day]	= mirror -> value	By day
+
+	List of hashes for the HTTP responses:
+	STATS_HTTP							= handler code -> value	All time
+	STATS_HTTP_[year]					= handler code -> value	By year
+	STATS_HTTP_[year]_[month]			= handler code -> value	By month
+	STATS_HTTP_[year]_[month]_[day]		= handler code -> value	By day
 */
 
 var (
@@ -46,6 +56,34 @@ type Stats struct {
 	stop       chan bool
 	wg         sync.WaitGroup
 	downgraded bool
+
+	// Cumulative counters since startup, used by the metrics exporter
+	countersLock       sync.Mutex
+	counters           map[int]MirrorCounter
+	consensusFallbacks int64
+	staleResponses     int64
+	responses          map[ResponseKey]int64
+	pendingResponses   map[string]int64
+	protocols          map[ProtocolKey]int64
+}
+
+// ResponseKey identifies the responses sent by a handler with a status code
+type ResponseKey struct {
+	Handler string
+	Code    int
+}
+
+// ProtocolKey identifies the requests made using an HTTP protocol and a
+// TLS version, the latter being empty for plain HTTP
+type ProtocolKey struct {
+	Proto string
+	TLS   string
+}
+
+// MirrorCounter holds the number of downloads and bytes served by a mirror
+type MirrorCounter struct {
+	Downloads int64
+	Bytes     int64
 }
 
 type countItem struct {
@@ -62,6 +100,11 @@ func NewStats(redis *database.Redis) *Stats {
 		countChan: make(chan countItem, 1000),
 		mapStats:  make(map[string]int64),
 		stop:      make(chan bool),
+		counters:  make(map[int]MirrorCounter),
+		responses: make(map[ResponseKey]int64),
+		protocols: make(map[ProtocolKey]int64),
+
+		pendingResponses: make(map[string]int64),
 	}
 	go s.processCountDownload()
 	return s
@@ -82,11 +125,97 @@ func (s *Stats) CountDownload(m mirrors.Mirror, fileinfo filesystem.FileInfo) er
 	if fileinfo.Path == "" {
 		return errEmptyFileError
 	}
+	if !GetConfig().IsStatsEnabled(fileinfo.Path) {
+		return nil
+	}
 
 	s.countChan <- countItem{m.ID, fileinfo.Path, fileinfo.Size, time.Now().UTC()}
 	return nil
 }
 
+// Counters returns a copy of the cumulative download counters per mirror ID
+func (s *Stats) Counters() map[int]MirrorCounter {
+	s.countersLock.Lock()
+	defer s.countersLock.Unlock()
+	counters := make(map[int]MirrorCounter, len(s.counters))
+	for k, v := range s.counters {
+		counters[k] = v
+	}
+	return counters
+}
+
+// CountConsensusFallback counts a request served using the mirrors
+// consensus because the file was missing from the local repository
+func (s *Stats) CountConsensusFallback() {
+	s.countersLock.Lock()
+	s.consensusFallbacks++
+	s.countersLock.Unlock()
+}
+
+// ConsensusFallbacks returns the number of requests served using the
+// mirrors consensus since startup
+func (s *Stats) ConsensusFallbacks() int64 {
+	s.countersLock.Lock()
+	defer s.countersLock.Unlock()
+	return s.consensusFallbacks
+}
+
+// CountStaleResponse counts a request served using the stale entries of
+// the cache because the database failed
+func (s *Stats) CountStaleResponse() {
+	s.countersLock.Lock()
+	s.staleResponses++
+	s.countersLock.Unlock()
+}
+
+// StaleResponses returns the number of requests served using the stale
+// entries of the cache since startup
+func (s *Stats) StaleResponses() int64 {
+	s.countersLock.Lock()
+	defer s.countersLock.Unlock()
+	return s.staleResponses
+}
+
+// CountResponse counts a response sent to a client by the given handler
+func (s *Stats) CountResponse(handler string, code int) {
+	date := time.Now().Format("2006_01_02|") // Includes separator
+	s.countersLock.Lock()
+	s.responses[ResponseKey{handler, code}]++
+	s.pendingResponses["h"+date+handler+" "+strconv.Itoa(code)]++
+	s.countersLock.Unlock()
+}
+
+// Responses returns the number of responses sent per handler and
+// status code since startup
+func (s *Stats) Responses() map[ResponseKey]int64 {
+	s.countersLock.Lock()
+	defer s.countersLock.Unlock()
+	responses := make(map[ResponseKey]int64, len(s.responses))
+	for k, v := range s.responses {
+		responses[k] = v
+	}
+	return responses
+}
+
+// CountProtocol counts a request made using the given protocols
+func (s *Stats) CountProtocol(hints ClientHints) {
+	s.countersLock.Lock()
+	s.protocols[ProtocolKey{hints.Proto, hints.TLS}]++
+	s.countersLock.Unlock()
+}
+
+// Protocols returns the number of requests made per HTTP protocol and
+// TLS version since startup
+func (s *Stats) Protocols() map[ProtocolKey]int64 {
+	s.countersLock.Lock()
+	defer s.countersLock.Unlock()
+	protocols := make(map[ProtocolKey]int64, len(s.protocols))
+	for k, v := range s.protocols {
+		protocols[k] = v
+	}
+	return protocols
+}
+
 // Process all stacked download messages
 func (s *Stats) processCountDownload() {
 	s.wg.Add(1)
@@ -103,6 +232,12 @@ func (s *Stats) processCountDownload() {
 			s.mapStats["f"+date+c.filepath]++
 			s.mapStats["m"+date+strconv.Itoa(c.mirrorID)]++
 			s.mapStats["s"+date+strconv.Itoa(c.mirrorID)] += c.size
+			s.countersLock.Lock()
+			counter := s.counters[c.mirrorID]
+			counter.Downloads++
+			counter.Bytes += c.size
+			s.counters[c.mirrorID] = counter
+			s.countersLock.Unlock()
 		case <-pushTicker.C:
 			s.pushStats()
 		}
@@ -111,11 +246,20 @@ func (s *Stats) processCountDownload() {
 
 // Push the resulting stats on redis
 func (s *Stats) pushStats() {
+	// Merge the responses counted since the last push
+	s.countersLock.Lock()
+	for k, v := range s.pendingResponses {
+		s.mapStats[k] += v
+	}
+	s.pendingResponses = make(map[string]int64)
+	s.countersLock.Unlock()
+
 	if len(s.mapStats) <= 0 {
 		return
 	}
 
-	rconn := s.r.Get()
+	// The stats of a read-only replica are forwarded to the master
+	rconn := s.r.Master()
 	defer rconn.Close()
 
 	if rconn.Err() != nil {
@@ -149,7 +293,7 @@ func (s *Stats) pushStats() {
 			fkey := fmt.Sprintf("STATS_FILE_%s", date)
 
 			for i := 0; i < 4; i++ {
-				rconn.Send("HINCRBY", fkey, object, v)
+				rconn.Send("HINCRBY", database.StatsFileKey(fkey, object), object, v)
 				fkey = fkey[:strings.LastIndex(fkey, "_")]
 			}
 
@@ -173,6 +317,15 @@ func (s *Stats) pushStats() {
 				rconn.Send("HINCRBY", mkey, object, v)
 				mkey = mkey[:strings.LastIndex(mkey, "_")]
 			}
+		} else if typ == "h" {
+			// HTTP responses
+
+			hkey := fmt.Sprintf("STATS_HTTP_%s", date)
+
+			for i := 0; i < 4; i++ {
+				rconn.Send("HINCRBY", hkey, object, v)
+				hkey = hkey[:strings.LastIndex(hkey, "_")]
+			}
 		} else {
 			log.Warning("Stats: unknown type", typ)
 		}
@@ -0,0 +1,98 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Without any file on disk, the embedded templates are used
+	SetConfiguration(&Configuration{Templates: filepath.Join(dir, "missing")})
+	h := &HTTP{}
+	for _, name := range []string{"mirrorlist", "mirrorstats", "mirrorhistory", "sponsors", "directory"} {
+		tmpl, err := h.LoadTemplates(name)
+		if err != nil {
+			t.Fatalf("Unable to load the embedded %s template: %s", name, err)
+		}
+		if tmpl.Lookup("base") == nil {
+			t.Fatalf("The base template is missing from %s", name)
+		}
+	}
+
+	// Same without any templates directory configured
+	SetConfiguration(&Configuration{})
+	if _, err := h.LoadTemplates("mirrorlist"); err != nil {
+		t.Fatalf("Unable to load the embedded templates: %s", err)
+	}
+
+	// Files on disk override the embedded templates
+	err = ioutil.WriteFile(filepath.Join(dir, "mirrorlist.html"), []byte(`{{define "title"}}custom{{end}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetConfiguration(&Configuration{Templates: dir})
+	tmpl, err := h.LoadTemplates("mirrorlist")
+	if err != nil {
+		t.Fatalf("Unable to load the templates: %s", err)
+	}
+	if tmpl.Lookup("mirrorlist.html") == nil || tmpl.Lookup("base") == nil {
+		t.Fatalf("Expected the overridden template along with the embedded base")
+	}
+	if tmpl.Lookup("title").Tree.Root.String() != "custom" {
+		t.Fatalf("Expected the title of the overridden template, got %s", tmpl.Lookup("title").Tree.Root.String())
+	}
+
+	// Invalid templates are reported
+	err = ioutil.WriteFile(filepath.Join(dir, "mirrorstats.html"), []byte(`{{if}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.LoadTemplates("mirrorstats"); err == nil {
+		t.Fatalf("Expected an error for an invalid template")
+	}
+}
+
+func TestMirrorStatsTemplateHistory(t *testing.T) {
+	SetConfiguration(&Configuration{})
+	h := &HTTP{}
+	tmpl, err := h.LoadTemplates("mirrorstats")
+	if err != nil {
+		t.Fatalf("Unable to load the mirrorstats template: %s", err)
+	}
+
+	page := MirrorStatsPage{
+		List: []MirrorStats{{
+			ID:   1,
+			Name: "m1",
+			History: []DailyStats{
+				{Date: "2019-01-01", Downloads: 10, Bytes: 1024, Percent: 100},
+				{Date: "2019-01-02", Downloads: 5, Bytes: 512, Percent: 50},
+			},
+		}},
+		Days: 2,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "base", page); err != nil {
+		t.Fatalf("Unable to execute the mirrorstats template: %s", err)
+	}
+	for _, s := range []string{"Last 2 days", `<rect x="1" width="0.8" height="50">`, "2019-01-01: 10 downloads"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected %q in the mirrorstats page", s)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+	"github.com/etix/mirrorbits/utils"
+)
+
+// recentClientsSize is the number of recent requests kept to simulate
+// the traffic
+const recentClientsSize = 1000
+
+var (
+	// ErrNoTraffic is returned when simulating the traffic before any
+	// request has been answered
+	ErrNoTraffic = errors.New("no recent request to simulate")
+)
+
+// clientSample is a request recently answered by the server
+type clientSample struct {
+	path       string
+	clientInfo network.GeoIPRecord
+}
+
+// recentClients is a ring buffer holding the last requests answered
+// by the server
+type recentClients struct {
+	sync.Mutex
+	samples []clientSample
+	next    int
+}
+
+func (r *recentClients) add(s clientSample) {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.samples) < recentClientsSize {
+		r.samples = append(r.samples, s)
+		return
+	}
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % recentClientsSize
+}
+
+func (r *recentClients) list() []clientSample {
+	r.Lock()
+	defer r.Unlock()
+	return append([]clientSample(nil), r.samples...)
+}
+
+// HypotheticalMirror is a mirror that could be added to the pool
+type HypotheticalMirror struct {
+	Name          string
+	Latitude      float32
+	Longitude     float32
+	CountryCode   string
+	ContinentCode string
+	Asnum         uint
+	Score         int
+}
+
+// TrafficChange describes the changes of the pool to simulate
+type TrafficChange struct {
+	Disable []int
+	Add     []HypotheticalMirror
+}
+
+// TrafficShare is the share of the traffic redirected to a mirror
+// before and after a change, in percent
+type TrafficShare struct {
+	ID     int
+	Name   string
+	Before float32
+	After  float32
+}
+
+// TrafficSimulation is the predicted redistribution of the traffic
+type TrafficSimulation struct {
+	Samples        int
+	Mirrors        []TrafficShare
+	UnservedBefore float32
+	UnservedAfter  float32
+}
+
+// SimulateTraffic runs the selection of the mirrors for the last requests
+// answered by the server, with and without the given change, and returns
+// the share of the traffic each mirror would receive
+func (h *HTTP) SimulateTraffic(change TrafficChange) (*TrafficSimulation, error) {
+	samples := h.clients.list()
+	if len(samples) == 0 {
+		return nil, ErrNoTraffic
+	}
+
+	disabled := make(map[int]bool)
+	for _, id := range change.Disable {
+		disabled[id] = true
+	}
+
+	// Run the selection as for the mirrorlist to get the weights
+	// of the mirrors instead of a random order
+	ctx := NewContext(nil, &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{RawQuery: "mirrorlist"},
+		Header: make(http.Header),
+	}, h.templates)
+	engine := DefaultEngine{}
+
+	before := make(map[int]float64)
+	after := make(map[int]float64)
+	names := make(map[int]string)
+	var unservedBefore, unservedAfter float64
+	count := 0
+
+	for _, s := range samples {
+		fileInfo, err := h.cache.GetFileInfo(s.path)
+		if err != nil {
+			return nil, err
+		}
+		current, err := h.cache.GetMirrors(s.path, s.clientInfo)
+		if err != nil {
+			return nil, err
+		}
+		count++
+
+		candidates := append(mirrors.Mirrors(nil), current...)
+		mlist, _ := engine.rank(ctx, candidates, &fileInfo, s.clientInfo)
+		if !addTrafficShares(before, names, mlist, s.clientInfo) {
+			unservedBefore++
+		}
+
+		candidates = candidates[:0]
+		for _, m := range current {
+			if disabled[m.ID] {
+				m.Enabled = false
+			}
+			candidates = append(candidates, m)
+		}
+		for i, hm := range change.Add {
+			m := mirrors.Mirror{
+				ID:            -(i + 1),
+				Name:          hm.Name,
+				HttpURL:       "http://" + hm.Name + "/",
+				Enabled:       true,
+				Up:            true,
+				Latitude:      hm.Latitude,
+				Longitude:     hm.Longitude,
+				CountryCodes:  strings.ToUpper(hm.CountryCode),
+				CountryFields: strings.Fields(strings.ToUpper(hm.CountryCode)),
+				ContinentCode: strings.ToUpper(hm.ContinentCode),
+				Asnum:         hm.Asnum,
+				Score:         hm.Score,
+			}
+			if s.clientInfo.IsValid() {
+				m.Distance = utils.GetDistanceKm(s.clientInfo.Latitude, s.clientInfo.Longitude, m.Latitude, m.Longitude)
+			}
+			candidates = append(candidates, m)
+		}
+		mlist, _ = engine.rank(ctx, candidates, &fileInfo, s.clientInfo)
+		if !addTrafficShares(after, names, mlist, s.clientInfo) {
+			unservedAfter++
+		}
+	}
+
+	percent := func(v float64) float32 {
+		return float32(v * 100 / float64(count))
+	}
+	simulation := &TrafficSimulation{
+		Samples:        count,
+		UnservedBefore: percent(unservedBefore),
+		UnservedAfter:  percent(unservedAfter),
+	}
+	for id, name := range names {
+		simulation.Mirrors = append(simulation.Mirrors, TrafficShare{
+			ID:     id,
+			Name:   name,
+			Before: percent(before[id]),
+			After:  percent(after[id]),
+		})
+	}
+	sort.Slice(simulation.Mirrors, func(i, j int) bool {
+		a, b := simulation.Mirrors[i], simulation.Mirrors[j]
+		if a.After != b.After {
+			return a.After > b.After
+		}
+		if a.Before != b.Before {
+			return a.Before > b.Before
+		}
+		return a.Name < b.Name
+	})
+	return simulation, nil
+}
+
+// addTrafficShares adds the probability of each mirror of the ranked list
+// to be chosen for the client to the shares. It returns false if no mirror
+// would be chosen.
+func addTrafficShares(shares map[int]float64, names map[int]string, mlist mirrors.Mirrors, clientInfo network.GeoIPRecord) bool {
+	if len(mlist) == 0 {
+		return false
+	}
+	if !clientInfo.IsValid() {
+		// The list is shuffled for the clients that cannot be located
+		for _, m := range mlist {
+			shares[m.ID] += 1 / float64(len(mlist))
+			names[m.ID] = m.Name
+		}
+		return true
+	}
+	weighted := false
+	for _, m := range mlist {
+		if m.Weight > 0 {
+			shares[m.ID] += float64(m.Weight) / 100
+			names[m.ID] = m.Name
+			weighted = true
+		}
+	}
+	if !weighted {
+		shares[mlist[0].ID]++
+		names[mlist[0].ID] = mlist[0].Name
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package http
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/etix/mirrorbits/mirrors"
+	"github.com/etix/mirrorbits/network"
+)
+
+func TestRecentClients(t *testing.T) {
+	var r recentClients
+	for i := 0; i < recentClientsSize+10; i++ {
+		r.add(clientSample{path: "/" + strconv.Itoa(i)})
+	}
+	samples := r.list()
+	if len(samples) != recentClientsSize {
+		t.Fatalf("Expected %d samples, got %d", recentClientsSize, len(samples))
+	}
+	// The oldest samples are overwritten first
+	if samples[0].path != "/"+strconv.Itoa(recentClientsSize) || samples[10].path != "/10" {
+		t.Fatalf("Unexpected samples %s and %s", samples[0].path, samples[10].path)
+	}
+}
+
+func TestAddTrafficShares(t *testing.T) {
+	located := network.GeoIPRecord{CountryCode: "FR", ContinentCode: "EU", Latitude: 48.8, Longitude: 2.3}
+	shares := make(map[int]float64)
+	names := make(map[int]string)
+
+	if addTrafficShares(shares, names, nil, located) {
+		t.Fatalf("Expected the client not to be served")
+	}
+
+	// Weighted mirrors
+	addTrafficShares(shares, names, mirrors.Mirrors{
+		{ID: 1, Name: "m1", Weight: 75},
+		{ID: 2, Name: "m2", Weight: 25},
+		{ID: 3, Name: "m3"},
+	}, located)
+	if shares[1] != 0.75 || shares[2] != 0.25 || shares[3] != 0 {
+		t.Fatalf("Unexpected shares %v", shares)
+	}
+
+	// Without weights the first mirror gets the request
+	addTrafficShares(shares, names, mirrors.Mirrors{{ID: 3, Name: "m3"}, {ID: 1, Name: "m1"}}, located)
+	if shares[3] != 1 || shares[1] != 0.75 {
+		t.Fatalf("Unexpected shares %v", shares)
+	}
+
+	// The clients that cannot be located are spread evenly
+	addTrafficShares(shares, names, mirrors.Mirrors{{ID: 1, Name: "m1"}, {ID: 2, Name: "m2"}}, network.GeoIPRecord{})
+	if shares[1] != 1.25 || shares[2] != 0.75 {
+		t.Fatalf("Unexpected shares %v", shares)
+	}
+	if len(names) != 3 || names[3] != "m3" {
+		t.Fatalf("Unexpected names %v", names)
+	}
+}
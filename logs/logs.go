@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	stdlog "log"
+	"math/rand"
 	"os"
 	"runtime"
 	"strconv"
@@ -24,20 +25,21 @@ import (
 var (
 	log     = logging.MustGetLogger("main")
 	rlogger runtimeLogger
-	dlogger downloadsLogger
+	dlogger fileLogger
+	alogger fileLogger
 )
 
 type runtimeLogger struct {
 	f *os.File
 }
 
-type downloadsLogger struct {
+type fileLogger struct {
 	sync.RWMutex
 	l *stdlog.Logger
 	f io.WriteCloser
 }
 
-func (d *downloadsLogger) Close() {
+func (d *fileLogger) Close() {
 	if d.f != nil {
 		d.f.Close()
 		d.f = nil
@@ -50,6 +52,7 @@ func ReloadLogs() {
 	ReloadRuntimeLogs()
 	if core.Daemon {
 		ReloadDownloadLogs()
+		ReloadAccessLogs()
 	}
 }
 
@@ -115,8 +118,14 @@ func openLogFile(logfile string) (*os.File, bool, error) {
 }
 
 func setDownloadLogWriter(writer io.Writer, createHeader bool) {
-	dlogger.l = stdlog.New(writer, "", stdlog.Ldate|stdlog.Lmicroseconds)
+	dlogger.l = newFileLogger(writer, createHeader)
+}
+
+func setAccessLogWriter(writer io.Writer, createHeader bool) {
+	alogger.l = newFileLogger(writer, createHeader)
+}
 
+func newFileLogger(writer io.Writer, createHeader bool) *stdlog.Logger {
 	if createHeader {
 		var buf bytes.Buffer
 		hostname, _ := os.Hostname()
@@ -125,6 +134,7 @@ func setDownloadLogWriter(writer io.Writer, createHeader bool) {
 		fmt.Fprintf(&buf, "# Binary: Built with %s %s for %s/%s\n", runtime.Compiler, runtime.Version(), runtime.GOOS, runtime.GOARCH)
 		writer.Write(buf.Bytes())
 	}
+	return stdlog.New(writer, "", stdlog.Ldate|stdlog.Lmicroseconds)
 }
 
 // ReloadDownloadLogs reopens the download logs for writing
@@ -163,6 +173,11 @@ func LogDownload(typ string, statuscode int, p *mirrors.Results, err error) {
 		errstr = err.Error()
 	}
 
+	hints := ""
+	if p != nil && p.ClientHints != "" {
+		hints = " " + p.ClientHints
+	}
+
 	if (statuscode == 302 || statuscode == 200) && p != nil && len(p.MirrorList) > 0 {
 		var distance, countries string
 		m := p.MirrorList[0]
@@ -177,22 +192,97 @@ func LogDownload(typ string, statuscode int, p *mirrors.Results, err error) {
 			sameASNum = "same"
 		}
 
-		dlogger.l.Printf("%s %d \"%s\" ip:%s mirror:%s%s %sasn:%d distance:%skm countries:%s",
-			typ, statuscode, p.FileInfo.Path, p.IP, m.Name, fallback, sameASNum, m.Asnum, distance, countries)
+		dlogger.l.Printf("%s %d \"%s\" ip:%s mirror:%s%s %sasn:%d distance:%skm countries:%s%s",
+			typ, statuscode, p.FileInfo.Path, p.IP, m.Name, fallback, sameASNum, m.Asnum, distance, countries, hints)
 	} else if statuscode == 404 && p != nil {
-		dlogger.l.Printf("%s 404 \"%s\" ip:%s", typ, p.FileInfo.Path, p.IP)
+		dlogger.l.Printf("%s 404 \"%s\" ip:%s%s", typ, p.FileInfo.Path, p.IP, hints)
 	} else if statuscode == 500 && p != nil {
 		mirrorName := "unknown"
 		if len(p.MirrorList) > 0 {
 			mirrorName = p.MirrorList[0].Name
 		}
-		dlogger.l.Printf("%s 500 \"%s\" ip:%s mirror:%s error:%s", typ, p.FileInfo.Path, p.IP, mirrorName, errstr)
+		dlogger.l.Printf("%s 500 \"%s\" ip:%s mirror:%s error:%s%s", typ, p.FileInfo.Path, p.IP, mirrorName, errstr, hints)
 	} else {
 		var path, ip string
 		if p != nil {
 			path = p.FileInfo.Path
 			ip = p.IP
 		}
-		dlogger.l.Printf("%s %d \"%s\" ip:%s error:%s", typ, statuscode, path, ip, errstr)
+		dlogger.l.Printf("%s %d \"%s\" ip:%s error:%s%s", typ, statuscode, path, ip, errstr, hints)
 	}
 }
+
+// ReloadAccessLogs reopens the access logs for writing
+func ReloadAccessLogs() {
+	alogger.Lock()
+	defer alogger.Unlock()
+
+	alogger.Close()
+
+	if GetConfig().LogDir == "" || !GetConfig().AccessLog.Enabled {
+		return
+	}
+
+	logfile := GetConfig().LogDir + "/access.log"
+	f, createHeader, err := openLogFile(logfile)
+	if err != nil {
+		log.Criticalf("Cannot open log file %s", logfile)
+		return
+	}
+
+	alogger.f = f
+	setAccessLogWriter(f, createHeader)
+}
+
+// AccessEntry is a request written to the access log
+type AccessEntry struct {
+	Method    string
+	URI       string
+	Status    int
+	Handler   string
+	IP        string
+	UserAgent string
+	Mirror    string
+	Duration  time.Duration
+	Selection time.Duration
+}
+
+// IsAccessLogEnabled returns true if the requests are written to the access log
+func IsAccessLogEnabled() bool {
+	alogger.RLock()
+	defer alogger.RUnlock()
+	return alogger.l != nil
+}
+
+// LogAccess writes a request to the access log, the successful requests
+// being sampled according to the SampleRate of the AccessLog
+func LogAccess(e AccessEntry) {
+	alogger.RLock()
+	defer alogger.RUnlock()
+
+	if alogger.l == nil {
+		// Logs are disabled
+		return
+	}
+
+	if rate := GetConfig().AccessLog.SampleRate; e.Status < 400 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d %q handler:%s ip:%s", e.Method, e.Status, e.URI, e.Handler, e.IP)
+	if e.Mirror != "" {
+		fmt.Fprintf(&buf, " mirror:%s", e.Mirror)
+	}
+	fmt.Fprintf(&buf, " duration:%s", formatMilliseconds(e.Duration))
+	if e.Selection > 0 {
+		fmt.Fprintf(&buf, " selection:%s", formatMilliseconds(e.Selection))
+	}
+	fmt.Fprintf(&buf, " ua:%q", e.UserAgent)
+
+	alogger.l.Print(buf.String())
+}
+
+func formatMilliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64) + "ms"
+}
@@ -11,7 +11,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
 	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/mirrors"
@@ -311,6 +313,18 @@ func TestLogDownload(t *testing.T) {
 
 	buf.Reset()
 
+	/* */
+	p.ClientHints = "proto:HTTP/2.0 tls:1.3 alpn:h2"
+
+	LogDownload("JSON", 404, p, nil)
+
+	expected = "JSON 404 \"/test/file.tgz\" ip:192.168.0.1 proto:HTTP/2.0 tls:1.3 alpn:h2\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("Invalid log line:\nGot:\n%#vs\nExpected:\n%#v", buf.String(), expected)
+	}
+
+	buf.Reset()
+
 	/* */
 	p = &mirrors.Results{
 		MirrorList: mirrors.Mirrors{
@@ -351,3 +365,70 @@ func TestLogDownload(t *testing.T) {
 
 	buf.Reset()
 }
+
+func TestLogAccess(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := Configuration{}
+	c.AccessLog.SampleRate = 1
+	SetConfiguration(&c)
+	defer SetConfiguration(&Configuration{})
+
+	alogger.Close()
+
+	// The next line isn't supposed to crash.
+	LogAccess(AccessEntry{Status: 200})
+
+	if IsAccessLogEnabled() {
+		t.Fatalf("The access log should be disabled")
+	}
+
+	setAccessLogWriter(&buf, false)
+	defer alogger.Close()
+
+	LogAccess(AccessEntry{
+		Method:    "GET",
+		URI:       "/test/file.tgz",
+		Status:    302,
+		Handler:   "redirect",
+		IP:        "192.168.0.1",
+		UserAgent: "curl/7.68.0",
+		Mirror:    "m1",
+		Duration:  1500 * time.Microsecond,
+		Selection: 250 * time.Microsecond,
+	})
+
+	expected := "GET 302 \"/test/file.tgz\" handler:redirect ip:192.168.0.1 mirror:m1 duration:1.500ms selection:0.250ms ua:\"curl/7.68.0\"\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("Invalid log line:\nGot:\n%#v\nExpected:\n%#v", buf.String(), expected)
+	}
+
+	buf.Reset()
+
+	LogAccess(AccessEntry{
+		Method:   "GET",
+		URI:      "/test/file.tgz?mirrorlist",
+		Status:   404,
+		Handler:  "mirrorlist",
+		IP:       "192.168.0.1",
+		Duration: time.Millisecond,
+	})
+
+	expected = "GET 404 \"/test/file.tgz?mirrorlist\" handler:mirrorlist ip:192.168.0.1 duration:1.000ms ua:\"\"\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("Invalid log line:\nGot:\n%#v\nExpected:\n%#v", buf.String(), expected)
+	}
+
+	buf.Reset()
+
+	// Only the errors are written without sampling
+	c.AccessLog.SampleRate = 0
+
+	LogAccess(AccessEntry{Method: "GET", URI: "/", Status: 200})
+	LogAccess(AccessEntry{Method: "GET", URI: "/", Status: 304})
+	LogAccess(AccessEntry{Method: "GET", URI: "/", Status: 500})
+
+	if c := strings.Count(buf.String(), "\n"); c != 1 {
+		t.Fatalf("Invalid number of lines, got %d, expected 1", c)
+	}
+}
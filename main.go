@@ -12,6 +12,7 @@ import (
 	"syscall"
 	"time"
 
+	systemd "github.com/coreos/go-systemd/daemon"
 	"github.com/etix/mirrorbits/cli"
 	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
@@ -22,6 +23,7 @@ import (
 	"github.com/etix/mirrorbits/mirrors"
 	"github.com/etix/mirrorbits/process"
 	"github.com/etix/mirrorbits/rpc"
+	"github.com/etix/mirrorbits/utils"
 	"github.com/op/go-logging"
 	"github.com/pkg/errors"
 )
@@ -44,16 +46,34 @@ func main() {
 	}
 
 	if core.Daemon {
+		if core.ConfigTest {
+			if err := cli.ConfigTest(core.ConfigFile, false); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(cli.ExitCode(err))
+			}
+			os.Exit(0)
+		}
+
 		LoadConfig()
 		logs.ReloadLogs()
 
 		process.WritePidFile()
 
+		if core.SetStandby(GetConfig().Standby) {
+			log.Notice("Starting in standby mode")
+		}
+
 		// Show our nice welcome logo
 		fmt.Printf(core.Banner+"\n\n", core.VERSION)
 
+		/* Sockets passed by systemd (socket activation) */
+		httpListeners, rpcListener := process.ActivatedListeners()
+
 		/* Setup RPC */
 		rpcs := new(rpc.CLI)
+		if rpcListener != nil {
+			rpcs.SetListener(rpcListener)
+		}
 		if err := rpcs.Start(); err != nil {
 			log.Fatal(errors.Wrap(err, "rpc error"))
 		}
@@ -64,11 +84,16 @@ func main() {
 		rpcs.SetDatabase(r)
 		c := mirrors.NewCache(r)
 		rpcs.SetCache(c)
+		bootstrapSnapshot(r, c)
 		h := http.HTTPServer(r, c)
+		rpcs.SetSelector(h)
 
 		/* Start the background monitor */
 		m := daemon.NewMonitor(r, c)
-		if core.Monitor {
+		rpcs.SetMonitor(m)
+		if r.ReadOnly() {
+			log.Notice("Read-only replica: the monitor is disabled")
+		} else if core.Monitor {
 			go m.MonitorLoop()
 		}
 
@@ -83,9 +108,31 @@ func main() {
 			syscall.SIGUSR1, // Reopen log files
 			syscall.SIGUSR2, // Seamless binary upgrade
 		)
+		monitorEnabled := core.Monitor && !r.ReadOnly()
+		if watchdog := watchdogTicker(); watchdog != nil {
+			// Not delayed by the handling of the signals
+			go func() {
+				watchdogFailing := false
+				for range watchdog {
+					// The watchdog is only notified while the daemon is healthy,
+					// systemd restarting it otherwise
+					if err := checkHealth(r, h, monitorEnabled); err != nil {
+						if !watchdogFailing {
+							log.Warningf("Not notifying the systemd watchdog: %s", err)
+						}
+						watchdogFailing = true
+						continue
+					}
+					if watchdogFailing {
+						log.Notice("Notifying the systemd watchdog again")
+					}
+					watchdogFailing = false
+					systemd.SdNotify(false, systemd.SdNotifyWatchdog)
+				}
+			}()
+		}
 		go func() {
-			for {
-				sig := <-k
+			for sig := range k {
 				switch sig {
 				case syscall.SIGINT:
 					fallthrough
@@ -95,7 +142,7 @@ func main() {
 				case syscall.SIGQUIT:
 					m.Stop()
 					rpcs.Close()
-					if h.Listener != nil {
+					if len(h.Listeners) > 0 {
 						log.Notice("Waiting for running tasks to finish...")
 						h.Stop(5 * time.Second)
 					} else {
@@ -103,13 +150,22 @@ func main() {
 						os.Exit(0)
 					}
 				case syscall.SIGHUP:
-					listenAddress := GetConfig().ListenAddress
+					listenAddresses := GetConfig().GetListenAddresses()
+					standby := GetConfig().Standby
 					if err := ReloadConfig(); err != nil {
 						log.Warningf("SIGHUP Received: %s\n", err)
 					} else {
 						log.Notice("SIGHUP Received: Reloading configuration...")
+						publishReload(r)
 					}
-					if GetConfig().ListenAddress != listenAddress {
+					if GetConfig().Standby != standby && core.SetStandby(GetConfig().Standby) {
+						if GetConfig().Standby {
+							log.Notice("Switching to standby mode")
+						} else {
+							log.Notice("Promoted out of standby mode")
+						}
+					}
+					if !utils.StringSliceEq(GetConfig().GetListenAddresses(), listenAddresses) {
 						h.Restarting = true
 						h.Stop(1 * time.Second)
 					}
@@ -121,7 +177,7 @@ func main() {
 				case syscall.SIGUSR2:
 					log.Notice("SIGUSR2 Received: Seamless binary upgrade...")
 					rpcs.Close()
-					err := process.Relaunch(*h.Listener)
+					err := process.Relaunch(h.Listeners)
 					if err != nil {
 						log.Errorf("Relaunch failed: %s\n", err)
 					}
@@ -131,11 +187,13 @@ func main() {
 
 		// Recover an existing listener (see process.go)
 		if l, ppid, err := process.Recover(); err == nil {
-			h.SetListener(l)
+			h.SetListeners(l)
 			go func() {
 				time.Sleep(100 * time.Millisecond)
 				process.KillParent(ppid)
 			}()
+		} else if len(httpListeners) > 0 {
+			h.SetListeners(httpListeners)
 		}
 
 		/* Finally start the HTTP server */
@@ -172,9 +230,96 @@ func main() {
 	} else {
 		args := os.Args[len(os.Args)-core.NArg:]
 		if err := cli.ParseCommands(args...); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-			os.Exit(1)
+			if err != cli.ErrUsage {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+			}
+			os.Exit(cli.ExitCode(err))
 		}
 	}
 	os.Exit(0)
 }
+
+// bootstrapSnapshot serves the requests from the snapshot of the database
+// if it is unreachable at start, until the connection is established
+func bootstrapSnapshot(r *database.Redis, c *mirrors.Cache) {
+	path := GetConfig().Snapshot.Path
+	if path == "" {
+		return
+	}
+
+	reachable := func() bool {
+		conn := r.UnblockedGet()
+		defer conn.Close()
+		_, err := conn.Do("PING")
+		return err == nil
+	}
+	if reachable() {
+		return
+	}
+
+	s, err := mirrors.LoadSnapshot(path)
+	if err != nil {
+		log.Errorf("Database unreachable and unable to load the snapshot: %s", err)
+		return
+	}
+	if reachable() {
+		// Reconnected while loading the snapshot
+		return
+	}
+	c.UseSnapshot(s)
+	log.Warningf("Database unreachable, serving the snapshot of %s", s.Created.Format(time.RFC3339))
+}
+
+// publishReload notifies the external tools that the configuration of
+// this node has been reloaded
+func publishReload(r *database.Redis) {
+	conn := r.Get()
+	defer conn.Close()
+	err := database.PublishEvent(conn, database.Event{
+		Type:    database.EventConfigReloaded,
+		Message: fmt.Sprintf("Configuration reloaded on %s", utils.Hostname()),
+	})
+	if err != nil {
+		log.Warningf("Unable to publish the reload event: %s", err)
+	}
+}
+
+// watchdogMonitorTimeout is the delay after which the monitor loop is
+// considered wedged if it didn't iterate over the mirrors
+const watchdogMonitorTimeout = time.Minute
+
+// watchdogTicker returns a channel ticking at half the interval of the
+// systemd watchdog (WatchdogSec), or nil if the watchdog is disabled
+func watchdogTicker() <-chan time.Time {
+	interval, err := systemd.SdWatchdogEnabled(false)
+	if err != nil {
+		log.Warningf("Invalid systemd watchdog settings: %s", err)
+		return nil
+	}
+	if interval == 0 {
+		return nil
+	}
+	log.Debugf("Notifying the systemd watchdog every %s", interval/2)
+	return time.NewTicker(interval / 2).C
+}
+
+// checkHealth returns an error if the HTTP server isn't serving, if the
+// monitor loop is wedged or if redis is unreachable
+func checkHealth(r *database.Redis, h *http.HTTP, monitorEnabled bool) error {
+	if !h.Serving() {
+		return errors.New("the HTTP server is not serving")
+	}
+	if monitorEnabled {
+		// The loop only starts once the repository and the mirrors are loaded
+		lastTick, _, _ := daemon.MonitorHealth()
+		if !lastTick.IsZero() && time.Since(lastTick) > watchdogMonitorTimeout {
+			return fmt.Errorf("the monitor is stalled since %s", lastTick.Format(time.RFC3339))
+		}
+	}
+	conn := r.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		return errors.Wrap(err, "redis is unreachable")
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/op/go-logging"
+)
+
+const (
+	exportTimeout = 10 * time.Second
+
+	// collectCacheTTL is the duration during which the collected samples
+	// are reused, sparing the database when several scrapers are polling
+	collectCacheTTL = 10 * time.Second
+)
+
+var (
+	log = logging.MustGetLogger("main")
+)
+
+// Sample is a single value to export
+type Sample struct {
+	Name  string
+	Tags  map[string]string
+	Value float64
+}
+
+// Collector returns the samples to export
+type Collector func() []Sample
+
+// Exporter periodically pushes the collected samples to a
+// time-series database (InfluxDB, Graphite or statsd) or
+// exposes them to be scraped by Prometheus.
+type Exporter struct {
+	collect Collector
+	client  http.Client
+	stop    chan struct{}
+	wg      sync.WaitGroup
+
+	promLock    sync.Mutex
+	promServer  *http.Server
+	promAddress string
+
+	cacheLock sync.Mutex
+	cached    []Sample
+	cachedAt  time.Time
+}
+
+// NewExporter returns a new instance of the exporter
+func NewExporter(collect Collector) *Exporter {
+	e := &Exporter{
+		collect: collect,
+		client: http.Client{
+			Timeout: exportTimeout,
+		},
+		stop: make(chan struct{}),
+	}
+	e.updatePrometheus()
+	e.wg.Add(1)
+	go e.loop()
+	return e
+}
+
+// Stop stops the exporter and pushes the samples one last time
+func (e *Exporter) Stop() {
+	select {
+	case <-e.stop:
+		return
+	default:
+		close(e.stop)
+	}
+	e.wg.Wait()
+	e.stopPrometheus()
+}
+
+func (e *Exporter) loop() {
+	defer e.wg.Done()
+	for {
+		interval := time.Duration(GetConfig().MetricsExport.Interval) * time.Second
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		select {
+		case <-e.stop:
+			e.push()
+			return
+		case <-time.After(interval):
+			e.updatePrometheus()
+			e.push()
+		}
+	}
+}
+
+func (e *Exporter) push() {
+	cfg := GetConfig().MetricsExport
+	if cfg.Type == "" || cfg.Type == "prometheus" || cfg.Address == "" {
+		return
+	}
+
+	samples := e.samples()
+	if len(samples) == 0 {
+		return
+	}
+
+	var err error
+	now := time.Now()
+	switch cfg.Type {
+	case "influxdb":
+		err = e.pushInflux(cfg.Address, cfg.Database, formatInflux(cfg.Prefix, samples, now))
+	case "graphite":
+		err = pushStream("tcp", cfg.Address, formatGraphite(cfg.Prefix, samples, now))
+	case "statsd":
+		err = pushStream("udp", cfg.Address, formatStatsd(cfg.Prefix, samples))
+	default:
+		err = fmt.Errorf("unknown type %s", cfg.Type)
+	}
+	if err != nil {
+		log.Warningf("Metrics export to %s failed: %s", cfg.Address, err)
+	}
+}
+
+// samples returns the collected samples, collecting them again only once
+// the cached ones are too old
+func (e *Exporter) samples() []Sample {
+	e.cacheLock.Lock()
+	defer e.cacheLock.Unlock()
+
+	if e.cached == nil || time.Since(e.cachedAt) >= collectCacheTTL {
+		e.cached = e.collect()
+		e.cachedAt = time.Now()
+	}
+	return e.cached
+}
+
+func (e *Exporter) pushInflux(address, database string, payload []byte) error {
+	u, err := url.Parse(strings.TrimRight(address, "/") + "/write")
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("db", database)
+	q.Set("precision", "s")
+	u.RawQuery = q.Encode()
+
+	resp, err := e.client.Post(u.String(), "text/plain; charset=utf-8", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("influxdb returned %s", resp.Status)
+	}
+	return nil
+}
+
+func pushStream(network, address string, payload []byte) error {
+	conn, err := net.DialTimeout(network, address, exportTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(exportTimeout))
+
+	if network == "udp" {
+		// Avoid sending datagrams larger than a common MTU
+		for _, chunk := range splitLines(payload, 1400) {
+			if _, err = conn.Write(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err = conn.Write(payload)
+	return err
+}
+
+func splitLines(payload []byte, max int) (chunks [][]byte) {
+	for len(payload) > 0 {
+		end := len(payload)
+		if end > max {
+			end = bytes.LastIndexByte(payload[:max], '\n') + 1
+			if end <= 0 {
+				end = bytes.IndexByte(payload, '\n') + 1
+				if end <= 0 {
+					end = len(payload)
+				}
+			}
+		}
+		chunks = append(chunks, payload[:end])
+		payload = payload[end:]
+	}
+	return
+}
+
+func sortedTags(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+func formatInflux(prefix string, samples []Sample, now time.Time) []byte {
+	var buf bytes.Buffer
+	for _, s := range samples {
+		name := s.Name
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		buf.WriteString(influxEscaper.Replace(name))
+		for _, k := range sortedTags(s.Tags) {
+			fmt.Fprintf(&buf, ",%s=%s", influxEscaper.Replace(k), influxEscaper.Replace(s.Tags[k]))
+		}
+		fmt.Fprintf(&buf, " value=%g %d\n", s.Value, now.Unix())
+	}
+	return buf.Bytes()
+}
+
+var graphiteEscaper = strings.NewReplacer(".", "_", " ", "_", "/", "_", ":", "_")
+
+func graphitePath(prefix string, s Sample) string {
+	var parts []string
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	for _, k := range sortedTags(s.Tags) {
+		parts = append(parts, graphiteEscaper.Replace(k), graphiteEscaper.Replace(s.Tags[k]))
+	}
+	parts = append(parts, s.Name)
+	return strings.Join(parts, ".")
+}
+
+func formatGraphite(prefix string, samples []Sample, now time.Time) []byte {
+	var buf bytes.Buffer
+	for _, s := range samples {
+		fmt.Fprintf(&buf, "%s %g %d\n", graphitePath(prefix, s), s.Value, now.Unix())
+	}
+	return buf.Bytes()
+}
+
+func formatStatsd(prefix string, samples []Sample) []byte {
+	var buf bytes.Buffer
+	for _, s := range samples {
+		fmt.Fprintf(&buf, "%s:%g|g\n", graphitePath(prefix, s), s.Value)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testSamples = []Sample{
+	{Name: "mirrors_up", Value: 12},
+	{Name: "responses", Tags: map[string]string{"handler": "mirror", "code": "302"}, Value: 1.5e6},
+	{Name: "disk_usage", Tags: map[string]string{"region": "eu, west=1", "path": "/srv/repo", "host": "node1.example.org:8080"}, Value: 0.25},
+}
+
+var testTime = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFormatInflux(t *testing.T) {
+	expected := `mirrorbits_mirrors_up value=12 1577836800
+mirrorbits_responses,code=302,handler=mirror value=1.5e+06 1577836800
+mirrorbits_disk_usage,host=node1.example.org:8080,path=/srv/repo,region=eu\,\ west\=1 value=0.25 1577836800
+`
+	if out := string(formatInflux("mirrorbits", testSamples, testTime)); out != expected {
+		t.Fatalf("Unexpected output:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	expected = "mirrors_up value=12 1577836800\n"
+	if out := string(formatInflux("", testSamples[:1], testTime)); out != expected {
+		t.Fatalf("Unexpected output without prefix:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	expected = "my\\ prefix_mirrors_up value=12 1577836800\n"
+	if out := string(formatInflux("my prefix", testSamples[:1], testTime)); out != expected {
+		t.Fatalf("Expected the measurement to be escaped:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestFormatGraphite(t *testing.T) {
+	expected := `mirrorbits.mirrors_up 12 1577836800
+mirrorbits.code.302.handler.mirror.responses 1.5e+06 1577836800
+mirrorbits.host.node1_example_org_8080.path._srv_repo.region.eu,_west=1.disk_usage 0.25 1577836800
+`
+	if out := string(formatGraphite("mirrorbits", testSamples, testTime)); out != expected {
+		t.Fatalf("Unexpected output:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	// The prefix may be a path
+	expected = "servers.mirrorbits.mirrors_up 12 1577836800\n"
+	if out := string(formatGraphite("servers.mirrorbits", testSamples[:1], testTime)); out != expected {
+		t.Fatalf("Unexpected output with a path as prefix:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	expected = "mirrors_up 12 1577836800\n"
+	if out := string(formatGraphite("", testSamples[:1], testTime)); out != expected {
+		t.Fatalf("Unexpected output without prefix:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestFormatStatsd(t *testing.T) {
+	expected := `mirrorbits.mirrors_up:12|g
+mirrorbits.code.302.handler.mirror.responses:1.5e+06|g
+mirrorbits.host.node1_example_org_8080.path._srv_repo.region.eu,_west=1.disk_usage:0.25|g
+`
+	if out := string(formatStatsd("mirrorbits", testSamples)); out != expected {
+		t.Fatalf("Unexpected output:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	expected = "mirrors_up:12|g\n"
+	if out := string(formatStatsd("", testSamples[:1])); out != expected {
+		t.Fatalf("Unexpected output without prefix:\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		payload  string
+		max      int
+		expected []string
+	}{
+		{"", 8, nil},
+		{"aaa\nbbb\n", 8, []string{"aaa\nbbb\n"}},
+		{"aaa\nbbb\nccc\n", 8, []string{"aaa\nbbb\n", "ccc\n"}},
+		// A line longer than the maximum is sent alone
+		{"aaaaaaaaaa\nb\n", 4, []string{"aaaaaaaaaa\n", "b\n"}},
+		{"aaaaaaaaaa", 4, []string{"aaaaaaaaaa"}},
+	}
+
+	for _, test := range tests {
+		var chunks []string
+		for _, c := range splitLines([]byte(test.payload), test.max) {
+			chunks = append(chunks, string(c))
+		}
+		if !reflect.DeepEqual(chunks, test.expected) {
+			t.Errorf("splitLines(%q, %d): expected %q, got %q", test.payload, test.max, test.expected, chunks)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/network"
+)
+
+var (
+	prometheusInvalidChars = regexp.MustCompile("[^a-zA-Z0-9_:]")
+	prometheusEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// updatePrometheus starts, stops or moves the Prometheus endpoint
+// according to the current configuration
+func (e *Exporter) updatePrometheus() {
+	cfg := GetConfig().MetricsExport
+
+	address := ""
+	if cfg.Type == "prometheus" {
+		address = cfg.Address
+	}
+
+	e.promLock.Lock()
+	defer e.promLock.Unlock()
+
+	if address == e.promAddress {
+		return
+	}
+	if e.promServer != nil {
+		e.promServer.Close()
+		e.promServer = nil
+	}
+	e.promAddress = address
+	if address == "" {
+		return
+	}
+
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Errorf("Metrics: unable to listen on %s: %s", address, err)
+		e.promAddress = ""
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.prometheusHandler)
+	e.promServer = &http.Server{Handler: mux}
+	go e.promServer.Serve(l)
+}
+
+func (e *Exporter) stopPrometheus() {
+	e.promLock.Lock()
+	defer e.promLock.Unlock()
+	if e.promServer != nil {
+		e.promServer.Close()
+		e.promServer = nil
+	}
+	e.promAddress = ""
+}
+
+func (e *Exporter) prometheusHandler(w http.ResponseWriter, r *http.Request) {
+	if !network.CheckAccess(w, r, &GetConfig().MetricsExport.Access) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(formatPrometheus(GetConfig().MetricsExport.Prefix, e.samples()))
+}
+
+func formatPrometheus(prefix string, samples []Sample) []byte {
+	if prefix == "" {
+		prefix = "mirrorbits"
+	}
+
+	// Samples of the same metric must be grouped together
+	byName := make(map[string][]Sample)
+	var names []string
+	for _, s := range samples {
+		name := prometheusInvalidChars.ReplaceAllString(prefix+"_"+s.Name, "_")
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], s)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		for _, s := range byName[name] {
+			buf.WriteString(name)
+			if len(s.Tags) > 0 {
+				var labels []string
+				for _, k := range sortedTags(s.Tags) {
+					labels = append(labels, fmt.Sprintf("%s=\"%s\"", prometheusInvalidChars.ReplaceAllString(k, "_"), prometheusEscaper.Replace(s.Tags[k])))
+				}
+				fmt.Fprintf(&buf, "{%s}", strings.Join(labels, ","))
+			}
+			fmt.Fprintf(&buf, " %g\n", s.Value)
+		}
+	}
+	return buf.Bytes()
+}
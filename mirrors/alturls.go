@@ -0,0 +1,128 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/etix/mirrorbits/database"
+	"github.com/etix/mirrorbits/network"
+	"github.com/etix/mirrorbits/utils"
+)
+
+// AltURL is an alternative HTTP base URL of a mirror, typically a regional
+// hostname of the sponsor serving the same content. It has its own location
+// but shares the files, the state and the stats of the mirror.
+type AltURL struct {
+	URL           string   `yaml:"URL"`
+	Latitude      float32  `yaml:"Latitude"`
+	Longitude     float32  `yaml:"Longitude"`
+	ContinentCode string   `yaml:"ContinentCode"`
+	CountryCodes  string   `yaml:"CountryCodes"`
+	Asnum         uint     `yaml:"ASNum"`
+	CountryFields []string `json:"-" yaml:"-"`
+	Down          bool     `json:"-" yaml:"-"` // failing the health checks of the mirror
+}
+
+// AltURLs is the list of the alternative URLs of a mirror
+type AltURLs []AltURL
+
+// RedisArg serializes the alternative URLs
+func (a AltURLs) RedisArg() interface{} {
+	if len(a) == 0 {
+		return ""
+	}
+	value, _ := json.Marshal([]AltURL(a))
+	return value
+}
+
+// RedisScan deserializes the alternative URLs
+func (a *AltURLs) RedisScan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot convert from %T to %T", src, a)
+	}
+	if len(b) == 0 {
+		*a = nil
+		return nil
+	}
+	return json.Unmarshal(b, (*[]AltURL)(a))
+}
+
+// prepareAltURLs reformats the values of the alternative URLs after
+// retrieval from the database
+func (m *Mirror) prepareAltURLs() {
+	down := strings.Fields(m.AltURLsDown)
+	for i := range m.AltURLs {
+		m.AltURLs[i].CountryFields = strings.Fields(m.AltURLs[i].CountryCodes)
+		m.AltURLs[i].Down = utils.IsInSlice(m.AltURLs[i].URL, down)
+	}
+}
+
+// UseClosestURL makes the mirror serve the client from the closest of its
+// URLs up, the main one or an alternative one, whose location is taken
+// over. The URLs in the country of the client come first, then those in
+// its continent.
+func (m *Mirror) UseClosestURL(clientInfo network.GeoIPRecord) {
+	if len(m.AltURLs) == 0 || !clientInfo.IsValid() {
+		return
+	}
+
+	best := -1
+	bestRank := urlRank(clientInfo, m.CountryFields, m.ContinentCode)
+	bestDistance := utils.GetDistanceKm(clientInfo.Latitude, clientInfo.Longitude, m.Latitude, m.Longitude)
+	for i, alt := range m.AltURLs {
+		if alt.Down {
+			continue
+		}
+		rank := urlRank(clientInfo, alt.CountryFields, alt.ContinentCode)
+		distance := utils.GetDistanceKm(clientInfo.Latitude, clientInfo.Longitude, alt.Latitude, alt.Longitude)
+		if rank > bestRank || (rank == bestRank && distance < bestDistance) {
+			best, bestRank, bestDistance = i, rank, distance
+		}
+	}
+	if best < 0 {
+		return
+	}
+
+	alt := m.AltURLs[best]
+	m.HttpURL = alt.URL
+	m.Latitude = alt.Latitude
+	m.Longitude = alt.Longitude
+	m.ContinentCode = alt.ContinentCode
+	m.CountryCodes = alt.CountryCodes
+	m.CountryFields = alt.CountryFields
+	if alt.Asnum > 0 {
+		m.Asnum = alt.Asnum
+	}
+}
+
+func urlRank(clientInfo network.GeoIPRecord, countries []string, continent string) int {
+	if utils.IsInSlice(clientInfo.CountryCode, countries) {
+		return 2
+	}
+	if continent != "" && clientInfo.ContinentCode == continent {
+		return 1
+	}
+	return 0
+}
+
+// SetAltURLsDown records the alternative URLs of a mirror failing the
+// health checks, excluded from the selection
+func SetAltURLsDown(r *database.Redis, id int, down []string) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HSET", fmt.Sprintf("MIRROR_%d", id), "altURLsDown", strings.Join(down, " "))
+	if err != nil {
+		return err
+	}
+
+	// Publish update
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+
+	"github.com/etix/mirrorbits/network"
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestAltURLsRedis(t *testing.T) {
+	alts := AltURLs{
+		{URL: "http://eu.example.org/", Latitude: 48.8, Longitude: 2.3, ContinentCode: "EU", CountryCodes: "FR BE", Asnum: 1234},
+	}
+
+	var m Mirror
+	err := redis.ScanStruct([]interface{}{
+		[]byte("altURLs"), alts.RedisArg(),
+		[]byte("altURLsDown"), []byte("http://eu.example.org/"),
+	}, &m)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	m.Prepare()
+
+	if len(m.AltURLs) != 1 || m.AltURLs[0].URL != alts[0].URL || m.AltURLs[0].Asnum != 1234 {
+		t.Fatalf("Unexpected alternative URLs: %+v", m.AltURLs)
+	}
+	if len(m.AltURLs[0].CountryFields) != 2 || !m.AltURLs[0].Down {
+		t.Fatalf("Alternative URLs not prepared: %+v", m.AltURLs)
+	}
+
+	if v := (AltURLs{}).RedisArg(); v != "" {
+		t.Fatalf("Expected an empty value, got %v", v)
+	}
+	if err = m.AltURLs.RedisScan([]byte("")); err != nil || m.AltURLs != nil {
+		t.Fatalf("Expected no alternative URL, got %+v (%v)", m.AltURLs, err)
+	}
+}
+
+func TestMirror_UseClosestURL(t *testing.T) {
+	newMirror := func() Mirror {
+		m := Mirror{
+			HttpURL:       "http://us.example.org/",
+			Latitude:      40.7,
+			Longitude:     -74,
+			ContinentCode: "NA",
+			CountryCodes:  "US",
+			Asnum:         1,
+			AltURLs: AltURLs{
+				{URL: "http://fr.example.org/", Latitude: 48.8, Longitude: 2.3, ContinentCode: "EU", CountryCodes: "FR"},
+				{URL: "http://de.example.org/", Latitude: 52.5, Longitude: 13.4, ContinentCode: "EU", CountryCodes: "DE", Asnum: 3},
+			},
+		}
+		m.Prepare()
+		return m
+	}
+
+	// Same country
+	m := newMirror()
+	m.UseClosestURL(network.GeoIPRecord{CountryCode: "DE", ContinentCode: "EU", Latitude: 48.8, Longitude: 2.3})
+	if m.HttpURL != "http://de.example.org/" || m.Asnum != 3 || m.CountryCodes != "DE" || m.Latitude != 52.5 {
+		t.Fatalf("Expected the URL of the country of the client, got %+v", m)
+	}
+
+	// Same continent, the closest
+	m = newMirror()
+	m.UseClosestURL(network.GeoIPRecord{CountryCode: "ES", ContinentCode: "EU", Latitude: 40.4, Longitude: -3.7})
+	if m.HttpURL != "http://fr.example.org/" || m.Asnum != 1 || m.ContinentCode != "EU" {
+		t.Fatalf("Expected the closest URL of the continent, got %+v", m)
+	}
+
+	// The main URL remains the closest
+	m = newMirror()
+	m.UseClosestURL(network.GeoIPRecord{CountryCode: "CA", ContinentCode: "NA", Latitude: 45.5, Longitude: -73.6})
+	if m.HttpURL != "http://us.example.org/" {
+		t.Fatalf("Expected the main URL, got %s", m.HttpURL)
+	}
+
+	// The URLs down are skipped
+	m = newMirror()
+	m.AltURLsDown = "http://de.example.org/"
+	m.Prepare()
+	m.UseClosestURL(network.GeoIPRecord{CountryCode: "DE", ContinentCode: "EU", Latitude: 52.5, Longitude: 13.4})
+	if m.HttpURL != "http://fr.example.org/" {
+		t.Fatalf("Expected the URL up, got %s", m.HttpURL)
+	}
+
+	// Unknown location of the client
+	m = newMirror()
+	m.UseClosestURL(network.GeoIPRecord{})
+	if m.HttpURL != "http://us.example.org/" {
+		t.Fatalf("Expected the main URL, got %s", m.HttpURL)
+	}
+}
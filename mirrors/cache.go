@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
+	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/database"
 	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/network"
@@ -25,6 +28,20 @@ type Cache struct {
 	fmCache  *LRUCache
 	mCache   *LRUCache
 	fimCache *LRUCache
+	selCache *LRUCache
+
+	// selGeneration is incremented each time a selection may be invalidated
+	selLock       sync.Mutex
+	selGeneration uint32
+
+	// generation is incremented each time the cache is reset while keeping
+	// the previous entries as stale until staleUntil (unix nano), see ServeStale
+	generation uint32
+	staleUntil int64
+
+	// snapshot is served when the database is unreachable at start
+	snapshotLock sync.RWMutex
+	snapshot     *Snapshot
 
 	mirrorUpdateEvent      chan string
 	fileUpdateEvent        chan string
@@ -34,7 +51,8 @@ type Cache struct {
 }
 
 type fileInfoValue struct {
-	value filesystem.FileInfo
+	value      filesystem.FileInfo
+	generation uint32
 }
 
 func (f *fileInfoValue) Size() int {
@@ -42,7 +60,8 @@ func (f *fileInfoValue) Size() int {
 }
 
 type fileMirrorValue struct {
-	value []int
+	value      []int
+	generation uint32
 }
 
 func (f *fileMirrorValue) Size() int {
@@ -50,13 +69,39 @@ func (f *fileMirrorValue) Size() int {
 }
 
 type mirrorValue struct {
-	value Mirror
+	value      Mirror
+	generation uint32
 }
 
 func (f *mirrorValue) Size() int {
 	return int(unsafe.Sizeof(f.value))
 }
 
+// generationValue is implemented by the values tagged with the generation
+// of the cache they were fetched in
+type generationValue interface {
+	gen() uint32
+}
+
+func (f *fileInfoValue) gen() uint32   { return f.generation }
+func (f *fileMirrorValue) gen() uint32 { return f.generation }
+func (f *mirrorValue) gen() uint32     { return f.generation }
+
+type selectionEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// selectionValue holds the selections of a file for each location bucket,
+// it is replaced as a whole on update
+type selectionValue struct {
+	value map[string]selectionEntry
+}
+
+func (f *selectionValue) Size() int {
+	return len(f.value)
+}
+
 // NewCache constructs a new instance of Cache
 func NewCache(r *database.Redis) *Cache {
 	if r == nil || r.Pubsub == nil {
@@ -72,6 +117,7 @@ func NewCache(r *database.Redis) *Cache {
 	c.fmCache = NewLRUCache(2048000)
 	c.mCache = NewLRUCache(1024000)
 	c.fimCache = NewLRUCache(4096000)
+	c.selCache = NewLRUCache(100000)
 
 	// Create event channels
 	c.mirrorUpdateEvent = make(chan string, 10)
@@ -93,6 +139,7 @@ func NewCache(r *database.Redis) *Cache {
 			select {
 			case data := <-c.mirrorUpdateEvent:
 				c.mCache.Delete(data)
+				c.clearSelections()
 				select {
 				case c.invalidationEvent <- data:
 				default:
@@ -100,11 +147,14 @@ func NewCache(r *database.Redis) *Cache {
 				}
 			case data := <-c.fileUpdateEvent:
 				c.fiCache.Delete(data)
+				c.deleteSelections(data)
 			case data := <-c.mirrorFileUpdateEvent:
 				s := strings.SplitN(data, " ", 2)
 				c.fmCache.Delete(s[1])
 				c.fimCache.Delete(fmt.Sprintf("%s|%s", s[0], s[1]))
+				c.deleteSelections(s[1])
 			case <-c.pubsubReconnectedEvent:
+				c.UseSnapshot(nil)
 				c.Clear()
 			}
 		}
@@ -113,12 +163,116 @@ func NewCache(r *database.Redis) *Cache {
 	return c
 }
 
-// Clear clears the local cache
+// Clear clears the local cache. With ServeStale, the entries are kept to be
+// served if the database fails while the fresh ones are fetched.
 func (c *Cache) Clear() {
-	c.fiCache.Clear()
-	c.fmCache.Clear()
-	c.mCache.Clear()
-	c.fimCache.Clear()
+	if stale := GetConfig().ServeStale; stale > 0 {
+		atomic.StoreInt64(&c.staleUntil, time.Now().Add(time.Duration(stale)*time.Second).UnixNano())
+		atomic.AddUint32(&c.generation, 1)
+	} else {
+		c.fiCache.Clear()
+		c.fmCache.Clear()
+		c.mCache.Clear()
+		c.fimCache.Clear()
+	}
+	c.clearSelections()
+}
+
+// get returns the value stored in the given LRU cache, stale being true if
+// it was fetched before the last reset of the cache
+func (c *Cache) get(lru *LRUCache, key string) (v Value, stale, ok bool) {
+	v, ok = lru.Get(key)
+	if !ok {
+		return
+	}
+	stale = v.(generationValue).gen() != atomic.LoadUint32(&c.generation)
+	return
+}
+
+// serveStale returns true if a stale value can be served instead of
+// failing with the given error
+func (c *Cache) serveStale(err error) bool {
+	return err != redis.ErrNil && time.Now().UnixNano() < atomic.LoadInt64(&c.staleUntil)
+}
+
+// UseSnapshot sets the snapshot the values are served from when the
+// database fails, until the next reconnection to the database
+func (c *Cache) UseSnapshot(s *Snapshot) {
+	c.snapshotLock.Lock()
+	c.snapshot = s
+	c.snapshotLock.Unlock()
+}
+
+// fallbackSnapshot returns the snapshot to serve the values from
+// after the given error, nil if there is none or no error
+func (c *Cache) fallbackSnapshot(err error) *Snapshot {
+	if err == nil || err == redis.ErrNil {
+		return nil
+	}
+	c.snapshotLock.RLock()
+	defer c.snapshotLock.RUnlock()
+	return c.snapshot
+}
+
+func (c *Cache) clearSelections() {
+	c.selLock.Lock()
+	defer c.selLock.Unlock()
+	c.selGeneration++
+	c.selCache.Clear()
+}
+
+func (c *Cache) deleteSelections(path string) {
+	c.selLock.Lock()
+	defer c.selLock.Unlock()
+	c.selGeneration++
+	c.selCache.Delete(path)
+}
+
+// SelectionGeneration returns a token to be given to SetSelection, it must
+// be retrieved before fetching the data the selection is computed from.
+func (c *Cache) SelectionGeneration() uint32 {
+	c.selLock.Lock()
+	defer c.selLock.Unlock()
+	return c.selGeneration
+}
+
+// GetSelection returns the selection stored by SetSelection for the given
+// file and bucket, if it didn't expire yet
+func (c *Cache) GetSelection(path, bucket string) (interface{}, bool) {
+	v, ok := c.selCache.Get(path)
+	if !ok {
+		return nil, false
+	}
+	e, ok := v.(*selectionValue).value[bucket]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// SetSelection stores the selection of mirrors of a file for the given
+// bucket of clients during ttl. The selection is dropped if the cache has
+// been invalidated since the given generation was retrieved, the selection
+// being possibly computed from outdated data.
+func (c *Cache) SetSelection(path, bucket string, value interface{}, ttl time.Duration, generation uint32) {
+	c.selLock.Lock()
+	defer c.selLock.Unlock()
+	if c.selGeneration != generation {
+		return
+	}
+
+	now := time.Now()
+	entries := map[string]selectionEntry{
+		bucket: {value: value, expires: now.Add(ttl)},
+	}
+	if v, ok := c.selCache.Get(path); ok {
+		for k, e := range v.(*selectionValue).value {
+			if k != bucket && now.Before(e.expires) {
+				entries[k] = e
+			}
+		}
+	}
+	c.selCache.Set(path, &selectionValue{value: entries})
 }
 
 // GetMirrorInvalidationEvent returns a channel that contains ID of mirrors
@@ -132,11 +286,18 @@ func (c *Cache) GetMirrorInvalidationEvent() <-chan string {
 // GetFileInfo returns file information for a given file either from the cache
 // or directly from the database if the object is not yet stored in the cache.
 func (c *Cache) GetFileInfo(path string) (f filesystem.FileInfo, err error) {
-	v, ok := c.fiCache.Get(path)
-	if ok {
+	v, stale, ok := c.get(c.fiCache, path)
+	if ok && !stale {
 		f = v.(*fileInfoValue).value
 	} else {
 		f, err = c.fetchFileInfo(path)
+		if err != nil && ok && c.serveStale(err) {
+			f, err = v.(*fileInfoValue).value, nil
+		} else if s := c.fallbackSnapshot(err); s != nil {
+			if sf, found := s.fileInfo(path); found {
+				f, err = sf, nil
+			}
+		}
 	}
 	return
 }
@@ -146,30 +307,74 @@ func (c *Cache) fetchFileInfo(path string) (f filesystem.FileInfo, err error) {
 	defer rconn.Close()
 	f.Path = path // Path is not stored in the object instance in redis
 
-	reply, err := redis.Strings(rconn.Do("HMGET", fmt.Sprintf("FILE_%s", path), "size", "modTime", "sha1", "sha256", "md5"))
+	reply, err := redis.Strings(rconn.Do("HMGET", redis.Args{fmt.Sprintf("FILE_%s", path)}.AddFlat(fileInfoFields)...))
 	if err != nil {
 		return
 	}
+	if f, err = parseFileInfo(path, reply); err != nil {
+		return
+	}
+	c.fiCache.Set(path, &fileInfoValue{value: f, generation: atomic.LoadUint32(&c.generation)})
+	return
+}
 
-	f.Size, _ = strconv.ParseInt(reply[0], 10, 64)
-	f.ModTime, _ = time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", reply[1])
-	f.Sha1 = reply[2]
-	f.Sha256 = reply[3]
-	f.Md5 = reply[4]
-	c.fiCache.Set(path, &fileInfoValue{value: f})
+// fileInfoFields are the fields of the FILE_ hashes read by parseFileInfo
+var fileInfoFields = []string{"size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed"}
+
+// parseFileInfo returns the file information from the values of
+// fileInfoFields in the FILE_ hash of the given file
+func parseFileInfo(path string, reply []string) (f filesystem.FileInfo, err error) {
+	f.Path = path // Path is not stored in the object instance in redis
+
+	if len(reply[9]) > 0 {
+		if err = filesystem.UnpackFileInfo([]byte(reply[9]), &f); err != nil {
+			return
+		}
+	} else {
+		f.Size, _ = strconv.ParseInt(reply[0], 10, 64)
+		f.ModTime, _ = time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", reply[1])
+		f.Sha1 = reply[2]
+		f.Sha256 = reply[3]
+		f.Md5 = reply[4]
+		f.Sha512 = reply[5]
+		f.Blake2b = reply[6]
+	}
+	f.FirstSeen = parseUnixTime(reply[7])
+	f.LastSeen = parseUnixTime(reply[8])
 	return
 }
 
+// parseUnixTime returns the time stored as a unix timestamp or nil if the
+// value is not set
+func parseUnixTime(value string) *time.Time {
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || sec <= 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
+
 // GetMirrors returns all the mirrors serving a given file either from the cache
 // or directly from the database if the object is not yet stored in the cache.
 func (c *Cache) GetMirrors(path string, clientInfo network.GeoIPRecord) (mirrors []Mirror, err error) {
 	var mirrorsIDs []int
-	v, ok := c.fmCache.Get(path)
-	if ok {
+	v, stale, ok := c.get(c.fmCache, path)
+	if ok && !stale {
 		mirrorsIDs = v.(*fileMirrorValue).value
 	} else {
 		mirrorsIDs, err = c.fetchFileMirrors(path)
-		if err != nil {
+		if err == nil {
+			stale = false
+		} else if ok && c.serveStale(err) {
+			mirrorsIDs, err = v.(*fileMirrorValue).value, nil
+		} else if s := c.fallbackSnapshot(err); s != nil {
+			var found bool
+			if mirrorsIDs, found = s.fileMirrors(path); !found {
+				return
+			}
+			stale, err = true, nil
+		} else {
 			return
 		}
 	}
@@ -177,25 +382,21 @@ func (c *Cache) GetMirrors(path string, clientInfo network.GeoIPRecord) (mirrors
 	for _, id := range mirrorsIDs {
 		var mirror Mirror
 		var fileInfo filesystem.FileInfo
-		v, ok := c.mCache.Get(strconv.Itoa(id))
-		if ok {
-			mirror = v.(*mirrorValue).value
-		} else {
-			//TODO execute missing items in a MULTI query
-			mirror, err = c.fetchMirror(id)
-			if err != nil {
-				return
-			}
+		var mirrorStale, fileStale bool
+		mirror, mirrorStale, err = c.getMirror(id)
+		if err != nil {
+			return
 		}
-		v, ok = c.fimCache.Get(fmt.Sprintf("%d|%s", id, path))
-		if ok {
-			fileInfo = v.(*fileInfoValue).value
-		} else {
-			fileInfo, err = c.fetchFileInfoMirror(id, path)
-			if err != nil {
-				return
-			}
+		// The files of a removed mirror may still reference it until they
+		// are all updated
+		if !mirror.RemovedAt.IsZero() {
+			continue
 		}
+		fileInfo, fileStale, err = c.getFileInfoMirror(id, path)
+		if err != nil {
+			return
+		}
+		mirror.Stale = stale || mirrorStale || fileStale
 		if fileInfo.Size >= 0 {
 			mirror.FileInfo = &fileInfo
 		}
@@ -203,6 +404,9 @@ func (c *Cache) GetMirrors(path string, clientInfo network.GeoIPRecord) (mirrors
 		// Add the path in the results so we can access it from the templates
 		mirror.FileInfo.Path = path
 
+		// Serve the client from the closest URL of the mirror
+		mirror.UseClosestURL(clientInfo)
+
 		if clientInfo.IsValid() {
 			mirror.Distance = utils.GetDistanceKm(clientInfo.Latitude,
 				clientInfo.Longitude,
@@ -219,11 +423,11 @@ func (c *Cache) GetMirrors(path string, clientInfo network.GeoIPRecord) (mirrors
 func (c *Cache) fetchFileMirrors(path string) (ids []int, err error) {
 	rconn := c.r.Get()
 	defer rconn.Close()
-	ids, err = redis.Ints(rconn.Do("SMEMBERS", fmt.Sprintf("FILEMIRRORS_%s", path)))
+	ids, err = database.GetFileMirrors(rconn, path)
 	if err != nil {
 		return
 	}
-	c.fmCache.Set(path, &fileMirrorValue{value: ids})
+	c.fmCache.Set(path, &fileMirrorValue{value: ids, generation: atomic.LoadUint32(&c.generation)})
 	return
 }
 
@@ -234,6 +438,15 @@ func (c *Cache) fetchMirror(mirrorID int) (mirror Mirror, err error) {
 	if err != nil {
 		return
 	}
+	if mirror, err = parseMirror(reply); err != nil {
+		return
+	}
+	c.mCache.Set(strconv.Itoa(mirrorID), &mirrorValue{value: mirror, generation: atomic.LoadUint32(&c.generation)})
+	return
+}
+
+// parseMirror returns the mirror stored in the given MIRROR_ hash
+func parseMirror(reply []interface{}) (mirror Mirror, err error) {
 	if len(reply) == 0 {
 		err = redis.ErrNil
 		return
@@ -242,24 +455,35 @@ func (c *Cache) fetchMirror(mirrorID int) (mirror Mirror, err error) {
 	if err != nil {
 		return
 	}
+	mirror.Latencies, err = parseLatencies(reply)
+	if err != nil {
+		return
+	}
 	mirror.Prepare()
-	c.mCache.Set(strconv.Itoa(mirrorID), &mirrorValue{value: mirror})
 	return
 }
 
 func (c *Cache) GetFileInfoMirror(mirrorID int, path string) (f filesystem.FileInfo, err error) {
-	var fileInfo filesystem.FileInfo
+	f, _, err = c.getFileInfoMirror(mirrorID, path)
+	return
+}
 
-	v, ok := c.fimCache.Get(fmt.Sprintf("%d|%s", mirrorID, path))
-	if ok {
-		fileInfo = v.(*fileInfoValue).value
-	} else {
-		fileInfo, err = c.fetchFileInfoMirror(mirrorID, path)
-		if err != nil {
-			return
+// getFileInfoMirror returns the file information of the given mirror,
+// stale being true if it was served from the cache while the database failed
+func (c *Cache) getFileInfoMirror(mirrorID int, path string) (f filesystem.FileInfo, stale bool, err error) {
+	v, stale, ok := c.get(c.fimCache, fmt.Sprintf("%d|%s", mirrorID, path))
+	if ok && !stale {
+		return v.(*fileInfoValue).value, false, nil
+	}
+	f, err = c.fetchFileInfoMirror(mirrorID, path)
+	if err != nil && ok && c.serveStale(err) {
+		return v.(*fileInfoValue).value, true, nil
+	} else if s := c.fallbackSnapshot(err); s != nil {
+		if sf, found := s.fileInfoMirror(mirrorID, path); found {
+			return sf, true, nil
 		}
 	}
-	return fileInfo, nil
+	return f, false, err
 }
 
 func (c *Cache) fetchFileInfoMirror(id int, path string) (f filesystem.FileInfo, err error) {
@@ -275,27 +499,42 @@ func (c *Cache) fetchFileInfoMirror(id int, path string) (f filesystem.FileInfo,
 	// Note: as of today, only the size is stored by the scanners
 	// all other fields are left blank.
 
-	f.Size, _ = strconv.ParseInt(reply[0], 10, 64)
+	// An unknown size must not be mistaken for an empty file
+	f.Size = -1
+	if reply[0] != "" {
+		f.Size, _ = strconv.ParseInt(reply[0], 10, 64)
+	}
 	f.ModTime, _ = time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", reply[1])
 	f.Sha1 = reply[2]
 	f.Sha256 = reply[3]
 	f.Md5 = reply[4]
 
-	c.fimCache.Set(fmt.Sprintf("%d|%s", id, path), &fileInfoValue{value: f})
+	c.fimCache.Set(fmt.Sprintf("%d|%s", id, path), &fileInfoValue{value: f, generation: atomic.LoadUint32(&c.generation)})
 	return
 }
 
 // GetMirror returns all information about a given mirror either from the cache
 // or directly from the database if the object is not yet stored in the cache.
 func (c *Cache) GetMirror(id int) (mirror Mirror, err error) {
-	v, ok := c.mCache.Get(strconv.Itoa(id))
-	if ok {
-		mirror = v.(*mirrorValue).value
-	} else {
-		mirror, err = c.fetchMirror(id)
-		if err != nil {
-			return
+	mirror, _, err = c.getMirror(id)
+	return
+}
+
+// getMirror returns the given mirror, stale being true if it was served
+// from the cache while the database failed
+func (c *Cache) getMirror(id int) (mirror Mirror, stale bool, err error) {
+	v, stale, ok := c.get(c.mCache, strconv.Itoa(id))
+	if ok && !stale {
+		return v.(*mirrorValue).value, false, nil
+	}
+	//TODO execute missing items in a MULTI query
+	mirror, err = c.fetchMirror(id)
+	if err != nil && ok && c.serveStale(err) {
+		return v.(*mirrorValue).value, true, nil
+	} else if s := c.fallbackSnapshot(err); s != nil {
+		if m, found := s.mirror(id); found {
+			return m, true, nil
 		}
 	}
-	return
+	return mirror, false, err
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 	"unsafe"
 
+	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/filesystem"
 	"github.com/etix/mirrorbits/network"
 	. "github.com/etix/mirrorbits/testing"
@@ -51,6 +52,8 @@ func TestCache_Clear(t *testing.T) {
 	c.mCache.Set("test", &TestValue{"42"})
 	c.fimCache.Set("test", &TestValue{"42"})
 
+	// The entries are dropped unless ServeStale is set
+	SetConfiguration(&Configuration{})
 	c.Clear()
 
 	if _, ok := c.fiCache.Get("test"); ok {
@@ -67,6 +70,49 @@ func TestCache_Clear(t *testing.T) {
 	}
 }
 
+func TestCache_Selection(t *testing.T) {
+	_, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	c := NewCache(conn)
+
+	generation := c.SelectionGeneration()
+	c.SetSelection("/file", "FR", "ranking", time.Minute, generation)
+	c.SetSelection("/file", "DE", "other", time.Minute, generation)
+
+	if v, ok := c.GetSelection("/file", "FR"); !ok || v.(string) != "ranking" {
+		t.Fatalf("Selection not stored")
+	}
+	if v, ok := c.GetSelection("/file", "DE"); !ok || v.(string) != "other" {
+		t.Fatalf("Selection of another bucket not stored")
+	}
+	if _, ok := c.GetSelection("/file", "US"); ok {
+		t.Fatalf("Unexpected selection for an unknown bucket")
+	}
+
+	c.SetSelection("/expired", "FR", "ranking", -time.Second, generation)
+	if _, ok := c.GetSelection("/expired", "FR"); ok {
+		t.Fatalf("Expired selection returned")
+	}
+
+	c.deleteSelections("/file")
+	if _, ok := c.GetSelection("/file", "FR"); ok {
+		t.Fatalf("Selection should have been invalidated")
+	}
+
+	// Computed before the invalidation
+	c.SetSelection("/file", "FR", "ranking", time.Minute, generation)
+	if _, ok := c.GetSelection("/file", "FR"); ok {
+		t.Fatalf("Outdated selection should have been dropped")
+	}
+
+	c.SetSelection("/file", "FR", "ranking", time.Minute, c.SelectionGeneration())
+	c.Clear()
+	if _, ok := c.GetSelection("/file", "FR"); ok {
+		t.Fatalf("Selection should have been cleared")
+	}
+}
+
 func assertFileInfoEqual(t *testing.T, actual *filesystem.FileInfo, expected *filesystem.FileInfo) {
 	t.Helper()
 	if actual.Path != expected.Path {
@@ -87,6 +133,24 @@ func assertFileInfoEqual(t *testing.T, actual *filesystem.FileInfo, expected *fi
 	if actual.Md5 != expected.Md5 {
 		t.Fatalf("Md5 doesn't match, expected %#v got %#v", expected.Md5, actual.Md5)
 	}
+	if actual.Sha512 != expected.Sha512 {
+		t.Fatalf("Sha512 doesn't match, expected %#v got %#v", expected.Sha512, actual.Sha512)
+	}
+	if actual.Blake2b != expected.Blake2b {
+		t.Fatalf("Blake2b doesn't match, expected %#v got %#v", expected.Blake2b, actual.Blake2b)
+	}
+	for _, seen := range []struct {
+		name             string
+		actual, expected *time.Time
+	}{
+		{"FirstSeen", actual.FirstSeen, expected.FirstSeen},
+		{"LastSeen", actual.LastSeen, expected.LastSeen},
+	} {
+		if (seen.actual == nil) != (seen.expected == nil) ||
+			(seen.actual != nil && !seen.actual.Equal(*seen.expected)) {
+			t.Fatalf("%s doesn't match, expected %v got %v", seen.name, seen.expected, seen.actual)
+		}
+	}
 }
 
 func TestCache_fetchFileInfo(t *testing.T) {
@@ -102,19 +166,28 @@ func TestCache_fetchFileInfo(t *testing.T) {
 		Sha1:    "3ce963aea2d6f23fe915063f8bba21888db0ddfa",
 		Sha256:  "1c8e38c7e03e4d117eba4f82afaf6631a9b79f4c1e9dec144d4faf1d109aacda",
 		Md5:     "2c98ec39f49da6ddd9cfa7b1d7342afe",
+		Sha512:  "19cedd9e92b6265b6e69f78234a94d4422dbda0affa0dbb421dcab1bdcd7b81bbe4afc836868885e8bac9cac9f95da134d4b13f8d72df7fdb14b3a288f1e9913",
+		Blake2b: "95994415099df3b5d5c6bcf42f3b59e97f5f0802da8ab2610521c1fd04cd94d57c0299122a66b4732e52b722456ff4486ee36b3e92d1cef9f38cbee596d71cdf",
 	}
+	firstSeen, lastSeen := time.Unix(1500000000, 0), time.Unix(1600000000, 0)
+	testfile.FirstSeen, testfile.LastSeen = &firstSeen, &lastSeen
 
 	f, err := c.fetchFileInfo(testfile.Path)
 	if err == nil {
 		t.Fatalf("Error expected, mock command not yet registered")
 	}
 
-	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").Expect([]interface{}{
 		[]byte(strconv.FormatInt(testfile.Size, 10)),
 		[]byte(testfile.ModTime.Format("2006-01-02 15:04:05.999999999 -0700 MST")),
 		[]byte(testfile.Sha1),
 		[]byte(testfile.Sha256),
 		[]byte(testfile.Md5),
+		[]byte(testfile.Sha512),
+		[]byte(testfile.Blake2b),
+		[]byte(strconv.FormatInt(firstSeen.Unix(), 10)),
+		[]byte(strconv.FormatInt(lastSeen.Unix(), 10)),
+		nil,
 	})
 
 	f, err = c.fetchFileInfo(testfile.Path)
@@ -134,6 +207,46 @@ func TestCache_fetchFileInfo(t *testing.T) {
 	}
 }
 
+func TestCache_fetchFileInfo_packed(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	c := NewCache(conn)
+
+	testfile := filesystem.FileInfo{
+		Path:    "/test/file.tgz",
+		Size:    43000,
+		ModTime: time.Unix(1400000000, 12345),
+		Sha1:    "3ce963aea2d6f23fe915063f8bba21888db0ddfa",
+		Sha256:  "1c8e38c7e03e4d117eba4f82afaf6631a9b79f4c1e9dec144d4faf1d109aacda",
+	}
+	firstSeen, lastSeen := time.Unix(1500000000, 0), time.Unix(1600000000, 0)
+	testfile.FirstSeen, testfile.LastSeen = &firstSeen, &lastSeen
+
+	packed, err := filesystem.PackFileInfo(testfile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").Expect([]interface{}{
+		nil, nil, nil, nil, nil, nil, nil,
+		[]byte(strconv.FormatInt(firstSeen.Unix(), 10)),
+		[]byte(strconv.FormatInt(lastSeen.Unix(), 10)),
+		packed,
+	})
+
+	f, err := c.fetchFileInfo(testfile.Path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if mock.Stats(cmdGetFileinfo) < 1 {
+		t.Fatalf("HMGET not executed")
+	}
+
+	assertFileInfoEqual(t, &f, &testfile)
+}
+
 func TestCache_fetchFileInfo_non_existing(t *testing.T) {
 	mock, conn := PrepareRedisTest()
 	conn.ConnectPubsub()
@@ -147,6 +260,8 @@ func TestCache_fetchFileInfo_non_existing(t *testing.T) {
 		Sha1:    "",
 		Sha256:  "",
 		Md5:     "",
+		Sha512:  "",
+		Blake2b: "",
 	}
 
 	f, err := c.fetchFileInfo(testfile.Path)
@@ -154,12 +269,17 @@ func TestCache_fetchFileInfo_non_existing(t *testing.T) {
 		t.Fatalf("Error expected, mock command not yet registered")
 	}
 
-	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").Expect([]interface{}{
+		[]byte(""),
+		[]byte(""),
+		[]byte(""),
 		[]byte(""),
 		[]byte(""),
 		[]byte(""),
 		[]byte(""),
 		[]byte(""),
+		[]byte(""),
+		nil,
 	})
 
 	f, err = c.fetchFileInfo(testfile.Path)
@@ -194,6 +314,8 @@ func TestCache_GetFileInfo(t *testing.T) {
 		Sha1:    "3ce963aea2d6f23fe915063f8bba21888db0ddfa",
 		Sha256:  "1c8e38c7e03e4d117eba4f82afaf6631a9b79f4c1e9dec144d4faf1d109aacda",
 		Md5:     "2c98ec39f49da6ddd9cfa7b1d7342afe",
+		Sha512:  "19cedd9e92b6265b6e69f78234a94d4422dbda0affa0dbb421dcab1bdcd7b81bbe4afc836868885e8bac9cac9f95da134d4b13f8d72df7fdb14b3a288f1e9913",
+		Blake2b: "95994415099df3b5d5c6bcf42f3b59e97f5f0802da8ab2610521c1fd04cd94d57c0299122a66b4732e52b722456ff4486ee36b3e92d1cef9f38cbee596d71cdf",
 	}
 
 	_, err := c.GetFileInfo(testfile.Path)
@@ -201,12 +323,17 @@ func TestCache_GetFileInfo(t *testing.T) {
 		t.Fatalf("Error expected, mock command not yet registered")
 	}
 
-	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").Expect([]interface{}{
 		[]byte(strconv.FormatInt(testfile.Size, 10)),
 		[]byte(testfile.ModTime.Format("2006-01-02 15:04:05.999999999 -0700 MST")),
 		[]byte(testfile.Sha1),
 		[]byte(testfile.Sha256),
 		[]byte(testfile.Md5),
+		[]byte(testfile.Sha512),
+		[]byte(testfile.Blake2b),
+		[]byte(""),
+		[]byte(""),
+		nil,
 	})
 
 	f, err := c.GetFileInfo(testfile.Path)
@@ -244,6 +371,8 @@ func TestCache_GetFileInfo_non_existing(t *testing.T) {
 		Sha1:    "",
 		Sha256:  "",
 		Md5:     "",
+		Sha512:  "",
+		Blake2b: "",
 	}
 
 	_, err := c.GetFileInfo(testfile.Path)
@@ -251,12 +380,17 @@ func TestCache_GetFileInfo_non_existing(t *testing.T) {
 		t.Fatalf("Error expected, mock command not yet registered")
 	}
 
-	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+	cmdGetFileinfo := mock.Command("HMGET", "FILE_"+testfile.Path, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").Expect([]interface{}{
+		[]byte(""),
+		[]byte(""),
+		[]byte(""),
+		[]byte(""),
 		[]byte(""),
 		[]byte(""),
 		[]byte(""),
 		[]byte(""),
 		[]byte(""),
+		nil,
 	})
 
 	f, err := c.GetFileInfo(testfile.Path)
@@ -295,11 +429,8 @@ func TestCache_fetchFileMirrors(t *testing.T) {
 		t.Fatalf("Error expected, mock command not yet registered")
 	}
 
-	cmdGetFilemirrors := mock.Command("SMEMBERS", "FILEMIRRORS_"+filename).Expect([]interface{}{
-		[]byte("9"),
-		[]byte("2"),
-		[]byte("5"),
-	})
+	// Bitmap of the mirrors 2, 5 and 9
+	cmdGetFilemirrors := mock.Command("HGET", "DIRMIRRORS_/test", "file.tgz").Expect([]byte{0x24, 0x40})
 
 	ids, err := c.fetchFileMirrors(filename)
 	if err != nil {
@@ -307,11 +438,11 @@ func TestCache_fetchFileMirrors(t *testing.T) {
 	}
 
 	if mock.Stats(cmdGetFilemirrors) < 1 {
-		t.Fatalf("SMEMBERS not executed")
+		t.Fatalf("HGET not executed")
 	}
 
-	if len(ids) != 3 {
-		t.Fatalf("Invalid number of items returned")
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 5 || ids[2] != 9 {
+		t.Fatalf("Invalid items returned: %v", ids)
 	}
 
 	_, ok := c.fmCache.Get(filename)
@@ -448,6 +579,36 @@ func TestCache_fetchFileInfoMirror(t *testing.T) {
 	}
 }
 
+func TestCache_fetchFileInfoMirror_size(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	c := NewCache(conn)
+
+	mock.Command("HMGET", "FILEINFO_1_/empty", "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+		[]byte("0"), nil, nil, nil, nil,
+	})
+	mock.Command("HMGET", "FILEINFO_1_/unknown", "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+		nil, nil, nil, nil, nil,
+	})
+
+	f, err := c.fetchFileInfoMirror(1, "/empty")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if f.Size != 0 {
+		t.Fatalf("Expected an empty file, got %d", f.Size)
+	}
+
+	f, err = c.fetchFileInfoMirror(1, "/unknown")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if f.Size != -1 {
+		t.Fatalf("Expected an unknown size, got %d", f.Size)
+	}
+}
+
 func TestCache_GetMirror(t *testing.T) {
 	mock, conn := PrepareRedisTest()
 	conn.ConnectPubsub()
@@ -505,10 +666,8 @@ func TestCache_GetMirrors(t *testing.T) {
 		t.Fatalf("Error expected, mock command not yet registered")
 	}
 
-	cmdGetFilemirrors := mock.Command("SMEMBERS", "FILEMIRRORS_"+filename).Expect([]interface{}{
-		[]byte("1"),
-		[]byte("2"),
-	})
+	// Bitmap of the mirrors 1 and 2
+	cmdGetFilemirrors := mock.Command("HGET", "DIRMIRRORS_/test", "file.tgz").Expect([]byte{0x60})
 
 	cmdGetMirrorM1 := mock.Command("HGETALL", "MIRROR_1").ExpectMap(map[string]string{
 		"ID":        "1",
@@ -571,3 +730,62 @@ func TestCache_GetMirrors(t *testing.T) {
 		t.Fatalf("Distance between user and m2 is wrong, got %d, expected 334", int(mirrors[1].Distance))
 	}
 }
+
+func TestCache_ServeStale(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	c := NewCache(conn)
+
+	filename := "/test/file.tgz"
+
+	mock.Command("HGET", "DIRMIRRORS_/test", "file.tgz").Expect([]byte{0x40})
+	mock.Command("HGETALL", "MIRROR_1").ExpectMap(map[string]string{"ID": "1"})
+	mock.Command("HMGET", "FILEINFO_1_"+filename, "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+		[]byte("44000"), []byte(""), []byte(""), []byte(""), []byte(""),
+	})
+
+	if _, err := c.GetMirrors(filename, network.GeoIPRecord{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The database fails after a reconnection
+	SetConfiguration(&Configuration{ServeStale: 60})
+	defer SetConfiguration(&Configuration{})
+
+	c.Clear()
+	mock.Clear()
+
+	mirrors, err := c.GetMirrors(filename, network.GeoIPRecord{})
+	if err != nil {
+		t.Fatalf("Expected the stale entries to be served, got %s", err)
+	}
+	if len(mirrors) != 1 || !mirrors[0].Stale || mirrors[0].FileInfo.Size != 44000 {
+		t.Fatalf("Expected the stale mirror, got %+v", mirrors)
+	}
+	if !Mirrors(mirrors).HasStale() {
+		t.Fatalf("Expected the results to be degraded")
+	}
+
+	// The entries are fresh again once fetched
+	cmd := mock.Command("HGET", "DIRMIRRORS_/test", "file.tgz").Expect([]byte{0x40})
+	mock.Command("HGETALL", "MIRROR_1").ExpectMap(map[string]string{"ID": "1"})
+	mock.Command("HMGET", "FILEINFO_1_"+filename, "size", "modTime", "sha1", "sha256", "md5").Expect([]interface{}{
+		[]byte("44000"), []byte(""), []byte(""), []byte(""), []byte(""),
+	})
+	mirrors, err = c.GetMirrors(filename, network.GeoIPRecord{})
+	if err != nil || len(mirrors) != 1 || mirrors[0].Stale {
+		t.Fatalf("Expected the fresh mirror, got %+v (%v)", mirrors, err)
+	}
+	if mock.Stats(cmd) != 1 {
+		t.Fatalf("Expected the stale entries to be fetched again")
+	}
+
+	// Not served once expired
+	c.Clear()
+	mock.Clear()
+	c.staleUntil = time.Now().Add(-time.Second).UnixNano()
+	if _, err = c.GetMirrors(filename, network.GeoIPRecord{}); err == nil {
+		t.Fatalf("Expected an error once the stale entries expired")
+	}
+}
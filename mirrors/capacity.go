@@ -0,0 +1,224 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/database"
+	"github.com/gomodule/redigo/redis"
+)
+
+// capacityKey is the key holding the last computed capacity
+const capacityKey = "CAPACITY"
+
+// Capacity is the coverage of the countries and the continents by the
+// mirrors, computed periodically by the node leading the cluster
+type Capacity struct {
+	Created    time.Time
+	TopFiles   int // Number of files whose coverage is computed
+	Continents []RegionCapacity
+	Countries  []RegionCapacity
+}
+
+// RegionCapacity is the coverage of a country or of a continent
+type RegionCapacity struct {
+	Code         string
+	Mirrors      int     // Enabled mirrors up
+	Fallbacks    int     // Configured fallbacks
+	CoveredFiles int     // Top files carried by at least one of the mirrors
+	Coverage     float32 // Percentage of the top files covered
+	FallbackOnly bool    // No mirror, the clients are sent to the fallbacks
+}
+
+// ComputeCapacity computes the coverage of the regions by the mirrors for
+// the count most downloaded files of the month still in the repository
+func ComputeCapacity(r *database.Redis, c *Cache, count int) (*Capacity, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	mirrorsMap, err := r.GetListOfMirrors()
+	if err != nil {
+		return nil, err
+	}
+	mlist := make([]Mirror, 0, len(mirrorsMap))
+	for id := range mirrorsMap {
+		m, err := c.GetMirror(id)
+		if err != nil {
+			return nil, err
+		}
+		mlist = append(mlist, m)
+	}
+
+	// See http/stats.go for the storage structure
+	stats, err := database.FileDownloads(conn, "STATS_FILE"+time.Now().UTC().Format("_2006_01"))
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(stats))
+	for path := range stats {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if stats[paths[i]] == stats[paths[j]] {
+			return paths[i] < paths[j]
+		}
+		return stats[paths[i]] > stats[paths[j]]
+	})
+
+	// Skip the files removed from the repository, a batch at a time
+	var fileMirrors [][]int
+	for len(paths) > 0 && len(fileMirrors) < count {
+		batch := paths
+		if len(batch) > count {
+			batch = batch[:count]
+		}
+		paths = paths[len(batch):]
+
+		for _, path := range batch {
+			conn.Send("SISMEMBER", "FILES", path)
+			database.SendGetFileMirrors(conn, path)
+		}
+		if err = conn.Flush(); err != nil {
+			return nil, err
+		}
+		for range batch {
+			exists, err := redis.Bool(conn.Receive())
+			if err != nil {
+				return nil, err
+			}
+			ids, err := database.FileMirrorIDs(conn.Receive())
+			if err != nil {
+				return nil, err
+			}
+			if exists && len(fileMirrors) < count {
+				fileMirrors = append(fileMirrors, ids)
+			}
+		}
+	}
+
+	return newCapacity(mlist, fileMirrors), nil
+}
+
+// newCapacity aggregates the coverage of the regions given the mirrors and
+// the IDs of the mirrors carrying each of the top files
+func newCapacity(mlist []Mirror, fileMirrors [][]int) *Capacity {
+	countries := make(map[string]*RegionCapacity)
+	continents := make(map[string]*RegionCapacity)
+	region := func(regions map[string]*RegionCapacity, code string) *RegionCapacity {
+		code = strings.ToUpper(code)
+		if regions[code] == nil {
+			regions[code] = &RegionCapacity{Code: code}
+		}
+		return regions[code]
+	}
+
+	// Only the enabled mirrors up are serving the clients
+	up := make(map[int]Mirror)
+	for _, m := range mlist {
+		available := m.Enabled && m.Up
+		if available {
+			up[m.ID] = m
+		}
+		for _, code := range m.CountryFields {
+			if available {
+				region(countries, code).Mirrors++
+			} else {
+				region(countries, code)
+			}
+		}
+		if m.ContinentCode != "" {
+			if available {
+				region(continents, m.ContinentCode).Mirrors++
+			} else {
+				region(continents, m.ContinentCode)
+			}
+		}
+	}
+
+	for _, f := range GetConfig().Fallbacks {
+		if f.CountryCode != "" {
+			region(countries, f.CountryCode).Fallbacks++
+		}
+		if f.ContinentCode != "" {
+			region(continents, f.ContinentCode).Fallbacks++
+		}
+	}
+
+	for _, ids := range fileMirrors {
+		covered := make(map[*RegionCapacity]bool)
+		for _, id := range ids {
+			m, ok := up[id]
+			if !ok {
+				continue
+			}
+			for _, code := range m.CountryFields {
+				covered[region(countries, code)] = true
+			}
+			if m.ContinentCode != "" {
+				covered[region(continents, m.ContinentCode)] = true
+			}
+		}
+		for r := range covered {
+			r.CoveredFiles++
+		}
+	}
+
+	list := func(regions map[string]*RegionCapacity) []RegionCapacity {
+		l := make([]RegionCapacity, 0, len(regions))
+		for _, r := range regions {
+			if len(fileMirrors) > 0 {
+				r.Coverage = float32(r.CoveredFiles) * 100 / float32(len(fileMirrors))
+			}
+			r.FallbackOnly = r.Mirrors == 0 && r.Fallbacks > 0
+			l = append(l, *r)
+		}
+		sort.Slice(l, func(i, j int) bool {
+			return l[i].Code < l[j].Code
+		})
+		return l
+	}
+
+	return &Capacity{
+		Created:    time.Now().UTC(),
+		TopFiles:   len(fileMirrors),
+		Continents: list(continents),
+		Countries:  list(countries),
+	}
+}
+
+// SaveCapacity stores the capacity in the database
+func SaveCapacity(r *database.Redis, c *Capacity) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	value, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Do("SET", capacityKey, value)
+	return err
+}
+
+// GetCapacity returns the last computed capacity, nil if none
+func GetCapacity(r *database.Redis) (*Capacity, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	value, err := redis.Bytes(conn.Do("GET", capacityKey))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	c := &Capacity{}
+	if err = json.Unmarshal(value, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
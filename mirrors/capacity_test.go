@@ -0,0 +1,71 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewCapacity(t *testing.T) {
+	c := Configuration{}
+	err := yaml.Unmarshal([]byte(`
+Fallbacks:
+    - URL: http://fallback.example.org/
+      CountryCode: jp
+      ContinentCode: as
+`), &c)
+	if err != nil {
+		t.Fatalf("Unable to parse the configuration: %s", err)
+	}
+	SetConfiguration(&c)
+	defer SetConfiguration(&Configuration{})
+
+	mlist := []Mirror{
+		{ID: 1, Enabled: true, Up: true, ContinentCode: "EU", CountryFields: []string{"FR", "BE"}},
+		{ID: 2, Enabled: true, Up: true, ContinentCode: "EU", CountryFields: []string{"DE"}},
+		{ID: 3, Enabled: true, Up: false, ContinentCode: "NA", CountryFields: []string{"US"}},
+		{ID: 4, Enabled: false, Up: true, ContinentCode: "AS", CountryFields: []string{"JP"}},
+	}
+	fileMirrors := [][]int{
+		{1, 2, 3},
+		{2, 4},
+		{},
+		{1},
+	}
+
+	capacity := newCapacity(mlist, fileMirrors)
+	if capacity.TopFiles != 4 {
+		t.Fatalf("Expected 4 files, got %d", capacity.TopFiles)
+	}
+
+	expected := map[string]RegionCapacity{
+		"BE": {Code: "BE", Mirrors: 1, CoveredFiles: 2, Coverage: 50},
+		"DE": {Code: "DE", Mirrors: 1, CoveredFiles: 2, Coverage: 50},
+		"FR": {Code: "FR", Mirrors: 1, CoveredFiles: 2, Coverage: 50},
+		"JP": {Code: "JP", Fallbacks: 1, FallbackOnly: true},
+		"US": {Code: "US"},
+		"AS": {Code: "AS", Fallbacks: 1, FallbackOnly: true},
+		"EU": {Code: "EU", Mirrors: 2, CoveredFiles: 3, Coverage: 75},
+		"NA": {Code: "NA"},
+	}
+
+	check := func(regions []RegionCapacity, count int) {
+		if len(regions) != count {
+			t.Fatalf("Expected %d regions, got %+v", count, regions)
+		}
+		for i, r := range regions {
+			if i > 0 && regions[i-1].Code >= r.Code {
+				t.Fatalf("Regions not sorted: %+v", regions)
+			}
+			if r != expected[r.Code] {
+				t.Fatalf("Expected %+v, got %+v", expected[r.Code], r)
+			}
+		}
+	}
+	check(capacity.Countries, 5)
+	check(capacity.Continents, 3)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/etix/mirrorbits/database"
+	"github.com/gomodule/redigo/redis"
+)
+
+// NodeHealth is the result of the health check of a mirror
+// as seen by a single node of the cluster
+type NodeHealth struct {
+	Node   string    `json:"-"`
+	Up     bool      `json:"up"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// RecordNodeHealth stores the result of the health check made by a node
+// and returns the results of all the nodes not older than maxAge. Older
+// results, usually left by nodes gone from the cluster, are pruned.
+func RecordNodeHealth(r *database.Redis, id int, result NodeHealth, maxAge time.Duration) ([]NodeHealth, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	key := fmt.Sprintf("HEALTHCHECKS_%d", id)
+
+	value, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Do("HSET", key, result.Node, value)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := redis.Values(conn.Do("HGETALL", key))
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ParseNodeHealth(values)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := results[:0]
+	for _, h := range results {
+		if result.Time.Sub(h.Time) > maxAge {
+			conn.Do("HDEL", key, h.Node)
+			continue
+		}
+		fresh = append(fresh, h)
+	}
+	return fresh, nil
+}
+
+// ParseNodeHealth decodes the per-node health check results
+// of a mirror as returned by HGETALL
+func ParseNodeHealth(values []interface{}) ([]NodeHealth, error) {
+	fields, err := redis.StringMap(values, nil)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]NodeHealth, 0, len(fields))
+	for node, value := range fields {
+		var h NodeHealth
+		if err := json.Unmarshal([]byte(value), &h); err != nil {
+			continue
+		}
+		h.Node = node
+		results = append(results, h)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Node < results[j].Node
+	})
+	return results, nil
+}
+
+// MergeNodeHealth returns the state of a mirror given the results of
+// the nodes of the cluster. The mirror is down only if at least quorum
+// nodes (or all of them if there are fewer) report it as down, in which
+// case the reason of the most recent failure is returned.
+func MergeNodeHealth(results []NodeHealth, quorum int) (up bool, reason string) {
+	if len(results) == 0 {
+		return true, ""
+	}
+
+	down := 0
+	var last time.Time
+	for _, h := range results {
+		if h.Up {
+			continue
+		}
+		down++
+		if h.Time.After(last) || last.IsZero() {
+			last = h.Time
+			reason = h.Reason
+		}
+	}
+
+	if quorum > len(results) {
+		quorum = len(results)
+	}
+	if quorum < 1 {
+		quorum = 1
+	}
+	if down >= quorum {
+		return false, reason
+	}
+	return true, ""
+}
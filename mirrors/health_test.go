@@ -0,0 +1,61 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNodeHealth(t *testing.T) {
+	values := []interface{}{
+		[]byte("node2"), []byte(`{"up":false,"reason":"Unreachable","time":"2019-01-01T10:00:00Z"}`),
+		[]byte("node1"), []byte(`{"up":true,"time":"2019-01-01T10:00:00Z"}`),
+		[]byte("node3"), []byte("invalid"),
+	}
+
+	results, err := ParseNodeHealth(values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Node != "node1" || !results[0].Up {
+		t.Fatalf("Unexpected result for node1: %+v", results[0])
+	}
+	if results[1].Node != "node2" || results[1].Up || results[1].Reason != "Unreachable" {
+		t.Fatalf("Unexpected result for node2: %+v", results[1])
+	}
+}
+
+func TestMergeNodeHealth(t *testing.T) {
+	now := time.Now()
+
+	if up, _ := MergeNodeHealth(nil, 2); !up {
+		t.Fatalf("A mirror without results is expected to be up")
+	}
+
+	results := []NodeHealth{
+		{Node: "node1", Up: true, Time: now},
+		{Node: "node2", Up: false, Reason: "Unreachable", Time: now.Add(-time.Minute)},
+		{Node: "node3", Up: false, Reason: "Got status code 500", Time: now},
+	}
+
+	if up, _ := MergeNodeHealth(results[:2], 2); !up {
+		t.Fatalf("A single node is not expected to take the mirror down")
+	}
+
+	up, reason := MergeNodeHealth(results, 2)
+	if up {
+		t.Fatalf("The mirror is expected to be down")
+	}
+	if reason != "Got status code 500" {
+		t.Fatalf("Expected the most recent reason, got %q", reason)
+	}
+
+	if up, _ := MergeNodeHealth(results[1:2], 2); up {
+		t.Fatalf("The mirror is expected to be down when all the nodes agree")
+	}
+}
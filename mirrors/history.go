@@ -0,0 +1,174 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/etix/mirrorbits/database"
+	"github.com/gomodule/redigo/redis"
+)
+
+// The changes of state of each mirror are kept in a sorted set, scored by
+// the unix time of the change, to compute its uptime and its outages over
+// the last days. They are also part of the logs of the mirror, which are
+// neither indexed by date nor limited to the changes of state.
+
+// HistoryRetention is the period over which the changes of state of the
+// mirrors are kept
+const HistoryRetention = 90 * 24 * time.Hour
+
+// UptimePeriods are the periods, in days, over which the uptime of the
+// mirrors is computed
+var UptimePeriods = []int{30, 90}
+
+// StateEvent is a change of state of a mirror
+type StateEvent struct {
+	Time   time.Time
+	Up     bool
+	Reason string `json:",omitempty"`
+}
+
+// Outage is a period during which a mirror was down
+type Outage struct {
+	Start    time.Time
+	End      time.Time // zero if the mirror is still down
+	Duration time.Duration
+	Reason   string
+}
+
+// Uptime is the share of the time a mirror was up over a period
+type Uptime struct {
+	Days    int
+	Percent float32
+	Known   bool // false if the state of the mirror is unknown over the whole period
+}
+
+// History is the availability of a mirror over the last days
+type History struct {
+	Uptimes []Uptime
+	Outages []Outage // the most recent first
+}
+
+func historyKey(id int) string {
+	return fmt.Sprintf("MIRRORSTATES_%d", id)
+}
+
+// recordStateChange records the given change of state in the history of
+// the mirror, the changes older than HistoryRetention being removed except
+// the latest of them, giving the state at the start of the retention
+func recordStateChange(conn redis.Conn, l *LogStateChanged) error {
+	key := historyKey(l.MirrorID)
+	value, err := json.Marshal(StateEvent{
+		Time:   l.Timestamp,
+		Up:     l.Up,
+		Reason: l.Reason,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = conn.Do("ZADD", key, l.Timestamp.Unix(), value); err != nil {
+		return err
+	}
+
+	cutoff := l.Timestamp.Add(-HistoryRetention).Unix()
+	old, err := redis.Int(conn.Do("ZCOUNT", key, "-inf", fmt.Sprintf("(%d", cutoff)))
+	if err != nil {
+		return err
+	}
+	if old > 1 {
+		_, err = conn.Do("ZREMRANGEBYRANK", key, 0, old-2)
+	}
+	return err
+}
+
+// GetHistory returns the uptime and the outages of the given mirror over
+// the HistoryRetention period
+func GetHistory(r *database.Redis, id int) (*History, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("ZRANGE", historyKey(id), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	events := make([]StateEvent, 0, len(values))
+	for _, v := range values {
+		var e StateEvent
+		if err = json.Unmarshal(v, &e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return newHistory(events, time.Now()), nil
+}
+
+// newHistory computes the history of a mirror from its changes of state
+// in chronological order
+func newHistory(events []StateEvent, now time.Time) *History {
+	h := &History{}
+
+	for _, days := range UptimePeriods {
+		start := now.Add(-time.Duration(days) * 24 * time.Hour)
+		var known, up time.Duration
+		for i, e := range events {
+			end := now
+			if i+1 < len(events) {
+				end = events[i+1].Time
+			}
+			from := e.Time
+			if from.Before(start) {
+				from = start
+			}
+			if !end.After(from) {
+				continue
+			}
+			known += end.Sub(from)
+			if e.Up {
+				up += end.Sub(from)
+			}
+		}
+		uptime := Uptime{Days: days, Known: known > 0}
+		if known > 0 {
+			uptime.Percent = float32(float64(up) * 100 / float64(known))
+		}
+		h.Uptimes = append(h.Uptimes, uptime)
+	}
+
+	// Consecutive changes to down are part of the same outage
+	start := now.Add(-HistoryRetention)
+	var current *Outage
+	for _, e := range events {
+		if !e.Up && current == nil {
+			current = &Outage{Start: e.Time, Reason: e.Reason}
+		} else if e.Up && current != nil {
+			current.End = e.Time
+			if current.End.After(start) {
+				h.Outages = append(h.Outages, *current)
+			}
+			current = nil
+		}
+	}
+	if current != nil {
+		h.Outages = append(h.Outages, *current)
+	}
+
+	for i := range h.Outages {
+		o := &h.Outages[i]
+		if o.End.IsZero() {
+			o.Duration = now.Sub(o.Start)
+		} else {
+			o.Duration = o.End.Sub(o.Start)
+		}
+		o.Duration = o.Duration.Round(time.Second)
+	}
+
+	// The most recent first
+	for i, j := 0, len(h.Outages)-1; i < j; i, j = i+1, j-1 {
+		h.Outages[i], h.Outages[j] = h.Outages[j], h.Outages[i]
+	}
+	return h
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHistory(t *testing.T) {
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// No change of state recorded
+	h := newHistory(nil, now)
+	if len(h.Uptimes) != 2 || h.Uptimes[0].Known || h.Uptimes[1].Known || len(h.Outages) != 0 {
+		t.Fatalf("Unexpected history: %+v", h)
+	}
+
+	events := []StateEvent{
+		// Before the retention, giving the initial state
+		{Time: now.Add(-100 * day), Up: true},
+		// Down for 9 days within the 90 days
+		{Time: now.Add(-60 * day), Up: false, Reason: "Unreachable"},
+		{Time: now.Add(-59 * day), Up: false, Reason: "Got status code 500"},
+		{Time: now.Add(-51 * day), Up: true},
+		// Down for 3 days within the 30 days, still down
+		{Time: now.Add(-3 * day), Up: false, Reason: "File not found"},
+	}
+	h = newHistory(events, now)
+
+	if h.Uptimes[0].Days != 30 || !h.Uptimes[0].Known || h.Uptimes[0].Percent != 90 {
+		t.Fatalf("Unexpected 30 days uptime: %+v", h.Uptimes[0])
+	}
+	if h.Uptimes[1].Days != 90 || !h.Uptimes[1].Known || h.Uptimes[1].Percent < 86.66 || h.Uptimes[1].Percent > 86.67 {
+		t.Fatalf("Unexpected 90 days uptime: %+v", h.Uptimes[1])
+	}
+
+	if len(h.Outages) != 2 {
+		t.Fatalf("Expected 2 outages, got %+v", h.Outages)
+	}
+	if !h.Outages[0].End.IsZero() || h.Outages[0].Duration != 3*day || h.Outages[0].Reason != "File not found" {
+		t.Fatalf("Unexpected ongoing outage: %+v", h.Outages[0])
+	}
+	if !h.Outages[1].Start.Equal(now.Add(-60*day)) || h.Outages[1].Duration != 9*day || h.Outages[1].Reason != "Unreachable" {
+		t.Fatalf("Unexpected outage: %+v", h.Outages[1])
+	}
+
+	// The state is only known since the first change
+	h = newHistory([]StateEvent{{Time: now.Add(-10 * day), Up: true}}, now)
+	if !h.Uptimes[0].Known || h.Uptimes[0].Percent != 100 {
+		t.Fatalf("Unexpected uptime: %+v", h.Uptimes[0])
+	}
+}
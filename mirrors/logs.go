@@ -6,6 +6,7 @@ package mirrors
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/etix/mirrorbits/core"
@@ -32,6 +33,29 @@ const (
 	LOGTYPE_SCANCOMPLETED
 )
 
+// String returns the name of the log type as used in the published events
+func (t LogType) String() string {
+	switch t {
+	case LOGTYPE_ERROR:
+		return "error"
+	case LOGTYPE_ADDED:
+		return "added"
+	case LOGTYPE_EDITED:
+		return "edited"
+	case LOGTYPE_ENABLED:
+		return "enabled"
+	case LOGTYPE_DISABLED:
+		return "disabled"
+	case LOGTYPE_STATECHANGED:
+		return "state-changed"
+	case LOGTYPE_SCANSTARTED:
+		return "scan-started"
+	case LOGTYPE_SCANCOMPLETED:
+		return "scan-completed"
+	}
+	return "unknown"
+}
+
 func typeToInstance(typ LogType) LogAction {
 	switch LogType(typ) {
 	case LOGTYPE_ERROR:
@@ -59,6 +83,8 @@ type LogAction interface {
 	GetType() LogType
 	GetMirrorID() int
 	GetTimestamp() time.Time
+	GetActor() string
+	SetActor(actor string)
 	GetOutput() string
 }
 
@@ -66,6 +92,7 @@ type LogCommonAction struct {
 	Type      LogType
 	MirrorID  int
 	Timestamp time.Time
+	Actor     string `json:",omitempty"` // client at the origin of the action, if any
 }
 
 func (l LogCommonAction) GetType() LogType {
@@ -80,6 +107,22 @@ func (l LogCommonAction) GetTimestamp() time.Time {
 	return l.Timestamp
 }
 
+func (l LogCommonAction) GetActor() string {
+	return l.Actor
+}
+
+func (l *LogCommonAction) SetActor(actor string) {
+	l.Actor = actor
+}
+
+// logOutput returns the output of the action followed by its actor
+func logOutput(l LogAction) string {
+	if actor := l.GetActor(); actor != "" {
+		return fmt.Sprintf("%s (by %s)", l.GetOutput(), actor)
+	}
+	return l.GetOutput()
+}
+
 type LogError struct {
 	LogCommonAction
 	Err string
@@ -156,20 +199,34 @@ func NewLogEnabled(id int) LogAction {
 
 type LogDisabled struct {
 	LogCommonAction
+	Reason string     `json:",omitempty"`
+	Until  *time.Time `json:",omitempty"`
 }
 
 func (l *LogDisabled) GetOutput() string {
-	return "Mirror disabled"
+	output := "Mirror disabled"
+	if len(l.Reason) > 0 {
+		output += ": " + l.Reason
+	}
+	if l.Until != nil {
+		output += " (until " + l.Until.Local().Format(time.RFC1123) + ")"
+	}
+	return output
 }
 
-func NewLogDisabled(id int) LogAction {
-	return &LogDisabled{
+func NewLogDisabled(id int, reason string, until time.Time) LogAction {
+	l := &LogDisabled{
 		LogCommonAction: LogCommonAction{
 			Type:      LOGTYPE_DISABLED,
 			MirrorID:  id,
 			Timestamp: time.Now(),
 		},
+		Reason: reason,
+	}
+	if !until.IsZero() {
+		l.Until = &until
 	}
+	return l
 }
 
 type LogStateChanged struct {
@@ -208,10 +265,8 @@ type LogScanStarted struct {
 
 func (l *LogScanStarted) GetOutput() string {
 	switch l.Typ {
-	case core.RSYNC:
-		return "RSYNC scan started"
-	case core.FTP:
-		return "FTP scan started"
+	case core.RSYNC, core.FTP:
+		return strings.ToUpper(l.Typ.String()) + " scan started"
 	default:
 		return "Scan started using a unknown protocol"
 	}
@@ -230,10 +285,11 @@ func NewLogScanStarted(id int, typ core.ScannerType) LogAction {
 
 type LogScanCompleted struct {
 	LogCommonAction
-	FilesIndexed int64
-	KnownIndexed int64
-	Removed      int64
-	TZOffset     int64
+	FilesIndexed   int64
+	KnownIndexed   int64
+	Removed        int64
+	TZOffset       int64
+	CaseCollisions int64 `json:",omitempty"`
 }
 
 func (l *LogScanCompleted) GetOutput() string {
@@ -242,20 +298,24 @@ func (l *LogScanCompleted) GetOutput() string {
 		offset, _ := time.ParseDuration(fmt.Sprintf("%dms", l.TZOffset))
 		output += fmt.Sprintf(" (corrected timezone offset: %s)", offset)
 	}
+	if l.CaseCollisions > 0 {
+		output += fmt.Sprintf(", %d excluded due to case collisions", l.CaseCollisions)
+	}
 	return output
 }
 
-func NewLogScanCompleted(id int, files, known, removed, tzoffset int64) LogAction {
+func NewLogScanCompleted(id int, files, known, removed, tzoffset, collisions int64) LogAction {
 	return &LogScanCompleted{
 		LogCommonAction: LogCommonAction{
 			Type:      LOGTYPE_SCANCOMPLETED,
 			MirrorID:  id,
 			Timestamp: time.Now(),
 		},
-		FilesIndexed: files,
-		KnownIndexed: known,
-		Removed:      removed,
-		TZOffset:     tzoffset,
+		FilesIndexed:   files,
+		KnownIndexed:   known,
+		Removed:        removed,
+		TZOffset:       tzoffset,
+		CaseCollisions: collisions,
 	}
 }
 
@@ -270,58 +330,135 @@ func PushLog(r *database.Redis, logAction LogAction) error {
 	}
 
 	_, err = conn.Do("RPUSH", key, value)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if l, ok := logAction.(*LogStateChanged); ok {
+		if err = recordStateChange(conn, l); err != nil {
+			return err
+		}
+	}
+
+	// Notify the external tools watching the events
+	return database.PublishEvent(conn, database.Event{
+		Type:      logAction.GetType().String(),
+		MirrorID:  logAction.GetMirrorID(),
+		Message:   logAction.GetOutput(),
+		Actor:     logAction.GetActor(),
+		Timestamp: logAction.GetTimestamp(),
+	})
 }
 
-func ReadLogs(r *database.Redis, mirrorid, max int) ([]string, error) {
+// Number of log entries fetched at once when walking the logs backwards
+const logsChunkSize = 100
+
+// LogFilter restricts the entries returned by ReadLogs
+type LogFilter struct {
+	Max    int       // maximum number of entries, 500 if unset
+	Since  time.Time // oldest entry to return, unbounded if zero
+	Until  time.Time // newest entry to return, unbounded if zero
+	Cursor int64     // cursor returned by a previous call, 0 to start from the latest entry
+}
+
+// ReadLogs returns the log entries of a mirror matching the given filter in
+// chronological order. The returned cursor allows to resume with the older
+// entries and is 0 when there are no more entries to read.
+func ReadLogs(r *database.Redis, mirrorid int, filter LogFilter) ([]string, int64, error) {
 	conn := r.Get()
 	defer conn.Close()
 
+	max := filter.Max
 	if max <= 0 {
 		// Get the latest 500 events by default
 		max = 500
 	}
 
 	key := fmt.Sprintf("MIRRORLOGS_%d", mirrorid)
-	lines, err := redis.Strings(conn.Do("LRANGE", key, max*-1, -1))
-	if err != nil {
-		return nil, err
+
+	// The cursor is the exclusive upper bound of the next entries to read
+	// since the logs are only appended to the list
+	end := filter.Cursor
+	if end <= 0 {
+		length, err := redis.Int64(conn.Do("LLEN", key))
+		if err != nil {
+			return nil, 0, err
+		}
+		end = length
 	}
 
-	outputs := make([]string, 0, len(lines))
+	outputs := make([]string, 0, max)
+	next := int64(0)
 
-	for _, line := range lines {
-		var objmap map[string]interface{}
-		err = json.Unmarshal([]byte(line), &objmap)
+walk:
+	for end > 0 {
+		start := end - logsChunkSize
+		if start < 0 {
+			start = 0
+		}
+		lines, err := redis.Strings(conn.Do("LRANGE", key, start, end-1))
 		if err != nil {
-			log.Warningf("Unable to parse mirror log line: %s", err)
-			continue
+			return nil, 0, err
 		}
 
-		typf, ok := objmap["Type"].(float64)
-		if !ok {
-			log.Warning("Unable to parse mirror log line")
-			continue
+		for i := len(lines) - 1; i >= 0; i-- {
+			index := start + int64(i)
+			if len(outputs) >= max {
+				next = index + 1
+				break walk
+			}
+
+			action, err := parseLog(lines[i])
+			if err != nil {
+				log.Warningf("Unable to parse mirror log line: %s", err)
+				continue
+			}
+
+			t := action.GetTimestamp()
+			if !filter.Until.IsZero() && t.After(filter.Until) {
+				continue
+			}
+			if !filter.Since.IsZero() && t.Before(filter.Since) {
+				break walk
+			}
+
+			outputs = append(outputs, fmt.Sprintf("%s: %s", t.Format("2006-01-02 15:04:05 MST"), logOutput(action)))
 		}
+		end = start
+	}
 
-		// Truncate the received float64 back to int
-		typ := int(typf)
+	// Restore the chronological order
+	for i, j := 0, len(outputs)-1; i < j; i, j = i+1, j-1 {
+		outputs[i], outputs[j] = outputs[j], outputs[i]
+	}
 
-		action := typeToInstance(LogType(typ))
-		if action == nil {
-			log.Warning("Unknown mirror log action")
-			continue
-		}
+	return outputs, next, nil
+}
 
-		err = json.Unmarshal([]byte(line), action)
-		if err != nil {
-			log.Warningf("Unable to unmarshal mirror log line: %s", err)
-			continue
-		}
+// parseLog decodes a log entry as stored in the database
+func parseLog(line string) (LogAction, error) {
+	var objmap map[string]interface{}
+	err := json.Unmarshal([]byte(line), &objmap)
+	if err != nil {
+		return nil, err
+	}
 
-		line := fmt.Sprintf("%s: %s", action.GetTimestamp().Format("2006-01-02 15:04:05 MST"), action.GetOutput())
-		outputs = append(outputs, line)
+	typf, ok := objmap["Type"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing type")
 	}
 
-	return outputs, nil
+	// Truncate the received float64 back to int
+	typ := int(typf)
+
+	action := typeToInstance(LogType(typ))
+	if action == nil {
+		return nil, fmt.Errorf("unknown mirror log action")
+	}
+
+	err = json.Unmarshal([]byte(line), action)
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
 }
@@ -0,0 +1,123 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etix/mirrorbits/database"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestReadLogs(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	var lines []interface{}
+	for i := 0; i < 4; i++ {
+		l := NewLogEnabled(1)
+		l.(*LogEnabled).Timestamp = base.Add(time.Duration(i) * time.Hour)
+		value, _ := json.Marshal(l)
+		lines = append(lines, value)
+	}
+
+	mock.Command("LLEN", "MIRRORLOGS_1").Expect(int64(4))
+	mock.Command("LRANGE", "MIRRORLOGS_1", int64(0), int64(3)).Expect(lines)
+	mock.Command("LRANGE", "MIRRORLOGS_1", int64(0), int64(1)).Expect(lines[:2])
+
+	logs, next, err := ReadLogs(conn, 1, LogFilter{Max: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(logs) != 2 || !strings.HasPrefix(logs[0], "2019-01-01 02:00:00") || !strings.HasPrefix(logs[1], "2019-01-01 03:00:00") {
+		t.Fatalf("Unexpected logs: %v", logs)
+	}
+	if next != 2 {
+		t.Fatalf("Expected the cursor 2, got %d", next)
+	}
+
+	logs, next, err = ReadLogs(conn, 1, LogFilter{Cursor: next})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(logs) != 2 || next != 0 {
+		t.Fatalf("Unexpected logs: %v (cursor %d)", logs, next)
+	}
+
+	logs, next, err = ReadLogs(conn, 1, LogFilter{
+		Since: base.Add(time.Hour),
+		Until: base.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(logs) != 2 || !strings.HasPrefix(logs[0], "2019-01-01 01:00:00") || next != 0 {
+		t.Fatalf("Unexpected logs: %v (cursor %d)", logs, next)
+	}
+}
+
+func TestPushLogPublishesEvent(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	l := NewLogStateChanged(1, false, "timeout")
+	l.(*LogStateChanged).Timestamp = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	value, _ := json.Marshal(l)
+	event, _ := json.Marshal(database.Event{
+		Type:      "state-changed",
+		MirrorID:  1,
+		Message:   l.GetOutput(),
+		Timestamp: l.GetTimestamp(),
+	})
+
+	state, _ := json.Marshal(StateEvent{Time: l.GetTimestamp(), Reason: "timeout"})
+	cutoff := l.GetTimestamp().Add(-HistoryRetention).Unix()
+
+	cmdPush := mock.Command("RPUSH", "MIRRORLOGS_1", value).Expect(int64(1))
+	cmdState := mock.Command("ZADD", "MIRRORSTATES_1", l.GetTimestamp().Unix(), state).Expect(int64(1))
+	mock.Command("ZCOUNT", "MIRRORSTATES_1", "-inf", fmt.Sprintf("(%d", cutoff)).Expect(int64(0))
+	cmdPublish := mock.Command("PUBLISH", string(database.EVENTS), string(event)).Expect(int64(0))
+
+	if err := PushLog(conn, l); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if mock.Stats(cmdPush) != 1 || mock.Stats(cmdPublish) != 1 {
+		t.Fatalf("Expected the log to be pushed and the event to be published")
+	}
+	if mock.Stats(cmdState) != 1 {
+		t.Fatalf("Expected the change of state to be recorded in the history")
+	}
+}
+
+func TestLogActor(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	l := NewLogDisabled(1, "broken", time.Time{})
+	l.SetActor("alice@127.0.0.1:4242")
+	l.(*LogDisabled).Timestamp = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	value, _ := json.Marshal(l)
+
+	mock.Command("LLEN", "MIRRORLOGS_1").Expect(int64(1))
+	mock.Command("LRANGE", "MIRRORLOGS_1", int64(0), int64(0)).Expect([]interface{}{value})
+
+	logs, _, err := ReadLogs(conn, 1, LogFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(logs) != 1 || !strings.HasSuffix(logs[0], "Mirror disabled: broken (by alice@127.0.0.1:4242)") {
+		t.Fatalf("Expected the actor in the logs, got %v", logs)
+	}
+
+	// The entries without actor are left untouched
+	if !strings.Contains(string(value), `"Actor"`) {
+		t.Fatalf("Expected the actor to be stored")
+	}
+	value, _ = json.Marshal(NewLogEnabled(1))
+	if strings.Contains(string(value), `"Actor"`) {
+		t.Fatalf("Unexpected actor in %s", value)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a recurring period of time during which a mirror is
+// under maintenance. It starts at every minute matching a cron expression
+// and lasts for the given duration.
+type MaintenanceWindow struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+	// Whether the days of the month and of the week are restricted
+	anyDay     bool
+	anyWeekday bool
+
+	Duration time.Duration
+}
+
+// ParseMaintenance parses a list of maintenance windows separated by
+// semicolons. Each window is made of the five fields of a cron expression
+// (minute, hour, day of month, month, day of week) evaluated in UTC and
+// followed by its duration, i.e. "0 2 * * 0 2h" for every Sunday from 2am
+// to 4am.
+func ParseMaintenance(spec string) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	for _, s := range strings.Split(spec, ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		w, err := parseMaintenanceWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func parseMaintenanceWindow(s string) (w MaintenanceWindow, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != 6 {
+		return w, fmt.Errorf("invalid maintenance window '%s': expected 5 cron fields and a duration", strings.TrimSpace(s))
+	}
+
+	if err = parseCronField(fields[0], 0, 59, w.minutes[:]); err != nil {
+		return w, fmt.Errorf("invalid minute '%s': %s", fields[0], err)
+	}
+	if err = parseCronField(fields[1], 0, 23, w.hours[:]); err != nil {
+		return w, fmt.Errorf("invalid hour '%s': %s", fields[1], err)
+	}
+	if err = parseCronField(fields[2], 1, 31, w.days[:]); err != nil {
+		return w, fmt.Errorf("invalid day of month '%s': %s", fields[2], err)
+	}
+	if err = parseCronField(fields[3], 1, 12, w.months[:]); err != nil {
+		return w, fmt.Errorf("invalid month '%s': %s", fields[3], err)
+	}
+	var weekdays [8]bool
+	if err = parseCronField(fields[4], 0, 7, weekdays[:]); err != nil {
+		return w, fmt.Errorf("invalid day of week '%s': %s", fields[4], err)
+	}
+	copy(w.weekdays[:], weekdays[:7])
+	// Both 0 and 7 stand for Sunday
+	w.weekdays[0] = w.weekdays[0] || weekdays[7]
+	w.anyDay = fields[2] == "*"
+	w.anyWeekday = fields[4] == "*"
+
+	w.Duration, err = time.ParseDuration(fields[5])
+	if err != nil {
+		return w, fmt.Errorf("invalid duration '%s': %s", fields[5], err)
+	}
+	if w.Duration <= 0 {
+		return w, fmt.Errorf("invalid duration '%s': must be positive", fields[5])
+	}
+	return w, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges (a-b) or
+// wildcards, each optionally followed by a step (/n)
+func parseCronField(field string, min, max int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return fmt.Errorf("invalid step")
+			}
+			part = part[:i]
+		}
+
+		var from, to int
+		switch {
+		case part == "*":
+			from, to = min, max
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			from, err1 = strconv.Atoi(bounds[0])
+			to, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf("invalid range")
+			}
+		default:
+			var err error
+			from, err = strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("invalid value")
+			}
+			to = from
+		}
+
+		if from < min || to > max || from > to {
+			return fmt.Errorf("out of range")
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// matchDay returns true if the window can start on the day of t. Like cron,
+// when both the day of month and the day of week are restricted, matching
+// either of them is enough.
+func (w *MaintenanceWindow) matchDay(t time.Time) bool {
+	if !w.months[t.Month()] {
+		return false
+	}
+	day, weekday := w.days[t.Day()], w.weekdays[t.Weekday()]
+	switch {
+	case w.anyDay && w.anyWeekday:
+		return true
+	case w.anyDay:
+		return weekday
+	case w.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// Active returns true if the window is open at the given time
+func (w *MaintenanceWindow) Active(now time.Time) bool {
+	now = now.UTC()
+	limit := now.Add(-w.Duration)
+
+	// Look for the most recent start not older than the duration
+	// of the window, skipping whole days and hours when possible
+	t := now.Truncate(time.Minute)
+	for t.After(limit) {
+		if !w.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Add(-time.Minute)
+			continue
+		}
+		if !w.hours[t.Hour()] {
+			t = t.Truncate(time.Hour).Add(-time.Minute)
+			continue
+		}
+		if !w.minutes[t.Minute()] {
+			t = t.Add(-time.Minute)
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// InMaintenance returns true if the mirror is under maintenance at the
+// given time, either within one of its scheduled windows or until the
+// date set manually
+func (m *Mirror) InMaintenance(now time.Time) bool {
+	if now.Before(m.MaintenanceUntil.Time) {
+		return true
+	}
+	for i := range m.MaintenanceWindows {
+		if m.MaintenanceWindows[i].Active(now) {
+			return true
+		}
+	}
+	return false
+}
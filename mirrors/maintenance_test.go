@@ -0,0 +1,110 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMaintenance(t *testing.T) {
+	windows, err := ParseMaintenance("0 2 * * 0 2h; */15 1-3,22 1 1,7 * 10m")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(windows))
+	}
+	if windows[0].Duration != 2*time.Hour {
+		t.Fatalf("Unexpected duration %s", windows[0].Duration)
+	}
+
+	windows, err = ParseMaintenance("")
+	if err != nil || len(windows) != 0 {
+		t.Fatalf("An empty specification is expected to be valid")
+	}
+
+	for _, spec := range []string{
+		"0 2 * * 0",
+		"60 2 * * 0 1h",
+		"0 2 0 * * 1h",
+		"0 2 * 13 * 1h",
+		"0 2 * * 8 1h",
+		"0 2 * * */0 1h",
+		"5-1 2 * * * 1h",
+		"0 2 * * * forever",
+		"0 2 * * * -1h",
+	} {
+		if _, err := ParseMaintenance(spec); err == nil {
+			t.Fatalf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	windows, err := ParseMaintenance("30 23 * * 7 2h")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	w := windows[0]
+
+	// Sunday 2019-01-06 23:30 UTC to Monday 01:30
+	for _, tt := range []struct {
+		time   string
+		active bool
+	}{
+		{"2019-01-06T23:29:59Z", false},
+		{"2019-01-06T23:30:00Z", true},
+		{"2019-01-07T00:45:00Z", true},
+		{"2019-01-07T01:29:59Z", true},
+		{"2019-01-07T01:30:00Z", false},
+		{"2019-01-05T23:45:00Z", false},
+		{"2019-01-07T01:15:00+02:00", false},
+	} {
+		now, _ := time.Parse(time.RFC3339, tt.time)
+		if w.Active(now) != tt.active {
+			t.Fatalf("Expected active=%t at %s", tt.active, tt.time)
+		}
+	}
+
+	// Both the day of month and the day of week are restricted
+	windows, _ = ParseMaintenance("0 12 1 * 1 1h")
+	for _, tt := range []struct {
+		time   string
+		active bool
+	}{
+		{"2019-02-01T12:30:00Z", true},  // Friday the 1st
+		{"2019-02-04T12:30:00Z", true},  // Monday the 4th
+		{"2019-02-05T12:30:00Z", false}, // Tuesday the 5th
+	} {
+		now, _ := time.Parse(time.RFC3339, tt.time)
+		if windows[0].Active(now) != tt.active {
+			t.Fatalf("Expected active=%t at %s", tt.active, tt.time)
+		}
+	}
+}
+
+func TestMirrorInMaintenance(t *testing.T) {
+	now := time.Now()
+
+	m := Mirror{}
+	m.Prepare()
+	if m.InMaintenance(now) {
+		t.Fatalf("The mirror is not expected to be under maintenance")
+	}
+
+	m.MaintenanceUntil = Time{}.FromTime(now.Add(time.Hour))
+	if !m.InMaintenance(now) {
+		t.Fatalf("The mirror is expected to be under maintenance until the given date")
+	}
+	if m.InMaintenance(now.Add(2 * time.Hour)) {
+		t.Fatalf("The maintenance is expected to be over")
+	}
+
+	m = Mirror{Maintenance: "* * * * * 1m"}
+	m.Prepare()
+	if !m.InMaintenance(now) {
+		t.Fatalf("The mirror is expected to be within its maintenance window")
+	}
+}
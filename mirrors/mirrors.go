@@ -6,6 +6,7 @@ package mirrors
 import (
 	"fmt"
 	"math/rand"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -19,47 +20,81 @@ import (
 	"github.com/gomodule/redigo/redis"
 )
 
+// IntegrityFailedReason is the reason given for mirrors serving corrupted content
+const IntegrityFailedReason = "Integrity failed"
+
+const (
+	// Prefix of the fields holding the health-check latency of a mirror
+	latencyFieldPrefix = "latency"
+	// Weight of the last measure in the rolling average of the latency
+	latencySmoothing = 0.2
+)
+
 // Mirror is the structure representing all the information about a mirror
 type Mirror struct {
-	ID                          int              `redis:"ID" yaml:"-"`
-	Name                        string           `redis:"name" yaml:"Name"`
-	HttpURL                     string           `redis:"http" yaml:"HttpURL"`
-	RsyncURL                    string           `redis:"rsync" yaml:"RsyncURL"`
-	FtpURL                      string           `redis:"ftp" yaml:"FtpURL"`
-	SponsorName                 string           `redis:"sponsorName" yaml:"SponsorName"`
-	SponsorURL                  string           `redis:"sponsorURL" yaml:"SponsorURL"`
-	SponsorLogoURL              string           `redis:"sponsorLogo" yaml:"SponsorLogoURL"`
-	AdminName                   string           `redis:"adminName" yaml:"AdminName"`
-	AdminEmail                  string           `redis:"adminEmail" yaml:"AdminEmail"`
-	CustomData                  string           `redis:"customData" yaml:"CustomData"`
-	ContinentOnly               bool             `redis:"continentOnly" yaml:"ContinentOnly"`
-	CountryOnly                 bool             `redis:"countryOnly" yaml:"CountryOnly"`
-	ASOnly                      bool             `redis:"asOnly" yaml:"ASOnly"`
-	Score                       int              `redis:"score" yaml:"Score"`
-	Latitude                    float32          `redis:"latitude" yaml:"Latitude"`
-	Longitude                   float32          `redis:"longitude" yaml:"Longitude"`
-	ContinentCode               string           `redis:"continentCode" yaml:"ContinentCode"`
-	CountryCodes                string           `redis:"countryCodes" yaml:"CountryCodes"`
-	ExcludedCountryCodes        string           `redis:"excludedCountryCodes" yaml:"ExcludedCountryCodes"`
-	Asnum                       uint             `redis:"asnum" yaml:"ASNum"`
-	Comment                     string           `redis:"comment" yaml:"-"`
-	Enabled                     bool             `redis:"enabled" yaml:"Enabled"`
-	Up                          bool             `redis:"up" json:"-" yaml:"-"`
-	ExcludeReason               string           `redis:"excludeReason" json:",omitempty" yaml:"-"`
-	StateSince                  Time             `redis:"stateSince" json:",omitempty" yaml:"-"`
-	AllowRedirects              Redirects        `redis:"allowredirects" json:",omitempty" yaml:"AllowRedirects"`
-	TZOffset                    int64            `redis:"tzoffset" json:"-" yaml:"-"` // timezone offset in ms
-	Distance                    float32          `redis:"-" yaml:"-"`
-	CountryFields               []string         `redis:"-" json:"-" yaml:"-"`
-	ExcludedCountryFields       []string         `redis:"-" json:"-" yaml:"-"`
-	Filepath                    string           `redis:"-" json:"-" yaml:"-"`
-	Weight                      float32          `redis:"-" json:"-" yaml:"-"`
-	ComputedScore               int              `redis:"-" yaml:"-"`
-	LastSync                    Time             `redis:"lastSync" yaml:"-"`
-	LastSuccessfulSync          Time             `redis:"lastSuccessfulSync" yaml:"-"`
-	LastSuccessfulSyncProtocol  core.ScannerType `redis:"lastSuccessfulSyncProtocol" yaml:"-"`
-	LastSuccessfulSyncPrecision core.Precision   `redis:"lastSuccessfulSyncPrecision" yaml:"-"`
-	LastModTime                 Time             `redis:"lastModTime" yaml:"-"`
+	ID                          int                 `redis:"ID" yaml:"-"`
+	Name                        string              `redis:"name" yaml:"Name"`
+	HttpURL                     string              `redis:"http" yaml:"HttpURL"`
+	RsyncURL                    string              `redis:"rsync" yaml:"RsyncURL"`
+	FtpURL                      string              `redis:"ftp" yaml:"FtpURL"`
+	SponsorName                 string              `redis:"sponsorName" yaml:"SponsorName"`
+	SponsorURL                  string              `redis:"sponsorURL" yaml:"SponsorURL"`
+	SponsorLogoURL              string              `redis:"sponsorLogo" yaml:"SponsorLogoURL"`
+	AdminName                   string              `redis:"adminName" yaml:"AdminName"`
+	AdminEmail                  string              `redis:"adminEmail" yaml:"AdminEmail"`
+	CustomData                  string              `redis:"customData" yaml:"CustomData"`
+	ContinentOnly               bool                `redis:"continentOnly" yaml:"ContinentOnly"`
+	CountryOnly                 bool                `redis:"countryOnly" yaml:"CountryOnly"`
+	ASOnly                      bool                `redis:"asOnly" yaml:"ASOnly"`
+	Score                       int                 `redis:"score" yaml:"Score"`
+	Latitude                    float32             `redis:"latitude" yaml:"Latitude"`
+	Longitude                   float32             `redis:"longitude" yaml:"Longitude"`
+	ContinentCode               string              `redis:"continentCode" yaml:"ContinentCode"`
+	CountryCodes                string              `redis:"countryCodes" yaml:"CountryCodes"`
+	ExcludedCountryCodes        string              `redis:"excludedCountryCodes" yaml:"ExcludedCountryCodes"`
+	AdditionalServedCountries   string              `redis:"additionalServedCountries" json:",omitempty" yaml:"AdditionalServedCountries"` // countries served as local ones, see ServesCountry
+	Asnum                       uint                `redis:"asnum" yaml:"ASNum"`
+	Comment                     string              `redis:"comment" yaml:"-"`
+	Enabled                     bool                `redis:"enabled" yaml:"Enabled"`
+	DisabledReason              string              `redis:"disabledReason" json:",omitempty" yaml:"-"`
+	DisabledUntil               Time                `redis:"disabledUntil" json:"-" yaml:"-"` // date the mirror is automatically enabled again
+	RemovedAt                   Time                `redis:"removedAt" json:"-" yaml:"-"`     // date the mirror has been removed, see RemoveMirror
+	Up                          bool                `redis:"up" json:"-" yaml:"-"`
+	ExcludeReason               string              `redis:"excludeReason" json:",omitempty" yaml:"-"`
+	StateSince                  Time                `redis:"stateSince" json:",omitempty" yaml:"-"`
+	AllowRedirects              Redirects           `redis:"allowredirects" json:",omitempty" yaml:"AllowRedirects"`
+	TZOffset                    int64               `redis:"tzoffset" json:"-" yaml:"-"` // timezone offset in ms
+	Distance                    float32             `redis:"-" yaml:"-"`
+	CountryFields               []string            `redis:"-" json:"-" yaml:"-"`
+	ExcludedCountryFields       []string            `redis:"-" json:"-" yaml:"-"`
+	AdditionalServedFields      []string            `redis:"-" json:"-" yaml:"-"`
+	Filepath                    string              `redis:"-" json:"-" yaml:"-"`
+	Weight                      float32             `redis:"-" json:"-" yaml:"-"`
+	ComputedScore               int                 `redis:"-" yaml:"-"`
+	LastSync                    Time                `redis:"lastSync" yaml:"-"`
+	LastSuccessfulSync          Time                `redis:"lastSuccessfulSync" yaml:"-"`
+	LastSuccessfulSyncProtocol  core.ScannerType    `redis:"lastSuccessfulSyncProtocol" yaml:"-"`
+	LastSuccessfulSyncPrecision core.Precision      `redis:"lastSuccessfulSyncPrecision" yaml:"-"`
+	LastModTime                 Time                `redis:"lastModTime" yaml:"-"`
+	ModTimePrecision            core.Precision      `redis:"modTimePrecision" json:",omitempty" yaml:"ModTimePrecision"`
+	IntegrityFailed             bool                `redis:"integrityFailed" json:",omitempty" yaml:"-"`
+	PendingRescan               bool                `redis:"pendingRescan" json:",omitempty" yaml:"-"`
+	HealthCheckPath             string              `redis:"healthCheckPath" json:"-" yaml:"HealthCheckPath"`
+	HealthCheckCodes            string              `redis:"healthCheckCodes" json:"-" yaml:"HealthCheckCodes"`
+	Note                        string              `redis:"note" json:",omitempty" yaml:"Note"`             // public note shown along the exclude reason
+	Tags                        string              `redis:"tags" json:"-" yaml:"Tags"`                      // space separated list of tags used to filter the mirrors
+	Maintenance                 string              `redis:"maintenance" json:"-" yaml:"Maintenance"`        // scheduled maintenance windows, see ParseMaintenance
+	HostHeader                  string              `redis:"hostHeader" json:",omitempty" yaml:"HostHeader"` // Host header to send instead of the hostname of the URL
+	MaintenanceUntil            Time                `redis:"maintenanceUntil" json:"-" yaml:"-"`
+	ScanInterval                int                 `redis:"scanInterval" json:"-" yaml:"ScanInterval"`        // minutes between two scans, overrides the global ScanInterval
+	RsyncBandwidthLimit         int                 `redis:"rsyncBwLimit" json:"-" yaml:"RsyncBandwidthLimit"` // bandwidth limit of the rsync scans in KiB/s
+	FtpConnections              int                 `redis:"ftpConnections" json:"-" yaml:"FtpConnections"`    // number of concurrent connections of the ftp scans
+	AltURLs                     AltURLs             `redis:"altURLs" json:"-" yaml:"AltURLs"`                  // alternative HTTP base URLs, see UseClosestURL
+	AltURLsDown                 string              `redis:"altURLsDown" json:"-" yaml:"-"`                    // space separated alternative URLs failing the health checks
+	MaintenanceWindows          []MaintenanceWindow `redis:"-" json:"-" yaml:"-"`
+	Latencies                   map[string]int      `redis:"-" json:",omitempty" yaml:"-"` // average health-check latency in ms per continent of the probing node
+	NodeHealth                  []NodeHealth        `redis:"-" json:"-" yaml:"-"`          // health-check results per node of the cluster
+	Stale                       bool                `redis:"-" json:"-" yaml:"-"`          // served from the cache while the database failed, see ServeStale
 
 	FileInfo *filesystem.FileInfo `redis:"-" json:"-" yaml:"-"` // Details of the requested file on this specific mirror
 }
@@ -68,6 +103,80 @@ type Mirror struct {
 func (m *Mirror) Prepare() {
 	m.CountryFields = strings.Fields(m.CountryCodes)
 	m.ExcludedCountryFields = strings.Fields(m.ExcludedCountryCodes)
+	m.AdditionalServedFields = strings.Fields(m.AdditionalServedCountries)
+	// Invalid windows are rejected when the mirror is saved
+	m.MaintenanceWindows, _ = ParseMaintenance(m.Maintenance)
+	m.prepareAltURLs()
+}
+
+// ServesCountry returns true if the given country is one of the countries
+// of the mirror, either located by GeoIP or listed in its additional served
+// countries, such as a neighboring country it peers heavily with
+func (m *Mirror) ServesCountry(country string) bool {
+	if country == "" {
+		return false
+	}
+	return utils.IsInSlice(country, m.CountryFields) || utils.IsInSlice(country, m.AdditionalServedFields)
+}
+
+// Precision returns the precision of the modification times
+// on the mirror, either configured or detected during the scan
+func (m *Mirror) Precision() core.Precision {
+	if m.ModTimePrecision > 0 {
+		return m.ModTimePrecision
+	}
+	return m.LastSuccessfulSyncPrecision
+}
+
+// Latency returns the average health-check latency of the mirror in ms as
+// measured from the given continent, or the average of all the measures
+// if none were made from there. It returns 0 if the latency is unknown.
+func (m *Mirror) Latency(continent string) int {
+	if l, ok := m.Latencies[continent]; ok {
+		return l
+	}
+	if len(m.Latencies) == 0 {
+		return 0
+	}
+	total := 0
+	for _, l := range m.Latencies {
+		total += l
+	}
+	return total / len(m.Latencies)
+}
+
+// HasTag returns true if the given tag is one of the tags of the mirror
+func (m *Mirror) HasTag(tag string) bool {
+	for _, t := range strings.Fields(m.Tags) {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheckStatusCodes returns the HTTP status codes accepted
+// by the health-check of the mirror
+func (m *Mirror) HealthCheckStatusCodes() []int {
+	codes, err := ParseStatusCodes(m.HealthCheckCodes)
+	if err != nil || len(codes) == 0 {
+		return []int{http.StatusOK}
+	}
+	return codes
+}
+
+// ParseStatusCodes parses a list of HTTP status codes separated
+// by spaces or commas
+func ParseStatusCodes(s string) ([]int, error) {
+	var codes []int
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		code, err := strconv.Atoi(f)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code: %s", f)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
 }
 
 // IsHTTPS returns true if the mirror has an HTTPS address
@@ -84,6 +193,17 @@ func (s Mirrors) Len() int { return len(s) }
 // Swap swaps mirrors at index i and j
 func (s Mirrors) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
+// HasStale returns true if any of the mirrors was served from the cache
+// while the database failed
+func (s Mirrors) HasStale() bool {
+	for _, m := range s {
+		if m.Stale {
+			return true
+		}
+	}
+	return false
+}
+
 // ByRank is used to sort a slice of Mirror by their rank
 type ByRank struct {
 	Mirrors
@@ -103,11 +223,11 @@ func (m ByRank) Less(i, j int) bool {
 
 		//TODO Simplify me
 		if m.ClientInfo.CountryCode != "" {
-			if utils.IsInSlice(m.ClientInfo.CountryCode, m.Mirrors[i].CountryFields) {
-				if !utils.IsInSlice(m.ClientInfo.CountryCode, m.Mirrors[j].CountryFields) {
+			if m.Mirrors[i].ServesCountry(m.ClientInfo.CountryCode) {
+				if !m.Mirrors[j].ServesCountry(m.ClientInfo.CountryCode) {
 					return true
 				}
-			} else if utils.IsInSlice(m.ClientInfo.CountryCode, m.Mirrors[j].CountryFields) {
+			} else if m.Mirrors[j].ServesCountry(m.ClientInfo.CountryCode) {
 				return false
 			}
 		}
@@ -160,23 +280,82 @@ func DisableMirror(r *database.Redis, id int) error {
 	return SetMirrorEnabled(r, id, false)
 }
 
+// DisableMirrorUntil disables the given mirror for the given reason. Unless
+// the given date is zero, the mirror is enabled again by the monitor once
+// the date is reached.
+func DisableMirrorUntil(r *database.Redis, id int, reason string, until time.Time) error {
+	return setMirrorEnabled(r, id, false, reason, until, "")
+}
+
+// EnableMirrorBy is like EnableMirror, the given actor being recorded in
+// the logs of the mirror
+func EnableMirrorBy(r *database.Redis, id int, actor string) error {
+	return setMirrorEnabled(r, id, true, "", time.Time{}, actor)
+}
+
+// DisableMirrorUntilBy is like DisableMirrorUntil, the given actor being
+// recorded in the logs of the mirror
+func DisableMirrorUntilBy(r *database.Redis, id int, reason string, until time.Time, actor string) error {
+	return setMirrorEnabled(r, id, false, reason, until, actor)
+}
+
 // SetMirrorEnabled marks a mirror as enabled or disabled
 func SetMirrorEnabled(r *database.Redis, id int, state bool) error {
+	return setMirrorEnabled(r, id, state, "", time.Time{}, "")
+}
+
+func setMirrorEnabled(r *database.Redis, id int, state bool, reason string, until time.Time, actor string) error {
 	conn := r.Get()
 	defer conn.Close()
 
+	var untilValue int64
+	if !until.IsZero() {
+		untilValue = until.UTC().Unix()
+	}
+
 	key := fmt.Sprintf("MIRROR_%d", id)
-	_, err := conn.Do("HMSET", key, "enabled", state)
+	_, err := conn.Do("HMSET", key, "enabled", state, "disabledReason", reason, "disabledUntil", untilValue)
 
 	// Publish update
 	if err == nil {
 		database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
 
+		var l LogAction
 		if state == true {
-			PushLog(r, NewLogEnabled(id))
+			l = NewLogEnabled(id)
 		} else {
-			PushLog(r, NewLogDisabled(id))
+			l = NewLogDisabled(id, reason, until)
 		}
+		l.SetActor(actor)
+		PushLog(r, l)
+	}
+
+	return err
+}
+
+// DisableExpired returns true if the mirror has been disabled until
+// a date that is now reached
+func (m *Mirror) DisableExpired(now time.Time) bool {
+	return !m.Enabled && m.DisabledUntil.Unix() > 0 && !now.Before(m.DisabledUntil.Time)
+}
+
+// SetMaintenanceUntil puts the given mirror under maintenance until the
+// given date, a zero date ending the maintenance
+func SetMaintenanceUntil(r *database.Redis, id int, until time.Time) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	var value int64
+	if !until.IsZero() {
+		value = until.UTC().Unix()
+	}
+
+	key := fmt.Sprintf("MIRROR_%d", id)
+	_, err := conn.Do("HSET", key, "maintenanceUntil", value)
+
+	// Publish update
+	if err == nil {
+		database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
 	}
 
 	return err
@@ -225,6 +404,97 @@ func SetMirrorState(r *database.Redis, id int, state bool, reason string) error
 	return err
 }
 
+// SetMirrorIntegrity records the result of the integrity check of a mirror.
+// A mirror serving corrupted content is marked as down.
+func SetMirrorIntegrity(r *database.Redis, id int, valid bool, file string) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HSET", fmt.Sprintf("MIRROR_%d", id), "integrityFailed", !valid)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return MarkMirrorDown(r, id, fmt.Sprintf("%s (%s)", IntegrityFailedReason, file))
+	}
+
+	// Publish update
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
+
+// SetMirrorPendingRescan flags a mirror whose content must be rescanned
+// before it can be fully trusted again, the flag being cleared by the next
+// successful scan
+func SetMirrorPendingRescan(r *database.Redis, id int) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HSET", fmt.Sprintf("MIRROR_%d", id), "pendingRescan", true)
+	if err != nil {
+		return err
+	}
+
+	// Publish update
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
+
+// latencyField returns the name of the field holding the latency of a
+// mirror as measured from the given continent
+func latencyField(continent string) string {
+	if continent == "" {
+		return latencyFieldPrefix
+	}
+	return latencyFieldPrefix + "_" + continent
+}
+
+// parseLatencies extracts the health-check latencies from the fields
+// of a mirror as returned by HGETALL
+func parseLatencies(values []interface{}) (map[string]int, error) {
+	fields, err := redis.StringMap(values, nil)
+	if err != nil {
+		return nil, err
+	}
+	var latencies map[string]int
+	for k, v := range fields {
+		if k != latencyFieldPrefix && !strings.HasPrefix(k, latencyFieldPrefix+"_") {
+			continue
+		}
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		if latencies == nil {
+			latencies = make(map[string]int)
+		}
+		latencies[strings.TrimPrefix(strings.TrimPrefix(k, latencyFieldPrefix), "_")] = l
+	}
+	return latencies, nil
+}
+
+// RecordLatency updates the rolling average of the health-check latency
+// of a mirror as measured from the given continent
+func RecordLatency(r *database.Redis, id int, continent string, latency time.Duration) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	key := fmt.Sprintf("MIRROR_%d", id)
+	field := latencyField(continent)
+
+	ms := float64(latency / time.Millisecond)
+	previous, err := redis.Int(conn.Do("HGET", key, field))
+	if err == nil {
+		ms = float64(previous)*(1-latencySmoothing) + ms*latencySmoothing
+	} else if err != redis.ErrNil {
+		return err
+	}
+
+	_, err = conn.Do("HSET", key, field, int(ms+0.5))
+	return err
+}
+
 // Results is the resulting struct of a request and is
 // used by the renderers to generate the final page.
 type Results struct {
@@ -234,7 +504,9 @@ type Results struct {
 	MirrorList   Mirrors
 	ExcludedList Mirrors `json:",omitempty"`
 	Fallback     bool    `json:",omitempty"`
+	Degraded     bool    `json:",omitempty"` // based on stale data, the database failing
 	LocalJSPath  string
+	ClientHints  string `json:"-"`
 }
 
 // Redirects is handling the per-mirror authorization of HTTP redirects
@@ -6,6 +6,7 @@ package mirrors
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
@@ -238,6 +239,36 @@ func TestByRank_Less(t *testing.T) {
 		t.Fatalf("Order doesn't seem right: %s, expected M3, M4, M1, M2", formatMirrorOrder(m))
 	}
 
+	/* additional served countries */
+
+	m = Mirrors{
+		Mirror{
+			ID:            1,
+			Name:          "M1",
+			Distance:      100.0,
+			CountryFields: []string{"IT"},
+		},
+		Mirror{
+			ID:                     2,
+			Name:                   "M2",
+			Distance:               900.0,
+			CountryFields:          []string{"BE"},
+			AdditionalServedFields: []string{"FR"},
+		},
+		Mirror{
+			ID:            3,
+			Name:          "M3",
+			Distance:      500.0,
+			CountryFields: []string{"UK"},
+		},
+	}
+
+	sort.Sort(ByRank{m, c})
+
+	if !matchingMirrorOrder(m, []int{2, 1, 3}) {
+		t.Fatalf("Order doesn't seem right: %s, expected M2, M1, M3", formatMirrorOrder(m))
+	}
+
 	/* continentcode */
 
 	c = network.GeoIPRecord{
@@ -384,14 +415,14 @@ func TestByExcludeReason_Less(t *testing.T) {
 func TestEnableMirror(t *testing.T) {
 	mock, conn := PrepareRedisTest()
 
-	cmdEnable := mock.Command("HMSET", "MIRROR_1", "enabled", true).Expect("ok")
+	cmdEnable := mock.Command("HMSET", "MIRROR_1", "enabled", true, "disabledReason", "", "disabledUntil", int64(0)).Expect("ok")
 	EnableMirror(conn, 1)
 
 	if mock.Stats(cmdEnable) != 1 {
 		t.Fatalf("Mirror not enabled")
 	}
 
-	mock.Command("HMSET", "MIRROR_1", "enabled", true).ExpectError(redis.Error("blah"))
+	mock.Command("HMSET", "MIRROR_1", "enabled", true, "disabledReason", "", "disabledUntil", int64(0)).ExpectError(redis.Error("blah"))
 	if EnableMirror(conn, 1) == nil {
 		t.Fatalf("Error expected")
 	}
@@ -400,25 +431,61 @@ func TestEnableMirror(t *testing.T) {
 func TestDisableMirror(t *testing.T) {
 	mock, conn := PrepareRedisTest()
 
-	cmdDisable := mock.Command("HMSET", "MIRROR_1", "enabled", false).Expect("ok")
+	cmdDisable := mock.Command("HMSET", "MIRROR_1", "enabled", false, "disabledReason", "", "disabledUntil", int64(0)).Expect("ok")
 	DisableMirror(conn, 1)
 
 	if mock.Stats(cmdDisable) != 1 {
 		t.Fatalf("Mirror not enabled")
 	}
 
-	mock.Command("HMSET", "MIRROR_1", "enabled", false).ExpectError(redis.Error("blah"))
+	mock.Command("HMSET", "MIRROR_1", "enabled", false, "disabledReason", "", "disabledUntil", int64(0)).ExpectError(redis.Error("blah"))
 	if DisableMirror(conn, 1) == nil {
 		t.Fatalf("Error expected")
 	}
 }
 
+func TestDisableMirrorUntil(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	until := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	cmdDisable := mock.Command("HMSET", "MIRROR_1", "enabled", false, "disabledReason", "disk full", "disabledUntil", until.Unix()).Expect("ok")
+	DisableMirrorUntil(conn, 1, "disk full", until)
+
+	if mock.Stats(cmdDisable) != 1 {
+		t.Fatalf("Mirror not disabled")
+	}
+}
+
+func TestMirror_DisableExpired(t *testing.T) {
+	now := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	m := Mirror{Enabled: false, DisabledUntil: Time{}.FromTime(time.Unix(0, 0))}
+	if m.DisableExpired(now) {
+		t.Fatalf("A mirror disabled without a timer must stay disabled")
+	}
+
+	m.DisabledUntil = Time{}.FromTime(now.Add(time.Hour))
+	if m.DisableExpired(now) {
+		t.Fatalf("The timer isn't expired yet")
+	}
+
+	m.DisabledUntil = Time{}.FromTime(now.Add(-time.Hour))
+	if !m.DisableExpired(now) {
+		t.Fatalf("The timer is expired")
+	}
+
+	m.Enabled = true
+	if m.DisableExpired(now) {
+		t.Fatalf("An enabled mirror cannot be expired")
+	}
+}
+
 func TestSetMirrorEnabled(t *testing.T) {
 	mock, conn := PrepareRedisTest()
 
 	cmdPublish := mock.Command("PUBLISH", string(database.MIRROR_UPDATE), redigomock.NewAnyData()).Expect("ok")
 
-	cmdEnable := mock.Command("HMSET", "MIRROR_1", "enabled", true).Expect("ok")
+	cmdEnable := mock.Command("HMSET", "MIRROR_1", "enabled", true, "disabledReason", "", "disabledUntil", int64(0)).Expect("ok")
 	SetMirrorEnabled(conn, 1, true)
 
 	if mock.Stats(cmdEnable) < 1 {
@@ -431,12 +498,12 @@ func TestSetMirrorEnabled(t *testing.T) {
 		t.Fatalf("Event MIRROR_UPDATE not published")
 	}
 
-	mock.Command("HMSET", "MIRROR_1", "enabled", true).ExpectError(redis.Error("blah"))
+	mock.Command("HMSET", "MIRROR_1", "enabled", true, "disabledReason", "", "disabledUntil", int64(0)).ExpectError(redis.Error("blah"))
 	if SetMirrorEnabled(conn, 1, true) == nil {
 		t.Fatalf("Error expected")
 	}
 
-	cmdDisable := mock.Command("HMSET", "MIRROR_1", "enabled", false).Expect("ok")
+	cmdDisable := mock.Command("HMSET", "MIRROR_1", "enabled", false, "disabledReason", "", "disabledUntil", int64(0)).Expect("ok")
 	SetMirrorEnabled(conn, 1, false)
 
 	if mock.Stats(cmdDisable) != 1 {
@@ -449,7 +516,7 @@ func TestSetMirrorEnabled(t *testing.T) {
 		t.Fatalf("Event MIRROR_UPDATE not published")
 	}
 
-	mock.Command("HMSET", "MIRROR_1", "enabled", false).ExpectError(redis.Error("blah"))
+	mock.Command("HMSET", "MIRROR_1", "enabled", false, "disabledReason", "", "disabledUntil", int64(0)).ExpectError(redis.Error("blah"))
 	if SetMirrorEnabled(conn, 1, false) == nil {
 		t.Fatalf("Error expected")
 	}
@@ -541,3 +608,78 @@ func TestSetMirrorState(t *testing.T) {
 		t.Fatalf("Event MIRROR_UPDATE not published")
 	}
 }
+
+func TestParseStatusCodes(t *testing.T) {
+	codes, err := ParseStatusCodes("200, 204 302")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(codes, []int{200, 204, 302}) {
+		t.Fatalf("Expected [200 204 302], got %v", codes)
+	}
+
+	codes, err = ParseStatusCodes("")
+	if err != nil || len(codes) != 0 {
+		t.Fatalf("Expected no status code, got %v (%v)", codes, err)
+	}
+
+	for _, s := range []string{"abc", "200,99", "600"} {
+		if _, err := ParseStatusCodes(s); err == nil {
+			t.Fatalf("Error expected for %q", s)
+		}
+	}
+}
+
+func TestMirror_HealthCheckStatusCodes(t *testing.T) {
+	m := Mirror{}
+	if !reflect.DeepEqual(m.HealthCheckStatusCodes(), []int{200}) {
+		t.Fatalf("Expected the default status code 200, got %v", m.HealthCheckStatusCodes())
+	}
+
+	m.HealthCheckCodes = "200 301"
+	if !reflect.DeepEqual(m.HealthCheckStatusCodes(), []int{200, 301}) {
+		t.Fatalf("Expected [200 301], got %v", m.HealthCheckStatusCodes())
+	}
+}
+
+func TestMirror_Latency(t *testing.T) {
+	m := Mirror{}
+	if l := m.Latency("EU"); l != 0 {
+		t.Fatalf("Expected an unknown latency, got %d", l)
+	}
+
+	m.Latencies = map[string]int{"EU": 20, "NA": 100}
+	if l := m.Latency("EU"); l != 20 {
+		t.Fatalf("Expected 20, got %d", l)
+	}
+	if l := m.Latency("AS"); l != 60 {
+		t.Fatalf("Expected the average latency 60, got %d", l)
+	}
+}
+
+func TestMirror_HasTag(t *testing.T) {
+	m := Mirror{Tags: "europe HighBandwidth"}
+	if !m.HasTag("highbandwidth") {
+		t.Fatalf("The tags must match regardless of the case")
+	}
+	if m.HasTag("high") || (&Mirror{}).HasTag("highbandwidth") {
+		t.Fatalf("Unexpected tag match")
+	}
+}
+
+func TestParseLatencies(t *testing.T) {
+	values := []interface{}{
+		[]byte("name"), []byte("m1"),
+		[]byte("latency"), []byte("42"),
+		[]byte("latency_EU"), []byte("12"),
+		[]byte("latency_NA"), []byte("invalid"),
+	}
+
+	latencies, err := parseLatencies(values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(latencies, map[string]int{"": 42, "EU": 12}) {
+		t.Fatalf("Unexpected latencies: %v", latencies)
+	}
+}
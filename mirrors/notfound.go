@@ -0,0 +1,147 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/database"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Sources of the 404 recorded for a mirror
+const (
+	NotFoundHealthCheck = "healthcheck"
+	NotFoundReport      = "report"
+)
+
+const (
+	notFoundPrefixField = "p:"
+	notFoundSourceField = "s:"
+)
+
+// NotFoundPrefix returns the directory of the given file truncated
+// to depth levels, the root directory being returned as "/"
+func NotFoundPrefix(path string, depth int) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// Drop the file name
+	parts = parts[:len(parts)-1]
+	if depth >= 0 && len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// notFoundKey returns the key holding the 404 recorded for a mirror
+// during the window including the given time
+func notFoundKey(id int, now time.Time, window time.Duration) string {
+	bucket := now.Unix() / int64(window/time.Second)
+	return fmt.Sprintf("NOTFOUND_%d_%d", id, bucket)
+}
+
+// RecordNotFound records a 404 returned by a mirror for the given file and
+// returns the prefix it has been accounted to along with the number of 404
+// recorded for this prefix during the current window
+func RecordNotFound(r *database.Redis, id int, path, source string, window time.Duration, depth int) (prefix string, count int64, err error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	prefix = NotFoundPrefix(path, depth)
+	key := notFoundKey(id, time.Now(), window)
+
+	conn.Send("MULTI")
+	conn.Send("HINCRBY", key, notFoundPrefixField+prefix, 1)
+	conn.Send("HINCRBY", key, notFoundSourceField+source, 1)
+	conn.Send("EXPIRE", key, int64(2*window/time.Second))
+	values, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return "", 0, err
+	}
+	if len(values) == 0 {
+		return "", 0, redis.ErrNil
+	}
+	count, err = redis.Int64(values[0], nil)
+	return prefix, count, err
+}
+
+// NotFoundCounts returns the number of 404 recorded for the given mirrors
+// during the current window, by mirror and by source. The counts of all the
+// mirrors are fetched in a single pipeline.
+func NotFoundCounts(r *database.Redis, ids []int, window time.Duration) (map[int]map[string]int64, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	now := time.Now()
+	for _, id := range ids {
+		conn.Send("HGETALL", notFoundKey(id, now, window))
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	all := make(map[int]map[string]int64, len(ids))
+	for _, id := range ids {
+		fields, err := redis.StringMap(conn.Receive())
+		if err != nil {
+			return nil, err
+		}
+
+		counts := map[string]int64{
+			NotFoundHealthCheck: 0,
+			NotFoundReport:      0,
+		}
+		for field, value := range fields {
+			if !strings.HasPrefix(field, notFoundSourceField) {
+				continue
+			}
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			counts[strings.TrimPrefix(field, notFoundSourceField)] = n
+		}
+		all[id] = counts
+	}
+	return all, nil
+}
+
+// ReportNotFound records a 404 returned by a mirror and, when the number of
+// 404 under the same prefix reaches the configured threshold, asks for the
+// mirror to be rescanned
+func ReportNotFound(r *database.Redis, id int, path, source string) error {
+	cfg := GetConfig().NotFoundRescan
+	window := time.Duration(cfg.Window) * time.Minute
+
+	prefix, count, err := RecordNotFound(r, id, path, source, window, cfg.PrefixDepth)
+	if err != nil {
+		return err
+	}
+
+	// The rescan is only requested once per window
+	if cfg.Threshold <= 0 || count != int64(cfg.Threshold) {
+		return nil
+	}
+
+	conn := r.Get()
+	defer conn.Close()
+
+	return database.Publish(conn, database.MIRROR_RESCAN, fmt.Sprintf("%d %s", id, prefix))
+}
+
+// ParseRescanRequest decodes a message received on the MIRROR_RESCAN channel
+func ParseRescanRequest(message string) (id int, prefix string, err error) {
+	parts := strings.SplitN(message, " ", 2)
+	id, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", err
+	}
+	if len(parts) == 2 {
+		prefix = parts[1]
+	}
+	return id, prefix, nil
+}
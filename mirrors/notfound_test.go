@@ -0,0 +1,92 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestNotFoundPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		depth  int
+		prefix string
+	}{
+		{"/file.iso", 1, "/"},
+		{"/releases/file.iso", 1, "/releases"},
+		{"/releases/1.0/file.iso", 1, "/releases"},
+		{"/releases/1.0/file.iso", 2, "/releases/1.0"},
+		{"/releases/1.0/file.iso", 5, "/releases/1.0"},
+		{"/releases/1.0/file.iso", 0, "/"},
+	}
+
+	for _, test := range tests {
+		if prefix := NotFoundPrefix(test.path, test.depth); prefix != test.prefix {
+			t.Fatalf("Expected prefix %q for %s at depth %d, got %q", test.prefix, test.path, test.depth, prefix)
+		}
+	}
+}
+
+func TestRecordNotFound(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	window := time.Hour
+	key := notFoundKey(1, time.Now(), window)
+
+	mock.Command("MULTI").Expect("OK")
+	cmdPrefix := mock.Command("HINCRBY", key, "p:/releases", 1).Expect(int64(3))
+	cmdSource := mock.Command("HINCRBY", key, "s:report", 1).Expect(int64(1))
+	mock.Command("EXPIRE", key, int64(7200)).Expect(int64(1))
+	mock.Command("EXEC").Expect([]interface{}{int64(3), int64(1), int64(1)})
+
+	prefix, count, err := RecordNotFound(conn, 1, "/releases/1.0/file.iso", NotFoundReport, window, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if prefix != "/releases" || count != 3 {
+		t.Fatalf("Expected 3 reports under /releases, got %d under %s", count, prefix)
+	}
+	if mock.Stats(cmdPrefix) != 1 || mock.Stats(cmdSource) != 1 {
+		t.Fatalf("Expected both the prefix and the source to be counted")
+	}
+}
+
+func TestNotFoundCounts(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	window := time.Hour
+	mock.Command("HGETALL", notFoundKey(1, time.Now(), window)).ExpectMap(map[string]string{
+		"p:/releases": "3",
+		"s:report":    "2",
+	})
+	mock.Command("HGETALL", notFoundKey(2, time.Now(), window)).Expect([]interface{}{})
+
+	counts, err := NotFoundCounts(conn, []int{1, 2}, window)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if counts[1][NotFoundReport] != 2 || counts[1][NotFoundHealthCheck] != 0 {
+		t.Fatalf("Unexpected counts for the first mirror: %v", counts[1])
+	}
+	if len(counts[2]) != 2 || counts[2][NotFoundReport] != 0 {
+		t.Fatalf("Expected the sources of the second mirror to be zeroed, got %v", counts[2])
+	}
+}
+
+func TestParseRescanRequest(t *testing.T) {
+	id, prefix, err := ParseRescanRequest("12 /releases/1.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if id != 12 || prefix != "/releases/1.0" {
+		t.Fatalf("Unexpected request: %d %s", id, prefix)
+	}
+
+	if _, _, err := ParseRescanRequest("invalid"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/etix/mirrorbits/database"
+	"github.com/gomodule/redigo/redis"
+)
+
+// removalBatchSize is the number of files handled by each transaction when
+// removing or purging a mirror
+const removalBatchSize = 1000
+
+// removeMirrorScript disables the mirror and marks it as removed, its
+// previous state being kept to be restored along with the mirror
+var removeMirrorScript = redis.NewScript(1, `
+local enabled = redis.call('HGET', KEYS[1], 'enabled') or '0'
+redis.call('HMSET', KEYS[1], 'enabled', '0', 'enabledBeforeRemoval', enabled, 'removedAt', ARGV[1])
+`)
+
+// restoreMirrorScript restores the state of the mirror saved by
+// removeMirrorScript
+var restoreMirrorScript = redis.NewScript(1, `
+local enabled = redis.call('HGET', KEYS[1], 'enabledBeforeRemoval')
+if enabled then
+	redis.call('HSET', KEYS[1], 'enabled', enabled)
+end
+redis.call('HDEL', KEYS[1], 'enabledBeforeRemoval', 'removedAt')
+`)
+
+var (
+	// ErrMirrorNotFound is returned when the mirror doesn't exist
+	ErrMirrorNotFound = errors.New("mirror not found")
+	// ErrMirrorNotRemoved is returned when restoring a mirror which is not removed
+	ErrMirrorNotRemoved = errors.New("mirror not removed")
+	// ErrMirrorNameTaken is returned when restoring a mirror whose name is used by another mirror
+	ErrMirrorNameTaken = errors.New("mirror name already used by another mirror")
+)
+
+// RemovedMirror is a mirror removed but not purged yet
+type RemovedMirror struct {
+	ID        int
+	Name      string
+	RemovedAt time.Time
+}
+
+// RemovalReport details what removing or purging a mirror deletes
+type RemovalReport struct {
+	Files     int64 // Files served by the mirror, and FILEINFO keys deleted by a purge
+	Logs      int64 // Entries of the mirror logs deleted by a purge
+	StatsKeys int64 // Stats keys referencing the mirror, always retained
+}
+
+// GetRemovalReport returns what removing or purging the given mirror
+// deletes, without changing anything
+func GetRemovalReport(r *database.Redis, id int) (*RemovalReport, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	exists, err := redis.Bool(conn.Do("HEXISTS", "MIRRORS", id))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		exists, err = redis.Bool(conn.Do("HEXISTS", "REMOVEDMIRRORS", id))
+		if err != nil {
+			return nil, err
+		} else if !exists {
+			return nil, ErrMirrorNotFound
+		}
+	}
+
+	report := &RemovalReport{}
+	report.Files, err = redis.Int64(conn.Do("SCARD", fmt.Sprintf("MIRRORFILES_%d", id)))
+	if err != nil {
+		return nil, err
+	}
+	report.Logs, err = redis.Int64(conn.Do("LLEN", fmt.Sprintf("MIRRORLOGS_%d", id)))
+	if err != nil {
+		return nil, err
+	}
+
+	// The stats of the mirrors (STATS_MIRROR, STATS_MIRROR_BYTES_*...)
+	err = database.ScanKeys(conn, "STATS_MIRROR*", func(keys []string) error {
+		for _, key := range keys {
+			conn.Send("HEXISTS", key, id)
+		}
+		conn.Flush()
+		for range keys {
+			exists, err := redis.Bool(conn.Receive())
+			if err != nil {
+				return err
+			}
+			if exists {
+				report.StatsKeys++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// execFileBatches runs the commands sent by fn for the given files in
+// transactions of removalBatchSize files, a single transaction being too
+// large for the mirrors serving millions of files
+func execFileBatches(conn redis.Conn, files []string, fn func(files []string)) error {
+	for i := 0; i < len(files); i += removalBatchSize {
+		end := i + removalBatchSize
+		if end > len(files) {
+			end = len(files)
+		}
+
+		conn.Send("MULTI")
+		fn(files[i:end])
+		if _, err := conn.Do("EXEC"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveMirror removes the given mirror from the list of mirrors. Its
+// settings, files and statistics are kept until it is either restored with
+// RestoreMirror or purged with PurgeMirror.
+func RemoveMirror(r *database.Redis, id int) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	name, err := redis.String(conn.Do("HGET", "MIRRORS", id))
+	if err == redis.ErrNil {
+		return ErrMirrorNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// The mirror is disabled along with its removal so that it is neither
+	// selected nor scanned anymore, the scans in progress being aborted
+	// once the mirror is in the removed mirrors
+	conn.Send("MULTI")
+	removeMirrorScript.Send(conn, fmt.Sprintf("MIRROR_%d", id), time.Now().UTC().Unix())
+	conn.Send("HDEL", "MIRRORS", id)
+	conn.Send("HSET", "REMOVEDMIRRORS", id, name)
+
+	if _, err = conn.Do("EXEC"); err != nil {
+		return err
+	}
+
+	// Including the files of a scan committed but not completed yet
+	files, err := redis.Strings(conn.Do("SUNION", fmt.Sprintf("MIRRORFILES_%d", id), fmt.Sprintf("MIRRORFILESTMP_%d", id)))
+	if err != nil {
+		return err
+	}
+
+	// The mirror doesn't serve its files anymore
+	err = execFileBatches(conn, files, func(files []string) {
+		database.SendRemoveFileMirror(conn, id, files...)
+		for _, file := range files {
+			conn.Send("PUBLISH", database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", id, file))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
+
+// RestoreMirror restores a mirror removed by RemoveMirror, along with the
+// files it was known to serve
+func RestoreMirror(r *database.Redis, id int) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	// Abort the transaction if a mirror is added or renamed meanwhile
+	if _, err := conn.Do("WATCH", "MIRRORS"); err != nil {
+		return err
+	}
+	defer conn.Do("UNWATCH")
+
+	name, err := redis.String(conn.Do("HGET", "REMOVEDMIRRORS", id))
+	if err == redis.ErrNil {
+		return ErrMirrorNotRemoved
+	} else if err != nil {
+		return err
+	}
+
+	names, err := redis.Strings(conn.Do("HVALS", "MIRRORS"))
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if n == name {
+			return ErrMirrorNameTaken
+		}
+	}
+
+	files, err := redis.Strings(conn.Do("SMEMBERS", fmt.Sprintf("MIRRORFILES_%d", id)))
+	if err != nil {
+		return err
+	}
+
+	conn.Send("MULTI")
+
+	database.SendAddFileMirror(conn, id, files...)
+	for _, file := range files {
+		conn.Send("PUBLISH", database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", id, file))
+	}
+
+	restoreMirrorScript.Send(conn, fmt.Sprintf("MIRROR_%d", id))
+	conn.Send("HDEL", "REMOVEDMIRRORS", id)
+	conn.Send("HSET", "MIRRORS", id, name)
+
+	reply, err := conn.Do("EXEC")
+	if err != nil {
+		return err
+	} else if reply == nil {
+		return errors.New("the list of mirrors has been modified, try again")
+	}
+
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
+
+// PurgeMirror deletes all the keys of the given mirror, whether it was
+// removed before or not
+func PurgeMirror(r *database.Redis, id int) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	// Get all files supported by the given mirror
+	files, err := redis.Strings(conn.Do("SMEMBERS", fmt.Sprintf("MIRRORFILES_%d", id)))
+	if err != nil {
+		return err
+	}
+
+	// Remove each FILEINFO and the mirror from the DIRMIRRORS
+	err = execFileBatches(conn, files, func(files []string) {
+		database.SendRemoveFileMirror(conn, id, files...)
+		for _, file := range files {
+			conn.Send("DEL", fmt.Sprintf("FILEINFO_%d_%s", id, file))
+			conn.Send("PUBLISH", database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", id, file))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	conn.Send("MULTI")
+
+	// Remove all other keys
+	conn.Send("DEL",
+		fmt.Sprintf("MIRROR_%d", id),
+		fmt.Sprintf("MIRRORFILES_%d", id),
+		fmt.Sprintf("MIRRORFILESTMP_%d", id),
+		fmt.Sprintf("HANDLEDFILES_%d", id),
+		fmt.Sprintf("SCANNING_%d", id),
+		fmt.Sprintf("MIRRORLOGS_%d", id),
+		fmt.Sprintf("HEALTHCHECKS_%d", id),
+		historyKey(id))
+
+	// Remove the last references
+	conn.Send("HDEL", "MIRRORS", id)
+	conn.Send("HDEL", "REMOVEDMIRRORS", id)
+
+	if _, err = conn.Do("EXEC"); err != nil {
+		return err
+	}
+
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
+
+// GetRemovedMirrors returns the mirrors removed but not purged yet, ordered
+// by date of removal
+func GetRemovedMirrors(r *database.Redis) ([]RemovedMirror, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	removed, err := redis.StringMap(conn.Do("HGETALL", "REMOVEDMIRRORS"))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]RemovedMirror, 0, len(removed))
+	for k, name := range removed {
+		id, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		sec, err := redis.Int64(conn.Do("HGET", fmt.Sprintf("MIRROR_%d", id), "removedAt"))
+		if err != nil && err != redis.ErrNil {
+			return nil, err
+		}
+		list = append(list, RemovedMirror{
+			ID:        id,
+			Name:      name,
+			RemovedAt: time.Unix(sec, 0),
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].RemovedAt.Before(list[j].RemovedAt)
+	})
+	return list, nil
+}
+
+// PurgeRemovedMirrors purges the mirrors removed for longer than the given
+// retention and returns their number
+func PurgeRemovedMirrors(r *database.Redis, retention time.Duration) (int, error) {
+	list, err := GetRemovedMirrors(r)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	deadline := time.Now().Add(-retention)
+	for _, m := range list {
+		if m.RemovedAt.After(deadline) {
+			continue
+		}
+		if err := PurgeMirror(r, m.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"testing"
+
+	"github.com/etix/mirrorbits/database"
+	. "github.com/etix/mirrorbits/testing"
+	"github.com/rafaeljusto/redigomock"
+)
+
+func TestRemoveMirror(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	if err := RemoveMirror(conn, 1); err == nil {
+		t.Fatalf("Error expected, mock command not yet registered")
+	}
+
+	mock.Command("HGET", "MIRRORS", 2).Expect(nil)
+	if err := RemoveMirror(conn, 2); err != ErrMirrorNotFound {
+		t.Fatalf("Expected ErrMirrorNotFound, got %v", err)
+	}
+
+	mock.Command("HGET", "MIRRORS", 1).Expect("m1")
+	mock.Command("MULTI").Expect("OK")
+	cmdDisable := mock.Command("EVAL", redigomock.NewAnyData(), 1, "MIRROR_1", redigomock.NewAnyInt()).Expect("QUEUED")
+	cmdMirrors := mock.Command("HDEL", "MIRRORS", 1).Expect("QUEUED")
+	cmdRemoved := mock.Command("HSET", "REMOVEDMIRRORS", 1, "m1").Expect("QUEUED")
+	mock.Command("EXEC").Expect([]interface{}{int64(1), int64(1), int64(1)}).Expect([]interface{}{int64(0), int64(1)})
+	// The files of a scan in progress are removed as well
+	mock.Command("SUNION", "MIRRORFILES_1", "MIRRORFILESTMP_1").Expect([]interface{}{[]byte("/file")})
+	cmdFileMirrors := mock.Command("EVAL", redigomock.NewAnyData(), 1, "DIRMIRRORS_/", 1, "file").Expect(int64(0))
+	mock.Command("PUBLISH", database.MIRROR_FILE_UPDATE, "1 /file").Expect(int64(1))
+	cmdPublish := mock.Command("PUBLISH", string(database.MIRROR_UPDATE), "1").Expect(int64(1))
+
+	if err := RemoveMirror(conn, 1); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if mock.Stats(cmdDisable) != 1 {
+		t.Fatalf("The mirror should be disabled")
+	}
+	if mock.Stats(cmdMirrors) != 1 || mock.Stats(cmdRemoved) != 1 {
+		t.Fatalf("The mirror should be moved to the removed mirrors")
+	}
+	if mock.Stats(cmdFileMirrors) != 1 {
+		t.Fatalf("The mirror should not serve its files anymore")
+	}
+	if mock.Stats(cmdPublish) != 1 {
+		t.Fatalf("Update not published")
+	}
+}
+
+func TestRestoreMirror(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	mock.Command("WATCH", "MIRRORS").Expect("OK")
+	mock.Command("UNWATCH").Expect("OK")
+
+	mock.Command("HGET", "REMOVEDMIRRORS", 2).Expect(nil)
+	if err := RestoreMirror(conn, 2); err != ErrMirrorNotRemoved {
+		t.Fatalf("Expected ErrMirrorNotRemoved, got %v", err)
+	}
+
+	mock.Command("HGET", "REMOVEDMIRRORS", 1).Expect("m1")
+	mock.Command("HVALS", "MIRRORS").Expect([]interface{}{[]byte("m1")})
+	if err := RestoreMirror(conn, 1); err != ErrMirrorNameTaken {
+		t.Fatalf("Expected ErrMirrorNameTaken, got %v", err)
+	}
+
+	mock.Command("HVALS", "MIRRORS").Expect([]interface{}{[]byte("m2")})
+	mock.Command("SMEMBERS", "MIRRORFILES_1").Expect([]interface{}{[]byte("/file")})
+	mock.Command("MULTI").Expect("OK")
+	cmdFileMirrors := mock.Command("EVAL", redigomock.NewAnyData(), 1, "DIRMIRRORS_/", 1, "file").Expect(int64(0))
+	mock.Command("PUBLISH", database.MIRROR_FILE_UPDATE, "1 /file").Expect(int64(1))
+	cmdEnabled := mock.Command("EVAL", redigomock.NewAnyData(), 1, "MIRROR_1").Expect(nil)
+	mock.Command("HDEL", "REMOVEDMIRRORS", 1).Expect(int64(1))
+	cmdMirrors := mock.Command("HSET", "MIRRORS", 1, "m1").Expect(int64(1))
+	mock.Command("EXEC").Expect([]interface{}{int64(1), int64(1), int64(1), int64(1), int64(1)})
+	mock.Command("PUBLISH", string(database.MIRROR_UPDATE), "1").Expect(int64(1))
+
+	if err := RestoreMirror(conn, 1); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if mock.Stats(cmdFileMirrors) != 1 || mock.Stats(cmdMirrors) != 1 {
+		t.Fatalf("The mirror and its files should be restored")
+	}
+	if mock.Stats(cmdEnabled) != 1 {
+		t.Fatalf("The state of the mirror should be restored")
+	}
+}
+
+func TestGetRemovalReport(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	mock.Command("HEXISTS", "MIRRORS", 2).Expect(int64(0))
+	mock.Command("HEXISTS", "REMOVEDMIRRORS", 2).Expect(int64(0))
+	if _, err := GetRemovalReport(conn, 2); err != ErrMirrorNotFound {
+		t.Fatalf("Expected ErrMirrorNotFound, got %v", err)
+	}
+
+	mock.Command("HEXISTS", "MIRRORS", 1).Expect(int64(1))
+	mock.Command("SCARD", "MIRRORFILES_1").Expect(int64(3))
+	mock.Command("LLEN", "MIRRORLOGS_1").Expect(int64(5))
+	mock.Command("SCAN", 0, "MATCH", "STATS_MIRROR*", "COUNT", 1000).Expect([]interface{}{
+		[]byte("0"),
+		[]interface{}{[]byte("STATS_MIRROR"), []byte("STATS_MIRROR_2019")},
+	})
+	mock.Command("HEXISTS", "STATS_MIRROR", 1).Expect(int64(1))
+	mock.Command("HEXISTS", "STATS_MIRROR_2019", 1).Expect(int64(0))
+
+	report, err := GetRemovalReport(conn, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if report.Files != 3 || report.Logs != 5 || report.StatsKeys != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/etix/mirrorbits/database"
+	"github.com/etix/mirrorbits/filesystem"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Snapshot is a copy of the mirrors and of the files they serve, saved on
+// disk to serve the requests when the database is unreachable at start
+type Snapshot struct {
+	Created     time.Time
+	Mirrors     map[int][][]byte               // Raw MIRROR_ hashes
+	Files       map[string]filesystem.FileInfo // Files of the local repository
+	FileMirrors map[string]map[int]int64       // Mirrors of each file with the size of their copy, -1 if unknown
+
+	mirrors map[int]Mirror
+}
+
+// NewSnapshot takes a snapshot of the database
+func NewSnapshot(r *database.Redis) (*Snapshot, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	s := &Snapshot{
+		Created:     time.Now(),
+		Mirrors:     make(map[int][][]byte),
+		Files:       make(map[string]filesystem.FileInfo),
+		FileMirrors: make(map[string]map[int]int64),
+	}
+
+	ids, err := redis.Ints(conn.Do("HKEYS", "MIRRORS"))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		conn.Send("HGETALL", fmt.Sprintf("MIRROR_%d", id))
+	}
+	conn.Flush()
+	for _, id := range ids {
+		reply, err := redis.ByteSlices(conn.Receive())
+		if err != nil {
+			return nil, err
+		}
+		if len(reply) > 0 {
+			s.Mirrors[id] = reply
+		}
+	}
+
+	// The files are fetched a batch at a time, one pipeline per batch
+	err = database.ScanSet(conn, "FILES", func(files []string) error {
+		return s.addFiles(conn, files)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// addFiles adds the given files and their copies on the mirrors
+func (s *Snapshot) addFiles(conn redis.Conn, files []string) error {
+	for _, file := range files {
+		conn.Send("HMGET", redis.Args{"FILE_" + file}.AddFlat(fileInfoFields)...)
+		database.SendGetFileMirrors(conn, file)
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	mirrors := make([][]int, len(files))
+	for i, file := range files {
+		reply, err := redis.Strings(conn.Receive())
+		if err != nil {
+			return err
+		}
+		if s.Files[file], err = parseFileInfo(file, reply); err != nil {
+			return err
+		}
+		if mirrors[i], err = database.FileMirrorIDs(conn.Receive()); err != nil {
+			return err
+		}
+	}
+
+	for i, file := range files {
+		for _, id := range mirrors[i] {
+			conn.Send("HGET", fmt.Sprintf("FILEINFO_%d_%s", id, file), "size")
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	for i, file := range files {
+		sizes := make(map[int]int64, len(mirrors[i]))
+		for _, id := range mirrors[i] {
+			size, err := redis.Int64(conn.Receive())
+			if err == redis.ErrNil {
+				size = -1
+			} else if err != nil {
+				return err
+			}
+			sizes[id] = size
+		}
+		s.FileMirrors[file] = sizes
+	}
+	return nil
+}
+
+// Save writes the snapshot to the given file
+func (s *Snapshot) Save(path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	z := gzip.NewWriter(tmp)
+	if err = gob.NewEncoder(z).Encode(s); err == nil {
+		err = z.Close()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads the snapshot saved in the given file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	z, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err = gob.NewDecoder(z).Decode(s); err != nil {
+		return nil, err
+	}
+
+	s.mirrors = make(map[int]Mirror, len(s.Mirrors))
+	for id, reply := range s.Mirrors {
+		values := make([]interface{}, len(reply))
+		for i, v := range reply {
+			values[i] = v
+		}
+		m, err := parseMirror(values)
+		if err != nil {
+			return nil, err
+		}
+		s.mirrors[id] = m
+	}
+	return s, nil
+}
+
+// fileInfo returns the information of the given file
+func (s *Snapshot) fileInfo(path string) (filesystem.FileInfo, bool) {
+	f, ok := s.Files[path]
+	return f, ok
+}
+
+// fileMirrors returns the IDs of the mirrors serving the given file
+func (s *Snapshot) fileMirrors(path string) ([]int, bool) {
+	sizes, ok := s.FileMirrors[path]
+	if !ok {
+		return nil, false
+	}
+	ids := make([]int, 0, len(sizes))
+	for id := range sizes {
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// mirror returns the given mirror
+func (s *Snapshot) mirror(id int) (Mirror, bool) {
+	m, ok := s.mirrors[id]
+	return m, ok
+}
+
+// fileInfoMirror returns the information of the copy of the given file
+// on the given mirror
+func (s *Snapshot) fileInfoMirror(id int, path string) (filesystem.FileInfo, bool) {
+	size, ok := s.FileMirrors[path][id]
+	if !ok {
+		return filesystem.FileInfo{}, false
+	}
+	return filesystem.FileInfo{Path: path, Size: size}, true
+}
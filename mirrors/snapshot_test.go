@@ -0,0 +1,86 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package mirrors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etix/mirrorbits/network"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+	conn.ConnectPubsub()
+
+	filename := "/test/file.tgz"
+
+	mock.Command("HKEYS", "MIRRORS").Expect([]interface{}{[]byte("1")})
+	mock.Command("HGETALL", "MIRROR_1").ExpectMap(map[string]string{
+		"ID":   "1",
+		"name": "m1",
+		"http": "http://m1.example.org/",
+	})
+	mock.Command("SSCAN", "FILES", 0, "COUNT", 1000).Expect([]interface{}{
+		[]byte("0"),
+		[]interface{}{[]byte(filename)},
+	})
+	mock.Command("HMGET", "FILE_"+filename, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "firstSeen", "lastSeen", "packed").Expect([]interface{}{
+		[]byte("44000"), nil, []byte("abc"), nil, nil, nil, nil, nil, nil, nil,
+	})
+	mock.Command("HGET", "DIRMIRRORS_/test", "file.tgz").Expect([]byte{0x40})
+	mock.Command("HGET", "FILEINFO_1_"+filename, "size").Expect([]byte("44000"))
+
+	s, err := NewSnapshot(conn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "mirrorbits-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot")
+
+	if err = s.Save(path); err != nil {
+		t.Fatalf("Unable to save the snapshot: %s", err)
+	}
+	if s, err = LoadSnapshot(path); err != nil {
+		t.Fatalf("Unable to load the snapshot: %s", err)
+	}
+
+	// The database is unreachable
+	mock.Clear()
+	c := NewCache(conn)
+
+	if _, err = c.GetFileInfo(filename); err == nil {
+		t.Fatalf("Error expected without a snapshot")
+	}
+
+	c.UseSnapshot(s)
+
+	f, err := c.GetFileInfo(filename)
+	if err != nil {
+		t.Fatalf("Expected the file to be served from the snapshot, got %s", err)
+	}
+	if f.Path != filename || f.Size != 44000 || f.Sha1 != "abc" {
+		t.Fatalf("Unexpected file information: %+v", f)
+	}
+
+	mirrors, err := c.GetMirrors(filename, network.GeoIPRecord{})
+	if err != nil {
+		t.Fatalf("Expected the mirrors to be served from the snapshot, got %s", err)
+	}
+	if len(mirrors) != 1 || mirrors[0].Name != "m1" || mirrors[0].FileInfo.Size != 44000 || !mirrors[0].Stale {
+		t.Fatalf("Unexpected mirrors: %+v", mirrors)
+	}
+
+	if _, err = c.GetFileInfo("/unknown"); err == nil {
+		t.Fatalf("Error expected for a file missing from the snapshot")
+	}
+}
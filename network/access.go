@@ -0,0 +1,44 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package network
+
+import (
+	"net/http"
+	"strings"
+
+	. "github.com/etix/mirrorbits/config"
+)
+
+// CheckAccess returns true if the client of the given request is allowed
+// by the access control, otherwise the request is answered with an error.
+// The networks are checked against the address of the peer, the address
+// given by the forwarding headers being only used when the peer is one of
+// the TrustedProxies.
+func CheckAccess(w http.ResponseWriter, r *http.Request, a *AccessControl) bool {
+	if !a.IsRestricted() {
+		return true
+	}
+
+	username, password, _ := r.BasicAuth()
+	token := ""
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		token = strings.TrimSpace(auth[7:])
+	}
+	if a.IsAllowed(ClientIP(r), username, password, token) {
+		return true
+	}
+
+	if a.Password == "" && len(a.Tokens) == 0 {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+	if a.Password != "" {
+		w.Header().Add("WWW-Authenticate", `Basic realm="mirrorbits"`)
+	}
+	if len(a.Tokens) > 0 {
+		w.Header().Add("WWW-Authenticate", `Bearer realm="mirrorbits"`)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return false
+}
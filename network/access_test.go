@@ -0,0 +1,120 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	. "github.com/etix/mirrorbits/testing"
+)
+
+func TestCheckAccess(t *testing.T) {
+	SetConfiguration(&Configuration{})
+
+	request := func(setup func(r *http.Request)) *http.Request {
+		r := httptest.NewRequest("GET", "/?mirrorstats", nil)
+		if setup != nil {
+			setup(r)
+		}
+		return r
+	}
+
+	// Without any restriction the endpoint is public
+	w := httptest.NewRecorder()
+	if !CheckAccess(w, request(nil), &AccessControl{}) {
+		t.Fatalf("Expected the access to be allowed")
+	}
+
+	a := &AccessControl{
+		Tokens:   []string{"secret-token"},
+		Username: "noc",
+		Password: "secret",
+	}
+
+	w = httptest.NewRecorder()
+	if CheckAccess(w, request(nil), a) {
+		t.Fatalf("Expected the access to be denied")
+	}
+	if w.Code != http.StatusUnauthorized || len(w.Header()["Www-Authenticate"]) != 2 {
+		t.Fatalf("Expected a 401 with both challenges, got %d %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	if !CheckAccess(w, request(func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret-token") }), a) {
+		t.Fatalf("Expected the bearer token to be accepted")
+	}
+
+	w = httptest.NewRecorder()
+	if CheckAccess(w, request(func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }), a) {
+		t.Fatalf("Expected an invalid bearer token to be rejected")
+	}
+
+	w = httptest.NewRecorder()
+	if !CheckAccess(w, request(func(r *http.Request) { r.SetBasicAuth("noc", "secret") }), a) {
+		t.Fatalf("Expected the credentials to be accepted")
+	}
+
+	w = httptest.NewRecorder()
+	if CheckAccess(w, request(func(r *http.Request) { r.SetBasicAuth("noc", "wrong") }), a) {
+		t.Fatalf("Expected invalid credentials to be rejected")
+	}
+
+	// The client is not part of the allowed networks
+	w = httptest.NewRecorder()
+	if CheckAccess(w, request(nil), &AccessControl{Networks: []string{"10.0.0.0/8"}}) {
+		t.Fatalf("Expected the access to be denied")
+	}
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected a 403, got %d", w.Code)
+	}
+}
+
+func TestCheckAccessNetworks(t *testing.T) {
+	defer SetConfiguration(&Configuration{})
+
+	err := PrepareConfigTest(`Repository: /srv/repo
+TrustedProxies: [192.0.2.1]
+MirrorStatsAccess:
+    Networks: [10.0.0.0/8]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &GetConfig().MirrorStatsAccess
+
+	request := func(remoteAddr string, headers map[string]string) (bool, int) {
+		r := httptest.NewRequest("GET", "/?mirrorstats", nil)
+		r.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		return CheckAccess(w, r, a), w.Code
+	}
+
+	if ok, _ := request("10.1.2.3:1234", nil); !ok {
+		t.Fatalf("Expected the allowed network to be accepted")
+	}
+
+	// Spoofed headers sent by a client
+	for _, header := range []string{"X-Forwarded-For", "Forwarded", "CF-Connecting-IP", "True-Client-IP"} {
+		value := "10.1.2.3"
+		if header == "Forwarded" {
+			value = "for=10.1.2.3"
+		}
+		if ok, code := request("203.0.113.1:1234", map[string]string{header: value}); ok || code != http.StatusForbidden {
+			t.Fatalf("Expected the %s header of the client to be ignored", header)
+		}
+	}
+
+	// Address forwarded by the trusted proxy
+	if ok, _ := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "10.1.2.3"}); !ok {
+		t.Fatalf("Expected the address forwarded by the trusted proxy to be accepted")
+	}
+	if ok, _ := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "10.1.2.3, 203.0.113.1"}); ok {
+		t.Fatalf("Expected the client behind the trusted proxy to be refused")
+	}
+}
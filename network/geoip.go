@@ -142,6 +142,14 @@ func (g *GeoIP) LoadGeoIP() error {
 	return nil
 }
 
+// IsLoaded returns true if the city database is loaded, the clients
+// being located
+func (g *GeoIP) IsLoaded() bool {
+	g.RLock()
+	defer g.RUnlock()
+	return g.city != nil && g.city.db != nil
+}
+
 // GetRecord return informations about the given ip address
 // (works in IPv4 and v6)
 func (g *GeoIP) GetRecord(ip string) (ret GeoIPRecord) {
@@ -5,7 +5,10 @@ package network
 
 import (
 	"net"
+	"net/http"
 	"strings"
+
+	. "github.com/etix/mirrorbits/config"
 )
 
 // LookupMirrorIP returns the IP address of a mirror and returns an error
@@ -42,3 +45,126 @@ func ExtractRemoteIP(XForwardedFor string) string {
 	}
 	return ""
 }
+
+// Subnet returns the network (/24 for IPv4, /48 for IPv6) of the given
+// address or an empty string if the address is invalid
+func Subnet(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+	if v4 := addr.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return addr.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+// ParseForwarded extracts the list of client addresses (the "for" parameters)
+// from an RFC 7239 Forwarded header. Obfuscated and unknown identifiers are
+// returned as an empty string to preserve the position of each hop.
+func ParseForwarded(forwarded string) []string {
+	var addresses []string
+	if strings.TrimSpace(forwarded) == "" {
+		return addresses
+	}
+	for _, element := range strings.Split(forwarded, ",") {
+		addr := ""
+		for _, pair := range strings.Split(element, ";") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(kv[0], "for") {
+				continue
+			}
+			addr = stripPort(strings.Trim(kv[1], "\""))
+			if net.ParseIP(addr) == nil {
+				addr = ""
+			}
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses
+}
+
+// stripPort removes the optional port and brackets from an address
+func stripPort(addr string) string {
+	if strings.HasPrefix(addr, "[") {
+		if end := strings.Index(addr, "]"); end > 0 {
+			return addr[1:end]
+		}
+		return addr
+	}
+	if strings.Count(addr, ":") == 1 {
+		return addr[:strings.Index(addr, ":")]
+	}
+	return addr
+}
+
+// LastUntrustedIP walks the chain of addresses from the right-most (the
+// closest hop) and returns the first one not belonging to a trusted proxy.
+// The left-most address is returned if all the hops are trusted.
+func LastUntrustedIP(addresses []string, isTrusted func(ip string) bool) string {
+	for i := len(addresses) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(addresses[i])
+		if net.ParseIP(addr) == nil {
+			// Unknown, obfuscated or invalid hop, nothing more can be trusted
+			return ""
+		}
+		if i == 0 || !isTrusted(addr) {
+			return addr
+		}
+	}
+	return ""
+}
+
+// ClientIP returns the IP address of the client of the given request. The
+// forwarding headers are only honored when the request comes from one of the
+// trusted proxies or from a unix socket, no proxy being trusted by default.
+// The headers set by the CDNs are only honored when the request went through
+// one of the trusted CDNs, any client being able to set them otherwise.
+func ClientIP(r *http.Request) string {
+	peer := peerIP(r)
+	// Requests received on a unix socket come from a local proxy
+	if peer != "" && !GetConfig().IsTrustedProxy(peer) && !GetConfig().IsTrustedCDN(peer) {
+		return peer
+	}
+
+	// Address of the host connecting to the trusted proxies
+	addr := peer
+	if peer == "" || GetConfig().IsTrustedProxy(peer) {
+		var addresses []string
+		if forwarded := r.Header.Get("Forwarded"); forwarded != "" {
+			addresses = ParseForwarded(forwarded)
+		} else if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			addresses = strings.Split(xff, ",")
+		}
+		if last := LastUntrustedIP(addresses, GetConfig().IsTrustedProxy); last != "" {
+			addr = last
+		}
+	}
+
+	// Headers set by CDNs containing a single address
+	if GetConfig().IsTrustedCDN(addr) {
+		for _, header := range []string{"CF-Connecting-IP", "True-Client-IP"} {
+			if ip := strings.TrimSpace(r.Header.Get(header)); net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+	return addr
+}
+
+// FromTrustedProxy returns true if the request has been received from a
+// trusted proxy, either listed in TrustedProxies or connected to a unix
+// socket
+func FromTrustedProxy(r *http.Request) bool {
+	peer := peerIP(r)
+	return peer == "" || GetConfig().IsTrustedProxy(peer)
+}
+
+// peerIP returns the address of the host connected to mirrorbits, empty for
+// the requests received on a unix socket
+func peerIP(r *http.Request) string {
+	if !strings.Contains(r.RemoteAddr, ":") {
+		return ""
+	}
+	return stripPort(RemoteIPFromAddr(r.RemoteAddr))
+}
@@ -4,7 +4,11 @@
 package network
 
 import (
+	"net/http/httptest"
 	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	. "github.com/etix/mirrorbits/testing"
 )
 
 func TestRemoteIpFromAddr(t *testing.T) {
@@ -35,3 +39,144 @@ func TestExtractRemoteIP(t *testing.T) {
 		t.Fatalf("Expected '192.168.0.1', got %s", r)
 	}
 }
+
+func TestParseForwarded(t *testing.T) {
+	r := ParseForwarded("")
+	if len(r) != 0 {
+		t.Fatalf("Expected no address, got %v", r)
+	}
+
+	r = ParseForwarded(`for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8:cafe::17]:4711", for=unknown, for=198.51.100.17:8080`)
+	expected := []string{"192.0.2.60", "2001:db8:cafe::17", "", "198.51.100.17"}
+	if len(r) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, r)
+	}
+	for i := range expected {
+		if r[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, r)
+		}
+	}
+}
+
+func TestLastUntrustedIP(t *testing.T) {
+	trusted := func(ip string) bool {
+		return ip == "10.0.0.1" || ip == "10.0.0.2"
+	}
+
+	r := LastUntrustedIP([]string{"192.168.0.1", "192.168.0.2", "10.0.0.1"}, trusted)
+	if r != "192.168.0.2" {
+		t.Fatalf("Expected '192.168.0.2', got %s", r)
+	}
+
+	r = LastUntrustedIP([]string{"192.168.0.1", " 10.0.0.2", "10.0.0.1"}, trusted)
+	if r != "192.168.0.1" {
+		t.Fatalf("Expected '192.168.0.1', got %s", r)
+	}
+
+	r = LastUntrustedIP([]string{"10.0.0.2", "10.0.0.1"}, trusted)
+	if r != "10.0.0.2" {
+		t.Fatalf("Expected '10.0.0.2', got %s", r)
+	}
+
+	r = LastUntrustedIP([]string{"192.168.0.1", "", "10.0.0.1"}, trusted)
+	if r != "" {
+		t.Fatalf("Expected '', got %s", r)
+	}
+
+	r = LastUntrustedIP(nil, trusted)
+	if r != "" {
+		t.Fatalf("Expected '', got %s", r)
+	}
+
+	r = LastUntrustedIP([]string{"192.168.0.1", "evil", "10.0.0.1"}, trusted)
+	if r != "" {
+		t.Fatalf("Expected '', got %s", r)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	defer SetConfiguration(&Configuration{})
+
+	request := func(remoteAddr string, headers map[string]string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		return ClientIP(r)
+	}
+
+	// No proxy is trusted by default
+	if err := PrepareConfigTest("Repository: /srv/repo"); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range []string{"X-Forwarded-For", "CF-Connecting-IP", "True-Client-IP"} {
+		if ip := request("203.0.113.1:1234", map[string]string{header: "10.0.0.1"}); ip != "203.0.113.1" {
+			t.Fatalf("Expected the %s header to be ignored, got %s", header, ip)
+		}
+	}
+	if ip := request("@", map[string]string{"X-Forwarded-For": "198.51.100.1"}); ip != "198.51.100.1" {
+		t.Fatalf("Expected the header of the unix socket to be honored, got %s", ip)
+	}
+
+	if err := PrepareConfigTest("Repository: /srv/repo\nTrustedProxies: [192.0.2.0/24]"); err != nil {
+		t.Fatal(err)
+	}
+	if ip := request("203.0.113.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.1"}); ip != "203.0.113.1" {
+		t.Fatalf("Expected the header of an untrusted peer to be ignored, got %s", ip)
+	}
+	if ip := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.1, 198.51.100.1"}); ip != "198.51.100.1" {
+		t.Fatalf("Expected the header of the trusted proxy to be honored, got %s", ip)
+	}
+	// Any client of a generic reverse proxy can set the CDN headers
+	for _, header := range []string{"CF-Connecting-IP", "True-Client-IP"} {
+		if ip := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1", header: "10.0.0.1"}); ip != "198.51.100.1" {
+			t.Fatalf("Expected the %s header to be ignored without trusted CDN, got %s", header, ip)
+		}
+	}
+	if ip := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "not-an-ip"}); ip != "192.0.2.1" {
+		t.Fatalf("Expected an invalid address to be ignored, got %s", ip)
+	}
+
+	err := PrepareConfigTest(`Repository: /srv/repo
+TrustedProxies: [192.0.2.0/24]
+TrustedCDNs: [203.0.113.0/24]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The CDN connects directly
+	if ip := request("203.0.113.1:1234", map[string]string{"CF-Connecting-IP": "198.51.100.1"}); ip != "198.51.100.1" {
+		t.Fatalf("Expected the header of the trusted CDN to be honored, got %s", ip)
+	}
+	if ip := request("203.0.113.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.1"}); ip != "203.0.113.1" {
+		t.Fatalf("Expected the forwarding header of the CDN to be ignored, got %s", ip)
+	}
+	// The CDN connects through the trusted proxy
+	if ip := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.1", "True-Client-IP": "198.51.100.1"}); ip != "198.51.100.1" {
+		t.Fatalf("Expected the header of the trusted CDN to be honored, got %s", ip)
+	}
+	// A client of the trusted proxy not going through the CDN
+	if ip := request("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.2", "CF-Connecting-IP": "10.0.0.1"}); ip != "198.51.100.2" {
+		t.Fatalf("Expected the spoofed CDN header to be ignored, got %s", ip)
+	}
+	if ip := request("198.51.100.2:1234", map[string]string{"CF-Connecting-IP": "10.0.0.1"}); ip != "198.51.100.2" {
+		t.Fatalf("Expected the CDN header of an untrusted peer to be ignored, got %s", ip)
+	}
+}
+
+func TestSubnet(t *testing.T) {
+	r := Subnet("192.168.42.17")
+	if r != "192.168.42.0/24" {
+		t.Fatalf("Expected '192.168.42.0/24', got %s", r)
+	}
+
+	r = Subnet("2001:db8:cafe:17::1")
+	if r != "2001:db8:cafe::/48" {
+		t.Fatalf("Expected '2001:db8:cafe::/48', got %s", r)
+	}
+
+	r = Subnet("invalid")
+	if r != "" {
+		t.Fatalf("Expected '', got %s", r)
+	}
+}
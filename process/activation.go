@@ -0,0 +1,36 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package process
+
+import (
+	"net"
+
+	"github.com/coreos/go-systemd/activation"
+)
+
+// RPCSocketName is the name (FileDescriptorName) of the socket passed by
+// systemd for the RPC server, the other sockets being used by the HTTP
+// server
+const RPCSocketName = "rpc"
+
+// ActivatedListeners returns the listening sockets passed by systemd when
+// the daemon is started by socket activation (LISTEN_FDS), those of the
+// HTTP server and the one of the RPC server if any. The sockets are
+// already open when the daemon starts, the reverse proxies being able to
+// connect to them before it is ready.
+func ActivatedListeners() (httpListeners []net.Listener, rpcListener net.Listener) {
+	named, _ := activation.ListenersWithNames()
+	for name, listeners := range named {
+		if name != RPCSocketName {
+			httpListeners = append(httpListeners, listeners...)
+			continue
+		}
+		rpcListener = listeners[0]
+		for _, l := range listeners[1:] {
+			log.Warningf("Ignoring the extra RPC socket %s", l.Addr())
+			l.Close()
+		}
+	}
+	return
+}
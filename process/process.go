@@ -12,6 +12,7 @@ import (
 	"os/exec"
 	"path"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/etix/mirrorbits/core"
@@ -30,9 +31,9 @@ var (
 	log = logging.MustGetLogger("main")
 )
 
-// Relaunch launches {self} as a child process passing listener details
+// Relaunch launches {self} as a child process passing listeners details
 // to provide a seamless binary upgrade.
-func Relaunch(l net.Listener) error {
+func Relaunch(listeners []net.Listener) error {
 	argv0, err := exec.LookPath(os.Args[0])
 	if err != nil {
 		return err
@@ -45,52 +46,48 @@ func Relaunch(l net.Listener) error {
 		return err
 	}
 
-	var file *os.File
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	var fds, names []string
 
-	switch t := l.(type) {
-	case *net.TCPListener:
-		file, err = t.File()
-	case *net.UnixListener:
-		file, err = t.File()
-	default:
-		return ErrInvalidfd
-	}
-	if err != nil {
-		return err
-	}
-
-	fd := file.Fd()
-	sysfile := file.Name()
+	for _, l := range listeners {
+		var file *os.File
 
-	listener, ok := l.(*net.TCPListener)
-	if ok {
-		listenerFile, err := listener.File()
+		switch t := l.(type) {
+		case *net.TCPListener:
+			file, err = t.File()
+		case *net.UnixListener:
+			file, err = t.File()
+		default:
+			return ErrInvalidfd
+		}
 		if err != nil {
 			return err
 		}
-		fd = listenerFile.Fd()
-		sysfile = listenerFile.Name()
+
+		if file.Fd() < uintptr(syscall.Stderr) {
+			return ErrInvalidfd
+		}
+
+		// The file descriptors are renumbered sequentially in the child
+		fds = append(fds, fmt.Sprint(len(files)))
+		names = append(names, fmt.Sprintf("%s:%s->", l.Addr().Network(), l.Addr().String()))
+		files = append(files, file)
 	}
 
-	if fd < uintptr(syscall.Stderr) {
+	if len(fds) == 0 {
 		return ErrInvalidfd
 	}
 
-	if err := os.Setenv("OLD_FD", fmt.Sprint(fd)); err != nil {
+	if err := os.Setenv("OLD_FD", strings.Join(fds, ",")); err != nil {
 		return err
 	}
-	if err := os.Setenv("OLD_NAME", fmt.Sprintf("tcp:%s->", l.Addr().String())); err != nil {
+	if err := os.Setenv("OLD_NAME", strings.Join(names, ",")); err != nil {
 		return err
 	}
 	if err := os.Setenv("OLD_PPID", fmt.Sprint(syscall.Getpid())); err != nil {
 		return err
 	}
 
-	files := make([]*os.File, fd+1)
-	files[syscall.Stdin] = os.Stdin
-	files[syscall.Stdout] = os.Stdout
-	files[syscall.Stderr] = os.Stderr
-	files[fd] = os.NewFile(fd, sysfile)
 	p, err := os.StartProcess(argv0, os.Args, &os.ProcAttr{
 		Dir:   wd,
 		Env:   os.Environ(),
@@ -104,31 +101,43 @@ func Relaunch(l net.Listener) error {
 	return nil
 }
 
-// Recover from a seamless binary upgrade and use an already
-// existing listener to take over the connections
-func Recover() (l net.Listener, ppid int, err error) {
-	var fd uintptr
-	_, err = fmt.Sscan(os.Getenv("OLD_FD"), &fd)
-	if err != nil {
-		return
-	}
-	var i net.Listener
-	i, err = net.FileListener(os.NewFile(fd, os.Getenv("OLD_NAME")))
-	if err != nil {
-		return
-	}
-	switch i.(type) {
-	case *net.TCPListener:
-		l = i.(*net.TCPListener)
-	case *net.UnixListener:
-		l = i.(*net.UnixListener)
-	default:
-		err = fmt.Errorf("file descriptor is %T not *net.TCPListener or *net.UnixListener", i)
+// Recover from a seamless binary upgrade and use the already
+// existing listeners to take over the connections
+func Recover() (listeners []net.Listener, ppid int, err error) {
+	oldfds := os.Getenv("OLD_FD")
+	if oldfds == "" {
+		err = ErrInvalidfd
 		return
 	}
-	if err = syscall.Close(int(fd)); err != nil {
-		return
+	names := strings.Split(os.Getenv("OLD_NAME"), ",")
+
+	for i, oldfd := range strings.Split(oldfds, ",") {
+		var fd uintptr
+		_, err = fmt.Sscan(oldfd, &fd)
+		if err != nil {
+			return
+		}
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		var l net.Listener
+		l, err = net.FileListener(os.NewFile(fd, name))
+		if err != nil {
+			return
+		}
+		switch l.(type) {
+		case *net.TCPListener, *net.UnixListener:
+		default:
+			err = fmt.Errorf("file descriptor is %T not *net.TCPListener or *net.UnixListener", l)
+			return
+		}
+		if err = syscall.Close(int(fd)); err != nil {
+			return
+		}
+		listeners = append(listeners, l)
 	}
+
 	_, err = fmt.Sscan(os.Getenv("OLD_PPID"), &ppid)
 	if err != nil {
 		return
@@ -5,36 +5,136 @@ package rpc
 
 import (
 	"context"
+	"path"
 
 	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/mirrors"
 	grpc "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
+// actorKey is the context key of the client at the origin of a request
+type actorKey struct{}
+
+// roleLevels orders the roles by increasing permissions
+var roleLevels = map[string]int{
+	RoleReadOnly: 1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// methodRoles is the minimum role required to call each method. The
+// methods not listed here require the admin role.
+var methodRoles = map[string]string{
+	"GetVersion":         RoleReadOnly,
+	"Ping":               RoleReadOnly,
+	"List":               RoleReadOnly,
+	"MirrorInfo":         RoleReadOnly,
+	"StatsFile":          RoleReadOnly,
+	"StatsMirror":        RoleReadOnly,
+	"StatsTop":           RoleReadOnly,
+	"StatsHTTP":          RoleReadOnly,
+	"GetMirrorLogs":      RoleReadOnly,
+	"ClusterStatus":      RoleReadOnly,
+	"MatchMirror":        RoleReadOnly,
+	"SimulateSelection":  RoleReadOnly,
+	"SimulateTraffic":    RoleReadOnly,
+	"FileInfo":           RoleReadOnly,
+	"WatchEvents":        RoleReadOnly,
+	"ListRemovedMirrors": RoleReadOnly,
+	"RemovalReport":      RoleReadOnly,
+	"ChangeStatus":       RoleOperator,
+	"SetMaintenance":     RoleOperator,
+	"ScanMirror":         RoleOperator,
+	"RefreshRepository":  RoleOperator,
+	"GeoUpdateMirror":    RoleOperator,
+	"CheckMirrors":       RoleOperator,
+	"GetConfig":          RoleOperator,
+}
+
+// authorizedStream is a server stream whose context carries the identity of
+// the client
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
 func StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	if err := authorize(stream.Context()); err != nil {
+	ctx, err := authorize(stream.Context(), info.FullMethod)
+	if err != nil {
 		return err
 	}
 
-	return handler(srv, stream)
+	return handler(srv, authorizedStream{ServerStream: stream, ctx: ctx})
 }
 
 func UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	if err := authorize(ctx); err != nil {
+	ctx, err := authorize(ctx, info.FullMethod)
+	if err != nil {
 		return nil, err
 	}
 
 	return handler(ctx, req)
 }
 
-func authorize(ctx context.Context) error {
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if md["password"][0] == GetConfig().RPCPassword {
-			return nil
-		}
+// authorize checks the permissions of the client and returns the context
+// of the request along with the client's identity
+func authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	role, name, ok := GetConfig().RPCRole(firstValue(md, "password"), firstValue(md, "token"))
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "access denied")
 	}
 
-	return status.Error(codes.Unauthenticated, "access denied")
+	required, ok := methodRoles[path.Base(fullMethod)]
+	if !ok {
+		required = RoleAdmin
+	}
+	if roleLevels[role] < roleLevels[required] {
+		return ctx, status.Errorf(codes.PermissionDenied, "the %s role is required", required)
+	}
+	return context.WithValue(ctx, actorKey{}, clientActor(ctx, name)), nil
+}
+
+// clientActor identifies the client by the name of its token, if any, and
+// its address
+func clientActor(ctx context.Context, name string) string {
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	if name == "" {
+		return addr
+	}
+	return name + "@" + addr
+}
+
+// actor returns the client at the origin of the request, as recorded in
+// the logs of the mirrors
+func actor(ctx context.Context) string {
+	a, _ := ctx.Value(actorKey{}).(string)
+	return a
+}
+
+// withActor records the client at the origin of the request in the given
+// log entry
+func withActor(ctx context.Context, l mirrors.LogAction) mirrors.LogAction {
+	l.SetActor(actor(ctx))
+	return l
+}
+
+// firstValue returns the first value of the given metadata key
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
 }
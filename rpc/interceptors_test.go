@@ -0,0 +1,210 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	. "github.com/etix/mirrorbits/config"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// testStream is a server stream only providing a context
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testStream) Context() context.Context {
+	return s.ctx
+}
+
+func setTokens(password string) {
+	c := Configuration{
+		RPCPassword: password,
+		RPCTokens: []RPCToken{
+			{Name: "viewer", Token: "ro-secret", Role: RoleReadOnly},
+			{Name: "ops", Token: "op-secret", Role: RoleOperator},
+			{Name: "root", Token: "admin-secret", Role: RoleAdmin},
+		},
+	}
+	SetConfiguration(&c)
+}
+
+func incomingContext(kv ...string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4242},
+	})
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(kv...))
+}
+
+// callUnary calls the given method through the unary interceptor and
+// returns the actor seen by the handler
+func callUnary(ctx context.Context, method string) (string, error) {
+	var seen string
+	_, err := UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/CLI/" + method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			seen = actor(ctx)
+			return nil, nil
+		})
+	return seen, err
+}
+
+// callStream calls the given method through the stream interceptor and
+// returns the actor seen by the handler
+func callStream(ctx context.Context, method string) (string, error) {
+	var seen string
+	err := StreamInterceptor(nil, testStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/CLI/" + method},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			seen = actor(stream.Context())
+			return nil
+		})
+	return seen, err
+}
+
+func TestInterceptorsRoles(t *testing.T) {
+	setTokens("password")
+	defer SetConfiguration(&Configuration{})
+
+	tokens := []struct {
+		role  string
+		token string
+	}{
+		{RoleReadOnly, "ro-secret"},
+		{RoleOperator, "op-secret"},
+		{RoleAdmin, "admin-secret"},
+	}
+
+	tests := []struct {
+		method   string
+		stream   bool
+		required string
+	}{
+		{"GetVersion", false, RoleReadOnly},
+		{"List", false, RoleReadOnly},
+		{"MirrorInfo", false, RoleReadOnly},
+		{"StatsFile", false, RoleReadOnly},
+		{"GetMirrorLogs", false, RoleReadOnly},
+		{"WatchEvents", true, RoleReadOnly},
+		{"ChangeStatus", false, RoleOperator},
+		{"ScanMirror", false, RoleOperator},
+		{"RefreshRepository", false, RoleOperator},
+		{"GetConfig", false, RoleOperator},
+		{"CheckMirrors", true, RoleOperator},
+		{"AddMirror", false, RoleAdmin},
+		{"RemoveMirror", false, RoleAdmin},
+		{"Reload", false, RoleAdmin},
+		{"Upgrade", false, RoleAdmin},
+		{"DumpDatabase", true, RoleAdmin},
+		{"RestoreDatabase", true, RoleAdmin},
+	}
+
+	for _, test := range tests {
+		for _, tok := range tokens {
+			ctx := incomingContext("token", tok.token)
+			var err error
+			if test.stream {
+				_, err = callStream(ctx, test.method)
+			} else {
+				_, err = callUnary(ctx, test.method)
+			}
+			if roleLevels[tok.role] >= roleLevels[test.required] {
+				if err != nil {
+					t.Errorf("%s: expected the %s role to be accepted, got %v", test.method, tok.role, err)
+				}
+			} else if status.Code(err) != codes.PermissionDenied {
+				t.Errorf("%s: expected the %s role to be refused, got %v", test.method, tok.role, err)
+			}
+		}
+	}
+}
+
+func TestInterceptorsUnlistedMethods(t *testing.T) {
+	setTokens("password")
+	defer SetConfiguration(&Configuration{})
+
+	// Every method of the service either has an explicit role or requires
+	// the admin role
+	var methods []string
+	for _, m := range _CLI_serviceDesc.Methods {
+		methods = append(methods, m.MethodName)
+	}
+	for _, s := range _CLI_serviceDesc.Streams {
+		methods = append(methods, s.StreamName)
+	}
+
+	for _, method := range methods {
+		if _, ok := methodRoles[method]; ok {
+			continue
+		}
+		ctx := incomingContext("token", "op-secret")
+		if _, err := callStream(ctx, method); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: expected the operator role to be refused, got %v", method, err)
+		}
+		ctx = incomingContext("token", "admin-secret")
+		if _, err := callStream(ctx, method); err != nil {
+			t.Errorf("%s: expected the admin role to be accepted, got %v", method, err)
+		}
+	}
+
+	// Methods unknown to the service
+	if _, err := callStream(incomingContext("token", "op-secret"), "Unknown"); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected an unknown method to require the admin role, got %v", err)
+	}
+}
+
+func TestInterceptorsAuthentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		md       []string
+		code     codes.Code
+		actor    string
+	}{
+		{"password", "password", []string{"password", "password"}, codes.OK, "192.0.2.1:4242"},
+		{"wrong password", "password", []string{"password", "wrong"}, codes.Unauthenticated, ""},
+		{"no credentials", "password", nil, codes.Unauthenticated, ""},
+		{"token", "password", []string{"token", "admin-secret"}, codes.OK, "root@192.0.2.1:4242"},
+		{"unknown token", "password", []string{"token", "wrong"}, codes.Unauthenticated, ""},
+		{"unknown token with password", "password", []string{"password", "password", "token", "wrong"}, codes.Unauthenticated, ""},
+		// The empty password must not grant the admin role when only
+		// tokens are configured
+		{"empty password", "", nil, codes.Unauthenticated, ""},
+		{"empty password sent", "", []string{"password", ""}, codes.Unauthenticated, ""},
+	}
+
+	defer SetConfiguration(&Configuration{})
+
+	for _, test := range tests {
+		setTokens(test.password)
+
+		ctx := incomingContext(test.md...)
+		seen, err := callUnary(ctx, "Reload")
+		if status.Code(err) != test.code {
+			t.Errorf("%s: expected %s, got %v", test.name, test.code, err)
+		}
+		if seen != test.actor {
+			t.Errorf("%s: expected the actor %q, got %q", test.name, test.actor, seen)
+		}
+		seen, err = callStream(ctx, "DumpDatabase")
+		if status.Code(err) != test.code {
+			t.Errorf("%s: expected %s on a stream, got %v", test.name, test.code, err)
+		}
+		if seen != test.actor {
+			t.Errorf("%s: expected the actor %q on a stream, got %q", test.name, test.actor, seen)
+		}
+	}
+
+	// Without any token, the empty password is the default
+	SetConfiguration(&Configuration{})
+	if _, err := callUnary(incomingContext(), "Reload"); err != nil {
+		t.Fatalf("Expected the empty password to be accepted without tokens, got %v", err)
+	}
+}
@@ -5,20 +5,25 @@ package rpc
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/url"
 	"os"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
+	"github.com/etix/mirrorbits/daemon"
 	"github.com/etix/mirrorbits/database"
+	"github.com/etix/mirrorbits/http"
 	"github.com/etix/mirrorbits/mirrors"
 	"github.com/etix/mirrorbits/network"
 	"github.com/etix/mirrorbits/scan"
@@ -30,11 +35,20 @@ import (
 	context "golang.org/x/net/context"
 	grpc "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"gopkg.in/yaml.v3"
 )
 
+// maxConcurrentLookups is the number of mirrors located concurrently
+// when adding several mirrors at once
+const maxConcurrentLookups = 8
+
+// maxConcurrentChecks is the number of mirrors checked concurrently
+// when checking all the mirrors at once
+const maxConcurrentChecks = 10
+
 var (
 	// ErrNameAlreadyTaken is returned when the request name is already taken by another mirror
 	ErrNameAlreadyTaken = errors.New("name already taken")
@@ -47,18 +61,44 @@ type CLI struct {
 	sig      chan<- os.Signal
 	redis    *database.Redis
 	cache    *mirrors.Cache
+	monitor  ClusterMonitor
+	selector Selector
+}
+
+// ClusterMonitor is implemented by the monitor to expose the cluster state
+// and check the health of the mirrors on demand
+type ClusterMonitor interface {
+	ClusterStatus() []daemon.NodeStatus
+	CheckMirror(id int) (daemon.CheckResult, error)
+}
+
+// Selector is implemented by the HTTP server to simulate the selection of mirrors
+type Selector interface {
+	SimulateSelection(urlPath, ip, country string, secure http.SecureOption) (*mirrors.Results, error)
+	SimulateFallback(ip, country string) (*mirrors.Results, error)
+	SimulateTraffic(change http.TrafficChange) (*http.TrafficSimulation, error)
 }
 
 func (c *CLI) Start() error {
 	var err error
-	c.listener, err = net.Listen("tcp", GetConfig().RPCListenAddress)
+	tlsConfig, err := serverTLSConfig()
 	if err != nil {
-		return err
+		return fmt.Errorf("rpc: %s", err)
+	}
+	if c.listener == nil {
+		c.listener, err = net.Listen("tcp", GetConfig().RPCListenAddress)
+		if err != nil {
+			return err
+		}
 	}
-	c.server = grpc.NewServer(
+	options := []grpc.ServerOption{
 		grpc.UnaryInterceptor(UnaryInterceptor),
 		grpc.StreamInterceptor(StreamInterceptor),
-	)
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	c.server = grpc.NewServer(options...)
 	RegisterCLIServer(c.server, c)
 	reflection.Register(c.server)
 	go func() {
@@ -74,6 +114,12 @@ func (c *CLI) Close() error {
 	return c.listener.Close()
 }
 
+// SetListener sets the listener of the RPC server, such as a socket
+// passed by systemd, instead of listening on RPCListenAddress
+func (c *CLI) SetListener(l net.Listener) {
+	c.listener = l
+}
+
 func (c *CLI) SetSignals(sig chan<- os.Signal) {
 	c.sig = sig
 }
@@ -86,6 +132,14 @@ func (c *CLI) SetCache(cache *mirrors.Cache) {
 	c.cache = cache
 }
 
+func (c *CLI) SetMonitor(m ClusterMonitor) {
+	c.monitor = m
+}
+
+func (c *CLI) SetSelector(s Selector) {
+	c.selector = s
+}
+
 func (c *CLI) Ping(context.Context, *empty.Empty) (*empty.Empty, error) {
 	return &empty.Empty{}, nil
 }
@@ -119,6 +173,68 @@ func (c *CLI) Reload(ctx context.Context, in *empty.Empty) (*empty.Empty, error)
 	return &empty.Empty{}, nil
 }
 
+// GetConfig returns the configuration in use, the secrets being redacted
+func (c *CLI) GetConfig(ctx context.Context, in *empty.Empty) (*GetConfigReply, error) {
+	out, err := GetConfig().RedactedYAML()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't marshal the configuration")
+	}
+	return &GetConfigReply{
+		ConfigFile: core.ConfigFile,
+		Config:     string(out),
+	}, nil
+}
+
+func (c *CLI) ClusterStatus(ctx context.Context, in *empty.Empty) (*ClusterStatusReply, error) {
+	if c.monitor == nil {
+		return nil, status.Error(codes.Unavailable, "monitor not ready")
+	}
+
+	reply := &ClusterStatusReply{
+		Standby: core.IsStandby(),
+	}
+
+	conn := c.redis.Get()
+	_, err := conn.Do("PING")
+	reply.RedisReachable = err == nil
+	if reply.RedisReachable {
+		reply.Leader, _ = redis.String(conn.Do("GET", daemon.LeaderKey))
+	}
+	conn.Close()
+
+	var names map[int]string
+	if reply.RedisReachable {
+		names, _ = c.redis.GetListOfMirrors()
+	}
+
+	for _, n := range c.monitor.ClusterStatus() {
+		lastAnnounce, err := ptypes.TimestampProto(n.LastAnnounce)
+		if err != nil {
+			return nil, err
+		}
+		node := &ClusterNode{
+			ID:           n.ID,
+			LastAnnounce: lastAnnounce,
+			Self:         n.Self,
+		}
+		for _, id := range n.Mirrors {
+			name, ok := names[id]
+			if !ok {
+				name = fmt.Sprintf("#%d", id)
+			}
+			node.Mirrors = append(node.Mirrors, name)
+		}
+		reply.Nodes = append(reply.Nodes, node)
+	}
+
+	return reply, nil
+}
+
+func (c *CLI) SetStandby(ctx context.Context, in *SetStandbyRequest) (*empty.Empty, error) {
+	core.SetStandby(in.Standby)
+	return &empty.Empty{}, nil
+}
+
 func (c *CLI) MatchMirror(ctx context.Context, in *MatchRequest) (*MatchReply, error) {
 	if c.redis == nil {
 		return nil, status.Error(codes.Internal, "database not ready")
@@ -143,6 +259,369 @@ func (c *CLI) MatchMirror(ctx context.Context, in *MatchRequest) (*MatchReply, e
 	return reply, nil
 }
 
+func (c *CLI) SimulateSelection(ctx context.Context, in *SimulateSelectionRequest) (*SimulateSelectionReply, error) {
+	if c.selector == nil {
+		return nil, status.Error(codes.Unavailable, "http server not ready")
+	}
+
+	secure := http.UNDEFINED
+	switch in.Secure {
+	case SimulateSelectionRequest_HTTPS:
+		secure = http.WITHTLS
+	case SimulateSelectionRequest_HTTP:
+		secure = http.WITHOUTTLS
+	}
+
+	var results *mirrors.Results
+	var err error
+	if in.Fallback {
+		results, err = c.selector.SimulateFallback(in.IP, in.Country)
+	} else {
+		results, err = c.selector.SimulateSelection(in.Path, in.IP, in.Country, secure)
+	}
+	switch err {
+	case nil:
+	case http.ErrInvalidIP:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case http.ErrFileNotFound:
+		return nil, status.Error(codes.NotFound, err.Error())
+	case http.ErrNoMirror:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	default:
+		return nil, err
+	}
+
+	modTime, err := ptypes.TimestampProto(results.FileInfo.ModTime)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &SimulateSelectionReply{
+		Path:      results.FileInfo.Path,
+		Size:      results.FileInfo.Size,
+		ModTime:   modTime,
+		Country:   results.ClientInfo.CountryCode,
+		Continent: results.ClientInfo.ContinentCode,
+		City:      results.ClientInfo.City,
+		Latitude:  results.ClientInfo.Latitude,
+		Longitude: results.ClientInfo.Longitude,
+		Fallback:  results.Fallback,
+	}
+	if in.Fallback {
+		// The database being unavailable, the file is not looked up
+		reply.Path = in.Path
+		reply.Warnings = GetConfig().CheckFallbacks()
+	}
+	if results.ClientInfo.ASNum > 0 {
+		reply.ASN = fmt.Sprintf("%s (%d)", results.ClientInfo.ASName, results.ClientInfo.ASNum)
+	}
+	for _, m := range results.MirrorList {
+		reply.Mirrors = append(reply.Mirrors, selectedMirrorToRPC(m))
+	}
+	for _, m := range results.ExcludedList {
+		reply.Excluded = append(reply.Excluded, selectedMirrorToRPC(m))
+	}
+
+	return reply, nil
+}
+
+func (c *CLI) FileInfo(ctx context.Context, in *FileInfoRequest) (*FileInfoReply, error) {
+	if c.redis == nil || c.cache == nil {
+		return nil, status.Error(codes.Internal, "database not ready")
+	}
+
+	conn, err := c.redis.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	path := "/" + strings.TrimPrefix(in.Path, "/")
+	exists, err := redis.Bool(conn.Do("SISMEMBER", "FILES", path))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Error(codes.NotFound, http.ErrFileNotFound.Error())
+	}
+
+	fileInfo, err := c.cache.GetFileInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &FileInfoReply{
+		Path:    fileInfo.Path,
+		Size:    fileInfo.Size,
+		Sha1:    fileInfo.Sha1,
+		Sha256:  fileInfo.Sha256,
+		Md5:     fileInfo.Md5,
+		Sha512:  fileInfo.Sha512,
+		Blake2B: fileInfo.Blake2b,
+	}
+	if reply.ModTime, err = ptypes.TimestampProto(fileInfo.ModTime); err != nil {
+		return nil, err
+	}
+	if fileInfo.FirstSeen != nil {
+		if reply.FirstSeen, err = ptypes.TimestampProto(*fileInfo.FirstSeen); err != nil {
+			return nil, err
+		}
+	}
+	if fileInfo.LastSeen != nil {
+		if reply.LastSeen, err = ptypes.TimestampProto(*fileInfo.LastSeen); err != nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+func (c *CLI) WatchEvents(in *WatchEventsRequest, stream CLI_WatchEventsServer) error {
+	if c.redis == nil || c.redis.Pubsub == nil {
+		return status.Error(codes.Internal, "database not ready")
+	}
+
+	types := make(map[string]bool)
+	for _, t := range in.Types {
+		types[t] = true
+	}
+
+	events := make(chan string)
+	c.redis.Pubsub.SubscribeEvent(database.EVENTS, events)
+	defer c.redis.Pubsub.UnsubscribeEvent(database.EVENTS, events)
+
+	for {
+		var msg string
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg = <-events:
+		}
+
+		event, err := database.ParseEvent(msg)
+		if err != nil {
+			continue
+		}
+		if (len(types) > 0 && !types[event.Type]) || (in.MirrorID > 0 && int(in.MirrorID) != event.MirrorID) {
+			continue
+		}
+
+		timestamp, err := ptypes.TimestampProto(event.Timestamp)
+		if err != nil {
+			return err
+		}
+		e := &Event{
+			Type:      event.Type,
+			MirrorID:  int32(event.MirrorID),
+			Message:   event.Message,
+			Actor:     event.Actor,
+			Timestamp: timestamp,
+		}
+		if event.MirrorID > 0 && c.cache != nil {
+			if mirror, err := c.cache.GetMirror(event.MirrorID); err == nil {
+				e.MirrorName = mirror.Name
+			}
+		}
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *CLI) CheckMirrors(in *CheckMirrorsRequest, stream CLI_CheckMirrorsServer) error {
+	if c.monitor == nil {
+		return status.Error(codes.Unavailable, "monitor not ready")
+	}
+
+	var ids []int
+	if in.ID > 0 {
+		ids = []int{int(in.ID)}
+	} else {
+		list, err := c.redis.GetListOfMirrors()
+		if err != nil {
+			return errors.Wrap(err, "can't fetch the list of mirrors")
+		}
+		conn := c.redis.Get()
+		for id := range list {
+			enabled, err := redis.Bool(conn.Do("HGET", fmt.Sprintf("MIRROR_%d", id), "enabled"))
+			if err == nil && enabled {
+				ids = append(ids, id)
+			}
+		}
+		conn.Close()
+		sort.Ints(ids)
+	}
+
+	ctx := stream.Context()
+	replies := make(chan *CheckMirrorsReply)
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			reply := c.checkMirror(id)
+			<-sem
+			select {
+			case replies <- reply:
+			case <-ctx.Done():
+			}
+		}(id)
+	}
+	go func() {
+		wg.Wait()
+		close(replies)
+	}()
+
+	for reply := range replies {
+		if in.ID > 0 && reply.Error == daemon.ErrUnknownMirror.Error() {
+			return status.Error(codes.NotFound, "mirror not found")
+		}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CLI) DumpDatabase(in *empty.Empty, stream CLI_DumpDatabaseServer) error {
+	err := c.redis.Dump(func(r database.DumpRecord) error {
+		return stream.Send(&DumpRecord{
+			Key:    r.Key,
+			Type:   r.Type,
+			Values: r.Values,
+			TTL:    r.TTL,
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, "can't dump the database")
+	}
+	return nil
+}
+
+func (c *CLI) RestoreDatabase(stream CLI_RestoreDatabaseServer) error {
+	// The first message tells whether the existing keys can be replaced
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return stream.SendAndClose(&RestoreDatabaseReply{})
+	} else if err != nil {
+		return err
+	}
+
+	var pending = first
+	next := func() (database.DumpRecord, error) {
+		in := pending
+		if in == nil {
+			var err error
+			if in, err = stream.Recv(); err != nil {
+				return database.DumpRecord{}, err
+			}
+		}
+		pending = nil
+		if in.Record == nil {
+			return database.DumpRecord{}, errors.New("missing record")
+		}
+		return database.DumpRecord{
+			Key:    in.Record.Key,
+			Type:   in.Record.Type,
+			Values: in.Record.Values,
+			TTL:    in.Record.TTL,
+		}, nil
+	}
+
+	restored, err := c.redis.Restore(next, first.Force)
+	if err == database.ErrDatabaseNotEmpty {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		return errors.Wrapf(err, "restore failed after %d keys", restored)
+	}
+
+	return stream.SendAndClose(&RestoreDatabaseReply{
+		Restored: restored,
+	})
+}
+
+// checkMirror checks the health of a mirror and returns the result of the
+// check along with the resulting state of the mirror
+func (c *CLI) checkMirror(id int) *CheckMirrorsReply {
+	reply := &CheckMirrorsReply{
+		MirrorID: int32(id),
+	}
+
+	res, err := c.monitor.CheckMirror(id)
+	reply.File = res.File
+	reply.StatusCode = int32(res.StatusCode)
+	reply.LatencyMs = int64(res.Latency / time.Millisecond)
+	reply.CheckUp = res.Up
+	reply.Error = res.Error
+	if err != nil {
+		reply.Error = err.Error()
+	}
+
+	conn := c.redis.Get()
+	defer conn.Close()
+
+	// The cache may not be invalidated yet, get the state from the database
+	values, err := redis.Values(conn.Do("HMGET", fmt.Sprintf("MIRROR_%d", id), "name", "up", "excludeReason"))
+	if err == nil {
+		var up bool
+		redis.Scan(values, &reply.MirrorName, &up, &reply.ExcludeReason)
+		reply.Up = up
+	}
+	return reply
+}
+
+func (c *CLI) SimulateTraffic(ctx context.Context, in *SimulateTrafficRequest) (*SimulateTrafficReply, error) {
+	if c.selector == nil {
+		return nil, status.Error(codes.Unavailable, "http server not ready")
+	}
+
+	change := http.TrafficChange{}
+	for _, id := range in.Disable {
+		change.Disable = append(change.Disable, int(id))
+	}
+	for _, m := range in.Add {
+		if m.Name == "" {
+			return nil, status.Error(codes.InvalidArgument, "the hypothetical mirrors must have a name")
+		}
+		change.Add = append(change.Add, http.HypotheticalMirror{
+			Name:          m.Name,
+			Latitude:      m.Latitude,
+			Longitude:     m.Longitude,
+			CountryCode:   m.CountryCode,
+			ContinentCode: m.ContinentCode,
+			Asnum:         uint(m.Asnum),
+			Score:         int(m.Score),
+		})
+	}
+
+	simulation, err := c.selector.SimulateTraffic(change)
+	if err == http.ErrNoTraffic {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+
+	reply := &SimulateTrafficReply{
+		Samples:        int32(simulation.Samples),
+		UnservedBefore: simulation.UnservedBefore,
+		UnservedAfter:  simulation.UnservedAfter,
+	}
+	for _, m := range simulation.Mirrors {
+		reply.Mirrors = append(reply.Mirrors, &TrafficShare{
+			ID:     int32(m.ID),
+			Name:   m.Name,
+			Before: m.Before,
+			After:  m.After,
+		})
+	}
+	return reply, nil
+}
+
 func (c *CLI) ChangeStatus(ctx context.Context, in *ChangeStatusRequest) (*empty.Empty, error) {
 	if in.ID <= 0 {
 		return nil, status.Error(codes.FailedPrecondition, "invalid mirror id")
@@ -152,15 +631,52 @@ func (c *CLI) ChangeStatus(ctx context.Context, in *ChangeStatusRequest) (*empty
 
 	switch in.Enabled {
 	case true:
-		err = mirrors.EnableMirror(c.redis, int(in.ID))
+		err = mirrors.EnableMirrorBy(c.redis, int(in.ID), actor(ctx))
 	case false:
-		err = mirrors.DisableMirror(c.redis, int(in.ID))
+		var until time.Time
+		if in.Until != nil {
+			until, err = ptypes.Timestamp(in.Until)
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+		err = mirrors.DisableMirrorUntilBy(c.redis, int(in.ID), in.Reason, until, actor(ctx))
 	}
 
 	return &empty.Empty{}, err
 }
 
-func (c *CLI) List(ctx context.Context, in *empty.Empty) (*MirrorListReply, error) {
+func (c *CLI) SetMaintenance(ctx context.Context, in *SetMaintenanceRequest) (*empty.Empty, error) {
+	if in.ID <= 0 {
+		return nil, status.Error(codes.FailedPrecondition, "invalid mirror id")
+	}
+
+	var until time.Time
+	if in.Until != nil {
+		var err error
+		until, err = ptypes.Timestamp(in.Until)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	return &empty.Empty{}, mirrors.SetMaintenanceUntil(c.redis, int(in.ID), until)
+}
+
+func (c *CLI) List(ctx context.Context, in *MirrorListRequest) (*MirrorListReply, error) {
+	if in.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid page size")
+	}
+
+	var after int
+	if in.PageToken != "" {
+		var err error
+		after, err = strconv.Atoi(in.PageToken)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
 	conn, err := c.redis.Connect()
 	if err != nil {
 		return nil, err
@@ -172,8 +688,17 @@ func (c *CLI) List(ctx context.Context, in *empty.Empty) (*MirrorListReply, erro
 		return nil, errors.Wrap(err, "can't fetch the list of mirrors")
 	}
 
-	conn.Send("MULTI")
+	// Walk the mirrors by ID for the page token to remain stable
+	ids := make([]int, 0, len(mirrorsIDs))
 	for id := range mirrorsIDs {
+		if id > after {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	conn.Send("MULTI")
+	for _, id := range ids {
 		conn.Send("HGETALL", fmt.Sprintf("MIRROR_%d", id))
 	}
 
@@ -184,20 +709,64 @@ func (c *CLI) List(ctx context.Context, in *empty.Empty) (*MirrorListReply, erro
 
 	reply := &MirrorListReply{}
 
-	for _, e := range res {
+	var page []mirrors.Mirror
+	for i := range res {
 		var mirror mirrors.Mirror
-		res, ok := e.([]interface{})
+		values, ok := res[i].([]interface{})
 		if !ok {
 			return nil, errors.New("typecast failed")
 		}
-		err = redis.ScanStruct([]interface{}(res), &mirror)
+		err = redis.ScanStruct(values, &mirror)
 		if err != nil {
 			return nil, errors.Wrap(err, "scan struct failed")
 		}
-		m, err := MirrorToRPC(&mirror)
+		if !matchListFilters(&mirror, in) {
+			continue
+		}
+		reply.Total++
+		if in.PageSize > 0 && len(page) >= int(in.PageSize) {
+			continue
+		}
+		page = append(page, mirror)
+	}
+
+	if in.PageSize > 0 && int(reply.Total) > len(page) {
+		reply.NextPageToken = strconv.Itoa(page[len(page)-1].ID)
+	}
+
+	// Only fetch the health checks of the mirrors returned
+	if len(in.Fields) == 0 || hasField(in.Fields, "NodeHealth") {
+		conn.Send("MULTI")
+		for _, mirror := range page {
+			conn.Send("HGETALL", fmt.Sprintf("HEALTHCHECKS_%d", mirror.ID))
+		}
+		res, err = redis.Values(conn.Do("EXEC"))
+		if err != nil {
+			return nil, errors.Wrap(err, "database error")
+		}
+		for i := range page {
+			health, ok := res[i].([]interface{})
+			if !ok {
+				return nil, errors.New("typecast failed")
+			}
+			page[i].NodeHealth, err = mirrors.ParseNodeHealth(health)
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing health checks failed")
+			}
+		}
+	}
+
+	for i := range page {
+		m, err := MirrorToRPC(&page[i])
 		if err != nil {
 			return nil, err
 		}
+		if len(in.Fields) > 0 {
+			m, err = maskMirror(m, in.Fields)
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
 		reply.Mirrors = append(reply.Mirrors, m)
 	}
 
@@ -225,6 +794,7 @@ func (c *CLI) MirrorInfo(ctx context.Context, in *MirrorIDRequest) (*Mirror, err
 	if err != nil {
 		return nil, err
 	}
+	mi.Prepare()
 
 	rpcm, err := MirrorToRPC(&mi)
 	if err != nil {
@@ -279,6 +849,7 @@ func (c *CLI) GeoUpdateMirror(ctx context.Context, in *MirrorIDRequest) (*GeoUpd
 	geoRec := geo.GetRecord(ip)
 	if geoRec.IsValid() {
 		original := mirror
+		mirror.AltURLs = append(mirrors.AltURLs(nil), mirror.AltURLs...)
 		mirror.Latitude = geoRec.Latitude
 		mirror.Longitude = geoRec.Longitude
 		mirror.Asnum = geoRec.ASNum
@@ -296,6 +867,7 @@ func (c *CLI) GeoUpdateMirror(ctx context.Context, in *MirrorIDRequest) (*GeoUpd
 		if !utils.IsInSlice(country, strings.Fields(mirror.CountryCodes)) {
 			mirror.CountryCodes = country
 		}
+		reply.Warnings = append(reply.Warnings, locateAltURLs(geo, &mirror, true)...)
 		reply.Mirror, err = MirrorToRPC(&mirror)
 		if err != nil {
 			return nil, err
@@ -319,6 +891,198 @@ func (c *CLI) AddMirror(ctx context.Context, in *Mirror) (*AddMirrorReply, error
 		return nil, status.Error(codes.FailedPrecondition, "unexpected ID")
 	}
 
+	geo := network.NewGeoIP()
+	if err := geo.LoadGeoIP(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	reply, err := locateMirror(geo, mirror)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, c.setMirror(ctx, mirror)
+}
+
+// AddMirrors adds several mirrors at once. All the mirrors are validated and
+// located before being committed in a single transaction: if any of them is
+// invalid, none is added and the reason is given in its result. The new
+// mirrors are disabled while the updated ones keep their state.
+func (c *CLI) AddMirrors(ctx context.Context, in *AddMirrorsRequest) (*AddMirrorsReply, error) {
+	conn := c.redis.Get()
+	defer conn.Close()
+
+	// Abort the transaction if a mirror is added or renamed meanwhile
+	if _, err := conn.Do("WATCH", "MIRRORS"); err != nil {
+		return nil, err
+	}
+	defer conn.Do("UNWATCH")
+
+	existing, err := redis.StringMap(conn.Do("HGETALL", "MIRRORS"))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't fetch the list of mirrors")
+	}
+	ids := make(map[string]int, len(existing))
+	for id, name := range existing {
+		ids[name], _ = strconv.Atoi(id)
+	}
+	// The existing mirrors can only be listed once when updating them
+	taken := make(map[string]bool, len(existing)+len(in.Mirrors))
+	if !in.Update {
+		for name := range ids {
+			taken[name] = true
+		}
+	}
+
+	reply := &AddMirrorsReply{
+		Results: make([]*AddMirrorResult, len(in.Mirrors)),
+	}
+	list := make([]*mirrors.Mirror, len(in.Mirrors))
+	for i, m := range in.Mirrors {
+		result := &AddMirrorResult{Name: m.Name}
+		reply.Results[i] = result
+
+		mirror, err := MirrorFromRPC(m)
+		if err == nil {
+			err = validateNewMirror(mirror, taken)
+		}
+		if err == nil {
+			mirror.Enabled = false
+		}
+		if err == nil && in.Update && ids[mirror.Name] > 0 {
+			// The comment and the state are not part of the exported settings
+			mirror.ID = ids[mirror.Name]
+			var values []interface{}
+			values, err = redis.Values(conn.Do("HMGET", fmt.Sprintf("MIRROR_%d", mirror.ID), "comment", "enabled"))
+			if err == nil {
+				_, err = redis.Scan(values, &mirror.Comment, &mirror.Enabled)
+			}
+			result.ID = int32(mirror.ID)
+			result.Updated = true
+		}
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		taken[mirror.Name] = true
+		list[i] = mirror
+	}
+
+	// Locate the mirrors concurrently
+	var geo *network.GeoIP
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentLookups)
+	for i, mirror := range list {
+		if mirror == nil {
+			continue
+		}
+		if in.Update && hasLocation(mirror) {
+			// Keep the location given in the file
+			continue
+		}
+		if geo == nil {
+			geo = network.NewGeoIP()
+			if err := geo.LoadGeoIP(); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		wg.Add(1)
+		go func(result *AddMirrorResult, mirror *mirrors.Mirror) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			location, err := locateMirror(geo, mirror)
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+			result.Location = location
+		}(reply.Results[i], mirror)
+	}
+	wg.Wait()
+
+	for _, result := range reply.Results {
+		if result.Error != "" {
+			return reply, nil
+		}
+	}
+	if len(list) == 0 {
+		return reply, nil
+	}
+
+	// Reserve the IDs of the new mirrors
+	added := 0
+	for _, mirror := range list {
+		if mirror.ID == 0 {
+			added++
+		}
+	}
+	next := 0
+	if added > 0 {
+		last, err := redis.Int(conn.Do("INCRBY", "LAST_MID", added))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed creating new ids")
+		}
+		next = last - added + 1
+	}
+
+	conn.Send("MULTI")
+	for i, mirror := range list {
+		if mirror.ID == 0 {
+			mirror.ID = next
+			next++
+			reply.Results[i].ID = int32(mirror.ID)
+		}
+		sendMirror(conn, mirror)
+	}
+	res, err := conn.Do("EXEC")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't save the mirrors configuration")
+	}
+	if res == nil {
+		return nil, status.Error(codes.Aborted, "the list of mirrors has been modified concurrently, please retry")
+	}
+	reply.Committed = true
+
+	for i, mirror := range list {
+		// Publish update
+		database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(mirror.ID))
+		if reply.Results[i].Updated {
+			mirrors.PushLog(c.redis, withActor(ctx, mirrors.NewLogEdited(mirror.ID)))
+		} else {
+			mirrors.PushLog(c.redis, withActor(ctx, mirrors.NewLogAdded(mirror.ID)))
+		}
+	}
+
+	return reply, nil
+}
+
+// hasLocation returns true if the location of the mirror is set
+func hasLocation(mirror *mirrors.Mirror) bool {
+	return mirror.Latitude != 0 || mirror.Longitude != 0 || mirror.CountryCodes != ""
+}
+
+// validateNewMirror checks and normalizes the settings of a mirror to be
+// added, its name being checked against the names already taken
+func validateNewMirror(mirror *mirrors.Mirror, taken map[string]bool) error {
+	if mirror.ID != 0 {
+		return errors.New("unexpected ID")
+	}
+	if mirror.Name == "" || strings.Contains(mirror.Name, " ") {
+		return errors.New("invalid name")
+	}
+	if taken[mirror.Name] {
+		return ErrNameAlreadyTaken
+	}
+	if u, err := url.Parse(mirror.HttpURL); err != nil || u.Host == "" {
+		return errors.New("invalid http url")
+	}
+	return prepareMirror(mirror)
+}
+
+// locateMirror guesses the geographic location of a mirror from the address
+// of its HTTP URL
+func locateMirror(geo *network.GeoIP, mirror *mirrors.Mirror) (*AddMirrorReply, error) {
 	u, err := url.Parse(mirror.HttpURL)
 	if err != nil {
 		return nil, errors.Wrap(err, "can't parse http url")
@@ -334,11 +1098,6 @@ func (c *CLI) AddMirror(ctx context.Context, in *Mirror) (*AddMirrorReply, error
 		return nil, errors.Wrap(err, "IP lookup failed")
 	}
 
-	geo := network.NewGeoIP()
-	if err := geo.LoadGeoIP(); err != nil {
-		return nil, errors.WithStack(err)
-	}
-
 	geoRec := geo.GetRecord(ip)
 	if geoRec.IsValid() {
 		mirror.Latitude = geoRec.Latitude
@@ -357,7 +1116,46 @@ func (c *CLI) AddMirror(ctx context.Context, in *Mirror) (*AddMirrorReply, error
 			"Warning: unable to guess the geographic location of this mirror")
 	}
 
-	return reply, c.setMirror(mirror)
+	reply.Warnings = append(reply.Warnings, locateAltURLs(geo, mirror, false)...)
+
+	return reply, nil
+}
+
+// locateAltURLs guesses the geographic location of the alternative URLs of
+// a mirror from their address, only those without a location unless all is
+// set. It returns the warnings of the lookups.
+func locateAltURLs(geo *network.GeoIP, mirror *mirrors.Mirror, all bool) (warnings []string) {
+	for i := range mirror.AltURLs {
+		alt := &mirror.AltURLs[i]
+		if !all && (alt.Latitude != 0 || alt.Longitude != 0 || alt.CountryCodes != "") {
+			continue
+		}
+		u, err := url.Parse(alt.URL)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Warning: can't parse the alternative url %s", alt.URL))
+			continue
+		}
+		ip, err := network.LookupMirrorIP(u.Host)
+		if err != nil && err != network.ErrMultipleAddresses {
+			warnings = append(warnings, fmt.Sprintf("Warning: IP lookup of the alternative url %s failed: %s", alt.URL, err))
+			continue
+		}
+		geoRec := geo.GetRecord(ip)
+		if !geoRec.IsValid() {
+			warnings = append(warnings, fmt.Sprintf("Warning: unable to guess the geographic location of the alternative url %s", alt.URL))
+			continue
+		}
+		alt.Latitude = geoRec.Latitude
+		alt.Longitude = geoRec.Longitude
+		alt.ContinentCode = utils.SanitizeLocationCodes(geoRec.ContinentCode)
+		alt.Asnum = geoRec.ASNum
+		// Keep the countries the alternative URL is expected to serve
+		country := utils.SanitizeLocationCodes(geoRec.CountryCode)
+		if !utils.IsInSlice(country, strings.Fields(alt.CountryCodes)) {
+			alt.CountryCodes = country
+		}
+	}
+	return
 }
 
 func (c *CLI) UpdateMirror(ctx context.Context, in *Mirror) (*UpdateMirrorReply, error) {
@@ -387,11 +1185,25 @@ func (c *CLI) UpdateMirror(ctx context.Context, in *Mirror) (*UpdateMirrorReply,
 		return nil, err
 	}
 
+	// Locate the alternative URLs just added
+	var warnings []string
+	for _, alt := range mirror.AltURLs {
+		if alt.Latitude == 0 && alt.Longitude == 0 && alt.CountryCodes == "" {
+			geo := network.NewGeoIP()
+			if err := geo.LoadGeoIP(); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			warnings = locateAltURLs(geo, mirror, false)
+			break
+		}
+	}
+
 	diff := createDiff(&original, mirror)
 
 	return &UpdateMirrorReply{
-		Diff: diff,
-	}, c.setMirror(mirror)
+		Diff:     diff,
+		Warnings: warnings,
+	}, c.setMirror(ctx, mirror)
 }
 
 func createDiff(mirror1, mirror2 *mirrors.Mirror) (out string) {
@@ -401,16 +1213,24 @@ func createDiff(mirror1, mirror2 *mirrors.Mirror) (out string) {
 	splito := strings.Split(string(yamlo), "\n")
 	splitn := strings.Split(string(yamln), "\n")
 
-	for i, l := range splito {
-		if l != splitn[i] {
-			out += fmt.Sprintf("- %s\n+ %s\n", l, splitn[i])
+	// The lists (i.e. AltURLs) may differ in length
+	for i := 0; i < len(splito) || i < len(splitn); i++ {
+		var o, n string
+		if i < len(splito) {
+			o = splito[i]
+		}
+		if i < len(splitn) {
+			n = splitn[i]
+		}
+		if o != n {
+			out += fmt.Sprintf("- %s\n+ %s\n", o, n)
 		}
 	}
 
 	return
 }
 
-func (c *CLI) setMirror(mirror *mirrors.Mirror) error {
+func (c *CLI) setMirror(ctx context.Context, mirror *mirrors.Mirror) error {
 	conn, err := c.redis.Connect()
 	if err != nil {
 		return err
@@ -422,6 +1242,10 @@ func (c *CLI) setMirror(mirror *mirrors.Mirror) error {
 		return errors.Wrap(err, "can't fetch the list of mirrors")
 	}
 
+	if err := prepareMirror(mirror); err != nil {
+		return err
+	}
+
 	isUpdate := false
 
 	for id, name := range mirrorsIDs {
@@ -441,9 +1265,39 @@ func (c *CLI) setMirror(mirror *mirrors.Mirror) error {
 		}
 	}
 
+	// Save the values back into redis
+	conn.Send("MULTI")
+	sendMirror(conn, mirror)
+
+	_, err = conn.Do("EXEC")
+	if err != nil {
+		return errors.Wrap(err, "couldn't save the mirror configuration")
+	}
+
+	// Publish update
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(mirror.ID))
+
+	if isUpdate {
+		// This was an update of an existing mirror
+		mirrors.PushLog(c.redis, withActor(ctx, mirrors.NewLogEdited(mirror.ID)))
+	} else {
+		// We just added a new mirror
+		mirrors.PushLog(c.redis, withActor(ctx, mirrors.NewLogAdded(mirror.ID)))
+	}
+
+	return nil
+}
+
+// prepareMirror validates and normalizes the settings of a mirror
+func prepareMirror(mirror *mirrors.Mirror) error {
+	if _, err := mirrors.ParseMaintenance(mirror.Maintenance); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	// Reformat contry codes
 	mirror.CountryCodes = utils.SanitizeLocationCodes(mirror.CountryCodes)
 	mirror.ExcludedCountryCodes = utils.SanitizeLocationCodes(mirror.ExcludedCountryCodes)
+	mirror.AdditionalServedCountries = utils.SanitizeLocationCodes(mirror.AdditionalServedCountries)
 
 	// Reformat continent code
 	mirror.ContinentCode = utils.SanitizeLocationCodes(mirror.ContinentCode)
@@ -459,8 +1313,41 @@ func (c *CLI) setMirror(mirror *mirrors.Mirror) error {
 		mirror.FtpURL = utils.NormalizeURL(mirror.FtpURL)
 	}
 
-	// Save the values back into redis
-	conn.Send("MULTI")
+	// The alternative URLs are served instead of the HTTP URL, hence
+	// they must use the same scheme
+	for i := range mirror.AltURLs {
+		alt := &mirror.AltURLs[i]
+		u, err := url.Parse(alt.URL)
+		if err != nil || u.Host == "" {
+			return status.Errorf(codes.InvalidArgument, "invalid alternative url %s", alt.URL)
+		}
+		if !strings.HasPrefix(mirror.HttpURL, u.Scheme+"://") {
+			return status.Errorf(codes.InvalidArgument, "the alternative url %s must use the scheme of the http url", alt.URL)
+		}
+		alt.URL = utils.NormalizeURL(alt.URL)
+		alt.CountryCodes = utils.SanitizeLocationCodes(alt.CountryCodes)
+		alt.ContinentCode = utils.SanitizeLocationCodes(alt.ContinentCode)
+		alt.CountryFields = strings.Fields(alt.CountryCodes)
+	}
+
+	// Validate the health-check settings
+	if mirror.HealthCheckPath != "" && !strings.HasPrefix(mirror.HealthCheckPath, "/") {
+		mirror.HealthCheckPath = "/" + mirror.HealthCheckPath
+	}
+	if _, err := mirrors.ParseStatusCodes(mirror.HealthCheckCodes); err != nil {
+		return errors.Wrap(err, "invalid health-check codes")
+	}
+
+	// Validate the scan settings
+	if mirror.ScanInterval < 0 || mirror.RsyncBandwidthLimit < 0 || mirror.FtpConnections < 0 {
+		return status.Error(codes.InvalidArgument, "the scan interval and limits cannot be negative")
+	}
+	return nil
+}
+
+// sendMirror queues the commands saving the mirror, to be sent within a
+// transaction
+func sendMirror(conn redis.Conn, mirror *mirrors.Mirror) {
 	conn.Send("HMSET", fmt.Sprintf("MIRROR_%d", mirror.ID),
 		"ID", mirror.ID,
 		"name", mirror.Name,
@@ -482,86 +1369,109 @@ func (c *CLI) setMirror(mirror *mirrors.Mirror) error {
 		"continentCode", mirror.ContinentCode,
 		"countryCodes", mirror.CountryCodes,
 		"excludedCountryCodes", mirror.ExcludedCountryCodes,
+		"additionalServedCountries", mirror.AdditionalServedCountries,
 		"asnum", mirror.Asnum,
 		"comment", mirror.Comment,
 		"allowredirects", mirror.AllowRedirects,
+		"modTimePrecision", int64(mirror.ModTimePrecision),
+		"healthCheckPath", mirror.HealthCheckPath,
+		"healthCheckCodes", mirror.HealthCheckCodes,
+		"note", mirror.Note,
+		"tags", mirror.Tags,
+		"maintenance", mirror.Maintenance,
+		"hostHeader", mirror.HostHeader,
+		"scanInterval", mirror.ScanInterval,
+		"rsyncBwLimit", mirror.RsyncBandwidthLimit,
+		"ftpConnections", mirror.FtpConnections,
+		"altURLs", mirror.AltURLs,
 		"enabled", mirror.Enabled)
 
 	// The name of the mirror has been changed.
 	conn.Send("HSET", "MIRRORS", mirror.ID, mirror.Name)
-
-	_, err = conn.Do("EXEC")
-	if err != nil {
-		return errors.Wrap(err, "couldn't save the mirror configuration")
-	}
-
-	// Publish update
-	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(mirror.ID))
-
-	if isUpdate {
-		// This was an update of an existing mirror
-		mirrors.PushLog(c.redis, mirrors.NewLogEdited(mirror.ID))
-	} else {
-		// We just added a new mirror
-		mirrors.PushLog(c.redis, mirrors.NewLogAdded(mirror.ID))
-	}
-
-	return nil
 }
 
-func (c *CLI) RemoveMirror(ctx context.Context, in *MirrorIDRequest) (*empty.Empty, error) {
+// RemoveMirror removes a mirror, which can be restored during the retention
+// period set by RemovedMirrorRetention unless the mirror is purged at once
+func (c *CLI) RemoveMirror(ctx context.Context, in *RemoveMirrorRequest) (*empty.Empty, error) {
 	if in.ID <= 0 {
 		return nil, status.Error(codes.FailedPrecondition, "invalid mirror id")
 	}
 
-	conn, err := c.redis.Connect()
-	if err != nil {
-		return nil, err
-	}
-	defer conn.Close()
-
-	// First disable the mirror
-	err = mirrors.DisableMirror(c.redis, int(in.ID))
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to disable the mirror")
+	var err error
+	if in.Purge || GetConfig().RemovedMirrorRetention == 0 {
+		err = mirrors.PurgeMirror(c.redis, int(in.ID))
+	} else {
+		err = mirrors.RemoveMirror(c.redis, int(in.ID))
 	}
-
-	// Get all files supported by the given mirror
-	files, err := redis.Strings(conn.Do("SMEMBERS", fmt.Sprintf("MIRRORFILES_%d", in.ID)))
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to fetch the file list")
+	if err == mirrors.ErrMirrorNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, errors.Wrap(err, "operation failed")
 	}
 
-	conn.Send("MULTI")
+	return &empty.Empty{}, nil
+}
 
-	// Remove each FILEINFO / FILEMIRRORS
-	for _, file := range files {
-		conn.Send("DEL", fmt.Sprintf("FILEINFO_%d_%s", in.ID, file))
-		conn.Send("SREM", fmt.Sprintf("FILEMIRRORS_%s", file), in.ID)
-		conn.Send("PUBLISH", database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", in.ID, file))
+// RemovalReport returns what removing or purging a mirror deletes, along
+// with the number of days during which a removed mirror can be restored
+func (c *CLI) RemovalReport(ctx context.Context, in *MirrorIDRequest) (*RemovalReportReply, error) {
+	report, err := mirrors.GetRemovalReport(c.redis, int(in.ID))
+	if err == mirrors.ErrMirrorNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, errors.Wrap(err, "operation failed")
 	}
 
-	// Remove all other keys
-	conn.Send("DEL",
-		fmt.Sprintf("MIRROR_%d", in.ID),
-		fmt.Sprintf("MIRRORFILES_%d", in.ID),
-		fmt.Sprintf("MIRRORFILESTMP_%d", in.ID),
-		fmt.Sprintf("HANDLEDFILES_%d", in.ID),
-		fmt.Sprintf("SCANNING_%d", in.ID),
-		fmt.Sprintf("MIRRORLOGS_%d", in.ID))
+	return &RemovalReportReply{
+		Files:     report.Files,
+		Logs:      report.Logs,
+		StatsKeys: report.StatsKeys,
+		Retention: int32(GetConfig().RemovedMirrorRetention),
+	}, nil
+}
 
-	// Remove the last reference
-	conn.Send("HDEL", "MIRRORS", in.ID)
+// RestoreMirror restores a removed mirror
+func (c *CLI) RestoreMirror(ctx context.Context, in *MirrorIDRequest) (*empty.Empty, error) {
+	err := mirrors.RestoreMirror(c.redis, int(in.ID))
+	switch err {
+	case nil:
+		return &empty.Empty{}, nil
+	case mirrors.ErrMirrorNotRemoved:
+		return nil, status.Error(codes.NotFound, err.Error())
+	case mirrors.ErrMirrorNameTaken:
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	return nil, errors.Wrap(err, "operation failed")
+}
 
-	_, err = conn.Do("EXEC")
+// ListRemovedMirrors returns the mirrors removed but not purged yet
+func (c *CLI) ListRemovedMirrors(ctx context.Context, in *empty.Empty) (*RemovedMirrorsReply, error) {
+	list, err := mirrors.GetRemovedMirrors(c.redis)
 	if err != nil {
-		return nil, errors.Wrap(err, "operation failed")
+		return nil, errors.Wrap(err, "can't fetch the list of removed mirrors")
 	}
 
-	// Publish update
-	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(int(in.ID)))
-
-	return &empty.Empty{}, nil
+	retention := time.Duration(GetConfig().RemovedMirrorRetention) * 24 * time.Hour
+	reply := &RemovedMirrorsReply{
+		Mirrors: make([]*RemovedMirror, 0, len(list)),
+	}
+	for _, m := range list {
+		removedAt, err := ptypes.TimestampProto(m.RemovedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid removal date")
+		}
+		purgeAt, err := ptypes.TimestampProto(m.RemovedAt.Add(retention))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid purge date")
+		}
+		reply.Mirrors = append(reply.Mirrors, &RemovedMirror{
+			ID:        int32(m.ID),
+			Name:      m.Name,
+			RemovedAt: removedAt,
+			PurgeAt:   purgeAt,
+		})
+	}
+	return reply, nil
 }
 
 func (c *CLI) RefreshRepository(ctx context.Context, in *RefreshRepositoryRequest) (*empty.Empty, error) {
@@ -606,7 +1516,7 @@ func (c *CLI) ScanMirror(ctx context.Context, in *ScanMirrorRequest) (*ScanMirro
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err := trace.GetLastUpdate(mirror)
+		_, err := trace.GetLastUpdate(mirror)
 		if err != nil && err != scan.ErrNoTrace {
 			if numError, ok := err.(*strconv.NumError); ok {
 				if numError.Err == strconv.ErrSyntax {
@@ -625,17 +1535,17 @@ func (c *CLI) ScanMirror(ctx context.Context, in *ScanMirrorRequest) (*ScanMirro
 	if in.Protocol == ScanMirrorRequest_ALL {
 		// Use rsync (if applicable) and fallback to FTP
 		if mirror.RsyncURL != "" {
-			res, err = scan.Scan(core.RSYNC, c.redis, c.cache, mirror.RsyncURL, mirror.ID, ctx.Done())
+			res, err = scan.Scan(core.RSYNC, c.redis, c.cache, mirror.RsyncURL, mirror.ID, in.Force, ctx.Done())
 		}
 		if err != nil && mirror.FtpURL != "" {
-			res, err = scan.Scan(core.FTP, c.redis, c.cache, mirror.FtpURL, mirror.ID, ctx.Done())
+			res, err = scan.Scan(core.FTP, c.redis, c.cache, mirror.FtpURL, mirror.ID, in.Force, ctx.Done())
 		}
 	} else {
 		// Use the requested protocol
 		if in.Protocol == ScanMirrorRequest_RSYNC && mirror.RsyncURL != "" {
-			res, err = scan.Scan(core.RSYNC, c.redis, c.cache, mirror.RsyncURL, mirror.ID, ctx.Done())
+			res, err = scan.Scan(core.RSYNC, c.redis, c.cache, mirror.RsyncURL, mirror.ID, in.Force, ctx.Done())
 		} else if in.Protocol == ScanMirrorRequest_FTP && mirror.FtpURL != "" {
-			res, err = scan.Scan(core.FTP, c.redis, c.cache, mirror.FtpURL, mirror.ID, ctx.Done())
+			res, err = scan.Scan(core.FTP, c.redis, c.cache, mirror.FtpURL, mirror.ID, in.Force, ctx.Done())
 		}
 	}
 
@@ -644,15 +1554,16 @@ func (c *CLI) ScanMirror(ctx context.Context, in *ScanMirrorRequest) (*ScanMirro
 	}
 
 	reply := &ScanMirrorReply{
-		FilesIndexed: res.FilesIndexed,
-		KnownIndexed: res.KnownIndexed,
-		Removed:      res.Removed,
-		TZOffsetMs:   res.TZOffsetMs,
+		FilesIndexed:   res.FilesIndexed,
+		KnownIndexed:   res.KnownIndexed,
+		Removed:        res.Removed,
+		TZOffsetMs:     res.TZOffsetMs,
+		CaseCollisions: res.CaseCollisions,
 	}
 
 	// Finally enable the mirror if requested
 	if err == nil && in.AutoEnable == true {
-		if err := mirrors.EnableMirror(c.redis, mirror.ID); err != nil {
+		if err := mirrors.EnableMirrorBy(c.redis, mirror.ID, actor(ctx)); err != nil {
 			return nil, errors.Wrap(err, "couldn't enable the mirror")
 		}
 		reply.Enabled = true
@@ -693,7 +1604,9 @@ func (c *CLI) StatsFile(ctx context.Context, in *StatsFileRequest) (*StatsFileRe
 	conn.Send("MULTI")
 
 	for _, k := range tkcoverage {
-		conn.Send("HGETALL", "STATS_FILE_"+k)
+		for _, key := range database.StatsFileKeys("STATS_FILE_" + k) {
+			conn.Send("HGETALL", key)
+		}
 	}
 
 	stats, err := redis.Values(conn.Do("EXEC"))
@@ -791,15 +1704,183 @@ func (c *CLI) StatsMirror(ctx context.Context, in *StatsMirrorRequest) (*StatsMi
 	return reply, nil
 }
 
+func (c *CLI) StatsHTTP(ctx context.Context, in *StatsHTTPRequest) (*StatsHTTPReply, error) {
+	conn, err := c.redis.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Convert the timestamps
+	start, err := ptypes.Timestamp(in.DateStart)
+	if err != nil {
+		return nil, err
+	}
+	end, err := ptypes.Timestamp(in.DateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the list of redis key for the period
+	tkcoverage := utils.TimeKeyCoverage(start, end)
+
+	conn.Send("MULTI")
+	for _, k := range tkcoverage {
+		conn.Send("HGETALL", "STATS_HTTP_"+k)
+	}
+
+	stats, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't fetch stats")
+	}
+
+	// Merge the counters of the period
+	totals := make(map[string]int64)
+	for _, e := range stats {
+		counters, err := redis.Int64Map(e, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "stats error")
+		}
+		for k, v := range counters {
+			totals[k] += v
+		}
+	}
+
+	reply := &StatsHTTPReply{}
+	for k, v := range totals {
+		fields := strings.Fields(k)
+		if len(fields) != 2 {
+			continue
+		}
+		code, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		reply.Responses = append(reply.Responses, &StatsHTTPReply_Response{
+			Handler: fields[0],
+			Code:    int32(code),
+			Count:   v,
+		})
+	}
+
+	sort.Slice(reply.Responses, func(i, j int) bool {
+		a, b := reply.Responses[i], reply.Responses[j]
+		if a.Handler != b.Handler {
+			return a.Handler < b.Handler
+		}
+		return a.Code < b.Code
+	})
+
+	return reply, nil
+}
+
+func (c *CLI) StatsTop(ctx context.Context, in *StatsTopRequest) (*StatsTopReply, error) {
+	if in.Count <= 0 {
+		return nil, status.Error(codes.FailedPrecondition, "invalid count")
+	}
+
+	conn, err := c.redis.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	date, err := ptypes.Timestamp(in.Date)
+	if err != nil {
+		return nil, err
+	}
+	date = date.UTC()
+
+	// See http/stats.go for the storage structure
+	key := "STATS_FILE"
+	switch in.Period {
+	case StatsTopRequest_DAY:
+		key += date.Format("_2006_01_02")
+	case StatsTopRequest_MONTH:
+		key += date.Format("_2006_01")
+	case StatsTopRequest_YEAR:
+		key += date.Format("_2006")
+	}
+
+	var re *regexp.Regexp
+	if in.Pattern != "" {
+		re, err = regexp.Compile(in.Pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats, err := database.FileDownloads(conn, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't fetch stats")
+	}
+
+	reply := &StatsTopReply{}
+	files := make([]*FileDownloads, 0, len(stats))
+	for path, downloads := range stats {
+		if re != nil && !re.MatchString(path) {
+			continue
+		}
+		reply.Total += downloads
+		files = append(files, &FileDownloads{
+			Path:      path,
+			Downloads: downloads,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Downloads == files[j].Downloads {
+			return files[i].Path < files[j].Path
+		}
+		return files[i].Downloads > files[j].Downloads
+	})
+
+	if len(files) > int(in.Count) {
+		files = files[:in.Count]
+	}
+	reply.Files = files
+
+	return reply, nil
+}
+
+func (c *CLI) StatsPrune(ctx context.Context, in *empty.Empty) (*StatsPruneReply, error) {
+	removed, err := c.redis.PruneStats()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't prune stats")
+	}
+	return &StatsPruneReply{
+		Removed: int64(removed),
+	}, nil
+}
+
 func (c *CLI) GetMirrorLogs(ctx context.Context, in *GetMirrorLogsRequest) (*GetMirrorLogsReply, error) {
 	if in.ID <= 0 {
 		return nil, status.Error(codes.FailedPrecondition, "invalid mirror id")
 	}
 
-	lines, err := mirrors.ReadLogs(c.redis, int(in.ID), int(in.MaxResults))
+	filter := mirrors.LogFilter{
+		Max:    int(in.MaxResults),
+		Cursor: in.Cursor,
+	}
+	if in.Since != nil {
+		since, err := ptypes.Timestamp(in.Since)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid since timestamp")
+		}
+		filter.Since = since
+	}
+	if in.Until != nil {
+		until, err := ptypes.Timestamp(in.Until)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid until timestamp")
+		}
+		filter.Until = until
+	}
+
+	lines, next, err := mirrors.ReadLogs(c.redis, int(in.ID), filter)
 	if err != nil {
 		return nil, errors.Wrap(err, "mirror logs error")
 	}
 
-	return &GetMirrorLogsReply{Line: lines}, nil
+	return &GetMirrorLogsReply{Line: lines, NextCursor: next}, nil
 }
@@ -26,6 +26,62 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type MirrorListRequest_Filter int32
+
+const (
+	MirrorListRequest_ANY MirrorListRequest_Filter = 0
+	MirrorListRequest_YES MirrorListRequest_Filter = 1
+	MirrorListRequest_NO  MirrorListRequest_Filter = 2
+)
+
+var MirrorListRequest_Filter_name = map[int32]string{
+	0: "ANY",
+	1: "YES",
+	2: "NO",
+}
+
+var MirrorListRequest_Filter_value = map[string]int32{
+	"ANY": 0,
+	"YES": 1,
+	"NO":  2,
+}
+
+func (x MirrorListRequest_Filter) String() string {
+	return proto.EnumName(MirrorListRequest_Filter_name, int32(x))
+}
+
+func (MirrorListRequest_Filter) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{5, 0}
+}
+
+type SimulateSelectionRequest_Protocol int32
+
+const (
+	SimulateSelectionRequest_ANY   SimulateSelectionRequest_Protocol = 0
+	SimulateSelectionRequest_HTTPS SimulateSelectionRequest_Protocol = 1
+	SimulateSelectionRequest_HTTP  SimulateSelectionRequest_Protocol = 2
+)
+
+var SimulateSelectionRequest_Protocol_name = map[int32]string{
+	0: "ANY",
+	1: "HTTPS",
+	2: "HTTP",
+}
+
+var SimulateSelectionRequest_Protocol_value = map[string]int32{
+	"ANY":   0,
+	"HTTPS": 1,
+	"HTTP":  2,
+}
+
+func (x SimulateSelectionRequest_Protocol) String() string {
+	return proto.EnumName(SimulateSelectionRequest_Protocol_name, int32(x))
+}
+
+func (SimulateSelectionRequest_Protocol) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{8, 0}
+}
+
 type ScanMirrorRequest_Method int32
 
 const (
@@ -51,7 +107,38 @@ func (x ScanMirrorRequest_Method) String() string {
 }
 
 func (ScanMirrorRequest_Method) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{12, 0}
+	return fileDescriptor_77a6da22d6a3feb1, []int{36, 0}
+}
+
+type StatsTopRequest_PeriodType int32
+
+const (
+	StatsTopRequest_DAY   StatsTopRequest_PeriodType = 0
+	StatsTopRequest_MONTH StatsTopRequest_PeriodType = 1
+	StatsTopRequest_YEAR  StatsTopRequest_PeriodType = 2
+	StatsTopRequest_ALL   StatsTopRequest_PeriodType = 3
+)
+
+var StatsTopRequest_PeriodType_name = map[int32]string{
+	0: "DAY",
+	1: "MONTH",
+	2: "YEAR",
+	3: "ALL",
+}
+
+var StatsTopRequest_PeriodType_value = map[string]int32{
+	"DAY":   0,
+	"MONTH": 1,
+	"YEAR":  2,
+	"ALL":   3,
+}
+
+func (x StatsTopRequest_PeriodType) String() string {
+	return proto.EnumName(StatsTopRequest_PeriodType_name, int32(x))
+}
+
+func (StatsTopRequest_PeriodType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{44, 0}
 }
 
 type VersionReply struct {
@@ -173,39 +260,58 @@ func (m *MatchRequest) GetPattern() string {
 }
 
 type Mirror struct {
-	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	Name                 string               `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
-	HttpURL              string               `protobuf:"bytes,3,opt,name=HttpURL,proto3" json:"HttpURL,omitempty"`
-	RsyncURL             string               `protobuf:"bytes,4,opt,name=RsyncURL,proto3" json:"RsyncURL,omitempty"`
-	FtpURL               string               `protobuf:"bytes,5,opt,name=FtpURL,proto3" json:"FtpURL,omitempty"`
-	SponsorName          string               `protobuf:"bytes,6,opt,name=SponsorName,proto3" json:"SponsorName,omitempty"`
-	SponsorURL           string               `protobuf:"bytes,7,opt,name=SponsorURL,proto3" json:"SponsorURL,omitempty"`
-	SponsorLogoURL       string               `protobuf:"bytes,8,opt,name=SponsorLogoURL,proto3" json:"SponsorLogoURL,omitempty"`
-	AdminName            string               `protobuf:"bytes,9,opt,name=AdminName,proto3" json:"AdminName,omitempty"`
-	AdminEmail           string               `protobuf:"bytes,10,opt,name=AdminEmail,proto3" json:"AdminEmail,omitempty"`
-	CustomData           string               `protobuf:"bytes,11,opt,name=CustomData,proto3" json:"CustomData,omitempty"`
-	ContinentOnly        bool                 `protobuf:"varint,12,opt,name=ContinentOnly,proto3" json:"ContinentOnly,omitempty"`
-	CountryOnly          bool                 `protobuf:"varint,13,opt,name=CountryOnly,proto3" json:"CountryOnly,omitempty"`
-	ASOnly               bool                 `protobuf:"varint,14,opt,name=ASOnly,proto3" json:"ASOnly,omitempty"`
-	Score                int32                `protobuf:"varint,15,opt,name=Score,proto3" json:"Score,omitempty"`
-	Latitude             float32              `protobuf:"fixed32,16,opt,name=Latitude,proto3" json:"Latitude,omitempty"`
-	Longitude            float32              `protobuf:"fixed32,17,opt,name=Longitude,proto3" json:"Longitude,omitempty"`
-	ContinentCode        string               `protobuf:"bytes,18,opt,name=ContinentCode,proto3" json:"ContinentCode,omitempty"`
-	CountryCodes         string               `protobuf:"bytes,19,opt,name=CountryCodes,proto3" json:"CountryCodes,omitempty"`
-	ExcludedCountryCodes string               `protobuf:"bytes,20,opt,name=ExcludedCountryCodes,proto3" json:"ExcludedCountryCodes,omitempty"`
-	Asnum                uint32               `protobuf:"varint,21,opt,name=Asnum,proto3" json:"Asnum,omitempty"`
-	Comment              string               `protobuf:"bytes,22,opt,name=Comment,proto3" json:"Comment,omitempty"`
-	Enabled              bool                 `protobuf:"varint,23,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
-	Up                   bool                 `protobuf:"varint,24,opt,name=Up,proto3" json:"Up,omitempty"`
-	ExcludeReason        string               `protobuf:"bytes,25,opt,name=ExcludeReason,proto3" json:"ExcludeReason,omitempty"`
-	StateSince           *timestamp.Timestamp `protobuf:"bytes,26,opt,name=StateSince,proto3" json:"StateSince,omitempty"`
-	AllowRedirects       int32                `protobuf:"varint,27,opt,name=AllowRedirects,proto3" json:"AllowRedirects,omitempty"`
-	LastSync             *timestamp.Timestamp `protobuf:"bytes,28,opt,name=LastSync,proto3" json:"LastSync,omitempty"`
-	LastSuccessfulSync   *timestamp.Timestamp `protobuf:"bytes,29,opt,name=LastSuccessfulSync,proto3" json:"LastSuccessfulSync,omitempty"`
-	LastModTime          *timestamp.Timestamp `protobuf:"bytes,30,opt,name=LastModTime,proto3" json:"LastModTime,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	ID                          int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Name                        string               `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	HttpURL                     string               `protobuf:"bytes,3,opt,name=HttpURL,proto3" json:"HttpURL,omitempty"`
+	RsyncURL                    string               `protobuf:"bytes,4,opt,name=RsyncURL,proto3" json:"RsyncURL,omitempty"`
+	FtpURL                      string               `protobuf:"bytes,5,opt,name=FtpURL,proto3" json:"FtpURL,omitempty"`
+	SponsorName                 string               `protobuf:"bytes,6,opt,name=SponsorName,proto3" json:"SponsorName,omitempty"`
+	SponsorURL                  string               `protobuf:"bytes,7,opt,name=SponsorURL,proto3" json:"SponsorURL,omitempty"`
+	SponsorLogoURL              string               `protobuf:"bytes,8,opt,name=SponsorLogoURL,proto3" json:"SponsorLogoURL,omitempty"`
+	AdminName                   string               `protobuf:"bytes,9,opt,name=AdminName,proto3" json:"AdminName,omitempty"`
+	AdminEmail                  string               `protobuf:"bytes,10,opt,name=AdminEmail,proto3" json:"AdminEmail,omitempty"`
+	CustomData                  string               `protobuf:"bytes,11,opt,name=CustomData,proto3" json:"CustomData,omitempty"`
+	ContinentOnly               bool                 `protobuf:"varint,12,opt,name=ContinentOnly,proto3" json:"ContinentOnly,omitempty"`
+	CountryOnly                 bool                 `protobuf:"varint,13,opt,name=CountryOnly,proto3" json:"CountryOnly,omitempty"`
+	ASOnly                      bool                 `protobuf:"varint,14,opt,name=ASOnly,proto3" json:"ASOnly,omitempty"`
+	Score                       int32                `protobuf:"varint,15,opt,name=Score,proto3" json:"Score,omitempty"`
+	Latitude                    float32              `protobuf:"fixed32,16,opt,name=Latitude,proto3" json:"Latitude,omitempty"`
+	Longitude                   float32              `protobuf:"fixed32,17,opt,name=Longitude,proto3" json:"Longitude,omitempty"`
+	ContinentCode               string               `protobuf:"bytes,18,opt,name=ContinentCode,proto3" json:"ContinentCode,omitempty"`
+	CountryCodes                string               `protobuf:"bytes,19,opt,name=CountryCodes,proto3" json:"CountryCodes,omitempty"`
+	ExcludedCountryCodes        string               `protobuf:"bytes,20,opt,name=ExcludedCountryCodes,proto3" json:"ExcludedCountryCodes,omitempty"`
+	Asnum                       uint32               `protobuf:"varint,21,opt,name=Asnum,proto3" json:"Asnum,omitempty"`
+	Comment                     string               `protobuf:"bytes,22,opt,name=Comment,proto3" json:"Comment,omitempty"`
+	Enabled                     bool                 `protobuf:"varint,23,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
+	Up                          bool                 `protobuf:"varint,24,opt,name=Up,proto3" json:"Up,omitempty"`
+	ExcludeReason               string               `protobuf:"bytes,25,opt,name=ExcludeReason,proto3" json:"ExcludeReason,omitempty"`
+	StateSince                  *timestamp.Timestamp `protobuf:"bytes,26,opt,name=StateSince,proto3" json:"StateSince,omitempty"`
+	AllowRedirects              int32                `protobuf:"varint,27,opt,name=AllowRedirects,proto3" json:"AllowRedirects,omitempty"`
+	LastSync                    *timestamp.Timestamp `protobuf:"bytes,28,opt,name=LastSync,proto3" json:"LastSync,omitempty"`
+	LastSuccessfulSync          *timestamp.Timestamp `protobuf:"bytes,29,opt,name=LastSuccessfulSync,proto3" json:"LastSuccessfulSync,omitempty"`
+	LastModTime                 *timestamp.Timestamp `protobuf:"bytes,30,opt,name=LastModTime,proto3" json:"LastModTime,omitempty"`
+	LastSuccessfulSyncProtocol  int32                `protobuf:"varint,31,opt,name=LastSuccessfulSyncProtocol,proto3" json:"LastSuccessfulSyncProtocol,omitempty"`
+	LastSuccessfulSyncPrecision int64                `protobuf:"varint,32,opt,name=LastSuccessfulSyncPrecision,proto3" json:"LastSuccessfulSyncPrecision,omitempty"`
+	ModTimePrecision            int64                `protobuf:"varint,33,opt,name=ModTimePrecision,proto3" json:"ModTimePrecision,omitempty"`
+	IntegrityFailed             bool                 `protobuf:"varint,34,opt,name=IntegrityFailed,proto3" json:"IntegrityFailed,omitempty"`
+	HealthCheckPath             string               `protobuf:"bytes,35,opt,name=HealthCheckPath,proto3" json:"HealthCheckPath,omitempty"`
+	HealthCheckCodes            string               `protobuf:"bytes,36,opt,name=HealthCheckCodes,proto3" json:"HealthCheckCodes,omitempty"`
+	Note                        string               `protobuf:"bytes,37,opt,name=Note,proto3" json:"Note,omitempty"`
+	NodeHealth                  []*NodeHealth        `protobuf:"bytes,38,rep,name=NodeHealth,proto3" json:"NodeHealth,omitempty"`
+	Tags                        string               `protobuf:"bytes,39,opt,name=Tags,proto3" json:"Tags,omitempty"`
+	Maintenance                 string               `protobuf:"bytes,40,opt,name=Maintenance,proto3" json:"Maintenance,omitempty"`
+	MaintenanceUntil            *timestamp.Timestamp `protobuf:"bytes,41,opt,name=MaintenanceUntil,proto3" json:"MaintenanceUntil,omitempty"`
+	HostHeader                  string               `protobuf:"bytes,42,opt,name=HostHeader,proto3" json:"HostHeader,omitempty"`
+	DisabledReason              string               `protobuf:"bytes,43,opt,name=DisabledReason,proto3" json:"DisabledReason,omitempty"`
+	DisabledUntil               *timestamp.Timestamp `protobuf:"bytes,44,opt,name=DisabledUntil,proto3" json:"DisabledUntil,omitempty"`
+	ScanInterval                int32                `protobuf:"varint,45,opt,name=ScanInterval,proto3" json:"ScanInterval,omitempty"`
+	RsyncBandwidthLimit         int32                `protobuf:"varint,46,opt,name=RsyncBandwidthLimit,proto3" json:"RsyncBandwidthLimit,omitempty"`
+	FtpConnections              int32                `protobuf:"varint,47,opt,name=FtpConnections,proto3" json:"FtpConnections,omitempty"`
+	AltURLs                     []*AltURL            `protobuf:"bytes,48,rep,name=AltURLs,proto3" json:"AltURLs,omitempty"`
+	AdditionalServedCountries   string               `protobuf:"bytes,49,opt,name=AdditionalServedCountries,proto3" json:"AdditionalServedCountries,omitempty"`
+	XXX_NoUnkeyedLiteral        struct{}             `json:"-"`
+	XXX_unrecognized            []byte               `json:"-"`
+	XXX_sizecache               int32                `json:"-"`
 }
 
 func (m *Mirror) Reset()         { *m = Mirror{} }
@@ -443,218 +549,1874 @@ func (m *Mirror) GetLastModTime() *timestamp.Timestamp {
 	return nil
 }
 
-type MirrorListReply struct {
-	Mirrors              []*Mirror `protobuf:"bytes,1,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+func (m *Mirror) GetLastSuccessfulSyncProtocol() int32 {
+	if m != nil {
+		return m.LastSuccessfulSyncProtocol
+	}
+	return 0
 }
 
-func (m *MirrorListReply) Reset()         { *m = MirrorListReply{} }
-func (m *MirrorListReply) String() string { return proto.CompactTextString(m) }
-func (*MirrorListReply) ProtoMessage()    {}
-func (*MirrorListReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{3}
+func (m *Mirror) GetLastSuccessfulSyncPrecision() int64 {
+	if m != nil {
+		return m.LastSuccessfulSyncPrecision
+	}
+	return 0
 }
 
-func (m *MirrorListReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MirrorListReply.Unmarshal(m, b)
-}
-func (m *MirrorListReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MirrorListReply.Marshal(b, m, deterministic)
+func (m *Mirror) GetModTimePrecision() int64 {
+	if m != nil {
+		return m.ModTimePrecision
+	}
+	return 0
 }
-func (m *MirrorListReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MirrorListReply.Merge(m, src)
+
+func (m *Mirror) GetIntegrityFailed() bool {
+	if m != nil {
+		return m.IntegrityFailed
+	}
+	return false
 }
-func (m *MirrorListReply) XXX_Size() int {
-	return xxx_messageInfo_MirrorListReply.Size(m)
+
+func (m *Mirror) GetHealthCheckPath() string {
+	if m != nil {
+		return m.HealthCheckPath
+	}
+	return ""
 }
-func (m *MirrorListReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_MirrorListReply.DiscardUnknown(m)
+
+func (m *Mirror) GetHealthCheckCodes() string {
+	if m != nil {
+		return m.HealthCheckCodes
+	}
+	return ""
 }
 
-var xxx_messageInfo_MirrorListReply proto.InternalMessageInfo
+func (m *Mirror) GetNote() string {
+	if m != nil {
+		return m.Note
+	}
+	return ""
+}
 
-func (m *MirrorListReply) GetMirrors() []*Mirror {
+func (m *Mirror) GetNodeHealth() []*NodeHealth {
 	if m != nil {
-		return m.Mirrors
+		return m.NodeHealth
 	}
 	return nil
 }
 
-type MirrorID struct {
-	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	Name                 string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *Mirror) GetTags() string {
+	if m != nil {
+		return m.Tags
+	}
+	return ""
 }
 
-func (m *MirrorID) Reset()         { *m = MirrorID{} }
-func (m *MirrorID) String() string { return proto.CompactTextString(m) }
-func (*MirrorID) ProtoMessage()    {}
-func (*MirrorID) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{4}
+func (m *Mirror) GetMaintenance() string {
+	if m != nil {
+		return m.Maintenance
+	}
+	return ""
 }
 
-func (m *MirrorID) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MirrorID.Unmarshal(m, b)
+func (m *Mirror) GetMaintenanceUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.MaintenanceUntil
+	}
+	return nil
 }
-func (m *MirrorID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MirrorID.Marshal(b, m, deterministic)
+
+func (m *Mirror) GetHostHeader() string {
+	if m != nil {
+		return m.HostHeader
+	}
+	return ""
 }
-func (m *MirrorID) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MirrorID.Merge(m, src)
+
+func (m *Mirror) GetDisabledReason() string {
+	if m != nil {
+		return m.DisabledReason
+	}
+	return ""
 }
-func (m *MirrorID) XXX_Size() int {
-	return xxx_messageInfo_MirrorID.Size(m)
+
+func (m *Mirror) GetDisabledUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.DisabledUntil
+	}
+	return nil
 }
-func (m *MirrorID) XXX_DiscardUnknown() {
-	xxx_messageInfo_MirrorID.DiscardUnknown(m)
+
+func (m *Mirror) GetScanInterval() int32 {
+	if m != nil {
+		return m.ScanInterval
+	}
+	return 0
 }
 
-var xxx_messageInfo_MirrorID proto.InternalMessageInfo
+func (m *Mirror) GetRsyncBandwidthLimit() int32 {
+	if m != nil {
+		return m.RsyncBandwidthLimit
+	}
+	return 0
+}
 
-func (m *MirrorID) GetID() int32 {
+func (m *Mirror) GetFtpConnections() int32 {
 	if m != nil {
-		return m.ID
+		return m.FtpConnections
 	}
 	return 0
 }
 
-func (m *MirrorID) GetName() string {
+func (m *Mirror) GetAltURLs() []*AltURL {
 	if m != nil {
-		return m.Name
+		return m.AltURLs
+	}
+	return nil
+}
+
+func (m *Mirror) GetAdditionalServedCountries() string {
+	if m != nil {
+		return m.AdditionalServedCountries
 	}
 	return ""
 }
 
-type MatchReply struct {
-	Mirrors              []*MirrorID `protobuf:"bytes,1,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+type AltURL struct {
+	URL                  string   `protobuf:"bytes,1,opt,name=URL,proto3" json:"URL,omitempty"`
+	Latitude             float32  `protobuf:"fixed32,2,opt,name=Latitude,proto3" json:"Latitude,omitempty"`
+	Longitude            float32  `protobuf:"fixed32,3,opt,name=Longitude,proto3" json:"Longitude,omitempty"`
+	ContinentCode        string   `protobuf:"bytes,4,opt,name=ContinentCode,proto3" json:"ContinentCode,omitempty"`
+	CountryCodes         string   `protobuf:"bytes,5,opt,name=CountryCodes,proto3" json:"CountryCodes,omitempty"`
+	Asnum                uint32   `protobuf:"varint,6,opt,name=Asnum,proto3" json:"Asnum,omitempty"`
+	Down                 bool     `protobuf:"varint,7,opt,name=Down,proto3" json:"Down,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *MatchReply) Reset()         { *m = MatchReply{} }
-func (m *MatchReply) String() string { return proto.CompactTextString(m) }
-func (*MatchReply) ProtoMessage()    {}
-func (*MatchReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+func (m *AltURL) Reset()         { *m = AltURL{} }
+func (m *AltURL) String() string { return proto.CompactTextString(m) }
+func (*AltURL) ProtoMessage()    {}
+func (*AltURL) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{3}
 }
 
-func (m *MatchReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MatchReply.Unmarshal(m, b)
+func (m *AltURL) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AltURL.Unmarshal(m, b)
 }
-func (m *MatchReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MatchReply.Marshal(b, m, deterministic)
+func (m *AltURL) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AltURL.Marshal(b, m, deterministic)
 }
-func (m *MatchReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MatchReply.Merge(m, src)
+func (m *AltURL) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AltURL.Merge(m, src)
 }
-func (m *MatchReply) XXX_Size() int {
-	return xxx_messageInfo_MatchReply.Size(m)
+func (m *AltURL) XXX_Size() int {
+	return xxx_messageInfo_AltURL.Size(m)
 }
-func (m *MatchReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_MatchReply.DiscardUnknown(m)
+func (m *AltURL) XXX_DiscardUnknown() {
+	xxx_messageInfo_AltURL.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_MatchReply proto.InternalMessageInfo
+var xxx_messageInfo_AltURL proto.InternalMessageInfo
 
-func (m *MatchReply) GetMirrors() []*MirrorID {
+func (m *AltURL) GetURL() string {
 	if m != nil {
-		return m.Mirrors
+		return m.URL
 	}
-	return nil
+	return ""
 }
 
-type ChangeStatusRequest struct {
-	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	Enabled              bool     `protobuf:"varint,2,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *AltURL) GetLatitude() float32 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
 }
 
-func (m *ChangeStatusRequest) Reset()         { *m = ChangeStatusRequest{} }
-func (m *ChangeStatusRequest) String() string { return proto.CompactTextString(m) }
-func (*ChangeStatusRequest) ProtoMessage()    {}
-func (*ChangeStatusRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{6}
+func (m *AltURL) GetLongitude() float32 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
 }
 
-func (m *ChangeStatusRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ChangeStatusRequest.Unmarshal(m, b)
-}
-func (m *ChangeStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ChangeStatusRequest.Marshal(b, m, deterministic)
-}
-func (m *ChangeStatusRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ChangeStatusRequest.Merge(m, src)
-}
-func (m *ChangeStatusRequest) XXX_Size() int {
-	return xxx_messageInfo_ChangeStatusRequest.Size(m)
-}
-func (m *ChangeStatusRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_ChangeStatusRequest.DiscardUnknown(m)
+func (m *AltURL) GetContinentCode() string {
+	if m != nil {
+		return m.ContinentCode
+	}
+	return ""
 }
 
-var xxx_messageInfo_ChangeStatusRequest proto.InternalMessageInfo
+func (m *AltURL) GetCountryCodes() string {
+	if m != nil {
+		return m.CountryCodes
+	}
+	return ""
+}
 
-func (m *ChangeStatusRequest) GetID() int32 {
+func (m *AltURL) GetAsnum() uint32 {
 	if m != nil {
-		return m.ID
+		return m.Asnum
 	}
 	return 0
 }
 
-func (m *ChangeStatusRequest) GetEnabled() bool {
+func (m *AltURL) GetDown() bool {
 	if m != nil {
-		return m.Enabled
+		return m.Down
 	}
 	return false
 }
 
-type MirrorIDRequest struct {
-	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+type NodeHealth struct {
+	Node                 string               `protobuf:"bytes,1,opt,name=Node,proto3" json:"Node,omitempty"`
+	Up                   bool                 `protobuf:"varint,2,opt,name=Up,proto3" json:"Up,omitempty"`
+	Reason               string               `protobuf:"bytes,3,opt,name=Reason,proto3" json:"Reason,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,4,opt,name=Time,proto3" json:"Time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
-func (m *MirrorIDRequest) Reset()         { *m = MirrorIDRequest{} }
-func (m *MirrorIDRequest) String() string { return proto.CompactTextString(m) }
-func (*MirrorIDRequest) ProtoMessage()    {}
-func (*MirrorIDRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+func (m *NodeHealth) Reset()         { *m = NodeHealth{} }
+func (m *NodeHealth) String() string { return proto.CompactTextString(m) }
+func (*NodeHealth) ProtoMessage()    {}
+func (*NodeHealth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{4}
 }
 
-func (m *MirrorIDRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MirrorIDRequest.Unmarshal(m, b)
+func (m *NodeHealth) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeHealth.Unmarshal(m, b)
 }
-func (m *MirrorIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MirrorIDRequest.Marshal(b, m, deterministic)
+func (m *NodeHealth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeHealth.Marshal(b, m, deterministic)
 }
-func (m *MirrorIDRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MirrorIDRequest.Merge(m, src)
+func (m *NodeHealth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeHealth.Merge(m, src)
 }
-func (m *MirrorIDRequest) XXX_Size() int {
-	return xxx_messageInfo_MirrorIDRequest.Size(m)
+func (m *NodeHealth) XXX_Size() int {
+	return xxx_messageInfo_NodeHealth.Size(m)
 }
-func (m *MirrorIDRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_MirrorIDRequest.DiscardUnknown(m)
+func (m *NodeHealth) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeHealth.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_MirrorIDRequest proto.InternalMessageInfo
+var xxx_messageInfo_NodeHealth proto.InternalMessageInfo
 
-func (m *MirrorIDRequest) GetID() int32 {
+func (m *NodeHealth) GetNode() string {
 	if m != nil {
-		return m.ID
+		return m.Node
 	}
-	return 0
+	return ""
 }
 
-type AddMirrorReply struct {
+func (m *NodeHealth) GetUp() bool {
+	if m != nil {
+		return m.Up
+	}
+	return false
+}
+
+func (m *NodeHealth) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *NodeHealth) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+type MirrorListRequest struct {
+	PageSize             int32                    `protobuf:"varint,1,opt,name=PageSize,proto3" json:"PageSize,omitempty"`
+	PageToken            string                   `protobuf:"bytes,2,opt,name=PageToken,proto3" json:"PageToken,omitempty"`
+	Fields               []string                 `protobuf:"bytes,3,rep,name=Fields,proto3" json:"Fields,omitempty"`
+	Enabled              MirrorListRequest_Filter `protobuf:"varint,4,opt,name=Enabled,proto3,enum=MirrorListRequest_Filter" json:"Enabled,omitempty"`
+	Up                   MirrorListRequest_Filter `protobuf:"varint,5,opt,name=Up,proto3,enum=MirrorListRequest_Filter" json:"Up,omitempty"`
+	Country              string                   `protobuf:"bytes,6,opt,name=Country,proto3" json:"Country,omitempty"`
+	Tag                  string                   `protobuf:"bytes,7,opt,name=Tag,proto3" json:"Tag,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *MirrorListRequest) Reset()         { *m = MirrorListRequest{} }
+func (m *MirrorListRequest) String() string { return proto.CompactTextString(m) }
+func (*MirrorListRequest) ProtoMessage()    {}
+func (*MirrorListRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+}
+
+func (m *MirrorListRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MirrorListRequest.Unmarshal(m, b)
+}
+func (m *MirrorListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MirrorListRequest.Marshal(b, m, deterministic)
+}
+func (m *MirrorListRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MirrorListRequest.Merge(m, src)
+}
+func (m *MirrorListRequest) XXX_Size() int {
+	return xxx_messageInfo_MirrorListRequest.Size(m)
+}
+func (m *MirrorListRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MirrorListRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MirrorListRequest proto.InternalMessageInfo
+
+func (m *MirrorListRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *MirrorListRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *MirrorListRequest) GetFields() []string {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *MirrorListRequest) GetEnabled() MirrorListRequest_Filter {
+	if m != nil {
+		return m.Enabled
+	}
+	return MirrorListRequest_ANY
+}
+
+func (m *MirrorListRequest) GetUp() MirrorListRequest_Filter {
+	if m != nil {
+		return m.Up
+	}
+	return MirrorListRequest_ANY
+}
+
+func (m *MirrorListRequest) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *MirrorListRequest) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+type MirrorListReply struct {
+	Mirrors              []*Mirror `protobuf:"bytes,1,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	NextPageToken        string    `protobuf:"bytes,2,opt,name=NextPageToken,proto3" json:"NextPageToken,omitempty"`
+	Total                int32     `protobuf:"varint,3,opt,name=Total,proto3" json:"Total,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *MirrorListReply) Reset()         { *m = MirrorListReply{} }
+func (m *MirrorListReply) String() string { return proto.CompactTextString(m) }
+func (*MirrorListReply) ProtoMessage()    {}
+func (*MirrorListReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{6}
+}
+
+func (m *MirrorListReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MirrorListReply.Unmarshal(m, b)
+}
+func (m *MirrorListReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MirrorListReply.Marshal(b, m, deterministic)
+}
+func (m *MirrorListReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MirrorListReply.Merge(m, src)
+}
+func (m *MirrorListReply) XXX_Size() int {
+	return xxx_messageInfo_MirrorListReply.Size(m)
+}
+func (m *MirrorListReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MirrorListReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MirrorListReply proto.InternalMessageInfo
+
+func (m *MirrorListReply) GetMirrors() []*Mirror {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+func (m *MirrorListReply) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+func (m *MirrorListReply) GetTotal() int32 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+type MirrorID struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MirrorID) Reset()         { *m = MirrorID{} }
+func (m *MirrorID) String() string { return proto.CompactTextString(m) }
+func (*MirrorID) ProtoMessage()    {}
+func (*MirrorID) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+}
+
+func (m *MirrorID) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MirrorID.Unmarshal(m, b)
+}
+func (m *MirrorID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MirrorID.Marshal(b, m, deterministic)
+}
+func (m *MirrorID) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MirrorID.Merge(m, src)
+}
+func (m *MirrorID) XXX_Size() int {
+	return xxx_messageInfo_MirrorID.Size(m)
+}
+func (m *MirrorID) XXX_DiscardUnknown() {
+	xxx_messageInfo_MirrorID.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MirrorID proto.InternalMessageInfo
+
+func (m *MirrorID) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *MirrorID) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type SimulateSelectionRequest struct {
+	Path                 string                            `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	IP                   string                            `protobuf:"bytes,2,opt,name=IP,proto3" json:"IP,omitempty"`
+	Country              string                            `protobuf:"bytes,3,opt,name=Country,proto3" json:"Country,omitempty"`
+	Secure               SimulateSelectionRequest_Protocol `protobuf:"varint,4,opt,name=Secure,proto3,enum=SimulateSelectionRequest_Protocol" json:"Secure,omitempty"`
+	Fallback             bool                              `protobuf:"varint,5,opt,name=Fallback,proto3" json:"Fallback,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
+	XXX_unrecognized     []byte                            `json:"-"`
+	XXX_sizecache        int32                             `json:"-"`
+}
+
+func (m *SimulateSelectionRequest) Reset()         { *m = SimulateSelectionRequest{} }
+func (m *SimulateSelectionRequest) String() string { return proto.CompactTextString(m) }
+func (*SimulateSelectionRequest) ProtoMessage()    {}
+func (*SimulateSelectionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+}
+
+func (m *SimulateSelectionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SimulateSelectionRequest.Unmarshal(m, b)
+}
+func (m *SimulateSelectionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SimulateSelectionRequest.Marshal(b, m, deterministic)
+}
+func (m *SimulateSelectionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SimulateSelectionRequest.Merge(m, src)
+}
+func (m *SimulateSelectionRequest) XXX_Size() int {
+	return xxx_messageInfo_SimulateSelectionRequest.Size(m)
+}
+func (m *SimulateSelectionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SimulateSelectionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SimulateSelectionRequest proto.InternalMessageInfo
+
+func (m *SimulateSelectionRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *SimulateSelectionRequest) GetIP() string {
+	if m != nil {
+		return m.IP
+	}
+	return ""
+}
+
+func (m *SimulateSelectionRequest) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *SimulateSelectionRequest) GetSecure() SimulateSelectionRequest_Protocol {
+	if m != nil {
+		return m.Secure
+	}
+	return SimulateSelectionRequest_ANY
+}
+
+func (m *SimulateSelectionRequest) GetFallback() bool {
+	if m != nil {
+		return m.Fallback
+	}
+	return false
+}
+
+type SelectedMirror struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	HttpURL              string   `protobuf:"bytes,3,opt,name=HttpURL,proto3" json:"HttpURL,omitempty"`
+	CountryCodes         string   `protobuf:"bytes,4,opt,name=CountryCodes,proto3" json:"CountryCodes,omitempty"`
+	ContinentCode        string   `protobuf:"bytes,5,opt,name=ContinentCode,proto3" json:"ContinentCode,omitempty"`
+	Asnum                uint32   `protobuf:"varint,6,opt,name=Asnum,proto3" json:"Asnum,omitempty"`
+	Distance             float32  `protobuf:"fixed32,7,opt,name=Distance,proto3" json:"Distance,omitempty"`
+	Weight               float32  `protobuf:"fixed32,8,opt,name=Weight,proto3" json:"Weight,omitempty"`
+	ComputedScore        int32    `protobuf:"varint,9,opt,name=ComputedScore,proto3" json:"ComputedScore,omitempty"`
+	ExcludeReason        string   `protobuf:"bytes,10,opt,name=ExcludeReason,proto3" json:"ExcludeReason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SelectedMirror) Reset()         { *m = SelectedMirror{} }
+func (m *SelectedMirror) String() string { return proto.CompactTextString(m) }
+func (*SelectedMirror) ProtoMessage()    {}
+func (*SelectedMirror) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
+}
+
+func (m *SelectedMirror) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SelectedMirror.Unmarshal(m, b)
+}
+func (m *SelectedMirror) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SelectedMirror.Marshal(b, m, deterministic)
+}
+func (m *SelectedMirror) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SelectedMirror.Merge(m, src)
+}
+func (m *SelectedMirror) XXX_Size() int {
+	return xxx_messageInfo_SelectedMirror.Size(m)
+}
+func (m *SelectedMirror) XXX_DiscardUnknown() {
+	xxx_messageInfo_SelectedMirror.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SelectedMirror proto.InternalMessageInfo
+
+func (m *SelectedMirror) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *SelectedMirror) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SelectedMirror) GetHttpURL() string {
+	if m != nil {
+		return m.HttpURL
+	}
+	return ""
+}
+
+func (m *SelectedMirror) GetCountryCodes() string {
+	if m != nil {
+		return m.CountryCodes
+	}
+	return ""
+}
+
+func (m *SelectedMirror) GetContinentCode() string {
+	if m != nil {
+		return m.ContinentCode
+	}
+	return ""
+}
+
+func (m *SelectedMirror) GetAsnum() uint32 {
+	if m != nil {
+		return m.Asnum
+	}
+	return 0
+}
+
+func (m *SelectedMirror) GetDistance() float32 {
+	if m != nil {
+		return m.Distance
+	}
+	return 0
+}
+
+func (m *SelectedMirror) GetWeight() float32 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *SelectedMirror) GetComputedScore() int32 {
+	if m != nil {
+		return m.ComputedScore
+	}
+	return 0
+}
+
+func (m *SelectedMirror) GetExcludeReason() string {
+	if m != nil {
+		return m.ExcludeReason
+	}
+	return ""
+}
+
+type SimulateSelectionReply struct {
+	Path                 string               `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Size                 int64                `protobuf:"varint,2,opt,name=Size,proto3" json:"Size,omitempty"`
+	ModTime              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=ModTime,proto3" json:"ModTime,omitempty"`
+	Country              string               `protobuf:"bytes,4,opt,name=Country,proto3" json:"Country,omitempty"`
+	Continent            string               `protobuf:"bytes,5,opt,name=Continent,proto3" json:"Continent,omitempty"`
+	City                 string               `protobuf:"bytes,6,opt,name=City,proto3" json:"City,omitempty"`
+	ASN                  string               `protobuf:"bytes,7,opt,name=ASN,proto3" json:"ASN,omitempty"`
+	Latitude             float32              `protobuf:"fixed32,8,opt,name=Latitude,proto3" json:"Latitude,omitempty"`
+	Longitude            float32              `protobuf:"fixed32,9,opt,name=Longitude,proto3" json:"Longitude,omitempty"`
+	Fallback             bool                 `protobuf:"varint,10,opt,name=Fallback,proto3" json:"Fallback,omitempty"`
+	Mirrors              []*SelectedMirror    `protobuf:"bytes,11,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	Excluded             []*SelectedMirror    `protobuf:"bytes,12,rep,name=Excluded,proto3" json:"Excluded,omitempty"`
+	Warnings             []string             `protobuf:"bytes,13,rep,name=Warnings,proto3" json:"Warnings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *SimulateSelectionReply) Reset()         { *m = SimulateSelectionReply{} }
+func (m *SimulateSelectionReply) String() string { return proto.CompactTextString(m) }
+func (*SimulateSelectionReply) ProtoMessage()    {}
+func (*SimulateSelectionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{10}
+}
+
+func (m *SimulateSelectionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SimulateSelectionReply.Unmarshal(m, b)
+}
+func (m *SimulateSelectionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SimulateSelectionReply.Marshal(b, m, deterministic)
+}
+func (m *SimulateSelectionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SimulateSelectionReply.Merge(m, src)
+}
+func (m *SimulateSelectionReply) XXX_Size() int {
+	return xxx_messageInfo_SimulateSelectionReply.Size(m)
+}
+func (m *SimulateSelectionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SimulateSelectionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SimulateSelectionReply proto.InternalMessageInfo
+
+func (m *SimulateSelectionReply) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *SimulateSelectionReply) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *SimulateSelectionReply) GetModTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.ModTime
+	}
+	return nil
+}
+
+func (m *SimulateSelectionReply) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *SimulateSelectionReply) GetContinent() string {
+	if m != nil {
+		return m.Continent
+	}
+	return ""
+}
+
+func (m *SimulateSelectionReply) GetCity() string {
+	if m != nil {
+		return m.City
+	}
+	return ""
+}
+
+func (m *SimulateSelectionReply) GetASN() string {
+	if m != nil {
+		return m.ASN
+	}
+	return ""
+}
+
+func (m *SimulateSelectionReply) GetLatitude() float32 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *SimulateSelectionReply) GetLongitude() float32 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+func (m *SimulateSelectionReply) GetFallback() bool {
+	if m != nil {
+		return m.Fallback
+	}
+	return false
+}
+
+func (m *SimulateSelectionReply) GetMirrors() []*SelectedMirror {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+func (m *SimulateSelectionReply) GetExcluded() []*SelectedMirror {
+	if m != nil {
+		return m.Excluded
+	}
+	return nil
+}
+
+func (m *SimulateSelectionReply) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+type HypotheticalMirror struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Latitude             float32  `protobuf:"fixed32,2,opt,name=Latitude,proto3" json:"Latitude,omitempty"`
+	Longitude            float32  `protobuf:"fixed32,3,opt,name=Longitude,proto3" json:"Longitude,omitempty"`
+	CountryCode          string   `protobuf:"bytes,4,opt,name=CountryCode,proto3" json:"CountryCode,omitempty"`
+	ContinentCode        string   `protobuf:"bytes,5,opt,name=ContinentCode,proto3" json:"ContinentCode,omitempty"`
+	Asnum                uint32   `protobuf:"varint,6,opt,name=Asnum,proto3" json:"Asnum,omitempty"`
+	Score                int32    `protobuf:"varint,7,opt,name=Score,proto3" json:"Score,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HypotheticalMirror) Reset()         { *m = HypotheticalMirror{} }
+func (m *HypotheticalMirror) String() string { return proto.CompactTextString(m) }
+func (*HypotheticalMirror) ProtoMessage()    {}
+func (*HypotheticalMirror) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{11}
+}
+
+func (m *HypotheticalMirror) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HypotheticalMirror.Unmarshal(m, b)
+}
+func (m *HypotheticalMirror) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HypotheticalMirror.Marshal(b, m, deterministic)
+}
+func (m *HypotheticalMirror) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HypotheticalMirror.Merge(m, src)
+}
+func (m *HypotheticalMirror) XXX_Size() int {
+	return xxx_messageInfo_HypotheticalMirror.Size(m)
+}
+func (m *HypotheticalMirror) XXX_DiscardUnknown() {
+	xxx_messageInfo_HypotheticalMirror.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HypotheticalMirror proto.InternalMessageInfo
+
+func (m *HypotheticalMirror) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *HypotheticalMirror) GetLatitude() float32 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *HypotheticalMirror) GetLongitude() float32 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+func (m *HypotheticalMirror) GetCountryCode() string {
+	if m != nil {
+		return m.CountryCode
+	}
+	return ""
+}
+
+func (m *HypotheticalMirror) GetContinentCode() string {
+	if m != nil {
+		return m.ContinentCode
+	}
+	return ""
+}
+
+func (m *HypotheticalMirror) GetAsnum() uint32 {
+	if m != nil {
+		return m.Asnum
+	}
+	return 0
+}
+
+func (m *HypotheticalMirror) GetScore() int32 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+type SimulateTrafficRequest struct {
+	Disable              []int32               `protobuf:"varint,1,rep,packed,name=Disable,proto3" json:"Disable,omitempty"`
+	Add                  []*HypotheticalMirror `protobuf:"bytes,2,rep,name=Add,proto3" json:"Add,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *SimulateTrafficRequest) Reset()         { *m = SimulateTrafficRequest{} }
+func (m *SimulateTrafficRequest) String() string { return proto.CompactTextString(m) }
+func (*SimulateTrafficRequest) ProtoMessage()    {}
+func (*SimulateTrafficRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{12}
+}
+
+func (m *SimulateTrafficRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SimulateTrafficRequest.Unmarshal(m, b)
+}
+func (m *SimulateTrafficRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SimulateTrafficRequest.Marshal(b, m, deterministic)
+}
+func (m *SimulateTrafficRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SimulateTrafficRequest.Merge(m, src)
+}
+func (m *SimulateTrafficRequest) XXX_Size() int {
+	return xxx_messageInfo_SimulateTrafficRequest.Size(m)
+}
+func (m *SimulateTrafficRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SimulateTrafficRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SimulateTrafficRequest proto.InternalMessageInfo
+
+func (m *SimulateTrafficRequest) GetDisable() []int32 {
+	if m != nil {
+		return m.Disable
+	}
+	return nil
+}
+
+func (m *SimulateTrafficRequest) GetAdd() []*HypotheticalMirror {
+	if m != nil {
+		return m.Add
+	}
+	return nil
+}
+
+type TrafficShare struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Before               float32  `protobuf:"fixed32,3,opt,name=Before,proto3" json:"Before,omitempty"`
+	After                float32  `protobuf:"fixed32,4,opt,name=After,proto3" json:"After,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrafficShare) Reset()         { *m = TrafficShare{} }
+func (m *TrafficShare) String() string { return proto.CompactTextString(m) }
+func (*TrafficShare) ProtoMessage()    {}
+func (*TrafficShare) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{13}
+}
+
+func (m *TrafficShare) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrafficShare.Unmarshal(m, b)
+}
+func (m *TrafficShare) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrafficShare.Marshal(b, m, deterministic)
+}
+func (m *TrafficShare) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrafficShare.Merge(m, src)
+}
+func (m *TrafficShare) XXX_Size() int {
+	return xxx_messageInfo_TrafficShare.Size(m)
+}
+func (m *TrafficShare) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrafficShare.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrafficShare proto.InternalMessageInfo
+
+func (m *TrafficShare) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *TrafficShare) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TrafficShare) GetBefore() float32 {
+	if m != nil {
+		return m.Before
+	}
+	return 0
+}
+
+func (m *TrafficShare) GetAfter() float32 {
+	if m != nil {
+		return m.After
+	}
+	return 0
+}
+
+type SimulateTrafficReply struct {
+	Samples              int32           `protobuf:"varint,1,opt,name=Samples,proto3" json:"Samples,omitempty"`
+	Mirrors              []*TrafficShare `protobuf:"bytes,2,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	UnservedBefore       float32         `protobuf:"fixed32,3,opt,name=UnservedBefore,proto3" json:"UnservedBefore,omitempty"`
+	UnservedAfter        float32         `protobuf:"fixed32,4,opt,name=UnservedAfter,proto3" json:"UnservedAfter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *SimulateTrafficReply) Reset()         { *m = SimulateTrafficReply{} }
+func (m *SimulateTrafficReply) String() string { return proto.CompactTextString(m) }
+func (*SimulateTrafficReply) ProtoMessage()    {}
+func (*SimulateTrafficReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{14}
+}
+
+func (m *SimulateTrafficReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SimulateTrafficReply.Unmarshal(m, b)
+}
+func (m *SimulateTrafficReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SimulateTrafficReply.Marshal(b, m, deterministic)
+}
+func (m *SimulateTrafficReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SimulateTrafficReply.Merge(m, src)
+}
+func (m *SimulateTrafficReply) XXX_Size() int {
+	return xxx_messageInfo_SimulateTrafficReply.Size(m)
+}
+func (m *SimulateTrafficReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SimulateTrafficReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SimulateTrafficReply proto.InternalMessageInfo
+
+func (m *SimulateTrafficReply) GetSamples() int32 {
+	if m != nil {
+		return m.Samples
+	}
+	return 0
+}
+
+func (m *SimulateTrafficReply) GetMirrors() []*TrafficShare {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+func (m *SimulateTrafficReply) GetUnservedBefore() float32 {
+	if m != nil {
+		return m.UnservedBefore
+	}
+	return 0
+}
+
+func (m *SimulateTrafficReply) GetUnservedAfter() float32 {
+	if m != nil {
+		return m.UnservedAfter
+	}
+	return 0
+}
+
+type FileInfoRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FileInfoRequest) Reset()         { *m = FileInfoRequest{} }
+func (m *FileInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*FileInfoRequest) ProtoMessage()    {}
+func (*FileInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{15}
+}
+
+func (m *FileInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileInfoRequest.Unmarshal(m, b)
+}
+func (m *FileInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *FileInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileInfoRequest.Merge(m, src)
+}
+func (m *FileInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_FileInfoRequest.Size(m)
+}
+func (m *FileInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileInfoRequest proto.InternalMessageInfo
+
+func (m *FileInfoRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type FileInfoReply struct {
+	Path                 string               `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Size                 int64                `protobuf:"varint,2,opt,name=Size,proto3" json:"Size,omitempty"`
+	ModTime              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=ModTime,proto3" json:"ModTime,omitempty"`
+	Sha1                 string               `protobuf:"bytes,4,opt,name=Sha1,proto3" json:"Sha1,omitempty"`
+	Sha256               string               `protobuf:"bytes,5,opt,name=Sha256,proto3" json:"Sha256,omitempty"`
+	Md5                  string               `protobuf:"bytes,6,opt,name=Md5,proto3" json:"Md5,omitempty"`
+	Sha512               string               `protobuf:"bytes,7,opt,name=Sha512,proto3" json:"Sha512,omitempty"`
+	Blake2B              string               `protobuf:"bytes,8,opt,name=Blake2b,proto3" json:"Blake2b,omitempty"`
+	FirstSeen            *timestamp.Timestamp `protobuf:"bytes,9,opt,name=FirstSeen,proto3" json:"FirstSeen,omitempty"`
+	LastSeen             *timestamp.Timestamp `protobuf:"bytes,10,opt,name=LastSeen,proto3" json:"LastSeen,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *FileInfoReply) Reset()         { *m = FileInfoReply{} }
+func (m *FileInfoReply) String() string { return proto.CompactTextString(m) }
+func (*FileInfoReply) ProtoMessage()    {}
+func (*FileInfoReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{16}
+}
+
+func (m *FileInfoReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileInfoReply.Unmarshal(m, b)
+}
+func (m *FileInfoReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileInfoReply.Marshal(b, m, deterministic)
+}
+func (m *FileInfoReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileInfoReply.Merge(m, src)
+}
+func (m *FileInfoReply) XXX_Size() int {
+	return xxx_messageInfo_FileInfoReply.Size(m)
+}
+func (m *FileInfoReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileInfoReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileInfoReply proto.InternalMessageInfo
+
+func (m *FileInfoReply) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *FileInfoReply) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *FileInfoReply) GetModTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.ModTime
+	}
+	return nil
+}
+
+func (m *FileInfoReply) GetSha1() string {
+	if m != nil {
+		return m.Sha1
+	}
+	return ""
+}
+
+func (m *FileInfoReply) GetSha256() string {
+	if m != nil {
+		return m.Sha256
+	}
+	return ""
+}
+
+func (m *FileInfoReply) GetMd5() string {
+	if m != nil {
+		return m.Md5
+	}
+	return ""
+}
+
+func (m *FileInfoReply) GetSha512() string {
+	if m != nil {
+		return m.Sha512
+	}
+	return ""
+}
+
+func (m *FileInfoReply) GetBlake2B() string {
+	if m != nil {
+		return m.Blake2B
+	}
+	return ""
+}
+
+func (m *FileInfoReply) GetFirstSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.FirstSeen
+	}
+	return nil
+}
+
+func (m *FileInfoReply) GetLastSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastSeen
+	}
+	return nil
+}
+
+type WatchEventsRequest struct {
+	Types                []string `protobuf:"bytes,1,rep,name=Types,proto3" json:"Types,omitempty"`
+	MirrorID             int32    `protobuf:"varint,2,opt,name=MirrorID,proto3" json:"MirrorID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchEventsRequest) Reset()         { *m = WatchEventsRequest{} }
+func (m *WatchEventsRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchEventsRequest) ProtoMessage()    {}
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{17}
+}
+
+func (m *WatchEventsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEventsRequest.Unmarshal(m, b)
+}
+func (m *WatchEventsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEventsRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchEventsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEventsRequest.Merge(m, src)
+}
+func (m *WatchEventsRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchEventsRequest.Size(m)
+}
+func (m *WatchEventsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEventsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEventsRequest proto.InternalMessageInfo
+
+func (m *WatchEventsRequest) GetTypes() []string {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+func (m *WatchEventsRequest) GetMirrorID() int32 {
+	if m != nil {
+		return m.MirrorID
+	}
+	return 0
+}
+
+type Event struct {
+	Type                 string               `protobuf:"bytes,1,opt,name=Type,proto3" json:"Type,omitempty"`
+	MirrorID             int32                `protobuf:"varint,2,opt,name=MirrorID,proto3" json:"MirrorID,omitempty"`
+	MirrorName           string               `protobuf:"bytes,3,opt,name=MirrorName,proto3" json:"MirrorName,omitempty"`
+	Message              string               `protobuf:"bytes,4,opt,name=Message,proto3" json:"Message,omitempty"`
+	Timestamp            *timestamp.Timestamp `protobuf:"bytes,5,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	Actor                string               `protobuf:"bytes,6,opt,name=Actor,proto3" json:"Actor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+func (*Event) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{18}
+}
+
+func (m *Event) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Event.Unmarshal(m, b)
+}
+func (m *Event) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Event.Marshal(b, m, deterministic)
+}
+func (m *Event) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Event.Merge(m, src)
+}
+func (m *Event) XXX_Size() int {
+	return xxx_messageInfo_Event.Size(m)
+}
+func (m *Event) XXX_DiscardUnknown() {
+	xxx_messageInfo_Event.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Event proto.InternalMessageInfo
+
+func (m *Event) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Event) GetMirrorID() int32 {
+	if m != nil {
+		return m.MirrorID
+	}
+	return 0
+}
+
+func (m *Event) GetMirrorName() string {
+	if m != nil {
+		return m.MirrorName
+	}
+	return ""
+}
+
+func (m *Event) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *Event) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *Event) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+type CheckMirrorsRequest struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckMirrorsRequest) Reset()         { *m = CheckMirrorsRequest{} }
+func (m *CheckMirrorsRequest) String() string { return proto.CompactTextString(m) }
+func (*CheckMirrorsRequest) ProtoMessage()    {}
+func (*CheckMirrorsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{19}
+}
+
+func (m *CheckMirrorsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckMirrorsRequest.Unmarshal(m, b)
+}
+func (m *CheckMirrorsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckMirrorsRequest.Marshal(b, m, deterministic)
+}
+func (m *CheckMirrorsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckMirrorsRequest.Merge(m, src)
+}
+func (m *CheckMirrorsRequest) XXX_Size() int {
+	return xxx_messageInfo_CheckMirrorsRequest.Size(m)
+}
+func (m *CheckMirrorsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckMirrorsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckMirrorsRequest proto.InternalMessageInfo
+
+func (m *CheckMirrorsRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+type CheckMirrorsReply struct {
+	MirrorID             int32    `protobuf:"varint,1,opt,name=MirrorID,proto3" json:"MirrorID,omitempty"`
+	MirrorName           string   `protobuf:"bytes,2,opt,name=MirrorName,proto3" json:"MirrorName,omitempty"`
+	File                 string   `protobuf:"bytes,3,opt,name=File,proto3" json:"File,omitempty"`
+	StatusCode           int32    `protobuf:"varint,4,opt,name=StatusCode,proto3" json:"StatusCode,omitempty"`
+	LatencyMs            int64    `protobuf:"varint,5,opt,name=LatencyMs,proto3" json:"LatencyMs,omitempty"`
+	Error                string   `protobuf:"bytes,6,opt,name=Error,proto3" json:"Error,omitempty"`
+	CheckUp              bool     `protobuf:"varint,7,opt,name=CheckUp,proto3" json:"CheckUp,omitempty"`
+	Up                   bool     `protobuf:"varint,8,opt,name=Up,proto3" json:"Up,omitempty"`
+	ExcludeReason        string   `protobuf:"bytes,9,opt,name=ExcludeReason,proto3" json:"ExcludeReason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckMirrorsReply) Reset()         { *m = CheckMirrorsReply{} }
+func (m *CheckMirrorsReply) String() string { return proto.CompactTextString(m) }
+func (*CheckMirrorsReply) ProtoMessage()    {}
+func (*CheckMirrorsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{20}
+}
+
+func (m *CheckMirrorsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckMirrorsReply.Unmarshal(m, b)
+}
+func (m *CheckMirrorsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckMirrorsReply.Marshal(b, m, deterministic)
+}
+func (m *CheckMirrorsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckMirrorsReply.Merge(m, src)
+}
+func (m *CheckMirrorsReply) XXX_Size() int {
+	return xxx_messageInfo_CheckMirrorsReply.Size(m)
+}
+func (m *CheckMirrorsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckMirrorsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckMirrorsReply proto.InternalMessageInfo
+
+func (m *CheckMirrorsReply) GetMirrorID() int32 {
+	if m != nil {
+		return m.MirrorID
+	}
+	return 0
+}
+
+func (m *CheckMirrorsReply) GetMirrorName() string {
+	if m != nil {
+		return m.MirrorName
+	}
+	return ""
+}
+
+func (m *CheckMirrorsReply) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *CheckMirrorsReply) GetStatusCode() int32 {
+	if m != nil {
+		return m.StatusCode
+	}
+	return 0
+}
+
+func (m *CheckMirrorsReply) GetLatencyMs() int64 {
+	if m != nil {
+		return m.LatencyMs
+	}
+	return 0
+}
+
+func (m *CheckMirrorsReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *CheckMirrorsReply) GetCheckUp() bool {
+	if m != nil {
+		return m.CheckUp
+	}
+	return false
+}
+
+func (m *CheckMirrorsReply) GetUp() bool {
+	if m != nil {
+		return m.Up
+	}
+	return false
+}
+
+func (m *CheckMirrorsReply) GetExcludeReason() string {
+	if m != nil {
+		return m.ExcludeReason
+	}
+	return ""
+}
+
+type MatchReply struct {
+	Mirrors              []*MirrorID `protobuf:"bytes,1,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *MatchReply) Reset()         { *m = MatchReply{} }
+func (m *MatchReply) String() string { return proto.CompactTextString(m) }
+func (*MatchReply) ProtoMessage()    {}
+func (*MatchReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{21}
+}
+
+func (m *MatchReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MatchReply.Unmarshal(m, b)
+}
+func (m *MatchReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MatchReply.Marshal(b, m, deterministic)
+}
+func (m *MatchReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MatchReply.Merge(m, src)
+}
+func (m *MatchReply) XXX_Size() int {
+	return xxx_messageInfo_MatchReply.Size(m)
+}
+func (m *MatchReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MatchReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MatchReply proto.InternalMessageInfo
+
+func (m *MatchReply) GetMirrors() []*MirrorID {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+type ChangeStatusRequest struct {
+	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Enabled              bool                 `protobuf:"varint,2,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
+	Reason               string               `protobuf:"bytes,3,opt,name=Reason,proto3" json:"Reason,omitempty"`
+	Until                *timestamp.Timestamp `protobuf:"bytes,4,opt,name=Until,proto3" json:"Until,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ChangeStatusRequest) Reset()         { *m = ChangeStatusRequest{} }
+func (m *ChangeStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*ChangeStatusRequest) ProtoMessage()    {}
+func (*ChangeStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{22}
+}
+
+func (m *ChangeStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChangeStatusRequest.Unmarshal(m, b)
+}
+func (m *ChangeStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChangeStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *ChangeStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChangeStatusRequest.Merge(m, src)
+}
+func (m *ChangeStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_ChangeStatusRequest.Size(m)
+}
+func (m *ChangeStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChangeStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChangeStatusRequest proto.InternalMessageInfo
+
+func (m *ChangeStatusRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *ChangeStatusRequest) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+func (m *ChangeStatusRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *ChangeStatusRequest) GetUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.Until
+	}
+	return nil
+}
+
+type SetMaintenanceRequest struct {
+	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Until                *timestamp.Timestamp `protobuf:"bytes,2,opt,name=Until,proto3" json:"Until,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *SetMaintenanceRequest) Reset()         { *m = SetMaintenanceRequest{} }
+func (m *SetMaintenanceRequest) String() string { return proto.CompactTextString(m) }
+func (*SetMaintenanceRequest) ProtoMessage()    {}
+func (*SetMaintenanceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{23}
+}
+
+func (m *SetMaintenanceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetMaintenanceRequest.Unmarshal(m, b)
+}
+func (m *SetMaintenanceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetMaintenanceRequest.Marshal(b, m, deterministic)
+}
+func (m *SetMaintenanceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetMaintenanceRequest.Merge(m, src)
+}
+func (m *SetMaintenanceRequest) XXX_Size() int {
+	return xxx_messageInfo_SetMaintenanceRequest.Size(m)
+}
+func (m *SetMaintenanceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetMaintenanceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetMaintenanceRequest proto.InternalMessageInfo
+
+func (m *SetMaintenanceRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *SetMaintenanceRequest) GetUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.Until
+	}
+	return nil
+}
+
+type MirrorIDRequest struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MirrorIDRequest) Reset()         { *m = MirrorIDRequest{} }
+func (m *MirrorIDRequest) String() string { return proto.CompactTextString(m) }
+func (*MirrorIDRequest) ProtoMessage()    {}
+func (*MirrorIDRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{24}
+}
+
+func (m *MirrorIDRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MirrorIDRequest.Unmarshal(m, b)
+}
+func (m *MirrorIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MirrorIDRequest.Marshal(b, m, deterministic)
+}
+func (m *MirrorIDRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MirrorIDRequest.Merge(m, src)
+}
+func (m *MirrorIDRequest) XXX_Size() int {
+	return xxx_messageInfo_MirrorIDRequest.Size(m)
+}
+func (m *MirrorIDRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MirrorIDRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MirrorIDRequest proto.InternalMessageInfo
+
+func (m *MirrorIDRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+type RemoveMirrorRequest struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Purge                bool     `protobuf:"varint,2,opt,name=Purge,proto3" json:"Purge,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveMirrorRequest) Reset()         { *m = RemoveMirrorRequest{} }
+func (m *RemoveMirrorRequest) String() string { return proto.CompactTextString(m) }
+func (*RemoveMirrorRequest) ProtoMessage()    {}
+func (*RemoveMirrorRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{25}
+}
+
+func (m *RemoveMirrorRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveMirrorRequest.Unmarshal(m, b)
+}
+func (m *RemoveMirrorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveMirrorRequest.Marshal(b, m, deterministic)
+}
+func (m *RemoveMirrorRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveMirrorRequest.Merge(m, src)
+}
+func (m *RemoveMirrorRequest) XXX_Size() int {
+	return xxx_messageInfo_RemoveMirrorRequest.Size(m)
+}
+func (m *RemoveMirrorRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveMirrorRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveMirrorRequest proto.InternalMessageInfo
+
+func (m *RemoveMirrorRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *RemoveMirrorRequest) GetPurge() bool {
+	if m != nil {
+		return m.Purge
+	}
+	return false
+}
+
+type RemovalReportReply struct {
+	Files                int64    `protobuf:"varint,1,opt,name=Files,proto3" json:"Files,omitempty"`
+	Logs                 int64    `protobuf:"varint,2,opt,name=Logs,proto3" json:"Logs,omitempty"`
+	StatsKeys            int64    `protobuf:"varint,3,opt,name=StatsKeys,proto3" json:"StatsKeys,omitempty"`
+	Retention            int32    `protobuf:"varint,4,opt,name=Retention,proto3" json:"Retention,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemovalReportReply) Reset()         { *m = RemovalReportReply{} }
+func (m *RemovalReportReply) String() string { return proto.CompactTextString(m) }
+func (*RemovalReportReply) ProtoMessage()    {}
+func (*RemovalReportReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{26}
+}
+
+func (m *RemovalReportReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemovalReportReply.Unmarshal(m, b)
+}
+func (m *RemovalReportReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemovalReportReply.Marshal(b, m, deterministic)
+}
+func (m *RemovalReportReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemovalReportReply.Merge(m, src)
+}
+func (m *RemovalReportReply) XXX_Size() int {
+	return xxx_messageInfo_RemovalReportReply.Size(m)
+}
+func (m *RemovalReportReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemovalReportReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemovalReportReply proto.InternalMessageInfo
+
+func (m *RemovalReportReply) GetFiles() int64 {
+	if m != nil {
+		return m.Files
+	}
+	return 0
+}
+
+func (m *RemovalReportReply) GetLogs() int64 {
+	if m != nil {
+		return m.Logs
+	}
+	return 0
+}
+
+func (m *RemovalReportReply) GetStatsKeys() int64 {
+	if m != nil {
+		return m.StatsKeys
+	}
+	return 0
+}
+
+func (m *RemovalReportReply) GetRetention() int32 {
+	if m != nil {
+		return m.Retention
+	}
+	return 0
+}
+
+type RemovedMirror struct {
+	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Name                 string               `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	RemovedAt            *timestamp.Timestamp `protobuf:"bytes,3,opt,name=RemovedAt,proto3" json:"RemovedAt,omitempty"`
+	PurgeAt              *timestamp.Timestamp `protobuf:"bytes,4,opt,name=PurgeAt,proto3" json:"PurgeAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *RemovedMirror) Reset()         { *m = RemovedMirror{} }
+func (m *RemovedMirror) String() string { return proto.CompactTextString(m) }
+func (*RemovedMirror) ProtoMessage()    {}
+func (*RemovedMirror) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{27}
+}
+
+func (m *RemovedMirror) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemovedMirror.Unmarshal(m, b)
+}
+func (m *RemovedMirror) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemovedMirror.Marshal(b, m, deterministic)
+}
+func (m *RemovedMirror) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemovedMirror.Merge(m, src)
+}
+func (m *RemovedMirror) XXX_Size() int {
+	return xxx_messageInfo_RemovedMirror.Size(m)
+}
+func (m *RemovedMirror) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemovedMirror.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemovedMirror proto.InternalMessageInfo
+
+func (m *RemovedMirror) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *RemovedMirror) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *RemovedMirror) GetRemovedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.RemovedAt
+	}
+	return nil
+}
+
+func (m *RemovedMirror) GetPurgeAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.PurgeAt
+	}
+	return nil
+}
+
+type RemovedMirrorsReply struct {
+	Mirrors              []*RemovedMirror `protobuf:"bytes,1,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *RemovedMirrorsReply) Reset()         { *m = RemovedMirrorsReply{} }
+func (m *RemovedMirrorsReply) String() string { return proto.CompactTextString(m) }
+func (*RemovedMirrorsReply) ProtoMessage()    {}
+func (*RemovedMirrorsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{28}
+}
+
+func (m *RemovedMirrorsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemovedMirrorsReply.Unmarshal(m, b)
+}
+func (m *RemovedMirrorsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemovedMirrorsReply.Marshal(b, m, deterministic)
+}
+func (m *RemovedMirrorsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemovedMirrorsReply.Merge(m, src)
+}
+func (m *RemovedMirrorsReply) XXX_Size() int {
+	return xxx_messageInfo_RemovedMirrorsReply.Size(m)
+}
+func (m *RemovedMirrorsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemovedMirrorsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemovedMirrorsReply proto.InternalMessageInfo
+
+func (m *RemovedMirrorsReply) GetMirrors() []*RemovedMirror {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+type AddMirrorReply struct {
 	Latitude             float32  `protobuf:"fixed32,1,opt,name=Latitude,proto3" json:"Latitude,omitempty"`
 	Longitude            float32  `protobuf:"fixed32,2,opt,name=Longitude,proto3" json:"Longitude,omitempty"`
 	Country              string   `protobuf:"bytes,3,opt,name=Country,proto3" json:"Country,omitempty"`
@@ -666,633 +2428,1579 @@ type AddMirrorReply struct {
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *AddMirrorReply) Reset()         { *m = AddMirrorReply{} }
-func (m *AddMirrorReply) String() string { return proto.CompactTextString(m) }
-func (*AddMirrorReply) ProtoMessage()    {}
-func (*AddMirrorReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+func (m *AddMirrorReply) Reset()         { *m = AddMirrorReply{} }
+func (m *AddMirrorReply) String() string { return proto.CompactTextString(m) }
+func (*AddMirrorReply) ProtoMessage()    {}
+func (*AddMirrorReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{29}
+}
+
+func (m *AddMirrorReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddMirrorReply.Unmarshal(m, b)
+}
+func (m *AddMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddMirrorReply.Marshal(b, m, deterministic)
+}
+func (m *AddMirrorReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddMirrorReply.Merge(m, src)
+}
+func (m *AddMirrorReply) XXX_Size() int {
+	return xxx_messageInfo_AddMirrorReply.Size(m)
+}
+func (m *AddMirrorReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddMirrorReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddMirrorReply proto.InternalMessageInfo
+
+func (m *AddMirrorReply) GetLatitude() float32 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *AddMirrorReply) GetLongitude() float32 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+func (m *AddMirrorReply) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *AddMirrorReply) GetContinent() string {
+	if m != nil {
+		return m.Continent
+	}
+	return ""
+}
+
+func (m *AddMirrorReply) GetASN() string {
+	if m != nil {
+		return m.ASN
+	}
+	return ""
+}
+
+func (m *AddMirrorReply) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+type AddMirrorsRequest struct {
+	Mirrors              []*Mirror `protobuf:"bytes,1,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	Update               bool      `protobuf:"varint,2,opt,name=Update,proto3" json:"Update,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *AddMirrorsRequest) Reset()         { *m = AddMirrorsRequest{} }
+func (m *AddMirrorsRequest) String() string { return proto.CompactTextString(m) }
+func (*AddMirrorsRequest) ProtoMessage()    {}
+func (*AddMirrorsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{30}
+}
+
+func (m *AddMirrorsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddMirrorsRequest.Unmarshal(m, b)
+}
+func (m *AddMirrorsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddMirrorsRequest.Marshal(b, m, deterministic)
+}
+func (m *AddMirrorsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddMirrorsRequest.Merge(m, src)
+}
+func (m *AddMirrorsRequest) XXX_Size() int {
+	return xxx_messageInfo_AddMirrorsRequest.Size(m)
+}
+func (m *AddMirrorsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddMirrorsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddMirrorsRequest proto.InternalMessageInfo
+
+func (m *AddMirrorsRequest) GetMirrors() []*Mirror {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
+}
+
+func (m *AddMirrorsRequest) GetUpdate() bool {
+	if m != nil {
+		return m.Update
+	}
+	return false
+}
+
+type AddMirrorResult struct {
+	Name                 string          `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	ID                   int32           `protobuf:"varint,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	Location             *AddMirrorReply `protobuf:"bytes,3,opt,name=Location,proto3" json:"Location,omitempty"`
+	Error                string          `protobuf:"bytes,4,opt,name=Error,proto3" json:"Error,omitempty"`
+	Updated              bool            `protobuf:"varint,5,opt,name=Updated,proto3" json:"Updated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *AddMirrorResult) Reset()         { *m = AddMirrorResult{} }
+func (m *AddMirrorResult) String() string { return proto.CompactTextString(m) }
+func (*AddMirrorResult) ProtoMessage()    {}
+func (*AddMirrorResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{31}
+}
+
+func (m *AddMirrorResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddMirrorResult.Unmarshal(m, b)
+}
+func (m *AddMirrorResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddMirrorResult.Marshal(b, m, deterministic)
+}
+func (m *AddMirrorResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddMirrorResult.Merge(m, src)
+}
+func (m *AddMirrorResult) XXX_Size() int {
+	return xxx_messageInfo_AddMirrorResult.Size(m)
+}
+func (m *AddMirrorResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddMirrorResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddMirrorResult proto.InternalMessageInfo
+
+func (m *AddMirrorResult) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AddMirrorResult) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *AddMirrorResult) GetLocation() *AddMirrorReply {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+func (m *AddMirrorResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *AddMirrorResult) GetUpdated() bool {
+	if m != nil {
+		return m.Updated
+	}
+	return false
+}
+
+type AddMirrorsReply struct {
+	Results              []*AddMirrorResult `protobuf:"bytes,1,rep,name=Results,proto3" json:"Results,omitempty"`
+	Committed            bool               `protobuf:"varint,2,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *AddMirrorsReply) Reset()         { *m = AddMirrorsReply{} }
+func (m *AddMirrorsReply) String() string { return proto.CompactTextString(m) }
+func (*AddMirrorsReply) ProtoMessage()    {}
+func (*AddMirrorsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{32}
+}
+
+func (m *AddMirrorsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddMirrorsReply.Unmarshal(m, b)
+}
+func (m *AddMirrorsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddMirrorsReply.Marshal(b, m, deterministic)
+}
+func (m *AddMirrorsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddMirrorsReply.Merge(m, src)
+}
+func (m *AddMirrorsReply) XXX_Size() int {
+	return xxx_messageInfo_AddMirrorsReply.Size(m)
+}
+func (m *AddMirrorsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddMirrorsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddMirrorsReply proto.InternalMessageInfo
+
+func (m *AddMirrorsReply) GetResults() []*AddMirrorResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *AddMirrorsReply) GetCommitted() bool {
+	if m != nil {
+		return m.Committed
+	}
+	return false
+}
+
+type UpdateMirrorReply struct {
+	Diff                 string   `protobuf:"bytes,1,opt,name=Diff,proto3" json:"Diff,omitempty"`
+	Warnings             []string `protobuf:"bytes,2,rep,name=Warnings,proto3" json:"Warnings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateMirrorReply) Reset()         { *m = UpdateMirrorReply{} }
+func (m *UpdateMirrorReply) String() string { return proto.CompactTextString(m) }
+func (*UpdateMirrorReply) ProtoMessage()    {}
+func (*UpdateMirrorReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{33}
+}
+
+func (m *UpdateMirrorReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateMirrorReply.Unmarshal(m, b)
+}
+func (m *UpdateMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateMirrorReply.Marshal(b, m, deterministic)
+}
+func (m *UpdateMirrorReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateMirrorReply.Merge(m, src)
+}
+func (m *UpdateMirrorReply) XXX_Size() int {
+	return xxx_messageInfo_UpdateMirrorReply.Size(m)
+}
+func (m *UpdateMirrorReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateMirrorReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateMirrorReply proto.InternalMessageInfo
+
+func (m *UpdateMirrorReply) GetDiff() string {
+	if m != nil {
+		return m.Diff
+	}
+	return ""
+}
+
+func (m *UpdateMirrorReply) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+type GeoUpdateMirrorReply struct {
+	Mirror               *Mirror  `protobuf:"bytes,1,opt,name=Mirror,proto3" json:"Mirror,omitempty"`
+	Diff                 string   `protobuf:"bytes,2,opt,name=Diff,proto3" json:"Diff,omitempty"`
+	Warnings             []string `protobuf:"bytes,3,rep,name=Warnings,proto3" json:"Warnings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GeoUpdateMirrorReply) Reset()         { *m = GeoUpdateMirrorReply{} }
+func (m *GeoUpdateMirrorReply) String() string { return proto.CompactTextString(m) }
+func (*GeoUpdateMirrorReply) ProtoMessage()    {}
+func (*GeoUpdateMirrorReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{34}
+}
+
+func (m *GeoUpdateMirrorReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GeoUpdateMirrorReply.Unmarshal(m, b)
+}
+func (m *GeoUpdateMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GeoUpdateMirrorReply.Marshal(b, m, deterministic)
+}
+func (m *GeoUpdateMirrorReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GeoUpdateMirrorReply.Merge(m, src)
+}
+func (m *GeoUpdateMirrorReply) XXX_Size() int {
+	return xxx_messageInfo_GeoUpdateMirrorReply.Size(m)
+}
+func (m *GeoUpdateMirrorReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GeoUpdateMirrorReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GeoUpdateMirrorReply proto.InternalMessageInfo
+
+func (m *GeoUpdateMirrorReply) GetMirror() *Mirror {
+	if m != nil {
+		return m.Mirror
+	}
+	return nil
+}
+
+func (m *GeoUpdateMirrorReply) GetDiff() string {
+	if m != nil {
+		return m.Diff
+	}
+	return ""
+}
+
+func (m *GeoUpdateMirrorReply) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+type RefreshRepositoryRequest struct {
+	Rehash               bool     `protobuf:"varint,1,opt,name=Rehash,proto3" json:"Rehash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefreshRepositoryRequest) Reset()         { *m = RefreshRepositoryRequest{} }
+func (m *RefreshRepositoryRequest) String() string { return proto.CompactTextString(m) }
+func (*RefreshRepositoryRequest) ProtoMessage()    {}
+func (*RefreshRepositoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{35}
+}
+
+func (m *RefreshRepositoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshRepositoryRequest.Unmarshal(m, b)
+}
+func (m *RefreshRepositoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshRepositoryRequest.Marshal(b, m, deterministic)
+}
+func (m *RefreshRepositoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshRepositoryRequest.Merge(m, src)
+}
+func (m *RefreshRepositoryRequest) XXX_Size() int {
+	return xxx_messageInfo_RefreshRepositoryRequest.Size(m)
+}
+func (m *RefreshRepositoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshRepositoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefreshRepositoryRequest proto.InternalMessageInfo
+
+func (m *RefreshRepositoryRequest) GetRehash() bool {
+	if m != nil {
+		return m.Rehash
+	}
+	return false
+}
+
+type ScanMirrorRequest struct {
+	ID                   int32                    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	AutoEnable           bool                     `protobuf:"varint,2,opt,name=AutoEnable,proto3" json:"AutoEnable,omitempty"`
+	Protocol             ScanMirrorRequest_Method `protobuf:"varint,3,opt,name=Protocol,proto3,enum=ScanMirrorRequest_Method" json:"Protocol,omitempty"`
+	Force                bool                     `protobuf:"varint,4,opt,name=Force,proto3" json:"Force,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *ScanMirrorRequest) Reset()         { *m = ScanMirrorRequest{} }
+func (m *ScanMirrorRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanMirrorRequest) ProtoMessage()    {}
+func (*ScanMirrorRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{36}
+}
+
+func (m *ScanMirrorRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanMirrorRequest.Unmarshal(m, b)
+}
+func (m *ScanMirrorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanMirrorRequest.Marshal(b, m, deterministic)
+}
+func (m *ScanMirrorRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanMirrorRequest.Merge(m, src)
+}
+func (m *ScanMirrorRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanMirrorRequest.Size(m)
+}
+func (m *ScanMirrorRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanMirrorRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanMirrorRequest proto.InternalMessageInfo
+
+func (m *ScanMirrorRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *ScanMirrorRequest) GetAutoEnable() bool {
+	if m != nil {
+		return m.AutoEnable
+	}
+	return false
+}
+
+func (m *ScanMirrorRequest) GetProtocol() ScanMirrorRequest_Method {
+	if m != nil {
+		return m.Protocol
+	}
+	return ScanMirrorRequest_ALL
+}
+
+func (m *ScanMirrorRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+type ScanMirrorReply struct {
+	Enabled              bool     `protobuf:"varint,1,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
+	FilesIndexed         int64    `protobuf:"varint,2,opt,name=FilesIndexed,proto3" json:"FilesIndexed,omitempty"`
+	KnownIndexed         int64    `protobuf:"varint,3,opt,name=KnownIndexed,proto3" json:"KnownIndexed,omitempty"`
+	Removed              int64    `protobuf:"varint,4,opt,name=Removed,proto3" json:"Removed,omitempty"`
+	TZOffsetMs           int64    `protobuf:"varint,5,opt,name=TZOffsetMs,proto3" json:"TZOffsetMs,omitempty"`
+	CaseCollisions       []string `protobuf:"bytes,6,rep,name=CaseCollisions,proto3" json:"CaseCollisions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScanMirrorReply) Reset()         { *m = ScanMirrorReply{} }
+func (m *ScanMirrorReply) String() string { return proto.CompactTextString(m) }
+func (*ScanMirrorReply) ProtoMessage()    {}
+func (*ScanMirrorReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{37}
+}
+
+func (m *ScanMirrorReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanMirrorReply.Unmarshal(m, b)
+}
+func (m *ScanMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanMirrorReply.Marshal(b, m, deterministic)
+}
+func (m *ScanMirrorReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanMirrorReply.Merge(m, src)
+}
+func (m *ScanMirrorReply) XXX_Size() int {
+	return xxx_messageInfo_ScanMirrorReply.Size(m)
+}
+func (m *ScanMirrorReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanMirrorReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanMirrorReply proto.InternalMessageInfo
+
+func (m *ScanMirrorReply) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+func (m *ScanMirrorReply) GetFilesIndexed() int64 {
+	if m != nil {
+		return m.FilesIndexed
+	}
+	return 0
+}
+
+func (m *ScanMirrorReply) GetKnownIndexed() int64 {
+	if m != nil {
+		return m.KnownIndexed
+	}
+	return 0
+}
+
+func (m *ScanMirrorReply) GetRemoved() int64 {
+	if m != nil {
+		return m.Removed
+	}
+	return 0
+}
+
+func (m *ScanMirrorReply) GetTZOffsetMs() int64 {
+	if m != nil {
+		return m.TZOffsetMs
+	}
+	return 0
+}
+
+func (m *ScanMirrorReply) GetCaseCollisions() []string {
+	if m != nil {
+		return m.CaseCollisions
+	}
+	return nil
+}
+
+type StatsFileRequest struct {
+	Pattern              string               `protobuf:"bytes,1,opt,name=Pattern,proto3" json:"Pattern,omitempty"`
+	DateStart            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=DateStart,proto3" json:"DateStart,omitempty"`
+	DateEnd              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=DateEnd,proto3" json:"DateEnd,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *StatsFileRequest) Reset()         { *m = StatsFileRequest{} }
+func (m *StatsFileRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsFileRequest) ProtoMessage()    {}
+func (*StatsFileRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{38}
+}
+
+func (m *StatsFileRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsFileRequest.Unmarshal(m, b)
+}
+func (m *StatsFileRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsFileRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsFileRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsFileRequest.Merge(m, src)
+}
+func (m *StatsFileRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsFileRequest.Size(m)
+}
+func (m *StatsFileRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsFileRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsFileRequest proto.InternalMessageInfo
+
+func (m *StatsFileRequest) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *StatsFileRequest) GetDateStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.DateStart
+	}
+	return nil
+}
+
+func (m *StatsFileRequest) GetDateEnd() *timestamp.Timestamp {
+	if m != nil {
+		return m.DateEnd
+	}
+	return nil
+}
+
+type StatsFileReply struct {
+	Files                map[string]int64 `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *StatsFileReply) Reset()         { *m = StatsFileReply{} }
+func (m *StatsFileReply) String() string { return proto.CompactTextString(m) }
+func (*StatsFileReply) ProtoMessage()    {}
+func (*StatsFileReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{39}
+}
+
+func (m *StatsFileReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsFileReply.Unmarshal(m, b)
+}
+func (m *StatsFileReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsFileReply.Marshal(b, m, deterministic)
+}
+func (m *StatsFileReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsFileReply.Merge(m, src)
+}
+func (m *StatsFileReply) XXX_Size() int {
+	return xxx_messageInfo_StatsFileReply.Size(m)
+}
+func (m *StatsFileReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsFileReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsFileReply proto.InternalMessageInfo
+
+func (m *StatsFileReply) GetFiles() map[string]int64 {
+	if m != nil {
+		return m.Files
+	}
+	return nil
+}
+
+type StatsMirrorRequest struct {
+	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	DateStart            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=DateStart,proto3" json:"DateStart,omitempty"`
+	DateEnd              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=DateEnd,proto3" json:"DateEnd,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *StatsMirrorRequest) Reset()         { *m = StatsMirrorRequest{} }
+func (m *StatsMirrorRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsMirrorRequest) ProtoMessage()    {}
+func (*StatsMirrorRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{40}
+}
+
+func (m *StatsMirrorRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsMirrorRequest.Unmarshal(m, b)
+}
+func (m *StatsMirrorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsMirrorRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsMirrorRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsMirrorRequest.Merge(m, src)
+}
+func (m *StatsMirrorRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsMirrorRequest.Size(m)
+}
+func (m *StatsMirrorRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsMirrorRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsMirrorRequest proto.InternalMessageInfo
+
+func (m *StatsMirrorRequest) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *StatsMirrorRequest) GetDateStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.DateStart
+	}
+	return nil
+}
+
+func (m *StatsMirrorRequest) GetDateEnd() *timestamp.Timestamp {
+	if m != nil {
+		return m.DateEnd
+	}
+	return nil
+}
+
+type StatsMirrorReply struct {
+	Mirror               *Mirror  `protobuf:"bytes,1,opt,name=Mirror,proto3" json:"Mirror,omitempty"`
+	Requests             int64    `protobuf:"varint,2,opt,name=Requests,proto3" json:"Requests,omitempty"`
+	Bytes                int64    `protobuf:"varint,3,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsMirrorReply) Reset()         { *m = StatsMirrorReply{} }
+func (m *StatsMirrorReply) String() string { return proto.CompactTextString(m) }
+func (*StatsMirrorReply) ProtoMessage()    {}
+func (*StatsMirrorReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{41}
 }
 
-func (m *AddMirrorReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_AddMirrorReply.Unmarshal(m, b)
+func (m *StatsMirrorReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsMirrorReply.Unmarshal(m, b)
 }
-func (m *AddMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_AddMirrorReply.Marshal(b, m, deterministic)
+func (m *StatsMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsMirrorReply.Marshal(b, m, deterministic)
 }
-func (m *AddMirrorReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_AddMirrorReply.Merge(m, src)
+func (m *StatsMirrorReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsMirrorReply.Merge(m, src)
 }
-func (m *AddMirrorReply) XXX_Size() int {
-	return xxx_messageInfo_AddMirrorReply.Size(m)
+func (m *StatsMirrorReply) XXX_Size() int {
+	return xxx_messageInfo_StatsMirrorReply.Size(m)
 }
-func (m *AddMirrorReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_AddMirrorReply.DiscardUnknown(m)
+func (m *StatsMirrorReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsMirrorReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_AddMirrorReply proto.InternalMessageInfo
+var xxx_messageInfo_StatsMirrorReply proto.InternalMessageInfo
 
-func (m *AddMirrorReply) GetLatitude() float32 {
+func (m *StatsMirrorReply) GetMirror() *Mirror {
 	if m != nil {
-		return m.Latitude
+		return m.Mirror
 	}
-	return 0
+	return nil
 }
 
-func (m *AddMirrorReply) GetLongitude() float32 {
+func (m *StatsMirrorReply) GetRequests() int64 {
 	if m != nil {
-		return m.Longitude
+		return m.Requests
 	}
 	return 0
 }
 
-func (m *AddMirrorReply) GetCountry() string {
+func (m *StatsMirrorReply) GetBytes() int64 {
 	if m != nil {
-		return m.Country
+		return m.Bytes
 	}
-	return ""
+	return 0
 }
 
-func (m *AddMirrorReply) GetContinent() string {
+type StatsHTTPRequest struct {
+	DateStart            *timestamp.Timestamp `protobuf:"bytes,1,opt,name=DateStart,proto3" json:"DateStart,omitempty"`
+	DateEnd              *timestamp.Timestamp `protobuf:"bytes,2,opt,name=DateEnd,proto3" json:"DateEnd,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *StatsHTTPRequest) Reset()         { *m = StatsHTTPRequest{} }
+func (m *StatsHTTPRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsHTTPRequest) ProtoMessage()    {}
+func (*StatsHTTPRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{42}
+}
+
+func (m *StatsHTTPRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsHTTPRequest.Unmarshal(m, b)
+}
+func (m *StatsHTTPRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsHTTPRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsHTTPRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsHTTPRequest.Merge(m, src)
+}
+func (m *StatsHTTPRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsHTTPRequest.Size(m)
+}
+func (m *StatsHTTPRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsHTTPRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsHTTPRequest proto.InternalMessageInfo
+
+func (m *StatsHTTPRequest) GetDateStart() *timestamp.Timestamp {
 	if m != nil {
-		return m.Continent
+		return m.DateStart
 	}
-	return ""
+	return nil
 }
 
-func (m *AddMirrorReply) GetASN() string {
+func (m *StatsHTTPRequest) GetDateEnd() *timestamp.Timestamp {
 	if m != nil {
-		return m.ASN
+		return m.DateEnd
 	}
-	return ""
+	return nil
 }
 
-func (m *AddMirrorReply) GetWarnings() []string {
+type StatsHTTPReply struct {
+	Responses            []*StatsHTTPReply_Response `protobuf:"bytes,1,rep,name=Responses,proto3" json:"Responses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *StatsHTTPReply) Reset()         { *m = StatsHTTPReply{} }
+func (m *StatsHTTPReply) String() string { return proto.CompactTextString(m) }
+func (*StatsHTTPReply) ProtoMessage()    {}
+func (*StatsHTTPReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{43}
+}
+
+func (m *StatsHTTPReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsHTTPReply.Unmarshal(m, b)
+}
+func (m *StatsHTTPReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsHTTPReply.Marshal(b, m, deterministic)
+}
+func (m *StatsHTTPReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsHTTPReply.Merge(m, src)
+}
+func (m *StatsHTTPReply) XXX_Size() int {
+	return xxx_messageInfo_StatsHTTPReply.Size(m)
+}
+func (m *StatsHTTPReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsHTTPReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsHTTPReply proto.InternalMessageInfo
+
+func (m *StatsHTTPReply) GetResponses() []*StatsHTTPReply_Response {
 	if m != nil {
-		return m.Warnings
+		return m.Responses
 	}
 	return nil
 }
 
-type UpdateMirrorReply struct {
-	Diff                 string   `protobuf:"bytes,1,opt,name=Diff,proto3" json:"Diff,omitempty"`
+type StatsHTTPReply_Response struct {
+	Handler              string   `protobuf:"bytes,1,opt,name=Handler,proto3" json:"Handler,omitempty"`
+	Code                 int32    `protobuf:"varint,2,opt,name=Code,proto3" json:"Code,omitempty"`
+	Count                int64    `protobuf:"varint,3,opt,name=Count,proto3" json:"Count,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *UpdateMirrorReply) Reset()         { *m = UpdateMirrorReply{} }
-func (m *UpdateMirrorReply) String() string { return proto.CompactTextString(m) }
-func (*UpdateMirrorReply) ProtoMessage()    {}
-func (*UpdateMirrorReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{9}
+func (m *StatsHTTPReply_Response) Reset()         { *m = StatsHTTPReply_Response{} }
+func (m *StatsHTTPReply_Response) String() string { return proto.CompactTextString(m) }
+func (*StatsHTTPReply_Response) ProtoMessage()    {}
+func (*StatsHTTPReply_Response) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{43, 0}
 }
 
-func (m *UpdateMirrorReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_UpdateMirrorReply.Unmarshal(m, b)
+func (m *StatsHTTPReply_Response) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsHTTPReply_Response.Unmarshal(m, b)
 }
-func (m *UpdateMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_UpdateMirrorReply.Marshal(b, m, deterministic)
+func (m *StatsHTTPReply_Response) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsHTTPReply_Response.Marshal(b, m, deterministic)
 }
-func (m *UpdateMirrorReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_UpdateMirrorReply.Merge(m, src)
+func (m *StatsHTTPReply_Response) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsHTTPReply_Response.Merge(m, src)
 }
-func (m *UpdateMirrorReply) XXX_Size() int {
-	return xxx_messageInfo_UpdateMirrorReply.Size(m)
+func (m *StatsHTTPReply_Response) XXX_Size() int {
+	return xxx_messageInfo_StatsHTTPReply_Response.Size(m)
 }
-func (m *UpdateMirrorReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_UpdateMirrorReply.DiscardUnknown(m)
+func (m *StatsHTTPReply_Response) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsHTTPReply_Response.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_UpdateMirrorReply proto.InternalMessageInfo
+var xxx_messageInfo_StatsHTTPReply_Response proto.InternalMessageInfo
 
-func (m *UpdateMirrorReply) GetDiff() string {
+func (m *StatsHTTPReply_Response) GetHandler() string {
 	if m != nil {
-		return m.Diff
+		return m.Handler
 	}
 	return ""
 }
 
-type GeoUpdateMirrorReply struct {
-	Mirror               *Mirror  `protobuf:"bytes,1,opt,name=Mirror,proto3" json:"Mirror,omitempty"`
-	Diff                 string   `protobuf:"bytes,2,opt,name=Diff,proto3" json:"Diff,omitempty"`
-	Warnings             []string `protobuf:"bytes,3,rep,name=Warnings,proto3" json:"Warnings,omitempty"`
+func (m *StatsHTTPReply_Response) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *StatsHTTPReply_Response) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type StatsTopRequest struct {
+	Period               StatsTopRequest_PeriodType `protobuf:"varint,1,opt,name=Period,proto3,enum=StatsTopRequest_PeriodType" json:"Period,omitempty"`
+	Date                 *timestamp.Timestamp       `protobuf:"bytes,2,opt,name=Date,proto3" json:"Date,omitempty"`
+	Count                int32                      `protobuf:"varint,3,opt,name=Count,proto3" json:"Count,omitempty"`
+	Pattern              string                     `protobuf:"bytes,4,opt,name=Pattern,proto3" json:"Pattern,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *StatsTopRequest) Reset()         { *m = StatsTopRequest{} }
+func (m *StatsTopRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsTopRequest) ProtoMessage()    {}
+func (*StatsTopRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{44}
+}
+
+func (m *StatsTopRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsTopRequest.Unmarshal(m, b)
+}
+func (m *StatsTopRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsTopRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsTopRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsTopRequest.Merge(m, src)
+}
+func (m *StatsTopRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsTopRequest.Size(m)
+}
+func (m *StatsTopRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsTopRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsTopRequest proto.InternalMessageInfo
+
+func (m *StatsTopRequest) GetPeriod() StatsTopRequest_PeriodType {
+	if m != nil {
+		return m.Period
+	}
+	return StatsTopRequest_DAY
+}
+
+func (m *StatsTopRequest) GetDate() *timestamp.Timestamp {
+	if m != nil {
+		return m.Date
+	}
+	return nil
+}
+
+func (m *StatsTopRequest) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *StatsTopRequest) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+type FileDownloads struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Downloads            int64    `protobuf:"varint,2,opt,name=Downloads,proto3" json:"Downloads,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GeoUpdateMirrorReply) Reset()         { *m = GeoUpdateMirrorReply{} }
-func (m *GeoUpdateMirrorReply) String() string { return proto.CompactTextString(m) }
-func (*GeoUpdateMirrorReply) ProtoMessage()    {}
-func (*GeoUpdateMirrorReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{10}
+func (m *FileDownloads) Reset()         { *m = FileDownloads{} }
+func (m *FileDownloads) String() string { return proto.CompactTextString(m) }
+func (*FileDownloads) ProtoMessage()    {}
+func (*FileDownloads) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{45}
 }
 
-func (m *GeoUpdateMirrorReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GeoUpdateMirrorReply.Unmarshal(m, b)
+func (m *FileDownloads) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileDownloads.Unmarshal(m, b)
 }
-func (m *GeoUpdateMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GeoUpdateMirrorReply.Marshal(b, m, deterministic)
+func (m *FileDownloads) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileDownloads.Marshal(b, m, deterministic)
 }
-func (m *GeoUpdateMirrorReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GeoUpdateMirrorReply.Merge(m, src)
+func (m *FileDownloads) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileDownloads.Merge(m, src)
 }
-func (m *GeoUpdateMirrorReply) XXX_Size() int {
-	return xxx_messageInfo_GeoUpdateMirrorReply.Size(m)
+func (m *FileDownloads) XXX_Size() int {
+	return xxx_messageInfo_FileDownloads.Size(m)
 }
-func (m *GeoUpdateMirrorReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_GeoUpdateMirrorReply.DiscardUnknown(m)
+func (m *FileDownloads) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileDownloads.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GeoUpdateMirrorReply proto.InternalMessageInfo
+var xxx_messageInfo_FileDownloads proto.InternalMessageInfo
 
-func (m *GeoUpdateMirrorReply) GetMirror() *Mirror {
+func (m *FileDownloads) GetPath() string {
 	if m != nil {
-		return m.Mirror
+		return m.Path
 	}
-	return nil
+	return ""
 }
 
-func (m *GeoUpdateMirrorReply) GetDiff() string {
+func (m *FileDownloads) GetDownloads() int64 {
 	if m != nil {
-		return m.Diff
+		return m.Downloads
 	}
-	return ""
+	return 0
 }
 
-func (m *GeoUpdateMirrorReply) GetWarnings() []string {
+type StatsTopReply struct {
+	Files                []*FileDownloads `protobuf:"bytes,1,rep,name=Files,proto3" json:"Files,omitempty"`
+	Total                int64            `protobuf:"varint,2,opt,name=Total,proto3" json:"Total,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *StatsTopReply) Reset()         { *m = StatsTopReply{} }
+func (m *StatsTopReply) String() string { return proto.CompactTextString(m) }
+func (*StatsTopReply) ProtoMessage()    {}
+func (*StatsTopReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{46}
+}
+
+func (m *StatsTopReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsTopReply.Unmarshal(m, b)
+}
+func (m *StatsTopReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsTopReply.Marshal(b, m, deterministic)
+}
+func (m *StatsTopReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsTopReply.Merge(m, src)
+}
+func (m *StatsTopReply) XXX_Size() int {
+	return xxx_messageInfo_StatsTopReply.Size(m)
+}
+func (m *StatsTopReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsTopReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsTopReply proto.InternalMessageInfo
+
+func (m *StatsTopReply) GetFiles() []*FileDownloads {
 	if m != nil {
-		return m.Warnings
+		return m.Files
 	}
 	return nil
 }
 
-type RefreshRepositoryRequest struct {
-	Rehash               bool     `protobuf:"varint,1,opt,name=Rehash,proto3" json:"Rehash,omitempty"`
+func (m *StatsTopReply) GetTotal() int64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+type StatsPruneReply struct {
+	Removed              int64    `protobuf:"varint,1,opt,name=Removed,proto3" json:"Removed,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RefreshRepositoryRequest) Reset()         { *m = RefreshRepositoryRequest{} }
-func (m *RefreshRepositoryRequest) String() string { return proto.CompactTextString(m) }
-func (*RefreshRepositoryRequest) ProtoMessage()    {}
-func (*RefreshRepositoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{11}
+func (m *StatsPruneReply) Reset()         { *m = StatsPruneReply{} }
+func (m *StatsPruneReply) String() string { return proto.CompactTextString(m) }
+func (*StatsPruneReply) ProtoMessage()    {}
+func (*StatsPruneReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{47}
 }
 
-func (m *RefreshRepositoryRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RefreshRepositoryRequest.Unmarshal(m, b)
+func (m *StatsPruneReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsPruneReply.Unmarshal(m, b)
 }
-func (m *RefreshRepositoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RefreshRepositoryRequest.Marshal(b, m, deterministic)
+func (m *StatsPruneReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsPruneReply.Marshal(b, m, deterministic)
 }
-func (m *RefreshRepositoryRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RefreshRepositoryRequest.Merge(m, src)
+func (m *StatsPruneReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsPruneReply.Merge(m, src)
 }
-func (m *RefreshRepositoryRequest) XXX_Size() int {
-	return xxx_messageInfo_RefreshRepositoryRequest.Size(m)
+func (m *StatsPruneReply) XXX_Size() int {
+	return xxx_messageInfo_StatsPruneReply.Size(m)
 }
-func (m *RefreshRepositoryRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_RefreshRepositoryRequest.DiscardUnknown(m)
+func (m *StatsPruneReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsPruneReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RefreshRepositoryRequest proto.InternalMessageInfo
+var xxx_messageInfo_StatsPruneReply proto.InternalMessageInfo
 
-func (m *RefreshRepositoryRequest) GetRehash() bool {
+func (m *StatsPruneReply) GetRemoved() int64 {
 	if m != nil {
-		return m.Rehash
+		return m.Removed
 	}
-	return false
+	return 0
 }
 
-type ScanMirrorRequest struct {
-	ID                   int32                    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	AutoEnable           bool                     `protobuf:"varint,2,opt,name=AutoEnable,proto3" json:"AutoEnable,omitempty"`
-	Protocol             ScanMirrorRequest_Method `protobuf:"varint,3,opt,name=Protocol,proto3,enum=ScanMirrorRequest_Method" json:"Protocol,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
-	XXX_unrecognized     []byte                   `json:"-"`
-	XXX_sizecache        int32                    `json:"-"`
+type GetMirrorLogsRequest struct {
+	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	MaxResults           int32                `protobuf:"varint,2,opt,name=MaxResults,proto3" json:"MaxResults,omitempty"`
+	Since                *timestamp.Timestamp `protobuf:"bytes,3,opt,name=Since,proto3" json:"Since,omitempty"`
+	Until                *timestamp.Timestamp `protobuf:"bytes,4,opt,name=Until,proto3" json:"Until,omitempty"`
+	Cursor               int64                `protobuf:"varint,5,opt,name=Cursor,proto3" json:"Cursor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
-func (m *ScanMirrorRequest) Reset()         { *m = ScanMirrorRequest{} }
-func (m *ScanMirrorRequest) String() string { return proto.CompactTextString(m) }
-func (*ScanMirrorRequest) ProtoMessage()    {}
-func (*ScanMirrorRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{12}
+func (m *GetMirrorLogsRequest) Reset()         { *m = GetMirrorLogsRequest{} }
+func (m *GetMirrorLogsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetMirrorLogsRequest) ProtoMessage()    {}
+func (*GetMirrorLogsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{48}
 }
 
-func (m *ScanMirrorRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ScanMirrorRequest.Unmarshal(m, b)
+func (m *GetMirrorLogsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMirrorLogsRequest.Unmarshal(m, b)
 }
-func (m *ScanMirrorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ScanMirrorRequest.Marshal(b, m, deterministic)
+func (m *GetMirrorLogsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMirrorLogsRequest.Marshal(b, m, deterministic)
 }
-func (m *ScanMirrorRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ScanMirrorRequest.Merge(m, src)
+func (m *GetMirrorLogsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMirrorLogsRequest.Merge(m, src)
 }
-func (m *ScanMirrorRequest) XXX_Size() int {
-	return xxx_messageInfo_ScanMirrorRequest.Size(m)
+func (m *GetMirrorLogsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetMirrorLogsRequest.Size(m)
 }
-func (m *ScanMirrorRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_ScanMirrorRequest.DiscardUnknown(m)
+func (m *GetMirrorLogsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMirrorLogsRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ScanMirrorRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetMirrorLogsRequest proto.InternalMessageInfo
 
-func (m *ScanMirrorRequest) GetID() int32 {
+func (m *GetMirrorLogsRequest) GetID() int32 {
 	if m != nil {
 		return m.ID
 	}
 	return 0
 }
 
-func (m *ScanMirrorRequest) GetAutoEnable() bool {
+func (m *GetMirrorLogsRequest) GetMaxResults() int32 {
 	if m != nil {
-		return m.AutoEnable
+		return m.MaxResults
 	}
-	return false
+	return 0
 }
 
-func (m *ScanMirrorRequest) GetProtocol() ScanMirrorRequest_Method {
+func (m *GetMirrorLogsRequest) GetSince() *timestamp.Timestamp {
 	if m != nil {
-		return m.Protocol
+		return m.Since
 	}
-	return ScanMirrorRequest_ALL
+	return nil
 }
 
-type ScanMirrorReply struct {
-	Enabled              bool     `protobuf:"varint,1,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
-	FilesIndexed         int64    `protobuf:"varint,2,opt,name=FilesIndexed,proto3" json:"FilesIndexed,omitempty"`
-	KnownIndexed         int64    `protobuf:"varint,3,opt,name=KnownIndexed,proto3" json:"KnownIndexed,omitempty"`
-	Removed              int64    `protobuf:"varint,4,opt,name=Removed,proto3" json:"Removed,omitempty"`
-	TZOffsetMs           int64    `protobuf:"varint,5,opt,name=TZOffsetMs,proto3" json:"TZOffsetMs,omitempty"`
+func (m *GetMirrorLogsRequest) GetUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.Until
+	}
+	return nil
+}
+
+func (m *GetMirrorLogsRequest) GetCursor() int64 {
+	if m != nil {
+		return m.Cursor
+	}
+	return 0
+}
+
+type GetMirrorLogsReply struct {
+	Line                 []string `protobuf:"bytes,1,rep,name=line,proto3" json:"line,omitempty"`
+	NextCursor           int64    `protobuf:"varint,2,opt,name=NextCursor,proto3" json:"NextCursor,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ScanMirrorReply) Reset()         { *m = ScanMirrorReply{} }
-func (m *ScanMirrorReply) String() string { return proto.CompactTextString(m) }
-func (*ScanMirrorReply) ProtoMessage()    {}
-func (*ScanMirrorReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{13}
+func (m *GetMirrorLogsReply) Reset()         { *m = GetMirrorLogsReply{} }
+func (m *GetMirrorLogsReply) String() string { return proto.CompactTextString(m) }
+func (*GetMirrorLogsReply) ProtoMessage()    {}
+func (*GetMirrorLogsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{49}
 }
 
-func (m *ScanMirrorReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ScanMirrorReply.Unmarshal(m, b)
+func (m *GetMirrorLogsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMirrorLogsReply.Unmarshal(m, b)
 }
-func (m *ScanMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ScanMirrorReply.Marshal(b, m, deterministic)
+func (m *GetMirrorLogsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMirrorLogsReply.Marshal(b, m, deterministic)
 }
-func (m *ScanMirrorReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ScanMirrorReply.Merge(m, src)
+func (m *GetMirrorLogsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMirrorLogsReply.Merge(m, src)
 }
-func (m *ScanMirrorReply) XXX_Size() int {
-	return xxx_messageInfo_ScanMirrorReply.Size(m)
+func (m *GetMirrorLogsReply) XXX_Size() int {
+	return xxx_messageInfo_GetMirrorLogsReply.Size(m)
 }
-func (m *ScanMirrorReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_ScanMirrorReply.DiscardUnknown(m)
+func (m *GetMirrorLogsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMirrorLogsReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ScanMirrorReply proto.InternalMessageInfo
+var xxx_messageInfo_GetMirrorLogsReply proto.InternalMessageInfo
 
-func (m *ScanMirrorReply) GetEnabled() bool {
+func (m *GetMirrorLogsReply) GetLine() []string {
 	if m != nil {
-		return m.Enabled
+		return m.Line
 	}
-	return false
+	return nil
 }
 
-func (m *ScanMirrorReply) GetFilesIndexed() int64 {
+func (m *GetMirrorLogsReply) GetNextCursor() int64 {
 	if m != nil {
-		return m.FilesIndexed
+		return m.NextCursor
 	}
 	return 0
 }
 
-func (m *ScanMirrorReply) GetKnownIndexed() int64 {
+type ClusterNode struct {
+	ID                   string               `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	LastAnnounce         *timestamp.Timestamp `protobuf:"bytes,2,opt,name=LastAnnounce,proto3" json:"LastAnnounce,omitempty"`
+	Self                 bool                 `protobuf:"varint,3,opt,name=Self,proto3" json:"Self,omitempty"`
+	Mirrors              []string             `protobuf:"bytes,4,rep,name=Mirrors,proto3" json:"Mirrors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ClusterNode) Reset()         { *m = ClusterNode{} }
+func (m *ClusterNode) String() string { return proto.CompactTextString(m) }
+func (*ClusterNode) ProtoMessage()    {}
+func (*ClusterNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{50}
+}
+
+func (m *ClusterNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterNode.Unmarshal(m, b)
+}
+func (m *ClusterNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterNode.Marshal(b, m, deterministic)
+}
+func (m *ClusterNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterNode.Merge(m, src)
+}
+func (m *ClusterNode) XXX_Size() int {
+	return xxx_messageInfo_ClusterNode.Size(m)
+}
+func (m *ClusterNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterNode proto.InternalMessageInfo
+
+func (m *ClusterNode) GetID() string {
 	if m != nil {
-		return m.KnownIndexed
+		return m.ID
 	}
-	return 0
+	return ""
 }
 
-func (m *ScanMirrorReply) GetRemoved() int64 {
+func (m *ClusterNode) GetLastAnnounce() *timestamp.Timestamp {
 	if m != nil {
-		return m.Removed
+		return m.LastAnnounce
 	}
-	return 0
+	return nil
 }
 
-func (m *ScanMirrorReply) GetTZOffsetMs() int64 {
+func (m *ClusterNode) GetSelf() bool {
 	if m != nil {
-		return m.TZOffsetMs
+		return m.Self
 	}
-	return 0
+	return false
 }
 
-type StatsFileRequest struct {
-	Pattern              string               `protobuf:"bytes,1,opt,name=Pattern,proto3" json:"Pattern,omitempty"`
-	DateStart            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=DateStart,proto3" json:"DateStart,omitempty"`
-	DateEnd              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=DateEnd,proto3" json:"DateEnd,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+func (m *ClusterNode) GetMirrors() []string {
+	if m != nil {
+		return m.Mirrors
+	}
+	return nil
 }
 
-func (m *StatsFileRequest) Reset()         { *m = StatsFileRequest{} }
-func (m *StatsFileRequest) String() string { return proto.CompactTextString(m) }
-func (*StatsFileRequest) ProtoMessage()    {}
-func (*StatsFileRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{14}
+type ClusterStatusReply struct {
+	Nodes                []*ClusterNode `protobuf:"bytes,1,rep,name=Nodes,proto3" json:"Nodes,omitempty"`
+	RedisReachable       bool           `protobuf:"varint,2,opt,name=RedisReachable,proto3" json:"RedisReachable,omitempty"`
+	Standby              bool           `protobuf:"varint,3,opt,name=Standby,proto3" json:"Standby,omitempty"`
+	Leader               string         `protobuf:"bytes,4,opt,name=Leader,proto3" json:"Leader,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *StatsFileRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StatsFileRequest.Unmarshal(m, b)
+func (m *ClusterStatusReply) Reset()         { *m = ClusterStatusReply{} }
+func (m *ClusterStatusReply) String() string { return proto.CompactTextString(m) }
+func (*ClusterStatusReply) ProtoMessage()    {}
+func (*ClusterStatusReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{51}
 }
-func (m *StatsFileRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StatsFileRequest.Marshal(b, m, deterministic)
+
+func (m *ClusterStatusReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterStatusReply.Unmarshal(m, b)
 }
-func (m *StatsFileRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StatsFileRequest.Merge(m, src)
+func (m *ClusterStatusReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterStatusReply.Marshal(b, m, deterministic)
 }
-func (m *StatsFileRequest) XXX_Size() int {
-	return xxx_messageInfo_StatsFileRequest.Size(m)
+func (m *ClusterStatusReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterStatusReply.Merge(m, src)
 }
-func (m *StatsFileRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_StatsFileRequest.DiscardUnknown(m)
+func (m *ClusterStatusReply) XXX_Size() int {
+	return xxx_messageInfo_ClusterStatusReply.Size(m)
+}
+func (m *ClusterStatusReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterStatusReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StatsFileRequest proto.InternalMessageInfo
+var xxx_messageInfo_ClusterStatusReply proto.InternalMessageInfo
 
-func (m *StatsFileRequest) GetPattern() string {
+func (m *ClusterStatusReply) GetNodes() []*ClusterNode {
 	if m != nil {
-		return m.Pattern
+		return m.Nodes
 	}
-	return ""
+	return nil
 }
 
-func (m *StatsFileRequest) GetDateStart() *timestamp.Timestamp {
+func (m *ClusterStatusReply) GetRedisReachable() bool {
 	if m != nil {
-		return m.DateStart
+		return m.RedisReachable
 	}
-	return nil
+	return false
 }
 
-func (m *StatsFileRequest) GetDateEnd() *timestamp.Timestamp {
+func (m *ClusterStatusReply) GetStandby() bool {
 	if m != nil {
-		return m.DateEnd
+		return m.Standby
 	}
-	return nil
+	return false
 }
 
-type StatsFileReply struct {
-	Files                map[string]int64 `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+func (m *ClusterStatusReply) GetLeader() string {
+	if m != nil {
+		return m.Leader
+	}
+	return ""
 }
 
-func (m *StatsFileReply) Reset()         { *m = StatsFileReply{} }
-func (m *StatsFileReply) String() string { return proto.CompactTextString(m) }
-func (*StatsFileReply) ProtoMessage()    {}
-func (*StatsFileReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{15}
+type SetStandbyRequest struct {
+	Standby              bool     `protobuf:"varint,1,opt,name=Standby,proto3" json:"Standby,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *StatsFileReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StatsFileReply.Unmarshal(m, b)
+func (m *SetStandbyRequest) Reset()         { *m = SetStandbyRequest{} }
+func (m *SetStandbyRequest) String() string { return proto.CompactTextString(m) }
+func (*SetStandbyRequest) ProtoMessage()    {}
+func (*SetStandbyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{52}
 }
-func (m *StatsFileReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StatsFileReply.Marshal(b, m, deterministic)
+
+func (m *SetStandbyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetStandbyRequest.Unmarshal(m, b)
 }
-func (m *StatsFileReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StatsFileReply.Merge(m, src)
+func (m *SetStandbyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetStandbyRequest.Marshal(b, m, deterministic)
 }
-func (m *StatsFileReply) XXX_Size() int {
-	return xxx_messageInfo_StatsFileReply.Size(m)
+func (m *SetStandbyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetStandbyRequest.Merge(m, src)
 }
-func (m *StatsFileReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_StatsFileReply.DiscardUnknown(m)
+func (m *SetStandbyRequest) XXX_Size() int {
+	return xxx_messageInfo_SetStandbyRequest.Size(m)
+}
+func (m *SetStandbyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetStandbyRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StatsFileReply proto.InternalMessageInfo
+var xxx_messageInfo_SetStandbyRequest proto.InternalMessageInfo
 
-func (m *StatsFileReply) GetFiles() map[string]int64 {
+func (m *SetStandbyRequest) GetStandby() bool {
 	if m != nil {
-		return m.Files
+		return m.Standby
 	}
-	return nil
+	return false
 }
 
-type StatsMirrorRequest struct {
-	ID                   int32                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	DateStart            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=DateStart,proto3" json:"DateStart,omitempty"`
-	DateEnd              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=DateEnd,proto3" json:"DateEnd,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+type DumpRecord struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Type                 string   `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	Values               [][]byte `protobuf:"bytes,3,rep,name=Values,proto3" json:"Values,omitempty"`
+	TTL                  int64    `protobuf:"varint,4,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *StatsMirrorRequest) Reset()         { *m = StatsMirrorRequest{} }
-func (m *StatsMirrorRequest) String() string { return proto.CompactTextString(m) }
-func (*StatsMirrorRequest) ProtoMessage()    {}
-func (*StatsMirrorRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{16}
+func (m *DumpRecord) Reset()         { *m = DumpRecord{} }
+func (m *DumpRecord) String() string { return proto.CompactTextString(m) }
+func (*DumpRecord) ProtoMessage()    {}
+func (*DumpRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{53}
 }
 
-func (m *StatsMirrorRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StatsMirrorRequest.Unmarshal(m, b)
+func (m *DumpRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DumpRecord.Unmarshal(m, b)
 }
-func (m *StatsMirrorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StatsMirrorRequest.Marshal(b, m, deterministic)
+func (m *DumpRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DumpRecord.Marshal(b, m, deterministic)
 }
-func (m *StatsMirrorRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StatsMirrorRequest.Merge(m, src)
+func (m *DumpRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DumpRecord.Merge(m, src)
 }
-func (m *StatsMirrorRequest) XXX_Size() int {
-	return xxx_messageInfo_StatsMirrorRequest.Size(m)
+func (m *DumpRecord) XXX_Size() int {
+	return xxx_messageInfo_DumpRecord.Size(m)
 }
-func (m *StatsMirrorRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_StatsMirrorRequest.DiscardUnknown(m)
+func (m *DumpRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_DumpRecord.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StatsMirrorRequest proto.InternalMessageInfo
+var xxx_messageInfo_DumpRecord proto.InternalMessageInfo
 
-func (m *StatsMirrorRequest) GetID() int32 {
+func (m *DumpRecord) GetKey() string {
 	if m != nil {
-		return m.ID
+		return m.Key
 	}
-	return 0
+	return ""
 }
 
-func (m *StatsMirrorRequest) GetDateStart() *timestamp.Timestamp {
+func (m *DumpRecord) GetType() string {
 	if m != nil {
-		return m.DateStart
+		return m.Type
 	}
-	return nil
+	return ""
 }
 
-func (m *StatsMirrorRequest) GetDateEnd() *timestamp.Timestamp {
+func (m *DumpRecord) GetValues() [][]byte {
 	if m != nil {
-		return m.DateEnd
+		return m.Values
 	}
 	return nil
 }
 
-type StatsMirrorReply struct {
-	Mirror               *Mirror  `protobuf:"bytes,1,opt,name=Mirror,proto3" json:"Mirror,omitempty"`
-	Requests             int64    `protobuf:"varint,2,opt,name=Requests,proto3" json:"Requests,omitempty"`
-	Bytes                int64    `protobuf:"varint,3,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *DumpRecord) GetTTL() int64 {
+	if m != nil {
+		return m.TTL
+	}
+	return 0
 }
 
-func (m *StatsMirrorReply) Reset()         { *m = StatsMirrorReply{} }
-func (m *StatsMirrorReply) String() string { return proto.CompactTextString(m) }
-func (*StatsMirrorReply) ProtoMessage()    {}
-func (*StatsMirrorReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{17}
+type RestoreDatabaseRequest struct {
+	Record               *DumpRecord `protobuf:"bytes,1,opt,name=Record,proto3" json:"Record,omitempty"`
+	Force                bool        `protobuf:"varint,2,opt,name=Force,proto3" json:"Force,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
 }
 
-func (m *StatsMirrorReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StatsMirrorReply.Unmarshal(m, b)
-}
-func (m *StatsMirrorReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StatsMirrorReply.Marshal(b, m, deterministic)
+func (m *RestoreDatabaseRequest) Reset()         { *m = RestoreDatabaseRequest{} }
+func (m *RestoreDatabaseRequest) String() string { return proto.CompactTextString(m) }
+func (*RestoreDatabaseRequest) ProtoMessage()    {}
+func (*RestoreDatabaseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{54}
 }
-func (m *StatsMirrorReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StatsMirrorReply.Merge(m, src)
-}
-func (m *StatsMirrorReply) XXX_Size() int {
-	return xxx_messageInfo_StatsMirrorReply.Size(m)
+
+func (m *RestoreDatabaseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreDatabaseRequest.Unmarshal(m, b)
 }
-func (m *StatsMirrorReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_StatsMirrorReply.DiscardUnknown(m)
+func (m *RestoreDatabaseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreDatabaseRequest.Marshal(b, m, deterministic)
 }
-
-var xxx_messageInfo_StatsMirrorReply proto.InternalMessageInfo
-
-func (m *StatsMirrorReply) GetMirror() *Mirror {
-	if m != nil {
-		return m.Mirror
-	}
-	return nil
+func (m *RestoreDatabaseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreDatabaseRequest.Merge(m, src)
+}
+func (m *RestoreDatabaseRequest) XXX_Size() int {
+	return xxx_messageInfo_RestoreDatabaseRequest.Size(m)
+}
+func (m *RestoreDatabaseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreDatabaseRequest.DiscardUnknown(m)
 }
 
-func (m *StatsMirrorReply) GetRequests() int64 {
+var xxx_messageInfo_RestoreDatabaseRequest proto.InternalMessageInfo
+
+func (m *RestoreDatabaseRequest) GetRecord() *DumpRecord {
 	if m != nil {
-		return m.Requests
+		return m.Record
 	}
-	return 0
+	return nil
 }
 
-func (m *StatsMirrorReply) GetBytes() int64 {
+func (m *RestoreDatabaseRequest) GetForce() bool {
 	if m != nil {
-		return m.Bytes
+		return m.Force
 	}
-	return 0
+	return false
 }
 
-type GetMirrorLogsRequest struct {
-	ID                   int32    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	MaxResults           int32    `protobuf:"varint,2,opt,name=MaxResults,proto3" json:"MaxResults,omitempty"`
+type RestoreDatabaseReply struct {
+	Restored             int64    `protobuf:"varint,1,opt,name=Restored,proto3" json:"Restored,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetMirrorLogsRequest) Reset()         { *m = GetMirrorLogsRequest{} }
-func (m *GetMirrorLogsRequest) String() string { return proto.CompactTextString(m) }
-func (*GetMirrorLogsRequest) ProtoMessage()    {}
-func (*GetMirrorLogsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{18}
+func (m *RestoreDatabaseReply) Reset()         { *m = RestoreDatabaseReply{} }
+func (m *RestoreDatabaseReply) String() string { return proto.CompactTextString(m) }
+func (*RestoreDatabaseReply) ProtoMessage()    {}
+func (*RestoreDatabaseReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{55}
 }
 
-func (m *GetMirrorLogsRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetMirrorLogsRequest.Unmarshal(m, b)
+func (m *RestoreDatabaseReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreDatabaseReply.Unmarshal(m, b)
 }
-func (m *GetMirrorLogsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetMirrorLogsRequest.Marshal(b, m, deterministic)
+func (m *RestoreDatabaseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreDatabaseReply.Marshal(b, m, deterministic)
 }
-func (m *GetMirrorLogsRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetMirrorLogsRequest.Merge(m, src)
+func (m *RestoreDatabaseReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreDatabaseReply.Merge(m, src)
 }
-func (m *GetMirrorLogsRequest) XXX_Size() int {
-	return xxx_messageInfo_GetMirrorLogsRequest.Size(m)
+func (m *RestoreDatabaseReply) XXX_Size() int {
+	return xxx_messageInfo_RestoreDatabaseReply.Size(m)
 }
-func (m *GetMirrorLogsRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetMirrorLogsRequest.DiscardUnknown(m)
+func (m *RestoreDatabaseReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreDatabaseReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetMirrorLogsRequest proto.InternalMessageInfo
-
-func (m *GetMirrorLogsRequest) GetID() int32 {
-	if m != nil {
-		return m.ID
-	}
-	return 0
-}
+var xxx_messageInfo_RestoreDatabaseReply proto.InternalMessageInfo
 
-func (m *GetMirrorLogsRequest) GetMaxResults() int32 {
+func (m *RestoreDatabaseReply) GetRestored() int64 {
 	if m != nil {
-		return m.MaxResults
+		return m.Restored
 	}
 	return 0
 }
 
-type GetMirrorLogsReply struct {
-	Line                 []string `protobuf:"bytes,1,rep,name=line,proto3" json:"line,omitempty"`
+type GetConfigReply struct {
+	ConfigFile           string   `protobuf:"bytes,1,opt,name=ConfigFile,proto3" json:"ConfigFile,omitempty"`
+	Config               string   `protobuf:"bytes,2,opt,name=Config,proto3" json:"Config,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetMirrorLogsReply) Reset()         { *m = GetMirrorLogsReply{} }
-func (m *GetMirrorLogsReply) String() string { return proto.CompactTextString(m) }
-func (*GetMirrorLogsReply) ProtoMessage()    {}
-func (*GetMirrorLogsReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{19}
+func (m *GetConfigReply) Reset()         { *m = GetConfigReply{} }
+func (m *GetConfigReply) String() string { return proto.CompactTextString(m) }
+func (*GetConfigReply) ProtoMessage()    {}
+func (*GetConfigReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{56}
 }
 
-func (m *GetMirrorLogsReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetMirrorLogsReply.Unmarshal(m, b)
+func (m *GetConfigReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetConfigReply.Unmarshal(m, b)
 }
-func (m *GetMirrorLogsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetMirrorLogsReply.Marshal(b, m, deterministic)
+func (m *GetConfigReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetConfigReply.Marshal(b, m, deterministic)
 }
-func (m *GetMirrorLogsReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetMirrorLogsReply.Merge(m, src)
+func (m *GetConfigReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetConfigReply.Merge(m, src)
 }
-func (m *GetMirrorLogsReply) XXX_Size() int {
-	return xxx_messageInfo_GetMirrorLogsReply.Size(m)
+func (m *GetConfigReply) XXX_Size() int {
+	return xxx_messageInfo_GetConfigReply.Size(m)
 }
-func (m *GetMirrorLogsReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetMirrorLogsReply.DiscardUnknown(m)
+func (m *GetConfigReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetConfigReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetMirrorLogsReply proto.InternalMessageInfo
+var xxx_messageInfo_GetConfigReply proto.InternalMessageInfo
 
-func (m *GetMirrorLogsReply) GetLine() []string {
+func (m *GetConfigReply) GetConfigFile() string {
 	if m != nil {
-		return m.Line
+		return m.ConfigFile
 	}
-	return nil
+	return ""
+}
+
+func (m *GetConfigReply) GetConfig() string {
+	if m != nil {
+		return m.Config
+	}
+	return ""
 }
 
 func init() {
+	proto.RegisterEnum("MirrorListRequest_Filter", MirrorListRequest_Filter_name, MirrorListRequest_Filter_value)
+	proto.RegisterEnum("SimulateSelectionRequest_Protocol", SimulateSelectionRequest_Protocol_name, SimulateSelectionRequest_Protocol_value)
 	proto.RegisterEnum("ScanMirrorRequest_Method", ScanMirrorRequest_Method_name, ScanMirrorRequest_Method_value)
+	proto.RegisterEnum("StatsTopRequest_PeriodType", StatsTopRequest_PeriodType_name, StatsTopRequest_PeriodType_value)
 	proto.RegisterType((*VersionReply)(nil), "VersionReply")
 	proto.RegisterType((*MatchRequest)(nil), "MatchRequest")
 	proto.RegisterType((*Mirror)(nil), "Mirror")
+	proto.RegisterType((*AltURL)(nil), "AltURL")
+	proto.RegisterType((*NodeHealth)(nil), "NodeHealth")
+	proto.RegisterType((*MirrorListRequest)(nil), "MirrorListRequest")
 	proto.RegisterType((*MirrorListReply)(nil), "MirrorListReply")
 	proto.RegisterType((*MirrorID)(nil), "MirrorID")
+	proto.RegisterType((*SimulateSelectionRequest)(nil), "SimulateSelectionRequest")
+	proto.RegisterType((*SelectedMirror)(nil), "SelectedMirror")
+	proto.RegisterType((*SimulateSelectionReply)(nil), "SimulateSelectionReply")
+	proto.RegisterType((*HypotheticalMirror)(nil), "HypotheticalMirror")
+	proto.RegisterType((*SimulateTrafficRequest)(nil), "SimulateTrafficRequest")
+	proto.RegisterType((*TrafficShare)(nil), "TrafficShare")
+	proto.RegisterType((*SimulateTrafficReply)(nil), "SimulateTrafficReply")
+	proto.RegisterType((*FileInfoRequest)(nil), "FileInfoRequest")
+	proto.RegisterType((*FileInfoReply)(nil), "FileInfoReply")
+	proto.RegisterType((*WatchEventsRequest)(nil), "WatchEventsRequest")
+	proto.RegisterType((*Event)(nil), "Event")
+	proto.RegisterType((*CheckMirrorsRequest)(nil), "CheckMirrorsRequest")
+	proto.RegisterType((*CheckMirrorsReply)(nil), "CheckMirrorsReply")
 	proto.RegisterType((*MatchReply)(nil), "MatchReply")
 	proto.RegisterType((*ChangeStatusRequest)(nil), "ChangeStatusRequest")
+	proto.RegisterType((*SetMaintenanceRequest)(nil), "SetMaintenanceRequest")
 	proto.RegisterType((*MirrorIDRequest)(nil), "MirrorIDRequest")
+	proto.RegisterType((*RemoveMirrorRequest)(nil), "RemoveMirrorRequest")
+	proto.RegisterType((*RemovalReportReply)(nil), "RemovalReportReply")
+	proto.RegisterType((*RemovedMirror)(nil), "RemovedMirror")
+	proto.RegisterType((*RemovedMirrorsReply)(nil), "RemovedMirrorsReply")
 	proto.RegisterType((*AddMirrorReply)(nil), "AddMirrorReply")
+	proto.RegisterType((*AddMirrorsRequest)(nil), "AddMirrorsRequest")
+	proto.RegisterType((*AddMirrorResult)(nil), "AddMirrorResult")
+	proto.RegisterType((*AddMirrorsReply)(nil), "AddMirrorsReply")
 	proto.RegisterType((*UpdateMirrorReply)(nil), "UpdateMirrorReply")
 	proto.RegisterType((*GeoUpdateMirrorReply)(nil), "GeoUpdateMirrorReply")
 	proto.RegisterType((*RefreshRepositoryRequest)(nil), "RefreshRepositoryRequest")
@@ -1303,8 +4011,22 @@ func init() {
 	proto.RegisterMapType((map[string]int64)(nil), "StatsFileReply.FilesEntry")
 	proto.RegisterType((*StatsMirrorRequest)(nil), "StatsMirrorRequest")
 	proto.RegisterType((*StatsMirrorReply)(nil), "StatsMirrorReply")
+	proto.RegisterType((*StatsHTTPRequest)(nil), "StatsHTTPRequest")
+	proto.RegisterType((*StatsHTTPReply)(nil), "StatsHTTPReply")
+	proto.RegisterType((*StatsHTTPReply_Response)(nil), "StatsHTTPReply.Response")
+	proto.RegisterType((*StatsTopRequest)(nil), "StatsTopRequest")
+	proto.RegisterType((*FileDownloads)(nil), "FileDownloads")
+	proto.RegisterType((*StatsTopReply)(nil), "StatsTopReply")
+	proto.RegisterType((*StatsPruneReply)(nil), "StatsPruneReply")
 	proto.RegisterType((*GetMirrorLogsRequest)(nil), "GetMirrorLogsRequest")
 	proto.RegisterType((*GetMirrorLogsReply)(nil), "GetMirrorLogsReply")
+	proto.RegisterType((*ClusterNode)(nil), "ClusterNode")
+	proto.RegisterType((*ClusterStatusReply)(nil), "ClusterStatusReply")
+	proto.RegisterType((*SetStandbyRequest)(nil), "SetStandbyRequest")
+	proto.RegisterType((*DumpRecord)(nil), "DumpRecord")
+	proto.RegisterType((*RestoreDatabaseRequest)(nil), "RestoreDatabaseRequest")
+	proto.RegisterType((*RestoreDatabaseReply)(nil), "RestoreDatabaseReply")
+	proto.RegisterType((*GetConfigReply)(nil), "GetConfigReply")
 }
 
 func init() {
@@ -1312,98 +4034,239 @@ func init() {
 }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 1445 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0x4d, 0x73, 0x1b, 0x45,
-	0x13, 0xd6, 0x4a, 0xb6, 0x65, 0xb5, 0x64, 0x5b, 0x1e, 0x3b, 0x7e, 0x37, 0x4a, 0xde, 0x44, 0x19,
-	0x3e, 0x22, 0x8a, 0x62, 0x43, 0x4c, 0x02, 0xae, 0x10, 0xa0, 0x84, 0xfc, 0x11, 0x83, 0x1c, 0xbb,
-	0x56, 0x31, 0x14, 0xdc, 0x36, 0xda, 0x91, 0xbc, 0xc5, 0x6a, 0x47, 0xec, 0x8c, 0x12, 0xab, 0x8a,
-	0x9f, 0x41, 0x71, 0xe2, 0x00, 0x3f, 0x80, 0x2a, 0x8e, 0xfc, 0x3c, 0xaa, 0x67, 0x66, 0xa5, 0xd5,
-	0xca, 0x1f, 0x90, 0x03, 0xb7, 0xed, 0xa7, 0x7b, 0xa6, 0x7b, 0x7a, 0xba, 0x9f, 0x9e, 0x85, 0x52,
-	0x3c, 0xec, 0x3a, 0xc3, 0x98, 0x4b, 0x5e, 0xbb, 0xd5, 0xe7, 0xbc, 0x1f, 0xb2, 0x07, 0x4a, 0x7a,
-	0x39, 0xea, 0x3d, 0x60, 0x83, 0xa1, 0x1c, 0x1b, 0xe5, 0xdd, 0xac, 0x52, 0x06, 0x03, 0x26, 0xa4,
-	0x37, 0x18, 0x6a, 0x03, 0xfa, 0x9b, 0x05, 0x95, 0x6f, 0x58, 0x2c, 0x02, 0x1e, 0xb9, 0x6c, 0x18,
-	0x8e, 0x89, 0x0d, 0x45, 0x23, 0xdb, 0x56, 0xdd, 0x6a, 0x94, 0xdc, 0x44, 0x24, 0x9b, 0xb0, 0xf8,
-	0xe5, 0x28, 0x08, 0x7d, 0x3b, 0xaf, 0x70, 0x2d, 0x90, 0xdb, 0x50, 0x3a, 0xe0, 0xc9, 0x8a, 0x82,
-	0xd2, 0x4c, 0x01, 0xb2, 0x0a, 0xf9, 0xe3, 0x8e, 0xbd, 0xa0, 0xe0, 0xfc, 0x71, 0x87, 0x10, 0x58,
-	0x68, 0xc6, 0xdd, 0x33, 0x7b, 0x51, 0x21, 0xea, 0x9b, 0xdc, 0x01, 0x38, 0xe0, 0x47, 0xde, 0xf9,
-	0x49, 0xcc, 0xbb, 0xc2, 0x5e, 0xaa, 0x5b, 0x8d, 0x45, 0x37, 0x85, 0xd0, 0x06, 0x54, 0x8e, 0x3c,
-	0xd9, 0x3d, 0x73, 0xd9, 0x8f, 0x23, 0x26, 0x24, 0x46, 0x78, 0xe2, 0x49, 0xc9, 0xe2, 0x49, 0x84,
-	0x46, 0xa4, 0xbf, 0x2c, 0xc3, 0xd2, 0x51, 0x10, 0xc7, 0x3c, 0x46, 0xc7, 0x87, 0xbb, 0x4a, 0xbf,
-	0xe8, 0xe6, 0x0f, 0x77, 0xd1, 0xf1, 0x73, 0x6f, 0xc0, 0x4c, 0xec, 0xea, 0x1b, 0x37, 0x7a, 0x26,
-	0xe5, 0xf0, 0xd4, 0x6d, 0x9b, 0xc0, 0x13, 0x91, 0xd4, 0x60, 0xd9, 0x15, 0xe3, 0xa8, 0x8b, 0x2a,
-	0x1d, 0xfc, 0x44, 0x26, 0x5b, 0xb0, 0xb4, 0xaf, 0x17, 0xe9, 0x43, 0x18, 0x89, 0xd4, 0xa1, 0xdc,
-	0x19, 0xf2, 0x48, 0xf0, 0x58, 0x39, 0x5a, 0x52, 0xca, 0x34, 0x84, 0x07, 0x35, 0x22, 0xae, 0x2e,
-	0x2a, 0x83, 0x14, 0x42, 0xde, 0x85, 0x55, 0x23, 0xb5, 0x79, 0x9f, 0xa3, 0xcd, 0xb2, 0xb2, 0xc9,
-	0xa0, 0x98, 0xf2, 0xa6, 0x3f, 0x08, 0x22, 0xe5, 0xa7, 0xa4, 0x53, 0x3e, 0x01, 0xd0, 0x8b, 0x12,
-	0xf6, 0x06, 0x5e, 0x10, 0xda, 0xa0, 0xbd, 0x4c, 0x11, 0xd4, 0xb7, 0x46, 0x42, 0xf2, 0xc1, 0xae,
-	0x27, 0x3d, 0xbb, 0xac, 0xf5, 0x53, 0x84, 0xbc, 0x0d, 0x2b, 0x2d, 0x1e, 0xc9, 0x20, 0x62, 0x91,
-	0x3c, 0x8e, 0xc2, 0xb1, 0x5d, 0xa9, 0x5b, 0x8d, 0x65, 0x77, 0x16, 0xc4, 0xd3, 0xb6, 0xf8, 0x28,
-	0x92, 0xf1, 0x58, 0xd9, 0xac, 0x28, 0x9b, 0x34, 0x84, 0x79, 0x6a, 0x76, 0x94, 0x72, 0x55, 0x29,
-	0x8d, 0x84, 0x65, 0xd4, 0xe9, 0xf2, 0x98, 0xd9, 0x6b, 0xea, 0x72, 0xb4, 0x80, 0x19, 0x6f, 0x7b,
-	0x32, 0x90, 0x23, 0x9f, 0xd9, 0xd5, 0xba, 0xd5, 0xc8, 0xbb, 0x13, 0x19, 0xcf, 0xdb, 0xe6, 0x51,
-	0x5f, 0x2b, 0xd7, 0x95, 0x72, 0x0a, 0xcc, 0xc4, 0xdb, 0xe2, 0x3e, 0xb3, 0x89, 0x3a, 0xd2, 0x2c,
-	0x48, 0x28, 0x54, 0x4c, 0x70, 0x28, 0x0a, 0x7b, 0x43, 0x19, 0xcd, 0x60, 0x64, 0x1b, 0x36, 0xf7,
-	0xce, 0xbb, 0xe1, 0xc8, 0x67, 0xfe, 0x8c, 0xed, 0xa6, 0xb2, 0xbd, 0x50, 0x87, 0xa7, 0x69, 0x8a,
-	0x68, 0x34, 0xb0, 0x6f, 0xd4, 0xad, 0xc6, 0x8a, 0xab, 0x05, 0xac, 0xac, 0x16, 0x1f, 0x0c, 0x58,
-	0x24, 0xed, 0x2d, 0x5d, 0x59, 0x46, 0x44, 0xcd, 0x5e, 0xe4, 0xbd, 0x0c, 0x99, 0x6f, 0xff, 0x4f,
-	0xa5, 0x25, 0x11, 0xb1, 0x62, 0x4f, 0x87, 0xb6, 0xad, 0xc0, 0xfc, 0xe9, 0x10, 0xcf, 0x65, 0x3c,
-	0xba, 0xcc, 0x13, 0x3c, 0xb2, 0x6f, 0xea, 0x73, 0xcd, 0x80, 0xe4, 0x09, 0x40, 0x47, 0x7a, 0x92,
-	0x75, 0x82, 0xa8, 0xcb, 0xec, 0x5a, 0xdd, 0x6a, 0x94, 0xb7, 0x6b, 0x8e, 0xee, 0x7a, 0x27, 0xe9,
-	0x7a, 0xe7, 0x45, 0xd2, 0xf5, 0x6e, 0xca, 0x1a, 0xeb, 0xad, 0x19, 0x86, 0xfc, 0xb5, 0xcb, 0xfc,
-	0x20, 0x66, 0x5d, 0x29, 0xec, 0x5b, 0xea, 0x4a, 0x32, 0x28, 0xf9, 0x18, 0xef, 0x46, 0xc8, 0xce,
-	0x38, 0xea, 0xda, 0xb7, 0xaf, 0xf5, 0x30, 0xb1, 0x25, 0x5f, 0x01, 0x51, 0xdf, 0xa3, 0x6e, 0x97,
-	0x09, 0xd1, 0x1b, 0x85, 0x6a, 0x87, 0xff, 0x5f, 0xbb, 0xc3, 0x05, 0xab, 0xc8, 0x53, 0x28, 0x23,
-	0x7a, 0xc4, 0x7d, 0xb4, 0xb3, 0xef, 0x5c, 0xbb, 0x49, 0xda, 0x9c, 0x3e, 0x82, 0x35, 0xcd, 0x0b,
-	0xed, 0x40, 0x48, 0xcd, 0x73, 0xf7, 0xa0, 0xa8, 0x21, 0x61, 0x5b, 0xf5, 0x42, 0xa3, 0xbc, 0x5d,
-	0x74, 0xb4, 0xec, 0x26, 0x38, 0x75, 0x60, 0x59, 0x7f, 0x1e, 0xee, 0xfe, 0x13, 0x3e, 0xa1, 0x0f,
-	0x01, 0x0c, 0x51, 0xa1, 0x83, 0xb7, 0xb2, 0x0e, 0x4a, 0x4e, 0xb2, 0xdb, 0xd4, 0xc5, 0x17, 0xb0,
-	0xd1, 0x3a, 0xf3, 0xa2, 0x3e, 0xc3, 0x6b, 0x19, 0x89, 0x84, 0xe2, 0xb2, 0xde, 0x52, 0x55, 0x93,
-	0x9f, 0xa9, 0x1a, 0x7a, 0x2f, 0x39, 0xd9, 0xe1, 0xee, 0x25, 0x8b, 0xe9, 0x9f, 0x16, 0xac, 0x36,
-	0x7d, 0xdf, 0x9c, 0x4e, 0xc5, 0x96, 0xee, 0x36, 0xeb, 0xaa, 0x6e, 0xcb, 0x67, 0xbb, 0x4d, 0x55,
-	0xb6, 0xaa, 0xff, 0x84, 0x33, 0x8d, 0x88, 0xeb, 0x26, 0x2d, 0x67, 0x48, 0x73, 0x0a, 0x90, 0x2a,
-	0x14, 0x9a, 0x9d, 0xe7, 0x86, 0x32, 0xf1, 0x13, 0x63, 0xf8, 0xd6, 0x8b, 0xa3, 0x20, 0xea, 0x23,
-	0xe9, 0x17, 0x90, 0x63, 0x13, 0x99, 0xde, 0x87, 0xf5, 0xd3, 0xa1, 0xef, 0x49, 0x96, 0x0e, 0x9a,
-	0xc0, 0xc2, 0x6e, 0xd0, 0xeb, 0x19, 0xd2, 0x57, 0xdf, 0xb4, 0x0f, 0x9b, 0x07, 0x8c, 0xcf, 0xdb,
-	0xde, 0x4d, 0x06, 0x81, 0xb2, 0x4e, 0x5d, 0x6e, 0x32, 0x1f, 0x92, 0xcd, 0xf2, 0xd3, 0xcd, 0x66,
-	0x22, 0x2a, 0x64, 0x22, 0xda, 0x06, 0xdb, 0x65, 0xbd, 0x98, 0x09, 0xbc, 0x5d, 0x2e, 0x02, 0xc9,
-	0xe3, 0x71, 0x92, 0xf0, 0x2d, 0x58, 0x72, 0xd9, 0x99, 0x27, 0xce, 0x94, 0xb3, 0x65, 0xd7, 0x48,
-	0xf4, 0x77, 0x0b, 0xd6, 0x3b, 0x5d, 0x2f, 0x4a, 0x02, 0xbb, 0xf8, 0x6e, 0x91, 0xaf, 0x47, 0x92,
-	0xeb, 0x0b, 0x35, 0xd7, 0x9b, 0x42, 0xc8, 0x63, 0x58, 0x3e, 0xc1, 0xf2, 0xee, 0xf2, 0x50, 0xa5,
-	0x7c, 0x75, 0xfb, 0xa6, 0x33, 0xb7, 0xab, 0x73, 0xc4, 0xe4, 0x19, 0xf7, 0xdd, 0x89, 0x29, 0x7d,
-	0x07, 0x96, 0x34, 0x46, 0x8a, 0x50, 0x68, 0xb6, 0xdb, 0xd5, 0x1c, 0x7e, 0xec, 0xbf, 0x38, 0xa9,
-	0x5a, 0xa4, 0x04, 0x8b, 0x6e, 0xe7, 0xbb, 0xe7, 0xad, 0x6a, 0x9e, 0xfe, 0x61, 0xc1, 0x5a, 0x7a,
-	0x37, 0xf3, 0x04, 0x48, 0xaa, 0xcd, 0x9a, 0xe5, 0x28, 0x0a, 0x95, 0xfd, 0x20, 0x64, 0xe2, 0x30,
-	0xf2, 0xd9, 0xb9, 0x29, 0xc6, 0x82, 0x3b, 0x83, 0xa1, 0xcd, 0xd7, 0x11, 0x7f, 0x1d, 0x25, 0x36,
-	0x05, 0x6d, 0x93, 0xc6, 0xd0, 0x83, 0xcb, 0x06, 0xfc, 0x15, 0xf3, 0x55, 0xa5, 0x14, 0xdc, 0x44,
-	0xc4, 0x6c, 0xbc, 0xf8, 0xfe, 0xb8, 0xd7, 0x13, 0x4c, 0x1e, 0x09, 0x55, 0x2e, 0x05, 0x37, 0x85,
-	0xd0, 0x5f, 0x2d, 0xa8, 0x62, 0xaf, 0x08, 0xf4, 0x79, 0xed, 0x8b, 0x80, 0xec, 0x40, 0x69, 0x17,
-	0xf9, 0x4e, 0x7a, 0xb1, 0x54, 0xd1, 0x5e, 0x4d, 0x1a, 0x53, 0x63, 0xf2, 0x08, 0x8a, 0x28, 0xec,
-	0x45, 0xfa, 0x04, 0x57, 0xaf, 0x4b, 0x4c, 0xe9, 0x4f, 0xb0, 0x9a, 0x8a, 0x0e, 0x93, 0xf9, 0x21,
-	0x2c, 0xf6, 0x30, 0x3d, 0x86, 0x04, 0x6a, 0xce, 0xac, 0xde, 0x51, 0xb9, 0xdb, 0xc3, 0x0e, 0x72,
-	0xb5, 0x61, 0x6d, 0x07, 0x60, 0x0a, 0x62, 0xe3, 0xfc, 0xc0, 0xc6, 0xe6, 0x5c, 0xf8, 0x89, 0x23,
-	0xe7, 0x95, 0x17, 0x8e, 0x98, 0xc9, 0xbe, 0x16, 0x9e, 0xe4, 0x77, 0x2c, 0xfa, 0xb3, 0x05, 0x44,
-	0x6d, 0x7f, 0x75, 0xc5, 0xfd, 0xd7, 0x49, 0x61, 0xe6, 0xca, 0xfe, 0x55, 0x83, 0xe2, 0x13, 0x4c,
-	0xc7, 0x2f, 0xcc, 0x41, 0x27, 0xb2, 0x7a, 0x89, 0x8e, 0x25, 0x13, 0xa6, 0xb6, 0xb4, 0x40, 0xf7,
-	0x91, 0x0b, 0xa4, 0xe1, 0x79, 0xde, 0x17, 0x57, 0x34, 0xdc, 0x91, 0x77, 0xee, 0x32, 0x31, 0x0a,
-	0xcd, 0xde, 0x8b, 0x6e, 0x0a, 0xa1, 0x0d, 0x20, 0x99, 0x7d, 0x0c, 0xfb, 0x84, 0x41, 0xc4, 0xd4,
-	0x35, 0x96, 0x5c, 0xf5, 0xbd, 0xfd, 0x57, 0x11, 0x0a, 0xad, 0xf6, 0x21, 0x79, 0x0c, 0x70, 0xc0,
-	0x64, 0xf2, 0xe6, 0xdd, 0x9a, 0xcb, 0xc9, 0x1e, 0xbe, 0xc8, 0x6b, 0x2b, 0x4e, 0xfa, 0xa1, 0x4d,
-	0x73, 0xe4, 0x53, 0x28, 0x9e, 0x0e, 0xfb, 0xb1, 0xe7, 0xb3, 0x4b, 0xd7, 0x5c, 0x82, 0xd3, 0x1c,
-	0x79, 0x82, 0xa4, 0x13, 0x72, 0xcf, 0x7f, 0x83, 0xb5, 0x9f, 0x43, 0x25, 0x3d, 0x75, 0xc8, 0xa6,
-	0x73, 0xc1, 0x10, 0xba, 0x62, 0xfd, 0x36, 0x2c, 0xe0, 0x20, 0xbd, 0xd4, 0x73, 0xd5, 0xc9, 0x4c,
-	0x5b, 0x9a, 0x23, 0xef, 0x01, 0x98, 0x41, 0x15, 0xf5, 0x38, 0xa9, 0x3a, 0x99, 0xa9, 0x55, 0x4b,
-	0x0a, 0x80, 0xe6, 0xc8, 0x7d, 0x7c, 0xdf, 0x9a, 0x79, 0x45, 0x12, 0xbc, 0xb6, 0xe6, 0xcc, 0x0e,
-	0x31, 0x9a, 0x23, 0x1f, 0x40, 0x25, 0x4d, 0xfd, 0x53, 0x5b, 0xe2, 0xcc, 0x8d, 0x04, 0x95, 0xb2,
-	0x8a, 0xa6, 0x19, 0x63, 0x3e, 0x1f, 0xc4, 0xe5, 0x47, 0x7e, 0x0a, 0x6b, 0x99, 0x41, 0x73, 0xc1,
-	0xf2, 0x1b, 0xce, 0x45, 0xc3, 0x88, 0xe6, 0xc8, 0x33, 0x58, 0x9f, 0x9b, 0x1e, 0xe4, 0xa6, 0x73,
-	0xd9, 0x44, 0xb9, 0x22, 0x8e, 0x47, 0x00, 0x53, 0xba, 0x26, 0x64, 0x7e, 0x12, 0xd4, 0xaa, 0x4e,
-	0x86, 0xcf, 0x69, 0x8e, 0x3c, 0x84, 0xd2, 0x84, 0x76, 0xc8, 0xba, 0x93, 0x25, 0xd0, 0xda, 0x5a,
-	0x86, 0x95, 0x68, 0x8e, 0x7c, 0x02, 0xe5, 0x54, 0xd3, 0x92, 0x0d, 0x67, 0x9e, 0x58, 0x6a, 0xeb,
-	0x4e, 0xb6, 0xaf, 0x69, 0x8e, 0xec, 0xc0, 0xc2, 0x49, 0x10, 0xf5, 0xdf, 0xa0, 0x2c, 0x3f, 0x83,
-	0x95, 0x99, 0xc6, 0x23, 0x98, 0xcf, 0xf9, 0x86, 0xae, 0x6d, 0x38, 0xf3, 0xfd, 0x49, 0x73, 0xe4,
-	0x7d, 0x28, 0xab, 0xe7, 0x97, 0x89, 0x78, 0xc5, 0x49, 0xff, 0x35, 0xd6, 0xca, 0xce, 0xf4, 0x6d,
-	0x46, 0x73, 0x2f, 0x97, 0x94, 0xf7, 0x8f, 0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff, 0xc5, 0xae, 0x97,
-	0x9a, 0x49, 0x0f, 0x00, 0x00,
+	// 3704 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x3a, 0xcb, 0x72, 0x24, 0xc7,
+	0x56, 0x5d, 0xd5, 0x0f, 0x75, 0x1f, 0xbd, 0x5a, 0x29, 0x8d, 0x5c, 0xd3, 0x36, 0xd7, 0x72, 0xda,
+	0x63, 0xcb, 0x1e, 0x5c, 0x9e, 0x91, 0x3d, 0xbe, 0x13, 0xbe, 0xc6, 0xdc, 0x9e, 0x96, 0x34, 0x12,
+	0x23, 0x69, 0x14, 0xd5, 0x9a, 0xeb, 0x18, 0x60, 0x41, 0x4d, 0x77, 0xaa, 0x55, 0x31, 0xd5, 0x55,
+	0x4d, 0x55, 0xf6, 0x78, 0xfa, 0x06, 0x2b, 0x36, 0x04, 0xc1, 0x8e, 0x80, 0x08, 0x16, 0x04, 0x41,
+	0x04, 0x0b, 0x76, 0x04, 0x3b, 0xd6, 0xb0, 0x62, 0xc7, 0x0a, 0x16, 0xf0, 0x0b, 0x04, 0xdf, 0x40,
+	0x9c, 0x7c, 0xd4, 0xab, 0x5f, 0xba, 0xe6, 0xb1, 0xab, 0x73, 0xf2, 0x64, 0xe6, 0xc9, 0x93, 0xe7,
+	0x9d, 0x05, 0x8d, 0x68, 0xd4, 0xb3, 0x47, 0x51, 0xc8, 0xc3, 0xd6, 0xbb, 0x83, 0x30, 0x1c, 0xf8,
+	0xec, 0x0b, 0x01, 0xbd, 0x1a, 0x5f, 0x7f, 0xc1, 0x86, 0x23, 0x3e, 0x51, 0x83, 0xef, 0x17, 0x07,
+	0xb9, 0x37, 0x64, 0x31, 0x77, 0x87, 0x23, 0x49, 0x40, 0xff, 0xda, 0x80, 0xb5, 0x5f, 0xb0, 0x28,
+	0xf6, 0xc2, 0xc0, 0x61, 0x23, 0x7f, 0x42, 0x2c, 0x58, 0x51, 0xb0, 0x65, 0xec, 0x19, 0xfb, 0x0d,
+	0x47, 0x83, 0x64, 0x07, 0xaa, 0x4f, 0xc6, 0x9e, 0xdf, 0xb7, 0x4c, 0x81, 0x97, 0x00, 0x79, 0x0f,
+	0x1a, 0x4f, 0x43, 0x3d, 0xa3, 0x2c, 0x46, 0x52, 0x04, 0xd9, 0x00, 0xf3, 0x79, 0xd7, 0xaa, 0x08,
+	0xb4, 0xf9, 0xbc, 0x4b, 0x08, 0x54, 0xda, 0x51, 0xef, 0xc6, 0xaa, 0x0a, 0x8c, 0xf8, 0x26, 0x3f,
+	0x01, 0x78, 0x1a, 0x9e, 0xbb, 0x6f, 0x2f, 0xa3, 0xb0, 0x17, 0x5b, 0xb5, 0x3d, 0x63, 0xbf, 0xea,
+	0x64, 0x30, 0x74, 0x1f, 0xd6, 0xce, 0x5d, 0xde, 0xbb, 0x71, 0xd8, 0xef, 0x8f, 0x59, 0xcc, 0x91,
+	0xc3, 0x4b, 0x97, 0x73, 0x16, 0x25, 0x1c, 0x2a, 0x90, 0xfe, 0xe7, 0x06, 0xd4, 0xce, 0xbd, 0x28,
+	0x0a, 0x23, 0xdc, 0xf8, 0xf4, 0x50, 0x8c, 0x57, 0x1d, 0xf3, 0xf4, 0x10, 0x37, 0xbe, 0x70, 0x87,
+	0x4c, 0xf1, 0x2e, 0xbe, 0x71, 0xa1, 0x13, 0xce, 0x47, 0x2f, 0x9c, 0x33, 0xc5, 0xb8, 0x06, 0x49,
+	0x0b, 0xea, 0x4e, 0x3c, 0x09, 0x7a, 0x38, 0x24, 0x99, 0x4f, 0x60, 0xb2, 0x0b, 0xb5, 0x63, 0x39,
+	0x49, 0x1e, 0x42, 0x41, 0x64, 0x0f, 0x56, 0xbb, 0xa3, 0x30, 0x88, 0xc3, 0x48, 0x6c, 0x54, 0x13,
+	0x83, 0x59, 0x14, 0x1e, 0x54, 0x81, 0x38, 0x7b, 0x45, 0x10, 0x64, 0x30, 0xe4, 0x63, 0xd8, 0x50,
+	0xd0, 0x59, 0x38, 0x08, 0x91, 0xa6, 0x2e, 0x68, 0x0a, 0x58, 0x14, 0x79, 0xbb, 0x3f, 0xf4, 0x02,
+	0xb1, 0x4f, 0x43, 0x8a, 0x3c, 0x41, 0xe0, 0x2e, 0x02, 0x38, 0x1a, 0xba, 0x9e, 0x6f, 0x81, 0xdc,
+	0x25, 0xc5, 0xe0, 0x78, 0x67, 0x1c, 0xf3, 0x70, 0x78, 0xe8, 0x72, 0xd7, 0x5a, 0x95, 0xe3, 0x29,
+	0x86, 0x7c, 0x04, 0xeb, 0x9d, 0x30, 0xe0, 0x5e, 0xc0, 0x02, 0xfe, 0x3c, 0xf0, 0x27, 0xd6, 0xda,
+	0x9e, 0xb1, 0x5f, 0x77, 0xf2, 0x48, 0x3c, 0x6d, 0x27, 0x1c, 0x07, 0x3c, 0x9a, 0x08, 0x9a, 0x75,
+	0x41, 0x93, 0x45, 0xa1, 0x9c, 0xda, 0x5d, 0x31, 0xb8, 0x21, 0x06, 0x15, 0x84, 0x6a, 0xd4, 0xed,
+	0x85, 0x11, 0xb3, 0x36, 0xc5, 0xe5, 0x48, 0x00, 0x25, 0x7e, 0xe6, 0x72, 0x8f, 0x8f, 0xfb, 0xcc,
+	0x6a, 0xee, 0x19, 0xfb, 0xa6, 0x93, 0xc0, 0x78, 0xde, 0xb3, 0x30, 0x18, 0xc8, 0xc1, 0x2d, 0x31,
+	0x98, 0x22, 0x72, 0xfc, 0x76, 0xc2, 0x3e, 0xb3, 0x88, 0x38, 0x52, 0x1e, 0x49, 0x28, 0xac, 0x29,
+	0xe6, 0x10, 0x8c, 0xad, 0x6d, 0x41, 0x94, 0xc3, 0x91, 0x03, 0xd8, 0x39, 0x7a, 0xdb, 0xf3, 0xc7,
+	0x7d, 0xd6, 0xcf, 0xd1, 0xee, 0x08, 0xda, 0x99, 0x63, 0x78, 0x9a, 0x76, 0x1c, 0x8c, 0x87, 0xd6,
+	0x9d, 0x3d, 0x63, 0x7f, 0xdd, 0x91, 0x00, 0x6a, 0x56, 0x27, 0x1c, 0x0e, 0x59, 0xc0, 0xad, 0x5d,
+	0xa9, 0x59, 0x0a, 0xc4, 0x91, 0xa3, 0xc0, 0x7d, 0xe5, 0xb3, 0xbe, 0xf5, 0x8e, 0x10, 0x8b, 0x06,
+	0x51, 0x63, 0x5f, 0x8c, 0x2c, 0x4b, 0x20, 0xcd, 0x17, 0x23, 0x3c, 0x97, 0xda, 0xd1, 0x61, 0x6e,
+	0x1c, 0x06, 0xd6, 0x5d, 0x79, 0xae, 0x1c, 0x92, 0x7c, 0x03, 0xd0, 0xe5, 0x2e, 0x67, 0x5d, 0x2f,
+	0xe8, 0x31, 0xab, 0xb5, 0x67, 0xec, 0xaf, 0x1e, 0xb4, 0x6c, 0x69, 0xf5, 0xb6, 0xb6, 0x7a, 0xfb,
+	0x4a, 0x5b, 0xbd, 0x93, 0xa1, 0x46, 0x7d, 0x6b, 0xfb, 0x7e, 0xf8, 0x83, 0xc3, 0xfa, 0x5e, 0xc4,
+	0x7a, 0x3c, 0xb6, 0xde, 0x15, 0x57, 0x52, 0xc0, 0x92, 0xaf, 0xf1, 0x6e, 0x62, 0xde, 0x9d, 0x04,
+	0x3d, 0xeb, 0xbd, 0xa5, 0x3b, 0x24, 0xb4, 0xe4, 0xb7, 0x80, 0x88, 0xef, 0x71, 0xaf, 0xc7, 0xe2,
+	0xf8, 0x7a, 0xec, 0x8b, 0x15, 0x7e, 0x6d, 0xe9, 0x0a, 0x33, 0x66, 0x91, 0x6f, 0x61, 0x15, 0xb1,
+	0xe7, 0x61, 0x1f, 0xe9, 0xac, 0x9f, 0x2c, 0x5d, 0x24, 0x4b, 0x4e, 0xbe, 0x83, 0xd6, 0xf4, 0x9a,
+	0x97, 0x38, 0xa9, 0x17, 0xfa, 0xd6, 0xfb, 0xe2, 0xd4, 0x0b, 0x28, 0xc8, 0xcf, 0xe1, 0xdd, 0x59,
+	0xa3, 0xac, 0xe7, 0x09, 0xb7, 0xb7, 0xb7, 0x67, 0xec, 0x97, 0x9d, 0x45, 0x24, 0xe4, 0x33, 0x68,
+	0x2a, 0x66, 0xd2, 0x69, 0x1f, 0x88, 0x69, 0x53, 0x78, 0xb2, 0x0f, 0x9b, 0xa7, 0x01, 0x67, 0x83,
+	0xc8, 0xe3, 0x93, 0x63, 0xd7, 0x43, 0x5d, 0xa1, 0x42, 0x2d, 0x8a, 0x68, 0xa4, 0x3c, 0x61, 0xae,
+	0xcf, 0x6f, 0x3a, 0x37, 0xac, 0xf7, 0xfa, 0xd2, 0xe5, 0x37, 0xd6, 0x87, 0x42, 0x4b, 0x8a, 0x68,
+	0xdc, 0x3f, 0x83, 0x92, 0x7a, 0xfd, 0x91, 0x20, 0x9d, 0xc2, 0x0b, 0x5f, 0x19, 0x72, 0x66, 0xdd,
+	0x53, 0xbe, 0x32, 0xe4, 0x8c, 0xdc, 0x07, 0xb8, 0x08, 0xfb, 0x4c, 0xd2, 0x5a, 0x1f, 0xef, 0x95,
+	0xf7, 0x57, 0x0f, 0x56, 0xed, 0x14, 0xe5, 0x64, 0x86, 0x71, 0x81, 0x2b, 0x77, 0x10, 0x5b, 0x9f,
+	0xc8, 0x05, 0xf0, 0x1b, 0x1d, 0xc6, 0xb9, 0xeb, 0x05, 0x9c, 0x05, 0x2e, 0x6a, 0xea, 0xbe, 0x74,
+	0x8f, 0x19, 0x14, 0x39, 0x86, 0x66, 0x06, 0x7c, 0x11, 0x70, 0xcf, 0xb7, 0x3e, 0x5d, 0x7a, 0xcf,
+	0x53, 0x73, 0xd0, 0xc1, 0x9d, 0x84, 0x31, 0x3f, 0x61, 0x6e, 0x9f, 0x45, 0xd6, 0x67, 0xd2, 0xc1,
+	0xa5, 0x18, 0x54, 0xfb, 0x43, 0x2f, 0x16, 0x46, 0xa7, 0x2c, 0xeb, 0xbe, 0x74, 0xb3, 0x79, 0x2c,
+	0xf9, 0x39, 0xac, 0x6b, 0x8c, 0x64, 0xe6, 0xd7, 0x97, 0x32, 0x93, 0x9f, 0x80, 0x4e, 0xa7, 0xdb,
+	0x73, 0x03, 0xbc, 0xb5, 0xe8, 0x8d, 0xeb, 0x5b, 0x9f, 0x0b, 0x45, 0xcb, 0xe1, 0xc8, 0x03, 0xd8,
+	0x16, 0xa1, 0xe5, 0x89, 0x1b, 0xf4, 0x7f, 0xf0, 0xfa, 0xfc, 0xe6, 0xcc, 0x1b, 0x7a, 0xdc, 0xb2,
+	0x05, 0xe9, 0xac, 0x21, 0xe4, 0xff, 0x98, 0x8f, 0x3a, 0x61, 0x10, 0xb0, 0x1e, 0xf7, 0xc2, 0x20,
+	0xb6, 0xbe, 0x90, 0x66, 0x9b, 0xc7, 0x92, 0x0f, 0x60, 0xa5, 0xed, 0xf3, 0x17, 0xce, 0x59, 0x6c,
+	0x3d, 0x10, 0xf7, 0xb5, 0x62, 0x4b, 0xd8, 0xd1, 0x78, 0xf2, 0x2d, 0xdc, 0x6d, 0xf7, 0xfb, 0x1e,
+	0xd2, 0xbb, 0x7e, 0x97, 0x45, 0x6f, 0xb4, 0x77, 0xf3, 0x58, 0x6c, 0x3d, 0x14, 0x52, 0x99, 0x4f,
+	0x40, 0xff, 0xd9, 0x80, 0x9a, 0x5c, 0x89, 0x34, 0xa1, 0x8c, 0xf1, 0x4a, 0xc6, 0xe3, 0xb2, 0x0a,
+	0xa1, 0x89, 0x43, 0x37, 0x17, 0x39, 0xf4, 0xf2, 0x52, 0x87, 0x5e, 0xb9, 0x8d, 0x43, 0xaf, 0xce,
+	0x70, 0xe8, 0x89, 0x73, 0xae, 0x65, 0x9d, 0x33, 0x81, 0xca, 0x61, 0xf8, 0x43, 0x20, 0x02, 0x70,
+	0xdd, 0x11, 0xdf, 0xf4, 0x2d, 0x14, 0xf4, 0x17, 0x21, 0x75, 0x1c, 0xf1, 0xad, 0xdc, 0xb3, 0x99,
+	0xb8, 0xe7, 0x5d, 0xa8, 0x29, 0xed, 0x91, 0xb9, 0x83, 0x82, 0x88, 0x0d, 0x15, 0xe1, 0xa1, 0x2a,
+	0x4b, 0x95, 0x45, 0xd0, 0xd1, 0x3f, 0x37, 0x61, 0x4b, 0xe6, 0x2c, 0x67, 0x5e, 0xcc, 0x75, 0x8e,
+	0xd3, 0x82, 0xfa, 0xa5, 0x3b, 0x60, 0x5d, 0xef, 0x97, 0x4c, 0x25, 0x31, 0x09, 0x8c, 0xd2, 0xc3,
+	0xef, 0xab, 0xf0, 0x35, 0x0b, 0x54, 0x3e, 0x93, 0x22, 0x44, 0x7a, 0xe2, 0x31, 0xbf, 0x1f, 0x5b,
+	0xe5, 0xbd, 0xb2, 0x48, 0x4f, 0x04, 0x44, 0xbe, 0x4c, 0x03, 0x0f, 0xb2, 0xb6, 0x71, 0x70, 0xd7,
+	0x9e, 0xda, 0xd6, 0x3e, 0xf6, 0x7c, 0xce, 0xa2, 0x34, 0x26, 0x7d, 0x2a, 0x0e, 0x5d, 0x5d, 0x46,
+	0x8f, 0xf2, 0x10, 0x21, 0x4f, 0xc8, 0x5e, 0xa5, 0x3e, 0x1a, 0x44, 0xdd, 0xb8, 0x72, 0x07, 0x2a,
+	0xdf, 0xc1, 0x4f, 0x4a, 0xa1, 0x26, 0x67, 0x92, 0x15, 0x28, 0xb7, 0x2f, 0x5e, 0x36, 0x4b, 0xf8,
+	0xf1, 0xf2, 0xa8, 0xdb, 0x34, 0x48, 0x0d, 0xcc, 0x8b, 0xe7, 0x4d, 0x93, 0x8e, 0x60, 0x33, 0xbb,
+	0x1f, 0xa6, 0xa6, 0x1f, 0xc0, 0x8a, 0x44, 0xc5, 0x96, 0xa1, 0x14, 0x5a, 0xc2, 0x8e, 0xc6, 0xa3,
+	0xee, 0x5c, 0xb0, 0xb7, 0xbc, 0x28, 0x9f, 0x3c, 0x12, 0xf5, 0xe2, 0x2a, 0xe4, 0xae, 0x2f, 0xae,
+	0xae, 0xea, 0x48, 0x80, 0xda, 0x50, 0x97, 0xcb, 0x9c, 0x1e, 0xde, 0x26, 0x7d, 0xa4, 0xff, 0x6e,
+	0x80, 0xd5, 0xf5, 0x86, 0x63, 0x1f, 0x03, 0x2a, 0xf3, 0xa5, 0xd9, 0xe9, 0x0b, 0x24, 0x50, 0x11,
+	0xee, 0x58, 0xa9, 0x10, 0x7e, 0x8b, 0x45, 0x2f, 0xd5, 0x12, 0xe6, 0xe9, 0x65, 0x56, 0x64, 0xe5,
+	0xbc, 0xc8, 0xbe, 0x81, 0x5a, 0x97, 0xf5, 0xc6, 0x11, 0x53, 0x77, 0x45, 0xed, 0x79, 0x1b, 0xd9,
+	0x3a, 0x46, 0x39, 0x6a, 0x06, 0xaa, 0xce, 0xb1, 0xeb, 0xfb, 0xaf, 0xdc, 0xde, 0x6b, 0x71, 0x73,
+	0x75, 0x27, 0x81, 0xe9, 0x3e, 0xd4, 0x35, 0x7d, 0x2a, 0xfa, 0x06, 0x54, 0x4f, 0xae, 0xae, 0x2e,
+	0x51, 0xf8, 0x75, 0xa8, 0xe0, 0x67, 0xd3, 0xa4, 0x7f, 0x67, 0xc2, 0x86, 0xdc, 0x8b, 0xf5, 0xff,
+	0x57, 0x52, 0xea, 0xa2, 0xbd, 0x56, 0x66, 0xd8, 0xeb, 0x94, 0xe5, 0x57, 0x67, 0x59, 0xfe, 0x6c,
+	0xab, 0x6e, 0x41, 0xfd, 0xd0, 0x8b, 0xb9, 0x08, 0x2e, 0x2b, 0xd2, 0xdf, 0x68, 0x18, 0x6d, 0xe2,
+	0x7b, 0xe6, 0x0d, 0x6e, 0xb8, 0x48, 0xa8, 0x4d, 0x47, 0x41, 0x72, 0xbf, 0xe1, 0x68, 0xcc, 0x59,
+	0x5f, 0xa6, 0xa4, 0x0d, 0x71, 0xb8, 0x3c, 0x72, 0x3a, 0x11, 0x83, 0x19, 0x89, 0x18, 0xfd, 0xab,
+	0x32, 0xec, 0xce, 0xb8, 0x24, 0xd4, 0xdb, 0x59, 0xba, 0x40, 0xa0, 0x22, 0x8c, 0xdb, 0x14, 0x39,
+	0x80, 0xf8, 0x26, 0x5f, 0xc1, 0x8a, 0xce, 0x6f, 0xca, 0x4b, 0xbd, 0x87, 0x26, 0xcd, 0x6a, 0x51,
+	0x25, 0xaf, 0x45, 0xef, 0x41, 0x23, 0x91, 0x9c, 0x12, 0x65, 0x8a, 0x40, 0x0e, 0x3a, 0x1e, 0xd7,
+	0xd6, 0x2a, 0xbe, 0xd1, 0x54, 0xdb, 0xdd, 0x0b, 0x6d, 0xaa, 0xed, 0xee, 0x45, 0xce, 0x8d, 0xd7,
+	0x17, 0xb9, 0xf1, 0x46, 0xd1, 0x8d, 0x67, 0xf5, 0x10, 0xf2, 0x7a, 0x48, 0x3e, 0x4d, 0x2d, 0x79,
+	0x55, 0x58, 0xf2, 0xa6, 0x9d, 0x57, 0xb6, 0xd4, 0xa2, 0xef, 0x43, 0x5d, 0x27, 0xde, 0xd6, 0xda,
+	0x6c, 0xda, 0x84, 0x00, 0xf7, 0xfc, 0xde, 0x8d, 0x02, 0x2f, 0x18, 0xc4, 0xd6, 0xba, 0x70, 0x7f,
+	0x09, 0x4c, 0xff, 0xcd, 0x00, 0x72, 0x32, 0x19, 0x85, 0xfc, 0x86, 0x71, 0xaf, 0xe7, 0xfa, 0x4a,
+	0xab, 0xb5, 0x16, 0x1b, 0x19, 0x2d, 0xfe, 0xf1, 0xb1, 0x2b, 0x2d, 0x8b, 0x32, 0x91, 0x2b, 0x8b,
+	0xfa, 0x1f, 0xe9, 0x78, 0x52, 0x3a, 0xad, 0x64, 0x4a, 0x27, 0xfa, 0x32, 0x55, 0xbc, 0xab, 0xc8,
+	0xbd, 0xbe, 0xf6, 0x7a, 0x99, 0x4a, 0x59, 0x25, 0x24, 0xc2, 0x61, 0x56, 0x1d, 0x0d, 0x92, 0x7b,
+	0x50, 0x6e, 0xf7, 0xb1, 0x92, 0x47, 0x81, 0x6e, 0xdb, 0xd3, 0x72, 0x71, 0x70, 0x9c, 0xfe, 0x1e,
+	0xac, 0xa9, 0x25, 0xbb, 0x37, 0x6e, 0xc4, 0x6e, 0xe5, 0x02, 0x76, 0xa1, 0xf6, 0x84, 0x5d, 0x87,
+	0x91, 0x96, 0x8e, 0x82, 0xc4, 0x91, 0xae, 0x39, 0x8b, 0x84, 0x50, 0x4c, 0x47, 0x02, 0xf4, 0x6f,
+	0x0d, 0xd8, 0x99, 0xe2, 0x5e, 0xf5, 0x21, 0xba, 0xee, 0x70, 0xe4, 0xb3, 0x58, 0xed, 0xa7, 0x41,
+	0xf2, 0x49, 0xaa, 0x3c, 0x92, 0xff, 0x75, 0x3b, 0xcb, 0x64, 0xaa, 0x3a, 0x1f, 0xc3, 0xc6, 0x8b,
+	0x20, 0x16, 0x49, 0x4b, 0x8e, 0xa3, 0x02, 0x16, 0xaf, 0x44, 0x63, 0xb2, 0x1c, 0xe6, 0x91, 0xf4,
+	0x1e, 0x6c, 0x1e, 0x7b, 0x3e, 0x3b, 0x0d, 0xae, 0xc3, 0x05, 0x4e, 0x9e, 0xfe, 0x8b, 0x09, 0xeb,
+	0x29, 0xdd, 0xff, 0xbd, 0xf9, 0xe3, 0x4a, 0x37, 0xee, 0x43, 0xa5, 0x6a, 0xe2, 0x1b, 0xaf, 0xa0,
+	0x7b, 0xe3, 0x1e, 0x3c, 0xfa, 0x5a, 0xb7, 0x28, 0x24, 0x84, 0xe6, 0x7d, 0xde, 0x7f, 0xa4, 0x2c,
+	0x1e, 0x3f, 0x15, 0xe5, 0xa3, 0x87, 0x07, 0xca, 0xe6, 0x15, 0x84, 0xd2, 0x7f, 0xe2, 0xbb, 0xaf,
+	0xd9, 0xc1, 0x2b, 0xd5, 0x83, 0xd0, 0x20, 0x79, 0x0c, 0x8d, 0x63, 0x2f, 0x8a, 0x79, 0x97, 0xb1,
+	0xc0, 0x6a, 0x2c, 0xe5, 0x33, 0x25, 0x4e, 0xca, 0x48, 0x9c, 0x08, 0xb7, 0x2c, 0x23, 0x19, 0x0b,
+	0xe8, 0x31, 0x90, 0xef, 0xb1, 0xff, 0x73, 0xf4, 0x86, 0x05, 0x3c, 0xd6, 0xb2, 0xc7, 0x18, 0x3e,
+	0x19, 0x31, 0x99, 0x0a, 0x34, 0x1c, 0x09, 0xa0, 0xe5, 0xea, 0x18, 0x2e, 0x64, 0x5b, 0x75, 0x12,
+	0x98, 0xfe, 0xa3, 0x01, 0x55, 0xb1, 0x86, 0xa8, 0x4f, 0x26, 0xa3, 0xc4, 0xe6, 0xf1, 0x7b, 0xd1,
+	0x4c, 0xac, 0x28, 0xe4, 0xf7, 0x85, 0xab, 0x2e, 0xa7, 0xe1, 0x64, 0x30, 0x28, 0xad, 0x73, 0x16,
+	0xc7, 0xee, 0x40, 0x5b, 0xbc, 0x06, 0x51, 0x5a, 0xc9, 0x91, 0xac, 0xea, 0xd2, 0x43, 0xa7, 0xc4,
+	0xc2, 0x5c, 0x7a, 0x3c, 0x8c, 0xd4, 0x6d, 0x49, 0x80, 0xde, 0x83, 0x6d, 0x51, 0xa8, 0x29, 0x15,
+	0xd7, 0xc2, 0x28, 0xd8, 0x25, 0xfd, 0x23, 0x13, 0xb6, 0xf2, 0x74, 0xa8, 0x88, 0xd9, 0x23, 0x1a,
+	0x0b, 0x8f, 0x68, 0x4e, 0x1d, 0x91, 0x40, 0x05, 0xb5, 0x5a, 0x1d, 0x5e, 0x7c, 0xe3, 0x1c, 0xec,
+	0x26, 0x8c, 0xe3, 0xc4, 0xd7, 0x55, 0x9d, 0x0c, 0x46, 0xb8, 0x4a, 0x97, 0xb3, 0xa0, 0x37, 0x39,
+	0x97, 0xf9, 0x79, 0xd9, 0x49, 0x11, 0x78, 0xc0, 0xa3, 0x28, 0x4a, 0x0f, 0x28, 0x00, 0x11, 0xcd,
+	0x90, 0xf1, 0x17, 0x23, 0x95, 0x9f, 0x6b, 0x50, 0x25, 0xe0, 0xf5, 0xf9, 0xfd, 0x91, 0xc6, 0xac,
+	0xb0, 0xfc, 0x10, 0x40, 0x35, 0x0f, 0x51, 0x02, 0x1f, 0x16, 0x33, 0xc8, 0x86, 0xad, 0x25, 0x90,
+	0xb8, 0x0d, 0xfa, 0xc7, 0x06, 0x0a, 0xd9, 0x0d, 0x06, 0x4c, 0x9e, 0x65, 0x8e, 0x90, 0xb3, 0xad,
+	0x1c, 0x33, 0xdf, 0xca, 0x99, 0x57, 0x1b, 0x3c, 0x80, 0xaa, 0xac, 0x24, 0x97, 0x17, 0x07, 0x92,
+	0x90, 0xbe, 0x84, 0x3b, 0x5d, 0xc6, 0x33, 0x25, 0xee, 0x3c, 0x66, 0x92, 0xa5, 0xcd, 0xdb, 0x2e,
+	0xfd, 0x81, 0x4e, 0xb0, 0x4f, 0x0f, 0xe7, 0xa9, 0xd1, 0xcf, 0x60, 0xdb, 0x61, 0xc3, 0xf0, 0x0d,
+	0x93, 0x84, 0xf3, 0xf6, 0xde, 0x81, 0xea, 0xe5, 0x38, 0x1a, 0x30, 0x25, 0x06, 0x09, 0xd0, 0x5f,
+	0x02, 0x11, 0x93, 0x5d, 0xdf, 0x61, 0xa3, 0x30, 0x52, 0x39, 0xfc, 0x0e, 0x54, 0x51, 0x77, 0xa4,
+	0x53, 0x2f, 0x3b, 0x12, 0x40, 0xed, 0x3a, 0x0b, 0x07, 0xb1, 0x76, 0x87, 0xf8, 0x8d, 0xda, 0x83,
+	0xf2, 0x8f, 0x9f, 0xb1, 0x49, 0x2c, 0xe4, 0x58, 0x76, 0x52, 0x04, 0x8e, 0x3a, 0x8c, 0xb3, 0x00,
+	0xb3, 0x2c, 0xa5, 0x7a, 0x29, 0x82, 0xfe, 0x8d, 0x01, 0xeb, 0x92, 0xf3, 0x5f, 0x25, 0x79, 0x7d,
+	0x0c, 0x0d, 0x35, 0xa9, 0xcd, 0x6f, 0xe1, 0x82, 0x53, 0x62, 0x74, 0xdd, 0xe2, 0xd0, 0x6d, 0x7e,
+	0x8b, 0xab, 0xd5, 0xa4, 0xf4, 0x37, 0x61, 0x3b, 0xc7, 0xa4, 0x32, 0xd3, 0xfd, 0xa2, 0x92, 0x6e,
+	0xd8, 0x39, 0xb2, 0x54, 0x53, 0xff, 0xde, 0x80, 0x8d, 0x76, 0x5f, 0xa3, 0xb5, 0x8d, 0x27, 0xa9,
+	0x8b, 0xb1, 0x28, 0x75, 0x31, 0x8b, 0xa9, 0xcb, 0xfc, 0x6a, 0x24, 0x97, 0x47, 0x56, 0x8a, 0x79,
+	0xa4, 0xca, 0x19, 0xab, 0xb9, 0x9c, 0x31, 0xc9, 0xc2, 0x6a, 0x85, 0x2c, 0xec, 0x02, 0xb6, 0x12,
+	0x8e, 0x13, 0xcb, 0xba, 0x45, 0x61, 0xb7, 0x0b, 0xb5, 0x17, 0xa3, 0xbe, 0xcb, 0xb5, 0x92, 0x29,
+	0x88, 0xfe, 0xa9, 0x01, 0x9b, 0x19, 0x11, 0xc4, 0x63, 0x9f, 0xcf, 0x4c, 0xe9, 0xe4, 0xfd, 0x9b,
+	0xc9, 0xfd, 0xdf, 0x87, 0xfa, 0x59, 0xd8, 0x73, 0xb9, 0x7e, 0xb5, 0xc0, 0xb4, 0x32, 0x2f, 0x4a,
+	0x27, 0x21, 0x48, 0x5d, 0x55, 0xa5, 0xe0, 0xaa, 0x24, 0x13, 0x7d, 0x55, 0x67, 0x69, 0x90, 0xfe,
+	0x4e, 0x86, 0x27, 0x75, 0xa9, 0x9f, 0xc1, 0x8a, 0xe4, 0x4e, 0x1f, 0xb1, 0x69, 0x17, 0xd8, 0x76,
+	0x34, 0x81, 0x94, 0xf7, 0x70, 0xe8, 0x71, 0x9e, 0xb8, 0x96, 0x14, 0x41, 0x3b, 0xb0, 0x25, 0xf7,
+	0xc9, 0x5e, 0x3b, 0xf6, 0x34, 0xbc, 0xeb, 0x6b, 0x7d, 0x64, 0xfc, 0xce, 0x5d, 0x83, 0x59, 0xb8,
+	0x86, 0x01, 0xec, 0x3c, 0x65, 0xe1, 0xf4, 0x3a, 0xef, 0xeb, 0x07, 0x14, 0xb1, 0x52, 0xe6, 0x22,
+	0x6a, 0x69, 0xba, 0x2c, 0x36, 0x32, 0xe7, 0x6c, 0x54, 0x2e, 0x6c, 0x74, 0x00, 0x96, 0xc3, 0xae,
+	0x23, 0x16, 0xa3, 0x07, 0x0e, 0x63, 0x8f, 0x87, 0xd1, 0x44, 0x5f, 0xbb, 0x70, 0x93, 0x37, 0x6e,
+	0x2c, 0x53, 0xa3, 0xba, 0xa3, 0x20, 0xfa, 0x0f, 0x06, 0x6c, 0x61, 0x8f, 0x6c, 0xb1, 0xd7, 0xc1,
+	0x77, 0x8e, 0x31, 0x0f, 0xa5, 0xcf, 0x55, 0x62, 0xca, 0x60, 0xc8, 0xa3, 0xb4, 0xd6, 0xb5, 0xca,
+	0xaa, 0x83, 0x31, 0xb5, 0xaa, 0x7d, 0xce, 0xf8, 0x4d, 0xd8, 0x77, 0x12, 0x52, 0xe1, 0xa0, 0xc2,
+	0xa8, 0x27, 0xe3, 0x59, 0xdd, 0x91, 0x00, 0xbd, 0x07, 0x35, 0x49, 0x29, 0xca, 0xe6, 0xb3, 0x33,
+	0xd9, 0xb1, 0x38, 0xbe, 0xba, 0x6c, 0x1a, 0x58, 0x3f, 0x3b, 0xdd, 0x97, 0x17, 0x9d, 0xa6, 0x49,
+	0xff, 0xd5, 0x80, 0xcd, 0xec, 0x1e, 0x2a, 0x91, 0xd5, 0x61, 0xc2, 0xc8, 0x87, 0x09, 0x0a, 0x6b,
+	0xc2, 0xfd, 0x9d, 0x06, 0x7d, 0xf6, 0x56, 0x5d, 0x75, 0xd9, 0xc9, 0xe1, 0x90, 0xe6, 0x59, 0x10,
+	0xfe, 0x10, 0x68, 0x1a, 0xe9, 0x08, 0x73, 0x38, 0xdc, 0x41, 0x39, 0x08, 0xc1, 0x74, 0xd9, 0xd1,
+	0x20, 0xca, 0xe8, 0xea, 0xb7, 0x9f, 0x5f, 0x5f, 0xc7, 0x8c, 0x27, 0x21, 0x38, 0x83, 0xc1, 0x0c,
+	0xb9, 0xe3, 0xc6, 0xac, 0x13, 0xfa, 0xbe, 0x68, 0x3d, 0x6b, 0x7b, 0x2d, 0x60, 0xe9, 0x5f, 0x1a,
+	0xd0, 0x14, 0xbe, 0x17, 0x79, 0x5b, 0xfa, 0x0e, 0x87, 0x8e, 0xf4, 0x10, 0xcb, 0x60, 0xee, 0x46,
+	0xfc, 0x16, 0x01, 0x29, 0x25, 0x46, 0x47, 0x8a, 0xc0, 0x51, 0xd0, 0xbf, 0x4d, 0x0e, 0xac, 0x48,
+	0xe9, 0x1f, 0xc0, 0x46, 0x86, 0x3b, 0x14, 0xfa, 0x03, 0xa8, 0x5e, 0xab, 0x30, 0x53, 0x16, 0xab,
+	0xe4, 0xc7, 0xb1, 0x71, 0xc5, 0xe2, 0x23, 0xf4, 0x6e, 0x8e, 0x24, 0x6c, 0x3d, 0x06, 0x48, 0x91,
+	0xe8, 0xd4, 0x5e, 0xb3, 0x89, 0xee, 0x67, 0xbe, 0x66, 0x22, 0x70, 0xbd, 0x71, 0xfd, 0xb1, 0x4e,
+	0xd9, 0x25, 0xf0, 0x8d, 0xf9, 0xd8, 0xa0, 0x7f, 0x66, 0x00, 0x11, 0xcb, 0x2f, 0xd6, 0xd7, 0xff,
+	0x6f, 0xa1, 0x30, 0x68, 0xe6, 0xb8, 0xba, 0x95, 0x79, 0xe3, 0xc3, 0xa7, 0xe4, 0x5f, 0x07, 0xe3,
+	0x04, 0x16, 0xef, 0xbf, 0x13, 0xce, 0x74, 0x30, 0x96, 0x00, 0xfd, 0x43, 0xad, 0x1a, 0xd8, 0x38,
+	0xd2, 0x67, 0xcf, 0x9d, 0xd5, 0xf8, 0x91, 0x67, 0x35, 0x6f, 0x7f, 0xd6, 0xbf, 0x30, 0x60, 0x23,
+	0xc3, 0x04, 0x1e, 0xf5, 0x6b, 0x0c, 0xe6, 0x31, 0x3e, 0x9c, 0x26, 0x5a, 0x60, 0xd9, 0x79, 0x1a,
+	0x5b, 0x13, 0x38, 0x29, 0x69, 0xeb, 0x02, 0xea, 0x1a, 0x10, 0xdd, 0x2c, 0x37, 0xe8, 0xfb, 0x2c,
+	0xd2, 0x1a, 0xae, 0x40, 0xd1, 0x3c, 0x09, 0x55, 0x14, 0xad, 0x3a, 0x15, 0x5d, 0xb3, 0x8b, 0x88,
+	0xa9, 0xe5, 0x23, 0x00, 0xfa, 0x1f, 0xe8, 0x12, 0x70, 0xdb, 0xab, 0x70, 0xa4, 0xc5, 0xf3, 0x25,
+	0xd4, 0x2e, 0x59, 0xe4, 0x85, 0xd2, 0x23, 0x6c, 0x1c, 0xbc, 0x6b, 0x17, 0x28, 0x6c, 0x39, 0x8c,
+	0x85, 0x89, 0xa3, 0x48, 0xb1, 0xb1, 0x7c, 0xa8, 0xe3, 0xdf, 0x92, 0xc6, 0x32, 0xd2, 0xe5, 0xd9,
+	0xa9, 0x2a, 0x76, 0xb2, 0x46, 0x5b, 0xc9, 0x3f, 0x9e, 0x7f, 0x09, 0x90, 0xee, 0x8a, 0xde, 0xed,
+	0xb0, 0xad, 0xba, 0x83, 0xe7, 0xcf, 0x2f, 0xae, 0x4e, 0x64, 0x77, 0xf0, 0xe5, 0x51, 0xdb, 0x69,
+	0x9a, 0xda, 0x09, 0x96, 0x69, 0x5b, 0x16, 0xbb, 0xd8, 0x43, 0xf7, 0x43, 0xb7, 0x1f, 0xcf, 0x2c,
+	0x76, 0xdf, 0x83, 0x46, 0x42, 0xa0, 0xb4, 0x2a, 0x45, 0xd0, 0x67, 0xb0, 0x9e, 0x9e, 0x1e, 0x6f,
+	0xee, 0xa3, 0x34, 0x45, 0x94, 0xd9, 0x4f, 0x6e, 0x07, 0x9d, 0x32, 0x26, 0x3d, 0x5c, 0x65, 0x8f,
+	0x02, 0xa0, 0xf7, 0x95, 0xb0, 0x2f, 0xa3, 0x71, 0xc0, 0x12, 0xff, 0xab, 0xbd, 0xa3, 0x91, 0xf3,
+	0x8e, 0xf4, 0x9f, 0x0c, 0x8c, 0x82, 0x5c, 0xb5, 0x99, 0xc3, 0x41, 0xbc, 0x20, 0xd4, 0x9c, 0xbb,
+	0x6f, 0x75, 0xfc, 0x96, 0x77, 0x9e, 0xc1, 0xa0, 0xb7, 0x91, 0xef, 0xaf, 0xcb, 0xcd, 0x53, 0x12,
+	0xfe, 0xea, 0x95, 0x00, 0x06, 0xcb, 0xce, 0x38, 0x8a, 0xc3, 0x48, 0xb9, 0x71, 0x05, 0xd1, 0x13,
+	0x20, 0x85, 0x33, 0xa8, 0x7c, 0xc0, 0xf7, 0x02, 0xa6, 0x8a, 0x63, 0xf1, 0x8d, 0xa7, 0xc0, 0x36,
+	0xb8, 0x5a, 0x45, 0x8a, 0x2d, 0x83, 0xa1, 0x7f, 0x62, 0xc0, 0x6a, 0xc7, 0x1f, 0xc7, 0x9c, 0x45,
+	0xfa, 0xc5, 0x43, 0x49, 0xa1, 0x21, 0xa4, 0xf0, 0x1d, 0xac, 0x61, 0x4d, 0xde, 0x0e, 0x82, 0x70,
+	0x8c, 0x87, 0x5d, 0xae, 0x88, 0x39, 0x7a, 0xd1, 0xa9, 0x60, 0xfe, 0xb5, 0x10, 0x52, 0xdd, 0x11,
+	0xdf, 0xa2, 0x72, 0x56, 0x99, 0x5f, 0x45, 0xb0, 0xaa, 0x41, 0x4c, 0xec, 0x88, 0xe2, 0x46, 0x97,
+	0x61, 0x78, 0x30, 0x0a, 0xd5, 0x0b, 0xd1, 0x3f, 0x96, 0xca, 0xb1, 0x66, 0x67, 0x38, 0x76, 0xe4,
+	0x10, 0x46, 0x35, 0x7c, 0xbc, 0x8e, 0x1d, 0xe6, 0xf6, 0x6e, 0x32, 0xd9, 0x41, 0x01, 0x8b, 0x9b,
+	0x77, 0xb9, 0x1b, 0xf4, 0x5f, 0x4d, 0x14, 0x4f, 0x1a, 0x44, 0x61, 0x9f, 0xc9, 0xe7, 0x43, 0x69,
+	0x24, 0x0a, 0xa2, 0x9f, 0xc3, 0x56, 0x97, 0x71, 0x45, 0x95, 0x89, 0x83, 0x7a, 0x19, 0x23, 0xb7,
+	0x0c, 0xfd, 0x5d, 0x80, 0xc3, 0xf1, 0x70, 0xe4, 0xb0, 0x5e, 0x18, 0xf5, 0x31, 0xa6, 0x3c, 0x4b,
+	0x63, 0xca, 0x33, 0x36, 0x49, 0xfa, 0x10, 0x66, 0xa6, 0x0f, 0xb1, 0x0b, 0xb5, 0x5f, 0x60, 0x68,
+	0x91, 0xa9, 0xd4, 0x9a, 0xa3, 0x20, 0x9c, 0x7d, 0x75, 0x75, 0xa6, 0x02, 0x3c, 0x7e, 0xd2, 0x2e,
+	0xec, 0x3a, 0x2c, 0xe6, 0x61, 0xc4, 0xf0, 0xbf, 0x8d, 0x57, 0x6e, 0x9c, 0x44, 0xe6, 0x0f, 0xa1,
+	0x26, 0xf7, 0x54, 0xbe, 0x77, 0xd5, 0x4e, 0xd9, 0x70, 0xd4, 0x50, 0x9a, 0xe8, 0x98, 0xd9, 0x44,
+	0xe7, 0x00, 0x76, 0xa6, 0x16, 0x55, 0x75, 0x85, 0xc2, 0x6b, 0x33, 0x4a, 0x60, 0x7a, 0x02, 0x1b,
+	0x4f, 0x19, 0xef, 0x84, 0xc1, 0xb5, 0x37, 0x90, 0xd4, 0xf8, 0x8f, 0x89, 0x00, 0x45, 0xcf, 0xc0,
+	0x50, 0xff, 0x98, 0x24, 0x18, 0xa1, 0xcc, 0x02, 0x52, 0x47, 0x57, 0xd0, 0xc1, 0x7f, 0x6d, 0x42,
+	0xb9, 0x73, 0x76, 0x4a, 0x1e, 0x01, 0x3c, 0x65, 0x5c, 0xff, 0x44, 0xb4, 0x3b, 0xa5, 0x62, 0x47,
+	0xf8, 0x8b, 0x53, 0x6b, 0xdd, 0xce, 0xfe, 0xb9, 0x44, 0x4b, 0xe4, 0x67, 0x98, 0x91, 0x0f, 0x22,
+	0xb7, 0xcf, 0xe6, 0xce, 0x99, 0x83, 0xa7, 0x25, 0x7c, 0x73, 0x71, 0x18, 0xba, 0x98, 0x1f, 0x31,
+	0xf7, 0x6b, 0x68, 0x24, 0x12, 0x98, 0x3b, 0x7d, 0xd3, 0xce, 0x4b, 0x89, 0x96, 0xd0, 0xa4, 0xb2,
+	0x9d, 0x06, 0xb2, 0x63, 0xcf, 0x68, 0x3c, 0x2c, 0xd8, 0xf7, 0x09, 0x6c, 0xe4, 0xdb, 0x03, 0x64,
+	0xd7, 0x9e, 0xd9, 0x2f, 0x58, 0xb0, 0x86, 0x0d, 0x15, 0x7c, 0x62, 0x23, 0x64, 0xfa, 0x7d, 0xaf,
+	0xd5, 0xb4, 0x0b, 0x6f, 0x70, 0xb4, 0x44, 0x3e, 0xd5, 0x9d, 0x22, 0xec, 0x70, 0x92, 0xa6, 0x5d,
+	0x68, 0x22, 0xb4, 0x74, 0x4e, 0x41, 0x4b, 0xe4, 0x13, 0x68, 0x24, 0x45, 0x0e, 0xd1, 0xf8, 0x56,
+	0xb1, 0xd0, 0xa2, 0x25, 0xf2, 0x15, 0x40, 0x82, 0x8b, 0x09, 0xb1, 0xa7, 0x4a, 0xc4, 0x56, 0xd3,
+	0x2e, 0x54, 0x54, 0xb4, 0x44, 0x3e, 0x87, 0xb5, 0x6c, 0x05, 0x93, 0xee, 0x40, 0xec, 0xa9, 0xca,
+	0x46, 0x0a, 0x3b, 0xdb, 0xcd, 0x20, 0x3b, 0xf6, 0x8c, 0xe6, 0xc6, 0x02, 0x41, 0x3d, 0x86, 0xf5,
+	0x5c, 0x43, 0x63, 0xc6, 0xd9, 0xb7, 0xed, 0xe9, 0x96, 0x87, 0xd0, 0xcb, 0x75, 0x65, 0x2c, 0x6a,
+	0xeb, 0xe9, 0x99, 0xf3, 0xb7, 0x3d, 0x04, 0x22, 0xc5, 0x9f, 0xed, 0x14, 0xcc, 0x55, 0xb2, 0x1d,
+	0x7b, 0x46, 0x4b, 0x81, 0x96, 0xc8, 0xb7, 0xb0, 0x59, 0x28, 0xf8, 0x66, 0x30, 0x71, 0xc7, 0x9e,
+	0x55, 0x14, 0xd2, 0x12, 0x39, 0x81, 0xad, 0xa9, 0x2a, 0x8e, 0xdc, 0xb5, 0xe7, 0x55, 0x76, 0x0b,
+	0x4e, 0xf3, 0x15, 0x40, 0x5a, 0x20, 0x11, 0x32, 0x5d, 0x91, 0xb5, 0x9a, 0x76, 0xa1, 0x82, 0xa2,
+	0x25, 0xf2, 0x50, 0xf5, 0x82, 0x84, 0xf3, 0xd8, 0xb2, 0x8b, 0xa5, 0x48, 0x6b, 0xb3, 0x90, 0xdf,
+	0xd3, 0x12, 0xf9, 0x29, 0xac, 0x66, 0xd2, 0x5f, 0xb2, 0x6d, 0x4f, 0xa7, 0xe8, 0xad, 0x2d, 0xbb,
+	0x98, 0x21, 0x0b, 0x7b, 0xa8, 0xeb, 0x7c, 0x84, 0x34, 0x8b, 0x89, 0x59, 0x6b, 0xc3, 0xce, 0x25,
+	0x2b, 0x19, 0xde, 0x30, 0xad, 0xd4, 0xbc, 0x65, 0x72, 0xe1, 0xd6, 0x66, 0x16, 0x25, 0xa7, 0x3c,
+	0x06, 0x48, 0xb3, 0x94, 0xb9, 0x57, 0xd9, 0xb4, 0x53, 0xa2, 0x74, 0x66, 0xe5, 0xd2, 0x0b, 0x06,
+	0x3f, 0xc2, 0x45, 0xfd, 0x06, 0xac, 0xe7, 0xf2, 0x04, 0x72, 0xc7, 0xce, 0xc1, 0xa9, 0x0a, 0x4f,
+	0xa7, 0x13, 0xc2, 0x3b, 0x42, 0x1a, 0xf9, 0xf0, 0xde, 0x8a, 0x61, 0x70, 0xc1, 0xd6, 0xdf, 0xc1,
+	0x7a, 0x2e, 0x92, 0xcf, 0xe5, 0x7e, 0xdb, 0x9e, 0x8e, 0xf8, 0xb4, 0x44, 0xee, 0xe3, 0xaf, 0x43,
+	0xbc, 0x77, 0xa3, 0xae, 0x72, 0xdd, 0xce, 0xfe, 0x0e, 0xda, 0x5a, 0xb5, 0xd3, 0x06, 0x2f, 0x2d,
+	0x91, 0x53, 0xd8, 0x9a, 0x7a, 0x86, 0x25, 0x77, 0xe7, 0xbe, 0x9f, 0xb7, 0xde, 0xb1, 0x67, 0xbf,
+	0xda, 0xd2, 0x12, 0xe9, 0xc0, 0x66, 0xe1, 0x69, 0x8a, 0xbc, 0x63, 0x17, 0x30, 0xa9, 0xe9, 0xcc,
+	0x7a, 0xc5, 0x92, 0xea, 0xa4, 0x9f, 0x83, 0x48, 0xd3, 0x2e, 0xbc, 0x20, 0xb5, 0x36, 0xec, 0xdc,
+	0x5b, 0x91, 0xa0, 0x5f, 0xcd, 0xbc, 0x76, 0x90, 0x6d, 0x7b, 0xfa, 0xed, 0xa3, 0x55, 0xb3, 0x05,
+	0x4c, 0x4b, 0x0f, 0x0c, 0xf2, 0x2d, 0xac, 0x65, 0x3b, 0xfd, 0x22, 0x84, 0x4c, 0x3d, 0x10, 0xb4,
+	0x88, 0x3d, 0xf5, 0x1c, 0x20, 0x66, 0xff, 0x14, 0xd6, 0x30, 0x35, 0xd0, 0xb1, 0x7e, 0xee, 0xcd,
+	0x64, 0x33, 0x08, 0x31, 0xf1, 0x08, 0x36, 0x0b, 0x79, 0x02, 0x79, 0xc7, 0x9e, 0x9d, 0x8e, 0xb4,
+	0xee, 0xd8, 0xb3, 0x52, 0x0a, 0x5a, 0xda, 0x37, 0x5e, 0xd5, 0xc4, 0x3e, 0x5f, 0xfe, 0xf7, 0x00,
+	0x39, 0x1e, 0x7e, 0xfa, 0xd0, 0x2c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1421,21 +4284,39 @@ type CLIClient interface {
 	GetVersion(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*VersionReply, error)
 	Upgrade(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	Reload(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetConfig(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetConfigReply, error)
 	ChangeStatus(ctx context.Context, in *ChangeStatusRequest, opts ...grpc.CallOption) (*empty.Empty, error)
-	List(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*MirrorListReply, error)
+	SetMaintenance(ctx context.Context, in *SetMaintenanceRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	List(ctx context.Context, in *MirrorListRequest, opts ...grpc.CallOption) (*MirrorListReply, error)
 	MirrorInfo(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*Mirror, error)
 	AddMirror(ctx context.Context, in *Mirror, opts ...grpc.CallOption) (*AddMirrorReply, error)
+	AddMirrors(ctx context.Context, in *AddMirrorsRequest, opts ...grpc.CallOption) (*AddMirrorsReply, error)
 	UpdateMirror(ctx context.Context, in *Mirror, opts ...grpc.CallOption) (*UpdateMirrorReply, error)
-	RemoveMirror(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	RemoveMirror(ctx context.Context, in *RemoveMirrorRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	RemovalReport(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*RemovalReportReply, error)
+	RestoreMirror(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ListRemovedMirrors(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RemovedMirrorsReply, error)
 	GeoUpdateMirror(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*GeoUpdateMirrorReply, error)
 	RefreshRepository(ctx context.Context, in *RefreshRepositoryRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	ScanMirror(ctx context.Context, in *ScanMirrorRequest, opts ...grpc.CallOption) (*ScanMirrorReply, error)
 	StatsFile(ctx context.Context, in *StatsFileRequest, opts ...grpc.CallOption) (*StatsFileReply, error)
 	StatsMirror(ctx context.Context, in *StatsMirrorRequest, opts ...grpc.CallOption) (*StatsMirrorReply, error)
+	StatsTop(ctx context.Context, in *StatsTopRequest, opts ...grpc.CallOption) (*StatsTopReply, error)
+	StatsHTTP(ctx context.Context, in *StatsHTTPRequest, opts ...grpc.CallOption) (*StatsHTTPReply, error)
+	StatsPrune(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StatsPruneReply, error)
 	Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	GetMirrorLogs(ctx context.Context, in *GetMirrorLogsRequest, opts ...grpc.CallOption) (*GetMirrorLogsReply, error)
+	SetStandby(ctx context.Context, in *SetStandbyRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ClusterStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ClusterStatusReply, error)
 	// Tools
 	MatchMirror(ctx context.Context, in *MatchRequest, opts ...grpc.CallOption) (*MatchReply, error)
+	SimulateSelection(ctx context.Context, in *SimulateSelectionRequest, opts ...grpc.CallOption) (*SimulateSelectionReply, error)
+	SimulateTraffic(ctx context.Context, in *SimulateTrafficRequest, opts ...grpc.CallOption) (*SimulateTrafficReply, error)
+	FileInfo(ctx context.Context, in *FileInfoRequest, opts ...grpc.CallOption) (*FileInfoReply, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (CLI_WatchEventsClient, error)
+	CheckMirrors(ctx context.Context, in *CheckMirrorsRequest, opts ...grpc.CallOption) (CLI_CheckMirrorsClient, error)
+	DumpDatabase(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (CLI_DumpDatabaseClient, error)
+	RestoreDatabase(ctx context.Context, opts ...grpc.CallOption) (CLI_RestoreDatabaseClient, error)
 }
 
 type cLIClient struct {
@@ -1473,6 +4354,15 @@ func (c *cLIClient) Reload(ctx context.Context, in *empty.Empty, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *cLIClient) GetConfig(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetConfigReply, error) {
+	out := new(GetConfigReply)
+	err := c.cc.Invoke(ctx, "/CLI/GetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cLIClient) ChangeStatus(ctx context.Context, in *ChangeStatusRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, "/CLI/ChangeStatus", in, out, opts...)
@@ -1482,7 +4372,16 @@ func (c *cLIClient) ChangeStatus(ctx context.Context, in *ChangeStatusRequest, o
 	return out, nil
 }
 
-func (c *cLIClient) List(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*MirrorListReply, error) {
+func (c *cLIClient) SetMaintenance(ctx context.Context, in *SetMaintenanceRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/CLI/SetMaintenance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) List(ctx context.Context, in *MirrorListRequest, opts ...grpc.CallOption) (*MirrorListReply, error) {
 	out := new(MirrorListReply)
 	err := c.cc.Invoke(ctx, "/CLI/List", in, out, opts...)
 	if err != nil {
@@ -1509,6 +4408,15 @@ func (c *cLIClient) AddMirror(ctx context.Context, in *Mirror, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *cLIClient) AddMirrors(ctx context.Context, in *AddMirrorsRequest, opts ...grpc.CallOption) (*AddMirrorsReply, error) {
+	out := new(AddMirrorsReply)
+	err := c.cc.Invoke(ctx, "/CLI/AddMirrors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cLIClient) UpdateMirror(ctx context.Context, in *Mirror, opts ...grpc.CallOption) (*UpdateMirrorReply, error) {
 	out := new(UpdateMirrorReply)
 	err := c.cc.Invoke(ctx, "/CLI/UpdateMirror", in, out, opts...)
@@ -1518,7 +4426,7 @@ func (c *cLIClient) UpdateMirror(ctx context.Context, in *Mirror, opts ...grpc.C
 	return out, nil
 }
 
-func (c *cLIClient) RemoveMirror(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+func (c *cLIClient) RemoveMirror(ctx context.Context, in *RemoveMirrorRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, "/CLI/RemoveMirror", in, out, opts...)
 	if err != nil {
@@ -1527,6 +4435,33 @@ func (c *cLIClient) RemoveMirror(ctx context.Context, in *MirrorIDRequest, opts
 	return out, nil
 }
 
+func (c *cLIClient) RemovalReport(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*RemovalReportReply, error) {
+	out := new(RemovalReportReply)
+	err := c.cc.Invoke(ctx, "/CLI/RemovalReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) RestoreMirror(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/CLI/RestoreMirror", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) ListRemovedMirrors(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RemovedMirrorsReply, error) {
+	out := new(RemovedMirrorsReply)
+	err := c.cc.Invoke(ctx, "/CLI/ListRemovedMirrors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cLIClient) GeoUpdateMirror(ctx context.Context, in *MirrorIDRequest, opts ...grpc.CallOption) (*GeoUpdateMirrorReply, error) {
 	out := new(GeoUpdateMirrorReply)
 	err := c.cc.Invoke(ctx, "/CLI/GeoUpdateMirror", in, out, opts...)
@@ -1572,6 +4507,33 @@ func (c *cLIClient) StatsMirror(ctx context.Context, in *StatsMirrorRequest, opt
 	return out, nil
 }
 
+func (c *cLIClient) StatsTop(ctx context.Context, in *StatsTopRequest, opts ...grpc.CallOption) (*StatsTopReply, error) {
+	out := new(StatsTopReply)
+	err := c.cc.Invoke(ctx, "/CLI/StatsTop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) StatsHTTP(ctx context.Context, in *StatsHTTPRequest, opts ...grpc.CallOption) (*StatsHTTPReply, error) {
+	out := new(StatsHTTPReply)
+	err := c.cc.Invoke(ctx, "/CLI/StatsHTTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) StatsPrune(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*StatsPruneReply, error) {
+	out := new(StatsPruneReply)
+	err := c.cc.Invoke(ctx, "/CLI/StatsPrune", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cLIClient) Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, "/CLI/Ping", in, out, opts...)
@@ -1590,6 +4552,24 @@ func (c *cLIClient) GetMirrorLogs(ctx context.Context, in *GetMirrorLogsRequest,
 	return out, nil
 }
 
+func (c *cLIClient) SetStandby(ctx context.Context, in *SetStandbyRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/CLI/SetStandby", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) ClusterStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ClusterStatusReply, error) {
+	out := new(ClusterStatusReply)
+	err := c.cc.Invoke(ctx, "/CLI/ClusterStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cLIClient) MatchMirror(ctx context.Context, in *MatchRequest, opts ...grpc.CallOption) (*MatchReply, error) {
 	out := new(MatchReply)
 	err := c.cc.Invoke(ctx, "/CLI/MatchMirror", in, out, opts...)
@@ -1599,26 +4579,201 @@ func (c *cLIClient) MatchMirror(ctx context.Context, in *MatchRequest, opts ...g
 	return out, nil
 }
 
+func (c *cLIClient) SimulateSelection(ctx context.Context, in *SimulateSelectionRequest, opts ...grpc.CallOption) (*SimulateSelectionReply, error) {
+	out := new(SimulateSelectionReply)
+	err := c.cc.Invoke(ctx, "/CLI/SimulateSelection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) SimulateTraffic(ctx context.Context, in *SimulateTrafficRequest, opts ...grpc.CallOption) (*SimulateTrafficReply, error) {
+	out := new(SimulateTrafficReply)
+	err := c.cc.Invoke(ctx, "/CLI/SimulateTraffic", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) FileInfo(ctx context.Context, in *FileInfoRequest, opts ...grpc.CallOption) (*FileInfoReply, error) {
+	out := new(FileInfoReply)
+	err := c.cc.Invoke(ctx, "/CLI/FileInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cLIClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (CLI_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_CLI_serviceDesc.Streams[0], "/CLI/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cLIWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CLI_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type cLIWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cLIWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cLIClient) CheckMirrors(ctx context.Context, in *CheckMirrorsRequest, opts ...grpc.CallOption) (CLI_CheckMirrorsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_CLI_serviceDesc.Streams[1], "/CLI/CheckMirrors", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cLICheckMirrorsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CLI_CheckMirrorsClient interface {
+	Recv() (*CheckMirrorsReply, error)
+	grpc.ClientStream
+}
+
+type cLICheckMirrorsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cLICheckMirrorsClient) Recv() (*CheckMirrorsReply, error) {
+	m := new(CheckMirrorsReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cLIClient) DumpDatabase(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (CLI_DumpDatabaseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_CLI_serviceDesc.Streams[2], "/CLI/DumpDatabase", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cLIDumpDatabaseClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CLI_DumpDatabaseClient interface {
+	Recv() (*DumpRecord, error)
+	grpc.ClientStream
+}
+
+type cLIDumpDatabaseClient struct {
+	grpc.ClientStream
+}
+
+func (x *cLIDumpDatabaseClient) Recv() (*DumpRecord, error) {
+	m := new(DumpRecord)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cLIClient) RestoreDatabase(ctx context.Context, opts ...grpc.CallOption) (CLI_RestoreDatabaseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_CLI_serviceDesc.Streams[3], "/CLI/RestoreDatabase", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cLIRestoreDatabaseClient{stream}
+	return x, nil
+}
+
+type CLI_RestoreDatabaseClient interface {
+	Send(*RestoreDatabaseRequest) error
+	CloseAndRecv() (*RestoreDatabaseReply, error)
+	grpc.ClientStream
+}
+
+type cLIRestoreDatabaseClient struct {
+	grpc.ClientStream
+}
+
+func (x *cLIRestoreDatabaseClient) Send(m *RestoreDatabaseRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *cLIRestoreDatabaseClient) CloseAndRecv() (*RestoreDatabaseReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(RestoreDatabaseReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CLIServer is the server API for CLI service.
 type CLIServer interface {
 	GetVersion(context.Context, *empty.Empty) (*VersionReply, error)
 	Upgrade(context.Context, *empty.Empty) (*empty.Empty, error)
 	Reload(context.Context, *empty.Empty) (*empty.Empty, error)
+	GetConfig(context.Context, *empty.Empty) (*GetConfigReply, error)
 	ChangeStatus(context.Context, *ChangeStatusRequest) (*empty.Empty, error)
-	List(context.Context, *empty.Empty) (*MirrorListReply, error)
+	SetMaintenance(context.Context, *SetMaintenanceRequest) (*empty.Empty, error)
+	List(context.Context, *MirrorListRequest) (*MirrorListReply, error)
 	MirrorInfo(context.Context, *MirrorIDRequest) (*Mirror, error)
 	AddMirror(context.Context, *Mirror) (*AddMirrorReply, error)
+	AddMirrors(context.Context, *AddMirrorsRequest) (*AddMirrorsReply, error)
 	UpdateMirror(context.Context, *Mirror) (*UpdateMirrorReply, error)
-	RemoveMirror(context.Context, *MirrorIDRequest) (*empty.Empty, error)
+	RemoveMirror(context.Context, *RemoveMirrorRequest) (*empty.Empty, error)
+	RemovalReport(context.Context, *MirrorIDRequest) (*RemovalReportReply, error)
+	RestoreMirror(context.Context, *MirrorIDRequest) (*empty.Empty, error)
+	ListRemovedMirrors(context.Context, *empty.Empty) (*RemovedMirrorsReply, error)
 	GeoUpdateMirror(context.Context, *MirrorIDRequest) (*GeoUpdateMirrorReply, error)
 	RefreshRepository(context.Context, *RefreshRepositoryRequest) (*empty.Empty, error)
 	ScanMirror(context.Context, *ScanMirrorRequest) (*ScanMirrorReply, error)
 	StatsFile(context.Context, *StatsFileRequest) (*StatsFileReply, error)
 	StatsMirror(context.Context, *StatsMirrorRequest) (*StatsMirrorReply, error)
+	StatsTop(context.Context, *StatsTopRequest) (*StatsTopReply, error)
+	StatsHTTP(context.Context, *StatsHTTPRequest) (*StatsHTTPReply, error)
+	StatsPrune(context.Context, *empty.Empty) (*StatsPruneReply, error)
 	Ping(context.Context, *empty.Empty) (*empty.Empty, error)
 	GetMirrorLogs(context.Context, *GetMirrorLogsRequest) (*GetMirrorLogsReply, error)
+	SetStandby(context.Context, *SetStandbyRequest) (*empty.Empty, error)
+	ClusterStatus(context.Context, *empty.Empty) (*ClusterStatusReply, error)
 	// Tools
 	MatchMirror(context.Context, *MatchRequest) (*MatchReply, error)
+	SimulateSelection(context.Context, *SimulateSelectionRequest) (*SimulateSelectionReply, error)
+	SimulateTraffic(context.Context, *SimulateTrafficRequest) (*SimulateTrafficReply, error)
+	FileInfo(context.Context, *FileInfoRequest) (*FileInfoReply, error)
+	WatchEvents(*WatchEventsRequest, CLI_WatchEventsServer) error
+	CheckMirrors(*CheckMirrorsRequest, CLI_CheckMirrorsServer) error
+	DumpDatabase(*empty.Empty, CLI_DumpDatabaseServer) error
+	RestoreDatabase(CLI_RestoreDatabaseServer) error
 }
 
 // UnimplementedCLIServer can be embedded to have forward compatible implementations.
@@ -1634,10 +4789,16 @@ func (*UnimplementedCLIServer) Upgrade(ctx context.Context, req *empty.Empty) (*
 func (*UnimplementedCLIServer) Reload(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Reload not implemented")
 }
+func (*UnimplementedCLIServer) GetConfig(ctx context.Context, req *empty.Empty) (*GetConfigReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
 func (*UnimplementedCLIServer) ChangeStatus(ctx context.Context, req *ChangeStatusRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ChangeStatus not implemented")
 }
-func (*UnimplementedCLIServer) List(ctx context.Context, req *empty.Empty) (*MirrorListReply, error) {
+func (*UnimplementedCLIServer) SetMaintenance(ctx context.Context, req *SetMaintenanceRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMaintenance not implemented")
+}
+func (*UnimplementedCLIServer) List(ctx context.Context, req *MirrorListRequest) (*MirrorListReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
 }
 func (*UnimplementedCLIServer) MirrorInfo(ctx context.Context, req *MirrorIDRequest) (*Mirror, error) {
@@ -1646,12 +4807,24 @@ func (*UnimplementedCLIServer) MirrorInfo(ctx context.Context, req *MirrorIDRequ
 func (*UnimplementedCLIServer) AddMirror(ctx context.Context, req *Mirror) (*AddMirrorReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddMirror not implemented")
 }
+func (*UnimplementedCLIServer) AddMirrors(ctx context.Context, req *AddMirrorsRequest) (*AddMirrorsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddMirrors not implemented")
+}
 func (*UnimplementedCLIServer) UpdateMirror(ctx context.Context, req *Mirror) (*UpdateMirrorReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateMirror not implemented")
 }
-func (*UnimplementedCLIServer) RemoveMirror(ctx context.Context, req *MirrorIDRequest) (*empty.Empty, error) {
+func (*UnimplementedCLIServer) RemoveMirror(ctx context.Context, req *RemoveMirrorRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RemoveMirror not implemented")
 }
+func (*UnimplementedCLIServer) RemovalReport(ctx context.Context, req *MirrorIDRequest) (*RemovalReportReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovalReport not implemented")
+}
+func (*UnimplementedCLIServer) RestoreMirror(ctx context.Context, req *MirrorIDRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreMirror not implemented")
+}
+func (*UnimplementedCLIServer) ListRemovedMirrors(ctx context.Context, req *empty.Empty) (*RemovedMirrorsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRemovedMirrors not implemented")
+}
 func (*UnimplementedCLIServer) GeoUpdateMirror(ctx context.Context, req *MirrorIDRequest) (*GeoUpdateMirrorReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GeoUpdateMirror not implemented")
 }
@@ -1667,15 +4840,51 @@ func (*UnimplementedCLIServer) StatsFile(ctx context.Context, req *StatsFileRequ
 func (*UnimplementedCLIServer) StatsMirror(ctx context.Context, req *StatsMirrorRequest) (*StatsMirrorReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StatsMirror not implemented")
 }
+func (*UnimplementedCLIServer) StatsTop(ctx context.Context, req *StatsTopRequest) (*StatsTopReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatsTop not implemented")
+}
+func (*UnimplementedCLIServer) StatsHTTP(ctx context.Context, req *StatsHTTPRequest) (*StatsHTTPReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatsHTTP not implemented")
+}
+func (*UnimplementedCLIServer) StatsPrune(ctx context.Context, req *empty.Empty) (*StatsPruneReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatsPrune not implemented")
+}
 func (*UnimplementedCLIServer) Ping(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
 func (*UnimplementedCLIServer) GetMirrorLogs(ctx context.Context, req *GetMirrorLogsRequest) (*GetMirrorLogsReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMirrorLogs not implemented")
 }
+func (*UnimplementedCLIServer) SetStandby(ctx context.Context, req *SetStandbyRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetStandby not implemented")
+}
+func (*UnimplementedCLIServer) ClusterStatus(ctx context.Context, req *empty.Empty) (*ClusterStatusReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClusterStatus not implemented")
+}
 func (*UnimplementedCLIServer) MatchMirror(ctx context.Context, req *MatchRequest) (*MatchReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MatchMirror not implemented")
 }
+func (*UnimplementedCLIServer) SimulateSelection(ctx context.Context, req *SimulateSelectionRequest) (*SimulateSelectionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SimulateSelection not implemented")
+}
+func (*UnimplementedCLIServer) SimulateTraffic(ctx context.Context, req *SimulateTrafficRequest) (*SimulateTrafficReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SimulateTraffic not implemented")
+}
+func (*UnimplementedCLIServer) FileInfo(ctx context.Context, req *FileInfoRequest) (*FileInfoReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileInfo not implemented")
+}
+func (*UnimplementedCLIServer) WatchEvents(req *WatchEventsRequest, srv CLI_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (*UnimplementedCLIServer) CheckMirrors(req *CheckMirrorsRequest, srv CLI_CheckMirrorsServer) error {
+	return status.Errorf(codes.Unimplemented, "method CheckMirrors not implemented")
+}
+func (*UnimplementedCLIServer) DumpDatabase(req *empty.Empty, srv CLI_DumpDatabaseServer) error {
+	return status.Errorf(codes.Unimplemented, "method DumpDatabase not implemented")
+}
+func (*UnimplementedCLIServer) RestoreDatabase(srv CLI_RestoreDatabaseServer) error {
+	return status.Errorf(codes.Unimplemented, "method RestoreDatabase not implemented")
+}
 
 func RegisterCLIServer(s *grpc.Server, srv CLIServer) {
 	s.RegisterService(&_CLI_serviceDesc, srv)
@@ -1717,20 +4926,38 @@ func _CLI_Upgrade_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
-func _CLI_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _CLI_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/Reload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).Reload(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CLIServer).Reload(ctx, in)
+		return srv.(CLIServer).GetConfig(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/CLI/Reload",
+		FullMethod: "/CLI/GetConfig",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CLIServer).Reload(ctx, req.(*empty.Empty))
+		return srv.(CLIServer).GetConfig(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -1753,8 +4980,26 @@ func _CLI_ChangeStatus_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CLI_SetMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMaintenanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).SetMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/SetMaintenance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).SetMaintenance(ctx, req.(*SetMaintenanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CLI_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(MirrorListRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -1766,7 +5011,7 @@ func _CLI_List_Handler(srv interface{}, ctx context.Context, dec func(interface{
 		FullMethod: "/CLI/List",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CLIServer).List(ctx, req.(*empty.Empty))
+		return srv.(CLIServer).List(ctx, req.(*MirrorListRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -1807,6 +5052,24 @@ func _CLI_AddMirror_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CLI_AddMirrors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddMirrorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).AddMirrors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/AddMirrors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).AddMirrors(ctx, req.(*AddMirrorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CLI_UpdateMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Mirror)
 	if err := dec(in); err != nil {
@@ -1826,7 +5089,7 @@ func _CLI_UpdateMirror_Handler(srv interface{}, ctx context.Context, dec func(in
 }
 
 func _CLI_RemoveMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MirrorIDRequest)
+	in := new(RemoveMirrorRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -1838,7 +5101,61 @@ func _CLI_RemoveMirror_Handler(srv interface{}, ctx context.Context, dec func(in
 		FullMethod: "/CLI/RemoveMirror",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CLIServer).RemoveMirror(ctx, req.(*MirrorIDRequest))
+		return srv.(CLIServer).RemoveMirror(ctx, req.(*RemoveMirrorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_RemovalReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MirrorIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).RemovalReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/RemovalReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).RemovalReport(ctx, req.(*MirrorIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_RestoreMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MirrorIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).RestoreMirror(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/RestoreMirror",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).RestoreMirror(ctx, req.(*MirrorIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_ListRemovedMirrors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).ListRemovedMirrors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/ListRemovedMirrors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).ListRemovedMirrors(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -1933,6 +5250,60 @@ func _CLI_StatsMirror_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CLI_StatsTop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsTopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).StatsTop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/StatsTop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).StatsTop(ctx, req.(*StatsTopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_StatsHTTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsHTTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).StatsHTTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/StatsHTTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).StatsHTTP(ctx, req.(*StatsHTTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_StatsPrune_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).StatsPrune(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/StatsPrune",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).StatsPrune(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CLI_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
@@ -1969,6 +5340,42 @@ func _CLI_GetMirrorLogs_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CLI_SetStandby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetStandbyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).SetStandby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/SetStandby",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).SetStandby(ctx, req.(*SetStandbyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_ClusterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).ClusterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/ClusterStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).ClusterStatus(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CLI_MatchMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MatchRequest)
 	if err := dec(in); err != nil {
@@ -1987,6 +5394,149 @@ func _CLI_MatchMirror_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CLI_SimulateSelection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulateSelectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).SimulateSelection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/SimulateSelection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).SimulateSelection(ctx, req.(*SimulateSelectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_SimulateTraffic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulateTrafficRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).SimulateTraffic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/SimulateTraffic",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).SimulateTraffic(ctx, req.(*SimulateTrafficRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_FileInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CLIServer).FileInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CLI/FileInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CLIServer).FileInfo(ctx, req.(*FileInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CLI_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CLIServer).WatchEvents(m, &cLIWatchEventsServer{stream})
+}
+
+type CLI_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type cLIWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cLIWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CLI_CheckMirrors_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CheckMirrorsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CLIServer).CheckMirrors(m, &cLICheckMirrorsServer{stream})
+}
+
+type CLI_CheckMirrorsServer interface {
+	Send(*CheckMirrorsReply) error
+	grpc.ServerStream
+}
+
+type cLICheckMirrorsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cLICheckMirrorsServer) Send(m *CheckMirrorsReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CLI_DumpDatabase_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(empty.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CLIServer).DumpDatabase(m, &cLIDumpDatabaseServer{stream})
+}
+
+type CLI_DumpDatabaseServer interface {
+	Send(*DumpRecord) error
+	grpc.ServerStream
+}
+
+type cLIDumpDatabaseServer struct {
+	grpc.ServerStream
+}
+
+func (x *cLIDumpDatabaseServer) Send(m *DumpRecord) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CLI_RestoreDatabase_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CLIServer).RestoreDatabase(&cLIRestoreDatabaseServer{stream})
+}
+
+type CLI_RestoreDatabaseServer interface {
+	SendAndClose(*RestoreDatabaseReply) error
+	Recv() (*RestoreDatabaseRequest, error)
+	grpc.ServerStream
+}
+
+type cLIRestoreDatabaseServer struct {
+	grpc.ServerStream
+}
+
+func (x *cLIRestoreDatabaseServer) SendAndClose(m *RestoreDatabaseReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *cLIRestoreDatabaseServer) Recv() (*RestoreDatabaseRequest, error) {
+	m := new(RestoreDatabaseRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _CLI_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "CLI",
 	HandlerType: (*CLIServer)(nil),
@@ -2003,10 +5553,18 @@ var _CLI_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Reload",
 			Handler:    _CLI_Reload_Handler,
 		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _CLI_GetConfig_Handler,
+		},
 		{
 			MethodName: "ChangeStatus",
 			Handler:    _CLI_ChangeStatus_Handler,
 		},
+		{
+			MethodName: "SetMaintenance",
+			Handler:    _CLI_SetMaintenance_Handler,
+		},
 		{
 			MethodName: "List",
 			Handler:    _CLI_List_Handler,
@@ -2019,6 +5577,10 @@ var _CLI_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddMirror",
 			Handler:    _CLI_AddMirror_Handler,
 		},
+		{
+			MethodName: "AddMirrors",
+			Handler:    _CLI_AddMirrors_Handler,
+		},
 		{
 			MethodName: "UpdateMirror",
 			Handler:    _CLI_UpdateMirror_Handler,
@@ -2027,6 +5589,18 @@ var _CLI_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RemoveMirror",
 			Handler:    _CLI_RemoveMirror_Handler,
 		},
+		{
+			MethodName: "RemovalReport",
+			Handler:    _CLI_RemovalReport_Handler,
+		},
+		{
+			MethodName: "RestoreMirror",
+			Handler:    _CLI_RestoreMirror_Handler,
+		},
+		{
+			MethodName: "ListRemovedMirrors",
+			Handler:    _CLI_ListRemovedMirrors_Handler,
+		},
 		{
 			MethodName: "GeoUpdateMirror",
 			Handler:    _CLI_GeoUpdateMirror_Handler,
@@ -2047,6 +5621,18 @@ var _CLI_serviceDesc = grpc.ServiceDesc{
 			MethodName: "StatsMirror",
 			Handler:    _CLI_StatsMirror_Handler,
 		},
+		{
+			MethodName: "StatsTop",
+			Handler:    _CLI_StatsTop_Handler,
+		},
+		{
+			MethodName: "StatsHTTP",
+			Handler:    _CLI_StatsHTTP_Handler,
+		},
+		{
+			MethodName: "StatsPrune",
+			Handler:    _CLI_StatsPrune_Handler,
+		},
 		{
 			MethodName: "Ping",
 			Handler:    _CLI_Ping_Handler,
@@ -2055,11 +5641,52 @@ var _CLI_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetMirrorLogs",
 			Handler:    _CLI_GetMirrorLogs_Handler,
 		},
+		{
+			MethodName: "SetStandby",
+			Handler:    _CLI_SetStandby_Handler,
+		},
+		{
+			MethodName: "ClusterStatus",
+			Handler:    _CLI_ClusterStatus_Handler,
+		},
 		{
 			MethodName: "MatchMirror",
 			Handler:    _CLI_MatchMirror_Handler,
 		},
+		{
+			MethodName: "SimulateSelection",
+			Handler:    _CLI_SimulateSelection_Handler,
+		},
+		{
+			MethodName: "SimulateTraffic",
+			Handler:    _CLI_SimulateTraffic_Handler,
+		},
+		{
+			MethodName: "FileInfo",
+			Handler:    _CLI_FileInfo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _CLI_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CheckMirrors",
+			Handler:       _CLI_CheckMirrors_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DumpDatabase",
+			Handler:       _CLI_DumpDatabase_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RestoreDatabase",
+			Handler:       _CLI_RestoreDatabase_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
@@ -0,0 +1,159 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/etix/mirrorbits/mirrors"
+	. "github.com/etix/mirrorbits/testing"
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sendRecorder records the arguments of the HMSET sent by sendMirror
+type sendRecorder struct {
+	redis.Conn
+	args []interface{}
+}
+
+func (r *sendRecorder) Send(commandName string, args ...interface{}) error {
+	if commandName == "HMSET" {
+		r.args = args
+	}
+	return nil
+}
+
+// expectMirror registers the commands saving the given mirror
+func expectMirror(mock *redigomock.Conn, mirror mirrors.Mirror) *redigomock.Cmd {
+	rec := &sendRecorder{}
+	sendMirror(rec, &mirror)
+	mock.Command("HSET", "MIRRORS", mirror.ID, mirror.Name).Expect("QUEUED")
+	return mock.Command("HMSET", rec.args...).Expect("QUEUED")
+}
+
+// importedMirror returns a mirror as listed in an import file
+func importedMirror(name string) mirrors.Mirror {
+	return mirrors.Mirror{
+		Name:          name,
+		HttpURL:       "http://" + name + ".example.org/",
+		Latitude:      48.85,
+		Longitude:     2.35,
+		ContinentCode: "EU",
+		CountryCodes:  "FR",
+		Enabled:       true,
+	}
+}
+
+func addMirrorsRequest(t *testing.T, update bool, list ...mirrors.Mirror) *AddMirrorsRequest {
+	req := &AddMirrorsRequest{Update: update}
+	for i := range list {
+		m, err := MirrorToRPC(&list[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Mirrors = append(req.Mirrors, m)
+	}
+	return req
+}
+
+// prepareAddMirrors returns a CLI using a mocked redis where m1 is the
+// only existing mirror
+func prepareAddMirrors() (*redigomock.Conn, *CLI) {
+	mock, conn := PrepareRedisTest()
+	mock.Command("WATCH", "MIRRORS").Expect("OK")
+	mock.Command("UNWATCH").Expect("OK")
+	mock.Command("HGETALL", "MIRRORS").Expect([]interface{}{[]byte("1"), []byte("m1")})
+	mock.GenericCommand("PUBLISH").Expect(int64(0))
+	mock.GenericCommand("RPUSH").Expect(int64(1))
+	return mock, &CLI{redis: conn}
+}
+
+func TestCLI_AddMirrors(t *testing.T) {
+	mock, c := prepareAddMirrors()
+
+	mock.Command("HMGET", "MIRROR_1", "comment", "enabled").Expect([]interface{}{[]byte("kept"), []byte("1")})
+	cmdIDs := mock.Command("INCRBY", "LAST_MID", 2).Expect(int64(7))
+	mock.Command("MULTI").Expect("OK")
+	mock.Command("EXEC").Expect([]interface{}{})
+
+	// The new mirrors are disabled whereas m1 is updated
+	m2, m1, m3 := importedMirror("m2"), importedMirror("m1"), importedMirror("m3")
+	m2.ID, m2.Enabled = 6, false
+	m1.ID, m1.Comment = 1, "kept"
+	m3.ID, m3.Enabled = 7, false
+	cmdMirrors := []*redigomock.Cmd{expectMirror(mock, m2), expectMirror(mock, m1), expectMirror(mock, m3)}
+
+	reply, err := c.AddMirrors(context.Background(), addMirrorsRequest(t, true, importedMirror("m2"), importedMirror("m1"), importedMirror("m3")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reply.Committed {
+		t.Fatalf("Expected the mirrors to be committed, got %v", reply.Results)
+	}
+	if mock.Stats(cmdIDs) != 1 {
+		t.Fatalf("Expected the IDs to be reserved at once")
+	}
+	for i, cmd := range cmdMirrors {
+		if mock.Stats(cmd) != 1 {
+			t.Fatalf("Mirror #%d not saved as expected: %v", i, mock.Errors)
+		}
+	}
+
+	expected := []struct {
+		id      int32
+		updated bool
+	}{{6, false}, {1, true}, {7, false}}
+	for i, e := range expected {
+		r := reply.Results[i]
+		if r.ID != e.id || r.Updated != e.updated || r.Error != "" {
+			t.Fatalf("Unexpected result for %s: %v", r.Name, r)
+		}
+	}
+}
+
+func TestCLI_AddMirrors_Invalid(t *testing.T) {
+	mock, c := prepareAddMirrors()
+	cmdIDs := mock.GenericCommand("INCRBY").Expect(int64(2))
+	cmdMulti := mock.Command("MULTI").Expect("OK")
+
+	invalid := importedMirror("m3")
+	invalid.Name = "invalid name"
+	reply, err := c.AddMirrors(context.Background(), addMirrorsRequest(t, true, importedMirror("m2"), invalid, importedMirror("m2")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if reply.Committed || mock.Stats(cmdIDs) > 0 || mock.Stats(cmdMulti) > 0 {
+		t.Fatalf("Expected the whole batch to be aborted")
+	}
+	if reply.Results[0].Error != "" || reply.Results[1].Error == "" {
+		t.Fatalf("Expected only the invalid mirror to be reported, got %v", reply.Results)
+	}
+	if reply.Results[2].Error != ErrNameAlreadyTaken.Error() {
+		t.Fatalf("Expected the duplicated name to be reported, got %v", reply.Results[2])
+	}
+}
+
+func TestCLI_AddMirrors_Conflict(t *testing.T) {
+	mock, c := prepareAddMirrors()
+	mock.Command("INCRBY", "LAST_MID", 1).Expect(int64(2))
+	mock.Command("MULTI").Expect("OK")
+	m2 := importedMirror("m2")
+	m2.ID, m2.Enabled = 2, false
+	expectMirror(mock, m2)
+	// The transaction is discarded when MIRRORS is modified meanwhile
+	mock.Command("EXEC").Expect(nil)
+	cmdPublish := mock.GenericCommand("PUBLISH")
+
+	_, err := c.AddMirrors(context.Background(), addMirrorsRequest(t, true, importedMirror("m2")))
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected the transaction to be aborted, got %v", err)
+	}
+	if mock.Stats(cmdPublish) > 0 {
+		t.Fatalf("Expected no update to be published")
+	}
+}
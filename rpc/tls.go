@@ -0,0 +1,82 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidCA is the cause of the error returned when a certificate
+	// authority file does not contain any PEM encoded certificate
+	ErrInvalidCA = errors.New("no certificate found in the CA file")
+)
+
+// serverTLSConfig returns the TLS configuration of the RPC listener or nil
+// if TLS is not enabled. Client certificates signed by the configured CA
+// are required if any.
+func serverTLSConfig() (*tls.Config, error) {
+	conf := GetConfig().RPCTLS
+	if conf.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if conf.ClientCAFile != "" {
+		pool, err := loadCertPool(conf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientTLSConfig returns the TLS configuration used by the CLI to connect
+// to the server. The server certificate is verified against the given CA
+// or the system roots and the client certificate is presented if any.
+func ClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Wrap(ErrInvalidCA, file)
+	}
+	return pool, nil
+}
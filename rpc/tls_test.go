@@ -0,0 +1,275 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/pkg/errors"
+)
+
+// testPKI writes certificates and their keys in a temporary directory
+type testPKI struct {
+	t      *testing.T
+	dir    string
+	serial int64
+}
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// issue creates a certificate signed by the given parent, or self-signed
+// if nil
+func (p *testPKI) issue(name string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	c := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(p.dir, name+".crt"),
+		keyFile:  filepath.Join(p.dir, name+".key"),
+	}
+	p.write(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	p.write(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+	return c
+}
+
+func (p *testPKI) write(file string, data []byte) {
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// handshake connects a client to a server with the given configurations
+// and returns the error seen by the server
+func handshake(t *testing.T, server, client *tls.Config) error {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			result <- err
+			return
+		}
+		defer conn.Close()
+		result <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), client)
+	if err == nil {
+		// The refusal of the client certificate may only be known once
+		// the handshake is complete on the server side
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	select {
+	case err = <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Handshake timed out")
+		return nil
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pki := &testPKI{t: t, dir: dir}
+	ca := pki.issue("ca", nil, 0)
+	server := pki.issue("server", ca, x509.ExtKeyUsageServerAuth)
+	client := pki.issue("client", ca, x509.ExtKeyUsageClientAuth)
+	otherCA := pki.issue("other-ca", nil, 0)
+	stranger := pki.issue("stranger", otherCA, x509.ExtKeyUsageClientAuth)
+
+	defer SetConfiguration(&Configuration{})
+
+	// TLS is disabled without certificate
+	SetConfiguration(&Configuration{})
+	if conf, err := serverTLSConfig(); conf != nil || err != nil {
+		t.Fatalf("Expected TLS to be disabled, got %v %v", conf, err)
+	}
+
+	// Server authentication only
+	c := Configuration{}
+	c.RPCTLS.CertFile = server.certFile
+	c.RPCTLS.KeyFile = server.keyFile
+	SetConfiguration(&c)
+
+	serverConf, err := serverTLSConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if serverConf.ClientAuth != tls.NoClientCert || serverConf.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("Unexpected server configuration")
+	}
+	clientConf, err := ClientTLSConfig("", "", ca.certFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err = handshake(t, serverConf, clientConf); err != nil {
+		t.Fatalf("Expected the connection to succeed, got %s", err)
+	}
+
+	// The server certificate is verified by the client
+	clientConf, err = ClientTLSConfig("", "", otherCA.certFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err = handshake(t, serverConf, clientConf); err == nil {
+		t.Fatalf("Expected the server certificate to be refused")
+	}
+
+	// Client certificates are required by the server
+	c.RPCTLS.ClientCAFile = ca.certFile
+	SetConfiguration(&c)
+
+	serverConf, err = serverTLSConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if serverConf.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("Expected the client certificates to be required")
+	}
+
+	clientConf, _ = ClientTLSConfig("", "", ca.certFile)
+	if err = handshake(t, serverConf, clientConf); err == nil {
+		t.Fatalf("Expected a client without certificate to be refused")
+	}
+	clientConf, _ = ClientTLSConfig(stranger.certFile, stranger.keyFile, ca.certFile)
+	if err = handshake(t, serverConf, clientConf); err == nil {
+		t.Fatalf("Expected a client certificate signed by another CA to be refused")
+	}
+	clientConf, err = ClientTLSConfig(client.certFile, client.keyFile, ca.certFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(clientConf.Certificates) != 1 {
+		t.Fatalf("Expected the client certificate to be presented")
+	}
+	if err = handshake(t, serverConf, clientConf); err != nil {
+		t.Fatalf("Expected the client certificate to be accepted, got %s", err)
+	}
+
+	// Invalid files
+	if _, err = ClientTLSConfig(client.certFile, "", ca.certFile); err == nil {
+		t.Fatalf("Expected an error without the client key")
+	}
+	c.RPCTLS.KeyFile = client.keyFile
+	SetConfiguration(&c)
+	if _, err = serverTLSConfig(); err == nil {
+		t.Fatalf("Expected an error with a mismatching key")
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirrorbits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pki := &testPKI{t: t, dir: dir}
+	ca := pki.issue("ca", nil, 0)
+
+	pool, err := loadCertPool(ca.certFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err = ca.cert.Verify(x509.VerifyOptions{Roots: pool}); err != nil {
+		t.Fatalf("Expected the CA to be in the pool: %s", err)
+	}
+
+	// Not a PEM file
+	invalid := filepath.Join(dir, "invalid.crt")
+	pki.write(invalid, ca.cert.Raw)
+	if _, err = loadCertPool(invalid); errors.Cause(err) != ErrInvalidCA {
+		t.Fatalf("Expected ErrInvalidCA, got %v", err)
+	}
+	// A private key is not a certificate either
+	if _, err = loadCertPool(ca.keyFile); errors.Cause(err) != ErrInvalidCA {
+		t.Fatalf("Expected ErrInvalidCA, got %v", err)
+	}
+	if _, err = loadCertPool(filepath.Join(dir, "missing.crt")); !os.IsNotExist(err) {
+		t.Fatalf("Expected a missing file error, got %v", err)
+	}
+
+	// Both ends reject an invalid CA file
+	if _, err = ClientTLSConfig("", "", invalid); errors.Cause(err) != ErrInvalidCA {
+		t.Fatalf("Expected ErrInvalidCA from the client configuration, got %v", err)
+	}
+	server := pki.issue("server", ca, x509.ExtKeyUsageServerAuth)
+	c := Configuration{}
+	c.RPCTLS.CertFile = server.certFile
+	c.RPCTLS.KeyFile = server.keyFile
+	c.RPCTLS.ClientCAFile = invalid
+	SetConfiguration(&c)
+	defer SetConfiguration(&Configuration{})
+	if _, err = serverTLSConfig(); errors.Cause(err) != ErrInvalidCA {
+		t.Fatalf("Expected ErrInvalidCA from the server configuration, got %v", err)
+	}
+}
@@ -4,6 +4,12 @@
 package rpc
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/etix/mirrorbits/core"
 	"github.com/etix/mirrorbits/mirrors"
 	"github.com/golang/protobuf/ptypes"
 )
@@ -25,37 +31,68 @@ func MirrorToRPC(m *mirrors.Mirror) (*Mirror, error) {
 	if err != nil {
 		return nil, err
 	}
+	maintenanceUntil, err := ptypes.TimestampProto(m.MaintenanceUntil.Time)
+	if err != nil {
+		return nil, err
+	}
+	disabledUntil, err := ptypes.TimestampProto(m.DisabledUntil.Time)
+	if err != nil {
+		return nil, err
+	}
+	nodeHealth, err := nodeHealthToRPC(m.NodeHealth)
+	if err != nil {
+		return nil, err
+	}
 	return &Mirror{
-		ID:                   int32(m.ID),
-		Name:                 m.Name,
-		HttpURL:              m.HttpURL,
-		RsyncURL:             m.RsyncURL,
-		FtpURL:               m.FtpURL,
-		SponsorName:          m.SponsorName,
-		SponsorURL:           m.SponsorURL,
-		SponsorLogoURL:       m.SponsorLogoURL,
-		AdminName:            m.AdminName,
-		AdminEmail:           m.AdminEmail,
-		CustomData:           m.CustomData,
-		ContinentOnly:        m.ContinentOnly,
-		CountryOnly:          m.CountryOnly,
-		ASOnly:               m.ASOnly,
-		Score:                int32(m.Score),
-		Latitude:             m.Latitude,
-		Longitude:            m.Longitude,
-		ContinentCode:        m.ContinentCode,
-		CountryCodes:         m.CountryCodes,
-		ExcludedCountryCodes: m.ExcludedCountryCodes,
-		Asnum:                uint32(m.Asnum),
-		Comment:              m.Comment,
-		Enabled:              m.Enabled,
-		Up:                   m.Up,
-		ExcludeReason:        m.ExcludeReason,
-		StateSince:           stateSince,
-		AllowRedirects:       int32(m.AllowRedirects),
-		LastSync:             lastSync,
-		LastSuccessfulSync:   lastSuccessfulSync,
-		LastModTime:          lastModTime,
+		ID:                          int32(m.ID),
+		Name:                        m.Name,
+		HttpURL:                     m.HttpURL,
+		RsyncURL:                    m.RsyncURL,
+		FtpURL:                      m.FtpURL,
+		SponsorName:                 m.SponsorName,
+		SponsorURL:                  m.SponsorURL,
+		SponsorLogoURL:              m.SponsorLogoURL,
+		AdminName:                   m.AdminName,
+		AdminEmail:                  m.AdminEmail,
+		CustomData:                  m.CustomData,
+		ContinentOnly:               m.ContinentOnly,
+		CountryOnly:                 m.CountryOnly,
+		ASOnly:                      m.ASOnly,
+		Score:                       int32(m.Score),
+		Latitude:                    m.Latitude,
+		Longitude:                   m.Longitude,
+		ContinentCode:               m.ContinentCode,
+		CountryCodes:                m.CountryCodes,
+		ExcludedCountryCodes:        m.ExcludedCountryCodes,
+		AdditionalServedCountries:   m.AdditionalServedCountries,
+		Asnum:                       uint32(m.Asnum),
+		Comment:                     m.Comment,
+		Enabled:                     m.Enabled,
+		Up:                          m.Up,
+		ExcludeReason:               m.ExcludeReason,
+		StateSince:                  stateSince,
+		AllowRedirects:              int32(m.AllowRedirects),
+		LastSync:                    lastSync,
+		LastSuccessfulSync:          lastSuccessfulSync,
+		LastModTime:                 lastModTime,
+		LastSuccessfulSyncProtocol:  int32(m.LastSuccessfulSyncProtocol),
+		LastSuccessfulSyncPrecision: int64(m.LastSuccessfulSyncPrecision),
+		ModTimePrecision:            int64(m.ModTimePrecision),
+		IntegrityFailed:             m.IntegrityFailed,
+		HealthCheckPath:             m.HealthCheckPath,
+		HealthCheckCodes:            m.HealthCheckCodes,
+		Note:                        m.Note,
+		NodeHealth:                  nodeHealth,
+		Tags:                        m.Tags,
+		Maintenance:                 m.Maintenance,
+		MaintenanceUntil:            maintenanceUntil,
+		HostHeader:                  m.HostHeader,
+		DisabledReason:              m.DisabledReason,
+		DisabledUntil:               disabledUntil,
+		ScanInterval:                int32(m.ScanInterval),
+		RsyncBandwidthLimit:         int32(m.RsyncBandwidthLimit),
+		FtpConnections:              int32(m.FtpConnections),
+		AltURLs:                     altURLsToRPC(m.AltURLs),
 	}, nil
 }
 
@@ -76,36 +113,194 @@ func MirrorFromRPC(m *Mirror) (*mirrors.Mirror, error) {
 	if err != nil {
 		return nil, err
 	}
+	var maintenanceUntil time.Time
+	if m.MaintenanceUntil != nil {
+		maintenanceUntil, err = ptypes.Timestamp(m.MaintenanceUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var disabledUntil time.Time
+	if m.DisabledUntil != nil {
+		disabledUntil, err = ptypes.Timestamp(m.DisabledUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &mirrors.Mirror{
-		ID:                   int(m.ID),
-		Name:                 m.Name,
-		HttpURL:              m.HttpURL,
-		RsyncURL:             m.RsyncURL,
-		FtpURL:               m.FtpURL,
-		SponsorName:          m.SponsorName,
-		SponsorURL:           m.SponsorURL,
-		SponsorLogoURL:       m.SponsorLogoURL,
-		AdminName:            m.AdminName,
-		AdminEmail:           m.AdminEmail,
-		CustomData:           m.CustomData,
-		ContinentOnly:        m.ContinentOnly,
-		CountryOnly:          m.CountryOnly,
-		ASOnly:               m.ASOnly,
-		Score:                int(m.Score),
-		Latitude:             m.Latitude,
-		Longitude:            m.Longitude,
-		ContinentCode:        m.ContinentCode,
-		CountryCodes:         m.CountryCodes,
-		ExcludedCountryCodes: m.ExcludedCountryCodes,
-		Asnum:                uint(m.Asnum),
-		Comment:              m.Comment,
-		Enabled:              m.Enabled,
-		Up:                   m.Up,
-		ExcludeReason:        m.ExcludeReason,
-		StateSince:           mirrors.Time{}.FromTime(stateSince),
-		AllowRedirects:       mirrors.Redirects(m.AllowRedirects),
-		LastSync:             mirrors.Time{}.FromTime(lastSync),
-		LastSuccessfulSync:   mirrors.Time{}.FromTime(lastSuccessfulSync),
-		LastModTime:          mirrors.Time{}.FromTime(lastModTime),
+		ID:                          int(m.ID),
+		Name:                        m.Name,
+		HttpURL:                     m.HttpURL,
+		RsyncURL:                    m.RsyncURL,
+		FtpURL:                      m.FtpURL,
+		SponsorName:                 m.SponsorName,
+		SponsorURL:                  m.SponsorURL,
+		SponsorLogoURL:              m.SponsorLogoURL,
+		AdminName:                   m.AdminName,
+		AdminEmail:                  m.AdminEmail,
+		CustomData:                  m.CustomData,
+		ContinentOnly:               m.ContinentOnly,
+		CountryOnly:                 m.CountryOnly,
+		ASOnly:                      m.ASOnly,
+		Score:                       int(m.Score),
+		Latitude:                    m.Latitude,
+		Longitude:                   m.Longitude,
+		ContinentCode:               m.ContinentCode,
+		CountryCodes:                m.CountryCodes,
+		ExcludedCountryCodes:        m.ExcludedCountryCodes,
+		AdditionalServedCountries:   m.AdditionalServedCountries,
+		Asnum:                       uint(m.Asnum),
+		Comment:                     m.Comment,
+		Enabled:                     m.Enabled,
+		Up:                          m.Up,
+		ExcludeReason:               m.ExcludeReason,
+		StateSince:                  mirrors.Time{}.FromTime(stateSince),
+		AllowRedirects:              mirrors.Redirects(m.AllowRedirects),
+		LastSync:                    mirrors.Time{}.FromTime(lastSync),
+		LastSuccessfulSync:          mirrors.Time{}.FromTime(lastSuccessfulSync),
+		LastModTime:                 mirrors.Time{}.FromTime(lastModTime),
+		LastSuccessfulSyncProtocol:  core.ScannerType(m.LastSuccessfulSyncProtocol),
+		LastSuccessfulSyncPrecision: core.Precision(m.LastSuccessfulSyncPrecision),
+		ModTimePrecision:            core.Precision(m.ModTimePrecision),
+		IntegrityFailed:             m.IntegrityFailed,
+		HealthCheckPath:             m.HealthCheckPath,
+		HealthCheckCodes:            m.HealthCheckCodes,
+		Note:                        m.Note,
+		Tags:                        m.Tags,
+		Maintenance:                 m.Maintenance,
+		MaintenanceUntil:            mirrors.Time{}.FromTime(maintenanceUntil),
+		HostHeader:                  m.HostHeader,
+		DisabledReason:              m.DisabledReason,
+		DisabledUntil:               mirrors.Time{}.FromTime(disabledUntil),
+		ScanInterval:                int(m.ScanInterval),
+		RsyncBandwidthLimit:         int(m.RsyncBandwidthLimit),
+		FtpConnections:              int(m.FtpConnections),
+		AltURLs:                     altURLsFromRPC(m.AltURLs),
 	}, nil
 }
+
+func altURLsToRPC(list mirrors.AltURLs) []*AltURL {
+	var alts []*AltURL
+	for _, a := range list {
+		alts = append(alts, &AltURL{
+			URL:           a.URL,
+			Latitude:      a.Latitude,
+			Longitude:     a.Longitude,
+			ContinentCode: a.ContinentCode,
+			CountryCodes:  a.CountryCodes,
+			Asnum:         uint32(a.Asnum),
+			Down:          a.Down,
+		})
+	}
+	return alts
+}
+
+func altURLsFromRPC(list []*AltURL) mirrors.AltURLs {
+	var alts mirrors.AltURLs
+	for _, a := range list {
+		alts = append(alts, mirrors.AltURL{
+			URL:           a.URL,
+			Latitude:      a.Latitude,
+			Longitude:     a.Longitude,
+			ContinentCode: a.ContinentCode,
+			CountryCodes:  a.CountryCodes,
+			Asnum:         uint(a.Asnum),
+			CountryFields: strings.Fields(a.CountryCodes),
+			Down:          a.Down,
+		})
+	}
+	return alts
+}
+
+func selectedMirrorToRPC(m mirrors.Mirror) *SelectedMirror {
+	return &SelectedMirror{
+		ID:            int32(m.ID),
+		Name:          m.Name,
+		HttpURL:       m.HttpURL,
+		CountryCodes:  m.CountryCodes,
+		ContinentCode: m.ContinentCode,
+		Asnum:         uint32(m.Asnum),
+		Distance:      m.Distance,
+		Weight:        m.Weight,
+		ComputedScore: int32(m.ComputedScore),
+		ExcludeReason: m.ExcludeReason,
+	}
+}
+
+func nodeHealthToRPC(results []mirrors.NodeHealth) ([]*NodeHealth, error) {
+	var list []*NodeHealth
+	for _, h := range results {
+		t, err := ptypes.TimestampProto(h.Time)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &NodeHealth{
+			Node:   h.Node,
+			Up:     h.Up,
+			Reason: h.Reason,
+			Time:   t,
+		})
+	}
+	return list, nil
+}
+
+// matchListFilters returns true if the mirror matches
+// the filters of the given list request
+func matchListFilters(m *mirrors.Mirror, in *MirrorListRequest) bool {
+	if !matchFilter(in.Enabled, m.Enabled) || !matchFilter(in.Up, m.Up) {
+		return false
+	}
+	if in.Country != "" && !containsFold(strings.Fields(m.CountryCodes), in.Country) {
+		return false
+	}
+	if in.Tag != "" && !containsFold(strings.Fields(m.Tags), in.Tag) {
+		return false
+	}
+	return true
+}
+
+func matchFilter(f MirrorListRequest_Filter, value bool) bool {
+	switch f {
+	case MirrorListRequest_YES:
+		return value
+	case MirrorListRequest_NO:
+		return !value
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasField(fields []string, name string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maskMirror returns a copy of the mirror with only the given fields set
+func maskMirror(m *Mirror, fields []string) (*Mirror, error) {
+	masked := &Mirror{}
+	src := reflect.ValueOf(m).Elem()
+	dst := reflect.ValueOf(masked).Elem()
+	for _, name := range fields {
+		if strings.HasPrefix(name, "XXX_") {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+		field := src.FieldByName(name)
+		if !field.IsValid() {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+		dst.FieldByName(name).Set(field)
+	}
+	return masked, nil
+}
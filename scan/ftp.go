@@ -4,29 +4,43 @@
 package scan
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	ftp "github.com/etix/goftp"
+	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
-	"github.com/etix/mirrorbits/utils"
 	"github.com/gomodule/redigo/redis"
 )
 
 const (
 	ftpConnTimeout = 5 * time.Second
 	ftpRWTimeout   = 30 * time.Second
+	ftpOpTimeout   = 5 * time.Minute
+)
+
+var (
+	// ErrScanTimeout is returned when a scan takes longer than allowed
+	ErrScanTimeout = errors.New("scan timed out")
+
+	errFtpOpTimeout = errors.New("ftp operation timed out")
 )
 
 // FTPScanner is the implementation of an ftp scanner
 type FTPScanner struct {
 	scan *scan
 
+	connections int // number of concurrent connections, 0 for 1
+
 	featMLST  bool
 	featMDTM  bool
 	precision core.Precision // Used for truncating time for comparison
+	mu        sync.Mutex     // Protects precision
 }
 
 // Scan starts an ftp scan of the given mirror
@@ -45,30 +59,28 @@ func (f *FTPScanner) Scan(scanurl, identifier string, conn redis.Conn, stop <-ch
 		host += ":21"
 	}
 
-	if utils.IsStopped(stop) {
-		return 0, ErrScanAborted
-	}
-
-	c, err := ftp.DialTimeout(host, ftpConnTimeout, ftpRWTimeout)
-	if err != nil {
-		return 0, err
+	// Abort the scan when it is stopped or when it takes too long
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := GetConfig().FtpScanTimeout; timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(timeout)*time.Minute)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
 	}
-	defer c.Quit()
-
-	username, password := "anonymous", "anonymous"
-
-	if ftpurl.User != nil {
-		username = ftpurl.User.Username()
-		pass, hasPassword := ftpurl.User.Password()
-		if hasPassword {
-			password = pass
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
 		}
-	}
+	}()
 
-	err = c.Login(username, password)
+	c, err := ftpConnect(ctx, host, ftpurl.User)
 	if err != nil {
 		return 0, err
 	}
+	defer ftpQuit(c)
 
 	_, f.featMLST = c.Feature("MLST")
 	_, f.featMDTM = c.Feature("MDTM")
@@ -79,24 +91,39 @@ func (f *FTPScanner) Scan(scanurl, identifier string, conn redis.Conn, stop <-ch
 
 	log.Infof("[%s] Requesting file list via ftp...", identifier)
 
-	files := make([]*filedata, 0, 1000)
-
-	err = c.ChangeDir(ftpurl.Path)
-	if err != nil {
-		return 0, fmt.Errorf("ftp error %s", err.Error())
+	if err := ftpDo(ctx, func() error {
+		return c.ChangeDir(ftpurl.Path)
+	}); err != nil {
+		return 0, ftpError(err)
 	}
 
-	_, err = c.CurrentDir()
-	if err != nil {
-		return 0, fmt.Errorf("ftp error %s", err.Error())
+	if err := ftpDo(ctx, func() error {
+		_, err := c.CurrentDir()
+		return err
+	}); err != nil {
+		return 0, ftpError(err)
 	}
 
 	// Remove the trailing slash
 	prefix := strings.TrimRight(ftpurl.Path, "/")
 
-	files, err = f.walkFtp(c, files, prefix+"/", stop)
+	// Open the additional connections, the server may refuse some of them
+	conns := []*ftp.ServerConn{c}
+	for i := 1; i < f.connections; i++ {
+		cx, err := ftpConnect(ctx, host, ftpurl.User)
+		if err == ErrScanAborted || err == ErrScanTimeout {
+			return 0, err
+		} else if err != nil {
+			log.Warningf("[%s] Unable to open ftp connection #%d: %s", identifier, i+1, err)
+			break
+		}
+		defer ftpQuit(cx)
+		conns = append(conns, cx)
+	}
+
+	files, err := f.walkFtp(ctx, conns, prefix+"/")
 	if err != nil {
-		return 0, fmt.Errorf("ftp error %s", err.Error())
+		return 0, ftpError(err)
 	}
 
 	count := 0
@@ -109,16 +136,172 @@ func (f *FTPScanner) Scan(scanurl, identifier string, conn redis.Conn, stop <-ch
 	return f.precision, nil
 }
 
-// Walk inside an FTP repository
-func (f *FTPScanner) walkFtp(c *ftp.ServerConn, files []*filedata, path string, stop <-chan struct{}) ([]*filedata, error) {
-	if utils.IsStopped(stop) {
-		return nil, ErrScanAborted
+// ftpConnect opens an ftp connection to the given host and logs in, unless
+// the scan is aborted meanwhile
+func ftpConnect(ctx context.Context, host string, user *url.Userinfo) (*ftp.ServerConn, error) {
+	type result struct {
+		c   *ftp.ServerConn
+		err error
 	}
 
-	flist, err := c.List(path)
+	r := make(chan result, 1)
+	go func() {
+		c, err := ftpLogin(host, user)
+		r <- result{c, err}
+	}()
+
+	select {
+	case res := <-r:
+		return res.c, res.err
+	case <-ctx.Done():
+		// Close the connection if it gets established anyway
+		go func() {
+			if res := <-r; res.c != nil {
+				res.c.Quit()
+			}
+		}()
+		return nil, abortError(ctx)
+	}
+}
+
+// ftpLogin opens an ftp connection to the given host and logs in
+func ftpLogin(host string, user *url.Userinfo) (*ftp.ServerConn, error) {
+	c, err := ftp.DialTimeout(host, ftpConnTimeout, ftpRWTimeout)
 	if err != nil {
 		return nil, err
 	}
+
+	username, password := "anonymous", "anonymous"
+
+	if user != nil {
+		username = user.Username()
+		pass, hasPassword := user.Password()
+		if hasPassword {
+			password = pass
+		}
+	}
+
+	err = c.Login(username, password)
+	if err != nil {
+		c.Quit()
+		return nil, err
+	}
+	return c, nil
+}
+
+// ftpDo runs an operation on an ftp connection and returns its error, or
+// gives up when it takes longer than ftpOpTimeout or when the scan is
+// aborted. Since the operation keeps running in the background, neither the
+// connection nor the variables set by the operation must be used anymore
+// after giving up.
+func ftpDo(ctx context.Context, op func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+
+	timer := time.NewTimer(ftpOpTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return errFtpOpTimeout
+	case <-ctx.Done():
+		return abortError(ctx)
+	}
+}
+
+// ftpQuit closes the connection in the background, a pending operation
+// abandoned by ftpDo would otherwise block the QUIT command
+func ftpQuit(c *ftp.ServerConn) {
+	go c.Quit()
+}
+
+// ftpError returns the error of an ftp operation, the errors of the server
+// being prefixed
+func ftpError(err error) error {
+	switch err {
+	case nil, ErrScanAborted, ErrScanTimeout, errFtpOpTimeout:
+		return err
+	}
+	return fmt.Errorf("ftp error %s", err.Error())
+}
+
+// abortError returns the error matching the reason why the scan was aborted
+func abortError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return ErrScanTimeout
+	}
+	return ErrScanAborted
+}
+
+// Walk inside an FTP repository, listing the directories concurrently on
+// the given connections
+func (f *FTPScanner) walkFtp(ctx context.Context, conns []*ftp.ServerConn, root string) ([]*filedata, error) {
+	var (
+		mu      sync.Mutex
+		cond    = sync.NewCond(&mu)
+		queue   = []string{root}
+		active  int
+		files   = make([]*filedata, 0, 1000)
+		walkErr error
+		wg      sync.WaitGroup
+	)
+
+	for _, c := range conns {
+		wg.Add(1)
+		go func(c *ftp.ServerConn) {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				// Wait for a directory unless the walk is over
+				for len(queue) == 0 && active > 0 && walkErr == nil {
+					cond.Wait()
+				}
+				if len(queue) == 0 || walkErr != nil {
+					mu.Unlock()
+					cond.Broadcast()
+					return
+				}
+				path := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+				active++
+				mu.Unlock()
+
+				found, dirs, err := f.listFtp(ctx, c, path)
+
+				mu.Lock()
+				active--
+				if err != nil && walkErr == nil {
+					walkErr = err
+				}
+				files = append(files, found...)
+				queue = append(queue, dirs...)
+				mu.Unlock()
+				cond.Broadcast()
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return files, walkErr
+}
+
+// listFtp returns the files and the sub-directories of the given directory
+func (f *FTPScanner) listFtp(ctx context.Context, c *ftp.ServerConn, path string) (files []*filedata, dirs []string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, abortError(ctx)
+	}
+
+	var flist []*ftp.Entry
+	if err := ftpDo(ctx, func() (err error) {
+		flist, err = c.List(path)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
 	for _, e := range flist {
 		if e.Type == ftp.EntryTypeFile {
 			newf := &filedata{}
@@ -126,10 +309,17 @@ func (f *FTPScanner) walkFtp(c *ftp.ServerConn, files []*filedata, path string,
 			newf.size = int64(e.Size)
 
 			if f.featMDTM {
-				t, _ := c.LastModificationDate(path + e.Name)
+				var t time.Time
+				if err := ftpDo(ctx, func() error {
+					t, _ = c.LastModificationDate(path + e.Name)
+					return nil
+				}); err != nil {
+					return nil, nil, err
+				}
 				if !t.IsZero() {
 					newf.modTime = t
 
+					f.mu.Lock()
 					if f.precision != core.Precision(time.Millisecond) {
 						// We are not yet sure that we can have millisecond precision
 						if newf.modTime.Truncate(time.Second).Equal(newf.modTime) {
@@ -140,14 +330,17 @@ func (f *FTPScanner) walkFtp(c *ftp.ServerConn, files []*filedata, path string,
 							f.precision = core.Precision(time.Millisecond)
 						}
 					}
+					f.mu.Unlock()
 				}
 			}
 			if newf.modTime.IsZero() {
 				if f.featMLST {
 					newf.modTime = e.Time
+					f.mu.Lock()
 					if f.precision == 0 {
 						f.precision = core.Precision(time.Second)
 					}
+					f.mu.Unlock()
 				} else {
 					newf.modTime = time.Time{}
 				}
@@ -157,11 +350,8 @@ func (f *FTPScanner) walkFtp(c *ftp.ServerConn, files []*filedata, path string,
 			if e.Name == "." || e.Name == ".." {
 				continue
 			}
-			files, err = f.walkFtp(c, files, path+e.Name+"/", stop)
-			if err != nil {
-				return files, err
-			}
+			dirs = append(dirs, path+e.Name+"/")
 		}
 	}
-	return files, err
+	return files, dirs, nil
 }
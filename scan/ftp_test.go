@@ -0,0 +1,156 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+	"github.com/rafaeljusto/redigomock"
+)
+
+func TestFtpDo(t *testing.T) {
+	called := false
+	if err := ftpDo(context.Background(), func() error { called = true; return nil }); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !called {
+		t.Fatalf("The operation was not run")
+	}
+
+	errOp := errors.New("operation failed")
+	if err := ftpDo(context.Background(), func() error { return errOp }); err != errOp {
+		t.Fatalf("Expected the error of the operation, got %v", err)
+	}
+
+	block := make(chan struct{})
+	defer close(block)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ftpDo(ctx, func() error { <-block; return errOp }); err != ErrScanAborted {
+		t.Fatalf("Expected ErrScanAborted, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := ftpDo(ctx, func() error { <-block; return errOp }); err != ErrScanTimeout {
+		t.Fatalf("Expected ErrScanTimeout, got %v", err)
+	}
+}
+
+// ftpTree is the content of the repository served by the fake ftp server,
+// the directories ending with a slash
+var ftpTree = map[string][]string{
+	"/pub/":       {"a/", "b/", "c/", "root.iso"},
+	"/pub/a/":     {"a1.iso", "a2.iso", "sub/"},
+	"/pub/a/sub/": {"deep.iso"},
+	"/pub/b/":     {"b1.iso"},
+	"/pub/c/":     {"c1.iso", "c2.iso", "c3.iso"},
+}
+
+// serveFtp serves ftpTree on the given listener, supporting just enough of
+// the protocol for the scanner
+func serveFtp(t *testing.T, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			tc := textproto.NewConn(conn)
+			tc.PrintfLine("220 ready")
+
+			var data net.Listener
+			for {
+				line, err := tc.ReadLine()
+				if err != nil {
+					return
+				}
+				cmd, arg := line, ""
+				if i := strings.Index(line, " "); i > 0 {
+					cmd, arg = line[:i], line[i+1:]
+				}
+				switch cmd {
+				case "FEAT":
+					tc.PrintfLine("211-Features:\r\n MDTM\r\n MLST\r\n211 End")
+				case "USER":
+					tc.PrintfLine("230 logged in")
+				case "TYPE":
+					tc.PrintfLine("200 ok")
+				case "CWD":
+					tc.PrintfLine("250 ok")
+				case "PWD":
+					tc.PrintfLine(`257 "/pub"`)
+				case "EPSV":
+					if data, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+						t.Error(err)
+						return
+					}
+					tc.PrintfLine("229 Entering Extended Passive Mode (|||%d|)", data.Addr().(*net.TCPAddr).Port)
+				case "MLSD":
+					tc.PrintfLine("150 listing")
+					dc, err := data.Accept()
+					data.Close()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					for _, name := range ftpTree[arg] {
+						if strings.HasSuffix(name, "/") {
+							fmt.Fprintf(dc, "type=dir;modify=20190102030405; %s\r\n", strings.TrimSuffix(name, "/"))
+						} else {
+							fmt.Fprintf(dc, "type=file;size=%d;modify=20190102030405; %s\r\n", len(name), name)
+						}
+					}
+					dc.Close()
+					tc.PrintfLine("226 done")
+				case "MDTM":
+					tc.PrintfLine("213 20190102030405")
+				case "QUIT":
+					tc.PrintfLine("221 bye")
+					return
+				default:
+					tc.PrintfLine("502 not implemented")
+				}
+			}
+		}(conn)
+	}
+}
+
+func TestFTPScannerConcurrent(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go serveFtp(t, l)
+
+	SetConfiguration(&Configuration{})
+	defer SetConfiguration(&Configuration{})
+
+	mock := redigomock.NewConn()
+	s := &scan{conn: mock, mirrorid: 1}
+	f := &FTPScanner{scan: s, connections: 3}
+
+	precision, err := f.Scan("ftp://"+l.Addr().String()+"/pub/", "m1", mock, make(chan struct{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if precision != core.Precision(time.Second) {
+		t.Fatalf("Expected a precision of one second, got %s", precision)
+	}
+	if s.count != 8 {
+		t.Fatalf("Expected 8 files, got %d", s.count)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	. "github.com/etix/mirrorbits/config"
 	"github.com/etix/mirrorbits/core"
 	"github.com/etix/mirrorbits/utils"
 	"github.com/gomodule/redigo/redis"
@@ -27,6 +28,8 @@ var (
 // RsyncScanner is the implementation of an rsync scanner
 type RsyncScanner struct {
 	scan *scan
+
+	bwLimit int // KiB/s, 0 for unlimited
 }
 
 // Scan starts an rsync scan of the given mirror
@@ -58,7 +61,15 @@ func (r *RsyncScanner) Scan(rsyncURL, identifier string, conn redis.Conn, stop <
 	// Don't use the local timezone, use UTC
 	env = append(env, "TZ=UTC")
 
-	cmd := exec.Command("rsync", "-r", "--no-motd", "--timeout=30", "--contimeout=30", "--exclude=.~tmp~/", u.String())
+	args := []string{"-r", "--no-motd", "--timeout=30", "--contimeout=30", "--exclude=.~tmp~/"}
+	if r.bwLimit > 0 {
+		args = append(args, fmt.Sprintf("--bwlimit=%d", r.bwLimit))
+	}
+	if GetConfig().FollowSymlinks {
+		// List the links pointing outside of the tree as their target
+		args = append(args, "--copy-unsafe-links")
+	}
+	cmd := exec.Command("rsync", append(args, u.String())...)
 
 	// Setup the environnement
 	cmd.Env = env
@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	. "github.com/etix/mirrorbits/config"
@@ -29,10 +31,38 @@ var (
 	ErrScanInProgress = errors.New("scan already in progress")
 	// ErrNoSyncMethod is returned when no sync protocol is available
 	ErrNoSyncMethod = errors.New("no suitable URL for the scan")
+	// ErrRemovalHeld is returned when a scan would remove too many files from a mirror
+	ErrRemovalHeld = errors.New("too many files would be removed, changes held (use a forced scan to apply them)")
+	// ErrMirrorRemoved is returned when the mirror is removed during the scan
+	ErrMirrorRemoved = errors.New("mirror removed during the scan")
 
 	log = logging.MustGetLogger("main")
 )
 
+// sourceRefreshKey is the hash holding the time of the last successful scan
+// of the local repository and the number of files it indexed, shared by all
+// the instances
+const sourceRefreshKey = "SOURCE_REFRESH"
+
+// LastSourceRefresh returns the time of the last successful scan of the
+// local repository and the number of files it indexed. The time is zero if
+// the repository has never been scanned.
+func LastSourceRefresh(r *database.Redis) (time.Time, int, error) {
+	conn := r.Get()
+	defer conn.Close()
+
+	values, err := redis.Values(conn.Do("HMGET", sourceRefreshKey, "lastRefresh", "files"))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var last int64
+	var files int
+	if _, err = redis.Scan(values, &last, &files); err != nil || last == 0 {
+		return time.Time{}, 0, err
+	}
+	return time.Unix(last, 0), files, nil
+}
+
 // Scanner is the interface that all scanners must implement
 type Scanner interface {
 	Scan(url, identifier string, conn redis.Conn, stop <-chan struct{}) (core.Precision, error)
@@ -43,6 +73,8 @@ type filedata struct {
 	sha1    string
 	sha256  string
 	md5     string
+	sha512  string
+	blake2b string
 	size    int64
 	modTime time.Time
 }
@@ -55,6 +87,8 @@ type scan struct {
 	mirrorid    int
 	filesTmpKey string
 	count       int64
+	prefix      string   // subtree being scanned
+	pending     []string // files of the current directory not yet marked as carried by the mirror
 }
 
 type ScanResult struct {
@@ -64,6 +98,11 @@ type ScanResult struct {
 	KnownIndexed int64
 	Removed      int64
 	TZOffsetMs   int64
+
+	// CaseCollisions contains the files excluded from the mirror
+	// because their path collides with another one when compared
+	// case-insensitively.
+	CaseCollisions []string
 }
 
 // IsScanning returns true is a scan is already in progress for the given mirror
@@ -71,8 +110,20 @@ func IsScanning(conn redis.Conn, id int) (bool, error) {
 	return redis.Bool(conn.Do("EXISTS", fmt.Sprintf("SCANNING_%d", id)))
 }
 
-// Scan starts a scan of the given mirror
-func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string, id int, stop <-chan struct{}) (*ScanResult, error) {
+// Scan starts a scan of the given mirror. Unless force is set, the removal of
+// the files no longer found on the mirror is held if it exceeds the configured
+// ScanRemovalThreshold.
+func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string, id int, force bool, stop <-chan struct{}) (*ScanResult, error) {
+	return scanMirror(typ, r, c, url, id, force, nil, stop)
+}
+
+// ScanSubtrees starts a scan of the given subdirectories of the mirror, the
+// files found outside of them during the previous scans are kept as is
+func ScanSubtrees(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string, id int, subtrees []string, stop <-chan struct{}) (*ScanResult, error) {
+	return scanMirror(typ, r, c, url, id, false, subtrees, stop)
+}
+
+func scanMirror(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string, id int, force bool, subtrees []string, stop <-chan struct{}) (*ScanResult, error) {
 	// Connect to the database
 	conn := r.Get()
 	defer conn.Close()
@@ -84,26 +135,34 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 		cache:    c,
 	}
 
+	// Get the mirror name
+	name, err := redis.String(conn.Do("HGET", "MIRRORS", id))
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the limits of the scans of this mirror
+	limits, err := redis.Ints(conn.Do("HMGET", fmt.Sprintf("MIRROR_%d", id), "rsyncBwLimit", "ftpConnections"))
+	if err != nil {
+		return nil, err
+	}
+
 	var scanner Scanner
 	switch typ {
 	case core.RSYNC:
 		scanner = &RsyncScanner{
-			scan: s,
+			scan:    s,
+			bwLimit: limits[0],
 		}
 	case core.FTP:
 		scanner = &FTPScanner{
-			scan: s,
+			scan:        s,
+			connections: limits[1],
 		}
 	default:
 		panic(fmt.Sprintf("Unknown scanner"))
 	}
 
-	// Get the mirror name
-	name, err := redis.String(conn.Do("HGET", "MIRRORS", id))
-	if err != nil {
-		return nil, err
-	}
-
 	// Try to acquire a lock so we don't have a scanning race
 	// from different nodes.
 	// Also make the key expire automatically in case our process
@@ -128,16 +187,61 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 		}
 	}(&err)
 
-	conn.Send("MULTI")
-
 	filesKey := fmt.Sprintf("MIRRORFILES_%d", id)
 	s.filesTmpKey = fmt.Sprintf("MIRRORFILESTMP_%d", id)
 
+	// Keep the files found outside of the subtrees
+	var kept []string
+	if len(subtrees) > 0 {
+		var files []string
+		files, err = redis.Strings(conn.Do("SMEMBERS", filesKey))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !inSubtrees(f, subtrees) {
+				kept = append(kept, f)
+			}
+		}
+		log.Infof("[%s] Scanning the changed subtrees: %s", name, strings.Join(subtrees, ", "))
+	}
+
+	// Abort the transaction if the mirror is removed meanwhile, a removed
+	// mirror must not be marked as carrying the files found by the scan
+	if _, err = conn.Do("WATCH", "REMOVEDMIRRORS"); err != nil {
+		return nil, err
+	}
+	var removed bool
+	removed, err = redis.Bool(conn.Do("HEXISTS", "REMOVEDMIRRORS", id))
+	if err == nil && removed {
+		err = ErrMirrorRemoved
+	}
+	if err != nil {
+		conn.Do("UNWATCH")
+		return nil, err
+	}
+
+	conn.Send("MULTI")
+
 	// Remove any left over
 	conn.Send("DEL", s.filesTmpKey)
 
+	for _, f := range kept {
+		conn.Send("SADD", s.filesTmpKey, f)
+	}
+
 	var precision core.Precision
-	precision, err = scanner.Scan(url, name, conn, stop)
+	if len(subtrees) == 0 {
+		precision, err = scanner.Scan(url, name, conn, stop)
+	} else {
+		for _, subtree := range subtrees {
+			s.prefix = subtree
+			precision, err = scanner.Scan(strings.TrimRight(url, "/")+subtree+"/", name, conn, stop)
+			if err != nil {
+				break
+			}
+		}
+	}
 	if err != nil {
 		// Discard MULTI
 		s.ScannerDiscard()
@@ -150,7 +254,12 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 	}
 
 	// Exec multi
-	s.ScannerCommit()
+	if err = s.ScannerCommit(); err != nil {
+		conn.Do("DEL", s.filesTmpKey)
+
+		log.Errorf("[%s] %s", name, err.Error())
+		return nil, err
+	}
 
 	// Get the list of files no more present on this mirror
 	var toremove []interface{}
@@ -159,12 +268,29 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 		return nil, err
 	}
 
+	// A mirror suddenly losing a large part of its files is most likely
+	// broken, hold the changes until an operator confirms them.
+	if threshold := GetConfig().ScanRemovalThreshold; !force && threshold > 0 && len(toremove) > 0 {
+		var total int64
+		total, err = redis.Int64(conn.Do("SCARD", filesKey))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(toremove))*100 > total*int64(threshold) {
+			conn.Do("DEL", s.filesTmpKey)
+			log.Errorf("[%s] Scan would remove %d of %d files (threshold %d%%), changes held", name, len(toremove), total, threshold)
+			err = ErrRemovalHeld
+			return nil, err
+		}
+	}
+
 	// Remove this mirror from the given file SET
 	if len(toremove) > 0 {
+		files, _ := redis.Strings(toremove, nil)
 		conn.Send("MULTI")
-		for _, e := range toremove {
+		database.SendRemoveFileMirror(conn, id, files...)
+		for _, e := range files {
 			log.Debugf("[%s] Removing %s from mirror", name, e)
-			conn.Send("SREM", fmt.Sprintf("FILEMIRRORS_%s", e), id)
 			conn.Send("DEL", fmt.Sprintf("FILEINFO_%d_%s", id, e))
 			// Publish update
 			database.SendPublish(conn, database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", id, e))
@@ -178,13 +304,19 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 
 	// Finally rename the temporary sets containing the list
 	// of files for this mirror to the production key
-	if s.count > 0 {
+	if s.count+int64(len(kept)) > 0 {
 		_, err = conn.Do("RENAME", s.filesTmpKey, filesKey)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	var collisions []string
+	collisions, err = s.excludeCaseCollisions(name, filesKey)
+	if err != nil {
+		return nil, err
+	}
+
 	sinterKey := fmt.Sprintf("HANDLEDFILES_%d", id)
 
 	// Count the number of files known on the remote end
@@ -203,13 +335,17 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 	}
 
 	log.Infof("[%s] Indexed %d files (%d known), %d removed", name, s.count, common, len(toremove))
+	if len(collisions) > 0 {
+		log.Warningf("[%s] %d files excluded due to case collisions", name, len(collisions))
+	}
 	res := &ScanResult{
-		MirrorID:     id,
-		MirrorName:   name,
-		FilesIndexed: s.count,
-		KnownIndexed: common,
-		Removed:      int64(len(toremove)),
-		TZOffsetMs:   tzoffset,
+		MirrorID:       id,
+		MirrorName:     name,
+		FilesIndexed:   s.count,
+		KnownIndexed:   common,
+		Removed:        int64(len(toremove)),
+		TZOffsetMs:     tzoffset,
+		CaseCollisions: collisions,
 	}
 
 	mirrors.PushLog(r, mirrors.NewLogScanCompleted(
@@ -217,20 +353,34 @@ func Scan(typ core.ScannerType, r *database.Redis, c *mirrors.Cache, url string,
 		res.FilesIndexed,
 		res.KnownIndexed,
 		res.Removed,
-		res.TZOffsetMs))
+		res.TZOffsetMs,
+		int64(len(res.CaseCollisions))))
 
 	return res, nil
 }
 
 func (s *scan) ScannerAddFile(f filedata) {
+	f.path = s.prefix + f.path
+
+	if GetConfig().ExceedsPathLimits(f.path) {
+		log.Warningf("[%d] Skipping %s: path too long or too deep", s.mirrorid, f.path)
+		return
+	}
+	if GetConfig().IsPathExcluded(f.path) {
+		return
+	}
+
 	s.count++
 
 	// Add all the files to a temporary key
 	s.conn.Send("SADD", s.filesTmpKey, f.path)
 
-	// Mark the file as being supported by this mirror
-	rk := fmt.Sprintf("FILEMIRRORS_%s", f.path)
-	s.conn.Send("SADD", rk, s.mirrorid)
+	// Mark the file as being supported by this mirror, the files being
+	// batched per directory
+	if len(s.pending) > 0 && path.Dir(s.pending[0]) != path.Dir(f.path) {
+		s.flushPending()
+	}
+	s.pending = append(s.pending, f.path)
 
 	// Save the size of the current file found on this mirror
 	ik := fmt.Sprintf("FILEINFO_%d_%s", s.mirrorid, f.path)
@@ -240,12 +390,144 @@ func (s *scan) ScannerAddFile(f filedata) {
 	database.SendPublish(s.conn, database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", s.mirrorid, f.path))
 }
 
+// inSubtrees returns true if the file is located in one of the subtrees
+func inSubtrees(file string, subtrees []string) bool {
+	for _, subtree := range subtrees {
+		if strings.HasPrefix(file, subtree+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SetUnchanged records a successful sync of a mirror known to be unchanged
+// since its last scan, without scanning it again
+func SetUnchanged(r *database.Redis, id int) error {
+	conn := r.Get()
+	defer conn.Close()
+
+	now := time.Now().UTC().Unix()
+	_, err := conn.Do("HMSET", fmt.Sprintf("MIRROR_%d", id), "lastSync", now, "lastSuccessfulSync", now)
+	if err != nil {
+		return err
+	}
+
+	// Publish an update on redis
+	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(id))
+	return nil
+}
+
+// caseCollisionsKey is the set of the files of the local repository whose
+// path collides with another one when compared case-insensitively. It is
+// built by the scan of the local repository and read by the scans of the
+// mirrors.
+const caseCollisionsKey = "CASECOLLISIONS"
+
+// caseCollisions returns the groups of paths only differing by case
+func caseCollisions(paths []string) [][]string {
+	groups := make(map[string][]string)
+	for _, p := range paths {
+		k := strings.ToLower(p)
+		groups[k] = append(groups[k], p)
+	}
+
+	var collisions [][]string
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Strings(group)
+		collisions = append(collisions, group)
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i][0] < collisions[j][0]
+	})
+	return collisions
+}
+
+// excludeCaseCollisions looks for files of the local repository whose paths
+// only differ by case and of which the mirror only carries a subset. Such a
+// mirror is most likely hosted on a case-insensitive filesystem where those
+// files were merged, meaning the content served can't be trusted. The
+// affected files are excluded from the mirror and returned.
+func (s *scan) excludeCaseCollisions(name, filesKey string) ([]string, error) {
+	members, err := redis.Strings(s.conn.Do("SMEMBERS", caseCollisionsKey))
+	if err != nil {
+		return nil, err
+	}
+	groups := caseCollisions(members)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	// Check the colliding paths on the mirror in a single pipeline
+	for _, paths := range groups {
+		for _, p := range paths {
+			s.conn.Send("SISMEMBER", filesKey, p)
+		}
+	}
+	if err = s.conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	var collisions []string
+	for _, paths := range groups {
+		var found []string
+		for _, p := range paths {
+			exists, err := redis.Bool(s.conn.Receive())
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				found = append(found, p)
+			}
+		}
+		if len(found) == 0 || len(found) == len(paths) {
+			continue
+		}
+		collisions = append(collisions, found...)
+	}
+
+	if len(collisions) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(collisions)
+
+	s.conn.Send("MULTI")
+	database.SendRemoveFileMirror(s.conn, s.mirrorid, collisions...)
+	for _, p := range collisions {
+		log.Warningf("[%s] Excluding %s: path collides with another file differing only by case", name, p)
+		s.conn.Send("SREM", filesKey, p)
+		s.conn.Send("DEL", fmt.Sprintf("FILEINFO_%d_%s", s.mirrorid, p))
+		// Publish update
+		database.SendPublish(s.conn, database.MIRROR_FILE_UPDATE, fmt.Sprintf("%d %s", s.mirrorid, p))
+	}
+	_, err = s.conn.Do("EXEC")
+	if err != nil {
+		return nil, err
+	}
+	return collisions, nil
+}
+
+// flushPending marks the pending files as carried by the mirror
+func (s *scan) flushPending() {
+	database.SendAddFileMirror(s.conn, s.mirrorid, s.pending...)
+	s.pending = s.pending[:0]
+}
+
 func (s *scan) ScannerDiscard() {
+	s.pending = nil
 	s.conn.Do("DISCARD")
 }
 
 func (s *scan) ScannerCommit() error {
-	_, err := s.conn.Do("EXEC")
+	s.flushPending()
+	reply, err := s.conn.Do("EXEC")
+	if err == nil && reply == nil {
+		// Only the removed mirrors are watched
+		err = ErrMirrorRemoved
+	}
 	return err
 }
 
@@ -265,8 +547,9 @@ func (s *scan) setLastSync(conn redis.Conn, id int, protocol core.ScannerType, p
 
 		conn.Send("HMSET", fmt.Sprintf("MIRROR_%d", id),
 			"lastSuccessfulSync", now,
-			"lastSuccessfulSyncProtocol", protocol,
-			"lastSuccessfulSyncPrecision", precision)
+			"lastSuccessfulSyncProtocol", int(protocol),
+			"lastSuccessfulSyncPrecision", int64(precision))
+		conn.Send("HDEL", fmt.Sprintf("MIRROR_%d", id), "pendingRescan")
 	}
 
 	_, err := conn.Do("EXEC")
@@ -331,6 +614,12 @@ func (s *scan) adjustTZOffset(name string, precision core.Precision) (ms int64,
 			continue
 		}
 
+		if p.local.Size == 0 {
+			// Empty files are likely to be created on the fly
+			// (e.g. lock or timestamp files), don't trust them
+			continue
+		}
+
 		// Add the file to valid pairs
 		pairs = append(pairs, p)
 	}
@@ -396,16 +685,25 @@ func (s *sourcescanner) walkSource(conn redis.Conn, path string, f os.FileInfo,
 
 	d := new(filedata)
 	d.path = path[len(GetConfig().Repository):]
+
+	if GetConfig().ExceedsPathLimits(d.path) {
+		log.Warningf("[source] Skipping %s: path too long or too deep", d.path)
+		return nil, nil
+	}
+	if GetConfig().IsPathExcluded(d.path) {
+		log.Debugf("[source] Skipping %s: excluded", d.path)
+		return nil, nil
+	}
 	d.size = f.Size()
 	d.modTime = f.ModTime()
 
 	// Get the previous file properties
-	properties, err := redis.Strings(conn.Do("HMGET", fmt.Sprintf("FILE_%s", d.path), "size", "modTime", "sha1", "sha256", "md5"))
+	properties, err := redis.Strings(conn.Do("HMGET", fmt.Sprintf("FILE_%s", d.path), "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b", "packed"))
 	if err != nil && err != redis.ErrNil {
 		return nil, err
-	} else if len(properties) < 5 {
+	} else if len(properties) < 8 {
 		// This will force a rehash
-		properties = make([]string, 5)
+		properties = make([]string, 8)
 	}
 
 	size, _ := strconv.ParseInt(properties[0], 10, 64)
@@ -413,11 +711,24 @@ func (s *sourcescanner) walkSource(conn redis.Conn, path string, f os.FileInfo,
 	sha1 := properties[2]
 	sha256 := properties[3]
 	md5 := properties[4]
+	sha512 := properties[5]
+	blake2b := properties[6]
+
+	if len(properties[7]) > 0 {
+		var p filesystem.FileInfo
+		// An undecodable value forces a rehash
+		if filesystem.UnpackFileInfo([]byte(properties[7]), &p) == nil {
+			size, modTime = p.Size, p.ModTime
+			sha1, sha256, md5, sha512, blake2b = p.Sha1, p.Sha256, p.Md5, p.Sha512, p.Blake2b
+		}
+	}
 
 	rehash = rehash ||
 		(GetConfig().Hashes.SHA1 && len(sha1) == 0) ||
 		(GetConfig().Hashes.SHA256 && len(sha256) == 0) ||
-		(GetConfig().Hashes.MD5 && len(md5) == 0)
+		(GetConfig().Hashes.MD5 && len(md5) == 0) ||
+		(GetConfig().Hashes.SHA512 && len(sha512) == 0) ||
+		(GetConfig().Hashes.BLAKE2 && len(blake2b) == 0)
 
 	if rehash || size != d.size || !modTime.Equal(d.modTime) {
 		h, err := filesystem.HashFile(GetConfig().Repository + d.path)
@@ -427,6 +738,8 @@ func (s *sourcescanner) walkSource(conn redis.Conn, path string, f os.FileInfo,
 			d.sha1 = h.Sha1
 			d.sha256 = h.Sha256
 			d.md5 = h.Md5
+			d.sha512 = h.Sha512
+			d.blake2b = h.Blake2b
 			if len(d.sha1) > 0 {
 				log.Infof("%s: SHA1 %s", d.path, d.sha1)
 			}
@@ -436,16 +749,46 @@ func (s *sourcescanner) walkSource(conn redis.Conn, path string, f os.FileInfo,
 			if len(d.md5) > 0 {
 				log.Infof("%s: MD5 %s", d.path, d.md5)
 			}
+			if len(d.sha512) > 0 {
+				log.Infof("%s: SHA512 %s", d.path, d.sha512)
+			}
+			if len(d.blake2b) > 0 {
+				log.Infof("%s: BLAKE2b %s", d.path, d.blake2b)
+			}
 		}
 	} else {
 		d.sha1 = sha1
 		d.sha256 = sha256
 		d.md5 = md5
+		d.sha512 = sha512
+		d.blake2b = blake2b
 	}
 
 	return d, nil
 }
 
+// indexDirectories returns the entries of each directory containing the
+// given files, directly or not. The files are listed by name and the
+// sub-directories by name followed by a slash.
+func indexDirectories(paths []string) map[string][]string {
+	dirs := make(map[string][]string)
+	for _, p := range paths {
+		entry := path.Base(p)
+		for dir := path.Dir(p); ; dir = path.Dir(dir) {
+			_, known := dirs[dir]
+			dirs[dir] = append(dirs[dir], entry)
+			if known || dir == "/" {
+				break
+			}
+			entry = path.Base(dir) + "/"
+		}
+	}
+	for _, entries := range dirs {
+		sort.Strings(entries)
+	}
+	return dirs
+}
+
 // ScanSource starts a scan of the local repository
 func ScanSource(r *database.Redis, forceRehash bool, stop <-chan struct{}) (err error) {
 	s := &sourcescanner{}
@@ -466,7 +809,7 @@ func ScanSource(r *database.Redis, forceRehash bool, stop <-chan struct{}) (err
 	}
 
 	log.Info("[source] Scanning the filesystem...")
-	err = filepath.Walk(GetConfig().Repository, func(path string, f os.FileInfo, err error) error {
+	err = walkRepository(GetConfig().Repository, GetConfig().FollowSymlinks, func(path string, f os.FileInfo, err error) error {
 		fd, err := s.walkSource(conn, path, f, forceRehash, err)
 		if err != nil {
 			return err
@@ -524,15 +867,61 @@ func ScanSource(r *database.Redis, forceRehash bool, stop <-chan struct{}) (err
 	// Do a diff between the sets to get the removed files
 	toremove, err := redis.Values(conn.Do("SDIFF", "FILES", "FILES_TMP"))
 
+	// Index the directories containing the files
+	paths := make([]string, 0, len(sourceFiles))
+	for _, e := range sourceFiles {
+		paths = append(paths, e.path)
+	}
+	dirs := indexDirectories(paths)
+	var collisions []string
+	for _, group := range caseCollisions(paths) {
+		collisions = append(collisions, group...)
+	}
+	previousDirs, err := redis.Strings(conn.Do("SMEMBERS", "DIRS"))
+	if err != nil {
+		return err
+	}
+
 	// Create/Update the files' hash keys with the fresh infos
+	now := time.Now().Unix()
+	pack := GetConfig().PackFileInfo
 	conn.Send("MULTI")
 	for _, e := range sourceFiles {
-		conn.Send("HMSET", fmt.Sprintf("FILE_%s", e.path),
-			"size", e.size,
-			"modTime", e.modTime,
-			"sha1", e.sha1,
-			"sha256", e.sha256,
-			"md5", e.md5)
+		key := fmt.Sprintf("FILE_%s", e.path)
+		var packed []byte
+		if pack {
+			packed, err = filesystem.PackFileInfo(filesystem.FileInfo{
+				Size:    e.size,
+				ModTime: e.modTime,
+				Sha1:    e.sha1,
+				Sha256:  e.sha256,
+				Md5:     e.md5,
+				Sha512:  e.sha512,
+				Blake2b: e.blake2b,
+			})
+			if err != nil {
+				log.Warningf("%s: unable to pack the file details: %s", e.path, err)
+			}
+		}
+		// Files are migrated from one encoding to the other on the fly
+		if len(packed) > 0 {
+			conn.Send("HMSET", key,
+				"packed", packed,
+				"lastSeen", now)
+			conn.Send("HDEL", key, "size", "modTime", "sha1", "sha256", "md5", "sha512", "blake2b")
+		} else {
+			conn.Send("HMSET", key,
+				"size", e.size,
+				"modTime", e.modTime,
+				"sha1", e.sha1,
+				"sha256", e.sha256,
+				"md5", e.md5,
+				"sha512", e.sha512,
+				"blake2b", e.blake2b,
+				"lastSeen", now)
+			conn.Send("HDEL", key, "packed")
+		}
+		conn.Send("HSETNX", key, "firstSeen", now)
 
 		// Publish update
 		database.SendPublish(conn, database.FILE_UPDATE, e.path)
@@ -548,9 +937,37 @@ func ScanSource(r *database.Redis, forceRehash bool, stop <-chan struct{}) (err
 		}
 	}
 
+	// Replace the content of the directories
+	conn.Send("DEL", "DIRS_TMP")
+	for dir, entries := range dirs {
+		conn.Send("DEL", "DIR_"+dir)
+		conn.Send("SADD", redis.Args{}.Add("DIR_"+dir).AddFlat(entries)...)
+		conn.Send("SADD", "DIRS_TMP", dir)
+	}
+	for _, dir := range previousDirs {
+		if _, ok := dirs[dir]; !ok {
+			conn.Send("DEL", "DIR_"+dir)
+		}
+	}
+
+	// Index the files colliding by case once for all the scans of the mirrors
+	conn.Send("DEL", caseCollisionsKey)
+	if len(collisions) > 0 {
+		conn.Send("SADD", redis.Args{}.Add(caseCollisionsKey).AddFlat(collisions)...)
+	}
+
 	// Finally rename the temporary sets containing the list
 	// of files to the production key
 	conn.Send("RENAME", "FILES_TMP", "FILES")
+	if len(dirs) > 0 {
+		conn.Send("RENAME", "DIRS_TMP", "DIRS")
+	} else {
+		conn.Send("DEL", "DIRS")
+	}
+
+	conn.Send("HMSET", sourceRefreshKey,
+		"lastRefresh", now,
+		"files", count)
 
 	_, err = conn.Do("EXEC")
 	if err != nil {
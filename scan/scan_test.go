@@ -0,0 +1,122 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package scan
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/etix/mirrorbits/testing"
+	"github.com/rafaeljusto/redigomock"
+)
+
+func TestIndexDirectories(t *testing.T) {
+	dirs := indexDirectories([]string{"/file", "/a/b/file1", "/a/b/file2", "/a/c/file", "/a/file"})
+
+	expected := map[string][]string{
+		"/":    {"a/", "file"},
+		"/a":   {"b/", "c/", "file"},
+		"/a/b": {"file1", "file2"},
+		"/a/c": {"file"},
+	}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Fatalf("Expected %v, got %v", expected, dirs)
+	}
+}
+
+func TestCaseCollisions(t *testing.T) {
+	groups := caseCollisions([]string{"/b/X", "/a/file", "/a/README", "/a/File", "/b/x", "/a/FILE", "/c"})
+
+	expected := [][]string{
+		{"/a/FILE", "/a/File", "/a/file"},
+		{"/b/X", "/b/x"},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("Expected %v, got %v", expected, groups)
+	}
+	if groups = caseCollisions([]string{"/a", "/b"}); len(groups) != 0 {
+		t.Fatalf("Expected no collision, got %v", groups)
+	}
+}
+
+func TestExcludeCaseCollisions(t *testing.T) {
+	mock := redigomock.NewConn()
+	s := &scan{conn: mock, mirrorid: 1}
+
+	// The index built by the scan of the local repository is reused
+	mock.Command("SMEMBERS", caseCollisionsKey).Expect([]interface{}{
+		[]byte("/a/file"), []byte("/a/File"), []byte("/b/x"), []byte("/b/X"),
+	})
+	// The mirror carries both /b files but a single /a file
+	mock.Command("SISMEMBER", "MIRRORFILES_1", "/a/File").Expect(int64(0))
+	mock.Command("SISMEMBER", "MIRRORFILES_1", "/a/file").Expect(int64(1))
+	mock.Command("SISMEMBER", "MIRRORFILES_1", "/b/X").Expect(int64(1))
+	mock.Command("SISMEMBER", "MIRRORFILES_1", "/b/x").Expect(int64(1))
+
+	mock.Command("MULTI").Expect("OK")
+	mock.GenericCommand("EVAL").Expect("QUEUED")
+	cmdRemove := mock.Command("SREM", "MIRRORFILES_1", "/a/file").Expect("QUEUED")
+	mock.Command("DEL", "FILEINFO_1_/a/file").Expect("QUEUED")
+	mock.Command("PUBLISH", "_mirrorbits_mirror_file_update", "1 /a/file").Expect("QUEUED")
+	mock.Command("EXEC").Expect([]interface{}{int64(0), int64(1), int64(1), int64(0)})
+
+	collisions, err := s.excludeCaseCollisions("m1", "MIRRORFILES_1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(collisions, []string{"/a/file"}) {
+		t.Fatalf("Expected /a/file to be excluded, got %v", collisions)
+	}
+	if mock.Stats(cmdRemove) != 1 {
+		t.Fatalf("Expected the file to be removed from the mirror")
+	}
+
+	// Nothing to check without collisions in the repository
+	mock = redigomock.NewConn()
+	s.conn = mock
+	mock.Command("SMEMBERS", caseCollisionsKey).Expect([]interface{}{})
+	if collisions, err = s.excludeCaseCollisions("m1", "MIRRORFILES_1"); err != nil || collisions != nil {
+		t.Fatalf("Expected no collision, got %v %v", collisions, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScannerCommit(t *testing.T) {
+	mock := redigomock.NewConn()
+	s := &scan{conn: mock, mirrorid: 1, pending: []string{"/a/file"}}
+
+	cmdFileMirrors := mock.Command("EVAL", redigomock.NewAnyData(), 1, "DIRMIRRORS_/a", 1, "file").Expect("QUEUED")
+	mock.Command("EXEC").Expect([]interface{}{int64(0)})
+	if err := s.ScannerCommit(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if mock.Stats(cmdFileMirrors) != 1 || len(s.pending) != 0 {
+		t.Fatalf("Expected the pending files to be committed")
+	}
+
+	// The transaction is aborted when the mirror is removed meanwhile
+	mock.Clear()
+	mock.Command("EXEC").Expect(nil)
+	if err := s.ScannerCommit(); err != ErrMirrorRemoved {
+		t.Fatalf("Expected ErrMirrorRemoved, got %v", err)
+	}
+}
+
+func TestLastSourceRefresh(t *testing.T) {
+	mock, conn := PrepareRedisTest()
+
+	mock.Command("HMGET", sourceRefreshKey, "lastRefresh", "files").
+		Expect([]interface{}{nil, nil}).
+		Expect([]interface{}{[]byte("1546300800"), []byte("42")})
+
+	if last, files, err := LastSourceRefresh(conn); err != nil || !last.IsZero() || files != 0 {
+		t.Fatalf("Expected no refresh, got %s, %d, %v", last, files, err)
+	}
+	if last, files, err := LastSourceRefresh(conn); err != nil || !last.Equal(time.Unix(1546300800, 0)) || files != 42 {
+		t.Fatalf("Unexpected refresh %s, %d, %v", last, files, err)
+	}
+}
@@ -8,9 +8,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	. "github.com/etix/mirrorbits/config"
@@ -18,6 +22,7 @@ import (
 	"github.com/etix/mirrorbits/database"
 	"github.com/etix/mirrorbits/mirrors"
 	"github.com/etix/mirrorbits/utils"
+	"github.com/gomodule/redigo/redis"
 )
 
 var (
@@ -29,6 +34,101 @@ var (
 	ErrNoTrace = errors.New("No trace file")
 )
 
+// TraceState is the content of the trace file of a mirror: the time of the
+// last update of the repository, optionally followed by the time of the last
+// update of some of its subdirectories, one per line:
+//
+//	1563451200
+//	1563451200 /debian
+//	1563400000 /ubuntu
+type TraceState struct {
+	Timestamp int64
+	Subtrees  map[string]int64
+}
+
+// parseTrace decodes a trace file
+func parseTrace(r io.Reader) (*TraceState, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	scanner.Scan()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	timestamp, err := strconv.ParseInt(scanner.Text(), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &TraceState{
+		Timestamp: timestamp,
+		Subtrees:  make(map[string]int64),
+	}
+
+	// The remaining words are the timestamp / subdirectory pairs, the
+	// malformed ones are ignored
+	for scanner.Scan() {
+		ts, err := strconv.ParseInt(scanner.Text(), 10, 64)
+		if err != nil || !scanner.Scan() {
+			continue
+		}
+		subtree := path.Clean("/" + scanner.Text())
+		if subtree == "/" {
+			continue
+		}
+		state.Subtrees[subtree] = ts
+	}
+	return state, scanner.Err()
+}
+
+// String encodes the state in the format of a trace file
+func (t *TraceState) String() string {
+	subtrees := make([]string, 0, len(t.Subtrees))
+	for subtree := range t.Subtrees {
+		subtrees = append(subtrees, subtree)
+	}
+	sort.Strings(subtrees)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d\n", t.Timestamp)
+	for _, subtree := range subtrees {
+		fmt.Fprintf(&b, "%d %s\n", t.Subtrees[subtree], subtree)
+	}
+	return b.String()
+}
+
+// ChangedSubtrees compares the state with the one of the last successful scan
+// of the mirror. It returns whether the mirror is unchanged and, if the
+// repository publishes the timestamps of its subdirectories, the ones which
+// must be rescanned. A nil list means the whole mirror must be rescanned.
+func (t *TraceState) ChangedSubtrees(previous *TraceState) (subtrees []string, unchanged bool) {
+	if previous == nil {
+		return nil, false
+	}
+	if t.Timestamp == previous.Timestamp {
+		return nil, true
+	}
+	if len(t.Subtrees) == 0 {
+		return nil, false
+	}
+	// The files of a subdirectory no longer published must be removed
+	for subtree := range previous.Subtrees {
+		if _, ok := t.Subtrees[subtree]; !ok {
+			return nil, false
+		}
+	}
+	for subtree, ts := range t.Subtrees {
+		if prev, ok := previous.Subtrees[subtree]; !ok || prev != ts {
+			subtrees = append(subtrees, subtree)
+		}
+	}
+	if len(subtrees) == 0 {
+		return nil, true
+	}
+	sort.Strings(subtrees)
+	return subtrees, false
+}
+
 // Trace is the internal trace handler
 type Trace struct {
 	redis      *database.Redis
@@ -69,11 +169,11 @@ func NewTraceHandler(redis *database.Redis, stop <-chan struct{}) *Trace {
 
 // GetLastUpdate connects in HTTP to the mirror to get the latest
 // trace file and computes the offset of the mirror.
-func (t *Trace) GetLastUpdate(mirror mirrors.Mirror) error {
+func (t *Trace) GetLastUpdate(mirror mirrors.Mirror) (*TraceState, error) {
 	traceFile := GetConfig().TraceFileLocation
 
 	if len(traceFile) == 0 {
-		return ErrNoTrace
+		return nil, ErrNoTrace
 	}
 
 	log.Debugf("Getting latest trace file for %s...", mirror.Name)
@@ -81,6 +181,7 @@ func (t *Trace) GetLastUpdate(mirror mirrors.Mirror) error {
 	// Prepare the HTTP request
 	req, err := http.NewRequest("GET", utils.ConcatURL(mirror.HttpURL, traceFile), nil)
 	req.Header.Set("User-Agent", userAgent)
+	req.Host = mirror.HostHeader
 	req.Close = true
 
 	// Prepare contexts
@@ -99,33 +200,50 @@ func (t *Trace) GetLastUpdate(mirror mirrors.Mirror) error {
 
 	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	scanner := bufio.NewScanner(bufio.NewReader(resp.Body))
-	scanner.Split(bufio.ScanWords)
-	scanner.Scan()
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	timestamp, err := strconv.ParseInt(scanner.Text(), 10, 64)
+	state, err := parseTrace(bufio.NewReader(resp.Body))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	conn := t.redis.Get()
 	defer conn.Close()
 
-	_, err = conn.Do("HSET", fmt.Sprintf("MIRROR_%d", mirror.ID), "lastModTime", timestamp)
+	_, err = conn.Do("HSET", fmt.Sprintf("MIRROR_%d", mirror.ID), "lastModTime", state.Timestamp)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Publish an update on redis
 	database.Publish(conn, database.MIRROR_UPDATE, strconv.Itoa(mirror.ID))
 
-	log.Debugf("[%s] trace last sync: %s", mirror.Name, time.Unix(timestamp, 0))
-	return nil
+	log.Debugf("[%s] trace last sync: %s", mirror.Name, time.Unix(state.Timestamp, 0))
+	return state, nil
+}
+
+// SyncedTrace returns the trace of the mirror at its last successful
+// scan, if known
+func (t *Trace) SyncedTrace(id int) (*TraceState, error) {
+	conn := t.redis.Get()
+	defer conn.Close()
+
+	trace, err := redis.String(conn.Do("HGET", fmt.Sprintf("MIRROR_%d", id), "syncedTrace"))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return parseTrace(strings.NewReader(trace))
+}
+
+// SetSyncedTrace records the trace of the mirror at its last successful scan
+func (t *Trace) SetSyncedTrace(id int, state *TraceState) error {
+	conn := t.redis.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HSET", fmt.Sprintf("MIRROR_%d", id), "syncedTrace", state.String())
+	return err
 }
@@ -0,0 +1,81 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package scan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTrace(t *testing.T) {
+	state, err := parseTrace(strings.NewReader("1563451200\n1563451200 /debian\n1563400000 ubuntu/\ninvalid\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if state.Timestamp != 1563451200 {
+		t.Fatalf("Expected timestamp 1563451200, got %d", state.Timestamp)
+	}
+	expected := map[string]int64{"/debian": 1563451200, "/ubuntu": 1563400000}
+	if !reflect.DeepEqual(state.Subtrees, expected) {
+		t.Fatalf("Expected %v, got %v", expected, state.Subtrees)
+	}
+
+	decoded, err := parseTrace(strings.NewReader(state.String()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, state) {
+		t.Fatalf("Expected %v, got %v", state, decoded)
+	}
+
+	if _, err := parseTrace(strings.NewReader("not a timestamp")); err == nil {
+		t.Fatalf("Error expected for an invalid timestamp")
+	}
+}
+
+func TestTraceState_ChangedSubtrees(t *testing.T) {
+	previous := &TraceState{
+		Timestamp: 100,
+		Subtrees:  map[string]int64{"/a": 50, "/b": 60},
+	}
+
+	tests := []struct {
+		name      string
+		state     *TraceState
+		previous  *TraceState
+		subtrees  []string
+		unchanged bool
+	}{
+		{"first scan", &TraceState{Timestamp: 100}, nil, nil, false},
+		{"same trace", &TraceState{Timestamp: 100}, previous, nil, true},
+		{"no subtrees", &TraceState{Timestamp: 200}, previous, nil, false},
+		{"changed subtree", &TraceState{Timestamp: 200, Subtrees: map[string]int64{"/a": 50, "/b": 70}}, previous, []string{"/b"}, false},
+		{"new subtree", &TraceState{Timestamp: 200, Subtrees: map[string]int64{"/a": 50, "/b": 60, "/c": 10}}, previous, []string{"/c"}, false},
+		{"removed subtree", &TraceState{Timestamp: 200, Subtrees: map[string]int64{"/a": 70}}, previous, nil, false},
+		{"unchanged subtrees", &TraceState{Timestamp: 200, Subtrees: map[string]int64{"/a": 50, "/b": 60}}, previous, nil, true},
+	}
+
+	for _, test := range tests {
+		subtrees, unchanged := test.state.ChangedSubtrees(test.previous)
+		if !reflect.DeepEqual(subtrees, test.subtrees) || unchanged != test.unchanged {
+			t.Fatalf("%s: expected %v (unchanged: %t), got %v (unchanged: %t)", test.name, test.subtrees, test.unchanged, subtrees, unchanged)
+		}
+	}
+}
+
+func TestInSubtrees(t *testing.T) {
+	subtrees := []string{"/a", "/b/c"}
+	for file, expected := range map[string]bool{
+		"/a/file":   true,
+		"/ab/file":  false,
+		"/b/c/file": true,
+		"/b/file":   false,
+		"/file":     false,
+	} {
+		if inSubtrees(file, subtrees) != expected {
+			t.Fatalf("%s: expected %t", file, expected)
+		}
+	}
+}
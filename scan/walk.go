@@ -0,0 +1,89 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package scan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/etix/mirrorbits/filesystem"
+)
+
+// walkRepository walks the file tree of the local repository like
+// filepath.Walk. When follow is set, the symbolic links pointing outside of
+// the repository are walked as the file or directory they point to, under
+// the path of the link. The links pointing inside the repository are aliases
+// resolved when serving the files and are skipped like with filepath.Walk.
+func walkRepository(root string, follow bool, fn filepath.WalkFunc) error {
+	if !follow {
+		return filepath.Walk(root, fn)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fn(root, info, err)
+	}
+	err = walkFollow(realRoot, root, info, nil, fn)
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walkFollow walks the given path, parents being the real paths of the
+// directories above it, used to detect the loops
+func walkFollow(realRoot, path string, info os.FileInfo, parents []string, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	for _, p := range parents {
+		if p == realPath {
+			log.Warningf("[source] Skipping %s: symbolic link loop", path)
+			return nil
+		}
+	}
+	parents = append(parents, realPath)
+
+	if err := fn(path, info, nil); err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+
+	for _, e := range entries {
+		p := filepath.Join(path, e.Name())
+		if e.Mode()&os.ModeSymlink != 0 {
+			target, err := filepath.EvalSymlinks(p)
+			if err != nil || filesystem.IsInRepository(realRoot, target) {
+				// Broken link or alias within the repository
+				continue
+			}
+			if e, err = os.Stat(p); err != nil {
+				continue
+			}
+		}
+		err = walkFollow(realRoot, p, e, parents, fn)
+		if err == filepath.SkipDir {
+			if !e.IsDir() {
+				return nil
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package scan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWalkRepository(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "mirrorbits-walk")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	repo := filepath.Join(tmp, "repo")
+	outside := filepath.Join(tmp, "outside")
+	for _, dir := range []string{filepath.Join(repo, "v2"), filepath.Join(outside, "dir")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Unable to create %s: %s", dir, err)
+		}
+	}
+	for _, file := range []string{filepath.Join(repo, "v2", "file"), filepath.Join(outside, "dir", "file"), filepath.Join(outside, "single")} {
+		if err := ioutil.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Unable to create %s: %s", file, err)
+		}
+	}
+	for link, target := range map[string]string{
+		filepath.Join(repo, "latest"):         "v2",
+		filepath.Join(repo, "external"):       filepath.Join(outside, "dir"),
+		filepath.Join(repo, "single"):         filepath.Join(outside, "single"),
+		filepath.Join(repo, "broken"):         filepath.Join(outside, "missing"),
+		filepath.Join(outside, "dir", "loop"): filepath.Join(outside, "dir"),
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatalf("Unable to create %s: %s", link, err)
+		}
+	}
+
+	walk := func(follow bool) []string {
+		var files []string
+		err := walkRepository(repo, follow, func(path string, f os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !f.IsDir() && f.Mode()&os.ModeSymlink == 0 {
+				files = append(files, path[len(repo):])
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	if files, expected := walk(false), []string{"/v2/file"}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+	if files, expected := walk(true), []string{"/external/file", "/single", "/v2/file"}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Expected %v when following the links, got %v", expected, files)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package testing
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/etix/mirrorbits/config"
+	"github.com/etix/mirrorbits/core"
+)
+
+// PrepareConfigTest loads the given configuration, validated and
+// normalized like a configuration file
+func PrepareConfigTest(content string) error {
+	f, err := ioutil.TempFile("", "mirrorbits-config")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(content)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	previous := core.ConfigFile
+	core.ConfigFile = f.Name()
+	defer func() { core.ConfigFile = previous }()
+
+	return config.ReloadConfig()
+}
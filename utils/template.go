@@ -0,0 +1,128 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package utils
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdItalic = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+// Markdown renders a small subset of markdown (paragraphs, line breaks,
+// **bold**, *italic*, `code` and [links](url)) as HTML. Any HTML contained
+// in the input is escaped and only http(s), mailto and relative links are
+// kept, making it safe to use with untrusted text.
+func Markdown(input string) template.HTML {
+	var out strings.Builder
+	input = strings.Replace(input, "\r\n", "\n", -1)
+	for _, paragraph := range strings.Split(input, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		out.WriteString("<p>")
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				out.WriteString("<br>")
+			}
+			out.WriteString(markdownInline(strings.TrimSpace(line)))
+		}
+		out.WriteString("</p>")
+	}
+	return template.HTML(out.String())
+}
+
+func markdownInline(line string) string {
+	var out strings.Builder
+	// Odd parts are code spans and are not processed any further
+	for i, part := range strings.Split(line, "`") {
+		part = html.EscapeString(part)
+		if i%2 == 1 {
+			out.WriteString("<code>" + part + "</code>")
+			continue
+		}
+		part = mdLink.ReplaceAllStringFunc(part, func(s string) string {
+			m := mdLink.FindStringSubmatch(s)
+			if !isSafeURL(m[2]) {
+				return m[1]
+			}
+			return `<a href="` + m[2] + `">` + m[1] + `</a>`
+		})
+		part = mdBold.ReplaceAllString(part, "<strong>$1</strong>")
+		part = mdItalic.ReplaceAllString(part, "<em>$1</em>")
+		out.WriteString(part)
+	}
+	return out.String()
+}
+
+// isSafeURL returns true if the url is relative or uses a scheme that
+// cannot execute code in the browser
+func isSafeURL(url string) bool {
+	i := strings.IndexAny(url, ":/?#")
+	if i < 0 || url[i] != ':' {
+		return true
+	}
+	switch strings.ToLower(url[:i]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// RelativeTime returns the time elapsed since t, or remaining until t,
+// in a human readable form such as "3 hours ago" or "in 2 days"
+func RelativeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	format := "%d %s%s ago"
+	if d < 0 {
+		d = -d
+		format = "in %d %s%s"
+	}
+
+	var value int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		value, unit = int(d.Minutes()), "minute"
+	case d < 24*time.Hour:
+		value, unit = int(d.Hours()), "hour"
+	case d < 365*24*time.Hour:
+		value, unit = int(d.Hours()/24), "day"
+	default:
+		value, unit = int(d.Hours()/24/365), "year"
+	}
+	return fmt.Sprintf(format, value, unit, Plural(value))
+}
+
+// CountryFlag returns the emoji flag of the given ISO 3166-1 alpha-2
+// country code, or an empty string if the code is invalid
+func CountryFlag(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 {
+		return ""
+	}
+	var flag []rune
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return ""
+		}
+		// Regional indicator symbols start at U+1F1E6
+		flag = append(flag, 0x1F1E6+c-'A')
+	}
+	return string(flag)
+}
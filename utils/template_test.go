@@ -0,0 +1,67 @@
+// Copyright (c) 2014-2019 Ludovic Fauvet
+// Licensed under the MIT license
+
+package utils
+
+import (
+	"html/template"
+	"testing"
+	"time"
+)
+
+func TestMarkdown(t *testing.T) {
+	for _, tt := range []struct {
+		input    string
+		expected template.HTML
+	}{
+		{"", ""},
+		{"Hello **world**", "<p>Hello <strong>world</strong></p>"},
+		{"*a*\nb\n\nc", "<p><em>a</em><br>b</p><p>c</p>"},
+		{"run `rm *x*`", "<p>run <code>rm *x*</code></p>"},
+		{"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"[site](https://example.org/)", `<p><a href="https://example.org/">site</a></p>`},
+		{"[page](/about?a=1&b=2)", `<p><a href="/about?a=1&amp;b=2">page</a></p>`},
+		{"[bad](javascript:alert(1))", "<p>bad)</p>"},
+		{`[q](http://x/"onclick=")`, `<p><a href="http://x/&#34;onclick=&#34;">q</a></p>`},
+	} {
+		if r := Markdown(tt.input); r != tt.expected {
+			t.Fatalf("Expected %q, got %q", tt.expected, r)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		time     time.Time
+		expected string
+	}{
+		{time.Time{}, ""},
+		{now, "just now"},
+		{now.Add(-90 * time.Second), "1 minute ago"},
+		{now.Add(-3*time.Hour - time.Minute), "3 hours ago"},
+		{now.Add(49 * time.Hour), "in 2 days"},
+		{now.Add(-800 * 24 * time.Hour), "2 years ago"},
+	} {
+		if r := RelativeTime(tt.time); r != tt.expected {
+			t.Fatalf("Expected %q, got %q", tt.expected, r)
+		}
+	}
+}
+
+func TestCountryFlag(t *testing.T) {
+	for _, tt := range []struct {
+		code     string
+		expected string
+	}{
+		{"FR", "\U0001F1EB\U0001F1F7"},
+		{"us", "\U0001F1FA\U0001F1F8"},
+		{"", ""},
+		{"FRA", ""},
+		{"F1", ""},
+	} {
+		if r := CountryFlag(tt.code); r != tt.expected {
+			t.Fatalf("Expected %q, got %q", tt.expected, r)
+		}
+	}
+}
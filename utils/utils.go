@@ -83,19 +83,30 @@ func IsInSlice(a string, list []string) bool {
 	return false
 }
 
-// IsAdditionalCountry returns true if the clientInfo country is in list
-func IsAdditionalCountry(clientInfo network.GeoIPRecord, list []string) bool {
-	if !clientInfo.IsValid() {
-		return false
-	}
-	for i, b := range list {
-		if i > 0 && b == clientInfo.CountryCode {
+// IsInIntSlice returns true is `a` is contained in `list`
+func IsInIntSlice(a int, list []int) bool {
+	for _, b := range list {
+		if b == a {
 			return true
 		}
 	}
 	return false
 }
 
+// StringSliceEq returns true if both slices contain the same strings
+// in the same order
+func StringSliceEq(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // IsPrimaryCountry returns true if the clientInfo country is the primary country
 func IsPrimaryCountry(clientInfo network.GeoIPRecord, list []string) bool {
 	if !clientInfo.IsValid() {
@@ -86,27 +86,33 @@ func TestIsInSlice(t *testing.T) {
 	}
 }
 
-func TestIsAdditionalCountry(t *testing.T) {
-	var b bool
-	list := []string{"FR", "DE", "GR"}
+func TestIsInIntSlice(t *testing.T) {
+	list := []int{200, 204, 302}
 
-	clientInfo := network.GeoIPRecord{
-		CountryCode: "FR",
+	if !IsInIntSlice(302, list) {
+		t.Fatal("Expected true, got false")
 	}
-
-	b = IsAdditionalCountry(clientInfo, list)
-	if b {
+	if IsInIntSlice(404, list) {
 		t.Fatal("Expected false, got true")
 	}
-
-	clientInfo = network.GeoIPRecord{
-		CountryCode: "GR",
+	if IsInIntSlice(0, nil) {
+		t.Fatal("Expected false, got true")
 	}
+}
 
-	b = IsAdditionalCountry(clientInfo, list)
-	if !b {
+func TestStringSliceEq(t *testing.T) {
+	if !StringSliceEq([]string{"a", "b"}, []string{"a", "b"}) {
+		t.Fatal("Expected true, got false")
+	}
+	if !StringSliceEq(nil, []string{}) {
 		t.Fatal("Expected true, got false")
 	}
+	if StringSliceEq([]string{"a", "b"}, []string{"b", "a"}) {
+		t.Fatal("Expected false, got true")
+	}
+	if StringSliceEq([]string{"a"}, []string{"a", "b"}) {
+		t.Fatal("Expected false, got true")
+	}
 }
 
 func TestIsPrimaryCountry(t *testing.T) {